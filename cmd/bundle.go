@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var bundleOutput string
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create and verify offline bundles for air-gapped installs.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Creates an offline bundle of a topology",
+	Long: `Pulls every configured chart at the version install resolves it to and writes it to a
+	bundle along with the list of the images the charts pull, a script mirroring them to
+	$MIRROR_REGISTRY, the topology, the kind configs of its clusters and a manifest of the
+	checksums of the contents. Mirroring the images is left to the script.
+	Install from the bundle with install --from-bundle. No cluster is touched`,
+	Example: `  kubeslice-cli bundle create -c topology.yaml -o bundle.tar.gz
+  kubeslice-cli bundle create -p enterprise-demo -o bundle.tar.gz --script docker`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" && profile == "" {
+			cmd.Help()
+			util.Fatalf("\n %v Please pass either --config or --profile option", util.Cross)
+		}
+		if mirrorScript != pkg.MirrorScriptSkopeo && mirrorScript != pkg.MirrorScriptDocker {
+			util.Fatalf("%v Unknown script: %s. Possible values %s", util.Cross, mirrorScript, []string{pkg.MirrorScriptSkopeo, pkg.MirrorScriptDocker})
+		}
+		validateProfile()
+		pkg.ReadAndValidateConfiguration(Config, profile)
+		pkg.CreateBundle(Config, bundleOutput, mirrorScript)
+	},
+}
+
+var bundleVerifyCmd = &cobra.Command{
+	Use:   "verify <bundle>",
+	Short: "Verifies the integrity of an offline bundle",
+	Long: `Checks every file of a bundle against the checksums of its manifest, e.g. after
+	transferring it into an air-gapped network, and lists its charts.
+	Exits with an error when a file is modified, missing or not listed`,
+	Example: `  kubeslice-cli bundle verify bundle.tar.gz`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pkg.VerifyBundle(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleVerifyCmd)
+	bundleCreateCmd.Flags().StringVarP(&profile, "profile", "p", "", `The profile whose charts are bundled: full-demo, minimal-demo, enterprise-demo or custom. With --config, applies to a topology not setting its own profile`)
+	bundleCreateCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output", "o", "kubeslice-bundle.tar.gz", `Path of the bundle to write`)
+	bundleCreateCmd.Flags().BoolVarP(&pkg.Options.Devel, "devel", "", false, `Resolves version ranges and unpinned chart versions to pre-releases too, as install --devel does`)
+	bundleCreateCmd.Flags().StringVarP(&mirrorScript, "script", "", "skopeo", `Tool the mirror script of the bundle copies the images with: skopeo or docker`)
+}
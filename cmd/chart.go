@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var chartCmd = &cobra.Command{
+	Use:   "chart",
+	Short: "Inspect the KubeSlice helm charts.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var chartVersionsCmd = &cobra.Command{
+	Use:   "versions [controller|worker|cert-manager|ui|prometheus]",
+	Short: "Lists the published versions of a chart",
+	Long: `Lists the versions of the chart of a component published in the configured
+	helm repository, newest first. The version pinned in the topology and the
+	versions currently deployed are marked`,
+	Example:   `  kubeslice-cli chart versions controller -c topology.yaml`,
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"controller", "worker", "cert-manager", "ui", "prometheus"},
+	Run: func(cmd *cobra.Command, args []string) {
+		pkg.ReadAndValidateConfiguration(Config, "")
+		pkg.ChartVersions(args[0], outputFormat)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chartCmd)
+	chartCmd.AddCommand(chartVersionsCmd)
+	chartVersionsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "supported values json")
+	chartVersionsCmd.Flags().BoolVarP(&pkg.Options.Devel, "devel", "", false, `Includes pre-release versions, which are marked`)
+}
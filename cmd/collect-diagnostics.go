@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var collectDiagnosticsCmd = &cobra.Command{
+	Use:   "collect-diagnostics",
+	Short: "Collects a diagnostics bundle of the KubeSlice clusters",
+	Long: `Collects what is needed to investigate a failed install into a bundle in the workspace:
+	the version and arguments of kubeslice-cli, the generated values files and manifests of the
+	workspace, and the pods, describes, events and last log lines of the kubeslice namespaces
+	of every cluster. Secrets are scrubbed and the logs are size limited, so that the bundle
+	can be attached to an issue. The install collects the bundle by itself when it fails.
+
+	Pass the workspace of the failed run with --workspace to include its generated files.
+	Without --config the clusters of the installation stored in ~/.kubeslice/state are diagnosed`,
+	Example: `  kubeslice-cli collect-diagnostics -c topology.yaml --workspace ~/.kubeslice/runs/20240101-120000-4242
+  kubeslice-cli collect-diagnostics --installation ks-ctrl`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" {
+			pkg.ReadInstallationState(pkg.Options.Installation)
+		} else {
+			pkg.ReadAndValidateConfiguration(Config, "")
+		}
+		pkg.CollectDiagnostics()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(collectDiagnosticsCmd)
+}
@@ -1,5 +1,13 @@
 package cmd
 
+import (
+	"os"
+
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
 var (
 	profile      string
 	skipSteps    = []string{}
@@ -14,3 +22,84 @@ func mapFromSlice(slice []string) map[string]string {
 	}
 	return resultantMap
 }
+
+// validateProfile checks --profile, the custom profile being the topology of
+// --config.
+func validateProfile() {
+	if profile == "" {
+		return
+	}
+	if err := pkg.ValidateProfile(profile); err != nil {
+		util.Fatalf("%v %v", util.Cross, err)
+	}
+	if profile == pkg.ProfileCustom && Config == "" {
+		util.Fatalf("\n %v The %s profile installs the topology of --config, please pass the --config option", util.Cross, profile)
+	}
+}
+
+// addDryRunFlags adds the flags printing the manifests a command generates
+// instead of applying them.
+func addDryRunFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Prints the generated manifests instead of applying them, supported value yaml")
+	cmd.Flags().String("dry-run", "none", "Prints the generated manifests instead of applying them when set to client, supported values none, client")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+}
+
+// dryRun tells whether the manifests are only printed. The progress messages
+// are written to stderr then, so that stdout is a clean YAML stream.
+func dryRun(cmd *cobra.Command) bool {
+	output, _ := cmd.Flags().GetString("output")
+	mode, _ := cmd.Flags().GetString("dry-run")
+	if output != "" && output != "yaml" {
+		util.Fatalf("%v Unknown output format: %s. Possible values %s", util.Cross, output, []string{"yaml"})
+	}
+	if mode != "none" && mode != "client" {
+		util.Fatalf("%v Unknown dry run: %s. Possible values %s", util.Cross, mode, []string{"none", "client"})
+	}
+	if output == "" && mode == "none" {
+		return false
+	}
+	util.Output = os.Stderr
+	return true
+}
+
+// addPlanFlags adds the flags stopping a run after printing its execution
+// plan.
+func addPlanFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&pkg.Options.PlanOnly, "plan-only", "", false, `Prints the execution plan of the run, the clusters, releases with their chart versions and objects of every step, and stops
+	without changing any cluster. Unlike --dry-run of the other commands, the read-only checks of the run still reach the clusters. Install and
+	upgrade still add and update the helm repositories of the charts to resolve their versions, install checks the registry credentials against
+	the token endpoints of the registries instead of with docker login`)
+	cmd.Flags().StringVarP(&pkg.Options.PlanOutput, "output", "o", "", "Prints the plan of --plan-only as json on stdout, the progress on stderr, supported values json")
+}
+
+// addStrictValuesFlag adds the flag failing on chart values of the topology
+// which the charts to install do not know.
+func addStrictValuesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&pkg.Options.StrictValues, pkg.StrictValuesSetting, "", false, `Fails when a chart value in the topology is not present in the chart's default values. Unknown values are only warned about otherwise`)
+}
+
+// addTemplatesDirFlag adds the flag overriding the generated Project, Cluster
+// and SliceConfig manifests with templates.
+func addTemplatesDirFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&pkg.Options.TemplatesDir, pkg.TemplatesDirSetting, "", "", `<path-to-directory>
+	Directory of Go templates overriding the generated Project, Cluster and SliceConfig manifests:
+	project.yaml.tmpl, cluster.yaml.tmpl and slice.yaml.tmpl. Refer: https://github.com/kubeslice/kubeslice-cli/blob/master/samples/templates`)
+}
+
+// addForceConflictsFlag adds the flag taking ownership of the conflicting
+// fields of the kubeslice objects applied server side.
+func addForceConflictsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&pkg.Options.ForceConflicts, pkg.ForceConflictsSetting, "", false, `Takes ownership of the fields of the applied kubeslice objects which other managers, e.g. kubectl, set.
+	The Project, Cluster, SliceConfig and other controller.kubeslice.io objects are applied server side with the kubeslice-cli field manager, conflicting fields fail the apply otherwise`)
+}
+
+// validatePlanFlags checks the output format of the plan.
+func validatePlanFlags() {
+	if pkg.Options.PlanOutput != "" && pkg.Options.PlanOutput != "json" {
+		util.Fatalf("%v Unknown output format: %s. Possible values %s", util.Cross, pkg.Options.PlanOutput, []string{"json"})
+	}
+	if pkg.Options.PlanOutput != "" && !pkg.Options.PlanOnly {
+		util.Fatalf("%v --output prints the plan of --plan-only, pass --plan-only as well", util.Cross)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generates the shell completion script",
+	Long: `Generates the completion script of kubeslice-cli for a shell. Besides the commands and flags, the
+	profiles, the clusters of the topology of --config and the names of projects, sliceConfigs,
+	serviceExportConfigs and workers on the controller cluster are completed. Names are not offered
+	when the controller cluster cannot be reached within 2 seconds
+
+	To load the completions of the current bash shell, which needs the bash-completion package:
+	  source <(kubeslice-cli completion bash)
+	and of every new shell:
+	  kubeslice-cli completion bash > /etc/bash_completion.d/kubeslice-cli
+
+	For zsh, with compinit enabled:
+	  kubeslice-cli completion zsh > "${fpath[1]}/_kubeslice-cli"
+
+	For fish:
+	  kubeslice-cli completion fish > ~/.config/fish/completions/kubeslice-cli.fish
+
+	For PowerShell:
+	  kubeslice-cli completion powershell | Out-String | Invoke-Expression`,
+	Example: `  source <(kubeslice-cli completion bash)
+  kubeslice-cli completion zsh > "${fpath[1]}/_kubeslice-cli"`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.ExactValidArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		switch args[0] {
+		case "bash":
+			rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+	},
+}
+
+// completeObjects completes the object type of a command, then the names of
+// the objects of that type on the controller cluster.
+func completeObjects(objectTypes ...string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return objectTypes, cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			ns, _ := cmd.Flags().GetString("namespace")
+			project, _ := cmd.Flags().GetString("project")
+			return pkg.CompleteObjectNames(Config, args[0], ns, project), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProfiles completes --profile.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return pkg.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeComponents completes install --components.
+func completeComponents(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return pkg.SelectableComponents, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCertManagerModes completes install --cert-manager.
+func completeCertManagerModes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{pkg.CertManagerAuto, pkg.CertManagerInstall, pkg.CertManagerSkip}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorkers completes the names of the workers of the topology.
+func completeWorkers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return pkg.CompleteClusterNames(Config, true), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	fromDefaults   bool
+	validateConfig bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage topology configuration files and the user defaults.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "Writes a topology configuration file",
+	Long: `Walks through the choices of a topology: kind clusters or existing clusters, the number of
+	workers, the kubeconfig and contexts of existing clusters, the project and its users, the chart
+	repository and versions and an optional image pull secret. Every answer is validated as it is
+	entered, the topology is written with comments to file, topology.yaml by default.
+
+	With --from-defaults the topology of the minimal-demo profile is written without asking, for
+	scripts. An existing file is only overwritten when confirmed or with --yes`,
+	Example: `  kubeslice-cli config init
+  kubeslice-cli config init my-topology.yaml --validate
+  kubeslice-cli config init topology.yaml --from-defaults`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName := "topology.yaml"
+		if len(args) > 0 {
+			fileName = args[0]
+		}
+		pkg.InitConfiguration(fileName, fromDefaults, validateConfig)
+	},
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Prints the effective user defaults",
+	Long: `Prints the effective value of every key of the user defaults file and where it came from: a flag,
+	an environment variable, the file or the built-in value, by precedence.
+
+	The user defaults file is ~/.kubeslice-cli.yaml, or the file of $KUBESLICE_CLI_CONFIG. Its keys are
+	the global flags, e.g. workspace or http-proxy, and workspace-dir, the directory the workspace of
+	every run is created in, profile, the profile of the commands taking one when --config is not
+	passed, chart-repo, the chart repository of the demo profiles, strict-values, the --strict-values
+	of install, upgrade and register, and templates-dir and force-conflicts, the --templates-dir and
+	--force-conflicts of install, create and register. Every key is also read from
+	the environment variable KUBESLICE_CLI_<KEY>, e.g. KUBESLICE_CLI_HTTP_PROXY. Unknown keys are
+	warned about on stderr.
+
+	kubeconfig sets the kubeconfig of the topologies of existing clusters which set none. There is no
+	log-level, debug prints the debug output, nor no-color, the output of kubeslice-cli is not colored`,
+	Example: `  kubeslice-cli config view
+  KUBESLICE_CLI_CONFIG=ci-defaults.yaml kubeslice-cli config view`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pkg.ShowUserSettings()
+	},
+}
+
+// applyUserDefaults sets the global flags which are not passed on the
+// command line, and the flags of the command which are other keys of the user
+// defaults file, the profile only without --config, from the environment and
+// the user defaults file.
+func applyUserDefaults(cmd *cobra.Command) {
+	flags := make(map[string]*pflag.Flag)
+	settings := make([]pkg.UserSetting, 0)
+	add := func(flag *pflag.Flag) {
+		setting := pkg.UserSetting{Key: flag.Name, Value: flag.Value.String()}
+		if flag.Changed {
+			setting.Source, setting.Origin = pkg.SettingSourceFlag, "--"+flag.Name
+		}
+		flags[flag.Name] = flag
+		settings = append(settings, setting)
+	}
+	// the topology is passed per run
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Name != "config" {
+			add(flag)
+		}
+	})
+	for _, setting := range pkg.DefaultSettings() {
+		// the flag of the command is set instead, the profile only without --config
+		if flag := cmd.Flags().Lookup(setting.Key); flag != nil && (setting.Key != pkg.ProfileSetting || Config == "") {
+			add(flag)
+			continue
+		}
+		settings = append(settings, setting)
+	}
+	for _, setting := range pkg.ResolveUserSettings(settings) {
+		flag, found := flags[setting.Key]
+		if !found || (setting.Source != pkg.SettingSourceEnv && setting.Source != pkg.SettingSourceFile) {
+			continue
+		}
+		if err := flag.Value.Set(setting.Value); err != nil {
+			util.Fatalf("%s Invalid value %q of %s from %s: %v", util.Cross, setting.Value, setting.Key, setting.Origin, err)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configViewCmd)
+	configInitCmd.Flags().BoolVarP(&fromDefaults, "from-defaults", "", false, `Writes the minimal-demo topology without asking`)
+	configInitCmd.Flags().BoolVarP(&validateConfig, "validate", "", false, `Validates the written topology as kubeslice-cli validate does`)
+}
@@ -9,26 +9,58 @@ import (
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create Kubeslice resources.",
-	Args:  cobra.MinimumNArgs(1),
+	Example: `  kubeslice-cli create sliceConfig demo -n kubeslice-demo -w ks-w-1,ks-w-2
+  kubeslice-cli create serviceExportConfig -n kubeslice-demo --slice demo --service iperf-server --service-namespace iperf --port 5201/TCP --cluster ks-w-1
+  kubeslice-cli create project demo -n kubeslice-controller -o yaml > project.yaml`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 		filename, _ := cmd.Flags().GetString("filename")
+		dryRun := dryRun(cmd)
 		workerList, _ := cmd.Flags().GetStringSlice("setWorker")
 		if len(args) > 1 {
 			objectName = args[1]
 		}
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename})
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename, DryRun: dryRun, Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
 		switch args[0] {
 		case "project":
+			if filename == "" {
+				if err := pkg.ValidateProjectName(objectName); err != nil {
+					util.Fatalf("%s %v", util.Cross, err)
+				}
+			}
 			pkg.CreateProject()
 		case "sliceConfig":
-			pkg.CreateSliceConfig(workerList)
+			params := pkg.SliceParams{Workers: workerList}
+			params.SliceSubnet, _ = cmd.Flags().GetString("slice-subnet")
+			params.ApplicationNamespaces, _ = cmd.Flags().GetStringArray("application-namespace")
+			params.AllowedNamespaces, _ = cmd.Flags().GetStringArray("allowed-namespace")
+			if cmd.Flags().Changed("namespace-isolation") {
+				isolation, _ := cmd.Flags().GetBool("namespace-isolation")
+				params.NamespaceIsolation = &isolation
+			}
+			params.QueueType, _ = cmd.Flags().GetString("queue-type")
+			if cmd.Flags().Changed("priority") {
+				priority, _ := cmd.Flags().GetInt("priority")
+				params.Priority = &priority
+			}
+			params.BandwidthCeilingKbps, _ = cmd.Flags().GetInt("bandwidth-ceiling-kbps")
+			params.BandwidthGuaranteedKbps, _ = cmd.Flags().GetInt("bandwidth-guaranteed-kbps")
+			params.DscpClass, _ = cmd.Flags().GetString("dscp-class")
+			pkg.CreateSliceConfig(params)
 		case "serviceExportConfig":
-			pkg.CreateServiceExportConfig(filename)
+			params := pkg.ServiceExportParams{}
+			params.Slice, _ = cmd.Flags().GetString("slice")
+			params.Service, _ = cmd.Flags().GetString("service")
+			params.ServiceNamespace, _ = cmd.Flags().GetString("service-namespace")
+			params.SourceCluster, _ = cmd.Flags().GetString("cluster")
+			params.Ports, _ = cmd.Flags().GetStringArray("port")
+			pkg.CreateServiceExportConfig(params)
 		default:
 			util.Fatalf("Invalid object type")
 		}
@@ -37,7 +69,26 @@ var createCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(createCmd)
+	addDryRunFlags(createCmd)
 	createCmd.Flags().StringP("namespace", "n", "", "namespace")
+	createCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
 	createCmd.Flags().StringP("filename", "f", "", "Filename, directory, or URL to file to use to create the resource")
 	createCmd.Flags().StringSliceP("setWorker", "w", nil, "List of Worker Clusters to be registered in the SliceConfig")
+	createCmd.Flags().String("slice-subnet", "", "Subnet of the SliceConfig, overrides configuration.kubeslice_configuration.slice.slice_subnet")
+	createCmd.Flags().StringArray("application-namespace", nil, "Application namespace of the SliceConfig as namespace[=cluster,...], on every cluster without clusters. Can be repeated")
+	createCmd.Flags().StringArray("allowed-namespace", nil, "Namespace allowed to reach the application namespaces as namespace[=cluster,...]. Can be repeated")
+	createCmd.Flags().Bool("namespace-isolation", false, "Isolate the application namespaces of the SliceConfig")
+	createCmd.Flags().String("queue-type", "", "QoS queue type of the SliceConfig (default HTB)")
+	createCmd.Flags().Int("priority", 1, "QoS priority of the SliceConfig traffic, 0 to 3")
+	createCmd.Flags().Int("bandwidth-ceiling-kbps", 0, "Maximum bandwidth of the SliceConfig traffic in kbps (default 5120)")
+	createCmd.Flags().Int("bandwidth-guaranteed-kbps", 0, "Guaranteed bandwidth of the SliceConfig traffic in kbps (default 2560)")
+	createCmd.Flags().String("dscp-class", "", "DSCP class the SliceConfig traffic is marked with (default AF11)")
+	createCmd.Flags().String("slice", "", "Slice the ServiceExportConfig exports the service to")
+	createCmd.Flags().String("service", "", "Service exported by the ServiceExportConfig")
+	createCmd.Flags().String("service-namespace", "", "Namespace of the exported service, an application namespace of the slice")
+	createCmd.Flags().StringArray("port", nil, "Port of the exported service as port[/protocol], TCP by default. Can be repeated")
+	createCmd.Flags().String("cluster", "", "Worker cluster the exported service runs on")
+	addTemplatesDirFlag(createCmd)
+	addForceConflictsFlag(createCmd)
+	createCmd.RegisterFlagCompletionFunc("cluster", completeWorkers)
 }
@@ -10,24 +10,40 @@ var deleteCmd = &cobra.Command{
 	Use:     "delete",
 	Aliases: []string{"d, remove"},
 	Short:   "Delete Kubeslice resources.",
-	Args:    cobra.MinimumNArgs(2),
+	Example: `  kubeslice-cli delete serviceExportConfig iperf-server-iperf-ks-w-1 -n kubeslice-demo
+  kubeslice-cli delete serviceExportConfig -n kubeslice-demo --slice demo
+  kubeslice-cli delete sliceConfig demo -n kubeslice-demo --timeout 5m
+  kubeslice-cli delete sliceConfig demo -n kubeslice-demo --force-finalizers
+  kubeslice-cli delete project demo -n kubeslice-controller --cascade --wait`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 
-		objectName = args[1]
+		slice, _ := cmd.Flags().GetString("slice")
+		if len(args) > 1 {
+			objectName = args[1]
+		} else if args[0] != "serviceExportConfig" || slice == "" {
+			util.Fatalf("Name of the %s to delete is required", args[0])
+		}
 
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0]})
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
 		switch args[0] {
 		case "project":
-			pkg.DeleteProject()
+			cascade, _ := cmd.Flags().GetBool("cascade")
+			wait, _ := cmd.Flags().GetBool("wait")
+			pkg.DeleteProject(cascade, wait)
 		case "sliceConfig":
-			pkg.DeleteSliceConfig()
+			// unlike the deletion of a project, the one of a sliceConfig is
+			// waited for unless --wait=false is passed
+			wait, _ := cmd.Flags().GetBool("wait")
+			pkg.DeleteSliceConfig(wait || !cmd.Flags().Changed("wait"))
 		case "serviceExportConfig":
-			pkg.DeleteServiceExportConfig()
+			pkg.DeleteServiceExportConfig(slice)
 		case "worker":
 			pkg.RemoveWorker()
 		default:
@@ -38,5 +54,14 @@ var deleteCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.ValidArgsFunction = completeObjects("project", "sliceConfig", "serviceExportConfig", "worker")
 	deleteCmd.Flags().StringP("namespace", "n", "", "namespace")
+	deleteCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
+	deleteCmd.Flags().String("slice", "", "Deletes all the serviceExportConfigs of the slice when no name is passed")
+	deleteCmd.Flags().Bool("cascade", false, "Deletes the serviceExportConfigs, sliceConfigs and registered workers of the project before the project")
+	deleteCmd.Flags().Bool("wait", false, `Waits for the workers to finish the deletion, the workers waited for are those of the topology passed with -c.
+A project is waited for until its namespace is gone and its workers are offboarded from its slices, off by default.
+A sliceConfig is waited for until its workers remove its Slice and SliceGateways and the labels of its namespaces, on by default, pass --wait=false to return right away`)
+	deleteCmd.Flags().DurationVarP(&pkg.Options.DeletionTimeout, "timeout", "", pkg.Options.DeletionTimeout, "How long to wait for the workers to be offboarded and the namespace of a project to be gone")
+	deleteCmd.Flags().BoolVarP(&pkg.Options.ForceFinalizers, "force-finalizers", "", false, "Removes the kubeslice finalizers of the objects still stuck in deletion once --timeout elapsed, and waits again")
 }
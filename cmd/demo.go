@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Works with the demo applications of a slice.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var demoVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies the connectivity of the slice with iperf",
+	Long: `Deploys an iperf server on the first worker of the slice and an iperf client on the second,
+	in an application namespace of the slice, and exports the server over the slice. Once the
+	ServiceImport of the server appears on the client's cluster, the client measures the bandwidth
+	to the server. The verification passes when data was transferred and, with --check-qos, the
+	bandwidth stays within the bandwidth ceiling of the QoS profile of the slice.
+	The iperf workloads are removed afterwards, also when the verification fails`,
+	Example: `  kubeslice-cli demo verify -p full-demo
+  kubeslice-cli demo verify -c topology.yaml --check-qos`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" && profile == "" {
+			cmd.Help()
+			util.Fatalf("\n %v Please pass either --config or --profile option", util.Cross)
+		}
+		validateProfile()
+		pkg.ReadAndValidateConfiguration(Config, profile)
+		pkg.VerifyDemo()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(demoCmd)
+	demoCmd.AddCommand(demoVerifyCmd)
+	demoVerifyCmd.Flags().StringVarP(&profile, "profile", "p", "", `The demo profile the slice was installed with: full-demo, minimal-demo or enterprise-demo`)
+	demoVerifyCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	demoVerifyCmd.Flags().BoolVarP(&pkg.Options.CheckQoS, "check-qos", "", false, `Fails when the bandwidth over the slice exceeds the bandwidth ceiling of its QoS profile`)
+	demoVerifyCmd.Flags().DurationVarP(&pkg.Options.RolloutTimeout, "timeout", "", pkg.Options.RolloutTimeout, `How long to wait for the iperf workloads to become ready and for the ServiceImport of the server`)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var deregisterCmd = &cobra.Command{
+	Use:   "deregister",
+	Short: "Deregister a Kubeslice worker cluster.",
+	Long: `Removes a worker cluster from the installation of the topology of --config.
+
+	A worker participating in slices is refused unless --detach-from-slices is passed, which
+	removes it from the SliceConfigs and waits until it is offboarded. The worker chart is then
+	uninstalled from the worker, its Cluster is deleted on the controller and waited for to be
+	finalized, and the worker is removed from the state of the installation. A worker cluster
+	which cannot be reached is deregistered with --force, which leaves it untouched`,
+	Example: `  kubeslice-cli deregister worker ks-w-3 -c topology.yaml
+  kubeslice-cli deregister worker ks-w-3 -c topology.yaml --detach-from-slices
+  kubeslice-cli deregister worker ks-w-3 -c topology.yaml --project blue --force`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ns, _ := cmd.Flags().GetString("namespace")
+		project, _ := cmd.Flags().GetString("project")
+		detach, _ := cmd.Flags().GetBool("detach-from-slices")
+		force, _ := cmd.Flags().GetBool("force")
+		if Config == "" {
+			util.Fatalf("%v Please pass the topology of the installation the worker is removed from with --config", util.Cross)
+		}
+
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: args[1], ObjectType: args[0], Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
+		switch args[0] {
+		case "worker":
+			pkg.DeregisterWorker(detach, force)
+		default:
+			util.Fatalf("Invalid object type")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deregisterCmd)
+	deregisterCmd.ValidArgsFunction = completeObjects("worker")
+	deregisterCmd.Flags().StringP("namespace", "n", "", "namespace")
+	deregisterCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
+	deregisterCmd.Flags().Bool("detach-from-slices", false, "Removes the worker from the slices it participates in and waits until it is offboarded")
+	deregisterCmd.Flags().Bool("force", false, "Skips the steps on the worker cluster, for a worker which cannot be reached")
+}
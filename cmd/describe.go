@@ -14,15 +14,16 @@ var describeCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 
 		if len(args) > 1 {
 			objectName = args[1]
 		}
 
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0]})
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
 		switch args[0] {
 		case "project":
 			pkg.DescribeProject()
@@ -40,5 +41,7 @@ var describeCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(describeCmd)
+	describeCmd.ValidArgsFunction = completeObjects("project", "sliceConfig", "serviceExportConfig", "worker")
 	describeCmd.Flags().StringP("namespace", "n", "", "namespace")
+	describeCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
 }
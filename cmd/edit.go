@@ -13,23 +13,39 @@ var editCmd = &cobra.Command{
 	Long: `The edit command allows you to directly edit any Kubeslice resource you can retrieve via the command line tools. It will open the editor defined by your KUBE_EDITOR, or EDITOR environment variables, or fall back to ‘vi’ for Linux or ‘notepad’ for Windows. You can edit multiple objects, although changes are applied one at a time. The command accepts filenames as well as command line arguments, although the files you point to must be previously saved versions of resources.
 	The default format is YAML.
 	In the event an error occurs while updating, a temporary file will be created on disk that contains your unapplied changes. The most common error when updating a resource is another editor changing the resource on the server. When this occurs, you will have to apply your changes to the newer version of the resource, or update your temporary saved copy to include the latest resource version.`,
+	Example: `  kubeslice-cli edit project demo -n kubeslice-controller
+  kubeslice-cli edit project demo -n kubeslice-controller --add-user alice --remove-user bob
+  kubeslice-cli edit project demo -n kubeslice-controller --add-user auditor --role readonly --dry-run`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 		filename, _ := cmd.Flags().GetString("filename")
 
 		if len(args) > 1 {
 			objectName = args[1]
 		}
 
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename})
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename, Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
 		switch args[0] {
 		case "project":
-			pkg.EditProject()
+			addUsers, _ := cmd.Flags().GetStringSlice("add-user")
+			removeUsers, _ := cmd.Flags().GetStringSlice("remove-user")
+			if len(addUsers) == 0 && len(removeUsers) == 0 {
+				pkg.EditProject()
+				break
+			}
+			role, _ := cmd.Flags().GetString("role")
+			if role != pkg.ProjectRoleReadWrite && role != pkg.ProjectRoleReadOnly {
+				util.Fatalf("%v Unknown role: %s. Possible values %s", util.Cross, role, []string{pkg.ProjectRoleReadWrite, pkg.ProjectRoleReadOnly})
+			}
+			force, _ := cmd.Flags().GetBool("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			pkg.EditProjectUsers(addUsers, removeUsers, role, force, dryRun)
 		case "sliceConfig":
 			pkg.EditSliceConfig()
 		case "serviceExportConfig":
@@ -44,6 +60,13 @@ var editCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(editCmd)
+	editCmd.ValidArgsFunction = completeObjects("project", "sliceConfig", "serviceExportConfig", "worker")
 	editCmd.Flags().StringP("namespace", "n", "", "namespace")
+	editCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
 	editCmd.Flags().StringP("filename", "f", "", "Filename, directory, or URL to file to use to create the resource")
+	editCmd.Flags().StringSlice("add-user", nil, "Users to add to the project instead of opening an editor")
+	editCmd.Flags().StringSlice("remove-user", nil, "Users to remove from the project instead of opening an editor")
+	editCmd.Flags().String("role", pkg.ProjectRoleReadWrite, "Role of the added and removed users of the project, readwrite or readonly")
+	editCmd.Flags().Bool("force", false, "Allow removing the last readwrite user of the project")
+	editCmd.Flags().Bool("dry-run", false, "Print the edited project instead of applying it")
 }
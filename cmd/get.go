@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/kubeslice/kubeslice-cli/pkg"
 	"github.com/kubeslice/kubeslice-cli/util"
 	"github.com/spf13/cobra"
@@ -10,42 +12,90 @@ var getCmd = &cobra.Command{
 	Use:     "get",
 	Aliases: []string{"g"},
 	Short:   "Get Kubeslice resources.",
-	Args:    cobra.RangeArgs(1, 2),
+	Example: `  kubeslice-cli get sliceConfig -n kubeslice-demo
+  kubeslice-cli get slice demo --status --config topology.yaml
+  kubeslice-cli get sliceConfig demo --status --config topology.yaml -o json
+  kubeslice-cli get serviceExportConfig -n kubeslice-demo --slice demo
+  kubeslice-cli get slice demo --config topology.yaml --watch
+  kubeslice-cli get project --config topology.yaml
+  kubeslice-cli get worker --config topology.yaml --project blue`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" && args[0] != "ui-endpoint" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 		worker, _ := cmd.Flags().GetString("worker")
+		status, _ := cmd.Flags().GetBool("status")
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("watch-interval")
 		if len(args) > 1 {
 			objectName = args[1]
 		}
 
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], OutputFormat: outputFormat})
-		switch args[0] {
-		case "project":
-			pkg.GetProject()
-		case "sliceConfig":
-			pkg.GetSliceConfig()
-		case "serviceExportConfig":
-			pkg.GetServiceExportConfig()
-		case "secrets":
-			pkg.GetSecrets(worker)
-		case "worker":
-			pkg.GetWorker()
-		case "ui-endpoint":
-			pkg.GetUIEndpoint()
-		default:
-			util.Fatalf("Invalid object type")
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], OutputFormat: outputFormat, Project: project})
+		if pkg.CliOptions.Namespace == "" && args[0] != "ui-endpoint" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
 		}
-
+		if watch {
+			switch args[0] {
+			case "project", "sliceConfig", "slice", "serviceExportConfig":
+			default:
+				util.Fatalf("%v --watch is not supported for %s. Possible values %s", util.Cross, args[0], []string{"project", "sliceConfig", "slice", "serviceExportConfig"})
+			}
+			if interval <= 0 {
+				util.Fatalf("%s --watch-interval must be positive", util.Cross)
+			}
+			util.Watch(interval, outputFormat == "", func() { get(cmd, args[0], objectName, status, worker) })
+			return
+		}
+		get(cmd, args[0], objectName, status, worker)
 	},
 }
 
+// get prints the objects of a type, once or on every refresh of --watch.
+func get(cmd *cobra.Command, objectType, objectName string, status bool, worker string) {
+	switch objectType {
+	case "project":
+		pkg.GetProject()
+	case "sliceConfig", "slice":
+		if status || objectType == "slice" {
+			if objectName == "" {
+				util.Fatalf("%s The status of a slice needs the name of the slice", util.Cross)
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				util.Fatalf("%v Unknown output format of the slice status: %s. Possible values %s", util.Cross, outputFormat, []string{"json"})
+			}
+			pkg.GetSliceStatus()
+		} else {
+			pkg.GetSliceConfig()
+		}
+	case "serviceExportConfig":
+		slice, _ := cmd.Flags().GetString("slice")
+		pkg.GetServiceExportConfig(slice)
+	case "secrets":
+		pkg.GetSecrets(worker)
+	case "worker":
+		pkg.GetWorker()
+	case "ui-endpoint":
+		pkg.GetUIEndpoint()
+	default:
+		util.Fatalf("Invalid object type")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(getCmd)
+	getCmd.ValidArgsFunction = completeObjects("project", "sliceConfig", "slice", "serviceExportConfig", "secrets", "worker", "ui-endpoint")
 	getCmd.Flags().StringP("namespace", "n", "", "namespace")
+	getCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
 	getCmd.Flags().StringP("worker", "w", "", "worker")
+	getCmd.RegisterFlagCompletionFunc("worker", completeWorkers)
+	getCmd.Flags().String("slice", "", "Lists the serviceExportConfigs of the slice")
+	getCmd.Flags().Bool("status", false, `Shows the state of the slice on every worker cluster it spans: the health of the slice, its gateways and their tunnels,
+the onboarded namespaces and app pods. The workers are reached with the contexts of the topology passed with --config,
+workers which cannot be reached are reported as unreachable. Implied by get slice`)
+	getCmd.Flags().BoolP("watch", "W", false, `Prints the objects again on every --watch-interval until interrupted with Ctrl+C, marking the rows changed since
+the previous refresh with * and the ones turned healthy with ✔. Supported for project, sliceConfig, slice and serviceExportConfig`)
+	getCmd.Flags().Duration("watch-interval", 2*time.Second, "How often --watch queries the objects")
 	getCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "supported values json, yaml")
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mirrorRegistry string
+	mirrorScript   string
+)
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Inspect the container images of the KubeSlice charts.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var imagesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the images an install pulls",
+	Long: `Renders every configured chart with helm template and the generated values,
+	and lists the images referenced by the manifests and the chart values, e.g. the
+	sidecars the worker operator starts, for mirroring them to an air-gapped registry.
+	The chart versions are resolved the way install resolves them. No cluster is touched`,
+	Example: `  kubeslice-cli images list -c topology.yaml
+  kubeslice-cli images list -p enterprise-demo -o json
+  kubeslice-cli images list -c topology.yaml --mirror-registry registry.internal:5000 --script docker > mirror.sh`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" && profile == "" {
+			cmd.Help()
+			util.Fatalf("\n %v Please pass either --config or --profile option", util.Cross)
+		}
+		if outputFormat != "" && outputFormat != "json" {
+			util.Fatalf("%v Unknown output format: %s. Possible values %s", util.Cross, outputFormat, []string{"json"})
+		}
+		if mirrorScript != pkg.MirrorScriptSkopeo && mirrorScript != pkg.MirrorScriptDocker {
+			util.Fatalf("%v Unknown script: %s. Possible values %s", util.Cross, mirrorScript, []string{pkg.MirrorScriptSkopeo, pkg.MirrorScriptDocker})
+		}
+		validateProfile()
+		if Config == "" && (profile == pkg.ProfileFullDemo || profile == pkg.ProfileMinimalDemo) {
+			skipSteps = append(skipSteps, "prometheus")
+		}
+		pkg.ReadAndValidateConfiguration(Config, profile)
+		if !withCertManager {
+			skipSteps = append(skipSteps, "cert-manager")
+		}
+		pkg.ListImages(mapFromSlice(skipSteps), outputFormat, mirrorRegistry, mirrorScript)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.AddCommand(imagesListCmd)
+	imagesListCmd.Flags().StringVarP(&profile, "profile", "p", "", `The profile whose images are listed: full-demo, minimal-demo, enterprise-demo or custom. With --config, applies to a topology not setting its own profile`)
+	imagesListCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	imagesListCmd.Flags().StringSliceVarP(&skipSteps, "skip", "s", []string{}, `Leaves out the images of the skipped install steps (comma-seperated): controller, worker, ui, prometheus`)
+	imagesListCmd.Flags().BoolVarP(&withCertManager, "with-cert-manager", "", false, `Includes the images of Cert-Manager`)
+	imagesListCmd.Flags().BoolVarP(&pkg.Options.Devel, "devel", "", false, `Resolves version ranges and unpinned chart versions to pre-releases too, as install --devel does`)
+	imagesListCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "supported values json")
+	imagesListCmd.Flags().StringVarP(&mirrorRegistry, "mirror-registry", "", "", `Prints a script copying every image to this registry instead of the list, keeping the repository paths`)
+	imagesListCmd.Flags().StringVarP(&mirrorScript, "script", "", "skopeo", `Tool the mirror script copies the images with: skopeo or docker`)
+}
@@ -7,6 +7,7 @@ import (
 )
 
 var withCertManager bool
+var skipHelmTests bool
 
 var installCmd = &cobra.Command{
 	Use:     "install",
@@ -17,32 +18,52 @@ var installCmd = &cobra.Command{
 	KubeSlice functionality`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// check if config and profile are both set, if so, error out
-		if Config != "" && profile != "" {
-			cmd.Help()
-			util.Fatalf("\n %v Cannot use both --config and --profile options", util.Cross)
-		}
 		// check if config and profile are both not set, if so, error out
 		if Config == "" && profile == "" {
 			cmd.Help()
 			util.Fatalf("\n %v Please pass either --config or --profile option", util.Cross)
 		}
-		if profile != "" {
-			switch profile {
-			case pkg.ProfileFullDemo:
-				skipSteps = append(skipSteps, "prometheus")
-			case pkg.ProfileMinimalDemo:
-				skipSteps = append(skipSteps, "prometheus")
-			case pkg.ProfileEntDemo:
-			default:
-				util.Fatalf("%v Unknown profile: %s. Possible values %s", util.Cross, profile, []string{pkg.ProfileFullDemo, pkg.ProfileMinimalDemo, pkg.ProfileEntDemo})
-			}
-			pkg.ReadAndValidateConfiguration("", profile)
-		} else {
-			pkg.ReadAndValidateConfiguration(Config, "")
+		validateProfile()
+		validatePlanFlags()
+		if pkg.Options.RenderTo != "" && profile != "" && profile != pkg.ProfileCustom {
+			util.Fatalf("\n %v Cannot use --render-to with --profile, profiles create kind clusters", util.Cross)
+		}
+		if pkg.Options.RenderTo != "" && pkg.Options.PlanOnly {
+			util.Fatalf("\n %v Cannot use --render-to with --plan-only, rendering changes no cluster either", util.Cross)
+		}
+		if pkg.Options.Resume && pkg.Options.NoResume {
+			util.Fatalf("\n %v Cannot use --resume with --no-resume", util.Cross)
+		}
+		if err := pkg.ValidateComponents(pkg.Options.Components); err != nil {
+			util.Fatalf("%v %v", util.Cross, err)
+		}
+		switch pkg.Options.OnExisting {
+		case pkg.OnExistingAdopt, pkg.OnExistingSkip, pkg.OnExistingFail:
+		default:
+			util.Fatalf("%v Unknown --on-existing: %s. Possible values %s", util.Cross, pkg.Options.OnExisting, []string{pkg.OnExistingAdopt, pkg.OnExistingSkip, pkg.OnExistingFail})
+		}
+		// the default topologies of the demo profiles install no prometheus
+		if Config == "" && (profile == pkg.ProfileFullDemo || profile == pkg.ProfileMinimalDemo) {
+			skipSteps = append(skipSteps, "prometheus")
 		}
-		// Default behaviour is not ot install cert-manager
-		if !withCertManager {
+		specs := pkg.ReadAndValidateConfiguration(Config, profile)
+		// helm tests run by default for the demo profiles only
+		if specs.Configuration.ClusterConfiguration.Profile != "" && !skipHelmTests {
+			pkg.Options.HelmTests = true
+		}
+		if withCertManager {
+			pkg.Options.CertManager = pkg.CertManagerInstall
+		}
+		switch pkg.Options.CertManager {
+		case pkg.CertManagerAuto, pkg.CertManagerInstall:
+		case pkg.CertManagerSkip:
+			skipSteps = append(skipSteps, "cert-manager")
+		default:
+			util.Fatalf("%v Unknown --cert-manager: %s. Possible values %s", util.Cross, pkg.Options.CertManager, []string{pkg.CertManagerAuto, pkg.CertManagerInstall, pkg.CertManagerSkip})
+		}
+		// without the clusters to detect an existing cert-manager on, only
+		// --cert-manager=install renders it
+		if pkg.Options.RenderTo != "" && pkg.Options.CertManager == pkg.CertManagerAuto {
 			skipSteps = append(skipSteps, "cert-manager")
 		}
 
@@ -61,9 +82,9 @@ Supported values:
 		3 Kind Clusters, including 1 KubeSlice Controller and 2 KubeSlice Workers, 
 		and installing iPerf application to generate network traffic.
 	- minimal-demo:
-		Sets up 3 Kind Clusters, including 1 KubeSlice Controller and 2 KubeSlice Workers. 
-		Generates the KubernetesManifests for user to manually apply, and verify 
-		the functionality
+		Sets up 2 Kind Clusters, including 1 KubeSlice Controller and 1 KubeSlice Worker,
+		without the KubeSlice Manager (UI). Prints the commands to apply a slice
+		and verify the functionality manually
 	- enterprise-demo:
 		Showcases the KubeSlice Enterprise functionality by spawning
 		3 Kind Clusters, including 1 KubeSlice Controller and 2 KubeSlice Workers, 
@@ -73,8 +94,12 @@ Supported values:
 
 		KUBESLICE_IMAGE_PULL_USERNAME : optional : Default 'aveshaenterprise'
 		KUBESLICE_IMAGE_PULL_PASSWORD : required
+	- custom:
+		Installs on the clusters of the topology of --config.
 
-Cannot be used with --config flag.`)
+With --config, the profile applies to a topology not setting cluster_configuration.profile,
+the fields of the topology winning over those of the profile.`)
+	installCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 	installCmd.Flags().StringSliceVarP(&skipSteps, "skip", "s", []string{}, `Skips the installation steps (comma-seperated). 
 Supported values:
 	- kind: Skips the creation of kind clusters
@@ -84,7 +109,74 @@ Supported values:
 	- worker: Skips the installation of KubeSlice Worker
 	- demo: Skips the installation of additional example applications
 	- ui: Skips the installtion of enterprise UI components (Kubeslice-Manager)
-	- prometheus: Skips the installation of prometheus`)
+	- prometheus: Skips the installation of prometheus
+	- monitoring: Skips the installation of the monitoring section of the topology`)
+	installCmd.Flags().StringSliceVarP(&pkg.Options.Components, "components", "", []string{}, `Installs only the selected components (comma-seperated): cert-manager, controller, ui, worker, prometheus.
+	The workers are registered with the controller. Without the controller, the workers need it reachable with their registration
+	secrets created, and the KubeSlice Manager needs the controller release`)
+	installCmd.RegisterFlagCompletionFunc("components", completeComponents)
+	installCmd.Flags().StringVarP(&pkg.Options.CertManager, "cert-manager", "", pkg.Options.CertManager, `How to handle cert-manager, which the controller needs, on the controller cluster.
+Supported values:
+	- auto: Uses a cert-manager found on the cluster when it is recent enough, fails when it is too old and installs it when absent. Topologies without cert_manager_chart leave cert-manager alone
+	- install: Installs cert-manager without looking for an existing one
+	- skip: Never installs cert-manager`)
+	installCmd.RegisterFlagCompletionFunc("cert-manager", completeCertManagerModes)
 	installCmd.Flags().BoolVarP(&withCertManager, "with-cert-manager", "", false, `Installs Cert-Manager for kubeslice controller (for versions < 0.7.0)`)
+	installCmd.Flags().MarkDeprecated("with-cert-manager", "use --cert-manager=install instead")
+	installCmd.Flags().DurationVarP(&pkg.Options.HelmTimeout, "timeout", "", pkg.Options.HelmTimeout, `How long to wait for the pods of each helm release to become ready`)
+	installCmd.Flags().DurationVarP(&pkg.Options.RolloutTimeout, "rollout-timeout", "", pkg.Options.RolloutTimeout, `How long to wait for the deployments of the controller and the workers to roll out with all replicas ready`)
+	installCmd.Flags().BoolVarP(&pkg.Options.RecreateClusters, "recreate-clusters", "", false, `Deletes and recreates the kind clusters of the demo profiles which exist already. They are reused by default when reachable and
+running the Kubernetes version of their node image, their stopped nodes being restarted`)
+	installCmd.Flags().BoolVarP(&pkg.Options.MergeKubeconfig, "merge-kubeconfig", "", false, `Merges the contexts of the kind clusters of the demo profiles into the default kubeconfig, ~/.kube/config or the first file of $KUBECONFIG.
+	Contexts of other clusters with the same name are kept, the kind clusters are then merged under a new name.
+	The kubeconfig is first copied to <kubeconfig>.kubeslice-backup-<timestamp>`)
+	addStrictValuesFlag(installCmd)
+	addTemplatesDirFlag(installCmd)
+	addForceConflictsFlag(installCmd)
+	installCmd.Flags().BoolVarP(&pkg.Options.Devel, "devel", "", false, `Resolves version ranges and unpinned chart versions to pre-releases too, e.g. release candidates`)
+	installCmd.Flags().BoolVarP(&pkg.Options.Diff, "diff", "", false, `Shows the manifest changes of every release being upgraded, rendered with helm template, and asks before applying them`)
+	installCmd.Flags().StringVarP(&pkg.Options.RenderTo, "render-to", "", "", `<path-to-directory>
+	Renders the values files and manifests of every release, the project and the cluster registration
+	into a directory per cluster and component instead of installing them, e.g. for GitOps.
+	No cluster is touched. Sensitive values are left out`)
+	installCmd.Flags().StringVarP(&pkg.Options.FromBundle, "from-bundle", "", "", `<path-to-bundle>
+	Installs the charts of an offline bundle of bundle create instead of pulling them from their repositories.
+	The bundle is verified first, every configured chart has to be part of it at the version the topology pins`)
+	installCmd.Flags().BoolVarP(&pkg.Options.ReuseValues, "reuse-values", "", false, `Merges the generated values on top of the values of already deployed releases,
+	keeping values set on a release outside of the topology, e.g. with helm upgrade --set.
+	Values removed from the topology are then kept on the release too, set them to null to delete them.
+	By default the topology is the only source of the values`)
+	installCmd.Flags().BoolVarP(&pkg.Options.RollbackOnFailure, "rollback-on-failure", "", false, `Rolls a release whose upgrade failed back to its previous revision.
+	Exits with 2 when the rollback succeeded and 3 when it failed too`)
+	installCmd.Flags().BoolVarP(&pkg.Options.HelmTests, "helm-tests", "", false, `Runs the helm tests of every installed release and fails when one fails. Default for the demo profiles`)
+	installCmd.Flags().BoolVarP(&skipHelmTests, "skip-helm-tests", "", false, `Skips the helm tests of the installed releases, which run by default for the demo profiles`)
+	installCmd.MarkFlagsMutuallyExclusive("helm-tests", "skip-helm-tests")
+	installCmd.Flags().BoolVarP(&pkg.Options.SkipRegistryCheck, "skip-registry-check", "", false, `Skips testing the image pull credentials against the registries before installing`)
+	installCmd.Flags().BoolVarP(&pkg.Options.SkipPreflight, "skip-preflight", "", false, `Skips checking that every cluster is reachable, its context may create namespaces and CRDs
+	and it runs a supported Kubernetes version before installing`)
+	installCmd.Flags().BoolVarP(&pkg.Options.IgnoreVersionCheck, "ignore-version-check", "", false, `Warns instead of failing when a cluster runs a Kubernetes version the charts to install do not support.
+	For kind clusters to be created the version of the node image is checked`)
+	installCmd.Flags().BoolVarP(&pkg.Options.NoAutoLabel, "no-auto-label", "", false, `Fails with the kubectl label command to run instead of labeling nodes of worker clusters
+	without a kubeslice.io/node-type=gateway node, which the slice gateways need`)
+	installCmd.Flags().StringVarP(&pkg.Options.OnExisting, "on-existing", "", pkg.OnExistingAdopt, `What to do with KubeSlice releases and CRDs found on the clusters which the state of the installation does not record,
+e.g. installed by someone else or by an older kubeslice-cli.
+Supported values:
+	- adopt: Records the existing releases in the state and installs only the missing components,
+	  warning about clusters with kubeslice CRDs but no release, which are installed to
+	- skip: Leaves the clusters with existing releases or CRDs untouched
+	- fail: Fails with a report of the existing releases and CRDs`)
+	installCmd.Flags().StringVarP(&pkg.Options.Platform, "platform", "", "", `Platform of the kind nodes of the demo profiles, e.g. linux/amd64, pulling the node images for it.
+	Defaults to the architecture of the container runtime. Off amd64 the node images and the images of the charts are verified to publish it.
+	Ignored with a warning when the run creates no kind clusters, and not combinable with --skip-image-prepull`)
+	installCmd.Flags().BoolVarP(&pkg.Options.SkipImagePrepull, "skip-image-prepull", "", false, `Leaves pulling the node images of the kind clusters to kind create cluster, for every cluster.
+	By default they are pulled once through the proxy of the run, showing the progress, before any cluster is created.
+	Not combinable with --platform, as kind pulls the node images for the platform of the container runtime`)
+	installCmd.Flags().BoolVarP(&pkg.Options.SkipVerify, "skip-verify", "", false, `Skips verifying the connectivity of the demo slice with iperf after installing the full-demo and enterprise-demo profiles`)
+	installCmd.Flags().BoolVarP(&pkg.Options.CheckQoS, "check-qos", "", false, `Verifies that the bandwidth over the demo slice stays within the bandwidth ceiling of its QoS profile`)
+	installCmd.Flags().BoolVarP(&pkg.Options.SkipDiagnostics, "skip-diagnostics", "", false, `Skips collecting a diagnostics bundle into the workspace when the install fails`)
+	installCmd.Flags().BoolVarP(&pkg.Options.Resume, "resume", "", false, `Resumes a failed install from the failed step. The steps it completed are skipped when their inputs did not change
+	and their clusters, releases and objects still exist, the others are run again. The checkpoints are kept in ~/.kubeslice/checkpoints`)
+	installCmd.Flags().BoolVarP(&pkg.Options.NoResume, "no-resume", "", false, `Discards the checkpoints of a failed install and runs every step`)
+	addPlanFlags(installCmd)
 
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var installationsCmd = &cobra.Command{
+	Use:   "installations",
+	Short: "Inspect the installations recorded by kubeslice-cli.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var installationsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the known installations",
+	Long: `Lists the installations whose state is recorded in ~/.kubeslice/state.
+	The state is written after every install and updated on uninstall`,
+	Example: `  kubeslice-cli installations list`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pkg.ListInstallations()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(installationsCmd)
+	installationsCmd.AddCommand(installationsListCmd)
+}
@@ -9,22 +9,55 @@ import (
 var registerCmd = &cobra.Command{
 	Use:   "register",
 	Short: "Register a Kubeslice worker cluster.",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Registers a worker cluster with the project of the controller.
+
+	With --kube-context, the worker joins the installation of the topology of --config:
+	it is registered with its project, the worker chart is installed onto it with the values
+	generated from its secret and it is waited for to be ready. The other clusters are left
+	untouched and the worker is recorded in the state of the installation`,
+	Example: `  kubeslice-cli register worker ks-w-3 -n kubeslice-demo
+  kubeslice-cli register worker ks-w-3 -n kubeslice-demo --dry-run=client
+  kubeslice-cli register worker --name ks-w-4 --kube-context gke-w-4 --kubeconfig w-4.yaml -c topology.yaml`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var objectName string
 		ns, _ := cmd.Flags().GetString("namespace")
-		if ns == "" {
-			util.Fatalf("Namespace is required")
-		}
+		project, _ := cmd.Flags().GetString("project")
 		filename, _ := cmd.Flags().GetString("filename")
+		dryRun := dryRun(cmd)
 
 		if len(args) > 1 {
 			objectName = args[1]
 		}
+		name, _ := cmd.Flags().GetString("name")
+		if name != "" {
+			objectName = name
+		}
+		kubeContext, _ := cmd.Flags().GetString("kube-context")
+		kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+		join := kubeContext != ""
+		if join {
+			if args[0] != "worker" || objectName == "" {
+				util.Fatalf("%v Please pass the name of the worker to join with --name", util.Cross)
+			}
+			if Config == "" {
+				util.Fatalf("%v Please pass the topology of the installation the worker joins with --config", util.Cross)
+			}
+			if dryRun || filename != "" || cmd.Flags().Changed("namespace") {
+				util.Fatalf("%v --kube-context registers the worker with its project, it cannot be combined with --dry-run, --filename or --namespace, use --project instead", util.Cross)
+			}
+		}
 
-		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename})
+		pkg.SetCliOptions(pkg.CliParams{Config: Config, Namespace: ns, ObjectName: objectName, ObjectType: args[0], FileName: filename, DryRun: dryRun, Project: project})
+		if pkg.CliOptions.Namespace == "" {
+			util.Fatalf("Namespace is required, pass it with -n or select the project with --project")
+		}
 		switch args[0] {
 		case "worker":
+			if join {
+				pkg.JoinWorker(kubeContext, kubeconfig)
+				return
+			}
 			pkg.RegisterWorker()
 		default:
 			util.Fatalf("Invalid object type")
@@ -34,6 +67,14 @@ var registerCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(registerCmd)
+	addDryRunFlags(registerCmd)
 	registerCmd.Flags().StringP("namespace", "n", "", "namespace")
+	registerCmd.Flags().String("project", "", "Project of the resources, sets the namespace to the one of the project when -n is not passed")
 	registerCmd.Flags().StringP("filename", "f", "", "Filename, directory, or URL to file to use to create the resource")
+	registerCmd.Flags().String("name", "", "Name of the worker, instead of the argument")
+	registerCmd.Flags().String("kube-context", "", "Context of the worker cluster, joins the worker to the installation of --config")
+	registerCmd.Flags().String("kubeconfig", "", "Kubeconfig of the worker cluster, kube_config_path of the topology by default")
+	addStrictValuesFlag(registerCmd)
+	addTemplatesDirFlag(registerCmd)
+	addForceConflictsFlag(registerCmd)
 }
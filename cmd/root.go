@@ -4,11 +4,18 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
 	"github.com/spf13/cobra"
 	// "github.com/spf13/cobra/doc"
 )
 
 var version = "0.6.0"
+
+// commit and buildDate are set at build time with
+// -ldflags "-X github.com/kubeslice/kubeslice-cli/cmd.commit=... -X github.com/kubeslice/kubeslice-cli/cmd.buildDate=..."
+var commit, buildDate string
+
 var rootCmd = &cobra.Command{
 	Use:     "kubeslice-cli",
 	Version: version,
@@ -18,6 +25,10 @@ var rootCmd = &cobra.Command{
 Use kubeslice-cli to install/uninstall required workloads to run KubeSlice Controller and KubeSlice Worker.
 Additional example applications can also be installed in demo profiles to showcase the
 KubeSlice functionality`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		applyUserDefaults(cmd)
+		pkg.SetWorkspace()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -25,13 +36,41 @@ KubeSlice functionality`,
 var RootCmd = rootCmd
 
 func Execute() {
+	pkg.SetVersion(version, commit, buildDate)
 	rootCmd.PersistentFlags().StringVarP(&Config, "config", "c", "", `<path-to-topology-configuration-yaml-file>
 	The yaml file with topology configuration. 
 	Refer: https://github.com/kubeslice/kubeslice-cli/blob/master/samples/template.yaml`)
+	rootCmd.MarkPersistentFlagFilename("config", "yaml", "yml")
+	rootCmd.PersistentFlags().BoolVarP(&pkg.Options.ExpandEnv, "expand-env", "", false, `Expands ${VAR} and ${VAR:-default} references in the topology configuration.
+	Use $$ for a literal dollar sign`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.Workspace, "workspace", "", "", `<path-to-directory>
+	The directory generated values files, manifests and kind configurations are written to.
+	Defaults to a new directory per run under ~/.kubeslice/runs, of which the 10 most recent are kept`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.KubeConfig, "kubeconfig", "", "", `<path-to-kubeconfig>
+	Kubeconfig of the clusters of a topology of existing clusters which sets no configuration.cluster_configuration.kube_config_path`)
+	rootCmd.PersistentFlags().BoolVarP(&pkg.Options.KeepGeneratedFiles, "keep-generated-files", "", false, `Keeps the generated helm values files after the run for inspection, and the workspaces of all earlier runs under ~/.kubeslice/runs`)
+	rootCmd.PersistentFlags().BoolVarP(&pkg.Options.AssumeYes, "yes", "y", false, `Answers yes to all confirmation prompts, which fail without it when stdin is not a terminal. Diffs are still printed`)
+	rootCmd.PersistentFlags().BoolVarP(&pkg.Options.RefreshCharts, "refresh-charts", "", false, `Updates the helm repo index even if it was updated within the charts cache TTL`)
+	rootCmd.PersistentFlags().BoolVarP(&pkg.Options.Offline, "offline", "", false, `Never updates the helm repo index. The repo must have been added by an earlier run`)
+	rootCmd.PersistentFlags().DurationVarP(&pkg.Options.ChartsCacheTTL, "charts-cache-ttl", "", pkg.Options.ChartsCacheTTL, `How long an updated helm repo index is used without updating it again`)
+	rootCmd.MarkFlagsMutuallyExclusive("refresh-charts", "offline")
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.HTTPProxy, "http-proxy", "", "", `Proxy for http requests of helm, docker and kind. Overrides configuration.proxy_configuration.http_proxy`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.HTTPSProxy, "https-proxy", "", "", `Proxy for https requests of helm, docker and kind. Overrides configuration.proxy_configuration.https_proxy`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.NoProxy, "no-proxy", "", "", `Comma separated hosts, domains and CIDRs which are not proxied. The cluster api servers are always added`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.HelmBackend, "helm-backend", "", "exec", `Manages the helm releases with the helm binary (exec) or the helm SDK (sdk).
+	The sdk backend is experimental and only available in builds with the helmsdk build tag`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.Installation, "installation", "", "", `Name the state of the installation is stored under in ~/.kubeslice/state.
+	Defaults to the name of the controller cluster. Selects the installation status and uninstall work on without --config`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.ForceAPIVersion, "force-api-version", "", "", `Generates the Project, Cluster, SliceConfig and ServiceExportConfig manifests for this version of
+	controller.kubeslice.io, e.g. v1alpha1, instead of the version detected on the controller cluster`)
+	rootCmd.PersistentFlags().StringVarP(&pkg.Options.KubeClient, "kube-client", "", "kubectl", `Reads and applies the kubeslice objects, secrets, namespaces and pods with the kubectl binary (kubectl) or client-go (client-go).
+	The client-go client is only available in builds with the clientgo build tag. Edit, describe and port-forward always run kubectl`)
+	rootCmd.PersistentFlags().BoolVarP(&util.Debug, "debug", "", false, `Prints debug output`)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Whoops. There was an error while executing kubeslice-cli '%s'", err)
-		os.Exit(1)
+		util.Exit(1)
 	}
+	util.RunExitHandlers()
 	//  Uncomment to generate docs for new commands/flags
 	// doc.GenMarkdownTree(rootCmd, "doc")
 
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var showValuesDiff bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows the health of the installation and whether it matches the topology",
+	Long: `Reports the health of the installation on every cluster: the status of its
+	releases, the readiness of the deployments of the KubeSlice namespaces, the health
+	the workers report to the controller, the slices with their gateways and
+	cert-manager with its webhook. A cluster which cannot be reached is reported as
+	such. A missing cert-manager is unhealthy when kubeslice-cli installed it. The
+	command exits with 1 when anything is unhealthy, -o json only prints the health,
+	on stdout, with the progress on stderr
+
+	Recomputes the helm values of every release from the topology and
+	compares them with the values the releases were last installed with.
+	The checksums of the installed values are recorded per installation in ~/.kubeslice/values-checksums.yaml
+
+	Without --config the releases recorded in the state of the installation in
+	~/.kubeslice/state are compared with the releases deployed on its clusters
+
+	The number of nodes of every worker labeled kubeslice.io/node-type=gateway,
+	which the slice gateways are scheduled on, is reported too
+
+	When the topology enables monitoring, whether Prometheus scrapes the metrics
+	of the controller and of every worker is reported`,
+	Example: `  kubeslice-cli status -c topology.yaml --diff
+  kubeslice-cli status --installation ks-ctrl
+  kubeslice-cli status -c topology.yaml -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if outputFormat != "" && outputFormat != "json" {
+			util.Fatalf("%v Unknown output format: %s. Possible values %s", util.Cross, outputFormat, []string{"json"})
+		}
+		if Config == "" {
+			pkg.ReadInstallationState(pkg.Options.Installation)
+		} else {
+			pkg.ReadAndValidateConfiguration(Config, "")
+		}
+		pkg.Status(showValuesDiff, outputFormat)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVarP(&showValuesDiff, "diff", "", false, `Prints the values diff of the releases with drift`)
+	statusCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Prints the health of the installation as json, supported values json")
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Forwards a local port to the KubeSlice Manager UI",
+	Long: `Forwards a local port to the kubeslice-ui-proxy service of the KubeSlice Manager on the controller cluster
+	and prints the URL to open and how to read the token to log in with. The forward runs until interrupted
+	with Ctrl+C and is restarted when it drops. The UI is installed by the enterprise-demo profile, with the
+	ui_chart of the topology or with ui.enabled.
+
+	Without --config the controller cluster of the installation stored in ~/.kubeslice/state is used`,
+	Example: `  kubeslice-cli ui --config topology.yaml
+  kubeslice-cli ui --installation ks-ctrl --port 9443`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetInt("port")
+		if port < 1 || port > 65535 {
+			util.Fatalf("%s --port must be between 1 and 65535", util.Cross)
+		}
+		if Config == "" {
+			pkg.ReadInstallationState(pkg.Options.Installation)
+		} else {
+			pkg.ReadAndValidateConfiguration(Config, "")
+		}
+		pkg.PortForwardUI(port)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+	uiCmd.Flags().Int("port", 8443, "Local port forwarded to the KubeSlice Manager UI")
+}
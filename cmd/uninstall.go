@@ -14,6 +14,7 @@ var (
 	uninstallWorker       = []string{}
 	workersToUninstall    map[string]string
 	componentsToUninstall map[string]string
+	deleteCRDs            bool
 )
 var uninstallCmd = &cobra.Command{
 	Use:     "uninstall",
@@ -21,13 +22,28 @@ var uninstallCmd = &cobra.Command{
 	Short:   "Performs cleanup of Kubeslice components.",
 	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		pkg.ReadAndValidateConfiguration(Config, "")
+		validatePlanFlags()
+		// without a topology the installation is loaded from its state, the
+		// demo topology is uninstalled when no state is stored
+		if Config == "" && (pkg.Options.Installation != "" || pkg.HasInstallations()) {
+			pkg.ReadInstallationState(pkg.Options.Installation)
+		} else {
+			pkg.ReadAndValidateConfiguration(Config, "")
+		}
+		if deleteCRDs {
+			pkg.Options.RetainCRDs = false
+		}
+		if pkg.Options.DeleteNamespaces && pkg.Options.RetainResources {
+			util.Fatalf("\n %v Cannot delete the namespaces while retaining the custom resources in them, pass --retain-custom-resources=false as well", util.Cross)
+		}
 		// if --all flag is passed, other flags should not be allowed
 		if uninstallAll && uninstallUI {
 			cmd.Help()
 			util.Fatalf("\n %v Cannot use other options if --all is passed", util.Cross)
 		}
 
+		// only an explicit --all deletes the kind clusters of a demo
+		deleteClusters := uninstallAll
 		// if no flags are passed, set uninstallAll true
 		if !uninstallAll && !uninstallUI {
 			uninstallAll = true
@@ -40,6 +56,8 @@ var uninstallCmd = &cobra.Command{
 			uninstallController = true
 			uninstallUI = true
 			uninstallWorker = []string{"*"}
+			// only the cert-manager the state records kubeslice-cli installing
+			uninstallCertManager = true
 		}
 		if uninstallController {
 			componentsToUninstall["controller"] = ""
@@ -54,16 +72,28 @@ var uninstallCmd = &cobra.Command{
 			componentsToUninstall["worker"] = ""
 			workersToUninstall = mapFromSlice(uninstallWorker)
 		}
-		pkg.Uninstall(componentsToUninstall, workersToUninstall)
+		pkg.Uninstall(componentsToUninstall, workersToUninstall, deleteClusters)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(uninstallCmd)
-	uninstallCmd.Flags().BoolVarP(&uninstallAll, "all", "a", false, `Uninstalls all components (Worker, Controller, UI)`)
+	uninstallCmd.Flags().BoolVarP(&uninstallAll, "all", "a", false, `Uninstalls all components (Worker, Controller, UI and the cert-manager kubeslice-cli installed). For the demo profiles, deletes the kind clusters created by kubeslice-cli instead,
+asking before deleting kind clusters of the same names it did not create, which --yes does not answer. The clusters of a topology of existing clusters are never deleted.
+Without it the components are uninstalled and the kind clusters retained`)
 	uninstallCmd.Flags().BoolVarP(&uninstallUI, "ui", "u", false, `Uninstalls enterprise UI components (Kubeslice-Manager)`)
-	// TODO: update the controller version after release
-	uninstallCmd.Flags().BoolVarP(&uninstallCertManager, "cert-manager", "", false, `Uninstalls Cert Manager (required for controller version < 0.7.0)`)
+	uninstallCmd.Flags().BoolVarP(&uninstallCertManager, "cert-manager", "", false, `Uninstalls Cert Manager along with the controller, when the state of the installation records kubeslice-cli installing it`)
+	uninstallCmd.Flags().BoolVarP(&pkg.Options.RetainCRDs, "retain-crds", "", true, `Keeps the KubeSlice CRDs, as helm does. With --retain-crds=false the CRDs are deleted, except those
+of retained custom resources`)
+	uninstallCmd.Flags().BoolVarP(&pkg.Options.RetainResources, "retain-custom-resources", "", true, `Keeps the Projects, SliceConfigs and other KubeSlice custom resources for install --on-existing=adopt to resume with.
+With --retain-custom-resources=false they are deleted, dependents first, clearing the kubeslice finalizers left without their controller`)
+	uninstallCmd.Flags().BoolVarP(&deleteCRDs, "delete-crds", "", false, `Deletes the KubeSlice CRDs once no custom resources of them remain`)
+	uninstallCmd.Flags().MarkDeprecated("delete-crds", "use --retain-crds=false instead")
+	uninstallCmd.Flags().BoolVarP(&pkg.Options.DeleteNamespaces, "delete-namespaces", "", false, `Deletes the KubeSlice namespaces and webhook configurations of the controller and worker clusters.
+Needs --retain-custom-resources=false, the namespaces holding the custom resources`)
+	uninstallCmd.Flags().BoolVarP(&pkg.Options.DeleteUnmanaged, "delete-unmanaged-kind-clusters", "", false, `Deletes with --all the kind clusters of the topology which kubeslice-cli did not create as well, without asking.
+Without it they are retained when stdin is not a terminal`)
+	addPlanFlags(uninstallCmd)
 	// TODO: A discussion is needed for graceful cleanup of worker clusters
 	// uninstallCmd.Flags().StringSliceVarP(&uninstallWorker, "worker", "", []string{}, `Uninstalls worker clusters`)
 	// uninstallCmd.Flags().Lookup("worker").NoOptDefVal = "*"
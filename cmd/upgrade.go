@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrades the KubeSlice components to the chart versions of the topology",
+	Long: `Compares the chart versions the topology pins with the releases deployed on its clusters
+	and prints the upgrade plan of every component and cluster. The KubeSlice Controller is then
+	upgraded first, followed by the KubeSlice Manager and the KubeSlice Workers, each waited for to be
+	ready before the next one. Releases at the target version already are reported as up-to-date,
+	components which are not installed are left to install
+
+	Helm does not upgrade the CRDs of a chart, the CRDs which changed are applied before its release`,
+	Example: `  kubeslice-cli upgrade -c topology.yaml
+  kubeslice-cli upgrade -c topology.yaml --rollback-on-failure --diff`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" {
+			util.Fatalf("\n %v Please pass the --config option", util.Cross)
+		}
+		validatePlanFlags()
+		pkg.ReadAndValidateConfiguration(Config, "")
+		pkg.Upgrade()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.Flags().DurationVarP(&pkg.Options.HelmTimeout, "timeout", "", pkg.Options.HelmTimeout, `How long to wait for the pods of each upgraded helm release to become ready`)
+	upgradeCmd.Flags().DurationVarP(&pkg.Options.RolloutTimeout, "rollout-timeout", "", pkg.Options.RolloutTimeout, `How long to wait for the deployments of the controller and the workers to roll out with all replicas ready`)
+	upgradeCmd.Flags().BoolVarP(&pkg.Options.RollbackOnFailure, "rollback-on-failure", "", false, `Rolls a release whose upgrade failed back to its previous revision.
+	Exits with 2 when the rollback succeeded and 3 when it failed too`)
+	upgradeCmd.Flags().BoolVarP(&pkg.Options.Diff, "diff", "", false, `Shows the manifest changes of every release being upgraded, rendered with helm template, and asks before applying them`)
+	upgradeCmd.Flags().BoolVarP(&pkg.Options.ReuseValues, "reuse-values", "", false, `Merges the generated values on top of the values of the deployed releases,
+	keeping values set on a release outside of the topology, e.g. with helm upgrade --set`)
+	addPlanFlags(upgradeCmd)
+	addStrictValuesFlag(upgradeCmd)
+	upgradeCmd.Flags().BoolVarP(&pkg.Options.Devel, "devel", "", false, `Resolves version ranges and unpinned chart versions to pre-releases too, e.g. release candidates`)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates a topology configuration file",
+	Long: `Checks a topology configuration file without touching the clusters: fields which are not
+	known, e.g. typos and mis-indented keys, values of the wrong type and missing required fields
+	are all reported at once with their YAML path, and their line where it is known.
+	Exits with an error when the topology is invalid`,
+	Example: `  kubeslice-cli validate -c topology.yaml`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Config == "" {
+			util.Fatalf("\n %v Please pass the --config option", util.Cross)
+		}
+		pkg.ValidateConfiguration(Config)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/kubeslice/kubeslice-cli/pkg"
+	"github.com/kubeslice/kubeslice-cli/util"
+	"github.com/spf13/cobra"
+)
+
+var remoteVersions bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Prints the versions of kubeslice-cli and of the deployed components",
+	Long: `Prints the version, commit and build date of kubeslice-cli.
+
+	With --remote the chart versions and the images of the controller, the workers
+	and cert-manager deployed on the clusters of the topology are reported too, flagging
+	components running different versions on their clusters or other versions than the
+	topology pins. The components of unreachable clusters are reported as unknown`,
+	Example: `  kubeslice-cli version
+  kubeslice-cli version --remote -c topology.yaml -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if outputFormat != "" && outputFormat != "json" {
+			util.Fatalf("%v Unknown output format: %s. Possible values %s", util.Cross, outputFormat, []string{"json"})
+		}
+		if remoteVersions {
+			if Config == "" {
+				util.Fatalf("\n %v Please pass the --config option with --remote", util.Cross)
+			}
+			pkg.ReadAndValidateConfiguration(Config, "")
+		}
+		pkg.Version(remoteVersions, outputFormat)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVarP(&remoteVersions, "remote", "", false, `Queries the clusters of the topology for the versions of the deployed components`)
+	versionCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "supported values json")
+}
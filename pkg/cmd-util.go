@@ -4,19 +4,47 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
-	"github.com/go-yaml/yaml"
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
 const (
-	ProfileFullDemo    = "full-demo"
-	ProfileMinimalDemo = "minimal-demo"
-	ProfileEntDemo     = "enterprise-demo"
+	ProfileFullDemo    = internal.ProfileFullDemo
+	ProfileMinimalDemo = internal.ProfileMinimalDemo
+	ProfileEntDemo     = internal.ProfileEntDemo
+	ProfileCustom      = internal.ProfileCustom
 	ClusterTypeKind    = "kind"
 )
 
+// Scripts images list can generate to mirror the images.
+const (
+	MirrorScriptSkopeo = internal.MirrorScriptSkopeo
+	MirrorScriptDocker = internal.MirrorScriptDocker
+)
+
+// What install does with KubeSlice components it finds installed outside of
+// the installation.
+const (
+	OnExistingAdopt = internal.OnExistingAdopt
+	OnExistingSkip  = internal.OnExistingSkip
+	OnExistingFail  = internal.OnExistingFail
+)
+
+// How install handles cert-manager on the controller cluster.
+const (
+	CertManagerAuto    = internal.CertManagerAuto
+	CertManagerInstall = internal.CertManagerInstall
+	CertManagerSkip    = internal.CertManagerSkip
+)
+
+// Roles of the users of a project.
+const (
+	ProjectRoleReadWrite = internal.ProjectRoleReadWrite
+	ProjectRoleReadOnly  = internal.ProjectRoleReadOnly
+)
+
 type CliParams struct {
 	ObjectType   string // "project", "cluster", "sliceConfig"
 	ObjectName   string // "projectName", "clusterName", "sliceConfigName"
@@ -24,11 +52,35 @@ type CliParams struct {
 	FileName     string // path to the resource description file
 	Config       string // cluster
 	OutputFormat string //output format
+	DryRun       bool   // print the generated manifests instead of applying them
+	Project      string // project of the objects, the only project of the topology when empty
 	Key          []string
 }
 
 var ApplicationConfiguration *internal.ConfigurationSpecs
 
+// SetVersion sets the version, commit and build date of the cli, which the
+// version command and the diagnostics report.
+func SetVersion(version, commit, buildDate string) {
+	internal.CLIVersion = version
+	if commit != "" {
+		internal.CLICommit = commit
+	}
+	if buildDate != "" {
+		internal.CLIBuildDate = buildDate
+	}
+}
+
+// SetWorkspace selects the directory the generated files of this run are
+// written to.
+func SetWorkspace() {
+	internal.SetWorkspace(Options.Workspace)
+}
+
+// Options exposes the run options of the internal steps so that the commands
+// can bind their flags to it.
+var Options = internal.Options
+
 var CliOptions *internal.CliOptionsStruct
 
 func SetCliOptions(cliParams CliParams) {
@@ -37,6 +89,12 @@ func SetCliOptions(cliParams CliParams) {
 	if cliParams.Config != "" {
 		controllerCluster = &configSpecs.Configuration.ClusterConfiguration.ControllerCluster
 	}
+	if cliParams.Project != "" {
+		configSpecs.Configuration.KubeSliceConfiguration.ProjectName = cliParams.Project
+	}
+	if cliParams.Namespace == "" && (cliParams.Config != "" || cliParams.Project != "") {
+		cliParams.Namespace = objectNamespace(configSpecs, cliParams.ObjectType, cliParams.Project)
+	}
 	options := &internal.CliOptionsStruct{
 		Namespace:    cliParams.Namespace,
 		ObjectName:   cliParams.ObjectName,
@@ -44,6 +102,7 @@ func SetCliOptions(cliParams CliParams) {
 		FileName:     cliParams.FileName,
 		Cluster:      controllerCluster,
 		OutputFormat: cliParams.OutputFormat,
+		DryRun:       cliParams.DryRun,
 	}
 	CliOptions = options
 	util.ExecutablePaths = map[string]string{
@@ -51,24 +110,30 @@ func SetCliOptions(cliParams CliParams) {
 	}
 }
 
+// objectNamespace returns the namespace of the objects of a project, the
+// only project of the topology when none is selected. It is empty for a
+// topology managing several projects, whose project must be selected.
+func objectNamespace(specs *internal.ConfigurationSpecs, objectType, project string) string {
+	if objectType == "project" {
+		return internal.ControllerNamespace(specs.Configuration.ClusterConfiguration.ControllerCluster)
+	}
+	if project == "" {
+		projects := internal.ProjectNames(specs.Configuration.KubeSliceConfiguration)
+		if len(projects) > 1 {
+			return ""
+		}
+		project = projects[0]
+	}
+	return "kubeslice-" + project
+}
+
+// defaultConfiguration is the topology of the profiles, with the kind
+// clusters of the profile.
 var defaultConfiguration = &internal.ConfigurationSpecs{
 	Configuration: internal.Configuration{
-		ClusterConfiguration: internal.ClusterConfiguration{
-			Profile: "full-demo",
-			ControllerCluster: internal.Cluster{
-				Name: "ks-ctrl",
-			},
-			WorkerClusters: []internal.Cluster{
-				{
-					Name: "ks-w-1",
-				},
-				{
-					Name: "ks-w-2",
-				},
-			},
-		},
 		KubeSliceConfiguration: internal.KubeSliceConfiguration{
 			ProjectName: "demo",
+			Slice:       internal.DemoSlice,
 		},
 		HelmChartConfiguration: internal.HelmChartConfiguration{
 			RepoAlias: "kubeslice-demo",
@@ -115,10 +180,12 @@ func readConfiguration(fileName string) *internal.ConfigurationSpecs {
 		util.Fatalf("%s Failed to read configuration file %v", util.Cross, err)
 
 	}
-	specs := &internal.ConfigurationSpecs{}
-	err = yaml.Unmarshal(file, specs)
-	if err != nil {
-		util.Fatalf("%s Failed to parse configuration file %v", util.Cross, err)
+	specs, errors := internal.DecodeTopology(file)
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Failed to parse configuration file %s", util.Cross, fileName)
 	}
 	return specs
 }
@@ -131,28 +198,43 @@ func validateConfiguration(specs *internal.ConfigurationSpecs) []string {
 	cc := &specs.Configuration.ClusterConfiguration
 	ksc := &specs.Configuration.KubeSliceConfiguration
 	hc := &specs.Configuration.HelmChartConfiguration
-	if hc.ImagePullSecret.Password == "" {
-		hc.ImagePullSecret.Password = os.Getenv("KUBESLICE_IMAGE_PULL_PASSWORD")
+	internal.SetDefaultProject(ksc)
+	// the environment provides the credentials of the first registry
+	if len(hc.ImagePullSecrets) == 0 {
+		hc.ImagePullSecrets = internal.ImagePullSecretList{{}}
 	}
-	if hc.ImagePullSecret.Username == "" {
+	ips := &hc.ImagePullSecrets[0]
+	if ips.Password == "" && ips.SecretName == "" {
+		ips.Password = os.Getenv("KUBESLICE_IMAGE_PULL_PASSWORD")
+	}
+	errors = append(errors, internal.ResolveHelmPasswords(hc)...)
+	errors = append(errors, internal.ValidateLicenseConfiguration(specs.Configuration.License)...)
+	internal.NormalizeImagePullSecrets(hc)
+	for _, secret := range hc.ImagePullSecrets {
+		util.RegisterSecret(secret.Password)
+	}
+	if ips.Username == "" && ips.SecretName == "" {
 		if os.Getenv("KUBESLICE_IMAGE_PULL_USERNAME") == "" {
-			hc.ImagePullSecret.Username = "aveshaenterprise"
+			ips.Username = "aveshaenterprise"
 		} else {
-			hc.ImagePullSecret.Username = os.Getenv("KUBESLICE_IMAGE_PULL_USERNAME")
+			ips.Username = os.Getenv("KUBESLICE_IMAGE_PULL_USERNAME")
 		}
 
 	}
+	// custom is the profile of the topologies of existing clusters
+	if cc.Profile == ProfileCustom {
+		cc.Profile = ""
+	}
 	if cc.Profile != "" {
 		switch cc.Profile {
 		case ProfileFullDemo:
 		case ProfileMinimalDemo:
 		case ProfileEntDemo:
-			if hc.ImagePullSecret.Password == "" {
+			if ips.Password == "" && ips.SecretName == "" {
 				errors = append(errors, fmt.Sprintf("%s Missing image pull secret password. Please set environment variable `KUBESLICE_IMAGE_PULL_PASSWORD`", util.Cross))
 			}
 		default:
-			errors = append(errors, fmt.Sprintf("%s Unknown profile: %s. Possible values %s", util.Cross, cc.Profile, []string{ProfileFullDemo,
-				ProfileMinimalDemo, ProfileEntDemo}))
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, internal.ValidateProfile(cc.Profile)))
 		}
 		if cc.KubeConfigPath != "" || cc.ControllerCluster.KubeConfigPath != "" {
 			errors = append(errors, fmt.Sprintf("%s Cannot specify configuration.cluster_configuration.kube_config_path or configuration.cluster_configuration.controller.kube_config_path when running a kind cluster demo", util.Cross))
@@ -162,9 +244,6 @@ func validateConfiguration(specs *internal.ConfigurationSpecs) []string {
 			errors = append(errors, fmt.Sprintf("%s Cannot specify configuration.cluster_configuration.controller.context_name when running a kind cluster demo", util.Cross))
 		}
 		cc.ControllerCluster.ContextName = "kind-" + cc.ControllerCluster.Name
-		if len(cc.WorkerClusters) < 2 {
-			errors = append(errors, fmt.Sprintf("%s At least 2 configuration.cluster_configuration.workers are required for kind cluster Demo", util.Cross))
-		}
 		for i, cluster := range cc.WorkerClusters {
 			if cluster.KubeConfigPath != "" {
 				errors = append(errors, fmt.Sprintf("%s Cannot specify configuration.cluster_configuration.kube_config_path or configuration.cluster_configuration.workers[%d].kube_config_path when running a kind cluster demo", util.Cross, i))
@@ -176,6 +255,11 @@ func validateConfiguration(specs *internal.ConfigurationSpecs) []string {
 			cc.WorkerClusters[i].ContextName = "kind-" + cluster.Name
 		}
 	} else {
+		// clusters are named after their context unless named otherwise
+		internal.DeriveClusterNames(cc)
+		if cc.KubeConfigPath == "" {
+			cc.KubeConfigPath = Options.KubeConfig
+		}
 		if cc.KubeConfigPath == "" && cc.ControllerCluster.KubeConfigPath == "" {
 			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.kube_config_path or configuration.cluster_configuration.controller.kube_config_path must be specified when setting up topology", util.Cross))
 		}
@@ -196,57 +280,155 @@ func validateConfiguration(specs *internal.ConfigurationSpecs) []string {
 				errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers[%d].context_name must be specified when setting up topology", util.Cross, i))
 			}
 		}
-	}
-	if cc.ControllerCluster.Name == "" {
-		errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.controller.name must be specified", util.Cross))
-	}
-	for i, cluster := range cc.WorkerClusters {
-		if cluster.Name == "" {
-			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers[%d].name must be specified", util.Cross, i))
+		// renders never touch the clusters
+		if Options.RenderTo == "" {
+			errors = append(errors, internal.ValidateKubeconfigs(*cc)...)
 		}
 	}
-	if ksc.ProjectName == "" {
-		errors = append(errors, fmt.Sprintf("%s configuration.kubeslice_configuration.project_name must be specified", util.Cross))
-	}
-	if hc.RepoAlias == "" {
+	errors = append(errors, internal.ValidateRequiredFields(specs)...)
+	errors = append(errors, internal.ValidateClusterNames(*cc)...)
+	errors = append(errors, internal.ValidateKindNodeImages(*cc)...)
+	errors = append(errors, internal.ValidateKindConfigurations(*cc)...)
+	if hc.RepoAlias == "" && !internal.IsOCIRepo(hc.RepoUrl) && internal.UsesDefaultRepository(*hc) {
 		errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.repo_alias must be specified", util.Cross))
 	}
-	if hc.RepoUrl == "" && !hc.UseLocal {
+	if hc.RepoUrl == "" && !hc.UseLocal && internal.UsesDefaultRepository(*hc) {
 		errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.repo_url must be specified", util.Cross))
 	}
-	if hc.CertManagerChart.ChartName == "" {
-		errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.cert_manager_chart must be specified", util.Cross))
+	errors = append(errors, internal.ValidateValuesYAML(hc)...)
+	errors = append(errors, internal.ValidateLocalCharts(hc)...)
+	errors = append(errors, internal.ValidateImagePullSecrets(*hc)...)
+	errors = append(errors, internal.ValidateProjects(specs.Configuration)...)
+	errors = append(errors, internal.ValidateObjectMetadata(specs.Configuration)...)
+	errors = append(errors, internal.ValidateNamespaces(specs.Configuration)...)
+	errors = append(errors, internal.ValidateSlice(specs)...)
+	errors = append(errors, internal.ValidateNodeIPs(specs.Configuration)...)
+	return errors
+}
+
+// validateInstallationTopology checks the topology recorded in the state of an
+// installation. It is the validated topology of an earlier run without its
+// credentials and values, which are not checked again.
+func validateInstallationTopology(specs *internal.ConfigurationSpecs) []string {
+	cc := specs.Configuration.ClusterConfiguration
+	errors := internal.ValidateRequiredFields(specs)
+	errors = append(errors, internal.ValidateClusterNames(cc)...)
+	// the kubeconfig of the kind clusters is gone once they are deleted
+	if cc.Profile == "" {
+		errors = append(errors, internal.ValidateKubeconfigs(cc)...)
+	}
+	errors = append(errors, internal.ValidateProjects(specs.Configuration)...)
+	errors = append(errors, internal.ValidateNamespaces(specs.Configuration)...)
+	return errors
+}
+
+// installation is the state the configuration was loaded from when no
+// topology was passed.
+var installation *internal.InstallationState
+
+// HasInstallations reports whether the state of any installation is stored.
+func HasInstallations() bool {
+	return internal.HasInstallations()
+}
+
+// ReadInstallationState loads the topology of an installation from its state
+// file, the only known installation when name is empty.
+func ReadInstallationState(name string) *internal.ConfigurationSpecs {
+	installation = internal.LoadInstallation(name)
+	Options.Installation = installation.Name
+	specs := &installation.Topology
+	if errors := validateInstallationTopology(specs); len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s The topology recorded for installation %s is invalid, pass --config with the topology of the installation", util.Cross, installation.Name)
 	}
-	if hc.ControllerChart.ChartName == "" {
-		errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.controller_chart must be specified", util.Cross))
+	if specs.Configuration.ClusterConfiguration.Profile != "" {
+		internal.SetKubeConfigPath()
 	}
-	if hc.WorkerChart.ChartName == "" {
-		errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.worker_chart must be specified", util.Cross))
+	internal.ConfigureProxy(specs)
+	internal.SelectChartInstaller(Options.HelmBackend)
+	internal.SelectKubeClient(Options.KubeClient)
+	if err := internal.ValidateForcedAPIVersion(Options.ForceAPIVersion); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
 	}
-	return errors
+	ApplicationConfiguration = specs
+	return specs
+}
+
+// SelectableComponents are the components of install --components.
+var SelectableComponents = internal.SelectableComponents
+
+// ValidateComponents checks that the components of --components are known.
+func ValidateComponents(components []string) error {
+	return internal.ValidateComponents(components)
+}
+
+// ValidateProfile checks that a profile of --profile is known.
+func ValidateProfile(profile string) error {
+	return internal.ValidateProfile(profile)
 }
 
+// ReadAndValidateConfiguration loads the topology of fileName, the default
+// topology of the profile without one. The profile applies to a topology
+// which does not set its own.
 func ReadAndValidateConfiguration(fileName, profile string) *internal.ConfigurationSpecs {
+	specs, errors := loadConfiguration(fileName, profile)
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to invalid configuration", util.Cross)
+	}
+	internal.ConfigureProxy(specs)
+	internal.SelectChartInstaller(Options.HelmBackend)
+	internal.SelectKubeClient(Options.KubeClient)
+	if err := internal.ValidateForcedAPIVersion(Options.ForceAPIVersion); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	ApplicationConfiguration = specs
+	return specs
+}
+
+// ValidateConfiguration only checks a topology file, exiting with an error
+// listing every problem of it when it is invalid.
+func ValidateConfiguration(fileName string) {
+	_, errors := loadConfiguration(fileName, "")
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Topology configuration %s is invalid", util.Cross, fileName)
+	}
+	util.Printf("%s Topology configuration %s is valid", util.Tick, fileName)
+}
+
+func loadConfiguration(fileName, profile string) (*internal.ConfigurationSpecs, []string) {
 	var specs *internal.ConfigurationSpecs
+	errors := make([]string, 0)
 	if fileName != "" {
 		specs = readConfiguration(fileName)
+		internal.ResolveRelativePaths(specs, filepath.Dir(fileName))
+		if Options.ExpandEnv {
+			errors = append(errors, internal.ExpandEnvInConfiguration(specs)...)
+		}
 	} else {
+		// the custom profile has no default topology
+		if profile == ProfileCustom {
+			return defaultConfiguration, []string{fmt.Sprintf("%s The %s profile installs the topology of --config, please pass the --config option", util.Cross, profile)}
+		}
 		specs = defaultConfiguration
 		specs.Configuration.ClusterConfiguration.ClusterType = ClusterTypeKind
 		// Set defaults for ent demo
 		if profile == ProfileEntDemo {
-			specs.Configuration.ClusterConfiguration.Profile = ProfileEntDemo
 			specs.Configuration.HelmChartConfiguration = *defaultEntConfiguration
 		}
 
 	}
-	errors := validateConfiguration(specs)
-	if len(errors) > 0 {
-		for _, s := range errors {
-			util.Printf(s)
-		}
-		util.Fatalf("%s Process failed due to invalid configuration", util.Cross)
+	if profile != "" {
+		internal.ApplyProfile(specs, profile)
 	}
-	ApplicationConfiguration = specs
-	return specs
+	internal.ApplyUIConfiguration(specs)
+	errors = append(errors, validateConfiguration(specs)...)
+	return specs, errors
 }
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/kubeslice/kubeslice-cli/pkg/internal"
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// ProfileNames returns the profiles install accepts.
+func ProfileNames() []string {
+	return internal.ProfileNames()
+}
+
+// completionConfiguration reads the topology for shell completion, nil when
+// it cannot be read. Completion never fails, it offers nothing instead.
+func completionConfiguration(fileName string) *internal.ConfigurationSpecs {
+	if fileName == "" {
+		return nil
+	}
+	file, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil
+	}
+	specs, errors := internal.DecodeTopology(file)
+	if len(errors) > 0 {
+		return nil
+	}
+	internal.ResolveRelativePaths(specs, filepath.Dir(fileName))
+	return specs
+}
+
+// CompleteClusterNames returns the names of the clusters of the topology,
+// only the workers when workers is set.
+func CompleteClusterNames(fileName string, workers bool) []string {
+	specs := completionConfiguration(fileName)
+	if specs == nil {
+		return nil
+	}
+	return internal.ClusterNames(specs, workers)
+}
+
+// CompleteObjectNames returns the names of the objects of a type on the
+// controller cluster of the topology, or of the current context without
+// one. The namespace is the one of the project when it is not passed.
+func CompleteObjectNames(fileName, objectType, namespace, project string) []string {
+	var cluster *internal.Cluster
+	specs := completionConfiguration(fileName)
+	if specs != nil {
+		cluster = &specs.Configuration.ClusterConfiguration.ControllerCluster
+		if namespace == "" {
+			if project != "" {
+				specs.Configuration.KubeSliceConfiguration.ProjectName = project
+			}
+			namespace = objectNamespace(specs, objectType, project)
+		}
+	} else if fileName != "" {
+		return nil
+	}
+	if namespace == "" && project != "" {
+		namespace = "kubeslice-" + project
+	}
+	// completion verifies no executables, kubectl is looked up on the path
+	if util.ExecutablePaths == nil {
+		util.ExecutablePaths = make(map[string]string)
+	}
+	util.ExecutablePaths["kubectl"] = "kubectl"
+	return internal.ObjectNames(cluster, objectType, namespace)
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestCompleteObjectNamesRunsKubectlOfThePath(t *testing.T) {
+	defer func(paths map[string]string) { util.ExecutablePaths = paths }(util.ExecutablePaths)
+	util.ExecutablePaths = nil
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	stub := "#!/bin/sh\necho \"$@\" > " + argsFile + "\necho blue red\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "kubectl"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if got, expected := CompleteObjectNames("", "sliceConfig", "", "demo"), []string{"blue", "red"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("CompleteObjectNames() = %q, want %q", got, expected)
+	}
+	args, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("kubectl did not run: %v", err)
+	}
+	if expected := "get sliceconfigs.controller.kubeslice.io -n kubeslice-demo"; !strings.Contains(string(args), expected) {
+		t.Errorf("kubectl %s, want it to %s", strings.TrimSpace(string(args)), expected)
+	}
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/kubeslice/kubeslice-cli/pkg/internal"
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// InitConfiguration writes a topology to fileName, asked for interactively
+// or the minimal-demo one with fromDefaults, and validates it with validate.
+func InitConfiguration(fileName string, fromDefaults, validate bool) {
+	if !fromDefaults && !util.IsTerminal(os.Stdin) {
+		util.Fatalf("%s stdin is not a terminal, pass --from-defaults to write the minimal-demo topology", util.Cross)
+	}
+	if _, err := os.Stat(fileName); err == nil && !Options.AssumeYes {
+		if fromDefaults || !util.Confirm("%s %s exists, overwrite it?", util.Warn, fileName) {
+			util.Fatalf("%s %s exists, pass --yes to overwrite it", util.Cross, fileName)
+		}
+	}
+	topology := internal.DefaultTopology()
+	if !fromDefaults {
+		topology = internal.AskTopology(os.Stdin, os.Stdout)
+		validate = validate || util.Confirm("Validate %s", fileName)
+	}
+	if err := ioutil.WriteFile(fileName, []byte(topology), 0644); err != nil {
+		util.Fatalf("%s Failed to write %s: %v", util.Cross, fileName, err)
+	}
+	util.Printf("%s Wrote the topology configuration to %s", util.Tick, fileName)
+	if validate {
+		ValidateConfiguration(fileName)
+	} else {
+		util.Printf("Check it with: kubeslice-cli validate -c %s", fileName)
+	}
+}
+
+// Keys of the user defaults file besides the global flags.
+const (
+	WorkspaceDirSetting   = "workspace-dir"
+	ProfileSetting        = "profile"
+	ChartRepoSetting      = "chart-repo"
+	StrictValuesSetting   = "strict-values"
+	TemplatesDirSetting   = "templates-dir"
+	ForceConflictsSetting = "force-conflicts"
+)
+
+// Sources of the value of a user setting.
+const (
+	SettingSourceFlag = internal.SettingSourceFlag
+	SettingSourceEnv  = internal.SettingSourceEnv
+	SettingSourceFile = internal.SettingSourceFile
+)
+
+// UserSetting is a key of the user defaults file with its effective value and
+// where it came from.
+type UserSetting = internal.UserSetting
+
+// userSettings are the effective settings of the run.
+var userSettings []UserSetting
+
+// DefaultSettings returns the keys of the user defaults file which are not
+// global flags, with their built-in values: the directory the workspaces of
+// the runs are created in, the profile of the commands taking one, the chart
+// repository of the demo profiles and the flags of the commands installing
+// charts, generating manifests and applying them.
+func DefaultSettings() []UserSetting {
+	return []UserSetting{
+		{Key: WorkspaceDirSetting, Value: internal.WorkspacesDirectory()},
+		{Key: ProfileSetting},
+		{Key: ChartRepoSetting, Value: defaultConfiguration.Configuration.HelmChartConfiguration.RepoUrl},
+		{Key: StrictValuesSetting, Value: "false"},
+		{Key: TemplatesDirSetting},
+		{Key: ForceConflictsSetting, Value: "false"},
+	}
+}
+
+// ResolveUserSettings resolves the settings not passed on the command line
+// from the environment and the user defaults file, and applies the ones which
+// are not flags. The commands set the flags of the returned settings.
+func ResolveUserSettings(settings []UserSetting) []UserSetting {
+	resolved, err := internal.ResolveUserSettings(settings)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	for _, setting := range resolved {
+		switch setting.Key {
+		case WorkspaceDirSetting:
+			internal.SetWorkspacesDirectory(setting.Value)
+		case ChartRepoSetting:
+			defaultConfiguration.Configuration.HelmChartConfiguration.RepoUrl = setting.Value
+		}
+	}
+	userSettings = resolved
+	return resolved
+}
+
+// ShowUserSettings prints the effective settings of the run and where every
+// value came from.
+func ShowUserSettings() {
+	internal.ShowUserSettings(userSettings)
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const kubesliceControllerGroup = "controller.kubeslice.io"
+
+// supportedControllerAPIVersions are the versions of the controller CRDs the
+// Project, Cluster, SliceConfig and ServiceExportConfig manifests can be
+// generated for, the preferred one first. They share the manifest schema of
+// manifests.go, a version moving fields needs a schema of its own.
+var supportedControllerAPIVersions = []string{"v1alpha1"}
+
+// kubesliceControllerAPIVersion is the apiVersion of the generated controller
+// manifests, the one the controller cluster serves once it is detected.
+var kubesliceControllerAPIVersion = kubesliceControllerGroup + "/" + supportedControllerAPIVersions[0]
+
+// apiVersionSummary records the apiVersion the controller manifests of a run
+// were generated for and how it was selected.
+type apiVersionSummary struct {
+	Version string   `yaml:"version"`
+	Source  string   `yaml:"source"` // detected, forced or default
+	Served  []string `yaml:"served,omitempty"`
+}
+
+// DetectControllerAPIVersion selects the apiVersion of the controller
+// manifests from the versions of the kubeslice controller group the
+// controller cluster serves. It is detected once per run, --force-api-version
+// skips the detection.
+func DetectControllerAPIVersion(controllerCluster *Cluster) {
+	if runSummary.ControllerAPI != nil {
+		return
+	}
+	if useForcedAPIVersion() {
+		writeControllerAPIVersion()
+		return
+	}
+	served, err := kubeClient.ServedVersions(controllerCluster, kubesliceControllerGroup)
+	if err != nil {
+		util.Fatalf("%s Unable to list the API versions of the controller cluster: %v", util.Cross, err)
+	}
+	util.Debugf("%s versions served by the controller cluster: %s", kubesliceControllerGroup, strings.Join(served, ", "))
+	summary, err := selectControllerAPIVersion(served)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	setControllerAPIVersion(summary)
+	writeControllerAPIVersion()
+	util.Printf("%s Generating controller manifests for apiVersion %s (%s)", util.Tick, kubesliceControllerAPIVersion, summary.Source)
+}
+
+// ValidateForcedAPIVersion checks the version of --force-api-version, with or
+// without the controller.kubeslice.io/ prefix, against the supported ones.
+func ValidateForcedAPIVersion(forced string) error {
+	if forced == "" {
+		return nil
+	}
+	if version := strings.TrimPrefix(forced, kubesliceControllerGroup+"/"); !containsString(supportedControllerAPIVersions, version) {
+		return fmt.Errorf("--force-api-version %s is not supported. Possible values %s", forced, strings.Join(supportedControllerAPIVersions, ", "))
+	}
+	return nil
+}
+
+// useForcedAPIVersion applies --force-api-version, also to the manifests
+// generated without reading from the clusters by dry runs and renders. It
+// writes no file, dry runs leave the workspace alone.
+func useForcedAPIVersion() bool {
+	if Options.ForceAPIVersion == "" {
+		return false
+	}
+	if runSummary.ControllerAPI == nil {
+		version := strings.TrimPrefix(Options.ForceAPIVersion, kubesliceControllerGroup+"/")
+		setControllerAPIVersion(apiVersionSummary{Version: version, Source: "forced"})
+		util.Printf("%s Generating controller manifests for the forced apiVersion %s", util.Warn, kubesliceControllerAPIVersion)
+	}
+	return true
+}
+
+func setControllerAPIVersion(summary apiVersionSummary) {
+	kubesliceControllerAPIVersion = kubesliceControllerGroup + "/" + summary.Version
+	runSummary.ControllerAPI = &summary
+}
+
+// writeControllerAPIVersion records the selected apiVersion in the run
+// summary of the workspace.
+func writeControllerAPIVersion() {
+	GenerateKubeSliceDirectory()
+	writeRunSummary()
+}
+
+// servedGroupVersions returns the versions of group in the output of
+// kubectl api-versions.
+func servedGroupVersions(apiVersions, group string) []string {
+	versions := make([]string, 0)
+	for _, line := range strings.Split(apiVersions, "\n") {
+		if version := strings.TrimPrefix(strings.TrimSpace(line), group+"/"); version != strings.TrimSpace(line) {
+			versions = appendUnique(versions, version)
+		}
+	}
+	return versions
+}
+
+// selectControllerAPIVersion returns the preferred supported version out of
+// the served ones. Without served versions the CRDs are not installed yet,
+// and the preferred version is used.
+func selectControllerAPIVersion(served []string) (apiVersionSummary, error) {
+	if len(served) == 0 {
+		return apiVersionSummary{Version: supportedControllerAPIVersions[0], Source: "default"}, nil
+	}
+	for _, version := range supportedControllerAPIVersions {
+		if containsString(served, version) {
+			return apiVersionSummary{Version: version, Source: "detected", Served: served}, nil
+		}
+	}
+	return apiVersionSummary{}, fmt.Errorf("controller version %s/%s is newer than this CLI supports (%s), upgrade kubeslice-cli or pass --force-api-version",
+		kubesliceControllerGroup, strings.Join(served, ","), strings.Join(supportedControllerAPIVersions, ", "))
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectControllerAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	apiVersions := "apps/v1\ncontroller.kubeslice.io/v1alpha1\nnetworking.kubeslice.io/v1beta1\nv1\n"
+	if got := servedGroupVersions(apiVersions, kubesliceControllerGroup); !reflect.DeepEqual(got, []string{"v1alpha1"}) {
+		t.Errorf("servedGroupVersions() = %v, want [v1alpha1]", got)
+	}
+
+	tests := []struct {
+		name     string
+		served   []string
+		expected apiVersionSummary
+		err      string
+	}{
+		{name: "CRDs not installed", expected: apiVersionSummary{Version: "v1alpha1", Source: "default"}},
+		{name: "Supported version", served: []string{"v1alpha1"}, expected: apiVersionSummary{Version: "v1alpha1", Source: "detected", Served: []string{"v1alpha1"}}},
+		{name: "Supported and newer versions", served: []string{"v1beta1", "v1alpha1"}, expected: apiVersionSummary{Version: "v1alpha1", Source: "detected", Served: []string{"v1beta1", "v1alpha1"}}},
+		{name: "Newer version only", served: []string{"v1beta1"}, err: "controller version controller.kubeslice.io/v1beta1 is newer than this CLI supports"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := selectControllerAPIVersion(tc.served)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("selectControllerAPIVersion(%v) error = %v, want %q", tc.served, err, tc.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("selectControllerAPIVersion(%v) = %+v, %v, want %+v", tc.served, got, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateForcedAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	for forced, valid := range map[string]bool{
+		"":                                 true,
+		"v1alpha1":                         true,
+		"controller.kubeslice.io/v1alpha1": true,
+		"v1beta1":                          false,
+		"networking.kubeslice.io/v1alpha1": false,
+	} {
+		if err := ValidateForcedAPIVersion(forced); (err == nil) != valid {
+			t.Errorf("ValidateForcedAPIVersion(%q) = %v, want valid %t", forced, err, valid)
+		}
+	}
+}
+
+func TestUseForcedAPIVersionWritesNothing(t *testing.T) {
+	defer func(options RunOptions, summary RunSummary, dir, apiVersion string) {
+		*Options, runSummary, kubesliceDirectory, kubesliceControllerAPIVersion = options, summary, dir, apiVersion
+	}(*Options, runSummary, kubesliceDirectory, kubesliceControllerAPIVersion)
+	Options.ForceAPIVersion = "controller.kubeslice.io/v1alpha1"
+	runSummary = RunSummary{}
+	kubesliceDirectory = filepath.Join(t.TempDir(), "workspace")
+
+	if !useForcedAPIVersion() {
+		t.Fatal("useForcedAPIVersion() = false, want true")
+	}
+	if runSummary.ControllerAPI == nil || runSummary.ControllerAPI.Source != "forced" {
+		t.Errorf("controller API = %+v, want the forced version", runSummary.ControllerAPI)
+	}
+	if _, err := os.Stat(kubesliceDirectory); !os.IsNotExist(err) {
+		t.Errorf("workspace %s was created by a dry run: %v", kubesliceDirectory, err)
+	}
+}
@@ -1,27 +1,48 @@
 package internal
 
+import (
+	"fmt"
+	"time"
+)
+
 type ConfigurationSpecs struct {
 	Configuration Configuration `yaml:"configuration"`
 }
 
 type Configuration struct {
-	ClusterConfiguration   ClusterConfiguration   `yaml:"cluster_configuration"`
-	KubeSliceConfiguration KubeSliceConfiguration `yaml:"kubeslice_configuration"`
-	HelmChartConfiguration HelmChartConfiguration `yaml:"helm_chart_configuration"`
+	ClusterConfiguration   ClusterConfiguration    `yaml:"cluster_configuration"`
+	KubeSliceConfiguration KubeSliceConfiguration  `yaml:"kubeslice_configuration"`
+	HelmChartConfiguration HelmChartConfiguration  `yaml:"helm_chart_configuration"`
+	ProxyConfiguration     ProxyConfiguration      `yaml:"proxy_configuration"`
+	Monitoring             MonitoringConfiguration `yaml:"monitoring"`
+	UI                     UIConfiguration         `yaml:"ui"`
+	License                LicenseConfiguration    `yaml:"license"`
 }
 
 type HelmChartConfiguration struct {
-	RepoAlias        string           `yaml:"repo_alias"`
-	RepoUrl          string           `yaml:"repo_url"`
-	CertManagerChart HelmChart        `yaml:"cert_manager_chart"`
-	ControllerChart  HelmChart        `yaml:"controller_chart"`
-	WorkerChart      HelmChart        `yaml:"worker_chart"`
-	UIChart          HelmChart        `yaml:"ui_chart"`
-	PrometheusChart  HelmChart        `yaml:"prometheus_chart"`
-	HelmUsername     string           `yaml:"helm_username"`
-	HelmPassword     string           `yaml:"helm_password"`
-	ImagePullSecret  ImagePullSecrets `yaml:"image_pull_secret"`
-	UseLocal         bool             `yaml:"use_local"`
+	RepoAlias        string              `yaml:"repo_alias"`
+	RepoUrl          string              `yaml:"repo_url"`
+	CertManagerChart HelmChart           `yaml:"cert_manager_chart"`
+	ControllerChart  HelmChart           `yaml:"controller_chart"`
+	WorkerChart      HelmChart           `yaml:"worker_chart"`
+	UIChart          HelmChart           `yaml:"ui_chart"`
+	PrometheusChart  HelmChart           `yaml:"prometheus_chart"`
+	HelmUsername     string              `yaml:"helm_username"`
+	HelmPassword     string              `yaml:"helm_password"`
+	ImagePullSecrets ImagePullSecretList `yaml:"image_pull_secret"`
+	UseLocal         bool                `yaml:"use_local"`
+	// Environment variable holding the helm password, used when helm_password is not set
+	HelmPasswordEnv string `yaml:"helm_password_env"`
+	// TLS options for accessing the chart repository
+	CAFile                string `yaml:"ca_file"`
+	CertFile              string `yaml:"cert_file"`
+	KeyFile               string `yaml:"key_file"`
+	InsecureSkipTLSVerify bool   `yaml:"insecure_skip_tls_verify"`
+	// Inject the image pull secret into the values of every chart
+	PropagateImagePullSecrets bool `yaml:"propagate_image_pull_secrets"`
+	// Registry the image references of every chart are rewritten to, for
+	// images mirrored to a private registry
+	GlobalImageRegistry string `yaml:"global_image_registry"`
 }
 
 type HelmChart struct {
@@ -29,11 +50,180 @@ type HelmChart struct {
 	Version   string `yaml:"version"`
 	// Values to be passed as --set arguments to helm install
 	Values map[string]interface{} `yaml:"values"`
+	// Values document merged below Values, for values which are awkward to
+	// express as dotted keys
+	ValuesYAML string `yaml:"values_yaml"`
+	// Additional value paths which must never be written to disk
+	SensitiveValues []string `yaml:"sensitive_values"`
+	// Value path the propagated image pull secret is written to, imagePullSecrets by default
+	ImagePullSecretsKey string `yaml:"image_pull_secrets_key"`
+	// Do not propagate the image pull secret into this chart
+	SkipImagePullSecrets bool `yaml:"skip_image_pull_secrets"`
+	// Chart archive or unpacked chart directory to install instead of the repository chart
+	LocalPath string `yaml:"local_path"`
+	// Fail on values unknown to the chart defaults instead of warning
+	Strict bool `yaml:"strict"`
+	// Value prefixes with user chosen keys which are not checked against the chart defaults
+	FreeFormValues []string `yaml:"free_form_values"`
+	// Repository of this chart with its credentials and TLS options, the
+	// repository of helm_chart_configuration is used when RepoUrl is not set
+	RepoAlias             string `yaml:"repo_alias"`
+	RepoUrl               string `yaml:"repo_url"`
+	HelmUsername          string `yaml:"helm_username"`
+	HelmPassword          string `yaml:"helm_password"`
+	HelmPasswordEnv       string `yaml:"helm_password_env"`
+	CAFile                string `yaml:"ca_file"`
+	CertFile              string `yaml:"cert_file"`
+	KeyFile               string `yaml:"key_file"`
+	InsecureSkipTLSVerify bool   `yaml:"insecure_skip_tls_verify"`
+	// Verify the provenance signature of the chart against Keyring, the
+	// default keyring of helm when empty
+	Verify  bool   `yaml:"verify"`
+	Keyring string `yaml:"keyring"`
+	// Resolve version ranges and the latest version to pre-releases too
+	Devel bool `yaml:"devel"`
+	// Registry the image references of this chart are rewritten to instead of
+	// global_image_registry, and the registries of value paths holding image
+	// references which are not found by their key names
+	ImageRegistry string            `yaml:"image_registry"`
+	ImageKeys     map[string]string `yaml:"image_keys"`
+}
+
+// ProxyConfiguration holds the proxy helm, docker, kind and kubectl reach the
+// chart repositories and registries through.
+type ProxyConfiguration struct {
+	HTTPProxy  string `yaml:"http_proxy"`
+	HTTPSProxy string `yaml:"https_proxy"`
+	NoProxy    string `yaml:"no_proxy"`
+}
+
+// MonitoringConfiguration installs Prometheus on the controller cluster and
+// the scrape configuration of the KubeSlice operators. Workers without a
+// Prometheus Operator remote-write the metrics of theirs to it.
+type MonitoringConfiguration struct {
+	Enabled bool `yaml:"enabled"`
+	// Chart installed unless a Prometheus Operator runs on the controller,
+	// kube-prometheus-stack of prometheus-community by default
+	Chart HelmChart `yaml:"chart"`
+	// Namespace of the chart, monitoring by default
+	Namespace string `yaml:"namespace"`
+}
+
+// UIConfiguration installs the KubeSlice Manager UI on the controller
+// cluster, from the ui_chart of the topology or the kubeslice-ui chart of its
+// repository.
+type UIConfiguration struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// LicenseConfiguration is the license of the enterprise charts, created as a
+// secret in the controller namespace before the controller is installed.
+type LicenseConfiguration struct {
+	// License file, relative paths resolve against the topology file
+	File string `yaml:"file"`
+	// License key, or the environment variable holding it
+	Key    string `yaml:"key"`
+	KeyEnv string `yaml:"key_env"`
+	// Name of the license secret, kubeslice-license-file by default
+	SecretName string `yaml:"secret_name"`
 }
 
 type KubeSliceConfiguration struct {
-	ProjectName  string   `yaml:"project_name"`
-	ProjectUsers []string `yaml:"project_users"`
+	ProjectName string `yaml:"project_name"`
+	// Read-write users of the project, an alias of users.readwrite
+	ProjectUsers []string     `yaml:"project_users"`
+	Users        ProjectUsers `yaml:"users"`
+	// Labels and annotations of every KubeSlice object the CLI creates
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+	// Labels and annotations of the Project and SliceConfig, merged on top
+	// of labels and annotations
+	ProjectMetadata ObjectMetadata `yaml:"project_metadata"`
+	SliceMetadata   ObjectMetadata `yaml:"slice_metadata"`
+	// The slice the demo and create sliceConfig create
+	Slice SliceConfiguration `yaml:"slice"`
+	// Projects of a controller shared by several teams, instead of the
+	// users of project_name. project_name selects the project of the slice
+	// and of the workers without a project, the first one when it is empty.
+	Projects ProjectList `yaml:"project"`
+}
+
+// Project is a project of the controller with its own users.
+type Project struct {
+	Name  string       `yaml:"name"`
+	Users ProjectUsers `yaml:"users"`
+	// Merged on top of project_metadata
+	Metadata ObjectMetadata `yaml:"metadata"`
+}
+
+// ProjectList holds the projects of the project section, which may be a
+// single project as well.
+type ProjectList []Project
+
+func (l *ProjectList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// decoded by shape for the errors of strict decoding to be those of the
+	// form used
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	if _, single := raw.(map[interface{}]interface{}); !single {
+		list := make([]Project, 0)
+		if err := unmarshal(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+	single := Project{}
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*l = ProjectList{single}
+	return nil
+}
+
+// SliceConfiguration describes the slice the CLI creates in the project.
+type SliceConfiguration struct {
+	Name        string `yaml:"name"`
+	SliceSubnet string `yaml:"slice_subnet"`
+	// Registered worker clusters joining the slice, all workers when empty
+	Clusters []string `yaml:"clusters"`
+	// Isolate the application namespaces from the namespaces outside of the
+	// slice, apart from allowed_namespaces
+	NamespaceIsolation    bool             `yaml:"namespace_isolation"`
+	ApplicationNamespaces []SliceNamespace `yaml:"application_namespaces"`
+	AllowedNamespaces     []SliceNamespace `yaml:"allowed_namespaces"`
+	// Bandwidth shaping of the slice, the fields left out keep the defaults
+	QoSProfile QoSProfile `yaml:"qos_profile"`
+}
+
+// QoSProfile is the traffic control of the gateways of a slice.
+type QoSProfile struct {
+	QueueType               string `yaml:"queue_type"`
+	Priority                *int   `yaml:"priority"`
+	BandwidthCeilingKbps    int    `yaml:"bandwidth_ceiling_kbps"`
+	BandwidthGuaranteedKbps int    `yaml:"bandwidth_guaranteed_kbps"`
+	DscpClass               string `yaml:"dscp_class"`
+}
+
+// SliceNamespace is a namespace of a slice on some of its clusters, all of
+// them with '*'.
+type SliceNamespace struct {
+	Namespace string   `yaml:"namespace"`
+	Clusters  []string `yaml:"clusters"`
+}
+
+// ObjectMetadata are the labels and annotations of a generated object.
+type ObjectMetadata struct {
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// ProjectUsers are the users the Project grants access to by role.
+type ProjectUsers struct {
+	ReadWrite []string `yaml:"readwrite"`
+	ReadOnly  []string `yaml:"readonly"`
 }
 
 type ClusterConfiguration struct {
@@ -42,6 +232,33 @@ type ClusterConfiguration struct {
 	ControllerCluster Cluster   `yaml:"controller"`
 	WorkerClusters    []Cluster `yaml:"workers"`
 	ClusterType       string    `yaml:"cluster_type"`
+	// Default Kubernetes version and node image of the kind clusters of the
+	// demo profiles, for the clusters setting neither
+	KubernetesVersion string `yaml:"kubernetes_version"`
+	NodeImage         string `yaml:"node_image"`
+}
+
+// KindClusterConfiguration is what the kind config of a cluster is generated
+// from, besides its node image.
+type KindClusterConfiguration struct {
+	// Worker nodes besides the control plane
+	WorkerNodes int `yaml:"worker_nodes"`
+	// Ports of the control plane exposed on the host, e.g. of NodePorts
+	ExtraPortMappings []KindPortMapping `yaml:"extra_port_mappings"`
+	// Address and port the API server listens on on the host, 127.0.0.1
+	// and a random port when empty
+	APIServerAddress string `yaml:"api_server_address"`
+	APIServerPort    int    `yaml:"api_server_port"`
+}
+
+// KindPortMapping exposes a port of a node of a kind cluster on the host.
+type KindPortMapping struct {
+	ContainerPort int `yaml:"container_port"`
+	HostPort      int `yaml:"host_port"`
+	// 0.0.0.0 when empty
+	ListenAddress string `yaml:"listen_address"`
+	// TCP, UDP or SCTP, TCP when empty
+	Protocol string `yaml:"protocol"`
 }
 
 type Cluster struct {
@@ -50,6 +267,54 @@ type Cluster struct {
 	KubeConfigPath      string `yaml:"kube_config_path"`
 	ControlPlaneAddress string `yaml:"control_plane_address"`
 	NodeIP              string `yaml:"node_ip"`
+	// Node IPs the other clusters reach the gateways of this cluster on,
+	// detected from its nodes when empty. node_ip is the first of them.
+	NodeIPs []string `yaml:"node_ips"`
+	// Allows node_ips to mix IPv4 and IPv6 addresses
+	AllowMixedIPFamilies bool `yaml:"allow_mixed_ip_families"`
+	// Project the worker is registered with, project_name when empty
+	Project string `yaml:"project"`
+	// Worker chart values of this cluster, merged on top of worker_chart.values
+	WorkerValues map[string]interface{} `yaml:"worker_values"`
+	// Labels and annotations of the Cluster registering this worker, merged
+	// on top of kubeslice_configuration.labels and annotations
+	RegistrationMetadata ObjectMetadata `yaml:"registration_metadata"`
+	// Namespace the controller is installed to on the controller cluster,
+	// kubeslice-controller when empty, and the worker on a worker cluster,
+	// kubeslice-system when empty
+	Namespace string `yaml:"namespace"`
+	// Labels and annotations of that namespace, merged on top of
+	// kubeslice_configuration.labels and annotations
+	NamespaceMetadata ObjectMetadata `yaml:"namespace_metadata"`
+	// Nodes of a worker labeled as gateway nodes when none is, 1 when zero
+	GatewayNodes int `yaml:"gateway_nodes"`
+	// Kubernetes version of the kind cluster of a demo profile, created from
+	// kindest/node:v<version>, or its node image. Both must agree when set.
+	KubernetesVersion string `yaml:"kubernetes_version"`
+	NodeImage         string `yaml:"node_image"`
+	// Nodes, port mappings and API server of the kind cluster of a demo
+	// profile
+	Kind KindClusterConfiguration `yaml:"kind"`
+}
+
+// UnmarshalYAML accepts kube_context as an alias of context_name.
+func (c *Cluster) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Cluster
+	value := struct {
+		plain       `yaml:",inline"`
+		KubeContext string `yaml:"kube_context"`
+	}{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	*c = Cluster(value.plain)
+	if value.KubeContext != "" {
+		if c.ContextName != "" && c.ContextName != value.KubeContext {
+			return fmt.Errorf("cluster %s sets both context_name %q and kube_context %q", c.Name, c.ContextName, value.KubeContext)
+		}
+		c.ContextName = value.KubeContext
+	}
+	return nil
 }
 
 type ImagePullSecrets struct {
@@ -57,6 +322,34 @@ type ImagePullSecrets struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	Email    string `yaml:"email"`
+	// Name of a pre-created dockerconfigjson secret to use instead of the credentials
+	SecretName string `yaml:"secret_name"`
+}
+
+// ImagePullSecretList holds the credentials of every registry images are
+// pulled from. A single object is accepted as well, as written by topologies
+// predating multiple registries.
+type ImagePullSecretList []ImagePullSecrets
+
+func (l *ImagePullSecretList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	if _, single := raw.(map[interface{}]interface{}); !single {
+		list := make([]ImagePullSecrets, 0)
+		if err := unmarshal(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+	single := ImagePullSecrets{}
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*l = ImagePullSecretList{single}
+	return nil
 }
 
 type CliOptionsStruct struct {
@@ -66,4 +359,71 @@ type CliOptionsStruct struct {
 	FileName     string   // path to the resource description file
 	Cluster      *Cluster // cluster
 	OutputFormat string
+	DryRun       bool // print the generated manifests instead of applying them
+}
+
+// RunOptions holds the command line flags that change how the individual
+// install/uninstall steps behave.
+type RunOptions struct {
+	AssumeYes          bool          // answer yes to every confirmation prompt
+	ExpandEnv          bool          // expand ${VAR} references while loading the topology
+	KeepGeneratedFiles bool          // keep the generated values files after the run
+	StrictValues       bool          // fail on chart values unknown to the chart defaults
+	Workspace          string        // directory the generated files are written to
+	SkipRegistryCheck  bool          // do not test the registry credentials during pre-flight
+	HelmTimeout        time.Duration // how long helm waits for the resources of a release to be ready
+	RolloutTimeout     time.Duration // how long the deployments of the controller and the workers are waited for
+	RetainCRDs         bool          // keep the kubeslice CRDs on uninstall, those without custom resources are deleted otherwise
+	RetainResources    bool          // keep the kubeslice custom resources on uninstall for a later install to adopt
+	DeleteNamespaces   bool          // delete the kubeslice namespaces on uninstall
+	DeleteUnmanaged    bool          // delete the kind clusters of a demo profile which the state does not record the CLI creating
+	RefreshCharts      bool          // update the helm repos even if their index is fresh
+	Offline            bool          // never update the helm repos
+	ChartsCacheTTL     time.Duration // how long an updated helm repo index is considered fresh
+	ReuseValues        bool          // merge the generated values on top of the values of deployed releases
+	RollbackOnFailure  bool          // roll back a release whose upgrade failed
+	Diff               bool          // show the manifest diff of every upgraded release
+	RenderTo           string        // directory the manifests are rendered to instead of installing them
+	HTTPProxy          string        // overrides proxy_configuration.http_proxy
+	HTTPSProxy         string        // overrides proxy_configuration.https_proxy
+	NoProxy            string        // overrides proxy_configuration.no_proxy
+	HelmTests          bool          // run the helm tests of the releases after installing them
+	Devel              bool          // resolve chart versions to pre-releases too
+	HelmBackend        string        // manage the releases with the helm binary or the helm SDK
+	Installation       string        // name the state of the installation is stored under
+	TemplatesDir       string        // directory of the templates overriding the generated manifests
+	ForceAPIVersion    string        // apiVersion of the controller manifests instead of the detected one
+	KubeClient         string        // read and apply the objects with the kubectl binary or client-go
+	MergeKubeconfig    bool          // merge the kind clusters into the default kubeconfig of the user
+	SkipDiagnostics    bool          // do not collect a diagnostics bundle when the install fails
+	SkipPreflight      bool          // do not check the connectivity and permissions of the clusters
+	IgnoreVersionCheck bool          // only warn about clusters running unsupported Kubernetes versions
+	NoAutoLabel        bool          // fail instead of labeling gateway nodes on workers without any
+	ForceConflicts     bool          // take ownership of the fields other managers set on applied objects
+	OnExisting         string        // adopt, skip or fail on KubeSlice components installed outside of the installation
+	RecreateClusters   bool          // delete and recreate the kind clusters of a demo profile instead of reusing them
+	SkipVerify         bool          // do not verify the connectivity of the slice after installing a demo profile
+	CheckQoS           bool          // fail the verification of a slice whose bandwidth exceeds its QoS ceiling
+	Components         []string      // components install selects, all of them when empty
+	CertManager        string        // auto, install or skip cert-manager on the controller cluster
+	FromBundle         string        // offline bundle the charts are installed from instead of their repositories
+	PlanOnly           bool          // stop after printing the execution plan of the run
+	PlanOutput         string        // print the execution plan as json
+	Resume             bool          // skip the steps a failed install completed with the same inputs
+	NoResume           bool          // discard the checkpoints of a failed install and run every step
+	DeletionTimeout    time.Duration // how long deletions wait for the workers to be offboarded and the namespaces to be gone
+	ForceFinalizers    bool          // remove the kubeslice finalizers of the objects blocking a deletion once it timed out
+	Platform           string        // platform of the kind nodes instead of the architecture of the container runtime
+	SkipImagePrepull   bool          // leave pulling the node images to kind instead of pulling them once beforehand
+	KubeConfig         string        // kubeconfig of the clusters of a topology which sets no kube_config_path
+}
+
+var Options = &RunOptions{
+	RetainCRDs:      true,
+	RetainResources: true,
+	HelmTimeout:     5 * time.Minute,
+	RolloutTimeout:  5 * time.Minute,
+	ChartsCacheTTL:  time.Hour,
+	CertManager:     CertManagerAuto,
+	DeletionTimeout: 10 * time.Minute,
 }
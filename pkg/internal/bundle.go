@@ -0,0 +1,423 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// The layout of an offline bundle, relative to its root directory:
+//
+//	manifest.json                      charts and checksums of every other file
+//	charts/<component>/<chart>.tgz     pulled chart archive, with its .prov if verified
+//	images.txt                         images the charts pull
+//	mirror-images.sh                   script copying the images to $MIRROR_REGISTRY
+//	topology.yaml                      topology the bundle was created from
+//	kind/<cluster>.yaml                kind configs of the clusters of a kind topology
+const (
+	bundleRoot         = "kubeslice-bundle"
+	bundleManifestFile = "manifest.json"
+	bundleChartsDir    = "charts"
+	// the registry of the mirror script is set when running it
+	bundleMirrorRegistry = "${MIRROR_REGISTRY:?set MIRROR_REGISTRY to the registry to mirror to}"
+)
+
+// bundleManifest lists the contents of a bundle.
+type bundleManifest struct {
+	CLIVersion string        `json:"cliVersion"`
+	Created    string        `json:"created"`
+	Charts     []bundleChart `json:"charts"`
+	Files      []bundleFile  `json:"files"`
+}
+
+// bundleChart is a chart of a bundle and the component it is installed for.
+type bundleChart struct {
+	Component string `json:"component"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version"`
+	File      string `json:"file"`
+}
+
+// bundleFile is a file of a bundle with its checksum.
+type bundleFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// bundledCharts returns the configured charts keyed by the component they are
+// installed for, the monitoring chart when monitoring is enabled.
+func bundledCharts(specs *ConfigurationSpecs) ([]string, map[string]*HelmChart) {
+	hc := &specs.Configuration.HelmChartConfiguration
+	_, charts := topologyCharts(hc)
+	components := make([]string, 0)
+	bundled := make(map[string]*HelmChart)
+	for _, component := range []string{CertManager_Component, Controller_Component, UI_install_Component, Worker_Component, Prometheus_Component} {
+		if chart := charts[componentChartKeys[component]]; chart.ChartName != "" {
+			components = append(components, component)
+			bundled[component] = chart
+		}
+	}
+	if mc := &specs.Configuration.Monitoring; mc.Enabled {
+		mc.Chart = monitoringChart(*mc)
+		components = append(components, Monitoring_Component)
+		bundled[Monitoring_Component] = &mc.Chart
+	}
+	return components, bundled
+}
+
+// CreateBundle writes an offline bundle of the topology to output: every
+// configured chart pulled at its resolved version, the images the charts
+// pull with a script mirroring them, the topology and the kind configs of its
+// clusters, and a manifest with the checksums of all of them. Mirroring the
+// images is left to the user. No cluster is touched.
+func CreateBundle(specs *ConfigurationSpecs, topologyFile, output, script string) {
+	defer func() { rewriteImages = true }()
+	rewriteImages = false
+
+	// only helm is needed, the clusters are not touched
+	verifyExecutable("helm")
+	GenerateKubeSliceDirectory()
+	AddHelmCharts(specs)
+	components, charts := bundledCharts(specs)
+	chartComponents := removeString(append([]string{}, components...), Monitoring_Component)
+	ValidateChartVersions(specs, chartComponents)
+
+	dir := filepath.Join(kubesliceDirectory, bundleRoot)
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	util.CreateDirectoryPath(dir)
+	manifest := bundleManifest{CLIVersion: CLIVersion, Created: time.Now().UTC().Format(time.RFC3339)}
+
+	util.Printf("\nPulling charts...")
+	hc := specs.Configuration.HelmChartConfiguration
+	for _, component := range components {
+		chart := charts[component]
+		// the repository of the monitoring chart is not one of the topology
+		if component == Monitoring_Component && chart.LocalPath == "" {
+			cleanup := ensureHelmRepo(hc.forChart(*chart))
+			defer cleanup()
+		}
+		file, err := pullBundleChart(hc, *chart, filepath.Join(dir, bundleChartsDir, component))
+		if err != nil {
+			util.Fatalf("%s Unable to pull chart %s: %v", util.Cross, chartReference(hc, *chart), err)
+		}
+		version := bundledChartVersion(file, chart.ChartName)
+		manifest.Charts = append(manifest.Charts, bundleChart{
+			Component: component,
+			Chart:     chart.ChartName,
+			Version:   version,
+			File:      filepath.ToSlash(filepath.Join(bundleChartsDir, component, file)),
+		})
+		util.Printf("%s Pulled chart %s %s", util.Tick, chartReference(hc, *chart), version)
+	}
+
+	images := collectChartImages(specs, chartComponents)
+	lines := make([]string, 0, len(images))
+	for _, image := range images {
+		lines = append(lines, image.Image)
+	}
+	writeBundleFile(dir, "images.txt", []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	writeBundleFile(dir, "mirror-images.sh", []byte(mirrorScript(images, bundleMirrorRegistry, script)+"\n"), 0755)
+	util.Printf("%s Listed %d images, run MIRROR_REGISTRY=<registry> ./mirror-images.sh to mirror them", util.Tick, len(images))
+
+	if topologyFile != "" {
+		data, err := ioutil.ReadFile(topologyFile)
+		if err != nil {
+			util.Fatalf("%s Unable to read the topology %s: %v", util.Cross, topologyFile, err)
+		}
+		writeBundleFile(dir, "topology.yaml", data, 0600)
+	}
+	cc := specs.Configuration.ClusterConfiguration
+	if cc.ClusterType == Kind_Component {
+		images := kindNodeImages(&cc)
+		for i, cluster := range getAllClusters(&cc) {
+			config, err := kindConfiguration(*cluster, images[i], i > 0, i == 0 && cc.Profile == ProfileEntDemo)
+			if err != nil {
+				util.Fatalf("%s Failed to generate the kind configuration of cluster %s: %v", util.Cross, cluster.Name, err)
+			}
+			writeBundleFile(dir, filepath.Join(kindSubDirectory, cluster.Name+".yaml"), []byte(config), 0644)
+		}
+	}
+
+	files, err := bundleFiles(dir)
+	if err != nil {
+		util.Fatalf("%s Unable to checksum the bundle: %v", util.Cross, err)
+	}
+	manifest.Files = files
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		util.Fatalf("%s Unable to write the manifest of the bundle: %v", util.Cross, err)
+	}
+	writeBundleFile(dir, bundleManifestFile, append(data, '\n'), 0644)
+	if err := writeTarGz(dir, output); err != nil {
+		util.Fatalf("%s Unable to write the bundle %s: %v", util.Cross, output, err)
+	}
+	util.Printf("%s Successfully created bundle %s with %d charts and %d images", util.Tick, output, len(manifest.Charts), len(images))
+}
+
+// pullBundleChart writes the archive of a chart to dir and returns its file
+// name. Local charts are copied or packaged, the others pulled with helm, with
+// the provenance file when the chart is verified.
+func pullBundleChart(hc HelmChartConfiguration, chart HelmChart, dir string) (string, error) {
+	util.CreateDirectoryPath(dir)
+	var args []string
+	switch {
+	case chart.LocalPath != "":
+		info, err := os.Stat(chart.LocalPath)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			args = []string{"package", chart.LocalPath, "--destination", dir}
+			break
+		}
+		data, err := ioutil.ReadFile(chart.LocalPath)
+		if err != nil {
+			return "", err
+		}
+		file := filepath.Base(chart.LocalPath)
+		return file, ioutil.WriteFile(filepath.Join(dir, file), data, 0644)
+	default:
+		args = []string{"pull", chartReference(hc, chart), "--destination", dir}
+		if chart.Version != "" {
+			args = append(args, "--version", chart.Version)
+		}
+		if chart.Verify {
+			args = append(args, "--prov")
+		}
+		args = append(args, helmTLSFlags(hc.forChart(chart), false)...)
+		args = append(args, helmDevelFlags(chart)...)
+	}
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...); err != nil {
+		return "", errors.New(util.Redact(strings.TrimSpace(errB.String())))
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tgz") {
+			return entry.Name(), nil
+		}
+	}
+	return "", fmt.Errorf("helm wrote no chart archive to %s", dir)
+}
+
+// bundledChartVersion returns the version of a chart archive named the way
+// helm names them, <chart>-<version>.tgz.
+func bundledChartVersion(file, chartName string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(file, chartName+"-"), ".tgz")
+}
+
+func writeBundleFile(dir, name string, data []byte, perm os.FileMode) {
+	path := filepath.Join(dir, name)
+	util.CreateDirectoryPath(filepath.Dir(path))
+	if err := util.WriteFileAtomic(path, data, perm); err != nil {
+		util.Fatalf("%s Failed to write %s: %v", util.Cross, path, err)
+	}
+}
+
+// bundleFiles returns the checksums of the files of a bundle directory except
+// its manifest, sorted by path.
+func bundleFiles(dir string) ([]bundleFile, error) {
+	files := make([]bundleFile, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if name == bundleManifestFile {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, bundleFile{Path: name, SHA256: fmt.Sprintf("%x", sha256.Sum256(data)), Size: info.Size()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, err
+}
+
+// verifyBundleDir checks the files of an extracted bundle against its
+// manifest, reporting modified, missing and unlisted files.
+func verifyBundleDir(dir string) (bundleManifest, []string) {
+	var manifest bundleManifest
+	data, err := ioutil.ReadFile(filepath.Join(dir, bundleManifestFile))
+	if err != nil {
+		return manifest, []string{fmt.Sprintf("the bundle has no %s: %v", bundleManifestFile, err)}
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, []string{fmt.Sprintf("unable to decode %s: %v", bundleManifestFile, err)}
+	}
+	files, err := bundleFiles(dir)
+	if err != nil {
+		return manifest, []string{err.Error()}
+	}
+	found := make(map[string]bundleFile, len(files))
+	for _, file := range files {
+		found[file.Path] = file
+	}
+	errors := make([]string, 0)
+	for _, expected := range manifest.Files {
+		file, ok := found[expected.Path]
+		switch {
+		case !ok:
+			errors = append(errors, fmt.Sprintf("%s is missing", expected.Path))
+		case file.SHA256 != expected.SHA256 || file.Size != expected.Size:
+			errors = append(errors, fmt.Sprintf("%s does not match its checksum", expected.Path))
+		}
+		delete(found, expected.Path)
+	}
+	for _, file := range files {
+		if _, unlisted := found[file.Path]; unlisted {
+			errors = append(errors, fmt.Sprintf("%s is not listed in the manifest", file.Path))
+		}
+	}
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, file := range manifest.Files {
+		listed[file.Path] = true
+	}
+	for _, chart := range manifest.Charts {
+		if !listed[chart.File] {
+			errors = append(errors, fmt.Sprintf("chart %s of component %s is not part of the bundle", chart.File, chart.Component))
+		}
+	}
+	return manifest, errors
+}
+
+// extractBundle extracts the files of a bundle archive into dir and returns
+// the root directory of the bundle. Entries escaping dir are rejected.
+func extractBundle(archive, dir string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a bundle: %v", archive, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to read %s: %v", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return "", fmt.Errorf("entry %s of %s is outside of the bundle", header.Name, archive)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return "", err
+		}
+		out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, bundleRoot), nil
+}
+
+// openBundle extracts a bundle into dir and verifies it, failing with every
+// integrity problem found.
+func openBundle(archive, dir string) (string, bundleManifest) {
+	root, err := extractBundle(archive, dir)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	manifest, errors := verifyBundleDir(root)
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf("%s %s", util.Cross, s)
+		}
+		util.Fatalf("%s Bundle %s failed the integrity check", util.Cross, archive)
+	}
+	return root, manifest
+}
+
+// VerifyBundle checks the integrity of a bundle, for example after it was
+// transferred to an air-gapped network, and lists its charts.
+func VerifyBundle(archive string) {
+	util.Printf("\nVerifying bundle %s...", archive)
+	dir, err := ioutil.TempDir("", "kubeslice-bundle")
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	defer os.RemoveAll(dir)
+	_, manifest := openBundle(archive, dir)
+	for _, chart := range manifest.Charts {
+		util.Printf("%s %s %s for %s", util.Tick, chart.Chart, chart.Version, chart.Component)
+	}
+	util.Printf("%s Bundle %s created by kubeslice-cli %s on %s is intact, %d files verified", util.Tick, archive, manifest.CLIVersion, manifest.Created, len(manifest.Files))
+}
+
+// UseBundle installs the charts of the topology from a bundle instead of
+// their repositories: the bundle is verified and extracted into the workspace
+// and every configured chart is pointed to its archive, so that no repository
+// is contacted. A chart missing from the bundle or of another version than
+// the topology pins fails the run.
+func UseBundle(specs *ConfigurationSpecs, archive string) {
+	dir := filepath.Join(kubesliceDirectory, "bundle")
+	os.RemoveAll(dir)
+	root, manifest := openBundle(archive, dir)
+	util.Printf("%s Installing the charts of bundle %s", util.Tick, archive)
+	bundled := make(map[string]bundleChart, len(manifest.Charts))
+	for _, chart := range manifest.Charts {
+		bundled[chart.Component] = chart
+	}
+	components, charts := bundledCharts(specs)
+	errors := make([]string, 0)
+	for _, component := range components {
+		chart := charts[component]
+		bc, found := bundled[component]
+		pinned := exactChartVersion(chart.Version)
+		switch {
+		case !found || bc.Chart != chart.ChartName:
+			errors = append(errors, fmt.Sprintf("%s Chart %s of component %s is not part of the bundle, create the bundle again", util.Cross, chart.ChartName, component))
+			continue
+		case pinned != "" && pinned != strings.TrimPrefix(bc.Version, "v"):
+			errors = append(errors, fmt.Sprintf("%s The topology pins version %s of chart %s, the bundle has version %s", util.Cross, chart.Version, chart.ChartName, bc.Version))
+			continue
+		}
+		chart.LocalPath = filepath.Join(root, filepath.FromSlash(bc.File))
+		chart.Version = bc.Version
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Bundle %s does not match the topology", util.Cross, archive)
+	}
+}
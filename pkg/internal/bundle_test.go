@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestVerifyBundleDir(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tamper   func(dir string)
+		expected []string
+	}{
+		{
+			name:     "Intact bundle",
+			tamper:   func(dir string) {},
+			expected: []string{},
+		},
+		{
+			name: "Modified chart",
+			tamper: func(dir string) {
+				ioutil.WriteFile(filepath.Join(dir, "charts", "controller", "kubeslice-controller-1.1.1.tgz"), []byte("tampered"), 0644)
+			},
+			expected: []string{"charts/controller/kubeslice-controller-1.1.1.tgz does not match its checksum"},
+		},
+		{
+			name: "Missing and unlisted files",
+			tamper: func(dir string) {
+				os.Remove(filepath.Join(dir, "images.txt"))
+				ioutil.WriteFile(filepath.Join(dir, "extra.sh"), []byte("curl"), 0755)
+			},
+			expected: []string{"images.txt is missing", "extra.sh is not listed in the manifest"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			os.MkdirAll(filepath.Join(dir, "charts", "controller"), 0700)
+			ioutil.WriteFile(filepath.Join(dir, "charts", "controller", "kubeslice-controller-1.1.1.tgz"), []byte("chart"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "images.txt"), []byte("docker.io/aveshasystems/kubeslice-controller:1.1.1\n"), 0644)
+			files, err := bundleFiles(dir)
+			if err != nil {
+				t.Fatalf("bundleFiles() error = %v", err)
+			}
+			manifest := bundleManifest{
+				Charts: []bundleChart{{Component: Controller_Component, Chart: "kubeslice-controller", Version: "1.1.1", File: "charts/controller/kubeslice-controller-1.1.1.tgz"}},
+				Files:  files,
+			}
+			data, _ := json.Marshal(manifest)
+			ioutil.WriteFile(filepath.Join(dir, bundleManifestFile), data, 0644)
+			tc.tamper(dir)
+			if _, errors := verifyBundleDir(dir); !reflect.DeepEqual(errors, tc.expected) {
+				t.Errorf("verifyBundleDir() = %q, want %q", errors, tc.expected)
+			}
+		})
+	}
+}
+
+func TestExtractBundleRejectsEscapingEntries(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "kubeslice-bundle/../../evil.sh", Mode: 0755, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("evil"))
+	tw.Close()
+	gz.Close()
+	f.Close()
+	if _, err := extractBundle(archive, filepath.Join(dir, "out")); err == nil {
+		t.Errorf("extractBundle() extracted an entry outside of the bundle")
+	}
+}
+
+func TestBundledChartVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		file, chart, expected string
+	}{
+		{"kubeslice-controller-1.1.1.tgz", "kubeslice-controller", "1.1.1"},
+		{"cert-manager-v1.7.0.tgz", "cert-manager", "v1.7.0"},
+		{"kubeslice-worker-1.2.0-rc.1.tgz", "kubeslice-worker", "1.2.0-rc.1"},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.file, func(t *testing.T) {
+			t.Parallel()
+			if version := bundledChartVersion(tc.file, tc.chart); version != tc.expected {
+				t.Errorf("bundledChartVersion() = %q, want %q", version, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUseBundle(t *testing.T) {
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	kubesliceDirectory = t.TempDir()
+	dir := filepath.Join(t.TempDir(), bundleRoot)
+	charts := []bundleChart{
+		{Component: CertManager_Component, Chart: "cert-manager", Version: "v1.7.0"},
+		{Component: Controller_Component, Chart: "kubeslice-controller", Version: "1.1.1"},
+		{Component: UI_install_Component, Chart: "kubeslice-ui", Version: "1.1.1"},
+		{Component: Worker_Component, Chart: "kubeslice-worker", Version: "1.1.1"},
+		{Component: Monitoring_Component, Chart: "kube-prometheus-stack", Version: "45.0.0"},
+	}
+	for i, chart := range charts {
+		charts[i].File = filepath.ToSlash(filepath.Join(bundleChartsDir, chart.Component, chart.Chart+"-"+chart.Version+".tgz"))
+		os.MkdirAll(filepath.Join(dir, bundleChartsDir, chart.Component), 0700)
+		ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(charts[i].File)), []byte(chart.Chart), 0644)
+	}
+	files, err := bundleFiles(dir)
+	if err != nil {
+		t.Fatalf("bundleFiles() error = %v", err)
+	}
+	data, _ := json.Marshal(bundleManifest{Charts: charts, Files: files})
+	ioutil.WriteFile(filepath.Join(dir, bundleManifestFile), data, 0644)
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := writeTarGz(dir, archive); err != nil {
+		t.Fatalf("writeTarGz() error = %v", err)
+	}
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		HelmChartConfiguration: HelmChartConfiguration{
+			RepoAlias:        "kubeslice",
+			RepoUrl:          "https://kubeslice.github.io/kubeslice/",
+			CertManagerChart: HelmChart{ChartName: "cert-manager"},
+			ControllerChart:  HelmChart{ChartName: "kubeslice-controller", Version: "1.1.1"},
+			UIChart:          HelmChart{ChartName: "kubeslice-ui"},
+			WorkerChart:      HelmChart{ChartName: "kubeslice-worker", Version: "~1.1"},
+		},
+		Monitoring: MonitoringConfiguration{Enabled: true},
+	}}
+	mock := &util.MockExecutor{}
+	defer util.UseExecutor(mock)()
+	UseBundle(specs, archive)
+	AddHelmCharts(specs)
+
+	root := filepath.Join(kubesliceDirectory, "bundle", bundleRoot)
+	components, bundled := bundledCharts(specs)
+	if len(components) != len(charts) {
+		t.Fatalf("bundledCharts() = %v, want the %d charts of the bundle", components, len(charts))
+	}
+	for _, chart := range charts {
+		got := bundled[chart.Component]
+		if expected := filepath.Join(root, filepath.FromSlash(chart.File)); got.LocalPath != expected || got.Version != chart.Version {
+			t.Errorf("chart of %s = %s %s, want %s %s", chart.Component, got.LocalPath, got.Version, expected, chart.Version)
+		}
+	}
+	if len(mock.Commands) > 0 {
+		t.Errorf("UseBundle() ran %q, want no repository contacted", mock.Commands)
+	}
+}
@@ -1,20 +1,179 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"os"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
+const certManagerValues = `
+installCRDs: true
+`
+
+// How install handles cert-manager on the controller cluster, --cert-manager.
+const (
+	CertManagerAuto    = "auto"
+	CertManagerInstall = "install"
+	CertManagerSkip    = "skip"
+)
+
+// minCertManagerVersion is the oldest cert-manager the controller works with.
+var minCertManagerVersion = "v1.7.0"
+
+// The objects an existing cert-manager is detected by.
+const (
+	certManagerCRD            = "certificates.cert-manager.io"
+	certManagerWebhook        = "cert-manager-webhook"
+	validatingWebhookResource = "validatingwebhookconfigurations.admissionregistration.k8s.io"
+)
+
+// certManagerDeployment is the cert-manager found on a cluster.
+type certManagerDeployment struct {
+	found     bool
+	namespace string
+	// version is the app.kubernetes.io/version of its CRDs, empty when unknown
+	version string
+	// installed tells that the state of the installation records the CLI
+	// installing it
+	installed bool
+}
+
+// detectCertManager looks for cert-manager on a cluster by its CRDs, its
+// webhook configuration and its namespace.
+func detectCertManager(specs *ConfigurationSpecs, cluster Cluster) (certManagerDeployment, error) {
+	var d certManagerDeployment
+	crd, err := kubeClient.Get(&cluster, crdResource, "", certManagerCRD)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return d, fmt.Errorf("unable to look up the cert-manager CRDs on %s: %v", cluster.Name, err)
+	}
+	if err == nil {
+		var object struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+		}
+		if err := decodeObject(crd, &object); err == nil {
+			d.found, d.version = true, object.Metadata.Labels["app.kubernetes.io/version"]
+		}
+	}
+	webhook, err := kubeClient.Get(&cluster, validatingWebhookResource, "", certManagerWebhook)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return d, fmt.Errorf("unable to look up the cert-manager webhook on %s: %v", cluster.Name, err)
+	}
+	if err == nil {
+		var object struct {
+			Webhooks []struct {
+				ClientConfig struct {
+					Service struct {
+						Namespace string `json:"namespace"`
+					} `json:"service"`
+				} `json:"clientConfig"`
+			} `json:"webhooks"`
+		}
+		if err := decodeObject(webhook, &object); err == nil && len(object.Webhooks) > 0 {
+			d.found, d.namespace = true, object.Webhooks[0].ClientConfig.Service.Namespace
+		}
+	}
+	if d.found && d.namespace == "" {
+		if exists, _ := objectExists(&cluster, namespaceResource, "", "cert-manager"); exists {
+			d.namespace = "cert-manager"
+		}
+	}
+	d.installed = certManagerInstalledByCLI(specs)
+	return d, nil
+}
+
+// certManagerInstalledByCLI tells whether the state of the installation
+// records the CLI installing cert-manager.
+func certManagerInstalledByCLI(specs *ConfigurationSpecs) bool {
+	state, err := loadInstallationState(InstallationName(specs))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			util.Printf("%s %v", util.Warn, err)
+		}
+		return false
+	}
+	for _, r := range state.Releases {
+		if r.Component == CertManager_Component {
+			return true
+		}
+	}
+	return false
+}
+
+// decideCertManager tells whether cert-manager is to be installed with a mode
+// of --cert-manager, given the one found on the controller cluster. The
+// cert-manager the CLI installed keeps being managed by it, one installed
+// otherwise is used when it is recent enough.
+func decideCertManager(mode string, d certManagerDeployment) (bool, string, error) {
+	switch {
+	case mode == CertManagerSkip:
+		return false, "Skipping the installation of cert-manager, --cert-manager=skip", nil
+	case mode == CertManagerInstall:
+		return true, "Installing cert-manager, --cert-manager=install", nil
+	case !d.found:
+		return true, "cert-manager not found, installing it", nil
+	case d.installed:
+		return true, "cert-manager was installed by kubeslice-cli, upgrading it", nil
+	case d.version == "":
+		return false, fmt.Sprintf("Detected cert-manager of an unknown version in namespace %s, skipping its installation", d.namespace), nil
+	case compareVersions(d.version, minCertManagerVersion) < 0:
+		return false, "", fmt.Errorf("cert-manager %s in namespace %s is older than %s, which the controller needs. Upgrade it, or pass --cert-manager=skip to use it anyway", d.version, d.namespace, minCertManagerVersion)
+	}
+	return false, fmt.Sprintf("Detected cert-manager %s in namespace %s, skipping its installation", d.version, d.namespace), nil
+}
+
+// ResolveCertManager decides whether the install installs cert-manager onto
+// the controller cluster, detecting an existing one with --cert-manager=auto.
+// The step is removed from the plan when it is not to be installed, the plan
+// has it only when the topology sets cert_manager_chart or with
+// --cert-manager=install. Nothing is detected on a kind cluster the install
+// is yet to create.
+func ResolveCertManager(specs *ConfigurationSpecs, plan *InstallPlan, createsKindClusters bool) {
+	if !plan.Includes(CertManager_Component) {
+		return
+	}
+	controller := specs.Configuration.ClusterConfiguration.ControllerCluster
+	util.Printf("\nDetecting cert-manager on %s...", controller.Name)
+	var d certManagerDeployment
+	if !kindClustersToCreate([]Cluster{controller}, createsKindClusters)[0] {
+		var err error
+		if d, err = detectCertManager(specs, controller); err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+	}
+	install, message, err := decideCertManager(Options.CertManager, d)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	util.Printf("%s %s", util.Tick, message)
+	if !install {
+		plan.Steps = removeString(plan.Steps, CertManager_Component)
+		return
+	}
+	if specs.Configuration.HelmChartConfiguration.CertManagerChart.ChartName == "" {
+		util.Fatalf("%s Set helm_chart_configuration.cert_manager_chart in the topology to install cert-manager, or pass --cert-manager=skip", util.Cross)
+	}
+}
+
 func InstallCertManager(ApplicationConfiguration *ConfigurationSpecs) {
 
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration
 	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
 	util.Printf("\nInstall Cert Manager to Controller Cluster...")
+	if leaveAsInstalled(cc.ControllerCluster, "cert-manager") {
+		return
+	}
 
-	installCertManager(cc.ControllerCluster, hc)
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.CertManagerChart)
+	generateCertManagerValuesFile(cc.ControllerCluster, ApplicationConfiguration.Configuration, valuesFile)
+	reuseReleaseValues(cc.ControllerCluster, "cert-manager", "cert-manager", valuesFile)
+	showValuesDiff(cc.ControllerCluster, "cert-manager", "cert-manager", valuesFile)
+	installCertManager(cc.ControllerCluster, hc, valuesFile)
+	recordValuesChecksum(InstallationName(ApplicationConfiguration), cc.ControllerCluster, "cert-manager", valuesFile)
 	util.Printf("%s Successfully installed helm chart %s/%s", util.Tick, hc.RepoAlias, hc.CertManagerChart.ChartName)
 	time.Sleep(200 * time.Millisecond)
 
@@ -24,11 +183,19 @@ func InstallCertManager(ApplicationConfiguration *ConfigurationSpecs) {
 	util.Printf("%s Successfully installed cert manager.\n", util.Tick)
 
 }
+
+// UninstallCertManager uninstalls cert-manager when the state of the
+// installation records the CLI installing it, one installed otherwise is
+// left alone.
 func UninstallCertManager(ApplicationConfiguration *ConfigurationSpecs) {
 
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration
 	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
 	util.Printf("\nUninstalling Cert Manager...")
+	if !certManagerInstalledByCLI(ApplicationConfiguration) {
+		util.Printf("%s cert-manager was not installed by kubeslice-cli, leaving it installed", util.Tick)
+		return
+	}
 
 	err := uninstallCertManager(cc.ControllerCluster, hc)
 	if err == nil {
@@ -39,22 +206,30 @@ func UninstallCertManager(ApplicationConfiguration *ConfigurationSpecs) {
 
 }
 
-func installCertManager(cluster Cluster, hc HelmChartConfiguration) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "upgrade", "-i", "cert-manager", fmt.Sprintf("%s/%s", hc.RepoAlias, hc.CertManagerChart.ChartName), "--namespace", "cert-manager", "--create-namespace", "--set", "installCRDs=true")
-	if hc.CertManagerChart.Version != "" {
-		args = append(args, "--version", hc.CertManagerChart.Version)
+func generateCertManagerValuesFile(cluster Cluster, config Configuration, valuesFile string) {
+	hcConfig := config.HelmChartConfiguration
+	if err := substituteChartPlaceholders(&hcConfig.CertManagerChart, cluster, config); err != nil {
+		util.Fatalf("%s %s", util.Cross, err)
+	}
+	verifyValueKeys(hcConfig, &hcConfig.CertManagerChart)
+	err := generateValuesFile(valuesFile, &hcConfig.CertManagerChart, certManagerValues, propagatedImagePullSecrets(hcConfig))
+	if err == nil {
+		err = rewriteImageRegistry(hcConfig, &hcConfig.CertManagerChart, valuesFile)
 	}
-	err := util.RunCommand("helm", args...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("%s %s", util.Cross, err)
 	}
 }
-func uninstallCertManager(cluster Cluster, hc HelmChartConfiguration) error {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "uninstall", "cert-manager", "--namespace", "cert-manager")
 
-	err := util.RunCommand("helm", args...)
+func installCertManager(cluster Cluster, hc HelmChartConfiguration, valuesFile string) {
+	installRelease(releaseRequest{Cluster: cluster, Release: "cert-manager", Namespace: "cert-manager", Chart: hc.CertManagerChart, Repo: hc, ValuesFile: valuesFile, CreateNamespace: true})
+}
+
+func uninstallCertManager(cluster Cluster, hc HelmChartConfiguration) error {
+	err := chartInstaller.Uninstall(cluster, "cert-manager", "cert-manager")
+	if err != nil {
+		diagnoseRelease(cluster, "cert-manager", "cert-manager")
+	}
 	return err
 
 }
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestDetectCertManager(t *testing.T) {
+	defer func(dir string, client KubeClient) {
+		installationStateDirectory, kubeClient = dir, client
+	}(installationStateDirectory, kubeClient)
+	installationStateDirectory = t.TempDir()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{ControllerCluster: Cluster{Name: "ks-ctrl"}}}}
+	crd := func(version string) map[string]interface{} {
+		return map[string]interface{}{"metadata": map[string]interface{}{
+			"name":   certManagerCRD,
+			"labels": map[string]interface{}{"app.kubernetes.io/version": version},
+		}}
+	}
+	webhook := map[string]interface{}{"webhooks": []interface{}{
+		map[string]interface{}{"clientConfig": map[string]interface{}{"service": map[string]interface{}{"namespace": "security"}}},
+	}}
+	tests := []struct {
+		name      string
+		documents map[string]map[string]interface{}
+		objects   map[string][]string
+		expected  string
+	}{
+		{
+			name:     "Absent",
+			expected: "cert-manager not found, installing it",
+		},
+		{
+			name: "Supported version",
+			documents: map[string]map[string]interface{}{
+				objectsKey(crdResource, "") + "/" + certManagerCRD:                   crd("v1.12.3"),
+				objectsKey(validatingWebhookResource, "") + "/" + certManagerWebhook: webhook,
+			},
+			expected: "Detected cert-manager v1.12.3 in namespace security, skipping its installation",
+		},
+		{
+			name: "Too old",
+			documents: map[string]map[string]interface{}{
+				objectsKey(crdResource, "") + "/" + certManagerCRD: crd("v1.5.4"),
+			},
+			objects:  map[string][]string{objectsKey(namespaceResource, ""): {"cert-manager"}},
+			expected: "cert-manager v1.5.4 in namespace cert-manager is older than v1.7.0, which the controller needs. Upgrade it, or pass --cert-manager=skip to use it anyway",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient = &fakeKubeClient{documents: tc.documents, objects: tc.objects}
+			d, err := detectCertManager(specs, specs.Configuration.ClusterConfiguration.ControllerCluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, got, err := decideCertManager(CertManagerAuto, d)
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.expected {
+				t.Errorf("decideCertManager() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDecideCertManager(t *testing.T) {
+	t.Parallel()
+	old := certManagerDeployment{found: true, namespace: "cert-manager", version: "v1.5.4"}
+	tests := []struct {
+		name       string
+		mode       string
+		deployment certManagerDeployment
+		install    bool
+		fails      bool
+	}{
+		{name: "Auto without cert-manager", mode: CertManagerAuto, install: true},
+		{name: "Auto with a supported cert-manager", mode: CertManagerAuto, deployment: certManagerDeployment{found: true, version: "v1.7.0"}},
+		{name: "Auto with an old cert-manager", mode: CertManagerAuto, deployment: old, fails: true},
+		{name: "Auto with an unknown version", mode: CertManagerAuto, deployment: certManagerDeployment{found: true}},
+		{name: "Auto with the cert-manager of the CLI", mode: CertManagerAuto, deployment: certManagerDeployment{found: true, version: "v1.5.4", installed: true}, install: true},
+		{name: "Install", mode: CertManagerInstall, deployment: old, install: true},
+		{name: "Skip", mode: CertManagerSkip},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			install, _, err := decideCertManager(tc.mode, tc.deployment)
+			if (err != nil) != tc.fails {
+				t.Fatalf("decideCertManager() error = %v, want failure %t", err, tc.fails)
+			}
+			if install != tc.install {
+				t.Errorf("decideCertManager() install = %t, want %t", install, tc.install)
+			}
+		})
+	}
+}
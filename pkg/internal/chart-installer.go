@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Helm backends selectable with --helm-backend.
+const (
+	HelmBackendExec = "exec"
+	HelmBackendSDK  = "sdk"
+)
+
+// releaseRequest describes a release to install or upgrade.
+type releaseRequest struct {
+	Cluster         Cluster
+	Release         string
+	Namespace       string
+	Chart           HelmChart
+	Repo            HelmChartConfiguration
+	ValuesFile      string
+	CreateNamespace bool
+}
+
+// ChartInstaller installs, upgrades, uninstalls and lists the helm releases
+// of the clusters.
+type ChartInstaller interface {
+	// Upgrade installs the release or upgrades it if it exists, waiting for
+	// its resources, and returns the status of the deployed release.
+	Upgrade(req releaseRequest) (*helmRelease, error)
+	Uninstall(cluster Cluster, release, namespace string) error
+	// List returns the releases of the namespace in any state, only the
+	// release of that name when name is set.
+	List(cluster Cluster, namespace, name string) ([]helmRelease, error)
+}
+
+// chartInstaller is the backend the releases are managed with.
+var chartInstaller ChartInstaller = execInstaller{}
+
+// newSDKInstaller creates the helm SDK backend. It is only set in builds with
+// the helmsdk build tag.
+var newSDKInstaller func() ChartInstaller
+
+// SelectChartInstaller selects the helm backend of the run.
+func SelectChartInstaller(backend string) {
+	switch backend {
+	case "", HelmBackendExec:
+		chartInstaller = execInstaller{}
+	case HelmBackendSDK:
+		if newSDKInstaller == nil {
+			util.Fatalf("%s This build of kubeslice-cli does not include the helm SDK backend, build it with -tags helmsdk", util.Cross)
+		}
+		chartInstaller = newSDKInstaller()
+	default:
+		util.Fatalf("%s Unknown helm backend %s. Possible values %s", util.Cross, backend, []string{HelmBackendExec, HelmBackendSDK})
+	}
+}
+
+// installRelease installs or upgrades a release with the selected backend,
+// showing the manifest diff first and rolling back on failure when requested.
+// The status of the deployed release is recorded in the run summary.
+func installRelease(req releaseRequest) {
+	prepareRelease(req.Cluster, req.Release, req.Namespace, req.Chart)
+	showManifestDiff(req.Cluster, req.Release, req.Namespace, req.ValuesFile, helmUpgradeArgs(req))
+	revision := previousRevision(req.Cluster, req.Release, req.Namespace)
+	status, err := chartInstaller.Upgrade(req)
+	if err != nil {
+		releaseFailed(req.Cluster, req.Release, req.Namespace, revision, err)
+	}
+	if status != nil {
+		runSummary.Releases = append(runSummary.Releases, releaseSummary{Cluster: req.Cluster.Name, Release: status.Name, Namespace: status.Namespace, Revision: status.Revision, Status: status.Status, Chart: status.Chart})
+	}
+}
+
+// helmUpgradeArgs returns the arguments of `helm upgrade -i` for a release.
+func helmUpgradeArgs(req releaseRequest) []string {
+	hc, chart := req.Repo, req.Chart
+	args := []string{"--kube-context", req.Cluster.ContextName, "--kubeconfig", req.Cluster.KubeConfigPath, "upgrade", "-i", req.Release, chartReference(hc, chart), "--namespace", req.Namespace}
+	if req.CreateNamespace {
+		args = append(args, "--create-namespace")
+	}
+	args = append(args, "-f", req.ValuesFile)
+	args = append(args, helmTLSFlags(hc.forChart(chart), false)...)
+	args = append(args, helmVerifyFlags(chart)...)
+	args = append(args, helmDevelFlags(chart)...)
+	args = append(args, helmWaitFlags()...)
+	if chart.Version != "" {
+		args = append(args, "--version", chart.Version)
+	}
+	return args
+}
+
+// execInstaller manages the releases with the helm binary.
+type execInstaller struct{}
+
+func (execInstaller) Upgrade(req releaseRequest) (*helmRelease, error) {
+	if err := runHelmWithValues(req.ValuesFile, helmUpgradeArgs(req)...); err != nil {
+		return nil, err
+	}
+	// the upgrade succeeded, a status which cannot be fetched is only missing
+	// from the summary
+	status, err := findRelease(req.Cluster, req.Release, req.Namespace)
+	if err != nil {
+		util.Printf("%s %v", util.Warn, err)
+	}
+	return status, nil
+}
+
+func (execInstaller) Uninstall(cluster Cluster, release, namespace string) error {
+	return util.RunCommand("helm", "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "uninstall", release, "--namespace", namespace)
+}
+
+func (execInstaller) List(cluster Cluster, namespace, name string) ([]helmRelease, error) {
+	args := []string{"--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "list", "--namespace", namespace}
+	if name != "" {
+		args = append(args, "--filter", "^"+name+"$")
+	}
+	out, err := runHelmList(cluster, namespace, append(args, "--all", "--output", "json")...)
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]helmRelease, 0)
+	for _, r := range out {
+		if name == "" || r.Name == name {
+			releases = append(releases, r)
+		}
+	}
+	return releases, nil
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// helmVerifyFlags returns the helm flags verifying the provenance signature of
+// the chart when verify is set on it.
+func helmVerifyFlags(chart HelmChart) []string {
+	if !chart.Verify {
+		return nil
+	}
+	flags := []string{"--verify"}
+	if chart.Keyring != "" {
+		flags = append(flags, "--keyring", chart.Keyring)
+	}
+	return flags
+}
+
+// VerifyChartProvenance verifies the provenance signatures of the charts of
+// the components to install which have verify set, before any cluster is
+// modified. Each chart is pulled with --verify, or verified directly when it
+// is a local chart archive.
+func VerifyChartProvenance(specs *ConfigurationSpecs, components []string) {
+	hc := specs.Configuration.HelmChartConfiguration
+	_, charts := topologyCharts(&hc)
+	verified := make([]HelmChart, 0)
+	for _, component := range components {
+		chart := charts[componentChartKeys[component]]
+		if chart != nil && chart.ChartName != "" && chart.Verify {
+			verified = append(verified, *chart)
+		}
+	}
+	if len(verified) == 0 {
+		return
+	}
+	util.Printf("\nVerifying chart provenance...")
+	errors := make([]string, 0)
+	for _, chart := range verified {
+		if err := verifyChartProvenance(hc, chart); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			continue
+		}
+		util.Printf("%s Verified provenance of chart %s", util.Tick, chartReference(hc, chart))
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to unverified charts, no cluster was modified", util.Cross)
+	}
+}
+
+// verifyChartProvenance verifies the signature of a single chart.
+func verifyChartProvenance(hc HelmChartConfiguration, chart HelmChart) error {
+	ref := chartReference(hc, chart)
+	keyring := "the default keyring of helm"
+	if chart.Keyring != "" {
+		keyring = "keyring " + chart.Keyring
+	}
+	args := make([]string, 0)
+	switch {
+	case chart.LocalPath != "":
+		if info, err := os.Stat(chart.LocalPath); err == nil && info.IsDir() {
+			return fmt.Errorf("provenance verification not supported for this source: chart %s is an unpacked directory, use the signed chart archive", ref)
+		}
+		args = append(args, "verify", chart.LocalPath)
+	case hc.UseLocal:
+		return fmt.Errorf("provenance verification not supported for this source: chart %s is a local chart, set local_path to the signed chart archive", ref)
+	case IsOCIRepo(hc.forChart(chart).RepoUrl):
+		return fmt.Errorf("provenance verification not supported for this source: chart %s is pulled from an OCI registry", ref)
+	default:
+		dir, err := ioutil.TempDir("", "kubeslice-provenance")
+		if err != nil {
+			return fmt.Errorf("unable to verify chart %s: %v", ref, err)
+		}
+		defer os.RemoveAll(dir)
+		args = append(args, "pull", ref, "--destination", dir, "--verify")
+		if chart.Version != "" {
+			args = append(args, "--version", chart.Version)
+		}
+		args = append(args, helmTLSFlags(hc.forChart(chart), false)...)
+		args = append(args, helmDevelFlags(chart)...)
+	}
+	if chart.Keyring != "" {
+		args = append(args, "--keyring", chart.Keyring)
+	}
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...); err != nil {
+		return fmt.Errorf("provenance verification of chart %s with %s failed: %s", ref, keyring, util.Redact(strings.TrimSpace(errB.String())))
+	}
+	return nil
+}
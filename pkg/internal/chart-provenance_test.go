@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestHelmVerifyFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		chart    HelmChart
+		expected []string
+	}{
+		{
+			name:     "Verification disabled",
+			chart:    HelmChart{ChartName: "kubeslice-controller", Keyring: "/keys/pubring.gpg"},
+			expected: nil,
+		},
+		{
+			name:     "Verification with keyring",
+			chart:    HelmChart{ChartName: "kubeslice-controller", Verify: true, Keyring: "/keys/pubring.gpg"},
+			expected: []string{"--verify", "--keyring", "/keys/pubring.gpg"},
+		},
+		{
+			name:     "Verification with the default keyring",
+			chart:    HelmChart{ChartName: "kubeslice-controller", Verify: true},
+			expected: []string{"--verify"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := helmVerifyFlags(tc.chart); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("helmVerifyFlags() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestVerifyChartProvenance(t *testing.T) {
+	hc := HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"}
+	chart := HelmChart{ChartName: "kubeslice-controller", Version: "0.5.0", Verify: true, Keyring: "/keys/pubring.gpg"}
+
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		chart    HelmChart
+		stderr   string
+		commands [][]string
+		err      string
+	}{
+		{
+			name:     "Signed chart",
+			hc:       hc,
+			chart:    chart,
+			commands: [][]string{{"helm", "pull", "kubeslice/kubeslice-controller", "--destination", "<dir>", "--verify", "--version", "0.5.0", "--keyring", "/keys/pubring.gpg"}},
+		},
+		{
+			name:     "Failed verification",
+			hc:       hc,
+			chart:    chart,
+			stderr:   "Error: openpgp: signature made by unknown entity",
+			commands: [][]string{{"helm", "pull", "kubeslice/kubeslice-controller", "--destination", "<dir>", "--verify", "--version", "0.5.0", "--keyring", "/keys/pubring.gpg"}},
+			err:      "provenance verification of chart kubeslice/kubeslice-controller with keyring /keys/pubring.gpg failed: Error: openpgp: signature made by unknown entity",
+		},
+		{
+			name:  "OCI registry",
+			hc:    HelmChartConfiguration{RepoUrl: "oci://registry.example.com/charts"},
+			chart: chart,
+			err:   "provenance verification not supported for this source",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				if tc.stderr == "" {
+					return nil
+				}
+				fmt.Fprint(stderr, tc.stderr)
+				return errors.New("exit status 1")
+			}}
+			defer util.UseExecutor(mock)()
+
+			err := verifyChartProvenance(tc.hc, tc.chart)
+			for _, command := range mock.Commands {
+				if len(command) > 4 && command[3] == "--destination" {
+					command[4] = "<dir>"
+				}
+			}
+			if !reflect.DeepEqual(mock.Commands, tc.commands) {
+				t.Errorf("commands = %q, want %q", mock.Commands, tc.commands)
+			}
+			if tc.err == "" && err != nil {
+				t.Fatalf("verifyChartProvenance() returned error: %v", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("verifyChartProvenance() error = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// withChartRepository returns hc with the repository options replaced by
+// those of the chart, if it has its own repository. Credentials and TLS
+// options of the default repository are not carried over to another one.
+func (hc HelmChartConfiguration) withChartRepository(chart HelmChart) HelmChartConfiguration {
+	if hc.UseLocal || chart.RepoUrl == "" || chart.RepoUrl == hc.RepoUrl {
+		return hc
+	}
+	hc.RepoAlias = chart.RepoAlias
+	hc.RepoUrl = chart.RepoUrl
+	hc.HelmUsername = chart.HelmUsername
+	hc.HelmPassword = chart.HelmPassword
+	hc.HelmPasswordEnv = chart.HelmPasswordEnv
+	hc.CAFile = chart.CAFile
+	hc.CertFile = chart.CertFile
+	hc.KeyFile = chart.KeyFile
+	hc.InsecureSkipTLSVerify = chart.InsecureSkipTLSVerify
+	return hc
+}
+
+// chartRepositories returns the configuration of every distinct repository the
+// charts of the topology are installed from, in the order of the charts.
+// Repositories are identified by their URL. A chart repository gets its
+// repo_alias, or the default alias, with a suffix derived from the URL when
+// the alias is already taken by another repository.
+func chartRepositories(hc HelmChartConfiguration) []HelmChartConfiguration {
+	repos := make([]HelmChartConfiguration, 0)
+	aliases := map[string]string{hc.RepoAlias: hc.RepoUrl}
+	names, charts := topologyCharts(&hc)
+	for _, name := range names {
+		chart := charts[name]
+		if chart.ChartName == "" || chart.LocalPath != "" {
+			continue
+		}
+		repo := hc.withChartRepository(*chart)
+		if containsRepository(repos, repo.RepoUrl) {
+			continue
+		}
+		if !IsOCIRepo(repo.RepoUrl) {
+			if repo.RepoAlias == "" {
+				repo.RepoAlias = hc.RepoAlias
+			}
+			if repo.RepoAlias == "" {
+				repo.RepoAlias = "kubeslice"
+			}
+			if url, taken := aliases[repo.RepoAlias]; taken && url != repo.RepoUrl {
+				sum := sha256.Sum256([]byte(repo.RepoUrl))
+				repo.RepoAlias = fmt.Sprintf("%s-%x", repo.RepoAlias, sum[:4])
+			}
+			aliases[repo.RepoAlias] = repo.RepoUrl
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+func containsRepository(repos []HelmChartConfiguration, url string) bool {
+	for _, repo := range repos {
+		if repo.RepoUrl == url {
+			return true
+		}
+	}
+	return false
+}
+
+// forChart returns hc with the options of the repository the chart is
+// installed from, including its generated alias.
+func (hc HelmChartConfiguration) forChart(chart HelmChart) HelmChartConfiguration {
+	repo := hc.withChartRepository(chart)
+	if repo.RepoUrl == hc.RepoUrl {
+		return repo
+	}
+	for _, r := range chartRepositories(hc) {
+		if r.RepoUrl == repo.RepoUrl {
+			return r
+		}
+	}
+	return repo
+}
+
+// UsesDefaultRepository reports whether a chart of the topology is installed
+// from the repository of helm_chart_configuration.
+func UsesDefaultRepository(hc HelmChartConfiguration) bool {
+	names, charts := topologyCharts(&hc)
+	for _, name := range names {
+		chart := charts[name]
+		if chart.ChartName != "" && chart.LocalPath == "" && (hc.UseLocal || chart.RepoUrl == "" || chart.RepoUrl == hc.RepoUrl) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveHelmPasswords reads the helm passwords given by helm_password_env
+// of the default repository and of every chart repository, and registers them
+// as secrets. The variables which are not set are reported.
+func ResolveHelmPasswords(hc *HelmChartConfiguration) []string {
+	errors := make([]string, 0)
+	resolve := func(password *string, env, field string) {
+		if *password == "" && env != "" {
+			*password = os.Getenv(env)
+			if *password == "" {
+				errors = append(errors, fmt.Sprintf("%s Environment variable %s of %s.helm_password_env is not set", util.Cross, env, field))
+			}
+		}
+		util.RegisterSecret(*password)
+	}
+	resolve(&hc.HelmPassword, hc.HelmPasswordEnv, "configuration.helm_chart_configuration")
+	names, charts := topologyCharts(hc)
+	for _, name := range names {
+		chart := charts[name]
+		resolve(&chart.HelmPassword, chart.HelmPasswordEnv, "configuration.helm_chart_configuration."+name)
+	}
+	return errors
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChartRepositories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		hc         HelmChartConfiguration
+		expected   []string // alias=url of every repository
+		references map[string]string
+	}{
+		{
+			name: "Charts of the default repository",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+				WorkerChart:     HelmChart{ChartName: "kubeslice-worker"},
+			},
+			expected:   []string{"kubeslice=https://kubeslice.github.io/kubeslice/"},
+			references: map[string]string{"kubeslice-controller": "kubeslice/kubeslice-controller", "kubeslice-worker": "kubeslice/kubeslice-worker"},
+		},
+		{
+			name: "Two charts from two repositories",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+				WorkerChart:     HelmChart{ChartName: "kubeslice-worker", RepoAlias: "mirror", RepoUrl: "https://charts.example.com"},
+			},
+			expected:   []string{"kubeslice=https://kubeslice.github.io/kubeslice/", "mirror=https://charts.example.com"},
+			references: map[string]string{"kubeslice-controller": "kubeslice/kubeslice-controller", "kubeslice-worker": "mirror/kubeslice-worker"},
+		},
+		{
+			name: "Alias of another repository gets a suffix",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+				WorkerChart:     HelmChart{ChartName: "kubeslice-worker", RepoUrl: "https://charts.example.com"},
+				UIChart:         HelmChart{ChartName: "kubeslice-ui", RepoAlias: "kubeslice", RepoUrl: "https://charts.example.com"},
+			},
+			expected:   []string{"kubeslice=https://kubeslice.github.io/kubeslice/", "kubeslice-73ce958f=https://charts.example.com"},
+			references: map[string]string{"kubeslice-worker": "kubeslice-73ce958f/kubeslice-worker", "kubeslice-ui": "kubeslice-73ce958f/kubeslice-ui"},
+		},
+		{
+			name: "Repository of the default URL is deduplicated",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller", RepoAlias: "upstream", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+				WorkerChart:     HelmChart{ChartName: "kubeslice-worker", LocalPath: "charts/kubeslice-worker"},
+			},
+			expected:   []string{"kubeslice=https://kubeslice.github.io/kubeslice/"},
+			references: map[string]string{"kubeslice-controller": "kubeslice/kubeslice-controller", "kubeslice-worker": "charts/kubeslice-worker"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make([]string, 0)
+			for _, repo := range chartRepositories(tc.hc) {
+				got = append(got, repo.RepoAlias+"="+repo.RepoUrl)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("chartRepositories() mismatch\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+			names, charts := topologyCharts(&tc.hc)
+			for _, name := range names {
+				chart := charts[name]
+				if expected, found := tc.references[chart.ChartName]; found {
+					if ref := chartReference(tc.hc, *chart); ref != expected {
+						t.Errorf("chartReference(%s) = %s, want %s", chart.ChartName, ref, expected)
+					}
+				}
+			}
+		})
+	}
+}
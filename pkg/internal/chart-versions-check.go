@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// chartNotFoundError is returned for a chart its repository does not publish.
+type chartNotFoundError struct {
+	chart string
+	repo  string
+}
+
+func (e chartNotFoundError) Error() string {
+	return fmt.Sprintf("chart %s not found in repository %s", e.chart, e.repo)
+}
+
+// componentChartKeys maps the installable components to the topology field
+// of their chart.
+var componentChartKeys = map[string]string{
+	CertManager_Component: "cert_manager_chart",
+	Controller_Component:  "controller_chart",
+	Worker_Component:      "worker_chart",
+	UI_install_Component:  "ui_chart",
+	Prometheus_Component:  "prometheus_chart",
+}
+
+// ValidateChartVersions checks before any install step that the pinned version
+// of each chart to install exists, failing with the nearest published
+// versions if it does not. Charts without a version are resolved to the latest
+// version, which is pinned for the rest of the run. The resolved versions are
+// recorded in the run summary.
+func ValidateChartVersions(specs *ConfigurationSpecs, components []string) {
+	hc := &specs.Configuration.HelmChartConfiguration
+	_, charts := topologyCharts(hc)
+	util.Printf("\nValidating chart versions...")
+	errors := make([]string, 0)
+	for _, component := range components {
+		chart := charts[componentChartKeys[component]]
+		if chart == nil || chart.ChartName == "" {
+			continue
+		}
+		if chart.LocalPath != "" || hc.UseLocal {
+			runSummary.Charts[component] = chartSummary{Chart: chartReference(*hc, *chart), Version: "local"}
+			continue
+		}
+		pinned := chart.Version
+		version, err := resolveChartVersion(*hc, *chart)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			continue
+		}
+		chart.Version = version
+		_, pre := splitPrerelease(strings.TrimPrefix(version, "v"))
+		runSummary.Charts[component] = chartSummary{Chart: chartReference(*hc, *chart), Version: version, Resolved: pinned == "", Prerelease: pre != ""}
+		if pre != "" {
+			util.Printf("%s %s %s (pre-release)", util.Warn, chartReference(*hc, *chart), version)
+			continue
+		}
+		util.Printf("%s %s %s", util.Tick, chartReference(*hc, *chart), version)
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to unavailable chart versions", util.Cross)
+	}
+	writeRunSummary()
+}
+
+// resolveChartVersion returns the version of the chart which will be
+// installed. Version constraints like ~1.2 are left to helm to resolve.
+func resolveChartVersion(hc HelmChartConfiguration, chart HelmChart) (string, error) {
+	if strings.ContainsAny(chart.Version, "^~<>=*| ,") {
+		return chart.Version, nil
+	}
+	hc = hc.forChart(chart)
+	if IsOCIRepo(hc.RepoUrl) {
+		return ociChartVersion(hc, chart)
+	}
+	var outB, errB bytes.Buffer
+	args := append([]string{"search", "repo", chartReference(hc, chart), "--versions", "--output", "json"}, helmDevelFlags(chart)...)
+	err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...)
+	if err != nil {
+		return "", fmt.Errorf("unable to list versions of chart %s: %s%s", chartReference(hc, chart), strings.TrimSpace(errB.String()), helmRepoErrorHint(errB.String()))
+	}
+	versions, err := parseChartVersions(outB.Bytes(), chartReference(hc, chart))
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", chartNotFoundError{chartReference(hc, chart), hc.RepoUrl}
+	}
+	if chart.Version == "" {
+		if develChart(chart) {
+			return versions[0].Version, nil
+		}
+		return latestVersion(versions), nil
+	}
+	for _, v := range versions {
+		if strings.TrimPrefix(v.Version, "v") == strings.TrimPrefix(chart.Version, "v") {
+			return v.Version, nil
+		}
+	}
+	return "", fmt.Errorf("version %s of chart %s does not exist, nearest available versions: %s", chart.Version, chartReference(hc, chart), strings.Join(nearestVersions(versions, chart.Version, 3), ", "))
+}
+
+// ociChartVersion checks the tag of a chart in an OCI registry. Registries
+// cannot be searched, so no alternatives are suggested.
+func ociChartVersion(hc HelmChartConfiguration, chart HelmChart) (string, error) {
+	args := []string{"show", "chart", chartReference(hc, chart)}
+	if chart.Version != "" {
+		args = append(args, "--version", chart.Version)
+	}
+	args = append(args, helmTLSFlags(hc, false)...)
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, true, append(args, helmDevelFlags(chart)...)...)
+	if err != nil {
+		if chart.Version == "" && strings.Contains(errB.String(), "not found") {
+			return "", chartNotFoundError{chartReference(hc, chart), hc.RepoUrl}
+		}
+		if chart.Version != "" {
+			return "", fmt.Errorf("version %s of chart %s does not exist: %s%s", chart.Version, chartReference(hc, chart), strings.TrimSpace(errB.String()), helmRepoErrorHint(errB.String()))
+		}
+		return "", fmt.Errorf("unable to fetch chart %s: %s%s", chartReference(hc, chart), strings.TrimSpace(errB.String()), helmRepoErrorHint(errB.String()))
+	}
+	metadata := struct {
+		Version string `yaml:"version"`
+	}{}
+	if err := yaml.Unmarshal(outB.Bytes(), &metadata); err != nil || metadata.Version == "" {
+		return "", fmt.Errorf("unable to read the version of chart %s", chartReference(hc, chart))
+	}
+	return metadata.Version, nil
+}
+
+// develChart reports whether versions of the chart resolve to pre-releases,
+// with --devel or devel set on the chart.
+func develChart(chart HelmChart) bool {
+	return Options.Devel || chart.Devel
+}
+
+// helmDevelFlags returns the helm flags including pre-release versions.
+func helmDevelFlags(chart HelmChart) []string {
+	if !develChart(chart) {
+		return nil
+	}
+	return []string{"--devel"}
+}
+
+// latestVersion returns the newest release of versions sorted newest first,
+// which is what helm installs when no version is given.
+func latestVersion(versions []chartVersion) string {
+	for _, v := range versions {
+		if _, pre := splitPrerelease(strings.TrimPrefix(v.Version, "v")); pre == "" {
+			return v.Version
+		}
+	}
+	return versions[0].Version
+}
+
+// nearestVersions returns up to n versions most similar to version, newer
+// versions first among equally similar ones.
+func nearestVersions(versions []chartVersion, version string, n int) []string {
+	candidates := make([]string, 0, len(versions))
+	for _, v := range versions {
+		candidates = append(candidates, v.Version)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		di, dj := editDistance(version, candidates[i]), editDistance(version, candidates[j])
+		if di != dj {
+			return di < dj
+		}
+		return compareVersions(candidates[i], candidates[j]) > 0
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
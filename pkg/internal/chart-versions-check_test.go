@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const controllerSearchOutput = `[
+	{"name":"kubeslice/kubeslice-controller","version":"1.3.0","app_version":"1.3.0"},
+	{"name":"kubeslice/kubeslice-controller","version":"1.2.1","app_version":"1.2.1"},
+	{"name":"kubeslice/kubeslice-controller","version":"1.2.0","app_version":"1.2.0"},
+	{"name":"kubeslice/kubeslice-controller","version":"0.9.0","app_version":"0.9.0"}
+]`
+
+func TestResolveChartVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		version  string
+		handler  func(command []string, stdout, stderr io.Writer) error
+		expected string
+		err      string
+	}{
+		{
+			name:     "Pinned version exists",
+			hc:       HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			version:  "1.2.0",
+			expected: "1.2.0",
+		},
+		{
+			name:     "Latest version is resolved",
+			hc:       HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			expected: "1.3.0",
+		},
+		{
+			name:    "Missing version lists the nearest versions",
+			hc:      HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			version: "1.2.2",
+			err:     "version 1.2.2 of chart kubeslice/kubeslice-controller does not exist, nearest available versions: 1.2.1, 1.2.0, 1.3.0",
+		},
+		{
+			name:     "OCI tag exists",
+			hc:       HelmChartConfiguration{RepoUrl: "oci://ghcr.io/kubeslice/charts"},
+			version:  "1.2.0",
+			expected: "1.2.0",
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stdout, "name: kubeslice-controller\nversion: 1.2.0\n")
+				return nil
+			},
+		},
+		{
+			name:    "OCI tag is missing",
+			hc:      HelmChartConfiguration{RepoUrl: "oci://ghcr.io/kubeslice/charts"},
+			version: "1.2.2",
+			err:     "version 1.2.2 of chart oci://ghcr.io/kubeslice/charts/kubeslice-controller does not exist: Error: manifest unknown",
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stderr, "Error: manifest unknown")
+				return errors.New("exit status 1")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: tc.handler}
+			if mock.Handler == nil {
+				mock.Handler = func(command []string, stdout, stderr io.Writer) error {
+					fmt.Fprint(stdout, controllerSearchOutput)
+					return nil
+				}
+			}
+			defer util.UseExecutor(mock)()
+
+			got, err := resolveChartVersion(tc.hc, HelmChart{ChartName: "kubeslice-controller", Version: tc.version})
+			if tc.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Fatalf("resolveChartVersion() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveChartVersion() returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("resolveChartVersion() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestResolveChartVersionDevel(t *testing.T) {
+	defer func(devel bool) { Options.Devel = devel }(Options.Devel)
+
+	searchOutput := `[
+	{"name":"kubeslice/kubeslice-controller","version":"1.4.0-rc.1","app_version":"1.4.0-rc.1"},
+	{"name":"kubeslice/kubeslice-controller","version":"1.3.0","app_version":"1.3.0"}
+]`
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		chart    HelmChart
+		flag     bool
+		output   string
+		expected string
+		command  []string
+	}{
+		{
+			name:     "Latest pre-release with --devel",
+			hc:       HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			chart:    HelmChart{ChartName: "kubeslice-controller"},
+			flag:     true,
+			output:   searchOutput,
+			expected: "1.4.0-rc.1",
+			command:  []string{"helm", "search", "repo", "kubeslice/kubeslice-controller", "--versions", "--output", "json", "--devel"},
+		},
+		{
+			name:     "Latest pre-release with devel on the chart",
+			hc:       HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			chart:    HelmChart{ChartName: "kubeslice-controller", Devel: true},
+			output:   searchOutput,
+			expected: "1.4.0-rc.1",
+			command:  []string{"helm", "search", "repo", "kubeslice/kubeslice-controller", "--versions", "--output", "json", "--devel"},
+		},
+		{
+			name:     "Latest release without devel",
+			hc:       HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"},
+			chart:    HelmChart{ChartName: "kubeslice-controller"},
+			output:   searchOutput,
+			expected: "1.3.0",
+			command:  []string{"helm", "search", "repo", "kubeslice/kubeslice-controller", "--versions", "--output", "json"},
+		},
+		{
+			name:     "OCI pre-release with --devel",
+			hc:       HelmChartConfiguration{RepoUrl: "oci://ghcr.io/kubeslice/charts"},
+			chart:    HelmChart{ChartName: "kubeslice-controller", Version: "1.4.0-rc.1"},
+			flag:     true,
+			output:   "name: kubeslice-controller\nversion: 1.4.0-rc.1\n",
+			expected: "1.4.0-rc.1",
+			command:  []string{"helm", "show", "chart", "oci://ghcr.io/kubeslice/charts/kubeslice-controller", "--version", "1.4.0-rc.1", "--devel"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.Devel = tc.flag
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stdout, tc.output)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			got, err := resolveChartVersion(tc.hc, tc.chart)
+			if err != nil {
+				t.Fatalf("resolveChartVersion() returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("resolveChartVersion() = %q, want %q", got, tc.expected)
+			}
+			if len(mock.Commands) != 1 || strings.Join(mock.Commands[0], " ") != strings.Join(tc.command, " ") {
+				t.Errorf("commands = %q, want %q", mock.Commands, tc.command)
+			}
+		})
+	}
+}
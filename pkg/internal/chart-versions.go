@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// chartVersion is an entry of `helm search repo --output json`.
+type chartVersion struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"app_version"`
+	Created    string `json:"-"`
+}
+
+// componentRelease describes where the chart of a component is installed.
+type componentRelease struct {
+	chart    HelmChart
+	clusters []Cluster
+	release  string
+	// namespace returns the namespace of the release on a cluster
+	namespace func(cluster Cluster) string
+}
+
+// inNamespace returns the namespace of a release installed to the same
+// namespace on every cluster.
+func inNamespace(namespace string) func(cluster Cluster) string {
+	return func(Cluster) string { return namespace }
+}
+
+// componentReleases returns the releases of the components which can be
+// named on the command line.
+func componentReleases(specs *ConfigurationSpecs) map[string]componentRelease {
+	cc := specs.Configuration.ClusterConfiguration
+	hc := specs.Configuration.HelmChartConfiguration
+	controller := []Cluster{cc.ControllerCluster}
+	return map[string]componentRelease{
+		CertManager_Component: {hc.CertManagerChart, controller, "cert-manager", inNamespace("cert-manager")},
+		Controller_Component:  {hc.ControllerChart, controller, KUBESLICE_CONTROLLER_NAMESPACE, ControllerNamespace},
+		UI_install_Component:  {hc.UIChart, controller, "kubeslice-ui", ControllerNamespace},
+		Worker_Component:      {hc.WorkerChart, cc.WorkerClusters, "kubeslice-worker", WorkerNamespace},
+		Prometheus_Component:  {hc.PrometheusChart, cc.WorkerClusters, hc.PrometheusChart.ChartName, inNamespace(PrometheusNamespace)},
+	}
+}
+
+// ShowChartVersions prints the versions of the chart of a component published
+// in the configured repository, marking the pinned and the deployed versions.
+func ShowChartVersions(specs *ConfigurationSpecs, component, outputFormat string) {
+	hc := specs.Configuration.HelmChartConfiguration
+	cr, found := componentReleases(specs)[component]
+	if !found || cr.chart.ChartName == "" {
+		util.Fatalf("%s Chart of component %s is not configured in the topology", util.Cross, component)
+	}
+	hc = hc.forChart(cr.chart)
+	if cr.chart.LocalPath != "" || hc.UseLocal || IsOCIRepo(hc.RepoUrl) {
+		util.Fatalf("%s Listing versions is only supported for helm chart repositories, %s is installed from %s", util.Cross, cr.chart.ChartName, chartReference(hc, cr.chart))
+	}
+	cleanup := ensureHelmRepo(hc)
+	defer cleanup()
+
+	out := runHelmRepoCommand(hc.RepoUrl, true, append([]string{"search", "repo", chartReference(hc, cr.chart), "--versions", "--output", "json"}, helmDevelFlags(cr.chart)...)...)
+	if outputFormat == "json" {
+		util.Printf("%s", strings.TrimSpace(string(out)))
+		return
+	}
+	versions, err := parseChartVersions(out, chartReference(hc, cr.chart))
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	addReleaseDates(versions, hc.RepoAlias, cr.chart.ChartName)
+
+	deployed := make(map[string][]string)
+	for _, cluster := range cr.clusters {
+		if version := deployedChartVersion(cluster, cr.release, cr.namespace(cluster), cr.chart.ChartName); version != "" {
+			deployed[version] = append(deployed[version], cluster.Name)
+		}
+	}
+	w := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPP VERSION\tCREATED\t")
+	for _, v := range versions {
+		notes := make([]string, 0)
+		if v.Version == cr.chart.Version {
+			notes = append(notes, "pinned")
+		}
+		if _, pre := splitPrerelease(strings.TrimPrefix(v.Version, "v")); pre != "" {
+			notes = append(notes, "pre-release")
+		}
+		if clusters, found := deployed[v.Version]; found {
+			notes = append(notes, "deployed on "+strings.Join(clusters, ", "))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Version, v.AppVersion, v.Created, strings.Join(notes, "; "))
+	}
+	w.Flush()
+}
+
+// parseChartVersions returns the versions of the named chart, newest first.
+func parseChartVersions(data []byte, name string) ([]chartVersion, error) {
+	all := make([]chartVersion, 0)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("error parsing helm search output: %v", err)
+	}
+	versions := make([]chartVersion, 0, len(all))
+	for _, v := range all {
+		// helm search matches substrings, other charts may be listed as well
+		if v.Name == name {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+	return versions, nil
+}
+
+// addReleaseDates fills in the creation dates from the cached repository
+// index, which helm search does not print. Dates stay empty when the index
+// cannot be read.
+func addReleaseDates(versions []chartVersion, repoAlias, chartName string) {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, "env", "HELM_REPOSITORY_CACHE"); err != nil {
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(strings.TrimSpace(outB.String()), repoAlias+"-index.yaml"))
+	if err != nil {
+		return
+	}
+	index := struct {
+		Entries map[string][]struct {
+			Version string `yaml:"version"`
+			Created string `yaml:"created"`
+		} `yaml:"entries"`
+	}{}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return
+	}
+	created := make(map[string]string)
+	for _, entry := range index.Entries[chartName] {
+		if len(entry.Created) >= len("2006-01-02") {
+			created[entry.Version] = entry.Created[:len("2006-01-02")]
+		}
+	}
+	for i := range versions {
+		versions[i].Created = created[versions[i].Version]
+	}
+}
+
+// ensureHelmRepo makes sure the chart repository is added with an up to date
+// index. A repository which was not added before is removed again by the
+// returned function.
+func ensureHelmRepo(hc HelmChartConfiguration) func() {
+	if _, found := configuredHelmRepos()[hc.RepoAlias]; found {
+		if !helmRepoUpToDate(hc) {
+			runHelmRepoCommand(hc.RepoUrl, true, "repo", "update", hc.RepoAlias)
+			recordHelmRepoUpdate(hc.RepoUrl)
+		}
+		return func() {}
+	}
+	if Options.Offline {
+		util.Fatalf("%s Helm repo %s is not added as %s, run once without --offline to add it", util.Cross, hc.RepoUrl, hc.RepoAlias)
+	}
+	args := append([]string{"repo", "add", hc.RepoAlias, hc.RepoUrl}, helmRepoCredentials(hc)...)
+	runHelmRepoCommand(hc.RepoUrl, true, append(args, helmTLSFlags(hc, false)...)...)
+	return func() {
+		util.RunCommandWithoutPrint("helm", "repo", "remove", hc.RepoAlias)
+	}
+}
+
+// deployedChartVersion returns the chart version of the release installed on
+// the cluster, or an empty string if it is not installed.
+func deployedChartVersion(cluster Cluster, release, namespace, chartName string) string {
+	existing, err := findRelease(cluster, release, namespace)
+	if err != nil || existing == nil || existing.Status != "deployed" {
+		return ""
+	}
+	return existing.chartVersion(chartName)
+}
+
+// compareVersions compares two semantic versions, returning a negative number
+// when a is older than b and a positive one when it is newer. Pre-releases
+// are older than the release they precede.
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	aCore, aPre := splitPrerelease(a)
+	bCore, bPre := splitPrerelease(b)
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+func splitPrerelease(version string) (string, string) {
+	version = strings.SplitN(version, "+", 2)[0]
+	parts := strings.SplitN(version, "-", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{name: "Equal versions", a: "1.2.3", b: "1.2.3", expected: 0},
+		{name: "Numeric not lexical", a: "1.10.0", b: "1.9.0", expected: 1},
+		{name: "Leading v is ignored", a: "v0.5.0", b: "0.6.0", expected: -1},
+		{name: "Pre-release is older", a: "1.0.0-rc.1", b: "1.0.0", expected: -1},
+		{name: "Build metadata is ignored", a: "1.0.0+abc", b: "1.0.0", expected: 0},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := compareVersions(tc.a, tc.b)
+			if (got > 0) != (tc.expected > 0) || (got < 0) != (tc.expected < 0) {
+				t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", tc.a, tc.b, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseChartVersions(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`[
+		{"name":"kubeslice/kubeslice-controller","version":"0.9.0","app_version":"0.9.0"},
+		{"name":"kubeslice/kubeslice-controller","version":"0.10.0","app_version":"0.10.0"},
+		{"name":"kubeslice/kubeslice-controller-ent","version":"1.0.0","app_version":"1.0.0"}
+	]`)
+	versions, err := parseChartVersions(data, "kubeslice/kubeslice-controller")
+	if err != nil {
+		t.Fatalf("parseChartVersions() returned error: %v", err)
+	}
+	got := make([]string, 0)
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if expected := []string{"0.10.0", "0.9.0"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseChartVersions() = %v, want %v", got, expected)
+	}
+}
@@ -1,7 +1,7 @@
 package internal
 
 import (
-	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
@@ -11,42 +11,32 @@ const (
 	clusterRegistrationFileName = "cluster-registration.yaml"
 )
 
-const clusterRegistrationTemplate = `
-apiVersion: controller.kubeslice.io/v1alpha1
-kind: Cluster
-metadata:
-  name: %s 
-  namespace: %s
-spec:
-  clusterProperty: %s
----
-
-`
-const regionTemplate1 = `
-    geoLocation:
-      cloudProvider: GCP
-      cloudRegion: custom
-      latitude: "36.7783"
-      longitude: "-119.4179"
-`
-const regionTemplate2 = `
-    geoLocation:
-      cloudProvider: DATACENTER
-      cloudRegion: custom
-      latitude: "40.6976633"
-      longitude: "-74.1201054"
-`
-
-var regionTemplates = map[string]string{
-	"ks-w-1": regionTemplate1,
-	"ks-w-2": regionTemplate2,
+var clusterGeoLocations = map[string]*geoLocation{
+	"ks-w-1": {CloudProvider: "GCP", CloudRegion: "custom", Latitude: "36.7783", Longitude: "-119.4179"},
+	"ks-w-2": {CloudProvider: "DATACENTER", CloudRegion: "custom", Latitude: "40.6976633", Longitude: "-74.1201054"},
 }
 
 func RegisterWorkerClusters(ApplicationConfiguration *ConfigurationSpecs, cliOptions *CliOptionsStruct) {
 	util.Printf("\nRegistering Worker Clusters with Project...")
+	if cliOptions != nil && cliOptions.DryRun {
+		useForcedAPIVersion()
+		if cliOptions.FileName != "" {
+			PrintManifestFile(cliOptions.FileName)
+			return
+		}
+		manifest, err := clusterRegistrationManifest(ApplicationConfiguration, cliOptions.Namespace)
+		if err != nil {
+			util.Fatalf("%s Failed to generate cluster registration manifest: %v", util.Cross, err)
+		}
+		printDryRunManifest(manifest)
+		return
+	}
+	GenerateKubeSliceDirectory()
 
 	if cliOptions != nil {
+		WaitForControllerCRDs(cliOptions.Cluster)
 		if cliOptions.FileName == "" {
+			DetectControllerAPIVersion(cliOptions.Cluster)
 			cliOptions.FileName = kubesliceDirectory + "/" + "custom-" + clusterRegistrationFileName
 			generateClusterRegistrationManifest(ApplicationConfiguration, cliOptions.FileName, cliOptions.Namespace)
 		}
@@ -57,30 +47,100 @@ func RegisterWorkerClusters(ApplicationConfiguration *ConfigurationSpecs, cliOpt
 		time.Sleep(200 * time.Millisecond)
 	} else {
 		ac := ApplicationConfiguration.Configuration
-		generateClusterRegistrationManifest(ApplicationConfiguration, kubesliceDirectory+"/"+clusterRegistrationFileName, "kubeslice-"+ac.KubeSliceConfiguration.ProjectName)
-		util.Printf("%s Generated cluster registration manifest %s", util.Tick, clusterRegistrationFileName)
-		time.Sleep(200 * time.Millisecond)
+		if untouchedClusters[ac.ClusterConfiguration.ControllerCluster.Name] {
+			util.Printf("%s Leaving cluster %s untouched", util.Tick, ac.ClusterConfiguration.ControllerCluster.Name)
+			return
+		}
+		WaitForControllerCRDs(&ac.ClusterConfiguration.ControllerCluster)
+		DetectControllerAPIVersion(&ac.ClusterConfiguration.ControllerCluster)
+		for _, project := range ProjectNames(ac.KubeSliceConfiguration) {
+			workers := touchedWorkers(projectWorkers(ac, project))
+			if len(workers) == 0 {
+				continue
+			}
+			fileName := projectClusterRegistrationFileName(ac.KubeSliceConfiguration, project)
+			generateClusterRegistrationManifest(withWorkers(ApplicationConfiguration, workers), kubesliceDirectory+"/"+fileName, projectNamespace(project))
+			util.Printf("%s Generated cluster registration manifest %s", util.Tick, fileName)
+			time.Sleep(200 * time.Millisecond)
 
-		ApplyKubectlManifest(kubesliceDirectory+"/"+clusterRegistrationFileName, "kubeslice-"+ac.KubeSliceConfiguration.ProjectName, &ac.ClusterConfiguration.ControllerCluster)
-		util.Printf("%s Applied %s", util.Tick, clusterRegistrationFileName)
-		time.Sleep(200 * time.Millisecond)
+			ApplyKubectlManifest(kubesliceDirectory+"/"+fileName, projectNamespace(project), &ac.ClusterConfiguration.ControllerCluster)
+			util.Printf("%s Applied %s", util.Tick, fileName)
+			time.Sleep(200 * time.Millisecond)
+		}
 	}
 	util.Printf("Registered Worker Clusters with Project.")
 }
 
+// projectClusterRegistrationFileName returns the file of the registrations of
+// the workers of a project, cluster-registration.yaml for the default project.
+func projectClusterRegistrationFileName(ksc KubeSliceConfiguration, project string) string {
+	if project == ksc.ProjectName {
+		return clusterRegistrationFileName
+	}
+	return "cluster-registration-" + project + ".yaml"
+}
+
 func generateClusterRegistrationManifest(ApplicationConfiguration *ConfigurationSpecs, filename string, namespace string) {
-	var clusterRegistrationContent = ""
-	var regionTemplate = "{}"
-	if namespace == "" {
-		namespace = "kubeslice-" + ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName
+	manifest, err := clusterRegistrationManifest(ApplicationConfiguration, namespace)
+	if err != nil {
+		util.Fatalf("%s Failed to generate cluster registration manifest: %v", util.Cross, err)
+	}
+	util.DumpFile(manifest, filename)
+}
+
+// clusterRegistrationManifest returns the registrations of the workers in
+// namespace, or in the namespace of the project of each worker when it is
+// empty.
+func clusterRegistrationManifest(ApplicationConfiguration *ConfigurationSpecs, namespace string) (string, error) {
+	tmpl, err := loadManifestTemplate(clusterTemplateFile)
+	if err != nil {
+		return "", err
 	}
+	ksc := ApplicationConfiguration.Configuration.KubeSliceConfiguration
+	documents := make([]string, 0)
 	for _, cluster := range ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters {
+		clusterNamespace := namespace
+		if clusterNamespace == "" {
+			clusterNamespace = projectNamespace(workerProject(ksc, cluster))
+		}
+		var property clusterProperty
 		if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == ProfileEntDemo {
-			regionTemplate = regionTemplates[cluster.Name]
+			property.GeoLocation = clusterGeoLocations[cluster.Name]
+		}
+		registration := clusterManifest{
+			APIVersion: kubesliceControllerAPIVersion,
+			Kind:       "Cluster",
+			Metadata:   newObjectMeta(cluster.Name, clusterNamespace, ksc, cluster.RegistrationMetadata),
+			Spec:       clusterSpec{NodeIPs: clusterNodeIPs(cluster), ClusterProperty: property},
+		}
+		if tmpl == nil {
+			document, err := marshalManifests(registration)
+			if err != nil {
+				return "", err
+			}
+			documents = append(documents, document)
+			continue
+		}
+		var rendered clusterManifest
+		document, err := renderManifestTemplate(tmpl, ManifestTemplateData{
+			APIVersion:       registration.APIVersion,
+			Kind:             registration.Kind,
+			Name:             registration.Metadata.Name,
+			Namespace:        registration.Metadata.Namespace,
+			Labels:           registration.Metadata.Labels,
+			Annotations:      registration.Metadata.Annotations,
+			Project:          workerProject(ksc, cluster),
+			ProjectNamespace: clusterNamespace,
+			Cluster:          cluster,
+			NodeIPs:          registration.Spec.NodeIPs,
+			Spec:             registration.Spec,
+		}, &rendered)
+		if err != nil {
+			return "", err
 		}
-		clusterRegistrationContent = clusterRegistrationContent + fmt.Sprintf(clusterRegistrationTemplate, cluster.Name, namespace, regionTemplate)
+		documents = append(documents, document)
 	}
-	util.DumpFile(clusterRegistrationContent, filename)
+	return strings.Join(documents, "---\n"), nil
 }
 
 func GetKubeSliceCluster(clusterName string, namespace string, controllerCluster *Cluster, outputFormat string) {
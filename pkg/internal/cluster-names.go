@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// maxClusterNameLength keeps the names of the Cluster objects DNS-1123 labels.
+const maxClusterNameLength = 63
+
+var invalidClusterNameCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sanitizedClusterName turns a context into a name valid for Kubernetes
+// objects, e.g. arn:aws:eks:us-east-1:1234:cluster/prod into
+// arn-aws-eks-us-east-1-1234-cluster-prod.
+func sanitizedClusterName(context string) string {
+	name := strings.Trim(invalidClusterNameCharacters.ReplaceAllString(strings.ToLower(context), "-"), "-")
+	if len(name) > maxClusterNameLength {
+		name = strings.TrimRight(name[:maxClusterNameLength], "-")
+	}
+	return name
+}
+
+// DeriveClusterNames names the clusters of a topology without a name after
+// their context. The context keeps being used as is for kubectl and helm, the
+// name is what the manifests refer to the cluster by.
+func DeriveClusterNames(cc *ClusterConfiguration) {
+	clusters := append([]*Cluster{&cc.ControllerCluster}, workerPointers(cc)...)
+	for _, cluster := range clusters {
+		if cluster.Name == "" && cluster.ContextName != "" {
+			cluster.Name = sanitizedClusterName(cluster.ContextName)
+		}
+	}
+}
+
+func workerPointers(cc *ClusterConfiguration) []*Cluster {
+	workers := make([]*Cluster, 0, len(cc.WorkerClusters))
+	for i := range cc.WorkerClusters {
+		workers = append(workers, &cc.WorkerClusters[i])
+	}
+	return workers
+}
+
+// ValidateClusterNames checks that the names of the clusters are valid names
+// of Kubernetes objects and unique, naming the context of each cluster as
+// well since the two differ for clusters of cloud providers.
+func ValidateClusterNames(cc ClusterConfiguration) []string {
+	errors := make([]string, 0)
+	paths := []string{"configuration.cluster_configuration.controller"}
+	for i := range cc.WorkerClusters {
+		paths = append(paths, fmt.Sprintf("configuration.cluster_configuration.workers[%d]", i))
+	}
+	contexts := make(map[string]string)
+	for i, cluster := range append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...) {
+		if cluster.Name == "" {
+			continue
+		}
+		if err := validateName("cluster name", cluster.Name, maxClusterNameLength); err != nil {
+			suggestion := ""
+			if name := sanitizedClusterName(cluster.Name); name != "" {
+				suggestion = fmt.Sprintf(", e.g. name: %s", name)
+			}
+			errors = append(errors, fmt.Sprintf("%s %s: %v. Set a valid name for context %q%s", util.Cross, paths[i], err, cluster.ContextName, suggestion))
+			continue
+		}
+		// the controller cluster may be registered as a worker as well
+		if context, found := contexts[cluster.Name]; found && context != cluster.ContextName {
+			errors = append(errors, fmt.Sprintf("%s %s: cluster name %q of context %q is also the name of context %q", util.Cross, paths[i], cluster.Name, cluster.ContextName, context))
+			continue
+		}
+		contexts[cluster.Name] = cluster.ContextName
+	}
+	return errors
+}
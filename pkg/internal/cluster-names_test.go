@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateClusterNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		workers  []Cluster
+		expected []string
+	}{
+		{
+			name: "Names derived from EKS contexts and contexts with spaces",
+			workers: []Cluster{
+				{ContextName: "arn:aws:eks:us-east-1:1234:cluster/prod"},
+				{ContextName: "Prod Cluster (EU)"},
+			},
+		},
+		{
+			name:     "Invalid name",
+			workers:  []Cluster{{Name: "Prod Cluster", ContextName: "prod cluster"}},
+			expected: []string{`configuration.cluster_configuration.workers[0]: cluster name "Prod Cluster" is invalid: must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character. Set a valid name for context "prod cluster", e.g. name: prod-cluster`},
+		},
+		{
+			name:     "Contexts sanitized to the same name",
+			workers:  []Cluster{{ContextName: "prod_1"}, {ContextName: "prod-1"}},
+			expected: []string{`configuration.cluster_configuration.workers[1]: cluster name "prod-1" of context "prod-1" is also the name of context "prod_1"`},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cc := ClusterConfiguration{ControllerCluster: Cluster{ContextName: "gke_project_us-central1_controller"}, WorkerClusters: tc.workers}
+			DeriveClusterNames(&cc)
+			if cc.ControllerCluster.Name != "gke-project-us-central1-controller" {
+				t.Errorf("controller name = %q, want gke-project-us-central1-controller", cc.ControllerCluster.Name)
+			}
+			errors := ValidateClusterNames(cc)
+			if len(errors) != len(tc.expected) {
+				t.Fatalf("ValidateClusterNames() = %v, want %v", errors, tc.expected)
+			}
+			for i, expected := range tc.expected {
+				if !strings.Contains(errors[i], expected) {
+					t.Errorf("ValidateClusterNames()[%d] = %q, want it to contain %q", i, errors[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizedClusterName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"arn:aws:eks:us-east-1:1234:cluster/prod": "arn-aws-eks-us-east-1-1234-cluster-prod",
+		"Prod Cluster (EU)":                       "prod-cluster-eu",
+		"kind-ks-w-1":                             "kind-ks-w-1",
+		strings.Repeat("a", 62) + "/b":            strings.Repeat("a", 62),
+	}
+	for context, expected := range tests {
+		if got := sanitizedClusterName(context); got != expected {
+			t.Errorf("sanitizedClusterName(%q) = %q, want %q", context, got, expected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// completionTimeout bounds the requests of shell completion to the
+// controller cluster, so that completing never hangs on an unreachable one.
+var completionTimeout = "2s"
+
+// completionResources are the resources of the object types whose names are
+// completed from the controller cluster.
+var completionResources = map[string]string{
+	"project":             ProjectObject,
+	"sliceConfig":         SliceConfigObject,
+	"slice":               SliceConfigObject,
+	"serviceExportConfig": ServiceExportConfigObject,
+	"worker":              ClusterObject,
+}
+
+// ProfileNames returns the profiles install accepts, in the order they are
+// listed.
+func ProfileNames() []string {
+	return append([]string{}, profileNames...)
+}
+
+// ClusterNames returns the names of the clusters of a topology, only those of
+// the workers when workers is set.
+func ClusterNames(specs *ConfigurationSpecs, workers bool) []string {
+	cc := &specs.Configuration.ClusterConfiguration
+	names := make([]string, 0)
+	for _, cluster := range getAllClusters(cc) {
+		if workers && cluster == &cc.ControllerCluster {
+			continue
+		}
+		if cluster.Name != "" {
+			names = append(names, cluster.Name)
+		}
+	}
+	return names
+}
+
+// ObjectNames returns the names of the objects of a type in a namespace of
+// the controller cluster, for shell completion. Anything going wrong, e.g.
+// an unreachable cluster, yields no names rather than an error.
+func ObjectNames(cluster *Cluster, objectType, namespace string) []string {
+	resource, found := completionResources[objectType]
+	if !found {
+		return nil
+	}
+	if objectType == "project" {
+		// projects live in the namespace of the controller
+		namespace = KUBESLICE_CONTROLLER_NAMESPACE
+		if cluster != nil {
+			namespace = ControllerNamespace(*cluster)
+		}
+	}
+	if namespace == "" {
+		return nil
+	}
+	args := append(kubectlClusterArgs(cluster), "--request-timeout", completionTimeout, "get", resource, "-n", namespace, "-o", "jsonpath={.items[*].metadata.name}")
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+		return nil
+	}
+	return strings.Fields(outB.String())
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestObjectNames(t *testing.T) {
+	controller := &Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl", KubeConfigPath: "kubeconfig.yaml"}
+	tests := []struct {
+		name       string
+		cluster    *Cluster
+		objectType string
+		namespace  string
+		expected   []string
+		command    string
+	}{
+		{
+			name:       "SliceConfigs of the project",
+			cluster:    controller,
+			objectType: "sliceConfig",
+			namespace:  "kubeslice-demo",
+			expected:   []string{"blue", "red"},
+			command:    "kubectl --context=kind-ks-ctrl --kubeconfig=kubeconfig.yaml --request-timeout 2s get sliceconfigs.controller.kubeslice.io -n kubeslice-demo -o jsonpath={.items[*].metadata.name}",
+		},
+		{
+			name:       "Projects of the controller namespace",
+			cluster:    controller,
+			objectType: "project",
+			namespace:  "kubeslice-demo",
+			expected:   []string{"demo"},
+			command:    "kubectl --context=kind-ks-ctrl --kubeconfig=kubeconfig.yaml --request-timeout 2s get projects.controller.kubeslice.io -n kubeslice-controller -o jsonpath={.items[*].metadata.name}",
+		},
+		{
+			name:       "Unreachable cluster",
+			cluster:    &Cluster{Name: "ks-gone", ContextName: "kind-ks-gone", KubeConfigPath: "kubeconfig.yaml"},
+			objectType: "sliceConfig",
+			namespace:  "kubeslice-demo",
+			command:    "kubectl --context=kind-ks-gone --kubeconfig=kubeconfig.yaml --request-timeout 2s get sliceconfigs.controller.kubeslice.io -n kubeslice-demo -o jsonpath={.items[*].metadata.name}",
+		},
+		{
+			name:       "Type without names",
+			cluster:    controller,
+			objectType: "ui-endpoint",
+			namespace:  "kubeslice-demo",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				line := strings.Join(command, " ")
+				switch {
+				case strings.Contains(line, "kind-ks-gone"):
+					fmt.Fprint(stderr, "Unable to connect to the server: context deadline exceeded")
+					return errors.New("exit status 1")
+				case strings.Contains(line, "sliceconfigs"):
+					fmt.Fprint(stdout, "blue red")
+				case strings.Contains(line, "projects"):
+					fmt.Fprint(stdout, "demo")
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			got := ObjectNames(tc.cluster, tc.objectType, tc.namespace)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ObjectNames() = %q, want %q", got, tc.expected)
+			}
+			commands := make([]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				commands = append(commands, strings.Join(command, " "))
+			}
+			if tc.command == "" {
+				if len(commands) != 0 {
+					t.Errorf("commands = %q, want none", commands)
+				}
+				return
+			}
+			if !reflect.DeepEqual(commands, []string{tc.command}) {
+				t.Errorf("commands = %q, want %q", commands, tc.command)
+			}
+		})
+	}
+}
+
+func TestClusterNames(t *testing.T) {
+	t.Parallel()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+		ControllerCluster: Cluster{Name: "ks-ctrl"},
+		WorkerClusters:    []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}},
+	}}}
+	if got, expected := ClusterNames(specs, false), []string{"ks-ctrl", "ks-w-1", "ks-w-2"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("ClusterNames() = %q, want %q", got, expected)
+	}
+	if got, expected := ClusterNames(specs, true), []string{"ks-w-1", "ks-w-2"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("ClusterNames(workers) = %q, want %q", got, expected)
+	}
+}
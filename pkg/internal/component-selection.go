@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// SelectableComponents are the components install --components selects, in
+// install order.
+var SelectableComponents = []string{CertManager_Component, Controller_Component, UI_install_Component, Worker_Component, Prometheus_Component}
+
+// controllerSideComponents are installed on the controller cluster, the others
+// on the workers.
+var controllerSideComponents = []string{CertManager_Component, Controller_Component, UI_install_Component}
+
+// ValidateComponents checks that the selected components are known.
+func ValidateComponents(components []string) error {
+	for _, component := range components {
+		if !containsString(SelectableComponents, component) {
+			return fmt.Errorf("Unknown component: %s. Possible values %s", component, SelectableComponents)
+		}
+	}
+	return nil
+}
+
+// componentSelected tells whether an install with the selected components
+// installs a component, every component is selected by an empty selection.
+func componentSelected(components []string, component string) bool {
+	return len(components) == 0 || containsString(components, component)
+}
+
+// touchesCluster tells whether one of the charts installs onto the controller
+// cluster, or onto the workers.
+func touchesCluster(controller bool, charts []string) bool {
+	for _, chart := range charts {
+		if containsString(controllerSideComponents, chart) == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedClusters returns the clusters the selected components are installed
+// onto, the only ones the kind and Calico steps set up.
+func selectedClusters(cc *ClusterConfiguration) []*Cluster {
+	charts := Options.Components
+	if len(charts) == 0 {
+		return getAllClusters(cc)
+	}
+	clusters := make([]*Cluster, 0, len(cc.WorkerClusters)+1)
+	for _, cluster := range getAllClusters(cc) {
+		if touchesCluster(cluster == &cc.ControllerCluster, charts) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
+}
+
+// VerifyComponentDependencies checks what the components of the plan need
+// from those left out of it before anything is installed. Workers installed
+// without the controller need it reachable, their registration secrets
+// created and the controller endpoint of the secrets to answer with their
+// credentials, the KubeSlice Manager needs the controller release.
+func VerifyComponentDependencies(specs *ConfigurationSpecs, plan *InstallPlan) {
+	if plan.Includes(Controller_Component) {
+		return
+	}
+	errors := make([]string, 0)
+	if plan.Includes(UI_install_Component) {
+		if err := verifyControllerInstalled(specs); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+	if plan.Includes(Worker_Component) {
+		probed := false
+		for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+			secret, err := lookupWorkerSecret(cluster, specs.Configuration)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("worker %s is not registered with the controller: %v", cluster.Name, err))
+				continue
+			}
+			// the workers of a topology share the endpoint of the controller
+			if probed {
+				continue
+			}
+			probed = true
+			if err := probeControllerEndpoint(secret); err != nil {
+				// the host may not reach the addresses of the kind network
+				if kindTopology(specs.Configuration.ClusterConfiguration) {
+					util.Printf("%s %v, the workers may reach it on the kind network", util.Warn, err)
+					continue
+				}
+				errors = append(errors, err.Error())
+			}
+		}
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf("%s %s", util.Cross, s)
+		}
+		util.Fatalf("%s The components %s depend on the controller, install it first with --components %s", util.Cross, strings.Join(plan.Charts(), ","), Controller_Component)
+	}
+	util.Printf("%s The controller on %s is ready for the components %s", util.Tick, specs.Configuration.ClusterConfiguration.ControllerCluster.Name, strings.Join(plan.Charts(), ", "))
+}
+
+// probeControllerEndpoint checks that the controller endpoint of the secret
+// of a registered worker answers with the credentials of the secret, those
+// the worker operator connects with.
+func probeControllerEndpoint(secret workerSecret) error {
+	decoded := make(map[string]string, len(workerSecretKeys))
+	for _, key := range []string{"controllerEndpoint", "token"} {
+		value, err := base64.StdEncoding.DecodeString(secret.Data[key])
+		if err != nil {
+			return fmt.Errorf("key %s of %s is not valid base64: %v", key, secret, err)
+		}
+		decoded[key] = string(value)
+	}
+	util.RegisterSecret(decoded["token"])
+	endpoint := decoded["controllerEndpoint"]
+	config := &kubeconfig{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []kubeconfigEntry{{Name: "controller", Fields: map[string]interface{}{
+			"cluster": map[string]interface{}{"server": endpoint, "certificate-authority-data": secret.Data["ca.crt"]},
+		}}},
+		Users: []kubeconfigEntry{{Name: "worker", Fields: map[string]interface{}{
+			"user": map[string]interface{}{"token": decoded["token"]},
+		}}},
+		Contexts: []kubeconfigEntry{{Name: "controller", Fields: map[string]interface{}{
+			"context": map[string]interface{}{"cluster": "controller", "user": "worker"},
+		}}},
+		CurrentContext: "controller",
+	}
+	fileName := filepath.Join(kubesliceDirectory, "controller-endpoint-kubeconfig.yaml")
+	if err := writeKubeconfig(fileName, config); err != nil {
+		return err
+	}
+	defer os.Remove(fileName)
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--kubeconfig="+fileName, "--request-timeout", "10s", "get", "--raw", "/readyz"); err != nil {
+		return fmt.Errorf("controller endpoint %s of %s is not reachable: %s", endpoint, secret, commandError(err, &errB))
+	}
+	return nil
+}
+
+// verifyControllerInstalled checks that the controller release is deployed on
+// the controller cluster.
+func verifyControllerInstalled(specs *ConfigurationSpecs) error {
+	cr := componentReleases(specs)[Controller_Component]
+	cluster := specs.Configuration.ClusterConfiguration.ControllerCluster
+	release, err := findRelease(cluster, cr.release, cr.namespace(cluster))
+	if err != nil {
+		return fmt.Errorf("controller cluster %s is not reachable: %v", cluster.Name, err)
+	}
+	if release == nil {
+		return fmt.Errorf("release %s of the controller is not installed on cluster %s", cr.release, cluster.Name)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestComponentSelection(t *testing.T) {
+	defer func(components []string) { Options.Components = components }(Options.Components)
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	kubesliceDirectory = t.TempDir()
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		ClusterConfiguration: ClusterConfiguration{
+			ClusterType:       "cloud",
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl", KubeConfigPath: "kubeconfig.yaml"},
+			WorkerClusters:    []Cluster{{Name: "ks-w-1", ContextName: "ks-w-1", KubeConfigPath: "kubeconfig.yaml"}},
+		},
+		HelmChartConfiguration: HelmChartConfiguration{
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker"},
+			UIChart:         HelmChart{ChartName: "kubeslice-ui"},
+		},
+	}}
+	preflight := func(context string) []string {
+		prefix := "kubectl --context=" + context + " --kubeconfig=kubeconfig.yaml --request-timeout 10s "
+		return []string{
+			prefix + "get --raw /readyz",
+			prefix + "version -o json",
+			prefix + "auth can-i create namespaces",
+			prefix + "auth can-i create customresourcedefinitions.apiextensions.k8s.io",
+		}
+	}
+	tests := []struct {
+		name       string
+		components []string
+		steps      []string
+		commands   []string
+	}{
+		{
+			name:     "All",
+			steps:    []string{"controller", "ui", "worker-registration", "worker"},
+			commands: append(preflight("ks-ctrl"), preflight("ks-w-1")...),
+		},
+		{
+			name:       "Controller",
+			components: []string{"controller"},
+			steps:      []string{"controller", "worker-registration"},
+			commands:   preflight("ks-ctrl"),
+		},
+		{
+			name:       "Worker",
+			components: []string{"worker"},
+			steps:      []string{"worker"},
+			commands: append(preflight("ks-w-1"),
+				"kubectl --context=ks-ctrl --kubeconfig=kubeconfig.yaml get serviceaccounts -o jsonpath={.items[*].metadata.name} -n kubeslice-demo",
+				"kubectl --context=ks-ctrl --kubeconfig=kubeconfig.yaml get secret kubeslice-rbac-worker-ks-w-1 -n kubeslice-demo -o json",
+				"kubectl --kubeconfig="+kubesliceDirectory+"/controller-endpoint-kubeconfig.yaml --request-timeout 10s get --raw /readyz",
+			),
+		},
+		{
+			name:       "UI",
+			components: []string{"ui"},
+			steps:      []string{"ui"},
+			commands: append(preflight("ks-ctrl"),
+				"helm --kube-context ks-ctrl --kubeconfig kubeconfig.yaml list --namespace kubeslice-controller --filter ^kubeslice-controller$ --all --output json",
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.Components = tc.components
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch line := strings.Join(command, " "); {
+				case strings.Contains(line, " version "):
+					fmt.Fprint(stdout, `{"serverVersion": {"gitVersion": "v1.27.3"}}`)
+				case strings.Contains(line, " auth can-i "):
+					fmt.Fprintln(stdout, "yes")
+				case strings.Contains(line, "get serviceaccounts"):
+					fmt.Fprint(stdout, "kubeslice-rbac-worker-ks-w-1")
+				case strings.Contains(line, "get secret"):
+					fmt.Fprint(stdout, `{"data": {"namespace": "a3ViZXNsaWNlLWRlbW8=", "controllerEndpoint": "aHR0cHM6Ly9jb250cm9sbGVy", "ca.crt": "Y2E=", "token": "czNjcjN0LXdvcmtlci10b2tlbg=="}}`)
+				case command[0] == "helm":
+					fmt.Fprint(stdout, `[{"name": "kubeslice-controller", "namespace": "kubeslice-controller", "revision": "1", "status": "deployed", "chart": "kubeslice-controller-1.1.0"}]`)
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			plan := BuildInstallPlan(specs, map[string]string{CertManager_Component: ""})
+			if !reflect.DeepEqual(plan.Steps, tc.steps) {
+				t.Errorf("BuildInstallPlan() = %v, want %v", plan.Steps, tc.steps)
+			}
+			RunPreflightChecks(specs, plan.Charts(), false)
+			VerifyComponentDependencies(specs, plan)
+			commands := make([]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				commands = append(commands, strings.Join(command, " "))
+			}
+			if !reflect.DeepEqual(commands, tc.commands) {
+				t.Errorf("commands =\n%s\nwant\n%s", strings.Join(commands, "\n"), strings.Join(tc.commands, "\n"))
+			}
+		})
+	}
+}
+
+func TestSelectedClusters(t *testing.T) {
+	defer func(components []string) { Options.Components = components }(Options.Components)
+	cc := &ClusterConfiguration{
+		ControllerCluster: Cluster{Name: "ks-ctrl"},
+		WorkerClusters:    []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}},
+	}
+	tests := []struct {
+		name       string
+		components []string
+		clusters   []string
+	}{
+		{name: "All", clusters: []string{"ks-ctrl", "ks-w-1", "ks-w-2"}},
+		{name: "Controller", components: []string{"controller"}, clusters: []string{"ks-ctrl"}},
+		{name: "UI", components: []string{"ui"}, clusters: []string{"ks-ctrl"}},
+		{name: "Worker", components: []string{"worker"}, clusters: []string{"ks-w-1", "ks-w-2"}},
+		{name: "Controller and worker", components: []string{"controller", "worker"}, clusters: []string{"ks-ctrl", "ks-w-1", "ks-w-2"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.Components = tc.components
+			var clusters []string
+			for _, cluster := range selectedClusters(cc) {
+				clusters = append(clusters, cluster.Name)
+			}
+			if !reflect.DeepEqual(clusters, tc.clusters) {
+				t.Errorf("selectedClusters() = %v, want %v", clusters, tc.clusters)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package internal
+
+import (
+	"path/filepath"
+)
+
+// ResolveRelativePaths makes the file paths of the topology which are relative
+// resolve against dir, the directory of the topology file.
+func ResolveRelativePaths(specs *ConfigurationSpecs, dir string) {
+	hc := &specs.Configuration.HelmChartConfiguration
+	paths := []*string{&hc.CAFile, &hc.CertFile, &hc.KeyFile, &specs.Configuration.License.File}
+	names, charts := topologyCharts(hc)
+	for _, name := range names {
+		chart := charts[name]
+		paths = append(paths, &chart.LocalPath, &chart.CAFile, &chart.CertFile, &chart.KeyFile, &chart.Keyring)
+	}
+	for _, path := range paths {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+}
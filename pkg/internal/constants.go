@@ -2,6 +2,7 @@ package internal
 
 const (
 	KUBESLICE_CONTROLLER_NAMESPACE = "kubeslice-controller"
+	KUBESLICE_WORKER_NAMESPACE     = "kubeslice-system"
 	ProjectObject                  = "projects.controller.kubeslice.io"
 	ClusterObject                  = "clusters.controller.kubeslice.io"
 	SliceConfigObject              = "sliceconfigs.controller.kubeslice.io"
@@ -18,6 +19,7 @@ const (
 	Demo_Component                = "demo"
 	CertManager_Component         = "cert-manager"
 	Prometheus_Component          = "prometheus"
+	Monitoring_Component          = "monitoring"
 	SecretObject                  = "secrets"
 	OutputFormatYaml              = "yaml"
 	OutputFormatJson              = "json"
@@ -2,16 +2,11 @@ package internal
 
 import (
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-const (
-	controllerValuesFileName = "helm-values-controller.yaml"
-)
-
 const controllerValuesTemplate = `
 kubeslice:
   controller:
@@ -26,20 +21,31 @@ func InstallKubeSliceController(ApplicationConfiguration *ConfigurationSpecs) {
 
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration
 	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
-	generateControllerValuesFile(cc.ControllerCluster, ApplicationConfiguration.Configuration.HelmChartConfiguration)
-	util.Printf("%s Generated Helm Values file for Controller Installation %s", util.Tick, controllerValuesFileName)
+	if leaveAsInstalled(cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE) {
+		return
+	}
+	namespace := ControllerNamespace(cc.ControllerCluster)
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.ControllerChart)
+	generateControllerValuesFile(cc.ControllerCluster, ApplicationConfiguration.Configuration, valuesFile)
+	util.Printf("%s Generated Helm Values file for Controller Installation %s", util.Tick, valuesFile)
 	time.Sleep(200 * time.Millisecond)
 
-	installKubeSliceController(cc.ControllerCluster, hc)
+	reuseReleaseValues(cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE, namespace, valuesFile)
+
+	showValuesDiff(cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE, namespace, valuesFile)
+	CreateNamespace(cc.ControllerCluster, namespace, ApplicationConfiguration.Configuration.KubeSliceConfiguration)
+	ApplyLicenseSecret(ApplicationConfiguration)
+	installKubeSliceController(cc.ControllerCluster, hc, valuesFile)
+	recordValuesChecksum(InstallationName(ApplicationConfiguration), cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE, valuesFile)
 	util.Printf("%s Successfully installed helm chart %s/%s", util.Tick, hc.RepoAlias, hc.ControllerChart.ChartName)
 	time.Sleep(2 * time.Second)
 
 	util.Printf("%s Waiting for KubeSlice Controller Pods to be Healthy...", util.Wait)
-	PodVerification("Waiting for KubeSlice Controller Pods to be Healthy", cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE)
+	WaitForRollout(cc.ControllerCluster, "kubeslice-controller", namespace)
 
 	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" && ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == ProfileEntDemo {
 		util.Printf("%s Waiting for KubeSlice Trial License to be Ready...", util.Wait)
-		LicenseVerification("Waiting for KubeSlice Trial License to be Ready", cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE)
+		LicenseVerification("Waiting for KubeSlice Trial License to be Ready", cc.ControllerCluster, namespace)
 	}
 
 	util.Printf("%s Successfully installed KubeSlice Controller.\n", util.Tick)
@@ -57,30 +63,41 @@ func UninstallKubeSliceController(ApplicationConfiguration *ConfigurationSpecs)
 	// util.Printf("%s Waiting for KubeSlice Manager Pods to be removed...", util.Wait)
 }
 
-func generateControllerValuesFile(cluster Cluster, hcConfig HelmChartConfiguration) {
-	err := generateValuesFile(kubesliceDirectory+"/"+controllerValuesFileName, &hcConfig.ControllerChart, fmt.Sprintf(controllerValuesTemplate+generateImagePullSecretsValue(hcConfig.ImagePullSecret), cluster.ControlPlaneAddress))
-	if err != nil {
-		log.Fatalf("%s %s", util.Cross, err)
+// generateControllerValuesFile writes the controller values, with the metrics
+// of the controller enabled by monitoring and the license secret referenced.
+func generateControllerValuesFile(cluster Cluster, config Configuration, valuesFile string) {
+	hcConfig := config.HelmChartConfiguration
+	if err := substituteChartPlaceholders(&hcConfig.ControllerChart, cluster, config); err != nil {
+		util.Fatalf("%s %s", util.Cross, err)
 	}
-}
-
-func installKubeSliceController(cluster Cluster, hc HelmChartConfiguration) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "upgrade", "-i", KUBESLICE_CONTROLLER_NAMESPACE, fmt.Sprintf("%s/%s", hc.RepoAlias, hc.ControllerChart.ChartName), "--namespace", KUBESLICE_CONTROLLER_NAMESPACE, "--create-namespace", "-f", kubesliceDirectory+"/"+controllerValuesFileName)
-	if hc.ControllerChart.Version != "" {
-		args = append(args, "--version", hc.ControllerChart.Version)
+	verifyValueKeys(hcConfig, &hcConfig.ControllerChart)
+	defaults := fmt.Sprintf(controllerValuesTemplate, cluster.ControlPlaneAddress) + kubesliceImagePullSecretsValue(hcConfig.ImagePullSecrets)
+	var err error
+	if config.Monitoring.Enabled {
+		defaults, err = withMetricsValues(defaults, controllerMetricsValues)
+	}
+	if err == nil && licenseConfigured(config.License) {
+		defaults, err = withValues(defaults, licenseValues(config.License))
+	}
+	if err == nil {
+		err = generateValuesFile(valuesFile, &hcConfig.ControllerChart, defaults, propagatedImagePullSecrets(hcConfig))
+	}
+	if err == nil {
+		err = rewriteImageRegistry(hcConfig, &hcConfig.ControllerChart, valuesFile)
 	}
-	err := util.RunCommand("helm", args...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("%s %s", util.Cross, err)
 	}
 }
 
+func installKubeSliceController(cluster Cluster, hc HelmChartConfiguration, valuesFile string) {
+	installRelease(releaseRequest{Cluster: cluster, Release: KUBESLICE_CONTROLLER_NAMESPACE, Namespace: ControllerNamespace(cluster), Chart: hc.ControllerChart, Repo: hc, ValuesFile: valuesFile})
+}
+
 func uninstallKubeSliceController(cluster Cluster) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "uninstall", KUBESLICE_CONTROLLER_NAMESPACE, "--namespace", KUBESLICE_CONTROLLER_NAMESPACE)
-	err := util.RunCommand("helm", args...)
+	err := chartInstaller.Uninstall(cluster, KUBESLICE_CONTROLLER_NAMESPACE, ControllerNamespace(cluster))
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		diagnoseRelease(cluster, KUBESLICE_CONTROLLER_NAMESPACE, ControllerNamespace(cluster))
+		util.Fatalf("Process failed %v", err)
 	}
 }
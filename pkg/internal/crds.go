@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// controllerCRDs are the CRDs of the controller the Project, Cluster,
+// SliceConfig and ServiceExportConfig CRs are applied for.
+var controllerCRDs = []string{
+	"projects." + kubesliceControllerGroup,
+	"clusters." + kubesliceControllerGroup,
+	"sliceconfigs." + kubesliceControllerGroup,
+	"serviceexportconfigs." + kubesliceControllerGroup,
+}
+
+// How often and how long the CRDs of a freshly installed controller are
+// waited for to be established.
+var (
+	crdPollInterval = 2 * time.Second
+	crdTimeout      = 2 * time.Minute
+)
+
+// establishedCRDs are the clusters the controller CRDs were found established
+// on during the run, by kubeconfig and context.
+var establishedCRDs = make(map[string]bool)
+
+// WaitForControllerCRDs waits until the API server serves the controller
+// CRDs, which the chart of a freshly installed controller only registers
+// after the release is installed. Applying a CR before fails with no matches
+// for kind.
+func WaitForControllerCRDs(controllerCluster *Cluster) {
+	if err := waitForCRDsEstablished(controllerCluster, controllerCRDs); err != nil {
+		namespace := KUBESLICE_CONTROLLER_NAMESPACE
+		if controllerCluster != nil {
+			namespace = ControllerNamespace(*controllerCluster)
+		}
+		printRecentEvents(controllerCluster, namespace)
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+}
+
+func waitForCRDsEstablished(cluster *Cluster, crds []string) error {
+	key := ""
+	if cluster != nil {
+		key = cluster.KubeConfigPath + "\x00" + cluster.ContextName
+	}
+	if establishedCRDs[key] {
+		return nil
+	}
+	namespace := KUBESLICE_CONTROLLER_NAMESPACE
+	if cluster != nil {
+		namespace = ControllerNamespace(*cluster)
+	}
+	start := time.Now()
+	for {
+		pending := make([]string, 0)
+		for _, crd := range crds {
+			established, err := crdEstablished(cluster, crd)
+			if err != nil {
+				return fmt.Errorf("unable to get CRD %s: %v", crd, err)
+			}
+			if !established {
+				pending = append(pending, crd)
+			}
+		}
+		if len(pending) == 0 {
+			establishedCRDs[key] = true
+			return nil
+		}
+		elapsed := time.Since(start)
+		if elapsed >= crdTimeout {
+			return fmt.Errorf("timed out after %d seconds waiting for the CRDs %s to be established, check the pods of the controller with kubectl get pods -n %s",
+				int(elapsed.Seconds()), strings.Join(pending, ", "), namespace)
+		}
+		util.Printf("%s Waiting for the CRDs %s to be established... %d seconds elapsed", util.Wait, strings.Join(pending, ", "), int(elapsed.Seconds()))
+		time.Sleep(crdPollInterval)
+	}
+}
+
+// crdEstablished tells whether a CRD exists and its Established condition
+// is true.
+func crdEstablished(cluster *Cluster, name string) (bool, error) {
+	crd, err := kubeClient.Get(cluster, crdResource, "", name)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	status, _ := crd["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		if condition["type"] == "Established" {
+			return condition["status"] == "True", nil
+		}
+	}
+	return false, nil
+}
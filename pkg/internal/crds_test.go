@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForCRDsEstablished(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func(established map[string]bool) { establishedCRDs = established }(establishedCRDs)
+	defer func(interval, timeout time.Duration) { crdPollInterval, crdTimeout = interval, timeout }(crdPollInterval, crdTimeout)
+	crdPollInterval, crdTimeout = time.Millisecond, 10*time.Millisecond
+
+	tests := []struct {
+		name        string
+		established []string
+		expected    string
+	}{
+		{name: "Established", established: controllerCRDs},
+		{
+			name:        "Timed out",
+			established: controllerCRDs[:2],
+			expected:    "the CRDs sliceconfigs.controller.kubeslice.io, serviceexportconfigs.controller.kubeslice.io to be established, check the pods of the controller",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			establishedCRDs = make(map[string]bool)
+			kubeClient = &fakeKubeClient{established: tc.established}
+			err := waitForCRDsEstablished(&Cluster{ContextName: "kind-controller"}, controllerCRDs)
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("waitForCRDsEstablished() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("waitForCRDsEstablished() error = %v, want an error containing %q", err, tc.expected)
+			}
+		})
+	}
+}
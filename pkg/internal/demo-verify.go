@@ -0,0 +1,322 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// The workloads the connectivity of a slice is verified with. They are named
+// apart from the iperf demo applications, which stay installed.
+const (
+	verifyServerName     = "iperf-verify-server"
+	verifyClientName     = "iperf-verify-client"
+	verifyServerFileName = "iperf-verify-server.yaml"
+	verifyClientFileName = "iperf-verify-client.yaml"
+	verifyServerPort     = 5201
+	serviceImportObject  = "serviceimports.networking.kubeslice.io"
+)
+
+var (
+	// verifyDuration is how long the iperf client sends.
+	verifyDuration = 10 * time.Second
+	// verifyPollInterval is how often the ServiceImport is polled for.
+	verifyPollInterval = 5 * time.Second
+	// verifyQoSTolerance is how much the measured bandwidth may exceed the
+	// bandwidth ceiling of the slice, shaping is not exact.
+	verifyQoSTolerance = 0.1
+)
+
+const verifyServerTemplate = `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %[2]s
+  namespace: %[1]s
+  labels:
+    app: %[2]s
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: %[2]s
+  template:
+    metadata:
+      labels:
+        app: %[2]s
+    spec:
+      containers:
+      - name: iperf
+        image: mlabbe/iperf
+        imagePullPolicy: IfNotPresent
+        args:
+          - '-s'
+          - '-p'
+          - '%[3]d'
+        ports:
+        - containerPort: %[3]d
+          name: server
+`
+
+const verifyClientTemplate = `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %[2]s
+  namespace: %[1]s
+  labels:
+    app: %[2]s
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: %[2]s
+  template:
+    metadata:
+      labels:
+        app: %[2]s
+    spec:
+      containers:
+      - name: iperf
+        image: mlabbe/iperf
+        imagePullPolicy: IfNotPresent
+        command: ["/bin/sleep", "3650d"]
+`
+
+// iperfBandwidth matches the bandwidth of an iperf report line, e.g.
+// [  3]  0.0-10.0 sec  6.12 MBytes  5132 Kbits/sec.
+var iperfBandwidth = regexp.MustCompile(`([\d.]+)\s+([KMG]?)bits/sec`)
+
+// sliceVerification is where the connectivity of a slice is verified: the
+// server runs on the first worker of the slice and the client on the second,
+// in a namespace of the slice on both. The server is exported with a
+// ServiceExportConfig in the namespace of the project on the controller.
+type sliceVerification struct {
+	slice            SliceConfiguration
+	namespace        string
+	server           Cluster
+	client           Cluster
+	controller       Cluster
+	projectNamespace string
+	// clusters the verification created the namespace on
+	createdNamespace []string
+}
+
+// VerifyDemo deploys an iperf server and client on two workers of the slice,
+// exports the server over the slice and measures the bandwidth the client
+// achieves. With checkQoS the bandwidth must not exceed the ceiling of the QoS
+// profile of the slice. The workloads are removed afterwards, also when the
+// verification fails or the process exits early.
+func VerifyDemo(ApplicationConfiguration *ConfigurationSpecs, checkQoS bool) error {
+	util.Printf("\nVerifying the connectivity of the slice...")
+	v, err := newSliceVerification(ApplicationConfiguration)
+	if err != nil {
+		return err
+	}
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() { cleanupVerification(v) })
+	}
+	util.AtExit(cleanup)
+	defer cleanup()
+
+	bandwidth, err := runVerification(&v)
+	if err != nil {
+		return err
+	}
+	message, passed := evaluateVerification(bandwidth, sliceQoSProfile(v.slice.QoSProfile).BandwidthCeilingKbps, checkQoS)
+	if !passed {
+		return errors.New(message)
+	}
+	util.Printf("%s %s", util.Tick, message)
+	return nil
+}
+
+// newSliceVerification picks the clusters and the namespace of a slice to
+// verify it on.
+func newSliceVerification(ApplicationConfiguration *ConfigurationSpecs) (sliceVerification, error) {
+	slice := configuredSlice(ApplicationConfiguration, nil, "")
+	workers := make([]Cluster, 0)
+	for _, cluster := range ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters {
+		if containsString(slice.Clusters, cluster.Name) {
+			workers = append(workers, cluster)
+		}
+	}
+	if len(workers) < 2 {
+		return sliceVerification{}, fmt.Errorf("slice %s must join at least two workers of the topology to be verified", slice.Name)
+	}
+	v := sliceVerification{
+		slice:            slice,
+		server:           workers[0],
+		client:           workers[1],
+		controller:       ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster,
+		projectNamespace: projectNamespace(ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName),
+	}
+	for _, ns := range slice.ApplicationNamespaces {
+		onServer := containsString(ns.Clusters, "*") || containsString(ns.Clusters, v.server.Name)
+		onClient := containsString(ns.Clusters, "*") || containsString(ns.Clusters, v.client.Name)
+		if onServer && onClient {
+			v.namespace = ns.Namespace
+			break
+		}
+	}
+	if v.namespace == "" {
+		return sliceVerification{}, fmt.Errorf("slice %s has no application namespace on both %s and %s", slice.Name, v.server.Name, v.client.Name)
+	}
+	return v, nil
+}
+
+// verifyServiceExport is the export of the iperf server of a verification.
+func verifyServiceExport(v *sliceVerification) ServiceExport {
+	return ServiceExport{
+		Slice:            v.slice.Name,
+		Service:          verifyServerName,
+		ServiceNamespace: v.namespace,
+		SourceCluster:    v.server.Name,
+		Ports:            []string{fmt.Sprintf("%d/TCP", verifyServerPort)},
+	}
+}
+
+// runVerification deploys the workloads and returns the bandwidth in Kbits/sec
+// the client achieved. The clusters the namespace of the workloads is created
+// on are recorded, for the cleanup to delete it there.
+func runVerification(v *sliceVerification) (float64, error) {
+	GenerateKubeSliceDirectory()
+	util.DumpFile(fmt.Sprintf(verifyServerTemplate, v.namespace, verifyServerName, verifyServerPort), kubesliceDirectory+"/"+verifyServerFileName)
+	util.DumpFile(fmt.Sprintf(verifyClientTemplate, v.namespace, verifyClientName), kubesliceDirectory+"/"+verifyClientFileName)
+
+	for _, cluster := range []Cluster{v.server, v.client} {
+		exists, err := objectExists(&cluster, "namespaces", "", v.namespace)
+		if err != nil {
+			return 0, fmt.Errorf("unable to look up namespace %s on %s: %v", v.namespace, cluster.Name, err)
+		}
+		if !exists {
+			v.createdNamespace = append(v.createdNamespace, cluster.Name)
+		}
+	}
+	if err := kubeClient.Apply(&v.server, v.namespace, kubesliceDirectory+"/"+verifyServerFileName); err != nil {
+		return 0, fmt.Errorf("unable to deploy the iperf server on %s: %v", v.server.Name, err)
+	}
+	util.Printf("%s Deployed the iperf server on %s", util.Tick, v.server.Name)
+	if err := kubeClient.Apply(&v.client, v.namespace, kubesliceDirectory+"/"+verifyClientFileName); err != nil {
+		return 0, fmt.Errorf("unable to deploy the iperf client on %s: %v", v.client.Name, err)
+	}
+	util.Printf("%s Deployed the iperf client on %s", util.Tick, v.client.Name)
+	for _, cluster := range []Cluster{v.server, v.client} {
+		if err := waitForRollout(cluster, "iperf", v.namespace); err != nil {
+			return 0, fmt.Errorf("iperf is not ready on %s: %v", cluster.Name, err)
+		}
+	}
+
+	GenerateAndApplyServiceExportConfig(verifyServiceExport(v), v.projectNamespace, &v.controller, false)
+	util.Printf("%s Exported the iperf server to slice %s", util.Tick, v.slice.Name)
+	err := PollUntil(verifyPollInterval, Options.RolloutTimeout, func(elapsed time.Duration) (bool, error) {
+		imported, err := objectExists(&v.client, serviceImportObject, v.namespace, verifyServerName)
+		if err != nil || imported {
+			return imported, err
+		}
+		util.Printf("%s Waiting for the ServiceImport of the iperf server on %s... %d seconds elapsed", util.Wait, v.client.Name, int(elapsed.Seconds()))
+		return false, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("the iperf server was not imported on %s: %v", v.client.Name, err)
+	}
+	util.Printf("%s The iperf server is imported on %s", util.Tick, v.client.Name)
+
+	host := fmt.Sprintf("%s.%s.svc.slice.local", verifyServerName, v.namespace)
+	var out string
+	util.Printf("%s Measuring the bandwidth from %s to %s...", util.Run, v.client.Name, v.server.Name)
+	// the DNS entry of the import takes a moment to propagate
+	err = Retry(5, 5*time.Second, func() error {
+		out, err = runKubectl(v.client, "exec", "deploy/"+verifyClientName, "-n", v.namespace, "-c", "iperf", "--",
+			"iperf", "-c", host, "-p", strconv.Itoa(verifyServerPort), "-t", strconv.Itoa(int(verifyDuration.Seconds())), "-f", "k")
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("the iperf client on %s did not reach the server: %v", v.client.Name, err)
+	}
+	return parseIPerfBandwidth(out)
+}
+
+// parseIPerfBandwidth returns the bandwidth of the summary of an iperf client
+// in Kbits/sec, the last bandwidth it reports.
+func parseIPerfBandwidth(out string) (float64, error) {
+	matches := iperfBandwidth.FindAllStringSubmatch(out, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no bandwidth in the iperf output: %s", strings.TrimSpace(out))
+	}
+	last := matches[len(matches)-1]
+	value, err := strconv.ParseFloat(last[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q in the iperf output", last[0])
+	}
+	switch last[2] {
+	case "":
+		value /= 1000
+	case "M":
+		value *= 1000
+	case "G":
+		value *= 1000 * 1000
+	}
+	return value, nil
+}
+
+// evaluateVerification tells whether a measured bandwidth proves the
+// connectivity of the slice and, with checkQoS, its bandwidth ceiling.
+func evaluateVerification(bandwidthKbps float64, ceilingKbps int, checkQoS bool) (string, bool) {
+	switch {
+	case bandwidthKbps <= 0:
+		return "the iperf client did not transfer any data over the slice", false
+	case checkQoS && bandwidthKbps > float64(ceilingKbps)*(1+verifyQoSTolerance):
+		return fmt.Sprintf("measured %.0f Kbits/sec over the slice, above its bandwidth ceiling of %d Kbits/sec: the QoS profile is not enforced", bandwidthKbps, ceilingKbps), false
+	case checkQoS:
+		return fmt.Sprintf("Measured %.0f Kbits/sec over the slice, within its bandwidth ceiling of %d Kbits/sec", bandwidthKbps, ceilingKbps), true
+	}
+	return fmt.Sprintf("Measured %.0f Kbits/sec over the slice", bandwidthKbps), true
+}
+
+// cleanupVerification removes the workloads of a verification, whichever of
+// them were created, and the namespace on the clusters it created it on.
+func cleanupVerification(v sliceVerification) {
+	type deletion struct {
+		cluster   Cluster
+		resource  string
+		namespace string
+		name      string
+	}
+	deletions := []deletion{
+		{v.controller, ServiceExportConfigObject, v.projectNamespace, serviceExportConfigName(verifyServiceExport(&v))},
+		{v.server, "deployments.apps", v.namespace, verifyServerName},
+		{v.client, "deployments.apps", v.namespace, verifyClientName},
+	}
+	for _, cluster := range []Cluster{v.server, v.client} {
+		if containsString(v.createdNamespace, cluster.Name) {
+			deletions = append(deletions, deletion{cluster, "namespaces", "", v.namespace})
+		}
+	}
+	for _, d := range deletions {
+		cluster := d.cluster
+		if err := kubeClient.Delete(&cluster, d.resource, d.namespace, d.name); err != nil && !errors.Is(err, errNotFound) {
+			util.Printf("%s Unable to remove %s %s from %s: %v", util.Warn, d.resource, d.name, cluster.Name, err)
+		}
+	}
+	util.Printf("%s Removed the iperf verification workloads", util.Tick)
+}
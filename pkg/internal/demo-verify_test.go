@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestParseIPerfBandwidth(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		output   string
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name: "Kbits",
+			output: `------------------------------------------------------------
+Client connecting to iperf-verify-server.iperf.svc.slice.local, TCP port 5201
+------------------------------------------------------------
+[  1] local 10.1.1.5 port 41234 connected with 10.1.2.3 port 5201
+[ ID] Interval       Transfer     Bandwidth
+[  1] 0.00-10.02 sec  6.12 MBytes  5124 Kbits/sec`,
+			expected: 5124,
+		},
+		{name: "Mbits", output: "[  3]  0.0-10.0 sec  1.10 GBytes   943 Mbits/sec", expected: 943000},
+		{name: "Summary of the intervals", output: "[  3]  0.0- 1.0 sec  1.00 MBytes  8000 Kbits/sec\n[  3]  0.0-10.0 sec  6.00 MBytes  4800 Kbits/sec", expected: 4800},
+		{name: "No report", output: "connect failed: Connection refused", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseIPerfBandwidth(tc.output)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseIPerfBandwidth() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if got != tc.expected {
+				t.Errorf("parseIPerfBandwidth() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestVerifyDemo(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	defer func(api *apiVersionSummary) { runSummary.ControllerAPI = api }(runSummary.ControllerAPI)
+	kubesliceDirectory = t.TempDir()
+	// the apiVersion of the ServiceExportConfig is detected already
+	runSummary.ControllerAPI = &apiVersionSummary{Version: "v1alpha1"}
+	slice := `apiVersion: controller.kubeslice.io/v1alpha1
+kind: SliceConfig
+metadata:
+  name: demo
+spec:
+  clusters: [ks-w-1, ks-w-2]
+  namespaceIsolationProfile:
+    applicationNamespaces:
+    - namespace: iperf
+      clusters: ["*"]
+`
+	specs := &ConfigurationSpecs{Configuration: Configuration{KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"}, ClusterConfiguration: ClusterConfiguration{
+		ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl", KubeConfigPath: "kubeconfig.yaml"},
+		WorkerClusters: []Cluster{
+			{Name: "ks-w-1", ContextName: "kind-ks-w-1", KubeConfigPath: "kubeconfig.yaml"},
+			{Name: "ks-w-2", ContextName: "kind-ks-w-2", KubeConfigPath: "kubeconfig.yaml"},
+		},
+	}}}
+	tests := []struct {
+		name      string
+		bandwidth string
+		checkQoS  bool
+		expected  string
+	}{
+		{name: "Connected", bandwidth: "9312 Kbits/sec"},
+		{name: "Within the ceiling", bandwidth: "5124 Kbits/sec", checkQoS: true},
+		{name: "Above the ceiling", bandwidth: "9312 Kbits/sec", checkQoS: true, expected: "measured 9312 Kbits/sec over the slice, above its bandwidth ceiling of 5120 Kbits/sec: the QoS profile is not enforced"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeKubeClient{
+				objects:     map[string][]string{objectsKey(serviceImportObject, "iperf"): {verifyServerName}},
+				deployments: map[string][]deploymentReadiness{"iperf": {{Name: verifyServerName, Replicas: 1, Ready: 1, Updated: 1, Available: 1, Observed: true}}},
+			}
+			kubeClient = fake
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch line := strings.Join(command, " "); {
+				case strings.Contains(line, " get sliceconfigs.controller.kubeslice.io demo -n kubeslice-demo"):
+					fmt.Fprint(stdout, slice)
+				case strings.Contains(line, " exec "):
+					fmt.Fprintf(stdout, "[  1] 0.00-10.02 sec  6.12 MBytes  %s\n", tc.bandwidth)
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			got := ""
+			if err := VerifyDemo(specs, tc.checkQoS); err != nil {
+				got = err.Error()
+			}
+			if got != tc.expected {
+				t.Errorf("VerifyDemo() error = %q, want %q", got, tc.expected)
+			}
+			commands := make([]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				commands = append(commands, strings.Join(command, " "))
+			}
+			expectedCommands := []string{
+				"kubectl --context=kind-ks-ctrl --kubeconfig=kubeconfig.yaml get sliceconfigs.controller.kubeslice.io demo -n kubeslice-demo -o yaml",
+				"kubectl --context=kind-ks-ctrl --kubeconfig=kubeconfig.yaml apply -f " + kubesliceDirectory + "/serviceExportConfig-iperf-verify-server-iperf-ks-w-1.yaml -n kubeslice-demo",
+				"kubectl --context=kind-ks-w-2 --kubeconfig=kubeconfig.yaml exec deploy/iperf-verify-client -n iperf -c iperf -- iperf -c iperf-verify-server.iperf.svc.slice.local -p 5201 -t 10 -f k",
+			}
+			if !reflect.DeepEqual(commands, expectedCommands) {
+				t.Errorf("commands = %q, want %q", commands, expectedCommands)
+			}
+			applied := make([]string, 0)
+			for _, manifest := range fake.applied {
+				applied = append(applied, manifest.Context)
+			}
+			if expected := []string{"kind-ks-w-1", "kind-ks-w-2"}; !reflect.DeepEqual(applied, expected) {
+				t.Errorf("applied to %q, want %q", applied, expected)
+			}
+			deleted := []string{
+				"kind-ks-ctrl serviceexportconfigs.controller.kubeslice.io kubeslice-demo/iperf-verify-server-iperf-ks-w-1",
+				"kind-ks-w-1 deployments.apps iperf/iperf-verify-server",
+				"kind-ks-w-2 deployments.apps iperf/iperf-verify-client",
+				"kind-ks-w-1 namespaces /iperf",
+				"kind-ks-w-2 namespaces /iperf",
+			}
+			if !reflect.DeepEqual(fake.deleted, deleted) {
+				t.Errorf("deleted = %q, want %q", fake.deleted, deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// diagnosticLogLines is the number of log lines printed of a crashing
+// container.
+const diagnosticLogLines = "30"
+
+// helmWaitFlags makes helm wait until the resources of a release are ready,
+// so that a release with crash looping pods fails the install.
+func helmWaitFlags() []string {
+	return []string{"--wait", "--timeout", Options.HelmTimeout.String()}
+}
+
+type podStatus struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase      string `json:"phase"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+		ContainerStatuses []struct {
+			Name         string `json:"name"`
+			RestartCount int    `json:"restartCount"`
+			State        struct {
+				Waiting *struct {
+					Reason string `json:"reason"`
+				} `json:"waiting"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func (p podStatus) ready() bool {
+	if p.Status.Phase == "Succeeded" {
+		return true
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == "Ready" {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// crashingContainers returns the containers of the pod which are crash looping
+// or restarted.
+func (p podStatus) crashingContainers() []string {
+	containers := make([]string, 0)
+	for _, c := range p.Status.ContainerStatuses {
+		if (c.State.Waiting != nil && c.State.Waiting.Reason == "CrashLoopBackOff") || c.RestartCount > 0 {
+			containers = append(containers, c.Name)
+		}
+	}
+	return containers
+}
+
+// diagnoseRelease prints what is going on in the namespace of a release which
+// failed to install, upgrade or uninstall: the pods with their statuses, the
+// events of the pods which are not ready and the last log lines of crashing
+// containers. Diagnostics are best effort, failures are only warned about.
+func diagnoseRelease(cluster Cluster, release, namespace string) {
+	util.Printf("\n%s Diagnostics of release %s in namespace %s on cluster %s:", util.Warn, release, namespace, cluster.Name)
+	kubectl := func(args ...string) (string, bool) {
+		var outB, errB bytes.Buffer
+		args = append([]string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath}, args...)
+		if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+			util.Printf("%s Unable to gather diagnostics: %s", util.Warn, util.Redact(strings.TrimSpace(errB.String())))
+			return "", false
+		}
+		return util.Redact(outB.String()), true
+	}
+
+	out, ok := kubectl("get", "pods", "--namespace", namespace, "-o", "wide")
+	if !ok {
+		return
+	}
+	util.Printf("%s", strings.TrimRight(out, "\n"))
+	printRecentEvents(&cluster, namespace)
+	out, ok = kubectl("get", "pods", "--namespace", namespace, "-o", "json")
+	if !ok {
+		return
+	}
+	pods := struct {
+		Items []podStatus `json:"items"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &pods); err != nil {
+		util.Printf("%s Unable to parse pods of namespace %s: %v", util.Warn, namespace, err)
+		return
+	}
+	for _, pod := range pods.Items {
+		if pod.ready() {
+			continue
+		}
+		name := pod.Metadata.Name
+		if events, ok := kubectl("get", "events", "--namespace", namespace, "--field-selector", "involvedObject.kind=Pod,involvedObject.name="+name, "--sort-by", ".lastTimestamp"); ok {
+			util.Printf("\nEvents of pod %s:\n%s", name, strings.TrimRight(events, "\n"))
+		}
+		for _, container := range pod.crashingContainers() {
+			if logs, ok := kubectl("logs", name, "--namespace", namespace, "--container", container, "--previous", "--tail", diagnosticLogLines); ok {
+				util.Printf("\nLast %s log lines of container %s of pod %s:\n%s", diagnosticLogLines, container, name, strings.TrimRight(logs, "\n"))
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const diagnosePods = `{"items":[
+	{"metadata":{"name":"controller-0"},"status":{"phase":"Running","conditions":[{"type":"Ready","status":"False"}],
+		"containerStatuses":[{"name":"manager","restartCount":4,"state":{"waiting":{"reason":"CrashLoopBackOff"}}},{"name":"proxy","restartCount":0,"state":{}}]}},
+	{"metadata":{"name":"webhook-0"},"status":{"phase":"Pending","conditions":[{"type":"Ready","status":"False"}],
+		"containerStatuses":[{"name":"webhook","restartCount":0,"state":{"waiting":{"reason":"ImagePullBackOff"}}}]}},
+	{"metadata":{"name":"healthy-0"},"status":{"phase":"Running","conditions":[{"type":"Ready","status":"True"}]}}
+]}`
+
+func TestDiagnoseRelease(t *testing.T) {
+	mock := &util.MockExecutor{
+		Handler: func(command []string, stdout, stderr io.Writer) error {
+			if reflect.DeepEqual(command[3:], []string{"get", "pods", "--namespace", "kubeslice-controller", "-o", "json"}) {
+				fmt.Fprint(stdout, diagnosePods)
+			}
+			return nil
+		},
+	}
+	defer util.UseExecutor(mock)()
+
+	diagnoseRelease(Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl", KubeConfigPath: "kubeconfig.yaml"}, "kubeslice-controller", "kubeslice-controller")
+
+	expected := [][]string{
+		{"get", "pods", "--namespace", "kubeslice-controller", "-o", "wide"},
+		{"get", "events", "-n", "kubeslice-controller", "--sort-by=.lastTimestamp", "-o", "json"},
+		{"get", "pods", "--namespace", "kubeslice-controller", "-o", "json"},
+		{"get", "events", "--namespace", "kubeslice-controller", "--field-selector", "involvedObject.kind=Pod,involvedObject.name=controller-0", "--sort-by", ".lastTimestamp"},
+		{"logs", "controller-0", "--namespace", "kubeslice-controller", "--container", "manager", "--previous", "--tail", "30"},
+		{"get", "events", "--namespace", "kubeslice-controller", "--field-selector", "involvedObject.kind=Pod,involvedObject.name=webhook-0", "--sort-by", ".lastTimestamp"},
+	}
+	got := make([][]string, 0, len(mock.Commands))
+	for _, command := range mock.Commands {
+		if !reflect.DeepEqual(command[:3], []string{"kubectl", "--context=kind-ks-ctrl", "--kubeconfig=kubeconfig.yaml"}) {
+			t.Fatalf("command %q does not target the cluster", command)
+		}
+		got = append(got, command[3:])
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("command sequence mismatch\nwant: %q\ngot:  %q", expected, got)
+	}
+}
@@ -0,0 +1,276 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// CLIVersion is the version of kubeslice-cli recorded in the diagnostics.
+var CLIVersion = "dev"
+
+// Limits keeping a diagnostics bundle small enough to attach it to an issue.
+const (
+	diagnosticsLogTail     = "200"
+	diagnosticsMaxLogBytes = 256 << 10
+	diagnosticsMaxFile     = 1 << 20
+)
+
+var (
+	secretYAMLValue = regexp.MustCompile(`(?im)^(\s*(?:-\s*)?"?[\w.-]*(?:password|passwd|token|dockerconfigjson|client-key-data|client-certificate-data|ca\.crt|tls\.key|private-key)"?\s*:[ \t]*)\S.*$`)
+	secretJSONValue = regexp.MustCompile(`(?i)("[\w.-]*(?:password|passwd|token|dockerconfigjson|client-key-data|client-certificate-data|ca\.crt|tls\.key|private-key)"\s*:\s*)"[^"]*"`)
+	secretArgument  = regexp.MustCompile(`(?i)(--(?:password|token)[= ])\S+`)
+	bearerToken     = regexp.MustCompile(`(?i)(bearer\s+)\S+`)
+)
+
+// scrubSecrets masks the registered secrets and the values of keys which
+// usually carry credentials in collected output.
+func scrubSecrets(s string) string {
+	s = util.Redact(s)
+	s = secretYAMLValue.ReplaceAllString(s, "${1}******")
+	s = secretJSONValue.ReplaceAllString(s, `${1}"******"`)
+	s = secretArgument.ReplaceAllString(s, "${1}******")
+	return bearerToken.ReplaceAllString(s, "${1}******")
+}
+
+// truncateTail keeps the last max bytes of s.
+func truncateTail(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return fmt.Sprintf("[truncated to the last %d bytes]\n%s", max, s[len(s)-max:])
+}
+
+// CollectDiagnosticsOnFailure collects a diagnostics bundle when the run
+// fails, before the generated files are removed.
+func CollectDiagnosticsOnFailure(specs *ConfigurationSpecs) {
+	util.OnFailure(func() {
+		CollectDiagnostics(specs)
+	})
+}
+
+// CollectDiagnostics writes a bundle of what is needed to investigate a failed
+// install to the workspace: the version and arguments of the cli, the failed
+// commands, the generated values files and manifests, and the pods, describes,
+// events and last log lines of the kubeslice namespaces of every cluster. The
+// collected output is scrubbed of secrets and the logs are size limited.
+// Collecting is best effort, what could not be collected is listed in the
+// bundle. It returns the path of the bundle.
+func CollectDiagnostics(specs *ConfigurationSpecs) string {
+	util.Printf("\nCollecting diagnostics...")
+	failedCommands := util.FailedCommands()
+	name := "diagnostics-" + time.Now().Format("20060102-150405")
+	dir := filepath.Join(kubesliceDirectory, name)
+	bundle := &diagnosticsBundle{dir: dir}
+	bundle.write("cli.txt", diagnosticsSummary())
+	if len(failedCommands) > 0 {
+		var sb strings.Builder
+		for _, command := range failedCommands {
+			fmt.Fprintf(&sb, "$ %s\n%s\nerror: %s\n\n", command.Command, command.Output, command.Err)
+		}
+		bundle.write("failed-commands.txt", sb.String())
+	}
+	bundle.copyWorkspace("workspace")
+	if specs != nil {
+		bundle.collectClusters(specs)
+	}
+	if len(bundle.errors) > 0 {
+		bundle.write("collection-errors.txt", strings.Join(bundle.errors, "\n")+"\n")
+	}
+
+	archive := dir + ".tar.gz"
+	if err := writeTarGz(dir, archive); err != nil {
+		util.Printf("%s Unable to archive the diagnostics: %v", util.Warn, err)
+		util.Printf("%s Collected diagnostics in %s", util.Warn, dir)
+		return dir
+	}
+	os.RemoveAll(dir)
+	util.Printf("%s Collected diagnostics in %s, attach it to the issue you open", util.Warn, archive)
+	util.Printf("%s Run kubeslice-cli collect-diagnostics -c <topology> --workspace %s to collect them again", util.Warn, kubesliceDirectory)
+	return archive
+}
+
+func diagnosticsSummary() string {
+	lines := []string{
+		"kubeslice-cli version: " + CLIVersion,
+		"platform: " + runtime.GOOS + "/" + runtime.GOARCH,
+		"time: " + time.Now().Format(time.RFC3339),
+		"command: " + strings.Join(os.Args, " "),
+	}
+	if message := util.FatalMessage(); message != "" {
+		lines = append(lines, "failure: "+message)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// diagnosticsBundle is the directory the diagnostics are collected to.
+type diagnosticsBundle struct {
+	dir    string
+	errors []string
+}
+
+func (b *diagnosticsBundle) write(name, content string) {
+	path := filepath.Join(b.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		b.errors = append(b.errors, err.Error())
+		return
+	}
+	if err := ioutil.WriteFile(path, []byte(scrubSecrets(content)), 0600); err != nil {
+		b.errors = append(b.errors, err.Error())
+	}
+}
+
+// copyWorkspace copies the generated values files and manifests to the target
+// directory of the bundle. Kubeconfigs and earlier bundles are left out.
+func (b *diagnosticsBundle) copyWorkspace(target string) {
+	filepath.Walk(kubesliceDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), "diagnostics-") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if strings.Contains(info.Name(), "kubeconfig") || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			b.errors = append(b.errors, fmt.Sprintf("unable to read %s: %v", path, err))
+			return nil
+		}
+		content := string(data)
+		if len(content) > diagnosticsMaxFile {
+			content = content[:diagnosticsMaxFile] + "\n[truncated]\n"
+		}
+		relative, _ := filepath.Rel(kubesliceDirectory, path)
+		b.write(filepath.Join(target, relative), content)
+		return nil
+	})
+}
+
+// diagnosticsNamespaces returns the kubeslice namespaces of every cluster of
+// the topology.
+func diagnosticsNamespaces(specs *ConfigurationSpecs) []clusterNamespaces {
+	cc := specs.Configuration.ClusterConfiguration
+	controller := []string{ControllerNamespace(cc.ControllerCluster)}
+	for _, project := range ProjectNames(specs.Configuration.KubeSliceConfiguration) {
+		controller = appendUnique(controller, projectNamespace(project))
+	}
+	namespaces := []clusterNamespaces{{cc.ControllerCluster, controller}}
+	for _, cluster := range cc.WorkerClusters {
+		namespaces = append(namespaces, clusterNamespaces{cluster, []string{WorkerNamespace(cluster)}})
+	}
+	return namespaces
+}
+
+type clusterNamespaces struct {
+	cluster    Cluster
+	namespaces []string
+}
+
+func (b *diagnosticsBundle) collectClusters(specs *ConfigurationSpecs) {
+	for _, cn := range diagnosticsNamespaces(specs) {
+		cluster := cn.cluster
+		kubectl := func(args ...string) (string, bool) {
+			var outB, errB bytes.Buffer
+			args = append([]string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath, "--request-timeout", "20s"}, args...)
+			if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+				b.errors = append(b.errors, fmt.Sprintf("kubectl %s on cluster %s: %v %s", strings.Join(args[6:], " "), cluster.Name, err, strings.TrimSpace(errB.String())))
+				return "", false
+			}
+			return outB.String(), true
+		}
+		for _, namespace := range cn.namespaces {
+			prefix := filepath.Join("clusters", cluster.Name, namespace)
+			if out, ok := kubectl("get", "pods", "--namespace", namespace, "-o", "wide"); ok {
+				b.write(filepath.Join(prefix, "pods.txt"), out)
+			}
+			if out, ok := kubectl("describe", "pods", "--namespace", namespace); ok {
+				b.write(filepath.Join(prefix, "describe-pods.txt"), out)
+			}
+			if out, ok := kubectl("get", "events", "--namespace", namespace, "--sort-by", ".lastTimestamp"); ok {
+				b.write(filepath.Join(prefix, "events.txt"), out)
+			}
+			if out, ok := kubectl("get", "events", "--namespace", namespace, "-o", "json"); ok {
+				events, err := recentWarningEvents([]byte(out), time.Now(), recentEventsWindow, recentEventsLimit)
+				if err != nil {
+					b.errors = append(b.errors, fmt.Sprintf("events of namespace %s on cluster %s: %v", namespace, cluster.Name, err))
+				} else {
+					b.write(filepath.Join(prefix, "recent-warning-events.txt"), formatEvents(events, time.Now()))
+				}
+			}
+			pods, err := kubeClient.Pods(&cluster, namespace)
+			if err != nil {
+				b.errors = append(b.errors, fmt.Sprintf("unable to list the pods of namespace %s on cluster %s: %v", namespace, cluster.Name, err))
+				continue
+			}
+			for _, pod := range pods {
+				if out, ok := kubectl("logs", pod.Name, "--namespace", namespace, "--all-containers", "--prefix", "--tail", diagnosticsLogTail); ok {
+					b.write(filepath.Join(prefix, "logs", pod.Name+".log"), truncateTail(out, diagnosticsMaxLogBytes))
+				}
+				if pod.Reason == "CrashLoopBackOff" {
+					if out, ok := kubectl("logs", pod.Name, "--namespace", namespace, "--all-containers", "--prefix", "--previous", "--tail", diagnosticsLogTail); ok {
+						b.write(filepath.Join(prefix, "logs", pod.Name+".previous.log"), truncateTail(out, diagnosticsMaxLogBytes))
+					}
+				}
+			}
+		}
+	}
+}
+
+// writeTarGz archives the files of dir, named relative to its parent.
+func writeTarGz(dir, archive string) error {
+	f, err := os.OpenFile(archive, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(filepath.Dir(dir), path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
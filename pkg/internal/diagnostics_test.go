@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestScrubSecrets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Values file",
+			input:    "controllerSecret:\n  namespace: a3ViZXNsaWNl\n  token: ZXlKaGJHY2lP\n  ca.crt: LS0tLS1CRUdJ\nusers:\n- user:\n    client-key-data: LS0tLS1CRUdJ\n",
+			expected: "controllerSecret:\n  namespace: a3ViZXNsaWNl\n  token: ******\n  ca.crt: ******\nusers:\n- user:\n    client-key-data: ******\n",
+		},
+		{
+			name:     "JSON",
+			input:    `{"data": {"token": "ZXlKaGJHY2lP", "namespace": "a3ViZXNsaWNl"}}`,
+			expected: `{"data": {"token": "******", "namespace": "a3ViZXNsaWNl"}}`,
+		},
+		{
+			name:     "Command line",
+			input:    "kubectl get pods --token=ZXlKaGJHY2lP\ncurl -H 'Authorization: Bearer abc.def'",
+			expected: "kubectl get pods --token=******\ncurl -H 'Authorization: Bearer ******",
+		},
+		{
+			name:     "Describe without secrets",
+			input:    "Name:         kubeslice-operator-6b7f\nNamespace:    kubeslice-system\n",
+			expected: "Name:         kubeslice-operator-6b7f\nNamespace:    kubeslice-system\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := scrubSecrets(tc.input); got != tc.expected {
+				t.Errorf("scrubSecrets() =\n%s\nwant\n%s", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	kubesliceDirectory = t.TempDir()
+	kubeClient = &fakeKubeClient{pods: map[string][]podReadiness{
+		"kubeslice-system": {{Name: "kubeslice-operator-0", Reason: "CrashLoopBackOff"}},
+	}}
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		switch command[5] {
+		case "logs":
+			fmt.Fprint(stdout, strings.Repeat("log line\n", diagnosticsMaxLogBytes/8))
+		case "describe":
+			fmt.Fprint(stdout, "Environment:\n  TOKEN: s3cr3t\n")
+		default:
+			if command[len(command)-1] == "json" {
+				fmt.Fprint(stdout, `{"items": []}`)
+				break
+			}
+			fmt.Fprintf(stdout, "%s of %s\n", command[6], command[8])
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	for name, content := range map[string]string{
+		"helm-values-worker-1-kubeslice-worker.yaml": "controllerSecret:\n  token: s3cr3t\n",
+		"kubeslice-kubeconfig.yaml":                  "users:\n- name: kind\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(kubesliceDirectory, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "controller", ContextName: "kind-controller"},
+			WorkerClusters:    []Cluster{{Name: "worker-1", ContextName: "kind-worker-1"}},
+		},
+	}}
+	archive := CollectDiagnostics(specs)
+
+	files := readTarGz(t, archive)
+	bundle := strings.TrimSuffix(filepath.Base(archive), ".tar.gz")
+	names := make([]string, 0, len(files))
+	for name := range files {
+		// The commands other tests failed with are not collected deterministically.
+		if name = strings.TrimPrefix(name, bundle+"/"); name != "failed-commands.txt" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	expected := []string{
+		"cli.txt",
+		"clusters/controller/kubeslice-controller/describe-pods.txt",
+		"clusters/controller/kubeslice-controller/events.txt",
+		"clusters/controller/kubeslice-controller/pods.txt",
+		"clusters/controller/kubeslice-controller/recent-warning-events.txt",
+		"clusters/controller/kubeslice-demo/describe-pods.txt",
+		"clusters/controller/kubeslice-demo/events.txt",
+		"clusters/controller/kubeslice-demo/pods.txt",
+		"clusters/controller/kubeslice-demo/recent-warning-events.txt",
+		"clusters/worker-1/kubeslice-system/describe-pods.txt",
+		"clusters/worker-1/kubeslice-system/events.txt",
+		"clusters/worker-1/kubeslice-system/logs/kubeslice-operator-0.log",
+		"clusters/worker-1/kubeslice-system/logs/kubeslice-operator-0.previous.log",
+		"clusters/worker-1/kubeslice-system/pods.txt",
+		"clusters/worker-1/kubeslice-system/recent-warning-events.txt",
+		"workspace/helm-values-worker-1-kubeslice-worker.yaml",
+	}
+	if strings.Join(names, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("bundle files =\n%s\nwant\n%s", strings.Join(names, "\n"), strings.Join(expected, "\n"))
+	}
+	for name, content := range files {
+		if strings.Contains(content, "s3cr3t") {
+			t.Errorf("%s is not scrubbed:\n%s", name, content)
+		}
+	}
+	if log := files[bundle+"/clusters/worker-1/kubeslice-system/logs/kubeslice-operator-0.log"]; len(log) > diagnosticsMaxLogBytes+100 {
+		t.Errorf("log of %d bytes, want it truncated to %d", len(log), diagnosticsMaxLogBytes)
+	}
+	if _, err := os.Stat(filepath.Join(kubesliceDirectory, bundle)); !os.IsNotExist(err) {
+		t.Errorf("directory of the bundle was not removed after archiving it: %v", err)
+	}
+}
+
+func readTarGz(t *testing.T, archive string) map[string]string {
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatalf("unable to open the bundle: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("bundle is not gzipped: %v", err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("bundle is not a valid tar: %v", err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(data)
+	}
+}
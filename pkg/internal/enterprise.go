@@ -6,17 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-const (
-	uiValuesFileName = "helm-values-ui.yaml"
-)
-
 const UIValuesTemplate = `
 kubeslice:
   uiproxy:
@@ -32,21 +27,30 @@ func InstallKubeSliceUI(ApplicationConfiguration *ConfigurationSpecs) {
 	}
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration
 	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
+	if leaveAsInstalled(cc.ControllerCluster, "kubeslice-ui") {
+		return
+	}
 	time.Sleep(200 * time.Millisecond)
 
-	clusterType := ApplicationConfiguration.Configuration.ClusterConfiguration.ClusterType
-	filename := "helm-values-ui.yaml"
-	generateUIValuesFile(clusterType, cc.ControllerCluster, ApplicationConfiguration.Configuration.HelmChartConfiguration)
-	util.Printf("%s Generated Helm Values file for Kubeslice Manager Installation %s", util.Tick, filename)
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.UIChart)
+	generateUIValuesFile(cc.ControllerCluster, ApplicationConfiguration.Configuration, valuesFile)
+	util.Printf("%s Generated Helm Values file for Kubeslice Manager Installation %s", util.Tick, valuesFile)
 	time.Sleep(200 * time.Millisecond)
 
-	installKubeSliceUI(cc.ControllerCluster, hc)
+	reuseReleaseValues(cc.ControllerCluster, "kubeslice-ui", ControllerNamespace(cc.ControllerCluster), valuesFile)
+
+	showValuesDiff(cc.ControllerCluster, "kubeslice-ui", ControllerNamespace(cc.ControllerCluster), valuesFile)
+	installKubeSliceUI(cc.ControllerCluster, hc, valuesFile)
+	recordValuesChecksum(InstallationName(ApplicationConfiguration), cc.ControllerCluster, "kubeslice-ui", valuesFile)
 	util.Printf("%s Successfully installed helm chart %s/%s", util.Tick, hc.RepoAlias, hc.UIChart.ChartName)
 	time.Sleep(200 * time.Millisecond)
 
 	util.Printf("%s Waiting for KubeSlice Manager Pods to be Healthy...", util.Wait)
 	PodVerification("Waiting for KubeSlice Manager Pods to be Healthy", cc.ControllerCluster, "kubernetes-dashboard")
 	util.Printf("%s Successfully installed KubeSlice Manager.\n", util.Tick)
+	if ApplicationConfiguration.Configuration.UI.Enabled {
+		PrintUIAccess(ApplicationConfiguration)
+	}
 }
 
 func UninstallKubeSliceUI(ApplicationConfiguration *ConfigurationSpecs) {
@@ -55,7 +59,7 @@ func UninstallKubeSliceUI(ApplicationConfiguration *ConfigurationSpecs) {
 	time.Sleep(200 * time.Millisecond)
 	ok, err := uninstallKubeSliceUI(cc.ControllerCluster)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 	if ok {
 		time.Sleep(200 * time.Millisecond)
@@ -63,44 +67,43 @@ func UninstallKubeSliceUI(ApplicationConfiguration *ConfigurationSpecs) {
 	}
 }
 
-func generateUIValuesFile(clusterType string, cluster Cluster, hcConfig HelmChartConfiguration) {
+func generateUIValuesFile(cluster Cluster, config Configuration, valuesFile string) {
+	hcConfig := config.HelmChartConfiguration
+	if err := substituteChartPlaceholders(&hcConfig.UIChart, cluster, config); err != nil {
+		util.Fatalf("%s %s", util.Cross, err)
+	}
+	verifyValueKeys(hcConfig, &hcConfig.UIChart)
 	serviceType := ""
-	if clusterType == "kind" {
+	if config.ClusterConfiguration.ClusterType == "kind" {
 		serviceType = "NodePort"
 	} else {
 		serviceType = "LoadBalancer"
 	}
-	err := generateValuesFile(kubesliceDirectory+"/"+uiValuesFileName, &hcConfig.UIChart, fmt.Sprintf(UIValuesTemplate+generateImagePullSecretsValue(hcConfig.ImagePullSecret), serviceType))
+	err := generateValuesFile(valuesFile, &hcConfig.UIChart, fmt.Sprintf(UIValuesTemplate, serviceType)+kubesliceImagePullSecretsValue(hcConfig.ImagePullSecrets), propagatedImagePullSecrets(hcConfig))
+	if err == nil {
+		err = rewriteImageRegistry(hcConfig, &hcConfig.UIChart, valuesFile)
+	}
 	if err != nil {
-		log.Fatalf("%s %s", util.Cross, err)
+		util.Fatalf("%s %s", util.Cross, err)
 	}
 }
 
-func installKubeSliceUI(cluster Cluster, hc HelmChartConfiguration) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "upgrade", "-i", "kubeslice-ui", fmt.Sprintf("%s/%s", hc.RepoAlias, hc.UIChart.ChartName), "--namespace", KUBESLICE_CONTROLLER_NAMESPACE, "-f", kubesliceDirectory+"/"+uiValuesFileName)
-	if hc.UIChart.Version != "" {
-		args = append(args, "--version", hc.UIChart.Version)
-	}
-	err := util.RunCommand("helm", args...)
-	if err != nil {
-		log.Fatalf("Process failed %v", err)
-	}
+func installKubeSliceUI(cluster Cluster, hc HelmChartConfiguration, valuesFile string) {
+	installRelease(releaseRequest{Cluster: cluster, Release: "kubeslice-ui", Namespace: ControllerNamespace(cluster), Chart: hc.UIChart, Repo: hc, ValuesFile: valuesFile})
 }
 
 func uninstallKubeSliceUI(cluster Cluster) (bool, error) {
 	args := make([]string, 0)
 	// fetching UI release
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "status", "kubeslice-ui", "--namespace", KUBESLICE_CONTROLLER_NAMESPACE)
+	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "status", "kubeslice-ui", "--namespace", ControllerNamespace(cluster))
 	err := util.RunCommandWithoutPrint("helm", args...)
 	if err != nil {
 		util.Printf("%s KubeSlice Manager not installed, skipping uninstall.", util.Cross)
 		return false, nil
 	} else {
-		args = make([]string, 0)
-		args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "uninstall", "kubeslice-ui", "--namespace", KUBESLICE_CONTROLLER_NAMESPACE)
-		err = util.RunCommand("helm", args...)
+		err = chartInstaller.Uninstall(cluster, "kubeslice-ui", ControllerNamespace(cluster))
 		if err != nil {
+			diagnoseRelease(cluster, "kubeslice-ui", ControllerNamespace(cluster))
 			return false, err
 		}
 	}
@@ -112,7 +115,7 @@ func GetUIEndpoint(cc *Cluster, profile string) string {
 	ep := ""
 
 	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", "services", "kubeslice-ui-proxy", "-n", KUBESLICE_CONTROLLER_NAMESPACE, "-o", "jsonpath='{.spec}'")
+	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", "services", "kubeslice-ui-proxy", "-n", ControllerNamespace(*cc), "-o", "jsonpath='{.spec}'")
 	if err == nil {
 		jsonMap := make(map[string]interface{})
 		err = json.Unmarshal(outB.Bytes()[1:len(outB.Bytes())-1], &jsonMap)
@@ -169,7 +172,7 @@ func findUserSecret(username string, projectName string, cc Cluster) string {
 	var outB, errB bytes.Buffer
 	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", "sa", "-n", "kubeslice-"+projectName, "-o", "name")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 
 	var secret string
@@ -180,7 +183,7 @@ func findUserSecret(username string, projectName string, cc Cluster) string {
 		}
 	}
 	if secret == "" {
-		log.Fatalf("failed to find secret for %s", username)
+		util.Fatalf("failed to find secret for %s", username)
 	}
 	return secret
 }
@@ -192,13 +195,13 @@ func GetUIAdminToken(cc *Cluster, username, projectName string) string {
 	var outB, errB bytes.Buffer
 	err := util.RunCommandCustomIO("kubectl", &outB, &errB, false, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", secret, "-n", "kubeslice-"+projectName, "-o", "jsonpath={.data.token}")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 	x := outB.String()
 	// base64 decode
 	data, err := base64.StdEncoding.DecodeString(x)
 	if err != nil {
-		log.Fatalf("Unable to decode token %v", err)
+		util.Fatalf("Unable to decode token %v", err)
 	}
 	return string(data)
 
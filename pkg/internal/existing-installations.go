@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// What install does with KubeSlice components found on the clusters which
+// the state of the installation does not record, --on-existing.
+const (
+	OnExistingAdopt = "adopt"
+	OnExistingSkip  = "skip"
+	OnExistingFail  = "fail"
+)
+
+// existingInstallation is what a cluster already has installed of KubeSlice
+// outside of the installation: releases it did not record and kubeslice CRDs.
+type existingInstallation struct {
+	cluster  Cluster
+	releases []existingRelease
+	crds     []string
+}
+
+type existingRelease struct {
+	component string
+	release   string
+	namespace string
+	chart     string
+	version   string
+	revision  string
+	status    string
+}
+
+var (
+	// adoptedReleases are left as they are installed, by cluster and release
+	adoptedReleases = make(map[string]bool)
+	// untouchedClusters are not installed to, by name
+	untouchedClusters = make(map[string]bool)
+)
+
+// leftAsInstalled tells whether a release of the run is not installed since
+// it was adopted or its cluster is skipped.
+func leftAsInstalled(cluster Cluster, release string) bool {
+	return untouchedClusters[cluster.Name] || adoptedReleases[cluster.Name+"/"+release]
+}
+
+// DetectExistingInstallations looks for KubeSlice releases and CRDs on the
+// clusters of the topology which the state of the installation does not
+// record, e.g. installed by someone else or by an older kubeslice-cli, before
+// anything is installed. Depending on --on-existing they are adopted into
+// the state and left as installed, the default, their clusters are left
+// untouched, or the run fails with a report. Kind clusters the install is yet to create are not
+// looked at.
+func DetectExistingInstallations(specs *ConfigurationSpecs, components []string, createsKindClusters bool) {
+	found := findExistingInstallations(specs, components, createsKindClusters)
+	if len(found) == 0 {
+		return
+	}
+	mode := Options.OnExisting
+	if mode == "" {
+		mode = OnExistingAdopt
+	}
+	util.Printf("\nFound existing KubeSlice installations not recorded in installation %s:", InstallationName(specs))
+	for _, existing := range found {
+		for _, r := range existing.releases {
+			util.Printf("%s %s: release %s (%s %s, revision %s, %s) in namespace %s", util.Warn, existing.cluster.Name, r.release, r.chart, r.version, r.revision, r.status, r.namespace)
+		}
+		if len(existing.crds) > 0 {
+			util.Printf("%s %s: %d kubeslice CRDs: %s", util.Warn, existing.cluster.Name, len(existing.crds), strings.Join(existing.crds, ", "))
+		}
+	}
+	switch mode {
+	case OnExistingAdopt:
+		adoptExistingInstallations(specs, found)
+	case OnExistingSkip:
+		for _, existing := range found {
+			untouchedClusters[existing.cluster.Name] = true
+			util.Printf("%s Leaving cluster %s untouched", util.Tick, existing.cluster.Name)
+		}
+	default:
+		util.Fatalf("%s Process failed due to existing KubeSlice installations. Use --on-existing=adopt to take them over and install only the missing components, or --on-existing=skip to leave those clusters untouched", util.Cross)
+	}
+}
+
+func findExistingInstallations(specs *ConfigurationSpecs, components []string, createsKindClusters bool) []existingInstallation {
+	state, err := loadInstallationState(InstallationName(specs))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	recorded := func(cluster Cluster, release string) bool {
+		if state == nil {
+			return false
+		}
+		for _, r := range state.Releases {
+			if r.Cluster == cluster.Name && r.Release == release {
+				return true
+			}
+		}
+		return false
+	}
+
+	cc := specs.Configuration.ClusterConfiguration
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	toCreate := kindClustersToCreate(clusters, createsKindClusters)
+	crs := componentReleases(specs)
+	found := make([]existingInstallation, 0)
+	for i, cluster := range clusters {
+		if toCreate[i] {
+			continue
+		}
+		clusterComponents := []string{Worker_Component}
+		if i == 0 {
+			clusterComponents = []string{Controller_Component, UI_install_Component}
+		}
+		// e.g. the controller of a workers only install is someone else's
+		selected := false
+		for _, component := range clusterComponents {
+			selected = selected || containsString(components, component)
+		}
+		if !selected {
+			continue
+		}
+		existing := existingInstallation{cluster: cluster}
+		ours := false
+		for _, component := range clusterComponents {
+			cr := crs[component]
+			if !containsString(components, component) || cr.chart.ChartName == "" {
+				continue
+			}
+			if recorded(cluster, cr.release) {
+				ours = true
+				continue
+			}
+			namespace := cr.namespace(cluster)
+			release, err := findRelease(cluster, cr.release, namespace)
+			if err != nil {
+				util.Fatalf("%s %v", util.Cross, err)
+			}
+			if release != nil {
+				existing.releases = append(existing.releases, existingRelease{
+					component: component,
+					release:   cr.release,
+					namespace: namespace,
+					chart:     cr.chart.ChartName,
+					version:   release.chartVersion(cr.chart.ChartName),
+					revision:  release.Revision,
+					status:    release.Status,
+				})
+			}
+		}
+		if !ours {
+			crds, err := kubesliceCRDs(cluster, i == 0)
+			if err != nil {
+				util.Fatalf("%s Unable to list the CRDs of %s: %v", util.Cross, cluster.Name, err)
+			}
+			existing.crds = crds
+		}
+		if len(existing.releases) > 0 || len(existing.crds) > 0 {
+			found = append(found, existing)
+		}
+	}
+	return found
+}
+
+// kubesliceCRDs returns the kubeslice CRDs of a cluster, of the controller
+// groups on the controller cluster and of the others on workers.
+func kubesliceCRDs(cluster Cluster, controller bool) ([]string, error) {
+	names, err := kubeClient.List(&cluster, crdResource, "")
+	if err != nil {
+		return nil, err
+	}
+	crds := make([]string, 0)
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".kubeslice.io") {
+			continue
+		}
+		controllerGroup := strings.HasSuffix(name, "."+kubesliceControllerGroup) || strings.HasSuffix(name, ".worker.kubeslice.io")
+		if controllerGroup == controller {
+			crds = append(crds, name)
+		}
+	}
+	return crds, nil
+}
+
+// adoptExistingInstallations records the existing releases in the state of
+// the installation and leaves them as installed. Adopted releases of another
+// version than the topology pins are called out, a later install upgrades
+// them. Clusters with kubeslice CRDs but no release are installed to with a
+// warning.
+func adoptExistingInstallations(specs *ConfigurationSpecs, found []existingInstallation) {
+	name := InstallationName(specs)
+	// a run stopping after its plan records nothing
+	if !Options.PlanOnly {
+		err := updateInstallationState(name, func(state *InstallationState) {
+			if len(state.Topology.Configuration.ClusterConfiguration.WorkerClusters) == 0 {
+				state.Topology = stateTopology(specs)
+				state.ProjectName = specs.Configuration.KubeSliceConfiguration.ProjectName
+			}
+			for _, existing := range found {
+				for _, r := range existing.releases {
+					state.Releases = setInstalledRelease(state.Releases, installedRelease{Component: r.component, Cluster: existing.cluster.Name, Release: r.release, Namespace: r.namespace, Chart: r.chart, Version: r.version, Revision: r.revision})
+				}
+			}
+		})
+		if err != nil {
+			util.Fatalf("%s Unable to adopt the existing releases into installation %s: %v", util.Cross, name, err)
+		}
+	}
+	for _, existing := range found {
+		if len(existing.releases) == 0 {
+			util.Printf("%s %s has kubeslice CRDs but no KubeSlice release, installing over them", util.Warn, existing.cluster.Name)
+		}
+		for _, r := range existing.releases {
+			adoptedReleases[existing.cluster.Name+"/"+r.release] = true
+			util.Printf("%s Adopted release %s on %s, it is left as installed", util.Tick, r.release, existing.cluster.Name)
+			if pinned := pinnedChartVersion(r.component); pinned != "" && pinned != r.version {
+				util.Printf("%s Adopted %s %s on %s is not the version %s the topology pins. Rerun install to upgrade it", util.Warn, r.chart, r.version, existing.cluster.Name, pinned)
+			}
+		}
+	}
+}
+
+// pinnedChartVersion returns the version the chart of a component resolved
+// to for this run, if it is an exact version.
+func pinnedChartVersion(component string) string {
+	chart, ok := runSummary.Charts[component]
+	if !ok || chart.Version == "local" {
+		return ""
+	}
+	return exactChartVersion(chart.Version)
+}
+
+// exactChartVersion returns a chart version without its v prefix, empty when
+// it is empty or a version constraint.
+func exactChartVersion(version string) string {
+	if strings.ContainsAny(version, "^~<>=*| ,") {
+		return ""
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// leaveAsInstalled prints that a release is left as installed when it is.
+func leaveAsInstalled(cluster Cluster, release string) bool {
+	if !leftAsInstalled(cluster, release) {
+		return false
+	}
+	util.Printf("%s Leaving %s on %s as installed", util.Tick, release, cluster.Name)
+	return true
+}
+
+// touchedWorkers returns the workers the run installs to.
+func touchedWorkers(workers []Cluster) []Cluster {
+	touched := make([]Cluster, 0, len(workers))
+	for _, cluster := range workers {
+		if !untouchedClusters[cluster.Name] {
+			touched = append(touched, cluster)
+		}
+	}
+	return touched
+}
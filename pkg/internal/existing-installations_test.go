@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestDetectExistingInstallations(t *testing.T) {
+	defer func(dir string, client KubeClient, summary RunSummary, mode string) {
+		installationStateDirectory, kubeClient, runSummary, Options.OnExisting = dir, client, summary, mode
+	}(installationStateDirectory, kubeClient, runSummary, Options.OnExisting)
+	defer func() {
+		adoptedReleases = make(map[string]bool)
+		untouchedClusters = make(map[string]bool)
+	}()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl", KubeConfigPath: "kubeconfig.yaml"},
+			WorkerClusters: []Cluster{
+				{Name: "ks-w-1", ContextName: "ks-w-1", KubeConfigPath: "kubeconfig.yaml"},
+				{Name: "ks-w-2", ContextName: "ks-w-2", KubeConfigPath: "kubeconfig.yaml"},
+			},
+		},
+		HelmChartConfiguration: HelmChartConfiguration{
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker"},
+		},
+	}}
+	components := []string{Controller_Component, Worker_Component}
+	// the controller has an existing release, the workers only kubeslice CRDs
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		if command[2] == "ks-ctrl" && containsString(command, "^kubeslice-controller$") {
+			fmt.Fprint(stdout, `[{"name": "kubeslice-controller", "namespace": "kubeslice-controller", "revision": "3", "status": "deployed", "chart": "kubeslice-controller-0.9.0"}]`)
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+	kubeClient = &fakeKubeClient{objects: map[string][]string{
+		objectsKey(crdResource, ""): {"projects.controller.kubeslice.io", "slicegateways.networking.kubeslice.io"},
+	}}
+
+	tests := []struct {
+		name      string
+		mode      string
+		adopted   map[string]bool
+		untouched map[string]bool
+	}{
+		{
+			name:      "Adopt",
+			mode:      OnExistingAdopt,
+			adopted:   map[string]bool{"ks-ctrl/kubeslice-controller": true},
+			untouched: map[string]bool{},
+		},
+		{
+			name:      "Adopt by default",
+			adopted:   map[string]bool{"ks-ctrl/kubeslice-controller": true},
+			untouched: map[string]bool{},
+		},
+		{
+			name:      "Skip",
+			mode:      OnExistingSkip,
+			adopted:   map[string]bool{},
+			untouched: map[string]bool{"ks-ctrl": true, "ks-w-1": true, "ks-w-2": true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			installationStateDirectory = t.TempDir()
+			adoptedReleases = make(map[string]bool)
+			untouchedClusters = make(map[string]bool)
+			runSummary = RunSummary{Charts: map[string]chartSummary{Controller_Component: {Chart: "kubeslice-controller", Version: "1.0.0"}}}
+			Options.OnExisting = tc.mode
+
+			DetectExistingInstallations(specs, components, false)
+			if !reflect.DeepEqual(adoptedReleases, tc.adopted) {
+				t.Errorf("adoptedReleases = %v, want %v", adoptedReleases, tc.adopted)
+			}
+			if !reflect.DeepEqual(untouchedClusters, tc.untouched) {
+				t.Errorf("untouchedClusters = %v, want %v", untouchedClusters, tc.untouched)
+			}
+			if tc.mode == OnExistingSkip {
+				return
+			}
+			state, err := loadInstallationState(InstallationName(specs))
+			if err != nil {
+				t.Fatalf("loadInstallationState() returned error: %v", err)
+			}
+			expected := []installedRelease{{Component: Controller_Component, Cluster: "ks-ctrl", Release: "kubeslice-controller", Namespace: "kubeslice-controller", Chart: "kubeslice-controller", Version: "0.9.0", Revision: "3"}}
+			if !reflect.DeepEqual(state.Releases, expected) {
+				t.Errorf("state releases = %+v, want %+v", state.Releases, expected)
+			}
+			// the adopted release is ours from then on, only the CRDs of the workers are not
+			found := findExistingInstallations(specs, components, false)
+			if len(found) != 2 || found[0].cluster.Name != "ks-w-1" || len(found[0].releases) != 0 {
+				t.Errorf("findExistingInstallations() after adopting = %+v", found)
+			}
+		})
+	}
+}
+
+func TestInstallKubeSliceWorkerSkipsUntouchedClusters(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func() { untouchedClusters = make(map[string]bool) }()
+	untouchedClusters = map[string]bool{"ks-w-1": true}
+	mock := &util.MockExecutor{}
+	defer util.UseExecutor(mock)()
+	kubeClient = &fakeKubeClient{}
+
+	InstallKubeSliceWorker(&ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{{Name: "ks-w-1", ContextName: "ks-w-1"}}},
+	}})
+	if len(mock.Commands) != 0 {
+		t.Errorf("commands = %q, want none on the untouched cluster", mock.Commands)
+	}
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// ExpandEnvInConfiguration replaces ${VAR} and ${VAR:-default} references in
+// the topology fields which commonly carry secrets or environment specific
+// values. A literal dollar sign is written as $$.
+func ExpandEnvInConfiguration(specs *ConfigurationSpecs) []string {
+	errors := make([]string, 0)
+	expand := func(value *string, field string) {
+		expanded, err := expandEnv(*value, field)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			return
+		}
+		*value = expanded
+	}
+	expandValuesMap := func(values map[string]interface{}, field string) {
+		for k, v := range values {
+			expanded, err := expandEnvInValue(v, field+"."+k)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+				continue
+			}
+			values[k] = expanded
+		}
+	}
+
+	cc := &specs.Configuration.ClusterConfiguration
+	expand(&cc.KubeConfigPath, "configuration.cluster_configuration.kube_config_path")
+	expandCluster := func(cluster *Cluster, field string) {
+		expand(&cluster.KubeConfigPath, field+".kube_config_path")
+		expand(&cluster.ControlPlaneAddress, field+".control_plane_address")
+		expand(&cluster.NodeIP, field+".node_ip")
+		for i := range cluster.NodeIPs {
+			expand(&cluster.NodeIPs[i], fmt.Sprintf("%s.node_ips[%d]", field, i))
+		}
+	}
+	expandCluster(&cc.ControllerCluster, "configuration.cluster_configuration.controller")
+	for i := range cc.WorkerClusters {
+		field := fmt.Sprintf("configuration.cluster_configuration.workers[%d]", i)
+		expandCluster(&cc.WorkerClusters[i], field)
+		expandValuesMap(cc.WorkerClusters[i].WorkerValues, field+".worker_values")
+	}
+
+	hc := &specs.Configuration.HelmChartConfiguration
+	expand(&hc.HelmUsername, "configuration.helm_chart_configuration.helm_username")
+	expand(&hc.HelmPassword, "configuration.helm_chart_configuration.helm_password")
+	expand(&hc.GlobalImageRegistry, "configuration.helm_chart_configuration.global_image_registry")
+	for i := range hc.ImagePullSecrets {
+		ips := &hc.ImagePullSecrets[i]
+		field := fmt.Sprintf("configuration.helm_chart_configuration.image_pull_secret[%d]", i)
+		expand(&ips.Registry, field+".registry")
+		expand(&ips.Username, field+".username")
+		expand(&ips.Password, field+".password")
+		expand(&ips.Email, field+".email")
+	}
+
+	names, charts := topologyCharts(hc)
+	for _, name := range names {
+		chart := charts[name]
+		expand(&chart.ValuesYAML, "configuration.helm_chart_configuration."+name+".values_yaml")
+		expandValuesMap(chart.Values, "configuration.helm_chart_configuration."+name+".values")
+		expand(&chart.HelmUsername, "configuration.helm_chart_configuration."+name+".helm_username")
+		expand(&chart.HelmPassword, "configuration.helm_chart_configuration."+name+".helm_password")
+		expand(&chart.ImageRegistry, "configuration.helm_chart_configuration."+name+".image_registry")
+	}
+
+	pc := &specs.Configuration.ProxyConfiguration
+	expand(&pc.HTTPProxy, "configuration.proxy_configuration.http_proxy")
+	expand(&pc.HTTPSProxy, "configuration.proxy_configuration.https_proxy")
+	expand(&pc.NoProxy, "configuration.proxy_configuration.no_proxy")
+	return errors
+}
+
+func expandEnvInValue(value interface{}, field string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return expandEnv(v, field)
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			expanded, err := expandEnvInValue(item, fmt.Sprintf("%s.%v", field, k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			expanded, err := expandEnvInValue(item, field+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []interface{}:
+		for i, item := range v {
+			expanded, err := expandEnvInValue(item, fmt.Sprintf("%s[%d]", field, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+// expandEnv expands the environment references in a single string. field is
+// the topology path of the value and is only used for error messages.
+func expandEnv(s, field string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("%s: unterminated variable reference in %q", field, s)
+			}
+			reference := s[i+2 : i+end]
+			name, def, hasDefault := reference, "", false
+			if idx := strings.Index(reference, ":-"); idx >= 0 {
+				name, def, hasDefault = reference[:idx], reference[idx+2:], true
+			}
+			if name == "" {
+				return "", fmt.Errorf("%s: empty variable reference in %q", field, s)
+			}
+			value, found := os.LookupEnv(name)
+			if !found || (value == "" && hasDefault) {
+				if !hasDefault {
+					return "", fmt.Errorf("%s: environment variable %s is not set", field, name)
+				}
+				value = def
+			}
+			sb.WriteString(value)
+			i += end
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
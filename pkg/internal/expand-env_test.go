@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("KS_TEST_PASSWORD", "s3cret")
+	t.Setenv("KS_TEST_EMPTY", "")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "Plain string is unchanged",
+			input:    "no variables here",
+			expected: "no variables here",
+		},
+		{
+			name:     "Set variable",
+			input:    "pass-${KS_TEST_PASSWORD}",
+			expected: "pass-s3cret",
+		},
+		{
+			name:     "Default used for unset variable",
+			input:    "${KS_TEST_UNSET:-https://10.0.0.1:6443}",
+			expected: "https://10.0.0.1:6443",
+		},
+		{
+			name:     "Default used for empty variable",
+			input:    "${KS_TEST_EMPTY:-fallback}",
+			expected: "fallback",
+		},
+		{
+			name:     "Escaped dollar sign",
+			input:    "pa$$word-$${KS_TEST_PASSWORD}",
+			expected: "pa$word-${KS_TEST_PASSWORD}",
+		},
+		{
+			name:     "Lone dollar sign is kept",
+			input:    "cost: 5$",
+			expected: "cost: 5$",
+		},
+		{
+			name:  "Unset variable without default",
+			input: "${KS_TEST_UNSET}",
+			err:   "field.path: environment variable KS_TEST_UNSET is not set",
+		},
+		{
+			name:  "Unterminated reference",
+			input: "${KS_TEST_PASSWORD",
+			err:   "unterminated variable reference",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandEnv(tc.input, "field.path")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expandEnv() error mismatch:\nwant: %q\ngot:  %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandEnv() returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expandEnv() mismatch:\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandEnvInConfiguration(t *testing.T) {
+	t.Setenv("KS_TEST_REGISTRY_PASSWORD", "s3cret")
+	t.Setenv("KS_TEST_NODE_IP", "10.1.1.1")
+
+	specs := &ConfigurationSpecs{}
+	specs.Configuration.ClusterConfiguration.WorkerClusters = []Cluster{{Name: "w1", NodeIP: "${KS_TEST_NODE_IP}"}}
+	specs.Configuration.HelmChartConfiguration.ImagePullSecrets = ImagePullSecretList{{Password: "${KS_TEST_REGISTRY_PASSWORD}"}}
+	specs.Configuration.HelmChartConfiguration.WorkerChart.Values = map[string]interface{}{
+		"nested": map[interface{}]interface{}{
+			"list": []interface{}{"${KS_TEST_NODE_IP}", 5},
+		},
+		"missing": "${KS_TEST_MISSING}",
+	}
+
+	errors := ExpandEnvInConfiguration(specs)
+	if len(errors) != 1 || !strings.Contains(errors[0], "configuration.helm_chart_configuration.worker_chart.values.missing: environment variable KS_TEST_MISSING is not set") {
+		t.Errorf("ExpandEnvInConfiguration() unexpected errors: %v", errors)
+	}
+	if got := specs.Configuration.HelmChartConfiguration.ImagePullSecrets[0].Password; got != "s3cret" {
+		t.Errorf("image pull secret password not expanded, got %q", got)
+	}
+	if got := specs.Configuration.ClusterConfiguration.WorkerClusters[0].NodeIP; got != "10.1.1.1" {
+		t.Errorf("worker node ip not expanded, got %q", got)
+	}
+	expected := map[interface{}]interface{}{"list": []interface{}{"10.1.1.1", 5}}
+	if got := specs.Configuration.HelmChartConfiguration.WorkerChart.Values["nested"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("nested values not expanded:\nwant: %v\ngot:  %v", expected, got)
+	}
+}
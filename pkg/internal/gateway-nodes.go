@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Slice gateway pods are only scheduled on the nodes with this label. The
+// kind worker clusters of the demo profiles label their node when created.
+const (
+	gatewayNodeLabel      = "kubeslice.io/node-type"
+	gatewayNodeLabelValue = "gateway"
+	controlPlaneNodeLabel = "node-role.kubernetes.io/control-plane"
+)
+
+// clusterNode is a node of a cluster as far as gateway labeling cares.
+type clusterNode struct {
+	name          string
+	gateway       bool
+	controlPlane  bool
+	unschedulable bool
+}
+
+// clusterNodes lists the nodes of a cluster.
+func clusterNodes(cluster Cluster) ([]clusterNode, error) {
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "nodes", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the nodes of %s: %s", cluster.Name, commandError(err, &errB))
+	}
+	return parseClusterNodes(outB.Bytes())
+}
+
+func parseClusterNodes(data []byte) ([]clusterNode, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name   string            `json:"name"`
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				Unschedulable bool `json:"unschedulable"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse the nodes: %v", err)
+	}
+	nodes := make([]clusterNode, 0, len(list.Items))
+	for _, item := range list.Items {
+		_, controlPlane := item.Metadata.Labels[controlPlaneNodeLabel]
+		nodes = append(nodes, clusterNode{
+			name:          item.Metadata.Name,
+			gateway:       item.Metadata.Labels[gatewayNodeLabel] == gatewayNodeLabelValue,
+			controlPlane:  controlPlane,
+			unschedulable: item.Spec.Unschedulable,
+		})
+	}
+	return nodes, nil
+}
+
+// gatewayNodeCount returns how many nodes of a worker cluster are labeled as
+// gateway nodes when none is, 1 unless gateway_nodes is set.
+func gatewayNodeCount(cluster Cluster) int {
+	if cluster.GatewayNodes > 0 {
+		return cluster.GatewayNodes
+	}
+	return 1
+}
+
+// selectGatewayNodes picks the nodes to label as gateway nodes: schedulable
+// nodes before cordoned ones, workers before control plane nodes, by name.
+func selectGatewayNodes(nodes []clusterNode, count int) []string {
+	candidates := append([]clusterNode(nil), nodes...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.unschedulable != b.unschedulable {
+			return !a.unschedulable
+		}
+		if a.controlPlane != b.controlPlane {
+			return !a.controlPlane
+		}
+		return a.name < b.name
+	})
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	names := make([]string, 0, count)
+	for _, node := range candidates[:count] {
+		names = append(names, node.name)
+	}
+	return names
+}
+
+func labelGatewayNodesCommand(cluster Cluster, names []string) []string {
+	args := []string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath, "label", "nodes"}
+	args = append(args, names...)
+	return append(args, gatewayNodeLabel+"="+gatewayNodeLabelValue)
+}
+
+// LabelGatewayNodes makes sure every worker cluster has a node the slice
+// gateways can be scheduled on. Clusters without a node labeled
+// kubeslice.io/node-type=gateway get gateway_nodes of their nodes labeled,
+// unless --no-auto-label is set, which fails the run with the command to
+// label them instead.
+func LabelGatewayNodes(specs *ConfigurationSpecs) {
+	util.Printf("\nVerifying gateway nodes...")
+	errors := make([]string, 0)
+	for _, cluster := range touchedWorkers(specs.Configuration.ClusterConfiguration.WorkerClusters) {
+		nodes, err := clusterNodes(cluster)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			continue
+		}
+		labeled := 0
+		for _, node := range nodes {
+			if node.gateway {
+				labeled++
+			}
+		}
+		if labeled > 0 {
+			util.Printf("%s %d gateway node(s) on %s", util.Tick, labeled, cluster.Name)
+			continue
+		}
+		names := selectGatewayNodes(nodes, gatewayNodeCount(cluster))
+		if len(names) == 0 {
+			errors = append(errors, fmt.Sprintf("%s %s has no nodes to label as gateway nodes", util.Cross, cluster.Name))
+			continue
+		}
+		args := labelGatewayNodesCommand(cluster, names)
+		if Options.NoAutoLabel {
+			errors = append(errors, fmt.Sprintf("%s No node of %s is labeled %s=%s, slice gateways cannot be scheduled. Label them with:\n  kubectl %s", util.Cross, cluster.Name, gatewayNodeLabel, gatewayNodeLabelValue, strings.Join(util.QuoteArgs(args...), " ")))
+			continue
+		}
+		var outB, errB bytes.Buffer
+		if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+			errors = append(errors, fmt.Sprintf("%s Failed to label the gateway nodes of %s: %s", util.Cross, cluster.Name, commandError(err, &errB)))
+			continue
+		}
+		util.Printf("%s Labeled %s of %s as gateway node(s)", util.Tick, strings.Join(names, ", "), cluster.Name)
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to worker clusters without gateway nodes", util.Cross)
+	}
+}
+
+// ShowGatewayNodes prints how many nodes of every worker cluster are labeled
+// as gateway nodes.
+func ShowGatewayNodes(specs *ConfigurationSpecs) {
+	util.Printf("\nGateway nodes:")
+	for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+		nodes, err := clusterNodes(cluster)
+		if err != nil {
+			util.Printf("%s %v", util.Warn, err)
+			continue
+		}
+		labeled := 0
+		for _, node := range nodes {
+			if node.gateway {
+				labeled++
+			}
+		}
+		if labeled == 0 {
+			util.Printf("%s %s: 0 of %d nodes labeled %s=%s", util.Cross, cluster.Name, len(nodes), gatewayNodeLabel, gatewayNodeLabelValue)
+			continue
+		}
+		util.Printf("%s %s: %d of %d nodes", util.Tick, cluster.Name, labeled, len(nodes))
+	}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestSelectGatewayNodes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		nodes    []clusterNode
+		count    int
+		expected []string
+	}{
+		{
+			name:     "Workers by name",
+			nodes:    []clusterNode{{name: "node-c"}, {name: "node-a"}, {name: "node-b"}},
+			count:    2,
+			expected: []string{"node-a", "node-b"},
+		},
+		{
+			name:     "Workers before control plane nodes",
+			nodes:    []clusterNode{{name: "cp-0", controlPlane: true}, {name: "worker-0"}},
+			count:    1,
+			expected: []string{"worker-0"},
+		},
+		{
+			name:     "Schedulable before cordoned nodes",
+			nodes:    []clusterNode{{name: "node-a", unschedulable: true}, {name: "node-b", controlPlane: true}},
+			count:    1,
+			expected: []string{"node-b"},
+		},
+		{
+			name:     "More nodes than the cluster has",
+			nodes:    []clusterNode{{name: "kind-control-plane", controlPlane: true}},
+			count:    3,
+			expected: []string{"kind-control-plane"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := selectGatewayNodes(tc.nodes, tc.count); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("selectGatewayNodes() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLabelGatewayNodes(t *testing.T) {
+	nodes := `{"items": [
+		{"metadata": {"name": "worker-1-control-plane", "labels": {"node-role.kubernetes.io/control-plane": ""}}},
+		{"metadata": {"name": "worker-1-node-b", "labels": {}}},
+		{"metadata": {"name": "worker-1-node-a", "labels": {}}}]}`
+	labeled := `{"items": [{"metadata": {"name": "worker-2-node", "labels": {"kubeslice.io/node-type": "gateway"}}}]}`
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		if command[3] == "get" && strings.Contains(command[1], "worker-2") {
+			fmt.Fprint(stdout, labeled)
+		} else if command[3] == "get" {
+			fmt.Fprint(stdout, nodes)
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+		WorkerClusters: []Cluster{
+			{Name: "worker-1", ContextName: "worker-1", KubeConfigPath: "kubeconfig.yaml", GatewayNodes: 2},
+			{Name: "worker-2", ContextName: "worker-2", KubeConfigPath: "kubeconfig.yaml"},
+		},
+	}}}
+	LabelGatewayNodes(specs)
+
+	labels := make([]string, 0)
+	for _, command := range mock.Commands {
+		if command[3] == "label" {
+			labels = append(labels, strings.Join(command, " "))
+		}
+	}
+	expected := []string{"kubectl --context=worker-1 --kubeconfig=kubeconfig.yaml label nodes worker-1-node-a worker-1-node-b kubeslice.io/node-type=gateway"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("label commands = %q, want %q", labels, expected)
+	}
+}
@@ -1,69 +1,86 @@
 package internal
 
 import (
+	"bytes"
 	"fmt"
-	"log"
+	"io/ioutil"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
 )
 
 const (
-	kubesliceDirectory = "kubeslice"
-	kindSubDirectory   = "kind"
-	ProfileEntDemo     = "enterprise-demo"
+	kindSubDirectory = "kind"
+	ProfileEntDemo   = "enterprise-demo"
+	// kindNodeImage is the node image of the kind clusters of the demo profiles
+	// which set no kubernetes_version or node_image
+	kindNodeImage = "kindest/node:v1.25.11"
+	// minKindVersion is the first kind release reading the v1alpha4 configs
+	// generated here
+	minKindVersion = "0.8.0"
 )
 
-const kubesliceControllerTemplate = `
-kind: Cluster
-apiVersion: kind.x-k8s.io/v1alpha4
-name: %s
-networking:
-  disableDefaultCNI: true # disable kindnet
-  podSubnet: 192.168.0.0/16 # set to Calico's default subnet
-nodes:
-  - role: control-plane
-    image: kindest/node:v1.25.11
+// gatewayNodePatch labels the control plane of a single node worker cluster
+// as its gateway node, gatewayWorkerNodePatch the kind worker nodes of a
+// multi-node one.
+const (
+	gatewayNodePatch = `kind: InitConfiguration
+nodeRegistration:
+  kubeletExtraArgs:
+    node-labels: "kubeslice.io/node-type=gateway"
 `
-const kubesliceEntControllerTemplate = `
-kind: Cluster
-apiVersion: kind.x-k8s.io/v1alpha4
-name: %s
-networking:
-  disableDefaultCNI: true # disable kindnet
-  podSubnet: 192.168.0.0/16 # set to Calico's default subnet
-nodes:
-  - role: control-plane
-    image: kindest/node:v1.25.11
-    extraPortMappings:
-      - containerPort: 31000
-        hostPort: 8443
-        protocol: TCP
+	gatewayWorkerNodePatch = `kind: JoinConfiguration
+nodeRegistration:
+  kubeletExtraArgs:
+    node-labels: "kubeslice.io/node-type=gateway"
 `
+)
 
-const kubesliceWorkerTemplate = `
-kind: Cluster
-apiVersion: kind.x-k8s.io/v1alpha4
-name: %s
-networking:
-  disableDefaultCNI: true # disable kindnet
-  podSubnet: 192.168.0.0/16 # set to Calico's default subnet
-nodes:
-  - role: control-plane
-    image: kindest/node:v1.25.11
-    kubeadmConfigPatches:
-      - |
-        kind: InitConfiguration
-        nodeRegistration:
-          kubeletExtraArgs:
-            node-labels: "kubeslice.io/node-type=gateway"
-`
+// The kind cluster configs are marshalled from the kind section of the
+// clusters, see https://kind.sigs.k8s.io/docs/user/configuration/.
+
+type kindClusterConfig struct {
+	Kind       string         `yaml:"kind"`
+	APIVersion string         `yaml:"apiVersion"`
+	Name       string         `yaml:"name"`
+	Networking kindNetworking `yaml:"networking"`
+	Nodes      []kindNode     `yaml:"nodes"`
+}
+
+type kindNetworking struct {
+	// kindnet is replaced by Calico
+	DisableDefaultCNI bool   `yaml:"disableDefaultCNI"`
+	PodSubnet         string `yaml:"podSubnet"`
+	APIServerAddress  string `yaml:"apiServerAddress,omitempty"`
+	APIServerPort     int    `yaml:"apiServerPort,omitempty"`
+}
+
+type kindNode struct {
+	Role                 string            `yaml:"role"`
+	Image                string            `yaml:"image"`
+	ExtraPortMappings    []kindPortMapping `yaml:"extraPortMappings,omitempty"`
+	KubeadmConfigPatches []string          `yaml:"kubeadmConfigPatches,omitempty"`
+}
+
+type kindPortMapping struct {
+	ContainerPort int    `yaml:"containerPort"`
+	HostPort      int    `yaml:"hostPort"`
+	ListenAddress string `yaml:"listenAddress,omitempty"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+// enterpriseUIPortMapping exposes the NodePort of the KubeSlice Manager of the
+// enterprise demo on the host.
+var enterpriseUIPortMapping = KindPortMapping{ContainerPort: 31000, HostPort: 8443, Protocol: "TCP"}
 
 func DeleteKubeSliceDirectory() {
 	err := os.RemoveAll(kubesliceDirectory)
 	if err != nil {
-		log.Fatalf("\nFailed to delete directory %s\n", kubesliceDirectory)
+		util.Fatalf("\nFailed to delete directory %s\n", kubesliceDirectory)
 	}
 }
 
@@ -78,18 +95,185 @@ func GenerateKindConfiguration(ApplicationConfiguration *ConfigurationSpecs) {
 
 	util.CreateDirectoryPath(directory)
 
-	controllerTemplate := kubesliceControllerTemplate
-	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == ProfileEntDemo {
-		controllerTemplate = kubesliceEntControllerTemplate
+	images := kindNodeImages(&cc)
+	for i, cluster := range selectedClusters(&cc) {
+		controller := cluster == &cc.ControllerCluster
+		config, err := kindConfiguration(*cluster, images[i], !controller, controller && cc.Profile == ProfileEntDemo)
+		if err != nil {
+			util.Fatalf("%s Failed to generate the kind configuration of cluster %s: %v", util.Cross, cluster.Name, err)
+		}
+		util.DumpFile(config, directory+"/"+cluster.Name+".yaml")
+		util.Printf("%s Generated %s", util.Tick, directory+"/"+cluster.Name+".yaml")
+		time.Sleep(200 * time.Millisecond)
 	}
+}
 
-	util.DumpFile(fmt.Sprintf(controllerTemplate, cc.ControllerCluster.Name), directory+"/"+cc.ControllerCluster.Name+".yaml")
-	util.Printf("%s Generated %s", util.Tick, directory+"/"+cc.ControllerCluster.Name+".yaml")
-	time.Sleep(200 * time.Millisecond)
+// kindConfiguration is the kind config of a cluster, a control plane with the
+// kind worker nodes of the cluster. A single node worker cluster has its
+// control plane labeled as gateway node, a multi-node one its first
+// gateway_nodes worker nodes, so that they are labeled with --no-auto-label
+// too.
+func kindConfiguration(cluster Cluster, image string, worker, enterpriseController bool) (string, error) {
+	kc := cluster.Kind
+	controlPlane := kindNode{Role: "control-plane", Image: image}
+	mappings := kc.ExtraPortMappings
+	if enterpriseController {
+		mappings = append([]KindPortMapping{enterpriseUIPortMapping}, mappings...)
+	}
+	for _, mapping := range mappings {
+		controlPlane.ExtraPortMappings = append(controlPlane.ExtraPortMappings, kindPortMapping{
+			ContainerPort: mapping.ContainerPort,
+			HostPort:      mapping.HostPort,
+			ListenAddress: mapping.ListenAddress,
+			Protocol:      strings.ToUpper(mapping.Protocol),
+		})
+	}
+	if worker && kc.WorkerNodes == 0 {
+		controlPlane.KubeadmConfigPatches = []string{gatewayNodePatch}
+	}
+	config := kindClusterConfig{
+		Kind:       "Cluster",
+		APIVersion: "kind.x-k8s.io/v1alpha4",
+		Name:       cluster.Name,
+		Networking: kindNetworking{
+			DisableDefaultCNI: true,
+			// the default subnet of Calico
+			PodSubnet:        "192.168.0.0/16",
+			APIServerAddress: kc.APIServerAddress,
+			APIServerPort:    kc.APIServerPort,
+		},
+		Nodes: []kindNode{controlPlane},
+	}
+	for i := 0; i < kc.WorkerNodes; i++ {
+		node := kindNode{Role: "worker", Image: image}
+		if worker && i < gatewayNodeCount(cluster) {
+			node.KubeadmConfigPatches = []string{gatewayWorkerNodePatch}
+		}
+		config.Nodes = append(config.Nodes, node)
+	}
+	data, err := yaml.Marshal(config)
+	return string(data), err
+}
 
-	for _, cluster := range cc.WorkerClusters {
-		util.DumpFile(fmt.Sprintf(kubesliceWorkerTemplate, cluster.Name), directory+"/"+cluster.Name+".yaml")
-		util.Printf("%s Generated %s", util.Tick, directory+"/"+cluster.Name+".yaml")
-		time.Sleep(200 * time.Millisecond)
+// kindConfigNodeImages returns the node images the generated kind
+// configuration of a cluster specifies, which kind create cluster pulls.
+func kindConfigNodeImages(cluster string) ([]string, error) {
+	fileName := kubesliceDirectory + "/" + kindSubDirectory + "/" + cluster + ".yaml"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var config kindClusterConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse the kind configuration %s: %v", fileName, err)
+	}
+	images := make([]string, 0, len(config.Nodes))
+	for _, node := range config.Nodes {
+		if node.Image != "" && !containsString(images, node.Image) {
+			images = append(images, node.Image)
+		}
+	}
+	return images, nil
+}
+
+// ValidateKindConfigurations checks the kind sections of the clusters of a
+// demo profile, and that no two clusters bind the same host port.
+func ValidateKindConfigurations(cc ClusterConfiguration) []string {
+	errors := make([]string, 0)
+	if cc.Profile == "" {
+		return errors
+	}
+	// host ports bound so far, by protocol and port
+	bound := make(map[string][]hostBinding)
+	bind := func(binding hostBinding) {
+		key := fmt.Sprintf("%s/%d", binding.protocol, binding.port)
+		for _, other := range bound[key] {
+			if binding.conflicts(other) {
+				errors = append(errors, fmt.Sprintf("%s %s and %s both bind host port %s", util.Cross, binding.path, other.path, key))
+			}
+		}
+		bound[key] = append(bound[key], binding)
+	}
+	if cc.Profile == ProfileEntDemo {
+		bind(hostBinding{"configuration.cluster_configuration.controller (KubeSlice Manager)", "0.0.0.0", enterpriseUIPortMapping.HostPort, "TCP"})
+	}
+	for i, cluster := range getAllClusters(&cc) {
+		path := "configuration.cluster_configuration.controller.kind"
+		if i > 0 {
+			path = fmt.Sprintf("configuration.cluster_configuration.workers[%d].kind", i-1)
+		}
+		kc := cluster.Kind
+		if kc.WorkerNodes < 0 {
+			errors = append(errors, fmt.Sprintf("%s %s.worker_nodes: must not be negative", util.Cross, path))
+		}
+		if kc.APIServerAddress != "" && net.ParseIP(kc.APIServerAddress) == nil {
+			errors = append(errors, fmt.Sprintf("%s %s.api_server_address: %s is not an IP address", util.Cross, path, kc.APIServerAddress))
+		}
+		if kc.APIServerPort < 0 || kc.APIServerPort > 65535 {
+			errors = append(errors, fmt.Sprintf("%s %s.api_server_port: %d is not a port", util.Cross, path, kc.APIServerPort))
+		} else if kc.APIServerPort > 0 {
+			address := kc.APIServerAddress
+			if address == "" {
+				address = "127.0.0.1"
+			}
+			bind(hostBinding{path + ".api_server_port", address, kc.APIServerPort, "TCP"})
+		}
+		for j, mapping := range kc.ExtraPortMappings {
+			mappingPath := fmt.Sprintf("%s.extra_port_mappings[%d]", path, j)
+			protocol := strings.ToUpper(mapping.Protocol)
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			switch {
+			case mapping.ContainerPort < 1 || mapping.ContainerPort > 65535:
+				errors = append(errors, fmt.Sprintf("%s %s.container_port: %d is not a port", util.Cross, mappingPath, mapping.ContainerPort))
+			case mapping.HostPort < 1 || mapping.HostPort > 65535:
+				errors = append(errors, fmt.Sprintf("%s %s.host_port: %d is not a port", util.Cross, mappingPath, mapping.HostPort))
+			case !containsString([]string{"TCP", "UDP", "SCTP"}, protocol):
+				errors = append(errors, fmt.Sprintf("%s %s.protocol: unknown protocol %s. Possible values %s", util.Cross, mappingPath, mapping.Protocol, []string{"TCP", "UDP", "SCTP"}))
+			case mapping.ListenAddress != "" && net.ParseIP(mapping.ListenAddress) == nil:
+				errors = append(errors, fmt.Sprintf("%s %s.listen_address: %s is not an IP address", util.Cross, mappingPath, mapping.ListenAddress))
+			default:
+				address := mapping.ListenAddress
+				if address == "" {
+					address = "0.0.0.0"
+				}
+				bind(hostBinding{mappingPath, address, mapping.HostPort, protocol})
+			}
+		}
+	}
+	return errors
+}
+
+// hostBinding is a host port a kind cluster binds.
+type hostBinding struct {
+	path     string
+	address  string
+	port     int
+	protocol string
+}
+
+// conflicts tells whether two bindings of the same port and protocol
+// overlap, the unspecified address overlapping any other.
+func (b hostBinding) conflicts(other hostBinding) bool {
+	unspecified := func(address string) bool { return net.ParseIP(address).IsUnspecified() }
+	return b.address == other.address || unspecified(b.address) || unspecified(other.address)
+}
+
+// checkKindVersion fails when the kind in use is older than the one reading
+// the generated configs.
+func checkKindVersion() {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kind", &outB, &errB, true, "version"); err != nil {
+		util.Fatalf("%s Unable to query the version of kind: %s", util.Cross, commandError(err, &errB))
+	}
+	// kind v0.20.0 go1.20.4 linux/amd64
+	fields := strings.Fields(outB.String())
+	if len(fields) < 2 || !patchVersion.MatchString(strings.SplitN(fields[1], "-", 2)[0]) {
+		util.Printf("%s Unable to parse the version of kind from %q, the kind configs need kind v%s or later", util.Warn, strings.TrimSpace(outB.String()), minKindVersion)
+		return
+	}
+	if compareVersions(fields[1], minKindVersion) < 0 {
+		util.Fatalf("%s kind %s is older than v%s, which the generated kind configs need. Please upgrade kind", util.Cross, fields[1], minKindVersion)
 	}
 }
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestKindConfiguration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                 string
+		cluster              Cluster
+		worker               bool
+		enterpriseController bool
+		golden               string
+	}{
+		{
+			name:                 "Enterprise controller",
+			cluster:              Cluster{Name: "ks-ctrl"},
+			enterpriseController: true,
+			golden: `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+name: ks-ctrl
+networking:
+  disableDefaultCNI: true
+  podSubnet: 192.168.0.0/16
+nodes:
+- role: control-plane
+  image: kindest/node:v1.27.3
+  extraPortMappings:
+  - containerPort: 31000
+    hostPort: 8443
+    protocol: TCP
+`,
+		},
+		{
+			name:    "Single node worker",
+			cluster: Cluster{Name: "ks-w-1"},
+			worker:  true,
+			golden: `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+name: ks-w-1
+networking:
+  disableDefaultCNI: true
+  podSubnet: 192.168.0.0/16
+nodes:
+- role: control-plane
+  image: kindest/node:v1.27.3
+  kubeadmConfigPatches:
+  - |
+    kind: InitConfiguration
+    nodeRegistration:
+      kubeletExtraArgs:
+        node-labels: "kubeslice.io/node-type=gateway"
+`,
+		},
+		{
+			name: "Multi-node worker",
+			cluster: Cluster{Name: "ks-w-2", Kind: KindClusterConfiguration{
+				WorkerNodes:       2,
+				ExtraPortMappings: []KindPortMapping{{ContainerPort: 30080, HostPort: 8080, ListenAddress: "127.0.0.1", Protocol: "udp"}},
+				APIServerAddress:  "0.0.0.0",
+				APIServerPort:     6443,
+			}},
+			worker: true,
+			golden: `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+name: ks-w-2
+networking:
+  disableDefaultCNI: true
+  podSubnet: 192.168.0.0/16
+  apiServerAddress: 0.0.0.0
+  apiServerPort: 6443
+nodes:
+- role: control-plane
+  image: kindest/node:v1.27.3
+  extraPortMappings:
+  - containerPort: 30080
+    hostPort: 8080
+    listenAddress: 127.0.0.1
+    protocol: UDP
+- role: worker
+  image: kindest/node:v1.27.3
+  kubeadmConfigPatches:
+  - |
+    kind: JoinConfiguration
+    nodeRegistration:
+      kubeletExtraArgs:
+        node-labels: "kubeslice.io/node-type=gateway"
+- role: worker
+  image: kindest/node:v1.27.3
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config, err := kindConfiguration(tc.cluster, "kindest/node:v1.27.3", tc.worker, tc.enterpriseController)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config != tc.golden {
+				t.Errorf("kindConfiguration() =\n%s\nwant\n%s", config, tc.golden)
+			}
+		})
+	}
+}
+
+func TestValidateKindConfigurations(t *testing.T) {
+	t.Parallel()
+	cc := ClusterConfiguration{
+		Profile: ProfileEntDemo,
+		ControllerCluster: Cluster{Name: "ks-ctrl", Kind: KindClusterConfiguration{
+			APIServerPort: 6443,
+		}},
+		WorkerClusters: []Cluster{
+			{Name: "ks-w-1", Kind: KindClusterConfiguration{
+				APIServerAddress:  "0.0.0.0",
+				APIServerPort:     6443,
+				ExtraPortMappings: []KindPortMapping{{ContainerPort: 30080, HostPort: 8443}},
+			}},
+			{Name: "ks-w-2", Kind: KindClusterConfiguration{
+				WorkerNodes: -1,
+				ExtraPortMappings: []KindPortMapping{
+					{ContainerPort: 30080, HostPort: 8443, Protocol: "udp"},
+					{ContainerPort: 30081, HostPort: 9090, Protocol: "http"},
+				},
+			}},
+		},
+	}
+	expected := []string{
+		"configuration.cluster_configuration.workers[0].kind.api_server_port and configuration.cluster_configuration.controller.kind.api_server_port both bind host port TCP/6443",
+		"configuration.cluster_configuration.workers[0].kind.extra_port_mappings[0] and configuration.cluster_configuration.controller (KubeSlice Manager) both bind host port TCP/8443",
+		"configuration.cluster_configuration.workers[1].kind.worker_nodes: must not be negative",
+		"configuration.cluster_configuration.workers[1].kind.extra_port_mappings[1].protocol: unknown protocol http. Possible values [TCP UDP SCTP]",
+	}
+	got := make([]string, 0)
+	for _, e := range ValidateKindConfigurations(cc) {
+		got = append(got, strings.TrimPrefix(e, util.Cross+" "))
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ValidateKindConfigurations() =\n%q\nwant\n%q", got, expected)
+	}
+}
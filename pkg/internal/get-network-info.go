@@ -3,7 +3,6 @@ package internal
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -13,9 +12,11 @@ import (
 func GatherNetworkInformation(ApplicationConfiguration *ConfigurationSpecs) {
 	util.Printf("\nFetching Network Address for Clusters...")
 
-	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == "" && ApplicationConfiguration.Configuration.ClusterConfiguration.ClusterType != "kind" {
+	// only kind clusters are reached over the docker network of kind, the
+	// addresses of the others are read from their kubeconfig and nodes
+	if !kindTopology(ApplicationConfiguration.Configuration.ClusterConfiguration) {
 		setControlPlaneAddress(&ApplicationConfiguration.Configuration.ClusterConfiguration)
-		setNodeIP(&ApplicationConfiguration.Configuration.ClusterConfiguration)
+		setNodeIPs(&ApplicationConfiguration.Configuration.ClusterConfiguration)
 	} else {
 		setNodeIPForKindClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)
 	}
@@ -26,8 +27,13 @@ func GatherNetworkInformation(ApplicationConfiguration *ConfigurationSpecs) {
 func setNodeIPForKindClusters(clusterConfig *ClusterConfiguration) {
 	clusters := getAllClusters(clusterConfig)
 	for _, cluster := range clusters {
-		ip := runDockerInspectForNodeIP(cluster.Name)
+		ip := kindControlPlaneIP(cluster)
 		cluster.NodeIP = ip
+		// node_ips set in the topology are kept, the guessed address is only
+		// the one of the control plane then
+		if len(cluster.NodeIPs) > 0 {
+			cluster.NodeIP = cluster.NodeIPs[0]
+		}
 		cluster.ControlPlaneAddress = "https://" + ip + ":6443"
 		util.Printf("%s Fetched Network Address for %s : %s", util.Tick, cluster.Name, ip)
 		time.Sleep(200 * time.Millisecond)
@@ -35,12 +41,32 @@ func setNodeIPForKindClusters(clusterConfig *ClusterConfiguration) {
 	}
 }
 
+// kindControlPlaneIP returns the address of the control plane node of a kind
+// cluster on the kind network, from docker when it was verified and from the
+// InternalIP of the node otherwise.
+func kindControlPlaneIP(cluster *Cluster) string {
+	if util.ExecutablePaths["docker"] != "" {
+		return runDockerInspectForNodeIP(cluster.Name)
+	}
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "nodes", "-l", "node-role.kubernetes.io/control-plane", "-o", nodeAddressesJSONPath)
+	if err != nil {
+		util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, outB.String(), errB.String(), err)
+		util.Exit(1)
+	}
+	ips, _ := detectNodeIPs(outB.String(), false)
+	if len(ips) == 0 {
+		util.Fatalf("%s The control plane node of %s has no address, set node_ips in the topology", util.Cross, cluster.Name)
+	}
+	return ips[0]
+}
+
 func runDockerInspectForNodeIP(clusterName string) string {
 	var outB, errB bytes.Buffer
 	err := util.RunCommandCustomIO("docker", &outB, &errB, true, "inspect", "--format={{.NetworkSettings.Networks.kind.IPAddress}}", fmt.Sprintf("%s-control-plane", clusterName))
 	if err != nil {
 		util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, outB.String(), errB.String(), err)
-		os.Exit(1)
+		util.Exit(1)
 	}
 	return strings.TrimSpace(outB.String())
 }
@@ -60,33 +86,7 @@ func _getControlPlaneAddress(cluster *Cluster) string {
 	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "config", "view", "--minify=true", "-o", "jsonpath={.clusters[0].cluster.server}")
 	if err != nil {
 		util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, outB.String(), errB.String(), err)
-		os.Exit(1)
+		util.Exit(1)
 	}
 	return outB.String()
 }
-
-func setNodeIP(clusterConfig *ClusterConfiguration) {
-	for _, cluster := range getAllClusters(clusterConfig) {
-		if cluster.NodeIP == "" {
-			ip := _getNodeIP(cluster)
-			cluster.NodeIP = ip
-			util.Printf("%s Node IP fetched %s for %s", util.Tick, cluster.NodeIP, cluster.Name)
-		}
-	}
-}
-
-func _getNodeIP(cluster *Cluster) string {
-	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "nodes", "-o", "jsonpath={\"ExternalIP=\"}{.items[0].status.addresses[?(@.type==\"ExternalIP\")].address}{\"\\n\"}{\"InternalIP=\"}{.items[0].status.addresses[?(@.type==\"InternalIP\")].address}")
-	if err != nil {
-		util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, outB.String(), errB.String(), err)
-		os.Exit(1)
-	}
-	for _, s := range strings.Split(outB.String(), "\n") {
-		splits := strings.Split(s, "=")
-		if strings.TrimSpace(splits[1]) != "" {
-			return strings.TrimSpace(splits[1])
-		}
-	}
-	return ""
-}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// helmRelease is an entry of `helm list --output json`.
+type helmRelease struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+}
+
+// chartVersion returns the version of the chart the release was installed
+// from.
+func (r helmRelease) chartVersion(chartName string) string {
+	return strings.TrimPrefix(r.Chart, chartName+"-")
+}
+
+// pending reports whether the release is in a state helm cannot upgrade from.
+func (r helmRelease) pending() bool {
+	return r.Status == "failed" || r.Status == "uninstalling" || strings.HasPrefix(r.Status, "pending-")
+}
+
+// findRelease returns the release of the given name in the namespace of the
+// cluster, in whatever state it is, or nil if there is none.
+func findRelease(cluster Cluster, release, namespace string) (*helmRelease, error) {
+	releases, err := chartInstaller.List(cluster, namespace, release)
+	if err != nil || len(releases) == 0 {
+		return nil, err
+	}
+	return &releases[0], nil
+}
+
+// runHelmList runs `helm list --output json` and parses the releases.
+func runHelmList(cluster Cluster, namespace string, args ...string) ([]helmRelease, error) {
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list releases of namespace %s on %s: %s", namespace, cluster.Name, commandError(err, &errB))
+	}
+	releases := make([]helmRelease, 0)
+	if len(bytes.TrimSpace(outB.Bytes())) == 0 {
+		return releases, nil
+	}
+	if err := json.Unmarshal(outB.Bytes(), &releases); err != nil {
+		return nil, fmt.Errorf("unable to parse releases of namespace %s on %s: %v", namespace, cluster.Name, err)
+	}
+	return releases, nil
+}
+
+// prepareRelease checks for an existing release before a chart is installed,
+// so that re-running an install upgrades what a previous run left behind. A
+// release stuck in a failed or pending state is not upgraded blindly, the run
+// fails pointing to rollback and uninstall instead.
+func prepareRelease(cluster Cluster, release, namespace string, chart HelmChart) {
+	existing, err := findRelease(cluster, release, namespace)
+	if err != nil {
+		util.Printf("%s %v, installing release %s", util.Warn, err, release)
+		return
+	}
+	if existing == nil {
+		return
+	}
+	if existing.pending() {
+		util.Fatalf("%s Release %s in namespace %s on %s is in state %s. Roll it back with `helm rollback %s --namespace %s` or remove it with `kubeslice-cli uninstall` before installing again",
+			util.Cross, release, namespace, cluster.Name, existing.Status, release, namespace)
+	}
+	target := chart.Version
+	if target == "" {
+		target = "latest"
+	}
+	util.Printf("%s Upgrading existing release %s on %s from version %s to %s", util.Run, release, cluster.Name, existing.chartVersion(chart.ChartName), target)
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestFindRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected *helmRelease
+		pending  bool
+	}{
+		{
+			name:   "No release",
+			output: "[]",
+		},
+		{
+			name:     "Deployed release",
+			output:   `[{"name":"kubeslice-worker","namespace":"kubeslice-system","revision":"2","status":"deployed","chart":"kubeslice-worker-1.2.0","app_version":"1.2.0"}]`,
+			expected: &helmRelease{Name: "kubeslice-worker", Namespace: "kubeslice-system", Revision: "2", Status: "deployed", Chart: "kubeslice-worker-1.2.0", AppVersion: "1.2.0"},
+		},
+		{
+			name:     "Release stuck in pending install",
+			output:   `[{"name":"kubeslice-worker","status":"pending-install","chart":"kubeslice-worker-1.2.0"}]`,
+			expected: &helmRelease{Name: "kubeslice-worker", Status: "pending-install", Chart: "kubeslice-worker-1.2.0"},
+			pending:  true,
+		},
+		{
+			name:     "Failed release",
+			output:   `[{"name":"kubeslice-worker","status":"failed","chart":"kubeslice-worker-1.2.0"}]`,
+			expected: &helmRelease{Name: "kubeslice-worker", Status: "failed", Chart: "kubeslice-worker-1.2.0"},
+			pending:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{
+				Handler: func(command []string, stdout, stderr io.Writer) error {
+					fmt.Fprint(stdout, tc.output)
+					return nil
+				},
+			}
+			defer util.UseExecutor(mock)()
+
+			got, err := findRelease(Cluster{Name: "ks-w-1"}, "kubeslice-worker", "kubeslice-system")
+			if err != nil {
+				t.Fatalf("findRelease() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("findRelease() = %+v, want %+v", got, tc.expected)
+			}
+			if got != nil {
+				if got.pending() != tc.pending {
+					t.Errorf("pending() = %v, want %v", got.pending(), tc.pending)
+				}
+				if version := got.chartVersion("kubeslice-worker"); version != "1.2.0" {
+					t.Errorf("chartVersion() = %q, want %q", version, "1.2.0")
+				}
+			}
+		})
+	}
+}
@@ -1,76 +1,236 @@
 package internal
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
 )
 
-const imagePullSecretsTemplate = `
-
-imagePullSecrets:
-  repository: %s
-  username: %s
-  password: %s
-  %s
-
-`
-
 func AddHelmCharts(ApplicationConfiguration *ConfigurationSpecs) {
 	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
 	// helm repo add avesha https://kubeslice.github.io/kubeslice/
-	if hc.UseLocal {
+	if hc.UseLocal || AllChartsLocal(hc) {
 		util.Printf("\nUsing Local Helm Charts...")
-	} else {
-		util.Printf("\nAdding KubeSlice Helm Charts...")
-
-		addHelmChart(ApplicationConfiguration)
-		util.Printf("%s Successfully added helm repo %s : %s", util.Tick, hc.RepoAlias, hc.RepoUrl)
-		time.Sleep(200 * time.Millisecond)
-
-		updateHelmChart()
-		util.Printf("%s Successfully updated helm repo", util.Tick)
-		time.Sleep(200 * time.Millisecond)
+		return
+	}
+	added := make([]string, 0)
+	for _, repo := range chartRepositories(hc) {
+		if IsOCIRepo(repo.RepoUrl) {
+			util.Printf("\nUsing KubeSlice Helm Charts from OCI registry %s...", repo.RepoUrl)
+			loginHelmRegistry(repo)
+		} else if helmRepoUpToDate(repo) {
+			util.Printf("\n%s Using cached index of helm repo %s : %s\n", util.Tick, repo.RepoAlias, repo.RepoUrl)
+		} else {
+			util.Printf("\nAdding KubeSlice Helm Charts...")
 
-		util.Printf("%s Successfully added helm charts.\n", util.Tick)
+			addHelmChart(repo)
+			util.Printf("%s Successfully added helm repo %s : %s", util.Tick, repo.RepoAlias, repo.RepoUrl)
+			time.Sleep(200 * time.Millisecond)
+			added = append(added, repo.RepoUrl)
+		}
+	}
+	if len(added) == 0 {
+		return
 	}
+	updateHelmChart()
+	for _, url := range added {
+		recordHelmRepoUpdate(url)
+	}
+	util.Printf("%s Successfully updated helm repo", util.Tick)
+	time.Sleep(200 * time.Millisecond)
+
+	util.Printf("%s Successfully added helm charts.\n", util.Tick)
 }
 
-func addHelmChart(ApplicationConfiguration *ConfigurationSpecs) {
-	hc := ApplicationConfiguration.Configuration.HelmChartConfiguration
+// addHelmChart adds the chart repository of hc, replacing an existing
+// repository of the same alias.
+func addHelmChart(hc HelmChartConfiguration) {
 	repoAddCommands := make([]string, 0)
 	repoAddCommands = append(repoAddCommands, "repo", "add", hc.RepoAlias, hc.RepoUrl, "--force-update")
-	if hc.HelmUsername != "" && hc.HelmPassword != "" {
-		repoAddCommands = append(repoAddCommands, "--pass-credentials", "--username", hc.HelmUsername, "--password", hc.HelmPassword)
+	repoAddCommands = append(repoAddCommands, helmRepoCredentials(hc)...)
+	repoAddCommands = append(repoAddCommands, helmTLSFlags(hc, false)...)
+	runHelmRepoCommand(hc.RepoUrl, false, repoAddCommands...)
+}
+
+// helmRepoCredentials returns the helm arguments authenticating against the
+// chart repository, if credentials are configured.
+func helmRepoCredentials(hc HelmChartConfiguration) []string {
+	if hc.HelmUsername == "" || hc.HelmPassword == "" {
+		return nil
 	}
-	err := util.RunCommand("helm", repoAddCommands...)
-	if err != nil {
-		log.Fatalf("Process failed %v", err)
+	return []string{"--pass-credentials", "--username", hc.HelmUsername, "--password", hc.HelmPassword}
+}
+
+// runHelmRepoCommand runs a helm command talking to the chart repository and
+// returns its output. When the repository rejects the request or cannot be
+// reached, a hint is printed instead of the raw helm output.
+func runHelmRepoCommand(repoUrl string, suppressPrint bool, args ...string) []byte {
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, suppressPrint, args...)
+	if err == nil {
+		return outB.Bytes()
+	}
+	if hint := proxyErrorHint(errB.String()); hint != "" {
+		util.Fatalf("%s Failed to access helm repository %s: %s", util.Cross, repoUrl, hint)
+	}
+	if hint := helmRepoErrorHint(errB.String()); hint != "" {
+		util.Fatalf("%s Failed to access helm repository %s: %s", util.Cross, repoUrl, hint)
+	}
+	util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, util.Redact(outB.String()), util.Redact(errB.String()), err)
+	util.Fatalf("Process failed %v", err)
+	return nil
+}
+
+// helmRepoErrorHint explains authentication and connectivity failures in the
+// helm output.
+func helmRepoErrorHint(stderr string) string {
+	lower := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(lower, "401") || strings.Contains(lower, "unauthorized"):
+		return "the repository requires authentication (401). The credentials may be missing or wrong, check configuration.helm_chart_configuration.helm_username and helm_password"
+	case strings.Contains(lower, "403") || strings.Contains(lower, "forbidden"):
+		return "access was denied (403). Check that the configured helm credentials may read from the repository"
+	case isConnectivityError(stderr):
+		return "the repository could not be reached. Check the network connectivity and the configured repo_url, or use local_path charts for offline installs"
+	}
+	return ""
+}
+
+// isConnectivityError reports whether the output of a command shows that a
+// host could not be reached.
+func isConnectivityError(output string) bool {
+	lower := strings.ToLower(output)
+	for _, s := range []string{"no such host", "connection refused", "timeout", "network is unreachable", "proxyconnect"} {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOCIRepo reports whether the charts are pulled from an OCI registry, which
+// helm addresses directly instead of through a repo alias.
+func IsOCIRepo(repoUrl string) bool {
+	return strings.HasPrefix(repoUrl, "oci://")
+}
+
+// chartReference returns the reference helm installs the chart from.
+func chartReference(hc HelmChartConfiguration, chart HelmChart) string {
+	if chart.LocalPath != "" {
+		return chart.LocalPath
+	}
+	hc = hc.forChart(chart)
+	if !hc.UseLocal && IsOCIRepo(hc.RepoUrl) {
+		return strings.TrimSuffix(hc.RepoUrl, "/") + "/" + chart.ChartName
+	}
+	return fmt.Sprintf("%s/%s", hc.RepoAlias, chart.ChartName)
+}
+
+// loginHelmRegistry logs in to the OCI registry of the charts with the helm
+// credentials, or with the image pull secret when it is for the same host.
+func loginHelmRegistry(hc HelmChartConfiguration) {
+	host := strings.SplitN(strings.TrimPrefix(hc.RepoUrl, "oci://"), "/", 2)[0]
+	username, password := hc.HelmUsername, hc.HelmPassword
+	if username == "" || password == "" {
+		for _, ips := range hc.ImagePullSecrets {
+			if registryHost(ips.Registry) == host {
+				username, password = ips.Username, ips.Password
+				break
+			}
+		}
 	}
+	if username == "" || password == "" {
+		return
+	}
+	util.RegisterSecret(password)
+	runHelmRepoCommand(hc.RepoUrl, false, append([]string{"registry", "login", host, "--username", username, "--password", password}, helmTLSFlags(hc, true)...)...)
+	util.Printf("%s Successfully logged in to helm registry %s", util.Tick, host)
+}
+
+// registryHost returns the host of a registry endpoint like
+// https://index.docker.io/v1/.
+func registryHost(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	return strings.SplitN(registry, "/", 2)[0]
 }
 
 func updateHelmChart() {
 	err := util.RunCommand("helm", "repo", "update")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
-func generateImagePullSecretsValue(ImagePullSecret ImagePullSecrets) string {
-	imagePullSecretsValue := ""
-	ips := ImagePullSecret
-	if ips.Username != "" && ips.Password != "" {
-		email := ""
-		if ips.Email != "" {
-			email = "email: " + ips.Email
+// generateImagePullSecretsValue renders the imagePullSecrets value of the
+// KubeSlice charts. A single registry uses the repository/username/password
+// fields, several registries are combined into one dockerconfigjson.
+// Entries without username or password, like secret references, are ignored.
+func generateImagePullSecretsValue(secrets ImagePullSecretList) string {
+	complete := make([]ImagePullSecrets, 0, len(secrets))
+	for _, ips := range secrets {
+		if ips.Username == "" || ips.Password == "" {
+			continue
 		}
 		// setting default registry
 		if ips.Registry == "" {
 			ips.Registry = "https://index.docker.io/v1/"
 		}
-		imagePullSecretsValue = fmt.Sprintf(imagePullSecretsTemplate, ips.Registry, ips.Username, ips.Password, email)
+		complete = append(complete, ips)
+	}
+	if len(complete) == 0 {
+		return ""
+	}
+	// the credentials are marshalled rather than templated, so that quotes,
+	// backslashes or colons in them cannot break the document
+	value := map[string]string{}
+	if len(complete) == 1 {
+		ips := complete[0]
+		value["repository"] = ips.Registry
+		value["username"] = ips.Username
+		value["password"] = ips.Password
+		if ips.Email != "" {
+			value["email"] = ips.Email
+		}
+	} else {
+		value["dockerconfigjson"] = dockerConfigJSON(complete)
+	}
+	return marshalImagePullSecrets(value)
+}
+
+// marshalImagePullSecrets renders value as the imagePullSecrets value
+// document appended to the chart defaults.
+func marshalImagePullSecrets(value interface{}) string {
+	out, err := yaml.Marshal(map[string]interface{}{"imagePullSecrets": value})
+	if err != nil {
+		util.Fatalf("%s Failed to encode image pull secrets: %v", util.Cross, err)
+	}
+	return "\n" + string(out)
+}
+
+// dockerConfigJSON returns the base64 encoded .dockerconfigjson holding the
+// credentials of all registries.
+func dockerConfigJSON(secrets []ImagePullSecrets) string {
+	type auth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Email    string `json:"email,omitempty"`
+		Auth     string `json:"auth"`
+	}
+	auths := make(map[string]auth)
+	for _, ips := range secrets {
+		auths[ips.Registry] = auth{
+			Username: ips.Username,
+			Password: ips.Password,
+			Email:    ips.Email,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(ips.Username + ":" + ips.Password)),
+		}
 	}
-	return imagePullSecretsValue
+	data, _ := json.Marshal(map[string]interface{}{"auths": auths})
+	return base64.StdEncoding.EncodeToString(data)
 }
@@ -1,9 +1,13 @@
 package internal
 
 import (
-	"fmt"
-	"strings"
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
 )
 
 func TestGenerateImagePullSecretsValue(t *testing.T) {
@@ -11,55 +15,91 @@ func TestGenerateImagePullSecretsValue(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		input    ImagePullSecrets
-		expected string
+		input    ImagePullSecretList
+		expected map[string]string
 	}{
 		{
 			name: "All fields provided",
-			input: ImagePullSecrets{
+			input: ImagePullSecretList{{
 				Registry: "my-registry.com",
 				Username: "user",
 				Password: "password123",
 				Email:    "user@example.com",
-			},
-			expected: fmt.Sprintf(imagePullSecretsTemplate, "my-registry.com", "user", "password123", "email: user@example.com"),
+			}},
+			expected: map[string]string{"repository": "my-registry.com", "username": "user", "password": "password123", "email": "user@example.com"},
 		},
 		{
 			name: "Default registry used when registry is empty",
-			input: ImagePullSecrets{
+			input: ImagePullSecretList{{
 				Username: "user",
 				Password: "password123",
 				Email:    "user@example.com",
-			},
-			expected: fmt.Sprintf(imagePullSecretsTemplate, "https://index.docker.io/v1/", "user", "password123", "email: user@example.com"),
+			}},
+			expected: map[string]string{"repository": "https://index.docker.io/v1/", "username": "user", "password": "password123", "email": "user@example.com"},
 		},
 		{
 			name: "Email is optional",
-			input: ImagePullSecrets{
+			input: ImagePullSecretList{{
 				Registry: "my-registry.com",
 				Username: "user",
 				Password: "password123",
-			},
-			expected: fmt.Sprintf(imagePullSecretsTemplate, "my-registry.com", "user", "password123", ""),
+			}},
+			expected: map[string]string{"repository": "my-registry.com", "username": "user", "password": "password123"},
+		},
+		{
+			name: "Quotes, backslashes and colons are preserved",
+			input: ImagePullSecretList{{
+				Registry: "my-registry.com",
+				Username: "robot$ci@example.com",
+				Password: `p"a\ss: #word'`,
+			}},
+			expected: map[string]string{"repository": "my-registry.com", "username": "robot$ci@example.com", "password": `p"a\ss: #word'`},
+		},
+		{
+			name: "Unicode and format verbs are preserved",
+			input: ImagePullSecretList{{
+				Registry: "my-registry.com",
+				Username: "user",
+				Password: "pässwörd-%s-🔑",
+			}},
+			expected: map[string]string{"repository": "my-registry.com", "username": "user", "password": "pässwörd-%s-🔑"},
 		},
 		{
 			name: "Returns empty string if username is missing",
-			input: ImagePullSecrets{
+			input: ImagePullSecretList{{
 				Password: "password123",
-			},
-			expected: "",
+			}},
 		},
 		{
 			name: "Returns empty string if password is missing",
-			input: ImagePullSecrets{
+			input: ImagePullSecretList{{
 				Username: "user",
+			}},
+		},
+		{
+			name:  "Returns empty string without entries",
+			input: ImagePullSecretList{},
+		},
+		{
+			name: "Two registries are combined into a dockerconfigjson",
+			input: ImagePullSecretList{
+				{Registry: "harbor.example.com", Username: "user", Password: `pa"ss`},
+				{Username: "hub", Password: "hub-pass"},
 			},
-			expected: "",
+			expected: map[string]string{"dockerconfigjson": base64.StdEncoding.EncodeToString([]byte(
+				`{"auths":{"harbor.example.com":{"username":"user","password":"pa\"ss","auth":"dXNlcjpwYSJzcw=="},"https://index.docker.io/v1/":{"username":"hub","password":"hub-pass","auth":"aHViOmh1Yi1wYXNz"}}}`))},
 		},
 		{
-			name:     "Returns empty string for empty input struct",
-			input:    ImagePullSecrets{},
-			expected: "",
+			name: "Incomplete entries are ignored",
+			input: ImagePullSecretList{
+				{Registry: "harbor.example.com", Username: "user"},
+				{Registry: "my-registry.com", Username: "user", Password: "password123"},
+			},
+			expected: map[string]string{"repository": "my-registry.com", "username": "user", "password": "password123"},
+		},
+		{
+			name:  "Returns empty string for empty input struct",
+			input: ImagePullSecretList{{}},
 		},
 	}
 
@@ -69,13 +109,215 @@ func TestGenerateImagePullSecretsValue(t *testing.T) {
 			t.Parallel()
 
 			got := generateImagePullSecretsValue(tc.input)
+			if tc.expected == nil {
+				if got != "" {
+					t.Errorf("generateImagePullSecretsValue() = %q, want empty string", got)
+				}
+				return
+			}
+			// the value must round trip through YAML unchanged
+			values := struct {
+				ImagePullSecrets map[string]string `yaml:"imagePullSecrets"`
+			}{}
+			if err := yaml.Unmarshal([]byte(got), &values); err != nil {
+				t.Fatalf("generateImagePullSecretsValue() returned invalid YAML %q: %v", got, err)
+			}
+			if !reflect.DeepEqual(values.ImagePullSecrets, tc.expected) {
+				t.Errorf("generateImagePullSecretsValue() mismatch:\nwant: %q\ngot:  %q", tc.expected, values.ImagePullSecrets)
+			}
+		})
+	}
+}
+
+func TestImagePullSecretListUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected ImagePullSecretList
+	}{
+		{
+			name:     "Legacy single object",
+			input:    "image_pull_secret:\n  registry: harbor.example.com\n  username: user\n  password: pass\n",
+			expected: ImagePullSecretList{{Registry: "harbor.example.com", Username: "user", Password: "pass"}},
+		},
+		{
+			name:  "List of registries",
+			input: "image_pull_secret:\n- registry: harbor.example.com\n  username: user\n- username: hub\n",
+			expected: ImagePullSecretList{
+				{Registry: "harbor.example.com", Username: "user"},
+				{Username: "hub"},
+			},
+		},
+		{
+			name:     "Not set",
+			input:    "use_local: true\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hc := HelmChartConfiguration{}
+			if err := yaml.Unmarshal([]byte(tc.input), &hc); err != nil {
+				t.Fatalf("yaml.Unmarshal() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(hc.ImagePullSecrets, tc.expected) {
+				t.Errorf("ImagePullSecrets = %#v, want %#v", hc.ImagePullSecrets, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHelmChartCommandSequence(t *testing.T) {
+	defer func(file string) { helmRepoUpdatesFile = file }(helmRepoUpdatesFile)
+
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		expected [][]string
+	}{
+		{
+			name: "HTTP repo is added and updated",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.0.0"},
+			},
+			expected: [][]string{
+				{"helm", "repo", "add", "kubeslice", "https://kubeslice.github.io/kubeslice/", "--force-update"},
+				{"helm", "repo", "update"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+				{"helm", "upgrade", "-i", "kubeslice-controller", "kubeslice/kubeslice-controller", "--namespace", "kubeslice-controller", "-f", "values.yaml", "--wait", "--timeout", "5m0s", "--version", "1.0.0"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+			},
+		},
+		{
+			name: "HTTP repo with credentials",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://charts.example.com",
+				HelmUsername:    "user",
+				HelmPassword:    "pass",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+			},
+			expected: [][]string{
+				{"helm", "repo", "add", "kubeslice", "https://charts.example.com", "--force-update", "--pass-credentials", "--username", "user", "--password", "pass"},
+				{"helm", "repo", "update"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+				{"helm", "upgrade", "-i", "kubeslice-controller", "kubeslice/kubeslice-controller", "--namespace", "kubeslice-controller", "-f", "values.yaml", "--wait", "--timeout", "5m0s"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+			},
+		},
+		{
+			name: "Worker chart from a mirror is added as a second repo",
+			hc: HelmChartConfiguration{
+				RepoAlias:       "kubeslice",
+				RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+				WorkerChart:     HelmChart{ChartName: "kubeslice-worker", RepoAlias: "mirror", RepoUrl: "https://charts.example.com", HelmUsername: "user", HelmPassword: "pass"},
+			},
+			expected: [][]string{
+				{"helm", "repo", "add", "kubeslice", "https://kubeslice.github.io/kubeslice/", "--force-update"},
+				{"helm", "repo", "add", "mirror", "https://charts.example.com", "--force-update", "--pass-credentials", "--username", "user", "--password", "pass"},
+				{"helm", "repo", "update"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+				{"helm", "upgrade", "-i", "kubeslice-controller", "kubeslice/kubeslice-controller", "--namespace", "kubeslice-controller", "-f", "values.yaml", "--wait", "--timeout", "5m0s"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+			},
+		},
+		{
+			name: "OCI registry without credentials",
+			hc: HelmChartConfiguration{
+				RepoUrl:         "oci://ghcr.io/kubeslice/charts/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.0.0"},
+			},
+			expected: [][]string{
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+				{"helm", "upgrade", "-i", "kubeslice-controller", "oci://ghcr.io/kubeslice/charts/kubeslice-controller", "--namespace", "kubeslice-controller", "-f", "values.yaml", "--wait", "--timeout", "5m0s", "--version", "1.0.0"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+			},
+		},
+		{
+			name: "OCI registry login with the image pull secret of the same host",
+			hc: HelmChartConfiguration{
+				RepoUrl:          "oci://harbor.example.com/kubeslice",
+				ControllerChart:  HelmChart{ChartName: "kubeslice-controller"},
+				ImagePullSecrets: ImagePullSecretList{{Registry: "https://index.docker.io/v1/", Username: "hub", Password: "hub-pass"}, {Registry: "https://harbor.example.com/v2/", Username: "user", Password: "pass"}},
+			},
+			expected: [][]string{
+				{"helm", "registry", "login", "harbor.example.com", "--username", "user", "--password", "pass"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+				{"helm", "upgrade", "-i", "kubeslice-controller", "oci://harbor.example.com/kubeslice/kubeslice-controller", "--namespace", "kubeslice-controller", "-f", "values.yaml", "--wait", "--timeout", "5m0s"},
+				{"helm", "list", "--namespace", "kubeslice-controller", "--filter", "^kubeslice-controller$", "--all", "--output", "json"},
+			},
+		},
+	}
 
-			// Trim whitespace to make the comparison robust
-			gotTrimmed := strings.TrimSpace(got)
-			expectedTrimmed := strings.TrimSpace(tc.expected)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			helmRepoUpdatesFile = filepath.Join(t.TempDir(), "helm-repo-updates.yaml")
+			mock := &util.MockExecutor{}
+			defer util.UseExecutor(mock)()
+
+			AddHelmCharts(&ConfigurationSpecs{Configuration: Configuration{HelmChartConfiguration: tc.hc}})
+			installKubeSliceController(Cluster{}, tc.hc, "values.yaml")
+
+			got := make([][]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				// the kube context and kubeconfig arguments are not of interest here
+				if len(command) > 5 && command[1] == "--kube-context" {
+					command = append([]string{command[0]}, command[5:]...)
+				}
+				got = append(got, command)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("command sequence mismatch\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestHelmRepoErrorHint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		stderr string
+		hint   bool
+	}{
+		{
+			name:   "Unauthorized",
+			stderr: `Error: looks like "https://charts.example.com" is not a valid chart repository or cannot be reached: failed to fetch https://charts.example.com/index.yaml : 401 Unauthorized`,
+			hint:   true,
+		},
+		{
+			name:   "Forbidden",
+			stderr: "Error: failed to fetch https://charts.example.com/index.yaml : 403 Forbidden",
+			hint:   true,
+		},
+		{
+			name:   "Unreachable repository",
+			stderr: "Error: dial tcp: lookup charts.example.com: no such host",
+			hint:   true,
+		},
+		{
+			name:   "Other errors",
+			stderr: `Error: repository name (kubeslice) already exists, please specify a different name`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 
-			if gotTrimmed != expectedTrimmed {
-				t.Errorf("generateImagePullSecretsValue() mismatch:\nwant: %q\ngot:  %q", expectedTrimmed, gotTrimmed)
+			if got := helmRepoErrorHint(tc.stderr); (got != "") != tc.hint {
+				t.Errorf("helmRepoErrorHint() = %q, expected a hint: %v", got, tc.hint)
 			}
 		})
 	}
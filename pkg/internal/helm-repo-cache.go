@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// helmRepoUpdatesFile records when the index of each helm repo was last
+// updated successfully, keyed by the exact repo URL.
+var helmRepoUpdatesFile = filepath.Join(kubesliceHomeDirectory, "helm-repo-updates.yaml")
+
+func loadHelmRepoUpdates() (map[string]time.Time, error) {
+	updates := make(map[string]time.Time)
+	data, err := ioutil.ReadFile(helmRepoUpdatesFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return updates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &updates); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", helmRepoUpdatesFile, err)
+	}
+	if updates == nil {
+		updates = make(map[string]time.Time)
+	}
+	return updates, nil
+}
+
+// recordHelmRepoUpdate stores the time the index of a helm repo was updated.
+// Failures only warn, the next run updates the repo again.
+func recordHelmRepoUpdate(repoURL string) {
+	updates, err := loadHelmRepoUpdates()
+	if err == nil {
+		updates[repoURL] = time.Now().UTC()
+		var data []byte
+		if data, err = yaml.Marshal(updates); err == nil {
+			util.CreateDirectoryPath(filepath.Dir(helmRepoUpdatesFile))
+			err = util.WriteFileAtomic(helmRepoUpdatesFile, data, 0600)
+		}
+	}
+	if err != nil {
+		util.Printf("%s Unable to record the update of helm repo %s: %v", util.Warn, repoURL, err)
+	}
+}
+
+// configuredHelmRepos returns the URL of every helm repo added to helm, keyed
+// by the repo alias.
+func configuredHelmRepos() map[string]string {
+	var outB, errB bytes.Buffer
+	repos := make([]struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}, 0)
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, "repo", "list", "--output", "json"); err == nil {
+		json.Unmarshal(outB.Bytes(), &repos)
+	}
+	urls := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		urls[repo.Name] = repo.URL
+	}
+	return urls
+}
+
+// helmRepoUpToDate reports whether the helm repo of the topology can be used
+// without updating its index, because it was updated within the cache TTL or
+// because updates are forbidden with --offline. The repo must already be
+// added under the same alias and URL.
+func helmRepoUpToDate(hc HelmChartConfiguration) bool {
+	if Options.RefreshCharts {
+		util.Debugf("Refreshing helm repo %s as requested", hc.RepoUrl)
+		return false
+	}
+	var updated time.Time
+	if !Options.Offline {
+		updates, err := loadHelmRepoUpdates()
+		if err != nil {
+			util.Printf("%s %v", util.Warn, err)
+			return false
+		}
+		var found bool
+		if updated, found = updates[hc.RepoUrl]; !found {
+			util.Debugf("No cached update of helm repo %s, updating", hc.RepoUrl)
+			return false
+		}
+		if age := time.Since(updated); age >= Options.ChartsCacheTTL {
+			util.Debugf("Cached index of helm repo %s is %s old, updating", hc.RepoUrl, age.Round(time.Second))
+			return false
+		}
+	}
+	if url, found := configuredHelmRepos()[hc.RepoAlias]; !found || url != hc.RepoUrl {
+		if Options.Offline {
+			util.Fatalf("%s Helm repo %s is not added as %s, run once without --offline to add it", util.Cross, hc.RepoUrl, hc.RepoAlias)
+		}
+		util.Debugf("Helm repo %s is not added as %s, updating", hc.RepoUrl, hc.RepoAlias)
+		return false
+	}
+	if Options.Offline {
+		util.Debugf("Using cached index of helm repo %s, updates are disabled with --offline", hc.RepoUrl)
+	} else {
+		util.Debugf("Using cached index of helm repo %s, updated %s ago", hc.RepoUrl, time.Since(updated).Round(time.Second))
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+func TestHelmRepoUpToDate(t *testing.T) {
+	defer func(file string) { helmRepoUpdatesFile = file }(helmRepoUpdatesFile)
+	defer func(options RunOptions) { *Options = options }(*Options)
+
+	hc := HelmChartConfiguration{RepoAlias: "kubeslice", RepoUrl: "https://kubeslice.github.io/kubeslice/"}
+	tests := []struct {
+		name     string
+		updated  time.Duration // how long ago the repo URL was updated, 0 if never
+		repoURL  string        // the URL the alias is added with
+		refresh  bool
+		offline  bool
+		expected bool
+	}{
+		{name: "Fresh index is used", updated: time.Minute, repoURL: hc.RepoUrl, expected: true},
+		{name: "Expired index is updated", updated: 2 * time.Hour, repoURL: hc.RepoUrl, expected: false},
+		{name: "Never updated", repoURL: hc.RepoUrl, expected: false},
+		{name: "Alias added with another URL", updated: time.Minute, repoURL: "https://charts.example.com", expected: false},
+		{name: "Refresh forces an update", updated: time.Minute, repoURL: hc.RepoUrl, refresh: true, expected: false},
+		{name: "Offline uses an expired index", updated: 2 * time.Hour, repoURL: hc.RepoUrl, offline: true, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			helmRepoUpdatesFile = filepath.Join(t.TempDir(), "helm-repo-updates.yaml")
+			if tc.updated != 0 {
+				writeHelmRepoUpdates(t, map[string]time.Time{hc.RepoUrl: time.Now().Add(-tc.updated)})
+			}
+			Options.RefreshCharts = tc.refresh
+			Options.Offline = tc.offline
+			Options.ChartsCacheTTL = time.Hour
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				io.WriteString(stdout, `[{"name":"kubeslice","url":"`+tc.repoURL+`"}]`)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			if got := helmRepoUpToDate(hc); got != tc.expected {
+				t.Errorf("helmRepoUpToDate() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func writeHelmRepoUpdates(t *testing.T, updates map[string]time.Time) {
+	t.Helper()
+	data, err := yaml.Marshal(updates)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() returned error: %v", err)
+	}
+	if err := ioutil.WriteFile(helmRepoUpdatesFile, data, 0600); err != nil {
+		t.Fatalf("WriteFile() returned error: %v", err)
+	}
+}
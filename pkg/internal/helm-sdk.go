@@ -0,0 +1,197 @@
+//go:build helmsdk
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func init() {
+	newSDKInstaller = func() ChartInstaller {
+		return sdkInstaller{configuration: sdkConfiguration}
+	}
+}
+
+// sdkInstaller manages the releases with the helm Go SDK instead of the helm
+// binary. The values of a release, including its sensitive values, are only
+// held in memory.
+//
+// The backend is experimental and only built with the helmsdk build tag, the
+// helm module is not a dependency of the default build:
+//
+//	go get helm.sh/helm/v3
+//	go build -tags helmsdk
+type sdkInstaller struct {
+	configuration func(cluster Cluster, namespace string) (*action.Configuration, error)
+}
+
+// sdkConfiguration returns the action configuration of a namespace of a
+// cluster, storing the releases with the driver helm uses.
+func sdkConfiguration(cluster Cluster, namespace string) (*action.Configuration, error) {
+	settings := cli.New()
+	settings.KubeConfig = cluster.KubeConfigPath
+	settings.KubeContext = cluster.ContextName
+	cfg := new(action.Configuration)
+	if err := cfg.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), util.Debugf); err != nil {
+		return nil, fmt.Errorf("unable to initialize helm for cluster %s: %v", cluster.Name, err)
+	}
+	// charts of OCI registries are pulled with the registry client of the
+	// configuration
+	client, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the registry client: %v", err)
+	}
+	cfg.RegistryClient = client
+	return cfg, nil
+}
+
+func (s sdkInstaller) Upgrade(req releaseRequest) (*helmRelease, error) {
+	cfg, err := s.configuration(req.Cluster, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	values, err := sdkValues(req.ValuesFile)
+	if err != nil {
+		return nil, err
+	}
+	history := action.NewHistory(cfg)
+	history.Max = 1
+	var rel *release.Release
+	if _, err := history.Run(req.Release); errors.Is(err, driver.ErrReleaseNotFound) {
+		install := action.NewInstall(cfg)
+		install.ReleaseName = req.Release
+		install.Namespace = req.Namespace
+		install.CreateNamespace = req.CreateNamespace
+		install.Wait = true
+		install.Timeout = Options.HelmTimeout
+		install.Devel = develChart(req.Chart)
+		ch, err := sdkLoadChart(req, &install.ChartPathOptions)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err = install.Run(ch, values); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		upgrade := action.NewUpgrade(cfg)
+		upgrade.Namespace = req.Namespace
+		upgrade.Wait = true
+		upgrade.Timeout = Options.HelmTimeout
+		upgrade.Devel = develChart(req.Chart)
+		ch, err := sdkLoadChart(req, &upgrade.ChartPathOptions)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err = upgrade.Run(req.Release, ch, values); err != nil {
+			return nil, err
+		}
+	}
+	status := sdkRelease(rel)
+	return &status, nil
+}
+
+func (s sdkInstaller) Uninstall(cluster Cluster, release, namespace string) error {
+	cfg, err := s.configuration(cluster, namespace)
+	if err != nil {
+		return err
+	}
+	uninstall := action.NewUninstall(cfg)
+	uninstall.Timeout = Options.HelmTimeout
+	_, err = uninstall.Run(release)
+	return err
+}
+
+func (s sdkInstaller) List(cluster Cluster, namespace, name string) ([]helmRelease, error) {
+	cfg, err := s.configuration(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := action.NewList(cfg)
+	list.All = true
+	if name != "" {
+		list.Filter = "^" + name + "$"
+	}
+	rels, err := list.Run()
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]helmRelease, 0, len(rels))
+	for _, rel := range rels {
+		releases = append(releases, sdkRelease(rel))
+	}
+	return releases, nil
+}
+
+// sdkLoadChart locates the chart of a release with the options the exec
+// backend passes to helm as flags, downloading it from its repository or OCI
+// registry when it is not local, and loads it.
+func sdkLoadChart(req releaseRequest, options *action.ChartPathOptions) (*chart.Chart, error) {
+	repo := req.Repo.forChart(req.Chart)
+	options.Version = req.Chart.Version
+	options.Verify = req.Chart.Verify
+	options.Keyring = req.Chart.Keyring
+	options.CaFile = repo.CAFile
+	options.CertFile = repo.CertFile
+	options.KeyFile = repo.KeyFile
+	options.InsecureSkipTLSverify = repo.InsecureSkipTLSVerify
+	ref := chartReference(req.Repo, req.Chart)
+	path, err := options.LocateChart(ref, cli.New())
+	if err != nil {
+		return nil, fmt.Errorf("unable to locate chart %s: %v", ref, err)
+	}
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load chart %s: %v", ref, err)
+	}
+	return ch, nil
+}
+
+// sdkValues reads the values of a generated values file merged with the
+// sensitive values split out of it, without writing them to disk.
+func sdkValues(valuesFile string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseValues(data)
+	if err != nil {
+		return nil, err
+	}
+	mergeMaps(values, normalizeValue(sensitiveValuesFor(valuesFile)).(map[interface{}]interface{}))
+	encoded, err := encodeValues(values)
+	if err != nil {
+		return nil, err
+	}
+	return chartutil.ReadValues([]byte(encoded))
+}
+
+// sdkRelease converts a release to the entry `helm list --output json` shows
+// for it.
+func sdkRelease(rel *release.Release) helmRelease {
+	r := helmRelease{Name: rel.Name, Namespace: rel.Namespace, Revision: strconv.Itoa(rel.Version)}
+	if rel.Info != nil {
+		r.Status = rel.Info.Status.String()
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		r.Chart = rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version
+		r.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	return r
+}
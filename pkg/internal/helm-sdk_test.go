@@ -0,0 +1,111 @@
+//go:build helmsdk
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// fakeHelm fakes the release state the helm binary keeps for the commands the
+// exec backend runs.
+func fakeHelm(releases map[string]helmRelease) func(command []string, stdout, stderr io.Writer) error {
+	return func(command []string, stdout, stderr io.Writer) error {
+		args := command[5:]
+		switch args[0] {
+		case "upgrade":
+			r := releases[args[2]]
+			revision, _ := strconv.Atoi(r.Revision)
+			releases[args[2]] = helmRelease{Name: args[2], Namespace: args[5], Revision: strconv.Itoa(revision + 1), Status: "deployed", Chart: "demo-0.1.0", AppVersion: "1.0.0"}
+		case "uninstall":
+			if _, ok := releases[args[1]]; !ok {
+				return fmt.Errorf("Error: uninstall: Release not loaded: %s: release: not found", args[1])
+			}
+			delete(releases, args[1])
+		case "list":
+			list := make([]helmRelease, 0)
+			for _, r := range releases {
+				if len(args) < 6 || args[4] != "--filter" || args[5] == "^"+r.Name+"$" {
+					list = append(list, r)
+				}
+			}
+			return json.NewEncoder(stdout).Encode(list)
+		}
+		return nil
+	}
+}
+
+func TestChartInstallerParity(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "demo")
+	files := map[string]string{
+		"Chart.yaml":               "apiVersion: v2\nname: demo\nversion: 0.1.0\nappVersion: 1.0.0\n",
+		"values.yaml":              "replicas: 0\n",
+		"templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\ndata:\n  replicas: \"{{ .Values.replicas }}\"\n",
+	}
+	for name, content := range files {
+		util.CreateDirectoryPath(filepath.Dir(filepath.Join(chartDir, name)))
+		if err := ioutil.WriteFile(filepath.Join(chartDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	valuesFile := filepath.Join(dir, "values.yaml")
+	if err := ioutil.WriteFile(valuesFile, []byte("replicas: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cluster := Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl", KubeConfigPath: "/tmp/kubeconfig"}
+	req := releaseRequest{Cluster: cluster, Release: "demo", Namespace: "demo", Chart: HelmChart{ChartName: "demo", LocalPath: chartDir}, ValuesFile: valuesFile, CreateNamespace: true}
+
+	store := storage.Init(driver.NewMemory())
+	installers := map[string]ChartInstaller{
+		HelmBackendExec: execInstaller{},
+		HelmBackendSDK: sdkInstaller{configuration: func(cluster Cluster, namespace string) (*action.Configuration, error) {
+			return &action.Configuration{Releases: store, KubeClient: &kubefake.PrintingKubeClient{Out: io.Discard}, Capabilities: chartutil.DefaultCapabilities, Log: t.Logf}, nil
+		}},
+	}
+	defer util.UseExecutor(&util.MockExecutor{Handler: fakeHelm(make(map[string]helmRelease))})()
+
+	results := make(map[string][]interface{})
+	for backend, installer := range installers {
+		var steps []interface{}
+		installed, err := installer.Upgrade(req)
+		steps = append(steps, installed, err == nil)
+		upgraded, err := installer.Upgrade(req)
+		steps = append(steps, upgraded, err == nil)
+		listed, err := installer.List(cluster, "demo", "demo")
+		steps = append(steps, listed, err == nil)
+		steps = append(steps, installer.Uninstall(cluster, "demo", "demo") == nil)
+		listed, err = installer.List(cluster, "demo", "")
+		steps = append(steps, listed, err == nil)
+		steps = append(steps, installer.Uninstall(cluster, "demo", "demo") == nil)
+		results[backend] = steps
+	}
+
+	expected := []interface{}{
+		&helmRelease{Name: "demo", Namespace: "demo", Revision: "1", Status: "deployed", Chart: "demo-0.1.0", AppVersion: "1.0.0"}, true,
+		&helmRelease{Name: "demo", Namespace: "demo", Revision: "2", Status: "deployed", Chart: "demo-0.1.0", AppVersion: "1.0.0"}, true,
+		[]helmRelease{{Name: "demo", Namespace: "demo", Revision: "2", Status: "deployed", Chart: "demo-0.1.0", AppVersion: "1.0.0"}}, true,
+		true,
+		[]helmRelease{}, true,
+		false,
+	}
+	for backend, steps := range results {
+		if !reflect.DeepEqual(steps, expected) {
+			t.Errorf("%s backend = %+v, want %+v", backend, steps, expected)
+		}
+	}
+}
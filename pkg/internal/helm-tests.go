@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Results of the helm tests of a release.
+const (
+	helmTestPassed  = "passed"
+	helmTestFailed  = "failed"
+	helmTestNoTests = "no tests"
+)
+
+// releaseTest records the outcome of the helm tests of a release in the run
+// summary.
+type releaseTest struct {
+	Component string `yaml:"component"`
+	Cluster   string `yaml:"cluster"`
+	Release   string `yaml:"release"`
+	Result    string `yaml:"result"`
+	// Output of helm test including the test pod logs, only kept for failures
+	Output string `yaml:"-"`
+}
+
+// RunHelmTests runs the helm test hooks of the releases of the installed
+// components and records the results in the run summary. A failed test fails
+// the run after every release was tested, showing the test pod logs.
+func RunHelmTests(specs *ConfigurationSpecs, components []string) {
+	util.Printf("\nRunning helm tests...")
+	releases := componentReleases(specs)
+	failed := make([]releaseTest, 0)
+	for _, component := range components {
+		cr := releases[component]
+		if cr.chart.ChartName == "" {
+			continue
+		}
+		for _, cluster := range cr.clusters {
+			result := runHelmTest(component, cluster, cr)
+			runSummary.Tests = append(runSummary.Tests, result)
+			switch result.Result {
+			case helmTestPassed:
+				util.Printf("%s Helm tests of release %s on %s passed", util.Tick, result.Release, cluster.Name)
+			case helmTestNoTests:
+				util.Printf("%s Release %s on %s has no helm tests", util.Warn, result.Release, cluster.Name)
+			default:
+				util.Printf("%s Helm tests of release %s on %s failed", util.Cross, result.Release, cluster.Name)
+				failed = append(failed, result)
+			}
+		}
+	}
+	writeRunSummary()
+	if len(failed) == 0 {
+		util.Printf("%s Helm tests completed\n", util.Tick)
+		return
+	}
+	for _, result := range failed {
+		util.Printf("\n%s Output of the helm tests of release %s on %s:\n%s", util.Warn, result.Release, result.Cluster, result.Output)
+	}
+	util.Fatalf("%s Helm tests failed for %d release(s), the installation is not healthy", util.Cross, len(failed))
+}
+
+// runHelmTest runs `helm test` for a release on a cluster with the test pod
+// logs.
+func runHelmTest(component string, cluster Cluster, cr componentRelease) releaseTest {
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, false, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "test", cr.release, "--namespace", cr.namespace(cluster), "--logs", "--timeout", Options.HelmTimeout.String())
+	result := releaseTest{Component: component, Cluster: cluster.Name, Release: cr.release}
+	result.Result = helmTestResult(outB.String(), err)
+	if result.Result == helmTestFailed {
+		result.Output = util.Redact(strings.TrimSpace(outB.String() + "\n" + errB.String()))
+	}
+	return result
+}
+
+// helmTestResult parses the output of helm test. A chart without test hooks
+// reports its test suite as None.
+func helmTestResult(output string, err error) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "TEST" && fields[1] == "SUITE:" && fields[2] == "None" {
+			return helmTestNoTests
+		}
+		if err == nil && len(fields) == 2 && fields[0] == "Phase:" && fields[1] != "Succeeded" {
+			return helmTestFailed
+		}
+	}
+	if err != nil {
+		return helmTestFailed
+	}
+	return helmTestPassed
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestRunHelmTest(t *testing.T) {
+	defer func(timeout time.Duration) { Options.HelmTimeout = timeout }(Options.HelmTimeout)
+	Options.HelmTimeout = 2 * time.Minute
+
+	cluster := Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl", KubeConfigPath: "/tmp/kubeconfig"}
+	cr := componentRelease{chart: HelmChart{ChartName: "kubeslice-controller"}, release: "kubeslice-controller", namespace: ControllerNamespace}
+	header := "NAME: kubeslice-controller\nNAMESPACE: kubeslice-controller\nSTATUS: deployed\nREVISION: 1\n"
+
+	tests := []struct {
+		name     string
+		stdout   string
+		stderr   string
+		err      error
+		expected string
+		output   string
+	}{
+		{
+			name:     "Tests passed",
+			stdout:   header + "TEST SUITE:     kubeslice-controller-test\nLast Started:   Mon Jan  2 15:04:05 2023\nPhase:          Succeeded\n\nPOD LOGS: kubeslice-controller-test\nok\n",
+			expected: helmTestPassed,
+		},
+		{
+			name:     "Test failed",
+			stdout:   header + "TEST SUITE:     kubeslice-controller-test\nPhase:          Failed\n\nPOD LOGS: kubeslice-controller-test\nconnection refused\n",
+			stderr:   "Error: 1 error occurred:\n\t* pod kubeslice-controller-test failed\n",
+			err:      errors.New("exit status 1"),
+			expected: helmTestFailed,
+			output:   "POD LOGS: kubeslice-controller-test\nconnection refused",
+		},
+		{
+			name:     "Chart without test hooks",
+			stdout:   header + "TEST SUITE: None\n",
+			expected: helmTestNoTests,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stdout, tc.stdout)
+				fmt.Fprint(stderr, tc.stderr)
+				return tc.err
+			}}
+			defer util.UseExecutor(mock)()
+
+			got := runHelmTest(Controller_Component, cluster, cr)
+			expectedCommands := [][]string{{"helm", "--kube-context", "kind-ks-ctrl", "--kubeconfig", "/tmp/kubeconfig", "test", "kubeslice-controller", "--namespace", "kubeslice-controller", "--logs", "--timeout", "2m0s"}}
+			if !reflect.DeepEqual(mock.Commands, expectedCommands) {
+				t.Errorf("commands = %q, want %q", mock.Commands, expectedCommands)
+			}
+			if got.Result != tc.expected {
+				t.Errorf("runHelmTest() result = %q, want %q", got.Result, tc.expected)
+			}
+			if tc.output != "" && !strings.Contains(got.Output, tc.output) {
+				t.Errorf("runHelmTest() output = %q, want it to contain %q", got.Output, tc.output)
+			}
+			if tc.output == "" && got.Output != "" {
+				t.Errorf("runHelmTest() output = %q, want none", got.Output)
+			}
+		})
+	}
+}
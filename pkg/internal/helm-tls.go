@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"sync"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+var insecureWarning sync.Once
+
+// helmTLSFlags returns the helm flags for the TLS options of the chart
+// repository. registry selects the flag names of `helm registry login`.
+func helmTLSFlags(hc HelmChartConfiguration, registry bool) []string {
+	flags := make([]string, 0)
+	if hc.CAFile != "" {
+		flags = append(flags, "--ca-file", hc.CAFile)
+	}
+	if hc.CertFile != "" {
+		flags = append(flags, "--cert-file", hc.CertFile)
+	}
+	if hc.KeyFile != "" {
+		flags = append(flags, "--key-file", hc.KeyFile)
+	}
+	if hc.InsecureSkipTLSVerify {
+		insecureWarning.Do(func() {
+			util.Printf("%s WARNING: TLS certificate verification of the helm repository %s is disabled (insecure_skip_tls_verify). Charts can be tampered with in transit", util.Warn, hc.RepoUrl)
+		})
+		if registry {
+			flags = append(flags, "--insecure")
+		} else {
+			flags = append(flags, "--insecure-skip-tls-verify")
+		}
+	}
+	return flags
+}
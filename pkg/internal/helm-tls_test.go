@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestHelmTLSFlags(t *testing.T) {
+	var out bytes.Buffer
+	util.Output = &out
+	defer func() {
+		util.Output = os.Stdout
+	}()
+	insecureWarning = sync.Once{}
+
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		registry bool
+		expected []string
+	}{
+		{
+			name:     "No TLS options",
+			expected: []string{},
+		},
+		{
+			name:     "Custom CA and client certificate",
+			hc:       HelmChartConfiguration{CAFile: "/certs/ca.crt", CertFile: "/certs/tls.crt", KeyFile: "/certs/tls.key"},
+			expected: []string{"--ca-file", "/certs/ca.crt", "--cert-file", "/certs/tls.crt", "--key-file", "/certs/tls.key"},
+		},
+		{
+			name:     "Skip verify",
+			hc:       HelmChartConfiguration{InsecureSkipTLSVerify: true},
+			expected: []string{"--insecure-skip-tls-verify"},
+		},
+		{
+			name:     "Skip verify on registry login",
+			hc:       HelmChartConfiguration{InsecureSkipTLSVerify: true},
+			registry: true,
+			expected: []string{"--insecure"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := helmTLSFlags(tc.hc, tc.registry); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("helmTLSFlags() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+	if warnings := strings.Count(out.String(), "TLS certificate verification"); warnings != 1 {
+		t.Errorf("expected the skip verify warning to be printed once, got %d times:\n%s", warnings, out.String())
+	}
+}
+
+func TestResolveRelativePaths(t *testing.T) {
+	t.Parallel()
+
+	specs := &ConfigurationSpecs{}
+	hc := &specs.Configuration.HelmChartConfiguration
+	hc.CAFile = "certs/ca.crt"
+	hc.CertFile = "/etc/certs/tls.crt"
+	ResolveRelativePaths(specs, "/home/user/topology")
+	if hc.CAFile != "/home/user/topology/certs/ca.crt" {
+		t.Errorf("relative CA file should resolve against the topology directory, got %s", hc.CAFile)
+	}
+	if hc.CertFile != "/etc/certs/tls.crt" || hc.KeyFile != "" {
+		t.Errorf("absolute and empty paths should be kept, got %q and %q", hc.CertFile, hc.KeyFile)
+	}
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// showValuesDiff prints how the generated values file differs from the values
+// of the deployed release and asks for confirmation before the upgrade. Releases
+// which are not installed yet are skipped silently.
+func showValuesDiff(cluster Cluster, release, namespace, valuesFile string) {
+	diff, installed, err := releaseValuesDiff(cluster, release, namespace, valuesFile)
+	if err != nil {
+		util.Printf("%s %v, skipping values diff", util.Warn, err)
+		return
+	}
+	if !installed {
+		return
+	}
+	if diff == "" {
+		util.Printf("%s No value changes for release %s on %s", util.Tick, release, cluster.Name)
+		return
+	}
+	util.Printf("Values changes for release %s on %s:\n%s", release, cluster.Name, diff)
+	if !Options.AssumeYes && !util.IsTerminal(os.Stdin) {
+		util.Fatalf("%s The values of release %s on %s differ from the deployed ones, re-run with --yes to upgrade it", util.Cross, release, cluster.Name)
+	}
+	if !Options.AssumeYes && !util.Confirm("Proceed with upgrade of release %s on %s?", release, cluster.Name) {
+		util.Fatalf("%s Upgrade of release %s aborted", util.Cross, release)
+	}
+}
+
+// releaseValuesDiff returns the diff between the values of the deployed
+// release and the generated values file, with sensitive values redacted.
+// installed is false when the release does not exist.
+func releaseValuesDiff(cluster Cluster, release, namespace, valuesFile string) (diff string, installed bool, err error) {
+	deployedValues, installed, err := deployedReleaseValues(cluster, release, namespace)
+	if err != nil || !installed {
+		return "", installed, err
+	}
+	generatedData, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		util.Fatalf("%s Failed to read %s: %v", util.Cross, valuesFile, err)
+	}
+	generatedValues, err := parseValues(generatedData)
+	if err != nil {
+		util.Fatalf("%s Failed to parse %s: %v", util.Cross, valuesFile, err)
+	}
+	// sensitive values are never printed, only their presence is compared
+	secrets := sensitiveValuesFor(valuesFile)
+	redactValues(deployedValues, secrets, false)
+	redactValues(generatedValues, secrets, true)
+	deployed, err := encodeValues(deployedValues)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	generated, err := encodeValues(generatedValues)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	return util.UnifiedDiff(release+" (deployed)", valuesFile, deployed, generated, 3), true, nil
+}
+
+// deployedReleaseValues returns the user supplied values of the deployed
+// release. installed is false when the release does not exist.
+func deployedReleaseValues(cluster Cluster, release, namespace string) (values map[interface{}]interface{}, installed bool, err error) {
+	var outB, errB bytes.Buffer
+	err = util.RunCommandCustomIO("helm", &outB, &errB, true, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "get", "values", release, "--namespace", namespace, "-o", "yaml")
+	if err != nil {
+		if strings.Contains(errB.String(), "not found") {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("unable to fetch values of release %s on %s: %s", release, cluster.Name, strings.TrimSpace(errB.String()))
+	}
+	values, err = parseValues(outB.Bytes())
+	if err != nil {
+		return nil, true, fmt.Errorf("unable to parse values of release %s on %s: %v", release, cluster.Name, err)
+	}
+	return values, true, nil
+}
+
+// normalizeValues re-encodes a values document so that key order and
+// formatting do not show up as changes.
+func normalizeValues(data []byte) (string, error) {
+	values, err := parseValues(data)
+	if err != nil {
+		return "", err
+	}
+	return encodeValues(values)
+}
+
+// parseValues parses a values document. A stream of several documents is
+// merged in order, later documents taking precedence, and empty documents are
+// ignored. `helm get values` prints null for releases installed with the chart
+// defaults only, which yields an empty map.
+func parseValues(data []byte) (map[interface{}]interface{}, error) {
+	values := make(map[interface{}]interface{})
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document map[interface{}]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		mergeMaps(values, document)
+	}
+}
+
+func encodeValues(values map[interface{}]interface{}) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("error encoding values as YAML: %v", err)
+	}
+	return string(out), nil
+}
+
+// redactValues replaces every value in values which is present in secrets
+// with a placeholder. When create is set, missing paths are added as well.
+func redactValues(values, secrets map[interface{}]interface{}, create bool) {
+	for k, v := range secrets {
+		nested, isMap := v.(map[interface{}]interface{})
+		if !isMap {
+			if _, found := values[k]; found || create {
+				values[k] = "<redacted>"
+			}
+			continue
+		}
+		existing, ok := values[k].(map[interface{}]interface{})
+		if !ok {
+			if !create {
+				continue
+			}
+			existing = make(map[interface{}]interface{})
+			values[k] = existing
+		}
+		redactValues(existing, nested, create)
+	}
+}
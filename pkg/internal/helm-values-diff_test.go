@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestNormalizeValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Null output for releases installed with defaults",
+			input:    "null\n",
+			expected: "",
+		},
+		{
+			name:     "Empty output",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "Keys are sorted",
+			input:    "b: 2\na:\n  d: 4\n  c: 3\n",
+			expected: "a:\n  c: 3\n  d: 4\nb: 2\n",
+		},
+		{
+			name:     "Two documents are merged in order",
+			input:    "a:\n  b: 1\n  c: 2\n---\na:\n  c: 3\nd: 4\n",
+			expected: "a:\n  b: 1\n  c: 3\nd: 4\n",
+		},
+		{
+			name:     "Empty leading document",
+			input:    "---\na: 1\n",
+			expected: "a: 1\n",
+		},
+		{
+			name:     "Trailing document separator",
+			input:    "a: 1\n---\n",
+			expected: "a: 1\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := normalizeValues([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("normalizeValues() returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("normalizeValues() mismatch:\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// imagePullSecretName returns the name of the pre-existing secret referenced
+// by the topology, or an empty string when credentials are configured.
+func imagePullSecretName(secrets ImagePullSecretList) string {
+	for _, ips := range secrets {
+		if ips.SecretName != "" {
+			return ips.SecretName
+		}
+	}
+	return ""
+}
+
+// kubesliceImagePullSecretsValue renders the image pull secret values of the
+// KubeSlice charts, which either create the secret from the credentials or
+// use the referenced secret by imagePullSecretsName.
+func kubesliceImagePullSecretsValue(secrets ImagePullSecretList) string {
+	if name := imagePullSecretName(secrets); name != "" {
+		out, err := yaml.Marshal(map[string]string{"imagePullSecretsName": name})
+		if err != nil {
+			util.Fatalf("%s Failed to encode image pull secret name: %v", util.Cross, err)
+		}
+		return "\n" + string(out)
+	}
+	return generateImagePullSecretsValue(secrets)
+}
+
+// NormalizeImagePullSecrets removes the trailing newlines, as left by reading
+// a password from a file, from the passwords of the image pull secrets.
+func NormalizeImagePullSecrets(hc *HelmChartConfiguration) {
+	for i := range hc.ImagePullSecrets {
+		hc.ImagePullSecrets[i].Password = strings.TrimRight(hc.ImagePullSecrets[i].Password, "\r\n")
+	}
+}
+
+// ValidateImagePullSecrets rejects control characters in the credentials and
+// entries which both reference a secret and carry credentials, since it would
+// be unclear which one is used. The trailing newlines of a password are left
+// to NormalizeImagePullSecrets.
+func ValidateImagePullSecrets(hc HelmChartConfiguration) []string {
+	errors := make([]string, 0)
+	for i, ips := range hc.ImagePullSecrets {
+		for field, value := range map[string]string{"registry": ips.Registry, "username": ips.Username, "password": strings.TrimRight(ips.Password, "\r\n"), "email": ips.Email, "secret_name": ips.SecretName} {
+			if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.image_pull_secret[%d].%s must not contain control characters", util.Cross, i, field))
+			}
+		}
+		if ips.SecretName == "" {
+			continue
+		}
+		if ips.Username != "" || ips.Password != "" {
+			errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.image_pull_secret[%d].secret_name cannot be combined with username or password", util.Cross, i))
+		}
+		if len(hc.ImagePullSecrets) > 1 {
+			errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.image_pull_secret[%d].secret_name must be the only image_pull_secret entry, the referenced secret holds the credentials of all registries", util.Cross, i))
+		}
+	}
+	return errors
+}
+
+// VerifyImagePullSecretReference checks that the referenced image pull secret
+// exists in the namespace of every release the components install.
+func VerifyImagePullSecretReference(specs *ConfigurationSpecs, components []string) {
+	hc := specs.Configuration.HelmChartConfiguration
+	name := imagePullSecretName(hc.ImagePullSecrets)
+	if name == "" {
+		return
+	}
+	util.Printf("\nVerifying image pull secret %s...", name)
+	releases := componentReleases(specs)
+	missing := make([]string, 0)
+	for _, component := range components {
+		cr := releases[component]
+		if cr.chart.ChartName == "" {
+			continue
+		}
+		// only the KubeSlice charts use the secret unless it is propagated
+		if (component == CertManager_Component || component == Prometheus_Component) && (!hc.PropagateImagePullSecrets || cr.chart.SkipImagePullSecrets) {
+			continue
+		}
+		for _, cluster := range cr.clusters {
+			var outB, errB bytes.Buffer
+			err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "secret", name, "--namespace", cr.namespace(cluster), "-o", "name")
+			if err != nil {
+				missing = append(missing, fmt.Sprintf("%s Image pull secret %s not found in namespace %s on cluster %s: %s", util.Cross, name, cr.namespace(cluster), cluster.Name, strings.TrimSpace(errB.String())))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		for _, s := range missing {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to missing image pull secrets", util.Cross)
+	}
+	util.Printf("%s Image pull secret %s is present on all clusters", util.Tick, name)
+}
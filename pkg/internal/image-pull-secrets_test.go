@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestValidateImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		secrets ImagePullSecretList
+		errors  int
+	}{
+		{
+			name:    "Secret reference",
+			secrets: ImagePullSecretList{{SecretName: "regcred"}},
+		},
+		{
+			name:    "Credentials",
+			secrets: ImagePullSecretList{{Username: "user", Password: "pass"}},
+		},
+		{
+			name:    "Secret reference with credentials is ambiguous",
+			secrets: ImagePullSecretList{{SecretName: "regcred", Username: "user", Password: "pass"}},
+			errors:  1,
+		},
+		{
+			name:    "Password with a trailing newline from a file",
+			secrets: ImagePullSecretList{{Username: "user", Password: "pass\n"}},
+		},
+		{
+			name:    "Control characters are rejected",
+			secrets: ImagePullSecretList{{Username: "user\tname", Password: "pa\x00ss"}},
+			errors:  2,
+		},
+		{
+			name:    "Secret reference next to further registries",
+			secrets: ImagePullSecretList{{SecretName: "regcred"}, {Username: "user", Password: "pass"}},
+			errors:  1,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errors := ValidateImagePullSecrets(HelmChartConfiguration{ImagePullSecrets: tc.secrets})
+			if len(errors) != tc.errors {
+				t.Errorf("ValidateImagePullSecrets() = %q, want %d errors", errors, tc.errors)
+			}
+		})
+	}
+}
+
+func TestNormalizeImagePullSecretsTrimsTrailingNewline(t *testing.T) {
+	t.Parallel()
+
+	hc := &HelmChartConfiguration{ImagePullSecrets: ImagePullSecretList{{Username: "user", Password: "pass\r\n"}}}
+	NormalizeImagePullSecrets(hc)
+	if got := hc.ImagePullSecrets[0].Password; got != "pass" {
+		t.Errorf("password = %q, want %q", got, "pass")
+	}
+	if errors := ValidateImagePullSecrets(*hc); len(errors) > 0 {
+		t.Errorf("ValidateImagePullSecrets() = %v after normalizing, want no errors", errors)
+	}
+}
+
+func TestImagePullSecretReferenceValues(t *testing.T) {
+	t.Parallel()
+
+	hc := HelmChartConfiguration{
+		ImagePullSecrets:          ImagePullSecretList{{SecretName: "regcred"}},
+		PropagateImagePullSecrets: true,
+	}
+	if got, expected := kubesliceImagePullSecretsValue(hc.ImagePullSecrets), "\nimagePullSecretsName: regcred\n"; got != expected {
+		t.Errorf("kubesliceImagePullSecretsValue() = %q, want %q", got, expected)
+	}
+	if got, expected := propagatedImagePullSecrets(hc), "\nimagePullSecrets:\n- name: regcred\n"; got != expected {
+		t.Errorf("propagatedImagePullSecrets() = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// imageRewrite is an image reference of the values of a chart rewritten to
+// the mirror registry.
+type imageRewrite struct {
+	path     string
+	from, to string
+}
+
+// chartImageRegistry returns the registry the images of a chart are rewritten
+// to, image_registry of the chart or global_image_registry.
+func chartImageRegistry(hcConfig HelmChartConfiguration, chart HelmChart) string {
+	if chart.ImageRegistry != "" {
+		return strings.TrimSuffix(chart.ImageRegistry, "/")
+	}
+	return strings.TrimSuffix(hcConfig.GlobalImageRegistry, "/")
+}
+
+// rewriteImages is cleared by ListImages, which lists the images of the
+// source registries to mirror.
+var rewriteImages = true
+
+// rewriteImageRegistry rewrites the image references of a generated values
+// file to the mirror registry of the chart. The references are looked up in
+// the values merged with the defaults of the chart, so that images only set in
+// the chart defaults are rewritten as well, and only the rewritten references
+// are added to the values file.
+func rewriteImageRegistry(hcConfig HelmChartConfiguration, chart *HelmChart, valuesFile string) error {
+	registry := chartImageRegistry(hcConfig, *chart)
+	if !rewriteImages || (registry == "" && len(chart.ImageKeys) == 0) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return err
+	}
+	values, err := parseValues(data)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %v", valuesFile, err)
+	}
+	defaults, err := showChartValues(hcConfig, chart)
+	if err != nil {
+		util.Printf("%s Unable to fetch the default values of chart %s, only images set in the topology are rewritten: %v", util.Warn, chart.ChartName, err)
+		defaults = make(map[interface{}]interface{})
+	}
+	merged := mergeMaps(normalizeValue(defaults).(map[interface{}]interface{}), normalizeValue(values).(map[interface{}]interface{}))
+	rewrites := imageRegistryRewrites(merged, registry, chart.ImageKeys)
+	if len(rewrites) == 0 {
+		util.Printf("%s No image references found in the values of chart %s to rewrite, set image_keys on the chart", util.Warn, chart.ChartName)
+		return nil
+	}
+	for _, r := range rewrites {
+		setValuePath(values, r.path, r.to)
+		util.Debugf("Rewrote image %s of chart %s to %s (%s)", r.from, chart.ChartName, r.to, r.path)
+	}
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("error encoding final data as YAML: %v", err)
+	}
+	if err := util.WriteFileAtomic(valuesFile, out, 0600); err != nil {
+		return fmt.Errorf("error writing values file: %v", err)
+	}
+	util.Printf("%s Rewrote %d image references of chart %s to the mirror registry", util.Tick, len(rewrites), chart.ChartName)
+	return nil
+}
+
+// imageRegistryRewrites returns the rewrites of the image references found in
+// values, sorted by path. An image is found as
+//
+//	image: docker.io/aveshasystems/worker-operator   the complete reference
+//	registry: quay.io                                 the registry beside a repository
+//	repository: quay.io/jetstack/cert-manager-webhook a repository without registry key
+//
+// and at the dotted paths of keys, which are always treated as references and
+// rewritten to the registry they map to, or to registry when it is empty.
+func imageRegistryRewrites(values map[interface{}]interface{}, registry string, keys map[string]string) []imageRewrite {
+	rewrites := make(map[string]imageRewrite)
+	add := func(path, from, to string) {
+		if from != to {
+			rewrites[path] = imageRewrite{path, from, to}
+		}
+	}
+	var walk func(values map[interface{}]interface{}, prefix string)
+	walk = func(values map[interface{}]interface{}, prefix string) {
+		for k, v := range values {
+			key, ok := k.(string)
+			if !ok || strings.Contains(key, ".") {
+				continue
+			}
+			path := prefix + key
+			switch value := v.(type) {
+			case map[interface{}]interface{}:
+				walk(value, path+".")
+			case string:
+				if value == "" || strings.ContainsAny(value, " {}") {
+					continue
+				}
+				_, hasRegistry := values["registry"].(string)
+				_, hasRepository := values["repository"].(string)
+				switch {
+				case key == "image" && looksLikeImage(value):
+					add(path, value, rewriteImageReference(value, registry))
+				case key == "registry" && hasRepository:
+					add(path, value, registry)
+				case key == "repository" && !hasRegistry && looksLikeImage(value):
+					add(path, value, rewriteImageReference(value, registry))
+				}
+			}
+		}
+	}
+	if registry != "" {
+		walk(values, "")
+	}
+	for key, keyRegistry := range keys {
+		if keyRegistry == "" {
+			keyRegistry = registry
+		}
+		if value, ok := valueAtPath(values, key).(string); ok && value != "" && keyRegistry != "" {
+			add(key, value, rewriteImageReference(value, strings.TrimSuffix(keyRegistry, "/")))
+		}
+	}
+	sorted := make([]imageRewrite, 0, len(rewrites))
+	for _, r := range rewrites {
+		sorted = append(sorted, r)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].path < sorted[j].path })
+	return sorted
+}
+
+// rewriteImageReference replaces the registry of an image reference, keeping
+// its repository path, tag and digest. Images of docker hub without an
+// organization keep the library path docker hub stores them under.
+func rewriteImageReference(image, registry string) string {
+	parts := strings.SplitN(image, "/", 2)
+	path := image
+	switch {
+	case len(parts) == 1:
+		path = "library/" + image
+	case strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost":
+		path = parts[1]
+	}
+	return registry + "/" + path
+}
+
+// valueAtPath returns the value at the dotted path, nil when it is not set.
+func valueAtPath(values map[interface{}]interface{}, path string) interface{} {
+	var value interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
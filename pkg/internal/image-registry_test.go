@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImageRegistryRewrites(t *testing.T) {
+	t.Parallel()
+
+	const mirror = "registry.corp.local"
+	tests := []struct {
+		name     string
+		values   string
+		keys     map[string]string
+		expected map[string]string
+	}{
+		{
+			name: "kubeslice-controller",
+			values: `
+kubeslice:
+  controller:
+    image: aveshasystems/kubeslice-controller
+    tag: 0.5.0
+    pullPolicy: IfNotPresent
+    endpoint: https://ks-ctrl-control-plane:6443
+`,
+			expected: map[string]string{
+				"kubeslice.controller.image": mirror + "/aveshasystems/kubeslice-controller",
+			},
+		},
+		{
+			name: "kubeslice-worker",
+			values: `
+operator:
+  image: docker.io/aveshasystems/worker-operator
+  tag: 0.5.0
+routerSidecar:
+  image: docker.io/aveshasystems/kubeslice-router-sidecar
+  tag: 1.4.2
+kubesliceNetworking:
+  enabled: true
+gateway:
+  image: docker.io/aveshasystems/gw-sidecar:0.2.0
+  openvpnImage: docker.io/aveshasystems/openvpn-server.ubuntu.18.04:1.0.0
+`,
+			keys: map[string]string{"gateway.openvpnImage": ""},
+			expected: map[string]string{
+				"gateway.image":        mirror + "/aveshasystems/gw-sidecar:0.2.0",
+				"gateway.openvpnImage": mirror + "/aveshasystems/openvpn-server.ubuntu.18.04:1.0.0",
+				"operator.image":       mirror + "/aveshasystems/worker-operator",
+				"routerSidecar.image":  mirror + "/aveshasystems/kubeslice-router-sidecar",
+			},
+		},
+		{
+			name: "cert-manager",
+			values: `
+image:
+  repository: quay.io/jetstack/cert-manager-controller
+  pullPolicy: IfNotPresent
+webhook:
+  image:
+    repository: quay.io/jetstack/cert-manager-webhook
+cainjector:
+  image:
+    repository: quay.io/jetstack/cert-manager-cainjector
+`,
+			expected: map[string]string{
+				"cainjector.image.repository": mirror + "/jetstack/cert-manager-cainjector",
+				"image.repository":            mirror + "/jetstack/cert-manager-controller",
+				"webhook.image.repository":    mirror + "/jetstack/cert-manager-webhook",
+			},
+		},
+		{
+			name: "kubeslice-ui",
+			values: `
+kubeslice:
+  ui:
+    image: aveshasystems/kubeslice-ui
+    tag: 0.1.0
+  uiproxy:
+    image: aveshasystems/kubeslice-ui-proxy
+    service:
+      type: NodePort
+  apigw:
+    image: aveshasystems/kubeslice-api-gw
+`,
+			expected: map[string]string{
+				"kubeslice.apigw.image":   mirror + "/aveshasystems/kubeslice-api-gw",
+				"kubeslice.ui.image":      mirror + "/aveshasystems/kubeslice-ui",
+				"kubeslice.uiproxy.image": mirror + "/aveshasystems/kubeslice-ui-proxy",
+			},
+		},
+		{
+			name: "prometheus",
+			values: `
+server:
+  image:
+    registry: docker.io
+    repository: bitnami/prometheus
+    tag: 2.45.0
+alertmanager:
+  image:
+    registry: docker.io
+    repository: bitnami/alertmanager
+`,
+			expected: map[string]string{
+				"alertmanager.image.registry": mirror,
+				"server.image.registry":       mirror,
+			},
+		},
+		{
+			name: "Image keys with their own registry",
+			values: `
+operator:
+  sidecarImage: nginx:1.25
+`,
+			keys: map[string]string{"operator.sidecarImage": "mirror.other.local/"},
+			expected: map[string]string{
+				"operator.sidecarImage": "mirror.other.local/library/nginx:1.25",
+			},
+		},
+		{
+			name: "Not images",
+			values: `
+image:
+  repository: controller
+  pullPolicy: Always
+gateway:
+  image: "{{ .Values.global.image }}"
+repository: https://kubeslice.github.io/kubeslice/
+`,
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			values, err := parseValues([]byte(tc.values))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, r := range imageRegistryRewrites(values, mirror, tc.keys) {
+				got[r.path] = r.to
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("imageRegistryRewrites() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteImageReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"nginx", "registry.corp.local/library/nginx"},
+		{"busybox:1.36", "registry.corp.local/library/busybox:1.36"},
+		{"aveshasystems/worker-operator:1.0.0", "registry.corp.local/aveshasystems/worker-operator:1.0.0"},
+		{"quay.io/jetstack/cert-manager-controller:v1.7.0", "registry.corp.local/jetstack/cert-manager-controller:v1.7.0"},
+		{"localhost:5000/kubeslice/controller", "registry.corp.local/kubeslice/controller"},
+		{"bitnami/kubectl@sha256:0123abcd", "registry.corp.local/bitnami/kubectl@sha256:0123abcd"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.image, func(t *testing.T) {
+			t.Parallel()
+
+			if got := rewriteImageReference(tc.image, "registry.corp.local"); got != tc.expected {
+				t.Errorf("rewriteImageReference(%q) = %q, want %q", tc.image, got, tc.expected)
+			}
+		})
+	}
+}
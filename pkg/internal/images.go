@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Scripts ListImages can generate to copy the images to a mirror registry.
+const (
+	MirrorScriptSkopeo = "skopeo"
+	MirrorScriptDocker = "docker"
+)
+
+// chartImage is an image referenced by the charts of an installation.
+type chartImage struct {
+	Image  string   `json:"image"`
+	Charts []string `json:"charts"`
+}
+
+// ListImages prints every image the charts of the components would pull,
+// found in the manifests helm template renders with the generated values and
+// in the merged values of the charts, e.g. the sidecars the worker operator
+// starts. With a mirror registry a script copying the images to it is printed
+// instead. The images are listed as pulled from their source registries,
+// global_image_registry is not applied. Progress is written to stderr so that the list can be piped.
+func ListImages(specs *ConfigurationSpecs, components []string, outputFormat, mirrorRegistry, script string) {
+	defer func(output io.Writer) { util.Output = output }(util.Output)
+	util.Output = os.Stderr
+	defer func() { rewriteImages = true }()
+	rewriteImages = false
+
+	// only helm is needed, the clusters are not touched
+	verifyExecutable("helm")
+	AddHelmCharts(specs)
+	ValidateChartVersions(specs, components)
+	images := collectChartImages(specs, components)
+
+	util.Output = os.Stdout
+	switch {
+	case mirrorRegistry != "":
+		util.Printf("%s", mirrorScript(images, mirrorRegistry, script))
+	case outputFormat == "json":
+		out, _ := json.MarshalIndent(images, "", "  ")
+		util.Printf("%s", out)
+	default:
+		for _, image := range images {
+			util.Printf("%s", image.Image)
+		}
+	}
+}
+
+// collectChartImages renders the releases of the components and returns the
+// images they reference, sorted and deduplicated.
+func collectChartImages(specs *ConfigurationSpecs, components []string) []chartImage {
+	GenerateKubeSliceDirectory()
+	config := specs.Configuration
+	hc := config.HelmChartConfiguration
+	// the control plane addresses only end up in the values, the clusters may
+	// not exist yet
+	for _, cluster := range getAllClusters(&config.ClusterConfiguration) {
+		if cluster.ControlPlaneAddress == "" {
+			cluster.ControlPlaneAddress = "https://" + cluster.Name + "-control-plane:6443"
+		}
+	}
+	found := make(map[string]map[string]bool)
+	add := func(image, chart string) {
+		image = normalizeImage(image)
+		if image == "" {
+			return
+		}
+		if found[image] == nil {
+			found[image] = make(map[string]bool)
+		}
+		found[image][chart] = true
+	}
+	releases := componentReleases(specs)
+	for _, component := range components {
+		cr := releases[component]
+		if cr.chart.ChartName == "" {
+			continue
+		}
+		util.Printf("\nRendering chart %s...", chartReference(hc, cr.chart))
+		defaults, err := showChartValues(hc, &cr.chart)
+		if err != nil {
+			util.Fatalf("%s Unable to read the values of chart %s: %v", util.Cross, chartReference(hc, cr.chart), err)
+		}
+		for _, cluster := range cr.clusters {
+			valuesFile := generateComponentValues(specs, component, cluster, cr)
+			manifest, err := templateRelease(hc, cr, cr.namespace(cluster), valuesFile)
+			if err != nil {
+				util.Fatalf("%s Unable to render release %s for %s: %v", util.Cross, cr.release, cluster.Name, err)
+			}
+			for _, image := range manifestImages(manifest) {
+				add(image, cr.chart.ChartName)
+			}
+			values, err := valuesFileValues(valuesFile)
+			if err != nil {
+				util.Fatalf("%s %v", util.Cross, err)
+			}
+			for _, image := range valuesImages(mergeMaps(normalizeValue(defaults).(map[interface{}]interface{}), values)) {
+				add(image, cr.chart.ChartName)
+			}
+		}
+		util.Printf("%s Rendered chart %s", util.Tick, chartReference(hc, cr.chart))
+	}
+	images := make([]chartImage, 0, len(found))
+	for image, charts := range found {
+		ci := chartImage{Image: image}
+		for chart := range charts {
+			ci.Charts = append(ci.Charts, chart)
+		}
+		sort.Strings(ci.Charts)
+		images = append(images, ci)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Image < images[j].Image })
+	return images
+}
+
+func valuesFileValues(valuesFile string) (map[interface{}]interface{}, error) {
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", valuesFile, err)
+	}
+	return values, nil
+}
+
+// manifestImages returns the image fields of the rendered manifests, which
+// covers containers, init containers and ephemeral containers of every kind
+// of workload.
+func manifestImages(manifest []byte) []string {
+	images := make([]string, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			// helm template output is valid YAML, nothing follows an error
+			return images
+		}
+		walkValues(document, func(key string, value interface{}, parent map[interface{}]interface{}) {
+			if s, ok := value.(string); ok && key == "image" {
+				images = append(images, s)
+			}
+		})
+	}
+}
+
+// valuesImages returns the images referenced in chart values, either as a
+// complete reference or split in registry, repository and tag, the way charts
+// commonly describe the images they pass to their operators:
+//
+//	image: aveshasystems/worker-operator
+//	tag: 1.0.0
+func valuesImages(values map[interface{}]interface{}) []string {
+	images := make([]string, 0)
+	walkValues(values, func(key string, value interface{}, parent map[interface{}]interface{}) {
+		s, ok := value.(string)
+		if !ok || s == "" || strings.ContainsAny(s, " {}") {
+			return
+		}
+		tag, _ := parent["tag"].(string)
+		switch key {
+		case "image":
+			if tag != "" && !strings.Contains(s[strings.LastIndex(s, "/")+1:], ":") {
+				s += ":" + tag
+			}
+			if looksLikeImage(s) {
+				images = append(images, s)
+			}
+		case "repository":
+			if registry, _ := parent["registry"].(string); registry != "" {
+				s = registry + "/" + s
+			}
+			// repositories without a tag are usually defaulted to the app
+			// version by the templates, which the rendered manifests cover
+			if tag != "" && looksLikeImage(s) {
+				images = append(images, s+":"+tag)
+			}
+		}
+	})
+	return images
+}
+
+// walkValues calls fn with every key of every map nested in value.
+func walkValues(value interface{}, fn func(key string, value interface{}, parent map[interface{}]interface{})) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			if key, ok := k.(string); ok {
+				fn(key, item, v)
+			}
+			walkValues(item, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkValues(item, fn)
+		}
+	}
+}
+
+// looksLikeImage reports whether a value is plausibly an image reference and
+// not e.g. a pull policy or a boolean.
+func looksLikeImage(s string) bool {
+	if strings.Contains(s, "://") {
+		return false
+	}
+	return strings.Contains(s, "/") || strings.Contains(s, ":")
+}
+
+// normalizeImage returns the fully qualified form of an image reference:
+// nginx becomes docker.io/library/nginx:latest.
+func normalizeImage(image string) string {
+	image = strings.TrimSpace(image)
+	if image == "" {
+		return ""
+	}
+	name, digest := image, ""
+	if i := strings.Index(image, "@"); i >= 0 {
+		name, digest = image[:i], image[i:]
+	}
+	if digest == "" && !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+	parts := strings.SplitN(name, "/", 2)
+	switch {
+	case len(parts) == 1:
+		name = "docker.io/library/" + name
+	case !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost":
+		name = "docker.io/" + name
+	}
+	return name + digest
+}
+
+// mirrorImage returns the reference of an image in the mirror registry, which
+// keeps the repository path of the source registry.
+func mirrorImage(image, mirrorRegistry string) string {
+	path := image[strings.Index(image, "/")+1:]
+	if i := strings.Index(path, "@"); i >= 0 {
+		// a digest cannot be pushed to, the mirror is tagged with the digest
+		path = path[:i] + ":" + strings.TrimPrefix(path[i+1:], "sha256:")
+	}
+	return strings.TrimSuffix(mirrorRegistry, "/") + "/" + path
+}
+
+// mirrorScript returns a shell script copying the images to the mirror
+// registry with skopeo or docker.
+func mirrorScript(images []chartImage, mirrorRegistry, script string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	for _, image := range images {
+		target := mirrorImage(image.Image, mirrorRegistry)
+		if script == MirrorScriptDocker {
+			fmt.Fprintf(&b, "docker pull %s\ndocker tag %s %s\ndocker push %s\n", image.Image, image.Image, target, target)
+			continue
+		}
+		fmt.Fprintf(&b, "skopeo copy --all docker://%s docker://%s\n", image.Image, target)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestManifestImages(t *testing.T) {
+	t.Parallel()
+
+	manifest := `---
+# Source: kubeslice-worker/templates/operator.yaml
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.36
+      containers:
+      - name: manager
+        image: docker.io/aveshasystems/worker-operator:1.0.0
+        env:
+        - name: AVESHA_VL3_SIDECAR_IMAGE
+          value: docker.io/aveshasystems/kubeslice-router-sidecar:1.4.2
+---
+# Source: kubeslice-worker/templates/job.yaml
+apiVersion: batch/v1
+kind: Job
+spec:
+  template:
+    spec:
+      containers:
+      - name: cleanup
+        image: "bitnami/kubectl@sha256:0123abcd"
+`
+	expected := []string{"bitnami/kubectl@sha256:0123abcd", "busybox:1.36", "docker.io/aveshasystems/worker-operator:1.0.0"}
+	got := manifestImages([]byte(manifest))
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("manifestImages() = %q, want %q", got, expected)
+	}
+}
+
+func TestValuesImages(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		values   map[interface{}]interface{}
+		expected []string
+	}{
+		{
+			name: "Image and tag",
+			values: map[interface{}]interface{}{
+				"routerSidecar": map[interface{}]interface{}{"image": "docker.io/aveshasystems/kubeslice-router-sidecar", "tag": "1.4.2", "pullPolicy": "IfNotPresent"},
+			},
+			expected: []string{"docker.io/aveshasystems/kubeslice-router-sidecar:1.4.2"},
+		},
+		{
+			name: "Registry, repository and tag",
+			values: map[interface{}]interface{}{
+				"prometheus": map[interface{}]interface{}{"image": map[interface{}]interface{}{"registry": "quay.io", "repository": "prometheus/prometheus", "tag": "v2.45.0"}},
+			},
+			expected: []string{"quay.io/prometheus/prometheus:v2.45.0"},
+		},
+		{
+			name: "Not images",
+			values: map[interface{}]interface{}{
+				"image":      map[interface{}]interface{}{"repository": "controller"},
+				"gateway":    map[interface{}]interface{}{"image": "{{ .Values.global.image }}"},
+				"metrics":    map[interface{}]interface{}{"image": "enabled"},
+				"repository": "https://kubeslice.github.io/kubeslice/",
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := valuesImages(tc.values); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("valuesImages() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"nginx", "docker.io/library/nginx:latest"},
+		{"busybox:1.36", "docker.io/library/busybox:1.36"},
+		{"aveshasystems/worker-operator:1.0.0", "docker.io/aveshasystems/worker-operator:1.0.0"},
+		{"quay.io/jetstack/cert-manager-controller:v1.7.0", "quay.io/jetstack/cert-manager-controller:v1.7.0"},
+		{"localhost:5000/kubeslice/controller", "localhost:5000/kubeslice/controller:latest"},
+		{"bitnami/kubectl@sha256:0123abcd", "docker.io/bitnami/kubectl@sha256:0123abcd"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.image, func(t *testing.T) {
+			t.Parallel()
+
+			if got := normalizeImage(tc.image); got != tc.expected {
+				t.Errorf("normalizeImage(%q) = %q, want %q", tc.image, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMirrorScript(t *testing.T) {
+	t.Parallel()
+
+	images := []chartImage{
+		{Image: "docker.io/aveshasystems/worker-operator:1.0.0"},
+		{Image: "docker.io/bitnami/kubectl@sha256:0123abcd"},
+	}
+	tests := []struct {
+		script   string
+		expected string
+	}{
+		{
+			script: MirrorScriptSkopeo,
+			expected: "#!/bin/sh\nset -e\n" +
+				"skopeo copy --all docker://docker.io/aveshasystems/worker-operator:1.0.0 docker://registry.internal:5000/aveshasystems/worker-operator:1.0.0\n" +
+				"skopeo copy --all docker://docker.io/bitnami/kubectl@sha256:0123abcd docker://registry.internal:5000/bitnami/kubectl:0123abcd",
+		},
+		{
+			script: MirrorScriptDocker,
+			expected: "#!/bin/sh\nset -e\n" +
+				"docker pull docker.io/aveshasystems/worker-operator:1.0.0\n" +
+				"docker tag docker.io/aveshasystems/worker-operator:1.0.0 registry.internal:5000/aveshasystems/worker-operator:1.0.0\n" +
+				"docker push registry.internal:5000/aveshasystems/worker-operator:1.0.0\n" +
+				"docker pull docker.io/bitnami/kubectl@sha256:0123abcd\n" +
+				"docker tag docker.io/bitnami/kubectl@sha256:0123abcd registry.internal:5000/bitnami/kubectl:0123abcd\n" +
+				"docker push registry.internal:5000/bitnami/kubectl:0123abcd",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.script, func(t *testing.T) {
+			t.Parallel()
+
+			if got := mirrorScript(images, "registry.internal:5000/", tc.script); got != tc.expected {
+				t.Errorf("mirrorScript() =\n%s\nwant\n%s", got, tc.expected)
+			}
+		})
+	}
+}
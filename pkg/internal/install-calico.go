@@ -1,18 +1,17 @@
 package internal
 
 import (
-	"bytes"
-	"log"
-	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
+// InstallCalico installs Calico on the kind clusters the selected components
+// are installed onto.
 func InstallCalico(clusterConfig *ClusterConfiguration) {
 	util.Printf("\nInstalling Calico Networking...")
 
-	clusters := getAllClusters(clusterConfig)
+	clusters := selectedClusters(clusterConfig)
 	for _, cluster := range clusters {
 		if !calicoAlreadyInstalled(cluster) {
 			util.Printf("Installing on Cluster %s", cluster.Name)
@@ -33,12 +32,8 @@ func InstallCalico(clusterConfig *ClusterConfiguration) {
 }
 
 func calicoAlreadyInstalled(cluster *Cluster) bool {
-	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "namespace", "calico-system")
-	if err != nil {
-		if strings.Contains(errB.String(), "NotFound") {
-			return false
-		}
+	if exists, err := objectExists(cluster, namespaceResource, "", "calico-system"); err == nil && !exists {
+		return false
 	}
 	PodVerification("Waiting for Calico Pods to be Healthy", *cluster, "calico-system")
 	util.Printf("%s Calico Networking already present on cluster %s", util.Tick, cluster.Name)
@@ -48,13 +43,13 @@ func calicoAlreadyInstalled(cluster *Cluster) bool {
 func installCalicoOperatorPrerequisites(cluster *Cluster) {
 	err := util.RunCommand("kubectl", "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "create", "-f", "https://raw.githubusercontent.com/projectcalico/calico/v3.24.0/manifests/tigera-operator.yaml")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
 func createCalicoOperator(cluster *Cluster) {
 	err := util.RunCommand("kubectl", "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "create", "-f", "https://raw.githubusercontent.com/projectcalico/calico/v3.24.0/manifests/custom-resources.yaml")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
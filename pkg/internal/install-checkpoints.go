@@ -0,0 +1,279 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// installCheckpointDirectory, ~/.kubeslice/checkpoints, holds a checkpoint
+// file per installation, recording the steps its last install completed so
+// that a failed install can be resumed from the failed step.
+var installCheckpointDirectory = filepath.Join(kubesliceHomeDirectory, "checkpoints")
+
+// stepCheckpoint records a step an install completed with the hash of its
+// inputs. A step whose inputs changed since is run again.
+type stepCheckpoint struct {
+	Step        string    `yaml:"step"`
+	InputsHash  string    `yaml:"inputs_hash"`
+	CompletedAt time.Time `yaml:"completed_at"`
+}
+
+// installCheckpoints is the checkpoint file of an installation.
+type installCheckpoints struct {
+	Installation string `yaml:"installation"`
+	// Workspace is the workspace of the run which completed the steps
+	Workspace string           `yaml:"workspace"`
+	Steps     []stepCheckpoint `yaml:"steps"`
+}
+
+// stepInputs are what a step is run with. The chart steps hash their chart
+// with the version it resolved to and the options of its repository, the
+// other steps the sections of the topology they apply.
+type stepInputs struct {
+	Step      string      `yaml:"step"`
+	Clusters  []Cluster   `yaml:"clusters"`
+	Chart     *HelmChart  `yaml:"chart,omitempty"`
+	Reference string      `yaml:"reference,omitempty"`
+	Version   string      `yaml:"version,omitempty"`
+	Registry  string      `yaml:"registry,omitempty"`
+	Secrets   bool        `yaml:"secrets,omitempty"`
+	Config    interface{} `yaml:"config,omitempty"`
+}
+
+func installCheckpointsPath(name string) string {
+	return filepath.Join(installCheckpointDirectory, name+".yaml")
+}
+
+// loadInstallCheckpoints reads the checkpoint file of an installation. A
+// missing file is reported with an error wrapping os.ErrNotExist.
+func loadInstallCheckpoints(name string) (*installCheckpoints, error) {
+	path := installCheckpointsPath(name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := &installCheckpoints{}
+	if err := yaml.Unmarshal(data, checkpoints); err != nil {
+		return nil, fmt.Errorf("checkpoints %s are corrupted: %v. Remove the file or pass --no-resume", path, err)
+	}
+	return checkpoints, nil
+}
+
+// stepInputsHash returns the hash of the inputs of a step of an install.
+func stepInputsHash(specs *ConfigurationSpecs, step string) (string, error) {
+	config := specs.Configuration
+	cc := config.ClusterConfiguration
+	hc := config.HelmChartConfiguration
+	inputs := stepInputs{Step: step, Clusters: append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)}
+	if cr, found := componentReleases(specs)[step]; found {
+		chart := cr.chart
+		inputs.Clusters, inputs.Chart = cr.clusters, &chart
+		inputs.Reference = chartReference(hc.forChart(chart), chart)
+		// a range pinned by the chart may resolve to another version
+		inputs.Version = runSummary.Charts[step].Version
+		inputs.Registry, inputs.Secrets = hc.GlobalImageRegistry, hc.PropagateImagePullSecrets
+	}
+	switch step {
+	case Kind_Component:
+		inputs.Config = cc.Profile
+	case Controller_Component:
+		inputs.Config = []interface{}{config.KubeSliceConfiguration, config.License, config.Monitoring.Enabled}
+	case Worker_Component:
+		inputs.Config = config.Monitoring.Enabled
+	case UI_install_Component:
+		inputs.Config = config.UI
+	case Worker_registration_Component, Demo_Component:
+		inputs.Config = config.KubeSliceConfiguration
+	case Monitoring_Component:
+		inputs.Config = config.Monitoring
+	}
+	data, err := yaml.Marshal(inputs)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// verifyCheckpoint re-verifies the cheap invariants of a step a failed install
+// completed before it is skipped: its kind clusters, releases and objects
+// still exist. It returns the releases of the step, recorded in the run
+// summary as if they were installed by this run.
+func verifyCheckpoint(specs *ConfigurationSpecs, step string) ([]releaseSummary, error) {
+	config := specs.Configuration
+	cc := config.ClusterConfiguration
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	releases := make([]releaseSummary, 0)
+	exists := func(cluster Cluster, resource, namespace, name string) error {
+		found, err := objectExists(&cluster, resource, namespace, name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%s %s no longer exists on %s", resource, name, cluster.Name)
+		}
+		return nil
+	}
+	switch step {
+	case Kind_Component:
+		pointers := getAllClusters(&cc)
+		for i, found := range getExistingClusters(pointers) {
+			if !found {
+				return nil, fmt.Errorf("kind cluster %s no longer exists", pointers[i].Name)
+			}
+		}
+	case Calico_Component:
+		for _, cluster := range clusters {
+			if err := exists(cluster, namespaceResource, "", "calico-system"); err != nil {
+				return nil, err
+			}
+		}
+	case Worker_registration_Component:
+		for _, worker := range touchedWorkers(cc.WorkerClusters) {
+			if err := exists(cc.ControllerCluster, ClusterObject, projectNamespace(workerProject(config.KubeSliceConfiguration, worker)), worker.Name); err != nil {
+				return nil, err
+			}
+		}
+	case Monitoring_Component:
+		for _, m := range kubesliceServiceMonitors(specs) {
+			if served, err := objectExists(&m.cluster, crdResource, "", serviceMonitorCRD); err != nil || !served {
+				continue
+			}
+			if err := exists(m.cluster, serviceMonitorCRD, m.namespace, m.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cr, found := componentReleases(specs)[step]; found {
+		for _, cluster := range cr.clusters {
+			if untouchedClusters[cluster.Name] {
+				continue
+			}
+			release, err := findRelease(cluster, cr.release, cr.namespace(cluster))
+			if err != nil {
+				return nil, err
+			}
+			if release == nil || release.Status != "deployed" {
+				return nil, fmt.Errorf("release %s is not deployed on %s", cr.release, cluster.Name)
+			}
+			releases = append(releases, releaseSummary{Cluster: cluster.Name, Release: release.Name, Namespace: release.Namespace, Revision: release.Revision, Status: release.Status, Chart: release.Chart})
+		}
+	}
+	if step == Controller_Component {
+		for _, project := range ProjectNames(config.KubeSliceConfiguration) {
+			if err := exists(cc.ControllerCluster, ProjectObject, ControllerNamespace(cc.ControllerCluster), project); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return releases, nil
+}
+
+// Resume loads the checkpoints of the previous install of the installation.
+// With --resume the steps it completed with the same inputs are skipped once
+// their invariants are verified, --no-resume discards them. The checkpoints
+// of this run start from the skipped steps.
+func (p *InstallPlan) Resume(specs *ConfigurationSpecs) {
+	name := InstallationName(specs)
+	p.installation, p.inputs, p.resumed = name, make(map[string]string), make(map[string]bool)
+	for _, step := range p.Steps {
+		hash, err := stepInputsHash(specs, step)
+		if err != nil {
+			util.Fatalf("%s Unable to hash the inputs of step %s: %v", util.Cross, step, err)
+		}
+		p.inputs[step] = hash
+	}
+	if !Options.PlanOnly {
+		util.OnFailure(func() {
+			if len(p.completed) > 0 {
+				util.Printf("%s Run the install again with --resume to skip the %d completed steps", util.Warn, len(p.completed))
+			}
+		})
+	}
+	if Options.NoResume {
+		if !Options.PlanOnly {
+			if err := os.Remove(installCheckpointsPath(name)); err == nil {
+				util.Printf("%s Discarded the checkpoints of the previous install of %s", util.Tick, name)
+			}
+		}
+		return
+	}
+	checkpoints, err := loadInstallCheckpoints(name)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if Options.Resume {
+			util.Printf("%s No failed install of %s to resume, every step is run", util.Warn, name)
+		}
+		return
+	case err != nil:
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if !Options.Resume {
+		if len(checkpoints.Steps) > 0 {
+			util.Printf("%s The previous install of %s completed %d steps before it failed, pass --resume to skip them", util.Warn, name, len(checkpoints.Steps))
+		}
+		return
+	}
+	util.Printf("\nResuming the failed install of %s, its generated files are in %s", name, checkpoints.Workspace)
+	completed := make(map[string]stepCheckpoint, len(checkpoints.Steps))
+	for _, checkpoint := range checkpoints.Steps {
+		completed[checkpoint.Step] = checkpoint
+	}
+	for _, step := range p.Steps {
+		checkpoint, found := completed[step]
+		if !found {
+			continue
+		}
+		if checkpoint.InputsHash != p.inputs[step] {
+			util.Printf("%s %s: the topology changed since, the step is run again", util.Warn, stepDescriptions[step])
+			continue
+		}
+		releases, err := verifyCheckpoint(specs, step)
+		if err != nil {
+			util.Printf("%s %s: %v, the step is run again", util.Warn, stepDescriptions[step], err)
+			continue
+		}
+		p.resumed[step] = true
+		p.completed = append(p.completed, checkpoint)
+		runSummary.Releases = append(runSummary.Releases, releases...)
+	}
+}
+
+// Resumed tells whether --resume skips a step a failed install completed.
+func (p *InstallPlan) Resumed(step string) bool {
+	return p.resumed[step]
+}
+
+// Complete records the checkpoint of a step this run completed.
+func (p *InstallPlan) Complete(step string) {
+	if p.installation == "" || Options.PlanOnly {
+		return
+	}
+	p.completed = append(p.completed, stepCheckpoint{Step: step, InputsHash: p.inputs[step], CompletedAt: time.Now().UTC().Truncate(time.Second)})
+	data, err := yaml.Marshal(installCheckpoints{Installation: p.installation, Workspace: kubesliceDirectory, Steps: p.completed})
+	if err == nil {
+		util.CreateDirectoryPath(installCheckpointDirectory)
+		err = util.WriteFileAtomic(installCheckpointsPath(p.installation), data, 0600)
+	}
+	if err != nil {
+		util.Printf("%s Unable to record the checkpoint of step %s: %v", util.Warn, step, err)
+	}
+}
+
+// Finish removes the checkpoints of an install which completed every step,
+// there is nothing left to resume.
+func (p *InstallPlan) Finish() {
+	if p.installation == "" {
+		return
+	}
+	if err := os.Remove(installCheckpointsPath(p.installation)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		util.Printf("%s Unable to remove the checkpoints of installation %s: %v", util.Warn, p.installation, err)
+	}
+}
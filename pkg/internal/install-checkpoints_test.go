@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func checkpointSpecs() *ConfigurationSpecs {
+	return &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl"},
+			WorkerClusters:    []Cluster{{Name: "ks-w-1", ContextName: "ks-w-1"}},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		HelmChartConfiguration: HelmChartConfiguration{
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.0.0"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker", Version: "1.0.0"},
+		},
+	}}
+}
+
+func TestStepInputsHash(t *testing.T) {
+	defer func(summary RunSummary) { runSummary = summary }(runSummary)
+	runSummary = RunSummary{}
+	specs := checkpointSpecs()
+	hash := func(step string) string {
+		h, err := stepInputsHash(specs, step)
+		if err != nil {
+			t.Fatalf("stepInputsHash() error = %v", err)
+		}
+		return h
+	}
+	controller, worker := hash(Controller_Component), hash(Worker_Component)
+	specs.Configuration.HelmChartConfiguration.ControllerChart.Version = "1.1.0"
+	if hash(Controller_Component) == controller {
+		t.Errorf("stepInputsHash() of the controller did not change with its chart version")
+	}
+	if hash(Worker_Component) != worker {
+		t.Errorf("stepInputsHash() of the worker changed with the version of the controller chart")
+	}
+	// a range resolves to the latest matching version of the run
+	specs.Configuration.HelmChartConfiguration.WorkerChart.Version = "~1.0"
+	runSummary.Charts = map[string]chartSummary{Worker_Component: {Version: "1.0.1"}}
+	ranged := hash(Worker_Component)
+	runSummary.Charts[Worker_Component] = chartSummary{Version: "1.0.2"}
+	if hash(Worker_Component) == ranged {
+		t.Errorf("stepInputsHash() of the worker did not change with the version its range resolved to")
+	}
+}
+
+func TestInstallPlanResume(t *testing.T) {
+	defer func(dir string, client KubeClient, options RunOptions) {
+		installCheckpointDirectory, kubeClient, *Options = dir, client, options
+	}(installCheckpointDirectory, kubeClient, *Options)
+
+	specs := checkpointSpecs()
+	steps := []string{Worker_registration_Component, Demo_Component}
+	tests := []struct {
+		name       string
+		resume     bool
+		registered []string
+		expected   map[string]bool
+	}{
+		{
+			name:       "Resume",
+			resume:     true,
+			registered: []string{"ks-w-1"},
+			expected:   map[string]bool{Worker_registration_Component: true, Demo_Component: false},
+		},
+		{
+			name:     "Invariant no longer holds",
+			resume:   true,
+			expected: map[string]bool{Worker_registration_Component: false, Demo_Component: false},
+		},
+		{
+			name:       "Without --resume",
+			registered: []string{"ks-w-1"},
+			expected:   map[string]bool{Worker_registration_Component: false, Demo_Component: false},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			installCheckpointDirectory = t.TempDir()
+			kubeClient = &fakeKubeClient{objects: map[string][]string{objectsKey(ClusterObject, "kubeslice-demo"): tc.registered}}
+			Options.Resume, Options.NoResume, Options.PlanOnly = tc.resume, false, false
+
+			// a failed install completed both steps, the demo with other inputs
+			failed := &InstallPlan{Steps: steps}
+			failed.Resume(specs)
+			failed.Complete(Worker_registration_Component)
+			failed.inputs[Demo_Component] = "changed"
+			failed.Complete(Demo_Component)
+
+			plan := &InstallPlan{Steps: steps}
+			plan.Resume(specs)
+			resumed := make(map[string]bool)
+			for _, step := range steps {
+				resumed[step] = plan.Resumed(step)
+			}
+			if !reflect.DeepEqual(resumed, tc.expected) {
+				t.Errorf("Resumed() = %v, want %v", resumed, tc.expected)
+			}
+
+			plan.Finish()
+			if _, err := os.Stat(installCheckpointsPath("ks-ctrl")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Finish() left the checkpoints behind: %v", err)
+			}
+		})
+	}
+}
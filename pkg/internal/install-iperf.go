@@ -1,37 +1,16 @@
 package internal
 
 import (
-	"log"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
 const (
-	iPerfClientFileName              = "iperf-client.yaml"
-	iPerfServerFileName              = "iperf-server.yaml"
-	iPerfServerServiceExportFileName = "iperf-server-service-export.yaml"
+	iPerfClientFileName = "iperf-client.yaml"
+	iPerfServerFileName = "iperf-server.yaml"
 )
 
-const iPerfServiceExportTemplate = `
----
-apiVersion: networking.kubeslice.io/v1beta1
-kind: ServiceExport
-metadata:
-  name: iperf-server
-  namespace: iperf
-spec:
-  slice: demo
-  selector:
-    matchLabels:
-      app: iperf-server
-  ingressEnabled: false
-  ports:
-  - name: tcp
-    containerPort: 5201
-    protocol: TCP
-`
-
 const iPerfServerTemplate = `
 ---
 apiVersion: v1
@@ -120,6 +99,7 @@ spec:
 
 func InstallIPerf(ApplicationConfiguration *ConfigurationSpecs) {
 	util.Printf("\nInstalling iPerf Application...")
+	WaitForWorkersReady(ApplicationConfiguration)
 
 	clientFileName := iPerfClientFileName
 	serverFileName := iPerfServerFileName
@@ -148,6 +128,7 @@ func InstallIPerf(ApplicationConfiguration *ConfigurationSpecs) {
 }
 
 func GenerateIPerfManifests() {
+	GenerateKubeSliceDirectory()
 	// --- Client Manifests
 	util.DumpFile(iPerfClientTemplate, kubesliceDirectory+"/"+iPerfClientFileName)
 	util.Printf("%s Generated iPerf Client manifest %s", util.Tick, iPerfClientFileName)
@@ -159,26 +140,49 @@ func GenerateIPerfManifests() {
 	time.Sleep(200 * time.Millisecond)
 }
 
+// iPerfServiceExport exports the iperf server of the first worker to the
+// slice of the demo.
+func iPerfServiceExport(ApplicationConfiguration *ConfigurationSpecs) ServiceExport {
+	return ServiceExport{
+		Slice:            configuredSlice(ApplicationConfiguration, nil, "").Name,
+		Service:          "iperf-server",
+		ServiceNamespace: "iperf",
+		SourceCluster:    ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters[0].Name,
+		Ports:            []string{"5201/TCP"},
+	}
+}
+
+// iPerfServiceExportConfigFileName is the file of the ServiceExportConfig of
+// the iperf server.
+func iPerfServiceExportConfigFileName(ApplicationConfiguration *ConfigurationSpecs) string {
+	return serviceExportConfigFileName(serviceExportConfigName(iPerfServiceExport(ApplicationConfiguration)))
+}
+
+// GenerateIPerfServiceExportManifest generates the ServiceExportConfig of the
+// iperf server, for the demos which leave applying it to the user.
 func GenerateIPerfServiceExportManifest(ApplicationConfiguration *ConfigurationSpecs) {
-	util.DumpFile(iPerfServiceExportTemplate, kubesliceDirectory+"/"+iPerfServerServiceExportFileName)
-	util.Printf("%s Generated iPerf Server Service Export manifest %s for cluster %s", util.Tick, iPerfServerServiceExportFileName, ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters[0].Name)
+	GenerateServiceExportConfig(iPerfServiceExport(ApplicationConfiguration), projectNamespace(ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName))
 	time.Sleep(200 * time.Millisecond)
 }
 
+// ApplyIPerfServiceExportManifest exports the iperf server to the slice of
+// the demo through a ServiceExportConfig on the controller cluster.
 func ApplyIPerfServiceExportManifest(ApplicationConfiguration *ConfigurationSpecs) {
-	ApplyKubectlManifest(kubesliceDirectory+"/"+iPerfServerServiceExportFileName, "iperf", &ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters[0])
+	GenerateAndApplyServiceExportConfig(iPerfServiceExport(ApplicationConfiguration),
+		projectNamespace(ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName),
+		&ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster, false)
 }
 
 func RolloutRestartIPerf(ApplicationConfiguration *ConfigurationSpecs) {
 	clusters := getAllClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)[1:]
-	err := util.RunCommand("kubectl", "rollout", "restart", "deployment/iperf-server", "-n", "iperf", "--context="+clusters[0].ContextName, "--kubeconfig="+clusters[0].KubeConfigPath)
+	err := util.RunCommand("kubectl", append([]string{"rollout", "restart", "deployment/iperf-server", "-n", "iperf"}, kubectlClusterArgs(clusters[0])...)...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 	for i := 1; i < len(clusters); i++ {
-		err = util.RunCommand("kubectl", "rollout", "restart", "deployment/iperf-sleep", "-n", "iperf", "--context="+clusters[i].ContextName, "--kubeconfig="+clusters[i].KubeConfigPath)
+		err = util.RunCommand("kubectl", append([]string{"rollout", "restart", "deployment/iperf-sleep", "-n", "iperf"}, kubectlClusterArgs(clusters[i])...)...)
 		if err != nil {
-			log.Fatalf("Process failed %v", err)
+			util.Fatalf("Process failed %v", err)
 		}
 	}
 
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Profiles of install besides the enterprise demo. The demo profiles create
+// kind clusters, custom installs on the clusters of the topology.
+const (
+	ProfileMinimalDemo = "minimal-demo"
+	ProfileFullDemo    = "full-demo"
+	ProfileCustom      = "custom"
+)
+
+// HelmTestsStep runs the helm tests of the installed releases.
+const HelmTestsStep = "helm-tests"
+
+// installProfile is what a profile sets up unless the topology says
+// otherwise.
+type installProfile struct {
+	// kind worker clusters of a topology without workers, and the least a
+	// topology of the profile needs
+	workers    int
+	minWorkers int
+	// installs the example applications after KubeSlice
+	demo bool
+}
+
+// profileNames are the profiles in the order they are listed, a new profile
+// is added here and to installProfiles.
+var profileNames = []string{ProfileMinimalDemo, ProfileFullDemo, ProfileEntDemo, ProfileCustom}
+
+var installProfiles = map[string]installProfile{
+	ProfileMinimalDemo: {workers: 1, minWorkers: 1, demo: true},
+	ProfileFullDemo:    {workers: 2, minWorkers: 2, demo: true},
+	ProfileEntDemo:     {workers: 2, minWorkers: 2, demo: true},
+	ProfileCustom:      {},
+}
+
+// stepDescriptions are printed when the steps start.
+var stepDescriptions = map[string]string{
+	Kind_Component:                "Creating the kind clusters",
+	Calico_Component:              "Installing Calico",
+	CertManager_Component:         "Installing Cert-Manager",
+	Controller_Component:          "Installing the KubeSlice Controller and the project",
+	UI_install_Component:          "Installing the KubeSlice Manager",
+	Worker_registration_Component: "Registering the worker clusters",
+	Worker_Component:              "Installing the KubeSlice Workers",
+	Prometheus_Component:          "Installing Prometheus",
+	Monitoring_Component:          "Installing monitoring",
+	HelmTestsStep:                 "Running the helm tests",
+	Demo_Component:                "Installing the example applications",
+}
+
+// ValidateProfile checks that a profile is known.
+func ValidateProfile(profile string) error {
+	if _, found := installProfiles[profile]; !found {
+		return fmt.Errorf("Unknown profile: %s. Possible values %s", profile, profileNames)
+	}
+	return nil
+}
+
+// ApplyProfile sets the profile of a topology which does not set one, with
+// the kind clusters of a demo profile when the topology has none. What the
+// topology sets wins, custom leaves it as is.
+func ApplyProfile(specs *ConfigurationSpecs, profile string) {
+	cc := &specs.Configuration.ClusterConfiguration
+	if profile == ProfileCustom || cc.Profile != "" {
+		return
+	}
+	cc.Profile = profile
+	if cc.ControllerCluster.Name == "" {
+		cc.ControllerCluster.Name = "ks-ctrl"
+	}
+	if len(cc.WorkerClusters) == 0 {
+		for i := 1; i <= installProfiles[profile].workers; i++ {
+			cc.WorkerClusters = append(cc.WorkerClusters, Cluster{Name: fmt.Sprintf("ks-w-%d", i)})
+		}
+	}
+}
+
+// InstallPlan is the steps an install runs in order, numbered as they start.
+type InstallPlan struct {
+	Profile string
+	// Components are those selected with --components, empty for all
+	Components []string
+	Steps      []string
+	// installation the checkpoints of the completed steps are recorded for,
+	// with the hashes of the inputs of the steps and those --resume skips
+	installation string
+	inputs       map[string]string
+	resumed      map[string]bool
+	completed    []stepCheckpoint
+}
+
+// BuildInstallPlan expands the profile of a topology into the steps of an
+// install: the kind clusters of the demo profiles, the charts the topology
+// configures and the example applications on two workers or more, less the
+// skipped steps and the components which are not selected. Workers are registered along with the
+// controller, the example applications need both.
+func BuildInstallPlan(specs *ConfigurationSpecs, skipSteps map[string]string) *InstallPlan {
+	cc := specs.Configuration.ClusterConfiguration
+	hc := specs.Configuration.HelmChartConfiguration
+	profile := cc.Profile
+	if profile == "" {
+		profile = ProfileCustom
+	}
+	plan := &InstallPlan{Profile: profile, Components: Options.Components, Steps: make([]string, 0)}
+	selected := func(component string) bool {
+		return componentSelected(Options.Components, component)
+	}
+	add := func(step string, included bool) {
+		if _, skip := skipSteps[step]; included && !skip {
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+	_, skipController := skipSteps[Controller_Component]
+	add(Kind_Component, cc.Profile != "")
+	add(Calico_Component, kindTopology(cc))
+	// topologies without a cert-manager chart bring their own cert-manager
+	certManagerChart := hc.CertManagerChart.ChartName != "" || Options.CertManager == CertManagerInstall
+	add(CertManager_Component, certManagerChart && !skipController && selected(CertManager_Component))
+	add(Controller_Component, selected(Controller_Component))
+	add(UI_install_Component, hc.UIChart.ChartName != "" && selected(UI_install_Component))
+	add(Worker_registration_Component, selected(Controller_Component))
+	add(Worker_Component, selected(Worker_Component))
+	add(Prometheus_Component, hc.PrometheusChart.ChartName != "" && selected(Prometheus_Component))
+	add(Monitoring_Component, specs.Configuration.Monitoring.Enabled && (selected(Controller_Component) || selected(Worker_Component)))
+	add(HelmTestsStep, Options.HelmTests)
+	// iPerf needs a worker for its server and one for its client
+	add(Demo_Component, installProfiles[profile].demo && len(cc.WorkerClusters) >= 2 && selected(Controller_Component) && selected(Worker_Component))
+	return plan
+}
+
+// Includes tells whether the plan runs a step.
+func (p *InstallPlan) Includes(step string) bool {
+	return containsString(p.Steps, step)
+}
+
+// Charts are the components of the plan a chart is installed for, in
+// install order.
+func (p *InstallPlan) Charts() []string {
+	charts := make([]string, 0)
+	for _, step := range p.Steps {
+		switch step {
+		case CertManager_Component, Controller_Component, UI_install_Component, Worker_Component, Prometheus_Component:
+			charts = append(charts, step)
+		}
+	}
+	return charts
+}
+
+// Record prints the execution plan of the steps and records them in the run
+// summary.
+func (p *InstallPlan) Record(specs *ConfigurationSpecs) {
+	PrintExecutionPlan(p.Execution(specs), Options.PlanOutput)
+	runSummary.Profile = p.Profile
+	runSummary.Components = p.Components
+	runSummary.Plan = p.Steps
+	runSummary.Resumed = make([]string, 0)
+	for _, step := range p.Steps {
+		if p.Resumed(step) {
+			runSummary.Resumed = append(runSummary.Resumed, step)
+		}
+	}
+}
+
+// Start prints the number and description of a step as it starts. It tells
+// whether the step runs, a step a failed install completed is skipped with
+// --resume.
+func (p *InstallPlan) Start(step string) bool {
+	for i, s := range p.Steps {
+		if s == step {
+			util.Printf("\n[%d/%d] %s", i+1, len(p.Steps), stepDescriptions[step])
+		}
+	}
+	if p.Resumed(step) {
+		util.Printf("%s Completed by the failed install, skipped with --resume", util.Tick)
+		return false
+	}
+	return true
+}
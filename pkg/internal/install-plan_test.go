@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildInstallPlan(t *testing.T) {
+	defer func(helmTests bool) { Options.HelmTests = helmTests }(Options.HelmTests)
+	demoCharts := HelmChartConfiguration{
+		CertManagerChart: HelmChart{ChartName: "cert-manager"},
+		ControllerChart:  HelmChart{ChartName: "kubeslice-controller"},
+		WorkerChart:      HelmChart{ChartName: "kubeslice-worker"},
+	}
+	withUI := demoCharts
+	withUI.UIChart = HelmChart{ChartName: "kubeslice-ui"}
+	withoutCertManager := demoCharts
+	withoutCertManager.CertManagerChart = HelmChart{}
+
+	tests := []struct {
+		name            string
+		profile         string
+		cc              ClusterConfiguration
+		hc              HelmChartConfiguration
+		skipSteps       map[string]string
+		helmTests       bool
+		expectedProfile string
+		expected        []string
+	}{
+		{
+			name:            "Minimal demo",
+			profile:         ProfileMinimalDemo,
+			hc:              demoCharts,
+			helmTests:       true,
+			expectedProfile: ProfileMinimalDemo,
+			expected:        []string{"kind", "calico", "cert-manager", "controller", "worker-registration", "worker", "helm-tests"},
+		},
+		{
+			name:            "Minimal demo on two workers",
+			profile:         ProfileMinimalDemo,
+			cc:              ClusterConfiguration{WorkerClusters: []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}}},
+			hc:              demoCharts,
+			expectedProfile: ProfileMinimalDemo,
+			expected:        []string{"kind", "calico", "cert-manager", "controller", "worker-registration", "worker", "demo"},
+		},
+		{
+			name:            "Full demo on existing kind clusters",
+			profile:         ProfileFullDemo,
+			hc:              demoCharts,
+			skipSteps:       map[string]string{"kind": "", "demo": ""},
+			expectedProfile: ProfileFullDemo,
+			expected:        []string{"calico", "cert-manager", "controller", "worker-registration", "worker"},
+		},
+		{
+			name:            "Custom topology",
+			profile:         ProfileCustom,
+			cc:              ClusterConfiguration{ClusterType: "cloud", ControllerCluster: Cluster{Name: "ctrl"}, WorkerClusters: []Cluster{{Name: "w1"}}},
+			hc:              withUI,
+			skipSteps:       map[string]string{"controller": ""},
+			expectedProfile: ProfileCustom,
+			expected:        []string{"ui", "worker-registration", "worker"},
+		},
+		{
+			name:            "Custom topology without a cert-manager chart",
+			profile:         ProfileCustom,
+			cc:              ClusterConfiguration{ClusterType: "cloud", ControllerCluster: Cluster{Name: "ctrl"}, WorkerClusters: []Cluster{{Name: "w1"}}},
+			hc:              withoutCertManager,
+			expectedProfile: ProfileCustom,
+			expected:        []string{"controller", "worker-registration", "worker"},
+		},
+		{
+			name:            "Topology profile wins",
+			profile:         ProfileMinimalDemo,
+			cc:              ClusterConfiguration{Profile: ProfileFullDemo, WorkerClusters: []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}}},
+			hc:              demoCharts,
+			expectedProfile: ProfileFullDemo,
+			expected:        []string{"kind", "calico", "cert-manager", "controller", "worker-registration", "worker", "demo"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.HelmTests = tc.helmTests
+			specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: tc.cc, HelmChartConfiguration: tc.hc}}
+			ApplyProfile(specs, tc.profile)
+			plan := BuildInstallPlan(specs, tc.skipSteps)
+			if plan.Profile != tc.expectedProfile || !reflect.DeepEqual(plan.Steps, tc.expected) {
+				t.Errorf("BuildInstallPlan() = %s %v, want %s %v", plan.Profile, plan.Steps, tc.expectedProfile, tc.expected)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		profile  string
+		cc       ClusterConfiguration
+		expected ClusterConfiguration
+	}{
+		{
+			name:    "Kind clusters of the profile",
+			profile: ProfileFullDemo,
+			expected: ClusterConfiguration{
+				Profile:           ProfileFullDemo,
+				ControllerCluster: Cluster{Name: "ks-ctrl"},
+				WorkerClusters:    []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}},
+			},
+		},
+		{
+			name:    "Clusters of the topology win",
+			profile: ProfileMinimalDemo,
+			cc:      ClusterConfiguration{ControllerCluster: Cluster{Name: "hub"}, WorkerClusters: []Cluster{{Name: "spoke"}}},
+			expected: ClusterConfiguration{
+				Profile:           ProfileMinimalDemo,
+				ControllerCluster: Cluster{Name: "hub"},
+				WorkerClusters:    []Cluster{{Name: "spoke"}},
+			},
+		},
+		{
+			name:     "Custom",
+			profile:  ProfileCustom,
+			cc:       ClusterConfiguration{ControllerCluster: Cluster{Name: "hub"}},
+			expected: ClusterConfiguration{ControllerCluster: Cluster{Name: "hub"}},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: tc.cc}}
+			ApplyProfile(specs, tc.profile)
+			if got := specs.Configuration.ClusterConfiguration; !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ApplyProfile() = %+v, want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	t.Parallel()
+	if err := ValidateProfile("custom"); err != nil {
+		t.Errorf("ValidateProfile(custom) = %v", err)
+	}
+	expected := "Unknown profile: tiny-demo. Possible values [minimal-demo full-demo enterprise-demo custom]"
+	if err := ValidateProfile("tiny-demo"); err == nil || err.Error() != expected {
+		t.Errorf("ValidateProfile(tiny-demo) = %v, want %s", err, expected)
+	}
+}
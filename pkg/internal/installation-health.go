@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Checks of the health of an installation.
+const (
+	healthReachable    = "reachable"
+	healthRelease      = "release"
+	healthDeployment   = "deployment"
+	healthRegistration = "registration"
+	healthSlices       = "slices"
+	healthSlice        = "slice"
+	healthCertManager  = "cert-manager"
+	healthWebhook      = "webhook"
+)
+
+// healthCheck is a row of the health of an installation.
+type healthCheck struct {
+	Cluster string `json:"cluster"`
+	Check   string `json:"check"`
+	Name    string `json:"name,omitempty"`
+	Healthy bool   `json:"healthy"`
+	Details string `json:"details"`
+}
+
+// installationHealth is the health of the clusters of an installation.
+type installationHealth struct {
+	Installation string        `json:"installation"`
+	Healthy      bool          `json:"healthy"`
+	Checks       []healthCheck `json:"checks"`
+}
+
+func (h *installationHealth) add(check healthCheck) {
+	h.Checks = append(h.Checks, check)
+	if !check.Healthy {
+		h.Healthy = false
+	}
+}
+
+// The part of the Cluster objects of the controller the health of a
+// registered worker is read from.
+type clusterHealthObject struct {
+	Status struct {
+		RegistrationStatus string `json:"registrationStatus"`
+		ClusterHealth      struct {
+			ClusterHealthStatus string `json:"clusterHealthStatus"`
+		} `json:"clusterHealth"`
+	} `json:"status"`
+}
+
+// ShowInstallationHealth reports the health of the installation of the
+// topology on every cluster: its releases, the readiness of the deployments
+// of the KubeSlice namespaces, the health the workers report to the
+// controller, the slices with their gateways and cert-manager with its
+// webhook. A cluster which cannot be reached is reported as such and its
+// other checks are left out. It tells whether everything is healthy.
+func ShowInstallationHealth(specs *ConfigurationSpecs, outputFormat string) bool {
+	health := getInstallationHealth(specs)
+	if outputFormat == OutputFormatJson {
+		out, err := json.MarshalIndent(health, "", "  ")
+		if err != nil {
+			util.Fatalf("%s Unable to marshal the health of installation %s: %v", util.Cross, health.Installation, err)
+		}
+		fmt.Fprintln(os.Stdout, string(out))
+		return health.Healthy
+	}
+	util.Printf("\nHealth of installation %s:", health.Installation)
+	util.Printf("%s", healthTable(health.Checks))
+	return health.Healthy
+}
+
+func getInstallationHealth(specs *ConfigurationSpecs) installationHealth {
+	config := specs.Configuration
+	cc := config.ClusterConfiguration
+	health := installationHealth{Installation: InstallationName(specs), Healthy: true, Checks: make([]healthCheck, 0)}
+	reachable := make(map[string]bool)
+	for _, cluster := range append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...) {
+		// any cluster serves kube-system, an error other than not found is
+		// the cluster being unreachable
+		if _, err := kubeClient.Get(&cluster, namespaceResource, "", "kube-system"); err != nil && !errors.Is(err, errNotFound) {
+			health.add(healthCheck{Cluster: cluster.Name, Check: healthReachable, Details: err.Error()})
+			continue
+		}
+		reachable[cluster.Name] = true
+	}
+
+	releases := componentReleases(specs)
+	for _, component := range []string{CertManager_Component, Controller_Component, UI_install_Component, Worker_Component, Prometheus_Component} {
+		cr := releases[component]
+		if cr.chart.ChartName == "" {
+			continue
+		}
+		for _, cluster := range cr.clusters {
+			if !reachable[cluster.Name] {
+				continue
+			}
+			// the optional charts are only reported when installed
+			optional := component != Controller_Component && component != Worker_Component
+			if check, found := releaseHealth(cluster, cr.release, cr.namespace(cluster)); found || !optional {
+				health.add(check)
+			}
+		}
+	}
+
+	controller := cc.ControllerCluster
+	if reachable[controller.Name] {
+		addDeploymentHealth(&health, controller, ControllerNamespace(controller))
+		addCertManagerHealth(&health, specs, controller)
+		for _, worker := range cc.WorkerClusters {
+			health.add(registrationHealth(controller, projectNamespace(workerProject(config.KubeSliceConfiguration, worker)), worker.Name))
+		}
+		for _, project := range ProjectNames(config.KubeSliceConfiguration) {
+			addSliceHealth(&health, specs, controller, projectNamespace(project))
+		}
+	}
+	for _, worker := range cc.WorkerClusters {
+		if reachable[worker.Name] {
+			addDeploymentHealth(&health, worker, WorkerNamespace(worker))
+		}
+	}
+	return health
+}
+
+// releaseHealth reports the status of a release, found tells whether it is
+// installed.
+func releaseHealth(cluster Cluster, release, namespace string) (healthCheck, bool) {
+	check := healthCheck{Cluster: cluster.Name, Check: healthRelease, Name: release}
+	deployed, err := findRelease(cluster, release, namespace)
+	switch {
+	case err != nil:
+		check.Details = err.Error()
+	case deployed == nil:
+		check.Details = "not installed in namespace " + namespace
+		return check, false
+	default:
+		check.Healthy = deployed.Status == "deployed"
+		check.Details = fmt.Sprintf("revision %s of %s %s", deployed.Revision, deployed.Chart, deployed.Status)
+	}
+	return check, true
+}
+
+func addDeploymentHealth(health *installationHealth, cluster Cluster, namespace string) {
+	deployments, err := kubeClient.Deployments(&cluster, namespace)
+	if err != nil {
+		health.add(healthCheck{Cluster: cluster.Name, Check: healthDeployment, Details: fmt.Sprintf("unable to list the deployments of namespace %s: %v", namespace, err)})
+		return
+	}
+	if len(deployments) == 0 {
+		health.add(healthCheck{Cluster: cluster.Name, Check: healthDeployment, Details: "no deployments in namespace " + namespace})
+	}
+	for _, d := range deployments {
+		health.add(healthCheck{Cluster: cluster.Name, Check: healthDeployment, Name: d.Name, Healthy: d.rolledOut(), Details: fmt.Sprintf("%d/%d ready in namespace %s", d.Ready, d.Replicas, namespace)})
+	}
+}
+
+// registrationHealth reports the health a worker reports to the controller
+// in the status of its Cluster object.
+func registrationHealth(controller Cluster, namespace, worker string) healthCheck {
+	check := healthCheck{Cluster: worker, Check: healthRegistration, Name: worker}
+	object, err := kubeClient.Get(&controller, ClusterObject, namespace, worker)
+	if err != nil {
+		check.Details = fmt.Sprintf("Cluster %s is not registered in namespace %s: %v", worker, namespace, err)
+		return check
+	}
+	var cluster clusterHealthObject
+	if err := decodeObject(object, &cluster); err != nil {
+		check.Details = fmt.Sprintf("unable to parse Cluster %s: %v", worker, err)
+		return check
+	}
+	status := cluster.Status
+	registration, clusterHealth := status.RegistrationStatus, status.ClusterHealth.ClusterHealthStatus
+	if registration == "" {
+		registration = "-"
+	}
+	if clusterHealth == "" {
+		clusterHealth = "not reported yet"
+	}
+	check.Healthy = (status.RegistrationStatus == "" || status.RegistrationStatus == "Registered") && (status.ClusterHealth.ClusterHealthStatus == "" || status.ClusterHealth.ClusterHealthStatus == "Normal")
+	check.Details = fmt.Sprintf("registration %s, health %s", registration, clusterHealth)
+	return check
+}
+
+// addSliceHealth reports the slices of a project and, on every worker they
+// span, whether the slice is ready with its tunnels up.
+func addSliceHealth(health *installationHealth, specs *ConfigurationSpecs, controller Cluster, namespace string) {
+	slices, err := kubeClient.List(&controller, SliceConfigObject, namespace)
+	if err != nil {
+		health.add(healthCheck{Cluster: controller.Name, Check: healthSlices, Details: fmt.Sprintf("unable to list the slices of namespace %s: %v", namespace, err)})
+		return
+	}
+	health.add(healthCheck{Cluster: controller.Name, Check: healthSlices, Healthy: true, Details: fmt.Sprintf("%d in namespace %s", len(slices), namespace)})
+	for _, name := range slices {
+		status, err := getSliceStatus(specs, name, namespace, &controller)
+		if err != nil {
+			health.add(healthCheck{Cluster: controller.Name, Check: healthSlice, Name: name, Details: err.Error()})
+			continue
+		}
+		for _, c := range status.Clusters {
+			up, total := c.tunnelsUp()
+			details := fmt.Sprintf("%s, %d gateways, %d/%d tunnels up", c.State, len(c.Gateways), up, total)
+			if c.Error != "" {
+				details += ": " + c.Error
+			}
+			health.add(healthCheck{Cluster: c.Cluster, Check: healthSlice, Name: name, Healthy: c.State == sliceClusterReady, Details: details})
+		}
+	}
+}
+
+// addCertManagerHealth reports cert-manager on the controller cluster and the
+// readiness of its webhook, which the controller webhooks depend on. A missing
+// cert-manager fails the check only when the state of the installation
+// records the CLI installing it.
+func addCertManagerHealth(health *installationHealth, specs *ConfigurationSpecs, controller Cluster) {
+	d, err := detectCertManager(specs, controller)
+	if err != nil {
+		health.add(healthCheck{Cluster: controller.Name, Check: healthCertManager, Details: err.Error()})
+		return
+	}
+	if !d.found {
+		if certManagerInstalledByCLI(specs) {
+			health.add(healthCheck{Cluster: controller.Name, Check: healthCertManager, Details: "not found"})
+		}
+		return
+	}
+	version := d.version
+	if version == "" {
+		version = "unknown version"
+	}
+	health.add(healthCheck{Cluster: controller.Name, Check: healthCertManager, Healthy: true, Details: fmt.Sprintf("%s in namespace %s", version, d.namespace)})
+	deployments, err := kubeClient.Deployments(&controller, d.namespace)
+	if err != nil {
+		health.add(healthCheck{Cluster: controller.Name, Check: healthWebhook, Name: certManagerWebhook, Details: err.Error()})
+		return
+	}
+	for _, deployment := range deployments {
+		if deployment.Name == certManagerWebhook {
+			health.add(healthCheck{Cluster: controller.Name, Check: healthWebhook, Name: certManagerWebhook, Healthy: deployment.rolledOut(), Details: fmt.Sprintf("%d/%d ready", deployment.Ready, deployment.Replicas)})
+			return
+		}
+	}
+	health.add(healthCheck{Cluster: controller.Name, Check: healthWebhook, Name: certManagerWebhook, Details: "deployment not found in namespace " + d.namespace})
+}
+
+// healthTable formats the checks with a Tick or Cross per row.
+func healthTable(checks []healthCheck) string {
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, " \tCLUSTER\tCHECK\tNAME\tDETAILS")
+	for _, c := range checks {
+		mark, name := util.Cross, c.Name
+		if c.Healthy {
+			mark = util.Tick
+		}
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mark, c.Cluster, c.Check, name, c.Details)
+	}
+	w.Flush()
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistrationHealth(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	kubeClient = &fakeKubeClient{documents: map[string]map[string]interface{}{
+		objectsKey(ClusterObject, "kubeslice-demo") + "/ks-w-1": {"status": map[string]interface{}{
+			"registrationStatus": "Registered", "clusterHealth": map[string]interface{}{"clusterHealthStatus": "Normal"},
+		}},
+		objectsKey(ClusterObject, "kubeslice-demo") + "/ks-w-2": {"status": map[string]interface{}{
+			"registrationStatus": "Registered", "clusterHealth": map[string]interface{}{"clusterHealthStatus": "Warning"},
+		}},
+		objectsKey(ClusterObject, "kubeslice-demo") + "/ks-w-3": {"status": map[string]interface{}{}},
+	}}
+	tests := []struct {
+		worker  string
+		healthy bool
+		details string
+	}{
+		{worker: "ks-w-1", healthy: true, details: "registration Registered, health Normal"},
+		{worker: "ks-w-2", healthy: false, details: "registration Registered, health Warning"},
+		{worker: "ks-w-3", healthy: true, details: "registration -, health not reported yet"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.worker, func(t *testing.T) {
+			check := registrationHealth(Cluster{Name: "ks-ctrl"}, "kubeslice-demo", tc.worker)
+			if check.Healthy != tc.healthy || check.Details != tc.details {
+				t.Errorf("registrationHealth() = %v, %q, want %v, %q", check.Healthy, check.Details, tc.healthy, tc.details)
+			}
+		})
+	}
+	if check := registrationHealth(Cluster{Name: "ks-ctrl"}, "kubeslice-demo", "ks-w-4"); check.Healthy {
+		t.Errorf("registrationHealth() of an unregistered worker is healthy")
+	}
+}
+
+func TestDeploymentHealth(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	kubeClient = &fakeKubeClient{deployments: map[string][]deploymentReadiness{
+		"kubeslice-system": {
+			{Name: "kubeslice-operator", Replicas: 1, Ready: 1, Updated: 1, Available: 1, Observed: true},
+			{Name: "kubeslice-dns", Replicas: 2, Ready: 1, Updated: 2, Available: 1, Observed: true},
+		},
+	}}
+	health := installationHealth{Healthy: true}
+	addDeploymentHealth(&health, Cluster{Name: "ks-w-1"}, "kubeslice-system")
+	expected := []healthCheck{
+		{Cluster: "ks-w-1", Check: healthDeployment, Name: "kubeslice-operator", Healthy: true, Details: "1/1 ready in namespace kubeslice-system"},
+		{Cluster: "ks-w-1", Check: healthDeployment, Name: "kubeslice-dns", Details: "1/2 ready in namespace kubeslice-system"},
+	}
+	if !reflect.DeepEqual(health.Checks, expected) || health.Healthy {
+		t.Errorf("addDeploymentHealth() = %v, healthy %v, want %v, unhealthy", health.Checks, health.Healthy, expected)
+	}
+}
+
+func TestCertManagerHealth(t *testing.T) {
+	defer func(client KubeClient, dir string) { kubeClient, installationStateDirectory = client, dir }(kubeClient, installationStateDirectory)
+	kubeClient = &fakeKubeClient{}
+	installationStateDirectory = t.TempDir()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{ControllerCluster: Cluster{Name: "ks-ctrl"}}}}
+
+	health := installationHealth{Healthy: true}
+	addCertManagerHealth(&health, specs, specs.Configuration.ClusterConfiguration.ControllerCluster)
+	if len(health.Checks) > 0 || !health.Healthy {
+		t.Errorf("addCertManagerHealth() without a cert-manager of the CLI = %v, want no checks", health.Checks)
+	}
+
+	if err := updateInstallationState("ks-ctrl", func(state *InstallationState) {
+		state.Releases = []installedRelease{{Component: CertManager_Component, Cluster: "ks-ctrl", Release: "cert-manager", Namespace: "cert-manager"}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	addCertManagerHealth(&health, specs, specs.Configuration.ClusterConfiguration.ControllerCluster)
+	expected := []healthCheck{{Cluster: "ks-ctrl", Check: healthCertManager, Details: "not found"}}
+	if !reflect.DeepEqual(health.Checks, expected) || health.Healthy {
+		t.Errorf("addCertManagerHealth() with a cert-manager of the CLI = %v, healthy %v, want %v, unhealthy", health.Checks, health.Healthy, expected)
+	}
+}
@@ -0,0 +1,395 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// installationStateDirectory holds a state file per installation, recording
+// what it deployed so that status and uninstall work without its topology.
+var installationStateDirectory = filepath.Join(kubesliceHomeDirectory, "state")
+
+const (
+	// stateLockTimeout is how long a run waits for another run to release the
+	// lock of a state file
+	stateLockTimeout = 30 * time.Second
+	// staleStateLock is the age of a lock file after which it is considered left
+	// behind by a run which was killed
+	staleStateLock = 10 * time.Minute
+)
+
+// InstallationState is the state file of an installation.
+type InstallationState struct {
+	Name              string             `yaml:"name"`
+	UpdatedAt         time.Time          `yaml:"updated_at"`
+	ProjectName       string             `yaml:"project_name"`
+	RegisteredWorkers []string           `yaml:"registered_workers,omitempty"`
+	Releases          []installedRelease `yaml:"releases,omitempty"`
+	// KindClusters are the kind clusters the CLI created for the installation
+	KindClusters []string `yaml:"kind_clusters,omitempty"`
+	// Topology holds the clusters and charts of the installation, without
+	// credentials and chart values
+	Topology ConfigurationSpecs `yaml:"topology"`
+}
+
+// installedRelease records a release an installation deployed.
+type installedRelease struct {
+	Component string `yaml:"component"`
+	Cluster   string `yaml:"cluster"`
+	Release   string `yaml:"release"`
+	Namespace string `yaml:"namespace"`
+	Chart     string `yaml:"chart"`
+	Version   string `yaml:"version"`
+	Revision  string `yaml:"revision"`
+}
+
+// InstallationName returns the name the state of an installation is stored
+// under, --installation or the name of the controller cluster.
+func InstallationName(specs *ConfigurationSpecs) string {
+	if Options.Installation != "" {
+		return Options.Installation
+	}
+	return specs.Configuration.ClusterConfiguration.ControllerCluster.Name
+}
+
+func installationStatePath(name string) string {
+	return filepath.Join(installationStateDirectory, name+".yaml")
+}
+
+// stateTopology returns a copy of the topology which can be stored in a state
+// file. Credentials, chart values and proxy settings are left out.
+func stateTopology(specs *ConfigurationSpecs) ConfigurationSpecs {
+	config := specs.Configuration
+	cc := config.ClusterConfiguration
+	workers := make([]Cluster, len(cc.WorkerClusters))
+	for i, cluster := range cc.WorkerClusters {
+		cluster.WorkerValues = nil
+		workers[i] = cluster
+	}
+	cc.WorkerClusters = workers
+	hc := config.HelmChartConfiguration
+	hc.HelmUsername, hc.HelmPassword, hc.ImagePullSecrets = "", "", nil
+	_, charts := topologyCharts(&hc)
+	for _, chart := range charts {
+		chart.Values, chart.ValuesYAML, chart.HelmUsername, chart.HelmPassword = nil, "", "", ""
+	}
+	return ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration:   cc,
+		KubeSliceConfiguration: config.KubeSliceConfiguration,
+		HelmChartConfiguration: hc,
+	}}
+}
+
+// lockInstallationState takes the lock of a state file, waiting for another
+// run holding it.
+func lockInstallationState(name string) (func(), error) {
+	lockFile := installationStatePath(name) + ".lock"
+	util.CreateDirectoryPath(installationStateDirectory)
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to lock installation state %s: %v", installationStatePath(name), err)
+		}
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) > staleStateLock {
+			util.Printf("%s Removing stale lock %s", util.Warn, lockFile)
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("installation state %s is locked by another run, remove %s if no other run is active", installationStatePath(name), lockFile)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// loadInstallationState reads the state file of an installation. A missing
+// state file is reported with an error wrapping os.ErrNotExist.
+func loadInstallationState(name string) (*InstallationState, error) {
+	path := installationStatePath(name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &InstallationState{}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("installation state %s is corrupted: %v. Fix or remove the file, or pass --config with the topology of the installation", path, err)
+	}
+	if state.Name == "" {
+		state.Name = name
+	}
+	return state, nil
+}
+
+// updateInstallationState applies update to the state of an installation
+// under its lock. A state without releases and registered workers is removed.
+func updateInstallationState(name string, update func(state *InstallationState)) error {
+	unlock, err := lockInstallationState(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := loadInstallationState(name)
+	if errors.Is(err, os.ErrNotExist) {
+		state, err = &InstallationState{Name: name}, nil
+	}
+	if err != nil {
+		return err
+	}
+	update(state)
+	path := installationStatePath(name)
+	if len(state.Releases) == 0 && len(state.RegisteredWorkers) == 0 && len(state.KindClusters) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	state.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, data, 0600)
+}
+
+// RecordInstallation records the releases this run deployed and the workers
+// it registered in the state of the installation. Failures only warn since
+// the install itself succeeded.
+func RecordInstallation(specs *ConfigurationSpecs, registeredWorkers bool) {
+	components := make(map[string]string)
+	for component, cr := range componentReleases(specs) {
+		components[cr.release] = component
+	}
+	name := InstallationName(specs)
+	err := updateInstallationState(name, func(state *InstallationState) {
+		state.Topology = stateTopology(specs)
+		state.ProjectName = specs.Configuration.KubeSliceConfiguration.ProjectName
+		if registeredWorkers {
+			for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+				state.RegisteredWorkers = appendUnique(state.RegisteredWorkers, cluster.Name)
+			}
+		}
+		for _, r := range runSummary.Releases {
+			chartName := strings.TrimSuffix(r.Chart, "-"+releaseChartVersion(r.Chart))
+			recorded := installedRelease{Component: components[r.Release], Cluster: r.Cluster, Release: r.Release, Namespace: r.Namespace, Chart: chartName, Version: releaseChartVersion(r.Chart), Revision: r.Revision}
+			state.Releases = setInstalledRelease(state.Releases, recorded)
+		}
+	})
+	if err != nil {
+		util.Printf("%s Unable to record the state of installation %s: %v", util.Warn, name, err)
+		return
+	}
+	util.Printf("%s Recorded the state of installation %s in %s", util.Tick, name, installationStatePath(name))
+}
+
+// RecordUninstall removes the releases of the uninstalled components from the
+// state of the installation, and the workers when they were uninstalled too.
+// A workers map containing "*" selects every worker.
+func RecordUninstall(specs *ConfigurationSpecs, components, workers map[string]string) {
+	name := InstallationName(specs)
+	_, allWorkers := workers["*"]
+	err := updateInstallationState(name, func(state *InstallationState) {
+		releases := make([]installedRelease, 0, len(state.Releases))
+		for _, r := range state.Releases {
+			_, uninstalled := components[r.Component]
+			if r.Component == Worker_Component || r.Component == Prometheus_Component {
+				_, worker := workers[r.Cluster]
+				uninstalled = uninstalled && (allWorkers || worker)
+			}
+			if !uninstalled {
+				releases = append(releases, r)
+			}
+		}
+		state.Releases = releases
+		if _, uninstalled := components[Controller_Component]; uninstalled {
+			state.RegisteredWorkers = nil
+		}
+	})
+	if err != nil {
+		util.Printf("%s Unable to update the state of installation %s: %v", util.Warn, name, err)
+	}
+}
+
+// RemoveInstallation removes the state of an installation whose clusters were
+// deleted.
+func RemoveInstallation(specs *ConfigurationSpecs) {
+	name := InstallationName(specs)
+	err := updateInstallationState(name, func(state *InstallationState) {
+		state.Releases, state.RegisteredWorkers, state.KindClusters = nil, nil, nil
+	})
+	if err != nil {
+		util.Printf("%s Unable to remove the state of installation %s: %v", util.Warn, name, err)
+	}
+}
+
+// HasInstallations reports whether the state of any installation is stored.
+func HasInstallations() bool {
+	files, _ := filepath.Glob(filepath.Join(installationStateDirectory, "*.yaml"))
+	return len(files) > 0
+}
+
+// LoadInstallation loads the state of an installation to run a command
+// without its topology. Without a name the only known installation is used.
+func LoadInstallation(name string) *InstallationState {
+	if name == "" {
+		states, _ := listInstallations()
+		switch len(states) {
+		case 0:
+			util.Fatalf("%s No installation state found in %s, pass --config with the topology of the installation", util.Cross, installationStateDirectory)
+		case 1:
+			name = states[0].Name
+		default:
+			util.Printf("Found several installations in %s:", installationStateDirectory)
+			writeInstallationsTable(util.Output, states)
+			if !util.IsTerminal(os.Stdin) {
+				util.Fatalf("%s Select one with --installation or pass --config", util.Cross)
+			}
+			var err error
+			if name, err = chooseInstallation(os.Stdin, states); err != nil {
+				util.Fatalf("%s %v, select one with --installation or pass --config", util.Cross, err)
+			}
+		}
+	}
+	state, err := loadInstallationState(name)
+	if errors.Is(err, os.ErrNotExist) {
+		util.Fatalf("%s No state found for installation %s in %s, pass --config with the topology of the installation", util.Cross, name, installationStateDirectory)
+	}
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	return state
+}
+
+// chooseInstallation asks for the name of one of several installations.
+func chooseInstallation(in io.Reader, states []*InstallationState) (string, error) {
+	fmt.Fprintf(util.Output, "Installation to use: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	for _, state := range states {
+		if state.Name == answer {
+			return answer, nil
+		}
+	}
+	if answer == "" {
+		return "", fmt.Errorf("no installation selected")
+	}
+	return "", fmt.Errorf("unknown installation %s", answer)
+}
+
+// listInstallations reads every state file, reporting the files which cannot
+// be read in errs.
+func listInstallations() (states []*InstallationState, errs []error) {
+	files, _ := filepath.Glob(filepath.Join(installationStateDirectory, "*.yaml"))
+	sort.Strings(files)
+	for _, file := range files {
+		state, err := loadInstallationState(strings.TrimSuffix(filepath.Base(file), ".yaml"))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, errs
+}
+
+// ShowInstallations prints the known installations.
+func ShowInstallations() {
+	states, errs := listInstallations()
+	for _, err := range errs {
+		util.Printf("%s %v", util.Warn, err)
+	}
+	if len(states) == 0 {
+		util.Printf("No installations found in %s", installationStateDirectory)
+		return
+	}
+	writeInstallationsTable(util.Output, states)
+}
+
+// writeInstallationsTable writes a line per installation.
+func writeInstallationsTable(out io.Writer, states []*InstallationState) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCONTROLLER\tWORKERS\tPROJECT\tRELEASES\tUPDATED\t")
+	for _, state := range states {
+		cc := state.Topology.Configuration.ClusterConfiguration
+		workers := make([]string, 0, len(cc.WorkerClusters))
+		for _, cluster := range cc.WorkerClusters {
+			workers = append(workers, cluster.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t\n", state.Name, cc.ControllerCluster.ContextName, strings.Join(workers, ","), state.ProjectName, len(state.Releases), state.UpdatedAt.Local().Format(time.RFC3339))
+	}
+	w.Flush()
+}
+
+// ShowInstallationStatus compares the releases recorded in the state of an
+// installation with the releases deployed on its clusters.
+func ShowInstallationStatus(state *InstallationState) {
+	util.Printf("\nChecking releases of installation %s...", state.Name)
+	clusters := make(map[string]Cluster)
+	for _, cluster := range getAllClusters(&state.Topology.Configuration.ClusterConfiguration) {
+		clusters[cluster.Name] = *cluster
+	}
+	for _, r := range state.Releases {
+		deployed, err := chartInstaller.List(clusters[r.Cluster], r.Namespace, r.Release)
+		switch {
+		case err != nil:
+			util.Printf("%s %s on %s: %v", util.Warn, r.Release, r.Cluster, err)
+		case len(deployed) == 0:
+			util.Printf("%s %s on %s: not installed, revision %s of %s %s was recorded", util.Cross, r.Release, r.Cluster, r.Revision, r.Chart, r.Version)
+		case deployed[0].Revision != r.Revision || deployed[0].chartVersion(r.Chart) != r.Version:
+			util.Printf("%s %s on %s: revision %s of %s %s is deployed, revision %s of %s was recorded", util.Warn, r.Release, r.Cluster, deployed[0].Revision, r.Chart, deployed[0].chartVersion(r.Chart), r.Revision, r.Version)
+		case deployed[0].Status != "deployed":
+			util.Printf("%s %s on %s: revision %s is %s", util.Cross, r.Release, r.Cluster, r.Revision, deployed[0].Status)
+		default:
+			util.Printf("%s %s on %s: revision %s of %s %s deployed", util.Tick, r.Release, r.Cluster, r.Revision, r.Chart, r.Version)
+		}
+	}
+}
+
+// setInstalledRelease replaces the recorded release of the same cluster and
+// name or appends it.
+func setInstalledRelease(releases []installedRelease, r installedRelease) []installedRelease {
+	for i := range releases {
+		if releases[i].Cluster == r.Cluster && releases[i].Release == r.Release {
+			releases[i] = r
+			return releases
+		}
+	}
+	return append(releases, r)
+}
+
+// releaseChartVersion returns the version suffix of a chart as helm lists it,
+// e.g. 0.5.0 of kubeslice-controller-0.5.0.
+func releaseChartVersion(chart string) string {
+	for i := 0; i < len(chart)-1; i++ {
+		if chart[i] == '-' && chart[i+1] >= '0' && chart[i+1] <= '9' {
+			return chart[i+1:]
+		}
+	}
+	return ""
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
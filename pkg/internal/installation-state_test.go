@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestRecordInstallationState(t *testing.T) {
+	defer func(dir string, summary RunSummary) {
+		installationStateDirectory, runSummary = dir, summary
+	}(installationStateDirectory, runSummary)
+	installationStateDirectory = t.TempDir()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl"},
+			WorkerClusters: []Cluster{
+				{Name: "ks-w-1", ContextName: "kind-ks-w-1", WorkerValues: map[string]interface{}{"token": "s3cr3t"}},
+				{Name: "ks-w-2", ContextName: "kind-ks-w-2"},
+			},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		HelmChartConfiguration: HelmChartConfiguration{
+			HelmPassword:     "s3cr3t",
+			ImagePullSecrets: ImagePullSecretList{{Registry: "docker.io", Password: "s3cr3t"}},
+			ControllerChart:  HelmChart{ChartName: "kubeslice-controller", Values: map[string]interface{}{"license.key": "s3cr3t"}},
+			WorkerChart:      HelmChart{ChartName: "kubeslice-worker"},
+		},
+	}}
+	runSummary = RunSummary{Releases: []releaseSummary{
+		{Cluster: "ks-ctrl", Release: "kubeslice-controller", Namespace: "kubeslice-controller", Revision: "1", Status: "deployed", Chart: "kubeslice-controller-0.5.0"},
+		{Cluster: "ks-w-1", Release: "kubeslice-worker", Namespace: "kubeslice-system", Revision: "1", Status: "deployed", Chart: "kubeslice-worker-0.5.0"},
+		{Cluster: "ks-w-2", Release: "kubeslice-worker", Namespace: "kubeslice-system", Revision: "1", Status: "deployed", Chart: "kubeslice-worker-0.5.0"},
+	}}
+	RecordInstallation(specs, true)
+
+	// a second run upgrading the controller replaces its release
+	runSummary = RunSummary{Releases: []releaseSummary{
+		{Cluster: "ks-ctrl", Release: "kubeslice-controller", Namespace: "kubeslice-controller", Revision: "2", Status: "deployed", Chart: "kubeslice-controller-0.6.0"},
+	}}
+	RecordInstallation(specs, false)
+
+	state, err := loadInstallationState("ks-ctrl")
+	if err != nil {
+		t.Fatalf("loadInstallationState() returned error: %v", err)
+	}
+	expected := []installedRelease{
+		{Component: Controller_Component, Cluster: "ks-ctrl", Release: "kubeslice-controller", Namespace: "kubeslice-controller", Chart: "kubeslice-controller", Version: "0.6.0", Revision: "2"},
+		{Component: Worker_Component, Cluster: "ks-w-1", Release: "kubeslice-worker", Namespace: "kubeslice-system", Chart: "kubeslice-worker", Version: "0.5.0", Revision: "1"},
+		{Component: Worker_Component, Cluster: "ks-w-2", Release: "kubeslice-worker", Namespace: "kubeslice-system", Chart: "kubeslice-worker", Version: "0.5.0", Revision: "1"},
+	}
+	if !reflect.DeepEqual(state.Releases, expected) {
+		t.Errorf("releases = %+v, want %+v", state.Releases, expected)
+	}
+	if !reflect.DeepEqual(state.RegisteredWorkers, []string{"ks-w-1", "ks-w-2"}) {
+		t.Errorf("registered workers = %q, want both workers", state.RegisteredWorkers)
+	}
+	data, _ := ioutil.ReadFile(installationStatePath("ks-ctrl"))
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("state file contains credentials or values:\n%s", data)
+	}
+	if specs.Configuration.HelmChartConfiguration.ControllerChart.Values == nil {
+		t.Errorf("recording the state modified the topology")
+	}
+
+	RecordUninstall(specs, map[string]string{Worker_Component: ""}, map[string]string{"ks-w-1": ""})
+	if state, _ = loadInstallationState("ks-ctrl"); len(state.Releases) != 2 || state.Releases[1].Cluster != "ks-w-2" {
+		t.Errorf("releases after uninstalling worker ks-w-1 = %+v", state.Releases)
+	}
+	RecordUninstall(specs, map[string]string{Controller_Component: "", Worker_Component: ""}, map[string]string{"*": ""})
+	if _, err := os.Stat(installationStatePath("ks-ctrl")); !os.IsNotExist(err) {
+		t.Errorf("state file of an uninstalled installation was not removed: %v", err)
+	}
+}
+
+func TestLoadInstallationStateCorrupted(t *testing.T) {
+	defer func(dir string) { installationStateDirectory = dir }(installationStateDirectory)
+	installationStateDirectory = t.TempDir()
+
+	path := installationStatePath("ks-ctrl")
+	if err := ioutil.WriteFile(path, []byte("releases: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loadInstallationState("ks-ctrl")
+	if err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "corrupted") {
+		t.Errorf("loadInstallationState() error = %v, want a corrupted state error naming %s", err, path)
+	}
+	if err := updateInstallationState("ks-ctrl", func(*InstallationState) {}); err == nil {
+		t.Errorf("updateInstallationState() overwrote a corrupted state file")
+	}
+}
+
+func TestLockInstallationState(t *testing.T) {
+	defer func(dir string) { installationStateDirectory = dir }(installationStateDirectory)
+	installationStateDirectory = t.TempDir()
+
+	unlock, err := lockInstallationState("ks-ctrl")
+	if err != nil {
+		t.Fatalf("lockInstallationState() returned error: %v", err)
+	}
+	unlock()
+
+	// a lock left behind by a killed run is taken over
+	lockFile := installationStatePath("ks-ctrl") + ".lock"
+	if err := ioutil.WriteFile(lockFile, []byte("1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleStateLock)
+	os.Chtimes(lockFile, old, old)
+	unlock, err = lockInstallationState("ks-ctrl")
+	if err != nil {
+		t.Fatalf("lockInstallationState() with a stale lock returned error: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(filepath.Join(installationStateDirectory, "ks-ctrl.yaml.lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file was not removed on unlock")
+	}
+}
+
+func TestChooseInstallation(t *testing.T) {
+	defer func(out io.Writer) { util.Output = out }(util.Output)
+	util.Output = ioutil.Discard
+
+	states := []*InstallationState{{Name: "ks-ctrl"}, {Name: "staging"}}
+	tests := []struct {
+		name     string
+		answer   string
+		expected string
+		err      string
+	}{
+		{name: "Known installation", answer: " staging\n", expected: "staging"},
+		{name: "Unknown installation", answer: "prod\n", err: "unknown installation prod"},
+		{name: "No answer", answer: "", err: "no installation selected"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := chooseInstallation(strings.NewReader(tc.answer), states)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("chooseInstallation() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil || name != tc.expected {
+				t.Errorf("chooseInstallation() = %q, %v, want %q", name, err, tc.expected)
+			}
+		})
+	}
+}
@@ -4,29 +4,59 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-const KubeconfigPath = kubesliceDirectory + "/kubeconfig.yaml"
+// KubeconfigPath is the kubeconfig shared by the kind clusters of the demo
+// profiles. It lives outside the per-run workspace so later runs find it.
+var KubeconfigPath = filepath.Join(kubesliceHomeDirectory, "kubeconfig.yaml")
 
+// CreateKindClusters creates the kind clusters the selected components are
+// installed onto which do not exist yet.
 func CreateKindClusters(ApplicationConfiguration *ConfigurationSpecs) {
-
-	clusters := getAllClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)
+	clusters := selectedClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)
 	existingClusters := getExistingClusters(clusters)
 	created := false
 	util.Printf("\nCreating Kind Clusters...")
+	checkKindVersion()
+	// the images are pulled exactly as the kind configurations specify them
+	toPull := make([]string, 0, len(clusters))
 	for i, cluster := range clusters {
-		if !existingClusters[i] {
-			created = true
-			createKindCluster(cluster.Name + ".yaml")
-			util.Printf("%s Created Kind Cluster : %s", util.Tick, cluster.Name)
-			time.Sleep(200 * time.Millisecond)
+		if existingClusters[i] && !Options.RecreateClusters {
+			continue
+		}
+		images, err := kindConfigNodeImages(cluster.Name)
+		if err != nil {
+			util.Fatalf("%s Unable to read the node images of cluster %s: %v", util.Cross, cluster.Name, err)
+		}
+		toPull = append(toPull, images...)
+	}
+	if Options.SkipImagePrepull {
+		util.Printf("%s Skipping the pre-pull of the node images, kind pulls them for every cluster", util.Warn)
+	} else {
+		pullNodeImages(toPull)
+	}
+	for i, cluster := range clusters {
+		if existingClusters[i] && Options.RecreateClusters {
+			util.Printf("%s Deleting existing cluster %s to recreate it", util.Wait, cluster.Name)
+			if err := util.RunCommand("kind", "delete", "cluster", "--name", cluster.Name); err != nil {
+				util.Fatalf("Process failed %v", err)
+			}
+			existingClusters[i] = false
 		}
+		if existingClusters[i] {
+			continue
+		}
+		created = true
+		createKindCluster(cluster.Name + ".yaml")
+		recordKindCluster(ApplicationConfiguration, cluster.Name)
+		util.Printf("%s Created Kind Cluster : %s", util.Tick, cluster.Name)
+		time.Sleep(200 * time.Millisecond)
 	}
 	if !created {
 		util.Printf("\nKind clusters already exist... Skipping\n")
@@ -35,12 +65,117 @@ func CreateKindClusters(ApplicationConfiguration *ConfigurationSpecs) {
 	}
 }
 
+// kindRestartTimeout is how long a restarted kind cluster is waited for to
+// become reachable.
+var kindRestartTimeout = 2 * time.Minute
+
+// CheckExistingKindClusters makes sure the kind clusters of a previous run
+// can be reused before the clusters are checked: each must be reachable,
+// restarting its stopped nodes otherwise, and run the Kubernetes version of
+// its node image. Clusters recreated with --recreate-clusters are left alone.
+func CheckExistingKindClusters(ApplicationConfiguration *ConfigurationSpecs) {
+	if Options.RecreateClusters {
+		return
+	}
+	cc := &ApplicationConfiguration.Configuration.ClusterConfiguration
+	clusters := selectedClusters(cc)
+	existingClusters := getExistingClusters(clusters)
+	for i, cluster := range clusters {
+		if !existingClusters[i] {
+			continue
+		}
+		image, err := kindNodeImageOf(cc, cluster)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		if err := checkExistingKindCluster(cluster, image); err != nil {
+			util.Fatalf("%s %v. Use --recreate-clusters to delete and recreate it", util.Cross, err)
+		}
+	}
+}
+
+// checkExistingKindCluster verifies that an existing kind cluster can be
+// reused, restarting its nodes once when it is not reachable.
+func checkExistingKindCluster(cluster *Cluster, image string) error {
+	if err := exportKindKubeconfig(cluster); err != nil {
+		return err
+	}
+	if err := kindClusterReady(cluster); err != nil {
+		util.Printf("%s Cluster %s exists but is not reachable, restarting its nodes...", util.Warn, cluster.Name)
+		if err := restartKindNodes(cluster); err != nil {
+			return err
+		}
+		// the API server may be published on another host port after a restart
+		if err := exportKindKubeconfig(cluster); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(kindRestartTimeout)
+		for err = kindClusterReady(cluster); err != nil; err = kindClusterReady(cluster) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("existing cluster %s is not reachable after restarting its nodes: %v", cluster.Name, err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+		util.Printf("%s Restarted cluster %s", util.Tick, cluster.Name)
+	}
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "version", "-o", "json"); err != nil {
+		return fmt.Errorf("unable to query the Kubernetes version of existing cluster %s: %s", cluster.Name, commandError(err, &errB))
+	}
+	version, err := serverVersion(outB.Bytes())
+	if err != nil {
+		return fmt.Errorf("unable to query the Kubernetes version of existing cluster %s: %v", cluster.Name, err)
+	}
+	if requested := nodeImageVersion(image); requested != "" && version != requested {
+		return fmt.Errorf("existing cluster %s runs Kubernetes %s, its node image %s is of %s", cluster.Name, version, image, requested)
+	}
+	util.Printf("%s Reusing existing cluster %s (Kubernetes %s)", util.Tick, cluster.Name, version)
+	return nil
+}
+
+// exportKindKubeconfig writes the context of a kind cluster to the kubeconfig
+// of the demo profiles, which the cluster may be missing from.
+func exportKindKubeconfig(cluster *Cluster) error {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kind", &outB, &errB, true, "export", "kubeconfig", "--name", cluster.Name, "--kubeconfig", cluster.KubeConfigPath); err != nil {
+		return fmt.Errorf("unable to export the kubeconfig of existing cluster %s: %s", cluster.Name, commandError(err, &errB))
+	}
+	return nil
+}
+
+func kindClusterReady(cluster *Cluster) error {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "--request-timeout", "10s", "get", "--raw", "/readyz"); err != nil {
+		return errors.New(commandError(err, &errB))
+	}
+	return nil
+}
+
+// restartKindNodes starts the node containers of a kind cluster with the
+// container runtime of kind.
+func restartKindNodes(cluster *Cluster) error {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kind", &outB, &errB, true, "get", "nodes", "--name", cluster.Name); err != nil {
+		return fmt.Errorf("unable to list the nodes of existing cluster %s: %s", cluster.Name, commandError(err, &errB))
+	}
+	nodes := strings.Fields(outB.String())
+	if len(nodes) == 0 {
+		return fmt.Errorf("existing cluster %s has no nodes to restart", cluster.Name)
+	}
+	errB.Reset()
+	if err := util.RunCommandCustomIO(kindRuntime(), &outB, &errB, true, append([]string{"start"}, nodes...)...); err != nil {
+		return fmt.Errorf("unable to restart the nodes of existing cluster %s: %s", cluster.Name, commandError(err, &errB))
+	}
+	return nil
+}
+
 func SetKubeConfigPath() {
 	os.Setenv("KUBECONFIG", KubeconfigPath)
 }
 
 func CreateKubeConfig() {
 	if _, err := os.Stat(KubeconfigPath); errors.Is(err, os.ErrNotExist) {
+		util.CreateDirectoryPath(filepath.Dir(KubeconfigPath))
 		util.DumpFile("", KubeconfigPath)
 		util.Printf("%s Created Empty KubeConfig file : %s", util.Tick, KubeconfigPath)
 		time.Sleep(200 * time.Millisecond)
@@ -52,11 +187,11 @@ func getExistingClusters(clusters []*Cluster) []bool {
 	var outB, errB bytes.Buffer
 	err := util.RunCommandCustomIO("kind", &outB, &errB, true, "get", "clusters")
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 	for i, cluster := range clusters {
 		for _, line := range strings.Split(outB.String(), "\n") {
-			if strings.Contains(line, cluster.Name) {
+			if strings.TrimSpace(line) == cluster.Name {
 				result[i] = true
 			}
 		}
@@ -68,29 +203,106 @@ func getExistingClusters(clusters []*Cluster) []bool {
 func createKindCluster(configFile string) {
 	err := util.RunCommandOnStdIO("kind", "create", "cluster", fmt.Sprintf("--config=%s/%s/%s", kubesliceDirectory, kindSubDirectory, configFile))
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
+// DeleteKindClusters deletes the kind clusters of a demo profile which the
+// CLI created, as recorded in the state of the installation. Kind clusters of
+// the same names it did not record are only deleted with
+// --delete-unmanaged-kind-clusters or once confirmed interactively, --yes does
+// not answer for them. The docker network of kind is removed along with the
+// last kind cluster.
 func DeleteKindClusters(ApplicationConfiguration *ConfigurationSpecs) {
 	clusters := getAllClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)
 	existingClusters := getExistingClusters(clusters)
-	args := make([]string, 0, 0)
-	args = append(args, "delete", "clusters")
+	created := make([]string, 0)
+	if state, err := loadInstallationState(InstallationName(ApplicationConfiguration)); err == nil {
+		created = state.KindClusters
+	}
 	cNames := make([]string, 0)
+	retained := make([]string, 0)
 	for i, cluster := range clusters {
-		if existingClusters[i] {
+		switch {
+		case !existingClusters[i]:
+		case containsString(created, cluster.Name) || Options.DeleteUnmanaged:
+			cNames = append(cNames, cluster.Name)
+		case !util.IsTerminal(os.Stdin):
+			util.Printf("%s Kind cluster %s was not created by kubeslice-cli for installation %s, retaining it. Delete it with --delete-unmanaged-kind-clusters", util.Warn, cluster.Name, InstallationName(ApplicationConfiguration))
+			retained = append(retained, cluster.Name)
+		case util.Confirm("%s Kind cluster %s was not created by kubeslice-cli for installation %s, delete it?", util.Warn, cluster.Name, InstallationName(ApplicationConfiguration)):
 			cNames = append(cNames, cluster.Name)
+		default:
+			retained = append(retained, cluster.Name)
 		}
 	}
 	if len(cNames) == 0 {
 		util.Printf("No Kind Clusters found for deletion")
+	} else {
+		err := util.RunCommand("kind", append([]string{"delete", "clusters"}, cNames...)...)
+		if err != nil {
+			util.Fatalf("Process failed %v", err)
+		}
+		removeKindNetwork()
+	}
+	printKindClusters(cNames, retained)
+}
+
+// RetainKindClusters reports the kind clusters of a demo profile which an
+// uninstall of the components only leaves running.
+func RetainKindClusters(ApplicationConfiguration *ConfigurationSpecs) {
+	clusters := getAllClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration)
+	existingClusters := getExistingClusters(clusters)
+	retained := make([]string, 0)
+	for i, cluster := range clusters {
+		if existingClusters[i] {
+			retained = append(retained, cluster.Name)
+		}
+	}
+	printKindClusters(nil, retained)
+	if len(retained) > 0 {
+		util.Printf("Delete them with uninstall --all")
+	}
+}
+
+func printKindClusters(deleted, retained []string) {
+	if len(deleted) > 0 {
+		util.Printf("\n%s Deleted kind clusters: %s", util.Tick, strings.Join(deleted, ", "))
+	}
+	if len(retained) > 0 {
+		util.Printf("\n%s Retained kind clusters: %s", util.Tick, strings.Join(retained, ", "))
+	}
+}
+
+// removeKindNetwork removes the docker network the kind clusters share once
+// none is left. The volumes of the nodes are removed by kind with them.
+func removeKindNetwork() {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("kind", &outB, &errB, true, "get", "clusters"); err != nil || strings.TrimSpace(outB.String()) != "" {
+		return
+	}
+	outB.Reset()
+	if err := util.RunCommandCustomIO(kindRuntime(), &outB, &errB, true, "network", "ls", "-q", "--filter", "name=^kind$"); err != nil || strings.TrimSpace(outB.String()) == "" {
+		return
+	}
+	errB.Reset()
+	if err := util.RunCommandCustomIO(kindRuntime(), &outB, &errB, true, "network", "rm", "kind"); err != nil {
+		util.Printf("%s Unable to remove the kind network: %s", util.Warn, commandError(err, &errB))
 		return
 	}
-	args = append(args, cNames...)
-	err := util.RunCommand("kind", args...)
+	util.Printf("%s Removed the kind network", util.Tick)
+}
+
+// recordKindCluster records a kind cluster the CLI created in the state of
+// the installation as soon as it is created, so that uninstall --all deletes
+// it even when the install fails later on.
+func recordKindCluster(specs *ConfigurationSpecs, cluster string) {
+	name := InstallationName(specs)
+	err := updateInstallationState(name, func(state *InstallationState) {
+		state.KindClusters = appendUnique(state.KindClusters, cluster)
+	})
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Printf("%s Unable to record the kind clusters of installation %s: %v", util.Warn, name, err)
 	}
 }
 
@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// kindHandler emulates kind, kubectl and docker for a ks-w-1 cluster running
+// Kubernetes v1.25.11, which becomes reachable once its nodes are started
+// when stopped is set.
+func kindHandler(stopped bool) func(command []string, stdout, stderr io.Writer) error {
+	return func(command []string, stdout, stderr io.Writer) error {
+		line := strings.Join(command, " ")
+		switch {
+		case line == "kind get clusters":
+			fmt.Fprintln(stdout, "ks-w-1")
+		case line == "kind version":
+			fmt.Fprintln(stdout, "kind v0.20.0 go1.20.4 linux/amd64")
+		case line == "kind get nodes --name ks-w-1":
+			fmt.Fprintln(stdout, "ks-w-1-control-plane")
+		case strings.HasPrefix(line, "docker start"):
+			stopped = false
+		case strings.HasSuffix(line, "get --raw /readyz"):
+			if stopped {
+				fmt.Fprint(stderr, "The connection to the server 127.0.0.1:40123 was refused")
+				return errors.New("exit status 1")
+			}
+		case strings.HasSuffix(line, "version -o json"):
+			fmt.Fprint(stdout, `{"serverVersion": {"gitVersion": "v1.25.11"}}`)
+		}
+		return nil
+	}
+}
+
+func TestCheckExistingKindCluster(t *testing.T) {
+	tests := []struct {
+		name          string
+		stopped       bool
+		image         string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:  "Reused",
+			image: kindNodeImage,
+			expected: []string{
+				"kind export kubeconfig --name ks-w-1 --kubeconfig kubeconfig.yaml",
+				"kubectl --context=kind-ks-w-1 --kubeconfig=kubeconfig.yaml --request-timeout 10s get --raw /readyz",
+				"kubectl --context=kind-ks-w-1 --kubeconfig=kubeconfig.yaml version -o json",
+			},
+		},
+		{
+			name:    "Unreachable cluster restarted",
+			stopped: true,
+			image:   kindNodeImage,
+			expected: []string{
+				"kind export kubeconfig --name ks-w-1 --kubeconfig kubeconfig.yaml",
+				"kubectl --context=kind-ks-w-1 --kubeconfig=kubeconfig.yaml --request-timeout 10s get --raw /readyz",
+				"kind get nodes --name ks-w-1",
+				"docker start ks-w-1-control-plane",
+				"kind export kubeconfig --name ks-w-1 --kubeconfig kubeconfig.yaml",
+				"kubectl --context=kind-ks-w-1 --kubeconfig=kubeconfig.yaml --request-timeout 10s get --raw /readyz",
+				"kubectl --context=kind-ks-w-1 --kubeconfig=kubeconfig.yaml version -o json",
+			},
+		},
+		{
+			name:          "Other Kubernetes version",
+			image:         "kindest/node:v1.27.3",
+			expectedError: "existing cluster ks-w-1 runs Kubernetes v1.25.11, its node image kindest/node:v1.27.3 is of v1.27.3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: kindHandler(tc.stopped)}
+			defer util.UseExecutor(mock)()
+
+			err := checkExistingKindCluster(&Cluster{Name: "ks-w-1", ContextName: "kind-ks-w-1", KubeConfigPath: "kubeconfig.yaml"}, tc.image)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("checkExistingKindCluster() error = %v, want %s", err, tc.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkExistingKindCluster() error = %v", err)
+			}
+			got := make([]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				got = append(got, strings.Join(command, " "))
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("commands =\n%q\nwant\n%q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCreateKindClustersRecreate(t *testing.T) {
+	defer func(recreate bool, dir, workspace string) {
+		Options.RecreateClusters, installationStateDirectory, kubesliceDirectory = recreate, dir, workspace
+	}(Options.RecreateClusters, installationStateDirectory, kubesliceDirectory)
+	Options.RecreateClusters, installationStateDirectory, kubesliceDirectory = true, t.TempDir(), t.TempDir()
+	mock := &util.MockExecutor{Handler: kindHandler(false)}
+	defer util.UseExecutor(mock)()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+		Profile:           ProfileMinimalDemo,
+		ControllerCluster: Cluster{Name: "ks-ctrl"},
+		WorkerClusters:    []Cluster{{Name: "ks-w-1"}},
+	}}}
+	GenerateKindConfiguration(specs)
+	CreateKindClusters(specs)
+	got := make([]string, 0, len(mock.Commands))
+	for _, command := range mock.Commands {
+		if command[0] == "kind" && (command[1] == "create" || command[1] == "delete") {
+			got = append(got, strings.Replace(strings.Join(command, " "), kubesliceDirectory+"/", "", 1))
+		}
+	}
+	expected := []string{
+		"kind create cluster --config=kind/ks-ctrl.yaml",
+		"kind delete cluster --name ks-w-1",
+		"kind create cluster --config=kind/ks-w-1.yaml",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("kind commands =\n%q\nwant\n%q", got, expected)
+	}
+	state, err := loadInstallationState("ks-ctrl")
+	if err != nil || !reflect.DeepEqual(state.KindClusters, []string{"ks-ctrl", "ks-w-1"}) {
+		t.Errorf("recorded kind clusters = %+v, %v", state, err)
+	}
+}
+
+func TestDeleteKindClusters(t *testing.T) {
+	defer func(dir string) { installationStateDirectory = dir }(installationStateDirectory)
+	installationStateDirectory = t.TempDir()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+		Profile:           ProfileMinimalDemo,
+		ControllerCluster: Cluster{Name: "ks-ctrl"},
+		WorkerClusters:    []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}},
+	}}}
+	recordKindCluster(specs, "ks-ctrl")
+	recordKindCluster(specs, "ks-w-1")
+	deleted := false
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		switch line := strings.Join(command, " "); {
+		case line == "kind get clusters" && !deleted:
+			fmt.Fprintln(stdout, "ks-ctrl\nks-w-1")
+		case strings.HasPrefix(line, "kind delete clusters"):
+			deleted = true
+		case strings.HasPrefix(line, "docker network ls"):
+			fmt.Fprintln(stdout, "0123456789ab")
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	DeleteKindClusters(specs)
+	got := make([]string, 0, len(mock.Commands))
+	for _, command := range mock.Commands {
+		got = append(got, strings.Join(command, " "))
+	}
+	expected := []string{
+		"kind get clusters",
+		"kind delete clusters ks-ctrl ks-w-1",
+		"kind get clusters",
+		"docker network ls -q --filter name=^kind$",
+		"docker network rm kind",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("commands =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestDeleteUnmanagedKindClusters(t *testing.T) {
+	defer func(dir string) { installationStateDirectory = dir }(installationStateDirectory)
+	defer func(yes, unmanaged bool) { Options.AssumeYes, Options.DeleteUnmanaged = yes, unmanaged }(Options.AssumeYes, Options.DeleteUnmanaged)
+	// the clusters the CLI did not create are never asked about without a terminal
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdin = f; stdin.Close() }(os.Stdin)
+	os.Stdin = stdin
+	testCases := []struct {
+		name            string
+		deleteUnmanaged bool
+		expectedDelete  string
+	}{
+		{
+			name:           "Unrecorded cluster retained with --yes",
+			expectedDelete: "kind delete clusters ks-ctrl",
+		},
+		{
+			name:            "Unrecorded cluster deleted with --delete-unmanaged-kind-clusters",
+			deleteUnmanaged: true,
+			expectedDelete:  "kind delete clusters ks-ctrl ks-w-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			installationStateDirectory = t.TempDir()
+			Options.AssumeYes, Options.DeleteUnmanaged = true, tc.deleteUnmanaged
+			specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+				Profile:           ProfileMinimalDemo,
+				ControllerCluster: Cluster{Name: "ks-ctrl"},
+				WorkerClusters:    []Cluster{{Name: "ks-w-1"}},
+			}}}
+			recordKindCluster(specs, "ks-ctrl")
+			deleted := ""
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				if line := strings.Join(command, " "); line == "kind get clusters" {
+					fmt.Fprintln(stdout, "ks-ctrl\nks-w-1")
+				} else if strings.HasPrefix(line, "kind delete clusters") {
+					deleted = line
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			DeleteKindClusters(specs)
+			if deleted != tc.expectedDelete {
+				t.Errorf("deleted with %q, want %q", deleted, tc.expectedDelete)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// kindKubeconfigFileName is the kubeconfig of the kind clusters of a run, in
+// the workspace.
+const kindKubeconfigFileName = "kubeslice-kubeconfig.yaml"
+
+// userKubeconfigPath is the default kubeconfig of the user --merge-kubeconfig
+// merges the kind clusters into, read before the demo profiles point
+// KUBECONFIG to their own kubeconfig.
+var userKubeconfigPath = defaultUserKubeconfig()
+
+func defaultUserKubeconfig() string {
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 && paths[0] != "" {
+		return paths[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".kube", "config")
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// kubeconfig holds the entries of a kubeconfig, keeping the fields it does not
+// need to look into as they are.
+type kubeconfig struct {
+	APIVersion     string                 `yaml:"apiVersion"`
+	Kind           string                 `yaml:"kind"`
+	Clusters       []kubeconfigEntry      `yaml:"clusters"`
+	Contexts       []kubeconfigEntry      `yaml:"contexts"`
+	Users          []kubeconfigEntry      `yaml:"users"`
+	CurrentContext string                 `yaml:"current-context"`
+	Rest           map[string]interface{} `yaml:",inline"`
+}
+
+type kubeconfigEntry struct {
+	Name   string                 `yaml:"name"`
+	Fields map[string]interface{} `yaml:",inline"`
+}
+
+func findKubeconfigEntry(entries []kubeconfigEntry, name string) int {
+	for i, entry := range entries {
+		if entry.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// entryField returns a field of the section of an entry, e.g. the server of
+// the cluster of a cluster entry.
+func (e kubeconfigEntry) entryField(section, field string) string {
+	values, _ := e.Fields[section].(map[interface{}]interface{})
+	value, _ := values[field].(string)
+	return value
+}
+
+func (e kubeconfigEntry) setEntryField(section, field, value string) {
+	if values, ok := e.Fields[section].(map[interface{}]interface{}); ok {
+		values[field] = value
+	}
+}
+
+// MergeKindKubeconfigs writes the kubeconfigs of the kind clusters of the
+// topology to one file in the workspace, merges them into the default
+// kubeconfig of the user with --merge-kubeconfig, and prints how to use them.
+func MergeKindKubeconfigs(ApplicationConfiguration *ConfigurationSpecs) {
+	util.Printf("\nMerging the kubeconfigs of the kind clusters...")
+	merged := &kubeconfig{APIVersion: "v1", Kind: "Config"}
+	for _, cluster := range getAllClusters(&ApplicationConfiguration.Configuration.ClusterConfiguration) {
+		var outB, errB bytes.Buffer
+		if err := util.RunCommandCustomIO("kind", &outB, &errB, true, "get", "kubeconfig", "--name", cluster.Name); err != nil {
+			util.Fatalf("%s Unable to get the kubeconfig of kind cluster %s: %v %s", util.Cross, cluster.Name, err, errB.String())
+		}
+		var config kubeconfig
+		if err := yaml.Unmarshal(outB.Bytes(), &config); err != nil {
+			util.Fatalf("%s Unable to parse the kubeconfig of kind cluster %s: %v", util.Cross, cluster.Name, err)
+		}
+		mergeKubeconfig(merged, &config)
+	}
+	merged.CurrentContext = ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster.ContextName
+	fileName := filepath.Join(kubesliceDirectory, kindKubeconfigFileName)
+	if err := writeKubeconfig(fileName, merged); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	util.Printf("%s Wrote the kubeconfig of the kind clusters to %s", util.Tick, fileName)
+
+	contexts := make([]string, 0, len(merged.Contexts))
+	for _, context := range merged.Contexts {
+		contexts = append(contexts, context.Name)
+	}
+	if Options.MergeKubeconfig {
+		user := &kubeconfig{APIVersion: "v1", Kind: "Config"}
+		if data, err := ioutil.ReadFile(userKubeconfigPath); err == nil {
+			if err := yaml.Unmarshal(data, user); err != nil {
+				util.Fatalf("%s Unable to parse kubeconfig %s: %v", util.Cross, userKubeconfigPath, err)
+			}
+			backup, err := backupKubeconfig(userKubeconfigPath, data, time.Now())
+			if err != nil {
+				util.Fatalf("%s Unable to back up kubeconfig %s: %v", util.Cross, userKubeconfigPath, err)
+			}
+			util.Printf("%s Backed up %s to %s", util.Tick, userKubeconfigPath, backup)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			util.Fatalf("%s Unable to read kubeconfig %s: %v", util.Cross, userKubeconfigPath, err)
+		}
+		userContexts := mergeKubeconfig(user, merged)
+		if err := writeKubeconfig(userKubeconfigPath, user); err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		util.Printf("%s Merged the kind clusters into %s as the contexts %s", util.Tick, userKubeconfigPath, strings.Join(userContexts, ", "))
+	}
+	printKubeconfigUsage(fileName, contexts)
+}
+
+// mergeKubeconfig merges the contexts of source into target, with their
+// clusters and users. An entry of target with the same name is replaced when
+// it is of the same cluster, for instance of an earlier run, and the merged
+// one is renamed otherwise. It returns the names of the merged contexts.
+func mergeKubeconfig(target, source *kubeconfig) []string {
+	merged := make([]string, 0)
+	for _, context := range source.Contexts {
+		clusterIndex := findKubeconfigEntry(source.Clusters, context.entryField("context", "cluster"))
+		if clusterIndex < 0 {
+			continue
+		}
+		cluster := copyKubeconfigEntry(source.Clusters[clusterIndex])
+		server := cluster.entryField("cluster", "server")
+
+		name := context.Name
+		if kubeconfigConflicts(target, name, server) {
+			name = uniqueContextName(target, name+"-kubeslice")
+			util.Printf("%s Context %s already exists for another cluster, merged the kind cluster as %s", util.Warn, context.Name, name)
+		}
+		context = copyKubeconfigEntry(context)
+		context.Name, cluster.Name = name, name
+		context.setEntryField("context", "cluster", name)
+		target.Clusters = replaceKubeconfigEntry(target.Clusters, cluster)
+		if i := findKubeconfigEntry(source.Users, context.entryField("context", "user")); i >= 0 {
+			user := copyKubeconfigEntry(source.Users[i])
+			user.Name = name
+			context.setEntryField("context", "user", name)
+			target.Users = replaceKubeconfigEntry(target.Users, user)
+		}
+		target.Contexts = replaceKubeconfigEntry(target.Contexts, context)
+		merged = append(merged, name)
+	}
+	return merged
+}
+
+// kubeconfigConflicts tells whether the context or cluster named name of
+// config is of another api server.
+func kubeconfigConflicts(config *kubeconfig, name, server string) bool {
+	if i := findKubeconfigEntry(config.Clusters, name); i >= 0 && config.Clusters[i].entryField("cluster", "server") != server {
+		return true
+	}
+	if i := findKubeconfigEntry(config.Contexts, name); i >= 0 {
+		cluster := findKubeconfigEntry(config.Clusters, config.Contexts[i].entryField("context", "cluster"))
+		return cluster < 0 || config.Clusters[cluster].entryField("cluster", "server") != server
+	}
+	return false
+}
+
+func uniqueContextName(config *kubeconfig, name string) string {
+	unique := name
+	for i := 2; findKubeconfigEntry(config.Contexts, unique) >= 0 || findKubeconfigEntry(config.Clusters, unique) >= 0 || findKubeconfigEntry(config.Users, unique) >= 0; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	return unique
+}
+
+func copyKubeconfigEntry(entry kubeconfigEntry) kubeconfigEntry {
+	fields := make(map[string]interface{}, len(entry.Fields))
+	for key, value := range entry.Fields {
+		if values, ok := value.(map[interface{}]interface{}); ok {
+			copied := make(map[interface{}]interface{}, len(values))
+			for k, v := range values {
+				copied[k] = v
+			}
+			value = copied
+		}
+		fields[key] = value
+	}
+	return kubeconfigEntry{Name: entry.Name, Fields: fields}
+}
+
+func replaceKubeconfigEntry(entries []kubeconfigEntry, entry kubeconfigEntry) []kubeconfigEntry {
+	if i := findKubeconfigEntry(entries, entry.Name); i >= 0 {
+		entries[i] = entry
+		return entries
+	}
+	return append(entries, entry)
+}
+
+// backupKubeconfig copies the kubeconfig of the user next to it before the
+// kind clusters are merged into it, and returns the name of the copy.
+func backupKubeconfig(fileName string, data []byte, now time.Time) (string, error) {
+	backup := fileName + ".kubeslice-backup-" + now.Format("20060102-150405")
+	if err := util.WriteFileAtomic(backup, data, 0600); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// writeKubeconfig replaces a kubeconfig, readable by the user only.
+func writeKubeconfig(fileName string, config *kubeconfig) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("unable to encode kubeconfig %s: %v", fileName, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	tmp := fileName + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("unable to write kubeconfig %s: %v", fileName, err)
+	}
+	return os.Rename(tmp, fileName)
+}
+
+// printKubeconfigUsage prints how to use the exported kubeconfig of the kind
+// clusters with the contexts of that file.
+func printKubeconfigUsage(fileName string, contexts []string) {
+	lines := []string{"\nUse the kind clusters with:", "\texport KUBECONFIG=" + fileName}
+	for _, context := range contexts {
+		lines = append(lines, fmt.Sprintf("\tkubectl --context %s get pods -A", context))
+	}
+	util.Printf("%s\n", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeKubeconfig(t *testing.T) {
+	t.Parallel()
+
+	const kind = `apiVersion: v1
+kind: Config
+clusters:
+- name: kind-ks-w-1
+  cluster:
+    server: https://127.0.0.1:40001
+contexts:
+- name: kind-ks-w-1
+  context:
+    cluster: kind-ks-w-1
+    user: kind-ks-w-1
+users:
+- name: kind-ks-w-1
+  user:
+    token: abc
+`
+	tests := []struct {
+		name     string
+		target   string
+		expected []string
+		// contexts of the merged kubeconfig
+		contexts []string
+	}{
+		{name: "Empty", expected: []string{"kind-ks-w-1"}, contexts: []string{"kind-ks-w-1"}},
+		{
+			name:     "Same cluster",
+			target:   "clusters:\n- name: kind-ks-w-1\n  cluster:\n    server: https://127.0.0.1:40001\ncontexts:\n- name: kind-ks-w-1\n  context:\n    cluster: kind-ks-w-1\n",
+			expected: []string{"kind-ks-w-1"},
+			contexts: []string{"kind-ks-w-1"},
+		},
+		{
+			name:     "Other cluster",
+			target:   "clusters:\n- name: kind-ks-w-1\n  cluster:\n    server: https://10.0.0.1:6443\ncontexts:\n- name: kind-ks-w-1\n  context:\n    cluster: kind-ks-w-1\n- name: kind-ks-w-1-kubeslice\n  context:\n    cluster: other\n",
+			expected: []string{"kind-ks-w-1-kubeslice-2"},
+			contexts: []string{"kind-ks-w-1", "kind-ks-w-1-kubeslice", "kind-ks-w-1-kubeslice-2"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var source, target kubeconfig
+			if err := yaml.Unmarshal([]byte(kind), &source); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tc.target), &target); err != nil {
+				t.Fatal(err)
+			}
+			if got := mergeKubeconfig(&target, &source); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("mergeKubeconfig() = %v, want %v", got, tc.expected)
+			}
+			contexts := make([]string, 0)
+			for _, context := range target.Contexts {
+				contexts = append(contexts, context.Name)
+			}
+			if !reflect.DeepEqual(contexts, tc.contexts) {
+				t.Errorf("contexts = %v, want %v", contexts, tc.contexts)
+			}
+			merged := target.Contexts[findKubeconfigEntry(target.Contexts, tc.expected[0])]
+			cluster := target.Clusters[findKubeconfigEntry(target.Clusters, merged.entryField("context", "cluster"))]
+			if cluster.entryField("cluster", "server") != "https://127.0.0.1:40001" || findKubeconfigEntry(target.Users, merged.entryField("context", "user")) < 0 {
+				t.Errorf("context %s does not refer to the kind cluster and user: %+v", merged.Name, target)
+			}
+			// the other cluster is kept
+			if tc.name == "Other cluster" && target.Clusters[0].entryField("cluster", "server") != "https://10.0.0.1:6443" {
+				t.Errorf("mergeKubeconfig() overwrote the cluster of the existing context: %+v", target.Clusters[0])
+			}
+		})
+	}
+}
+
+func TestBackupKubeconfig(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "config")
+	data := []byte("apiVersion: v1\nkind: Config\n")
+	backup, err := backupKubeconfig(fileName, data, time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("backupKubeconfig() returned error: %v", err)
+	}
+	if expected := fileName + ".kubeslice-backup-20261014-093000"; backup != expected {
+		t.Errorf("backup = %s, want %s", backup, expected)
+	}
+	if copied, err := ioutil.ReadFile(backup); err != nil || string(copied) != string(data) {
+		t.Errorf("backup holds %q, %v, want %q", copied, err, data)
+	}
+}
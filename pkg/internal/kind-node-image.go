@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// kindNodeImageRepository is where kind publishes the node images of the
+// Kubernetes releases.
+const kindNodeImageRepository = "kindest/node"
+
+// patchVersion matches a Kubernetes version like 1.27.3 or v1.27.3.
+var patchVersion = regexp.MustCompile(`^v?\d+\.\d+\.\d+$`)
+
+// kindNodeImageOf resolves the node image of a kind cluster from its
+// kubernetes_version and node_image, those of cluster_configuration when the
+// cluster sets neither, and the default node image when nothing is set.
+func kindNodeImageOf(cc *ClusterConfiguration, cluster *Cluster) (string, error) {
+	version, image, path := cluster.KubernetesVersion, cluster.NodeImage, "cluster "+cluster.Name
+	if version == "" && image == "" {
+		version, image, path = cc.KubernetesVersion, cc.NodeImage, "cluster_configuration"
+	}
+	switch {
+	case version == "" && image == "":
+		return kindNodeImage, nil
+	case version == "":
+		return image, nil
+	case image == "":
+		if !patchVersion.MatchString(version) {
+			return "", fmt.Errorf("kubernetes_version %s of %s is not a patch version like 1.27.3, set node_image for other tags", version, path)
+		}
+		return kindNodeImageRepository + ":v" + strings.TrimPrefix(version, "v"), nil
+	}
+	imageVersion := nodeImageVersion(image)
+	consistent := imageVersion == "v"+strings.TrimPrefix(version, "v")
+	if !patchVersion.MatchString(version) {
+		consistent = imageVersion != "" && kubernetesMinor(imageVersion) == kubernetesMinor(version)
+	}
+	if !consistent {
+		if imageVersion == "" {
+			return "", fmt.Errorf("kubernetes_version %s and node_image %s of %s are inconsistent, the tag of the image is not a Kubernetes version", version, image, path)
+		}
+		return "", fmt.Errorf("kubernetes_version %s and node_image %s of %s are inconsistent, the image runs Kubernetes %s", version, image, path, imageVersion)
+	}
+	return image, nil
+}
+
+// nodeImageVersion returns the Kubernetes version of the tag of a node image
+// like kindest/node:v1.27.3@sha256:..., empty when the tag is not one.
+func nodeImageVersion(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	tag := image[i+1:]
+	if !patchVersion.MatchString(tag) {
+		return ""
+	}
+	return "v" + strings.TrimPrefix(tag, "v")
+}
+
+// ValidateKindNodeImages checks that the node images of the kind clusters of
+// a demo profile can be resolved, and once the charts are resolved that they
+// run a Kubernetes version the charts to install on the cluster support.
+// Unsupported versions only warn with --ignore-version-check.
+func ValidateKindNodeImages(cc ClusterConfiguration) []string {
+	errors := make([]string, 0)
+	if cc.Profile == "" {
+		return errors
+	}
+	// the clusters report the global fields once
+	if _, err := kindNodeImageOf(&cc, &Cluster{}); err != nil {
+		errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+	}
+	for i, cluster := range getAllClusters(&cc) {
+		image, err := kindNodeImageOf(&cc, cluster)
+		if err != nil {
+			if cluster.KubernetesVersion != "" || cluster.NodeImage != "" {
+				errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			}
+			continue
+		}
+		if unsupported := nodeImageUnsupported(cluster.Name, image, i == 0); unsupported != "" {
+			if Options.IgnoreVersionCheck {
+				util.Printf("%s %s", util.Warn, unsupported)
+				continue
+			}
+			errors = append(errors, fmt.Sprintf("%s %s, use --ignore-version-check to create it anyway", util.Cross, unsupported))
+		}
+	}
+	return errors
+}
+
+// nodeImageUnsupported checks the Kubernetes version of the node image of a
+// kind cluster against the resolved charts of the components installed on
+// it, warning about versions newer than those tested.
+func nodeImageUnsupported(clusterName, image string, controller bool) string {
+	version := nodeImageVersion(image)
+	components := []string{Worker_Component}
+	if controller {
+		components = []string{Controller_Component, UI_install_Component}
+	}
+	supported, ok := supportedKubernetes(components)
+	switch {
+	case !ok || version == "":
+		return ""
+	case !supported.supports(version):
+		return fmt.Sprintf("node image %s of cluster %s runs Kubernetes %s, %s", image, clusterName, version, supported)
+	case supported.untested(version):
+		util.Printf("%s Node image %s of cluster %s runs Kubernetes %s, newer than the Kubernetes %s %s were tested with", util.Warn, image, clusterName, version, supported.max, strings.Join(supported.charts, ", "))
+	}
+	return ""
+}
+
+// kindNodeImages returns the node images of the clusters of
+// selectedClusters, with those SelectPlatform replaced, recording them in the
+// run summary.
+func kindNodeImages(cc *ClusterConfiguration) []string {
+	clusters := selectedClusters(cc)
+	images := make([]string, len(clusters))
+	runSummary.NodeImages = make(map[string]string, len(clusters))
+	for i, cluster := range clusters {
+		image, err := kindNodeImageOf(cc, cluster)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		if replacement, found := platformNodeImages[image]; found {
+			image = replacement
+		}
+		images[i] = image
+		runSummary.NodeImages[cluster.Name] = image
+	}
+	return images
+}
+
+// kindRuntime is the container runtime kind runs the nodes with.
+func kindRuntime() string {
+	if provider := os.Getenv("KIND_EXPERIMENTAL_PROVIDER"); provider != "" {
+		return provider
+	}
+	return "docker"
+}
+
+// pullNodeImages pulls every node image of the clusters to create once with
+// the container runtime of kind, through the proxy of the run, rather than
+// each cluster pulling it within kind create cluster, which stalls without
+// telling why when the pull fails. The progress of the pulls is shown and
+// every image is verified to be present before kind runs. Images which are
+// present already are not pulled again, unless --platform selects a variant.
+func pullNodeImages(images []string) {
+	runtime := kindRuntime()
+	if settings := proxySettings(); settings != "" && len(images) > 0 {
+		util.Printf("%s Pulling the node images through the proxy of the run: %s", util.Wait, settings)
+	}
+	pulled := make([]string, 0, len(images))
+	for _, image := range images {
+		if containsString(pulled, image) {
+			continue
+		}
+		pulled = append(pulled, image)
+		if Options.Platform == "" && nodeImagePresent(runtime, image) {
+			util.Printf("%s Node image %s is present", util.Tick, image)
+			continue
+		}
+		util.Printf("%s Pulling node image %s...", util.Wait, image)
+		if err := pullNodeImage(runtime, image); err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		util.Printf("%s Pulled node image %s", util.Tick, image)
+	}
+}
+
+// pullNodeImage pulls a node image, writing the progress of the pull to the
+// output, and verifies that it is present. A failed pull is reported with the
+// proxy settings of the run.
+func pullNodeImage(runtime, image string) error {
+	args := []string{"pull", image}
+	if Options.Platform != "" {
+		args = append(args, "--platform", Options.Platform)
+	}
+	var errB bytes.Buffer
+	if err := util.RunCommandCustomIO(runtime, util.Output, &errB, true, args...); err != nil {
+		return fmt.Errorf("unable to pull node image %s: %s%s", image, commandError(err, &errB), nodeImagePullHint(runtime, errB.String()))
+	}
+	if !nodeImagePresent(runtime, image) {
+		return fmt.Errorf("node image %s is not present after pulling it with %s", image, runtime)
+	}
+	return nil
+}
+
+// nodeImagePresent tells whether the container runtime has an image.
+func nodeImagePresent(runtime, image string) bool {
+	var outB, errB bytes.Buffer
+	return util.RunCommandCustomIO(runtime, &outB, &errB, true, "image", "inspect", "--format", "{{.Id}}", image) == nil
+}
+
+// nodeImagePullHint echoes the proxy settings of the run after a failed pull
+// of a node image. The docker daemon pulls with its own proxy settings rather
+// than those of the docker command, which is pointed out when it has none.
+func nodeImagePullHint(runtime, output string) string {
+	settings := proxySettings()
+	if settings == "" {
+		return ""
+	}
+	hint := "\nProxy settings of the run: " + settings
+	if h := proxyErrorHint(output); h != "" {
+		hint += "\n" + h
+	}
+	if runtime == "docker" {
+		var outB, errB bytes.Buffer
+		err := util.RunCommandCustomIO(runtime, &outB, &errB, true, "info", "--format", "{{.HTTPProxy}}{{.HTTPSProxy}}")
+		if err == nil && strings.TrimSpace(outB.String()) == "" {
+			hint += "\nThe docker daemon, which pulls the images, has no proxy configured. Configure it in the settings of Docker Desktop or the environment of the docker service"
+		}
+	}
+	return hint
+}
@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestKindNodeImageOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		cc            ClusterConfiguration
+		cluster       Cluster
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Default",
+			expected: kindNodeImage,
+		},
+		{
+			name:     "Global Kubernetes version",
+			cc:       ClusterConfiguration{KubernetesVersion: "1.27.3"},
+			expected: "kindest/node:v1.27.3",
+		},
+		{
+			name:     "Cluster node image wins",
+			cc:       ClusterConfiguration{KubernetesVersion: "1.27.3"},
+			cluster:  Cluster{Name: "worker-1", NodeImage: "registry.local/kindest/node:v1.28.0@sha256:0123"},
+			expected: "registry.local/kindest/node:v1.28.0@sha256:0123",
+		},
+		{
+			name:     "Minor version of the node image",
+			cluster:  Cluster{Name: "worker-1", KubernetesVersion: "v1.27", NodeImage: "kindest/node:v1.27.3"},
+			expected: "kindest/node:v1.27.3",
+		},
+		{
+			name:          "Minor version alone",
+			cc:            ClusterConfiguration{KubernetesVersion: "1.27"},
+			expectedError: "kubernetes_version 1.27 of cluster_configuration is not a patch version like 1.27.3, set node_image for other tags",
+		},
+		{
+			name:          "Inconsistent",
+			cluster:       Cluster{Name: "worker-1", KubernetesVersion: "1.27.3", NodeImage: "kindest/node:v1.26.6"},
+			expectedError: "kubernetes_version 1.27.3 and node_image kindest/node:v1.26.6 of cluster worker-1 are inconsistent, the image runs Kubernetes v1.26.6",
+		},
+		{
+			name:          "Tag is no version",
+			cc:            ClusterConfiguration{KubernetesVersion: "1.27.3", NodeImage: "registry.local:5000/node:latest"},
+			expectedError: "kubernetes_version 1.27.3 and node_image registry.local:5000/node:latest of cluster_configuration are inconsistent, the tag of the image is not a Kubernetes version",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			image, err := kindNodeImageOf(&tc.cc, &tc.cluster)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("kindNodeImageOf() error = %v, want %s", err, tc.expectedError)
+				}
+				return
+			}
+			if err != nil || image != tc.expected {
+				t.Errorf("kindNodeImageOf() = %q, %v, want %q", image, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateKindNodeImages(t *testing.T) {
+	defer func(charts map[string]chartSummary, ignore bool) {
+		runSummary.Charts, Options.IgnoreVersionCheck = charts, ignore
+	}(runSummary.Charts, Options.IgnoreVersionCheck)
+	resolved := map[string]chartSummary{
+		Controller_Component: {Chart: "kubeslice/kubeslice-controller", Version: "1.2.0"},
+		Worker_Component:     {Chart: "kubeslice/kubeslice-worker", Version: "1.2.0"},
+	}
+	tests := []struct {
+		name     string
+		cc       ClusterConfiguration
+		charts   map[string]chartSummary
+		ignore   bool
+		expected []string
+	}{
+		{
+			name:     "Charts not resolved yet",
+			cc:       ClusterConfiguration{Profile: ProfileMinimalDemo, KubernetesVersion: "1.22.0", WorkerClusters: []Cluster{{Name: "ks-w-1"}}},
+			expected: []string{},
+		},
+		{
+			name:   "Unsupported version",
+			cc:     ClusterConfiguration{Profile: ProfileMinimalDemo, ControllerCluster: Cluster{Name: "ks-ctrl"}, WorkerClusters: []Cluster{{Name: "ks-w-1", KubernetesVersion: "1.22.0"}}},
+			charts: resolved,
+			expected: []string{
+				util.Cross + " node image kindest/node:v1.22.0 of cluster ks-w-1 runs Kubernetes v1.22.0, kubeslice/kubeslice-worker 1.2.0 supports Kubernetes 1.23 and newer, tested up to 1.29, use --ignore-version-check to create it anyway",
+			},
+		},
+		{
+			name:     "Unsupported version ignored",
+			cc:       ClusterConfiguration{Profile: ProfileMinimalDemo, WorkerClusters: []Cluster{{Name: "ks-w-1", KubernetesVersion: "1.22.0"}}},
+			charts:   resolved,
+			ignore:   true,
+			expected: []string{},
+		},
+		{
+			name:     "Newer than tested",
+			cc:       ClusterConfiguration{Profile: ProfileMinimalDemo, KubernetesVersion: "1.31.0", WorkerClusters: []Cluster{{Name: "ks-w-1"}}},
+			charts:   resolved,
+			expected: []string{},
+		},
+		{
+			name:     "Existing clusters",
+			cc:       ClusterConfiguration{KubernetesVersion: "1.22.0", WorkerClusters: []Cluster{{Name: "w1"}}},
+			charts:   resolved,
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runSummary.Charts, Options.IgnoreVersionCheck = tc.charts, tc.ignore
+			if errors := ValidateKindNodeImages(tc.cc); !reflect.DeepEqual(errors, tc.expected) {
+				t.Errorf("ValidateKindNodeImages() = %q, want %q", errors, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPullNodeImage(t *testing.T) {
+	defer func(proxy ProxyConfiguration) { activeProxy = proxy }(activeProxy)
+	defer util.SetCommandEnv("docker")
+	activeProxy = ProxyConfiguration{HTTPSProxy: "http://proxy.internal:3128", NoProxy: "localhost"}
+	util.SetCommandEnv("docker", proxyEnv(activeProxy)...)
+
+	tests := []struct {
+		name   string
+		pulled bool
+		err    []string
+	}{
+		{
+			name:   "Pulled",
+			pulled: true,
+		},
+		{
+			name: "Pull failed",
+			err: []string{
+				"unable to pull node image kindest/node:v1.25.11: unauthorized",
+				"Proxy settings of the run: http proxy -, https proxy http://proxy.internal:3128, no proxy localhost",
+				"The docker daemon, which pulls the images, has no proxy configured",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			present := false
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch command[1] {
+				case "pull":
+					if !tc.pulled {
+						fmt.Fprint(stderr, "unauthorized")
+						return fmt.Errorf("exit status 1")
+					}
+					fmt.Fprintln(stdout, "v1.25.11: Pulling from kindest/node")
+					present = true
+				case "image":
+					if !present {
+						return fmt.Errorf("exit status 1")
+					}
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			err := pullNodeImage("docker", kindNodeImage)
+			if !containsString(mock.Envs[0], "HTTPS_PROXY=http://proxy.internal:3128") {
+				t.Errorf("pullNodeImage() ran %q without the proxy, env %q", mock.Commands[0], mock.Envs[0])
+			}
+			if len(tc.err) == 0 {
+				if err != nil {
+					t.Fatalf("pullNodeImage() returned error: %v", err)
+				}
+				if last := mock.Commands[len(mock.Commands)-1]; strings.Join(last, " ") != "docker image inspect --format {{.Id}} "+kindNodeImage {
+					t.Errorf("pullNodeImage() did not verify the pulled image, last ran %q", last)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("pullNodeImage() returned no error, want %q", tc.err)
+			}
+			for _, expected := range tc.err {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("pullNodeImage() error = %v, want %q", err, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestKindConfigNodeImages(t *testing.T) {
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	kubesliceDirectory = t.TempDir()
+
+	image := "kindest/node:v1.27.3@sha256:0123"
+	config, err := kindConfiguration(Cluster{Name: "ks-w-1", Kind: KindClusterConfiguration{WorkerNodes: 1}}, image, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(kubesliceDirectory, kindSubDirectory), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(kubesliceDirectory, kindSubDirectory, "ks-w-1.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	images, err := kindConfigNodeImages("ks-w-1")
+	if err != nil {
+		t.Fatalf("kindConfigNodeImages() error = %v", err)
+	}
+	if len(images) != 1 || images[0] != image {
+		t.Errorf("kindConfigNodeImages() = %q, want exactly %q", images, image)
+	}
+}
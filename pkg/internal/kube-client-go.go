@@ -0,0 +1,358 @@
+//go:build clientgo
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func init() {
+	newClientGoKubeClient = func() KubeClient {
+		return &clientGoKubeClient{newClients: newClusterClients, clients: make(map[string]*clusterClients)}
+	}
+}
+
+// clientGoKubeClient reads and applies the objects with client-go instead of
+// the kubectl binary, mapping the resources with the discovery of the
+// clusters.
+//
+// The client is only built with the clientgo build tag, client-go is not a
+// dependency of the default build:
+//
+//	go get k8s.io/client-go k8s.io/api
+//	go build -tags clientgo
+type clientGoKubeClient struct {
+	newClients func(cluster *Cluster) (*clusterClients, error)
+
+	mu      sync.Mutex
+	clients map[string]*clusterClients // by kubeconfig and context
+}
+
+// clusterClients are the clients of a context of a kubeconfig.
+type clusterClients struct {
+	dynamic dynamic.Interface
+	typed   kubernetes.Interface
+	mapper  meta.RESTMapper
+}
+
+// newClusterClients loads the kubeconfig like kubectl --kubeconfig --context
+// does: the default kubeconfig, $KUBECONFIG or ~/.kube/config, when the
+// cluster has no kubeconfig path, its current context when the cluster has
+// no context.
+func newClusterClients(cluster *Cluster) (*clusterClients, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{}
+	if cluster != nil {
+		if cluster.KubeConfigPath != "" {
+			rules.ExplicitPath = cluster.KubeConfigPath
+		}
+		overrides.CurrentContext = cluster.ContextName
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig: %v", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(typed.Discovery()))
+	return &clusterClients{dynamic: dynamicClient, typed: typed, mapper: mapper}, nil
+}
+
+func (c *clientGoKubeClient) clusterClients(cluster *Cluster) (*clusterClients, error) {
+	key := ""
+	if cluster != nil {
+		key = cluster.KubeConfigPath + "\x00" + cluster.ContextName
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if clients, ok := c.clients[key]; ok {
+		return clients, nil
+	}
+	clients, err := c.newClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[key] = clients
+	return clients, nil
+}
+
+// resource returns the client of a resource named like kubectl names it,
+// e.g. sliceconfigs.controller.kubeslice.io or namespaces.
+func (c *clientGoKubeClient) resource(cluster *Cluster, resource, namespace string) (dynamic.ResourceInterface, error) {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	_, groupResource := schema.ParseResourceArg(resource)
+	gvr, err := clients.mapper.ResourceFor(groupResource.WithVersion(""))
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource %s: %v", resource, err)
+	}
+	gvk, err := clients.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := clients.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return clients.dynamic.Resource(gvr).Namespace(namespace), nil
+	}
+	return clients.dynamic.Resource(gvr), nil
+}
+
+func notFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %v", errNotFound, err)
+	}
+	return err
+}
+
+func (c *clientGoKubeClient) Get(cluster *Cluster, resource, namespace, name string) (map[string]interface{}, error) {
+	client, err := c.resource(cluster, resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	object, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return object.Object, nil
+}
+
+func (c *clientGoKubeClient) List(cluster *Cluster, resource, namespace string) ([]string, error) {
+	client, err := c.resource(cluster, resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	return names, nil
+}
+
+// Apply creates the objects of the manifest and merge patches the existing
+// ones, printing them like kubectl apply does.
+func (c *clientGoKubeClient) Apply(cluster *Cluster, namespace, fileName string) error {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return err
+	}
+	manifest, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to decode %s: %v", fileName, err)
+		}
+		if len(object.Object) == 0 {
+			continue
+		}
+		gvk := object.GroupVersionKind()
+		mapping, err := clients.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("unknown kind %s of %s: %v", gvk, fileName, err)
+		}
+		var client dynamic.ResourceInterface = clients.dynamic.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if object.GetNamespace() == "" {
+				object.SetNamespace(namespace)
+			}
+			client = clients.dynamic.Resource(mapping.Resource).Namespace(object.GetNamespace())
+		}
+		patch, err := object.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		action := "serverside-applied"
+		if useClientSideApply(cluster) || gvk.Group != kubesliceControllerGroup {
+			action, err = clientSideApply(client, object, patch)
+		} else {
+			force := Options.ForceConflicts
+			_, err = client.Patch(context.TODO(), object.GetName(), types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+			if apierrors.IsUnsupportedMediaType(err) {
+				util.Debugf("%s does not support server side apply, applying client side: %v", kubectlClusterArgs(cluster), err)
+				fallBackToClientSideApply(cluster)
+				action, err = clientSideApply(client, object, patch)
+			}
+		}
+		if apierrors.IsConflict(err) {
+			if managers := applyConflictManagers(err.Error()); len(managers) > 0 {
+				return applyConflictError(fileName, managers, strings.Join(conflictCauses(err), "\n"))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("unable to apply %s %s: %v", strings.ToLower(gvk.Kind), object.GetName(), err)
+		}
+		util.Printf("%s.%s/%s %s", strings.ToLower(gvk.Kind), gvk.Group, object.GetName(), action)
+	}
+}
+
+// clientSideApply creates the object or merge patches it on clusters without
+// server side apply.
+func clientSideApply(client dynamic.ResourceInterface, object *unstructured.Unstructured, patch []byte) (string, error) {
+	_, err := client.Create(context.TODO(), object, metav1.CreateOptions{FieldManager: fieldManager})
+	if !apierrors.IsAlreadyExists(err) {
+		return "created", err
+	}
+	_, err = client.Patch(context.TODO(), object.GetName(), types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	return "configured", err
+}
+
+// conflictCauses returns the conflicting fields of a server side apply, one
+// per line like kubectl prints them.
+func conflictCauses(err error) []string {
+	causes := []string{err.Error()}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			causes = append(causes, "- "+cause.Message+": "+cause.Field)
+		}
+	}
+	return causes
+}
+
+func (c *clientGoKubeClient) Delete(cluster *Cluster, resource, namespace, name string) error {
+	client, err := c.resource(cluster, resource, namespace)
+	if err != nil {
+		return err
+	}
+	return notFound(client.Delete(context.TODO(), name, metav1.DeleteOptions{}))
+}
+
+func (c *clientGoKubeClient) SecretData(cluster *Cluster, namespace, name string) (map[string]string, error) {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := clients.typed.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	data := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	return data, nil
+}
+
+func (c *clientGoKubeClient) Pods(cluster *Cluster, namespace string) ([]podReadiness, error) {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	list, err := clients.typed.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	pods := make([]podReadiness, 0, len(list.Items))
+	for _, pod := range list.Items {
+		pods = append(pods, corePodReadiness(pod))
+	}
+	return pods, nil
+}
+
+func corePodReadiness(pod corev1.Pod) podReadiness {
+	status := podReadiness{Name: pod.Name, Phase: string(pod.Status.Phase), Total: len(pod.Status.ContainerStatuses)}
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Ready {
+			status.Ready++
+		}
+		switch {
+		case container.State.Waiting != nil && container.State.Waiting.Reason != "":
+			status.Reason = container.State.Waiting.Reason
+		case container.State.Terminated != nil && container.State.Terminated.Reason != "" && status.Reason == "":
+			status.Reason = container.State.Terminated.Reason
+		}
+	}
+	return status
+}
+
+func (c *clientGoKubeClient) Deployments(cluster *Cluster, namespace string) ([]deploymentReadiness, error) {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	list, err := clients.typed.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	deployments := make([]deploymentReadiness, 0, len(list.Items))
+	for _, item := range list.Items {
+		replicas := 1
+		if item.Spec.Replicas != nil {
+			replicas = int(*item.Spec.Replicas)
+		}
+		deployments = append(deployments, deploymentReadiness{
+			Name:      item.Name,
+			Replicas:  replicas,
+			Ready:     int(item.Status.ReadyReplicas),
+			Updated:   int(item.Status.UpdatedReplicas),
+			Available: int(item.Status.AvailableReplicas),
+			Observed:  item.Status.ObservedGeneration >= item.Generation,
+		})
+	}
+	return deployments, nil
+}
+
+func (c *clientGoKubeClient) ServedVersions(cluster *Cluster, group string) ([]string, error) {
+	clients, err := c.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := clients.typed.Discovery().ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0)
+	for _, apiGroup := range groups.Groups {
+		if apiGroup.Name != group {
+			continue
+		}
+		for _, version := range apiGroup.Versions {
+			versions = appendUnique(versions, version.Version)
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,125 @@
+//go:build clientgo
+
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func fakeClientGoKubeClient(objects ...runtime.Object) *clientGoKubeClient {
+	projects := schema.GroupVersionResource{Group: kubesliceControllerGroup, Version: "v1alpha1", Resource: "projects"}
+	clusters := schema.GroupVersionResource{Group: kubesliceControllerGroup, Version: "v1alpha1", Resource: "clusters"}
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: kubesliceControllerGroup, Version: "v1alpha1", Kind: "Project"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: kubesliceControllerGroup, Version: "v1alpha1", Kind: "Cluster"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		projects:   "ProjectList",
+		clusters:   "ClusterList",
+		namespaces: "NamespaceList",
+	})
+	// the fake tracker does not implement server side apply, an applied object
+	// replaces the tracked one
+	dynamicClient.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		object := &unstructured.Unstructured{}
+		if err := object.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := dynamicClient.Tracker()
+		if _, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName()); apierrors.IsNotFound(err) {
+			return true, object, tracker.Create(patch.GetResource(), object, patch.GetNamespace())
+		}
+		return true, object, tracker.Update(patch.GetResource(), object, patch.GetNamespace())
+	})
+	typed := kubefake.NewSimpleClientset(objects...)
+	typed.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: kubesliceControllerGroup + "/v1alpha1"},
+		{GroupVersion: "v1"},
+	}
+	clients := &clusterClients{dynamic: dynamicClient, typed: typed, mapper: mapper}
+	return &clientGoKubeClient{
+		newClients: func(cluster *Cluster) (*clusterClients, error) {
+			return clients, nil
+		},
+		clients: make(map[string]*clusterClients),
+	}
+}
+
+func TestClientGoKubeClient(t *testing.T) {
+	cluster := &Cluster{Name: "controller", ContextName: "kind-controller"}
+	client := fakeClientGoKubeClient(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kubeslice-rbac-worker-worker-1", Namespace: "kubeslice-demo"}, Data: map[string][]byte{"token": []byte("secret")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "controller-0", Namespace: "kubeslice-controller"}, Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Ready: true}, {State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}},
+		}},
+	)
+
+	manifest := filepath.Join(t.TempDir(), "project.yaml")
+	project := "apiVersion: controller.kubeslice.io/v1alpha1\nkind: Project\nmetadata:\n  name: demo\nspec:\n  serviceAccount:\n    readWrite: [admin]\n"
+	if err := os.WriteFile(manifest, []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// applied twice, the second time updating the project
+	for i := 0; i < 2; i++ {
+		if err := client.Apply(cluster, KUBESLICE_CONTROLLER_NAMESPACE, manifest); err != nil {
+			t.Fatalf("Apply() returned error: %v", err)
+		}
+	}
+	object, err := client.Get(cluster, ProjectObject, KUBESLICE_CONTROLLER_NAMESPACE, "demo")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if object["kind"] != "Project" {
+		t.Errorf("Get() = %v, want the applied project", object)
+	}
+	if names, err := client.List(cluster, ProjectObject, KUBESLICE_CONTROLLER_NAMESPACE); err != nil || !reflect.DeepEqual(names, []string{"demo"}) {
+		t.Errorf("List() = %v, %v, want [demo]", names, err)
+	}
+	if err := client.Delete(cluster, ProjectObject, KUBESLICE_CONTROLLER_NAMESPACE, "demo"); err != nil {
+		t.Errorf("Delete() returned error: %v", err)
+	}
+	if _, err := client.Get(cluster, ProjectObject, KUBESLICE_CONTROLLER_NAMESPACE, "demo"); !errors.Is(err, errNotFound) {
+		t.Errorf("Get() of a deleted project error = %v, want errNotFound", err)
+	}
+	if _, err := client.Get(cluster, namespaceResource, "", "calico-system"); !errors.Is(err, errNotFound) {
+		t.Errorf("Get() of a missing namespace error = %v, want errNotFound", err)
+	}
+
+	data, err := client.SecretData(cluster, "kubeslice-demo", "kubeslice-rbac-worker-worker-1")
+	if err != nil || data["token"] != "c2VjcmV0" {
+		t.Errorf("SecretData() = %v, %v, want the base64 encoded token", data, err)
+	}
+	pods, err := client.Pods(cluster, KUBESLICE_CONTROLLER_NAMESPACE)
+	if err != nil || !reflect.DeepEqual(pods, []podReadiness{{Name: "controller-0", Phase: "Running", Ready: 1, Total: 2, Reason: "CrashLoopBackOff"}}) {
+		t.Errorf("Pods() = %+v, %v", pods, err)
+	}
+	if status := podVerificationStatus(pods); status != PodVerificationStatusFailed {
+		t.Errorf("podVerificationStatus() = %v, want failed", status)
+	}
+	if versions, err := client.ServedVersions(cluster, kubesliceControllerGroup); err != nil || !reflect.DeepEqual(versions, []string{"v1alpha1"}) {
+		t.Errorf("ServedVersions() = %v, %v, want [v1alpha1]", versions, err)
+	}
+}
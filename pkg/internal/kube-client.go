@@ -0,0 +1,314 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Kubernetes clients selectable with --kube-client.
+const (
+	KubeClientKubectl  = "kubectl"
+	KubeClientClientGo = "client-go"
+)
+
+// errNotFound is wrapped by the errors of the KubeClient operations on
+// objects which do not exist.
+var errNotFound = errors.New("not found")
+
+// Resources of the KubeClient operations besides the kubeslice ones, named
+// like kubectl names them.
+const (
+	namespaceResource      = "namespaces"
+	serviceAccountResource = "serviceaccounts"
+	crdResource            = "customresourcedefinitions.apiextensions.k8s.io"
+)
+
+// podReadiness is the readiness of a pod and its containers.
+type podReadiness struct {
+	Name  string
+	Phase string
+	// Ready and Total count the containers of the pod
+	Ready int
+	Total int
+	// Reason is why a container is waiting or terminated, e.g. CrashLoopBackOff
+	Reason string
+}
+
+// deploymentReadiness is the rollout of a deployment.
+type deploymentReadiness struct {
+	Name string
+	// Replicas is the desired number of replicas
+	Replicas  int
+	Ready     int
+	Updated   int
+	Available int
+	// Observed tells whether the controller observed the latest spec
+	Observed bool
+}
+
+// rolledOut tells whether all the desired replicas are updated and ready.
+func (d deploymentReadiness) rolledOut() bool {
+	return d.Observed && d.Updated >= d.Replicas && d.Ready >= d.Replicas && d.Available >= d.Replicas
+}
+
+// KubeClient reads and applies the objects of the clusters. The cluster
+// selects the kubeconfig and context like the --kubeconfig and --context
+// flags of kubectl, nil is the current context of the default kubeconfig.
+// Exec-style operations like edit, describe and port-forward stay with the
+// kubectl binary.
+type KubeClient interface {
+	// Get returns the object of a resource, e.g. clusters.controller.kubeslice.io.
+	// The namespace is ignored for cluster scoped resources.
+	Get(cluster *Cluster, resource, namespace, name string) (map[string]interface{}, error)
+	// List returns the names of the objects of a resource in namespace.
+	List(cluster *Cluster, resource, namespace string) ([]string, error)
+	// Apply creates or updates the objects of a manifest file, in namespace
+	// when they have none.
+	Apply(cluster *Cluster, namespace, fileName string) error
+	Delete(cluster *Cluster, resource, namespace, name string) error
+	// SecretData returns the base64 encoded data of a secret.
+	SecretData(cluster *Cluster, namespace, name string) (map[string]string, error)
+	// Pods returns the pods of namespace.
+	Pods(cluster *Cluster, namespace string) ([]podReadiness, error)
+	// Deployments returns the deployments of namespace.
+	Deployments(cluster *Cluster, namespace string) ([]deploymentReadiness, error)
+	// ServedVersions returns the versions of an API group the cluster serves.
+	ServedVersions(cluster *Cluster, group string) ([]string, error)
+}
+
+// kubeClient is the client the clusters are read and applied with.
+var kubeClient KubeClient = kubectlClient{}
+
+// newClientGoKubeClient creates the client-go client. It is only set in
+// builds with the clientgo build tag.
+var newClientGoKubeClient func() KubeClient
+
+// SelectKubeClient selects the Kubernetes client of the run.
+func SelectKubeClient(client string) {
+	switch client {
+	case "", KubeClientKubectl:
+		kubeClient = kubectlClient{}
+	case KubeClientClientGo:
+		if newClientGoKubeClient == nil {
+			util.Fatalf("%s This build of kubeslice-cli does not include the client-go client, build it with -tags clientgo", util.Cross)
+		}
+		kubeClient = newClientGoKubeClient()
+	default:
+		util.Fatalf("%s Unknown Kubernetes client %s. Possible values %s", util.Cross, client, []string{KubeClientKubectl, KubeClientClientGo})
+	}
+}
+
+// objectExists tells whether an object exists, e.g. a namespace or a CRD.
+func objectExists(cluster *Cluster, resource, namespace, name string) (bool, error) {
+	_, err := kubeClient.Get(cluster, resource, namespace, name)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// kubectlClient runs the kubectl binary.
+type kubectlClient struct{}
+
+func (kubectlClient) run(cluster *Cluster, args ...string) ([]byte, error) {
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, append(kubectlClusterArgs(cluster), args...)...)
+	if err != nil {
+		if strings.Contains(errB.String(), "NotFound") {
+			return nil, fmt.Errorf("%w: %s", errNotFound, strings.TrimSpace(errB.String()))
+		}
+		return nil, fmt.Errorf("kubectl %s failed: %v %s", strings.Join(args, " "), err, strings.TrimSpace(errB.String()))
+	}
+	return outB.Bytes(), nil
+}
+
+func namespaceArgs(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return []string{"-n", namespace}
+}
+
+func (c kubectlClient) Get(cluster *Cluster, resource, namespace, name string) (map[string]interface{}, error) {
+	out, err := c.run(cluster, append([]string{"get", resource, name, "-o", "json"}, namespaceArgs(namespace)...)...)
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(out, &object); err != nil {
+		return nil, fmt.Errorf("unable to decode %s %s: %v", resource, name, err)
+	}
+	return object, nil
+}
+
+func (c kubectlClient) List(cluster *Cluster, resource, namespace string) ([]string, error) {
+	out, err := c.run(cluster, append([]string{"get", resource, "-o", "jsonpath={.items[*].metadata.name}"}, namespaceArgs(namespace)...)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Apply applies a manifest of kubeslice controller objects server side, taking
+// ownership of conflicting fields only with --force-conflicts. Other
+// manifests, and clusters which do not support server side apply, are applied
+// client side.
+func (kubectlClient) Apply(cluster *Cluster, namespace, fileName string) error {
+	if useClientSideApply(cluster) || !controllerManifest(fileName) {
+		return util.RunCommand("kubectl", append(kubectlClusterArgs(cluster), append([]string{"apply", "-f", fileName}, namespaceArgs(namespace)...)...)...)
+	}
+	args := append(kubectlClusterArgs(cluster), "apply", "--server-side", "--field-manager="+fieldManager)
+	if Options.ForceConflicts {
+		args = append(args, "--force-conflicts")
+	}
+	args = append(args, append([]string{"-f", fileName}, namespaceArgs(namespace)...)...)
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("kubectl", &outB, &errB, false, args...)
+	if err == nil {
+		return nil
+	}
+	message := util.Redact(errB.String())
+	if managers := applyConflictManagers(message); len(managers) > 0 {
+		return applyConflictError(fileName, managers, message)
+	}
+	if serverSideApplyUnsupported(message) {
+		util.Debugf("%s does not support server side apply, applying client side: %s", kubectlClusterArgs(cluster), strings.TrimSpace(message))
+		fallBackToClientSideApply(cluster)
+		return kubectlClient{}.Apply(cluster, namespace, fileName)
+	}
+	util.Printf("%s Failed to run command\nOutput: %s\nError: %s %v", util.Cross, util.Redact(outB.String()), message, err)
+	return err
+}
+
+func (c kubectlClient) Delete(cluster *Cluster, resource, namespace, name string) error {
+	_, err := c.run(cluster, append([]string{"delete", resource, name}, namespaceArgs(namespace)...)...)
+	return err
+}
+
+func (c kubectlClient) SecretData(cluster *Cluster, namespace, name string) (map[string]string, error) {
+	out, err := c.run(cluster, "get", "secret", name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(out, &secret); err != nil {
+		return nil, fmt.Errorf("unable to decode secret %s: %v", name, err)
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string]string)
+	}
+	return secret.Data, nil
+}
+
+func (c kubectlClient) Pods(cluster *Cluster, namespace string) ([]podReadiness, error) {
+	out, err := c.run(cluster, "get", "pods", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []podObject `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to decode the pods of %s: %v", namespace, err)
+	}
+	pods := make([]podReadiness, 0, len(list.Items))
+	for _, pod := range list.Items {
+		pods = append(pods, pod.status())
+	}
+	return pods, nil
+}
+
+func (c kubectlClient) Deployments(cluster *Cluster, namespace string) ([]deploymentReadiness, error) {
+	out, err := c.run(cluster, "get", "deployments", "-n", namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name       string `json:"name"`
+				Generation int64  `json:"generation"`
+			} `json:"metadata"`
+			Spec struct {
+				Replicas *int `json:"replicas"`
+			} `json:"spec"`
+			Status struct {
+				ObservedGeneration int64 `json:"observedGeneration"`
+				ReadyReplicas      int   `json:"readyReplicas"`
+				UpdatedReplicas    int   `json:"updatedReplicas"`
+				AvailableReplicas  int   `json:"availableReplicas"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to decode the deployments of %s: %v", namespace, err)
+	}
+	deployments := make([]deploymentReadiness, 0, len(list.Items))
+	for _, item := range list.Items {
+		replicas := 1
+		if item.Spec.Replicas != nil {
+			replicas = *item.Spec.Replicas
+		}
+		deployments = append(deployments, deploymentReadiness{
+			Name:      item.Metadata.Name,
+			Replicas:  replicas,
+			Ready:     item.Status.ReadyReplicas,
+			Updated:   item.Status.UpdatedReplicas,
+			Available: item.Status.AvailableReplicas,
+			Observed:  item.Status.ObservedGeneration >= item.Metadata.Generation,
+		})
+	}
+	return deployments, nil
+}
+
+func (c kubectlClient) ServedVersions(cluster *Cluster, group string) ([]string, error) {
+	out, err := c.run(cluster, "api-versions")
+	if err != nil {
+		return nil, err
+	}
+	return servedGroupVersions(string(out), group), nil
+}
+
+// podObject holds the fields of a pod its readiness is derived from.
+type podObject struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			Ready bool `json:"ready"`
+			State struct {
+				Waiting *struct {
+					Reason string `json:"reason"`
+				} `json:"waiting"`
+				Terminated *struct {
+					Reason string `json:"reason"`
+				} `json:"terminated"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func (pod podObject) status() podReadiness {
+	status := podReadiness{Name: pod.Metadata.Name, Phase: pod.Status.Phase, Total: len(pod.Status.ContainerStatuses)}
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Ready {
+			status.Ready++
+		}
+		switch {
+		case container.State.Waiting != nil && container.State.Waiting.Reason != "":
+			status.Reason = container.State.Waiting.Reason
+		case container.State.Terminated != nil && container.State.Terminated.Reason != "" && status.Reason == "":
+			status.Reason = container.State.Terminated.Reason
+		}
+	}
+	return status
+}
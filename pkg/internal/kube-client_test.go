@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeKubeClient serves the objects of an in-memory cluster and records the
+// manifests applied to it.
+type fakeKubeClient struct {
+	objects map[string][]string          // names by resource and namespace
+	secrets map[string]map[string]string // data by namespace/name
+	served  []string
+	applied []appliedManifest
+	// documents are the objects Get returns by resource/namespace/name,
+	// besides the names of objects
+	documents map[string]map[string]interface{}
+	// deleted are the deleted objects as context resource namespace/name
+	deleted []string
+	// pods and deployments by namespace
+	pods        map[string][]podReadiness
+	deployments map[string][]deploymentReadiness
+	// established are the CRDs with the Established condition
+	established []string
+}
+
+type appliedManifest struct {
+	Context   string
+	Namespace string
+	Manifest  string
+}
+
+func objectsKey(resource, namespace string) string {
+	return resource + "/" + namespace
+}
+
+func (f *fakeKubeClient) Get(cluster *Cluster, resource, namespace, name string) (map[string]interface{}, error) {
+	if resource == crdResource && containsString(f.established, name) {
+		return map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Established", "status": "True"},
+		}}}, nil
+	}
+	if document, ok := f.documents[objectsKey(resource, namespace)+"/"+name]; ok {
+		return document, nil
+	}
+	if !containsString(f.objects[objectsKey(resource, namespace)], name) {
+		return nil, fmt.Errorf("%w: %s %s", errNotFound, resource, name)
+	}
+	return map[string]interface{}{"metadata": map[string]interface{}{"name": name}}, nil
+}
+
+func (f *fakeKubeClient) List(cluster *Cluster, resource, namespace string) ([]string, error) {
+	return f.objects[objectsKey(resource, namespace)], nil
+}
+
+func (f *fakeKubeClient) Apply(cluster *Cluster, namespace, fileName string) error {
+	manifest, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	f.applied = append(f.applied, appliedManifest{Context: cluster.ContextName, Namespace: namespace, Manifest: string(manifest)})
+	return nil
+}
+
+func (f *fakeKubeClient) Delete(cluster *Cluster, resource, namespace, name string) error {
+	context := ""
+	if cluster != nil {
+		context = cluster.ContextName
+	}
+	f.deleted = append(f.deleted, context+" "+resource+" "+namespace+"/"+name)
+	return nil
+}
+
+func (f *fakeKubeClient) SecretData(cluster *Cluster, namespace, name string) (map[string]string, error) {
+	data, ok := f.secrets[namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("%w: secret %s", errNotFound, name)
+	}
+	return data, nil
+}
+
+func (f *fakeKubeClient) Pods(cluster *Cluster, namespace string) ([]podReadiness, error) {
+	return f.pods[namespace], nil
+}
+
+func (f *fakeKubeClient) Deployments(cluster *Cluster, namespace string) ([]deploymentReadiness, error) {
+	return f.deployments[namespace], nil
+}
+
+func (f *fakeKubeClient) ServedVersions(cluster *Cluster, group string) ([]string, error) {
+	return f.served, nil
+}
+
+func TestProjectAndRegistrationFlows(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	defer func(summary *apiVersionSummary) { runSummary.ControllerAPI = summary }(runSummary.ControllerAPI)
+	defer func(established map[string]bool) { establishedCRDs = established }(establishedCRDs)
+	kubesliceDirectory = t.TempDir()
+	runSummary.ControllerAPI = nil
+	establishedCRDs = make(map[string]bool)
+
+	fake := &fakeKubeClient{
+		objects: map[string][]string{
+			objectsKey(serviceAccountResource, "kubeslice-blue"): {"kubeslice-rbac-worker-worker-2", "default"},
+		},
+		secrets: map[string]map[string]string{
+			"kubeslice-blue/kubeslice-rbac-worker-worker-2": {"namespace": "a3ViZXNsaWNlLWJsdWU=", "controllerEndpoint": "aHR0cHM6Ly9jb250cm9sbGVy", "ca.crt": "Y2E=", "token": "dG9rZW4="},
+		},
+		served:      []string{"v1alpha1"},
+		established: controllerCRDs,
+	}
+	kubeClient = fake
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "red", Projects: ProjectList{{Name: "red"}, {Name: "blue"}}},
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "controller", ContextName: "kind-controller"},
+			WorkerClusters: []Cluster{
+				{Name: "worker-1", ContextName: "kind-worker-1", NodeIPs: []string{"10.0.0.1"}},
+				{Name: "worker-2", ContextName: "kind-worker-2", NodeIPs: []string{"10.0.0.2"}, Project: "blue"},
+			},
+		},
+	}}
+	CreateKubeSliceProject(specs, nil)
+	RegisterWorkerClusters(specs, nil)
+
+	got := make([]string, 0)
+	for _, applied := range fake.applied {
+		names := make([]string, 0)
+		for _, line := range strings.Split(applied.Manifest, "\n") {
+			if strings.HasPrefix(line, "  name: ") {
+				names = append(names, strings.TrimPrefix(line, "  name: "))
+			}
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", applied.Context, applied.Namespace, strings.Join(names, ",")))
+	}
+	expected := []string{
+		"kind-controller kubeslice-controller red,blue",
+		"kind-controller kubeslice-red worker-1",
+		"kind-controller kubeslice-blue worker-2",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("applied manifests =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	secrets := fetchWorkerSecrets(specs.Configuration.ClusterConfiguration.WorkerClusters[1], specs.Configuration)
+	if secrets["token"] != "dG9rZW4=" {
+		t.Errorf("fetchWorkerSecrets() = %v, want the secret of the service account of worker-2", secrets)
+	}
+}
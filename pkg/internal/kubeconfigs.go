@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// ValidateKubeconfigs checks that the kubeconfig of every cluster exists and
+// has the context of the cluster. Clusters may share a kubeconfig or each
+// have their own, every file is read once.
+func ValidateKubeconfigs(cc ClusterConfiguration) []string {
+	errors := make([]string, 0)
+	contexts := make(map[string][]string)
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	for _, cluster := range clusters {
+		if cluster.KubeConfigPath == "" || cluster.ContextName == "" {
+			continue
+		}
+		names, read := contexts[cluster.KubeConfigPath]
+		if !read {
+			var err error
+			if names, err = kubeconfigContexts(cluster.KubeConfigPath); err != nil {
+				errors = append(errors, fmt.Sprintf("%s kubeconfig of cluster %s: %v", util.Cross, cluster.Name, err))
+			}
+			contexts[cluster.KubeConfigPath] = names
+		}
+		if names != nil && !containsString(names, cluster.ContextName) {
+			hint := ""
+			if matches := closeContexts(cluster.ContextName, names); len(matches) > 0 {
+				hint = fmt.Sprintf(", did you mean %s?", quoteAll(matches))
+			}
+			errors = append(errors, fmt.Sprintf("%s context %q of cluster %s is not in kubeconfig %s%s", util.Cross, cluster.ContextName, cluster.Name, cluster.KubeConfigPath, hint))
+		}
+	}
+	return errors
+}
+
+// kubeconfigContexts returns the names of the contexts of a kubeconfig.
+func kubeconfigContexts(fileName string) ([]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", fileName, err)
+	}
+	names := make([]string, 0, len(config.Contexts))
+	for _, context := range config.Contexts {
+		names = append(names, context.Name)
+	}
+	return names, nil
+}
+
+// closeContexts returns the contexts a missing context is likely a typo of,
+// closest first: those a few edits away or differing in case, and those
+// containing it, e.g. the ARN of an EKS cluster for its name.
+func closeContexts(context string, names []string) []string {
+	maxDistance := len(context) / 4
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	distances := make(map[string]int)
+	matches := make([]string, 0)
+	for _, name := range names {
+		distance := editDistance(strings.ToLower(context), strings.ToLower(name))
+		if distance <= maxDistance || strings.Contains(strings.ToLower(name), strings.ToLower(context)) {
+			distances[name] = distance
+			matches = append(matches, name)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return distances[matches[i]] < distances[matches[j]] })
+	if len(matches) > 3 {
+		matches = matches[:3]
+	}
+	return matches
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%q", name))
+	}
+	return strings.Join(quoted, " or ")
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestValidateKubeconfigs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.yaml")
+	own := filepath.Join(dir, "worker-2.yaml")
+	files := map[string]string{
+		shared: "contexts:\n- name: controller\n- name: worker-1\n- name: arn:aws:eks:us-east-1:1234:cluster/prod\n- name: prod cluster\n",
+		own:    "contexts:\n- name: worker-2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		workers  []Cluster
+		expected []string
+	}{
+		{
+			name:    "Shared and own kubeconfigs",
+			workers: []Cluster{{Name: "worker-1", ContextName: "worker-1", KubeConfigPath: shared}, {Name: "worker-2", ContextName: "worker-2", KubeConfigPath: own}},
+		},
+		{
+			name:     "Context in another kubeconfig",
+			workers:  []Cluster{{Name: "worker-1", ContextName: "worker-1", KubeConfigPath: shared}, {Name: "worker-2", ContextName: "worker-2", KubeConfigPath: shared}},
+			expected: []string{`context "worker-2" of cluster worker-2 is not in kubeconfig ` + shared + `, did you mean "worker-1"?`},
+		},
+		{
+			name:    "EKS context and context with spaces",
+			workers: []Cluster{{Name: "prod", ContextName: "arn:aws:eks:us-east-1:1234:cluster/prod", KubeConfigPath: shared}, {Name: "prod-cluster", ContextName: "prod cluster", KubeConfigPath: shared}},
+		},
+		{
+			name:     "Near miss of an EKS context",
+			workers:  []Cluster{{Name: "prod", ContextName: "arn:aws:eks:us-east-2:1234:cluster/prod", KubeConfigPath: shared}},
+			expected: []string{`context "arn:aws:eks:us-east-2:1234:cluster/prod" of cluster prod is not in kubeconfig ` + shared + `, did you mean "arn:aws:eks:us-east-1:1234:cluster/prod"?`},
+		},
+		{
+			name:     "Missing kubeconfig",
+			workers:  []Cluster{{Name: "worker-1", ContextName: "worker-1", KubeConfigPath: filepath.Join(dir, "missing.yaml")}},
+			expected: []string{"kubeconfig of cluster worker-1: open " + filepath.Join(dir, "missing.yaml")},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cc := ClusterConfiguration{ControllerCluster: Cluster{Name: "controller", ContextName: "controller", KubeConfigPath: shared}, WorkerClusters: tc.workers}
+			errors := ValidateKubeconfigs(cc)
+			if len(errors) != len(tc.expected) {
+				t.Fatalf("ValidateKubeconfigs() = %v, want %v", errors, tc.expected)
+			}
+			for i, expected := range tc.expected {
+				if !strings.Contains(errors[i], expected) {
+					t.Errorf("ValidateKubeconfigs()[%d] = %q, want it to contain %q", i, errors[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterKubeContext(t *testing.T) {
+	t.Parallel()
+
+	var cc ClusterConfiguration
+	topology := "controller:\n  name: controller\n  kube_context: ctx-controller\nworkers:\n- name: worker-1\n  context_name: ctx-1\n  kube_context: ctx-1\n"
+	if err := yaml.Unmarshal([]byte(topology), &cc); err != nil {
+		t.Fatalf("yaml.Unmarshal() returned error: %v", err)
+	}
+	if got := []string{cc.ControllerCluster.ContextName, cc.WorkerClusters[0].ContextName}; !reflect.DeepEqual(got, []string{"ctx-controller", "ctx-1"}) {
+		t.Errorf("contexts = %v, want [ctx-controller ctx-1]", got)
+	}
+	if err := yaml.Unmarshal([]byte("name: worker-1\ncontext_name: a\nkube_context: b\n"), &Cluster{}); err == nil {
+		t.Errorf("yaml.Unmarshal() of differing context_name and kube_context returned no error")
+	}
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kubernetesCompatibility is the range of Kubernetes minor versions the charts
+// of a component support, for the chart versions from chartsFrom up to but
+// excluding chartsBelow. An empty chartsBelow is open ended, its
+// maxKubernetes is the newest version tested when the row was added rather
+// than an upper bound.
+type kubernetesCompatibility struct {
+	component     string
+	chartsFrom    string
+	chartsBelow   string
+	minKubernetes string
+	maxKubernetes string
+}
+
+// kubernetesCompatibilityTable lists the supported Kubernetes versions of the
+// kubeslice charts. Source: the Kubernetes prerequisites of the release notes
+// of the kubeslice-controller, kubeslice-worker and kubeslice-ui charts
+// published to https://kubeslice.github.io/kubeslice/, which
+// `helm show chart kubeslice/<chart> --version <version>` shows. Add a row
+// per component when a release changes the supported range, closing the
+// previous row with chartsBelow.
+var kubernetesCompatibilityTable = []kubernetesCompatibility{
+	{Controller_Component, "0.0.0", "1.0.0", "1.20", "1.24"},
+	{Controller_Component, "1.0.0", "1.2.0", "1.21", "1.27"},
+	{Controller_Component, "1.2.0", "", "1.23", "1.29"},
+	{Worker_Component, "0.0.0", "1.0.0", "1.20", "1.24"},
+	{Worker_Component, "1.0.0", "1.2.0", "1.21", "1.27"},
+	{Worker_Component, "1.2.0", "", "1.23", "1.29"},
+	{UI_install_Component, "0.0.0", "1.2.0", "1.21", "1.27"},
+	{UI_install_Component, "1.2.0", "", "1.23", "1.29"},
+}
+
+// kubernetesRange is the range of Kubernetes minor versions supported by all
+// the charts installed on a cluster. An open max is only the newest version
+// tested of open ended rows.
+type kubernetesRange struct {
+	min, max string
+	openMax  bool
+	charts   []string
+}
+
+func (r kubernetesRange) String() string {
+	if r.openMax {
+		return fmt.Sprintf("%s supports Kubernetes %s and newer, tested up to %s", strings.Join(r.charts, ", "), r.min, r.max)
+	}
+	return fmt.Sprintf("%s supports Kubernetes %s to %s", strings.Join(r.charts, ", "), r.min, r.max)
+}
+
+// supports tells whether a Kubernetes version like v1.27.3-gke.100 is in the
+// range. Versions newer than an open max are supported.
+func (r kubernetesRange) supports(version string) bool {
+	minor := kubernetesMinor(version)
+	return compareVersions(minor, r.min) >= 0 && (r.openMax || compareVersions(minor, r.max) <= 0)
+}
+
+// untested tells whether a Kubernetes version is newer than the newest
+// version tested of an open max.
+func (r kubernetesRange) untested(version string) bool {
+	return r.openMax && compareVersions(kubernetesMinor(version), r.max) > 0
+}
+
+// supportedKubernetes returns the Kubernetes versions supported by the charts
+// of the components resolved for this run. Charts whose version is not known
+// before installing them, local charts and version constraints, are left out.
+func supportedKubernetes(components []string) (kubernetesRange, bool) {
+	var r kubernetesRange
+	for _, component := range components {
+		chart, ok := runSummary.Charts[component]
+		if !ok || pinnedChartVersion(component) == "" {
+			continue
+		}
+		for _, row := range kubernetesCompatibilityTable {
+			if row.component != component || compareVersions(chart.Version, row.chartsFrom) < 0 || (row.chartsBelow != "" && compareVersions(chart.Version, row.chartsBelow) >= 0) {
+				continue
+			}
+			if r.min == "" || compareVersions(row.minKubernetes, r.min) > 0 {
+				r.min = row.minKubernetes
+			}
+			if c := compareVersions(row.maxKubernetes, r.max); r.max == "" || c < 0 {
+				r.max, r.openMax = row.maxKubernetes, row.chartsBelow == ""
+			} else if c == 0 && row.chartsBelow != "" {
+				r.openMax = false
+			}
+			r.charts = append(r.charts, chart.Chart+" "+chart.Version)
+			break
+		}
+	}
+	return r, len(r.charts) > 0
+}
+
+// kubernetesMinor returns the major.minor of a Kubernetes version.
+func kubernetesMinor(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[0] + "." + parts[1]
+}
@@ -0,0 +1,93 @@
+package internal
+
+import "testing"
+
+func TestSupportedKubernetes(t *testing.T) {
+	defer func(charts map[string]chartSummary) { runSummary.Charts = charts }(runSummary.Charts)
+
+	tests := []struct {
+		name       string
+		charts     map[string]chartSummary
+		components []string
+		version    string
+		checked    bool
+		supported  bool
+		untested   bool
+		expected   string
+	}{
+		{
+			name:       "Supported version",
+			charts:     map[string]chartSummary{Worker_Component: {Chart: "kubeslice/kubeslice-worker", Version: "1.1.0"}},
+			components: []string{Worker_Component},
+			version:    "v1.27.3-gke.100",
+			checked:    true,
+			supported:  true,
+			expected:   "kubeslice/kubeslice-worker 1.1.0 supports Kubernetes 1.21 to 1.27",
+		},
+		{
+			name:       "Too old version",
+			charts:     map[string]chartSummary{Worker_Component: {Chart: "kubeslice/kubeslice-worker", Version: "1.2.1"}},
+			components: []string{Worker_Component},
+			version:    "v1.22.17",
+			checked:    true,
+			supported:  false,
+			expected:   "kubeslice/kubeslice-worker 1.2.1 supports Kubernetes 1.23 and newer, tested up to 1.29",
+		},
+		{
+			name:       "Version newer than tested",
+			charts:     map[string]chartSummary{Worker_Component: {Chart: "kubeslice/kubeslice-worker", Version: "1.2.1"}},
+			components: []string{Worker_Component},
+			version:    "v1.31.2",
+			checked:    true,
+			supported:  true,
+			untested:   true,
+			expected:   "kubeslice/kubeslice-worker 1.2.1 supports Kubernetes 1.23 and newer, tested up to 1.29",
+		},
+		{
+			name: "Range of all the charts of the cluster",
+			charts: map[string]chartSummary{
+				Controller_Component: {Chart: "kubeslice/kubeslice-controller", Version: "1.2.0"},
+				UI_install_Component: {Chart: "kubeslice/kubeslice-ui", Version: "1.1.0"},
+			},
+			components: []string{Controller_Component, UI_install_Component},
+			version:    "v1.28.0",
+			checked:    true,
+			supported:  false,
+			expected:   "kubeslice/kubeslice-controller 1.2.0, kubeslice/kubeslice-ui 1.1.0 supports Kubernetes 1.23 to 1.27",
+		},
+		{
+			name:       "Local chart",
+			charts:     map[string]chartSummary{Worker_Component: {Chart: "./charts/kubeslice-worker", Version: "local"}},
+			components: []string{Worker_Component},
+			checked:    false,
+		},
+		{
+			name:       "Version constraint",
+			charts:     map[string]chartSummary{Worker_Component: {Chart: "kubeslice/kubeslice-worker", Version: "~1.1"}},
+			components: []string{Worker_Component},
+			checked:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			runSummary.Charts = tc.charts
+			supported, checked := supportedKubernetes(tc.components)
+			if checked != tc.checked {
+				t.Fatalf("supportedKubernetes() checked = %v, want %v", checked, tc.checked)
+			}
+			if !checked {
+				return
+			}
+			if supported.String() != tc.expected {
+				t.Errorf("supportedKubernetes() = %q, want %q", supported, tc.expected)
+			}
+			if supported.supports(tc.version) != tc.supported {
+				t.Errorf("supports(%s) = %v, want %v", tc.version, !tc.supported, tc.supported)
+			}
+			if supported.untested(tc.version) != tc.untested {
+				t.Errorf("untested(%s) = %v, want %v", tc.version, !tc.untested, tc.untested)
+			}
+		})
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
@@ -36,7 +38,7 @@ func PodVerification(message string, cluster Cluster, namespace string) {
 			backoffCount = backoffCount + 1
 			util.Printf("%s %s... Pod(s) in error state, waiting to recover... %d seconds elapsed", util.Wait, message, i*5)
 			if backoffCount > backoffLimit {
-				log.Fatalf("Pod(s) in error state,\n%s", output)
+				util.Fatalf("Pod(s) in error state,\n%s", output)
 			}
 		} else {
 			util.Printf("%s %s... %d seconds elapsed", util.Wait, message, i*5)
@@ -50,7 +52,7 @@ func LicenseVerification(message string, cluster Cluster, namespace string) {
 		return fetchLicenseSecret(LicenseFileName, cluster, namespace)
 	})
 	if err != nil {
-		log.Fatalf("Unable to fetch License\n%s", err)
+		util.Fatalf("Unable to fetch License\n%s", err)
 	}
 }
 
@@ -69,14 +71,9 @@ func fetchLicenseSecret(secretName string, cc Cluster, namespace string) error {
 }
 
 func ApplyKubectlManifest(fileName, namespace string, cluster *Cluster) {
-	cmdArgs := []string{}
-	if cluster != nil {
-		cmdArgs = append(cmdArgs, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath)
-	}
-	cmdArgs = append(cmdArgs, "apply", "-f", fileName, "-n", namespace)
-	err := util.RunCommand("kubectl", cmdArgs...)
+	err := kubeClient.Apply(cluster, namespace, fileName)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
@@ -94,9 +91,10 @@ func GetKubectlResources(resourceType string, resourceName string, namespace str
 
 		cmdArgs = append(cmdArgs, "-o", outputFormat)
 	}
-	err := util.RunCommandOnStdIO("kubectl", cmdArgs...)
+	// written to the output of the prints to be watched with them
+	err := util.RunCommandCustomIO("kubectl", util.Output, os.Stderr, false, cmdArgs...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
@@ -108,7 +106,7 @@ func DeleteKubectlResources(resourceType string, resourceName string, namespace
 	cmdArgs = append(cmdArgs, "delete", resourceType, resourceName, "-n", namespace)
 	err := util.RunCommandOnStdIO("kubectl", cmdArgs...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
@@ -120,7 +118,7 @@ func EditKubectlResources(resourceType string, resourceName string, namespace st
 	cmdArgs = append(cmdArgs, "edit", resourceType, resourceName, "-n", namespace)
 	err := util.RunCommandOnStdIO("kubectl", cmdArgs...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
@@ -132,39 +130,49 @@ func DescribeKubectlResources(resourceType string, resourceName string, namespac
 	cmdArgs = append(cmdArgs, "describe", resourceType, resourceName, "-n", namespace)
 	err := util.RunCommandOnStdIO("kubectl", cmdArgs...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
 func verifyPods(cluster Cluster, namespace string) (PodVerificationStatus, string) {
-	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "pods", "-n", namespace)
+	pods, err := kubeClient.Pods(&cluster, namespace)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
-	var count = 0
-	var lines = 0
-	for _, line := range strings.Split(outB.String(), "\n") {
-		if strings.Contains(line, "Error") || strings.Contains(line, "ImagePullBackOff") || strings.Contains(line, "CrashLoopBackOff") {
-			return PodVerificationStatusFailed, outB.String()
-		}
-		if strings.Contains(line, "Completed") {
+	return podVerificationStatus(pods), podsTable(pods)
+}
+
+// podVerificationStatus is successful once the containers of all the pods
+// which did not complete are ready.
+func podVerificationStatus(pods []podReadiness) PodVerificationStatus {
+	status := PodVerificationStatusSuccess
+	for _, pod := range pods {
+		switch {
+		case pod.Reason == "Error" || pod.Reason == "ImagePullBackOff" || pod.Reason == "ErrImagePull" || pod.Reason == "CrashLoopBackOff" || pod.Phase == "Failed":
+			return PodVerificationStatusFailed
+		case pod.Phase == "Succeeded":
 			continue
-		}
-		if strings.Contains(line, "/") {
-			index := strings.Index(line, "/")
-			upper, _ := strconv.Atoi(string(line[index+1]))
-			lower, _ := strconv.Atoi(string(line[index-1]))
-			if upper == lower {
-				count = count + 1
-			}
-			lines = lines + 1
+		case pod.Ready != pod.Total:
+			status = PodVerificationStatusInProgress
 		}
 	}
-	if count == lines {
-		return PodVerificationStatusSuccess, outB.String()
+	return status
+}
+
+// podsTable prints the pods like kubectl get pods.
+func podsTable(pods []podReadiness) string {
+	var out bytes.Buffer
+	w := tabwriter.NewWriter(&out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREADY\tSTATUS")
+	for _, pod := range pods {
+		status := pod.Phase
+		if pod.Reason != "" {
+			status = pod.Reason
+		}
+		fmt.Fprintf(w, "%s\t%d/%d\t%s\n", pod.Name, pod.Ready, pod.Total, status)
 	}
-	return PodVerificationStatusInProgress, outB.String()
+	w.Flush()
+	return out.String()
 }
 
 func ApplyFile(fileName, namespace string, cluster *Cluster) {
@@ -175,7 +183,7 @@ func ApplyFile(fileName, namespace string, cluster *Cluster) {
 	cmdArgs = append(cmdArgs, "apply", "-f", fileName, "-n", namespace)
 	err := util.RunCommandOnStdIO("kubectl", cmdArgs...)
 	if err != nil {
-		log.Fatalf("Process failed %v", err)
+		util.Fatalf("Process failed %v", err)
 	}
 }
 
@@ -192,7 +200,7 @@ func SetWorker(worker []string, filename string) {
 	}
 	err := ioutil.WriteFile(filename, []byte(value), 0644)
 	if err != nil {
-		log.Fatalf("file writing error #%v ", err)
+		util.Fatalf("file writing error #%v ", err)
 	}
 }
 
@@ -202,7 +210,7 @@ func SetWorker(worker []string, filename string) {
 func getConf(filename string) []byte {
 	yamlFile, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("yamlFile.Get err   #%v ", err)
+		util.Fatalf("yamlFile.Get err   #%v ", err)
 	}
 	jsonByte, err := YAML.YAMLToJSON(yamlFile)
 	return jsonByte
@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// licenseSecretKey is the key of the license in the license secret.
+const licenseSecretKey = "license"
+
+// licenseExpiryKeys are the fields a license document may carry its expiry
+// in, in the order they are looked up.
+var licenseExpiryKeys = []string{"exp", "expiry", "expiryDate", "expiry_date", "expiresAt", "expires_at", "validUntil", "valid_until"}
+
+// enterpriseRepoMarker is part of the URL of the repositories publishing the
+// enterprise charts.
+const enterpriseRepoMarker = "kubeslice-helm-ent"
+
+type licenseSecretManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   objectMeta        `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// licenseConfigured tells whether the topology configures a license.
+func licenseConfigured(lc LicenseConfiguration) bool {
+	return lc.File != "" || lc.Key != "" || lc.KeyEnv != ""
+}
+
+// enterpriseCharts tells whether the controller chart is installed from a
+// repository of the enterprise charts, which need a license.
+func enterpriseCharts(hc HelmChartConfiguration) bool {
+	if hc.ControllerChart.LocalPath != "" {
+		return false
+	}
+	return strings.Contains(hc.forChart(hc.ControllerChart).RepoUrl, enterpriseRepoMarker)
+}
+
+// licenseSecretName returns the name of the secret the license is created
+// as in the controller namespace.
+func licenseSecretName(lc LicenseConfiguration) string {
+	if lc.SecretName != "" {
+		return lc.SecretName
+	}
+	return LicenseFileName
+}
+
+// ValidateLicenseConfiguration checks that the license is given one way
+// only. Whether it is present and valid is checked before an install.
+func ValidateLicenseConfiguration(lc LicenseConfiguration) []string {
+	errors := make([]string, 0)
+	given := 0
+	for _, source := range []string{lc.File, lc.Key, lc.KeyEnv} {
+		if source != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		errors = append(errors, fmt.Sprintf("%s Only one of configuration.license.file, key and key_env can be set", util.Cross))
+	}
+	if lc.SecretName != "" {
+		if err := validateName("configuration.license.secret_name", lc.SecretName, 63); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+		}
+	}
+	return errors
+}
+
+// readLicense returns the license of the topology, registered as a secret so
+// that it is never printed.
+func readLicense(lc LicenseConfiguration) ([]byte, error) {
+	var license []byte
+	switch {
+	case lc.File != "":
+		data, err := ioutil.ReadFile(lc.File)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the license file of configuration.license.file: %v", err)
+		}
+		license = data
+	case lc.KeyEnv != "":
+		license = []byte(os.Getenv(lc.KeyEnv))
+		if len(license) == 0 {
+			return nil, fmt.Errorf("environment variable %s of configuration.license.key_env is not set", lc.KeyEnv)
+		}
+	default:
+		license = []byte(lc.Key)
+	}
+	license = bytes.TrimSpace(license)
+	if len(license) == 0 {
+		return nil, fmt.Errorf("the license of configuration.license is empty")
+	}
+	util.RegisterSecret(string(license))
+	util.RegisterSecret(base64.StdEncoding.EncodeToString(license))
+	return license, nil
+}
+
+// licenseExpiry returns the expiry a license carries: the exp claim of a
+// JWT, or an expiry field of a JSON or YAML document, also when the document
+// is base64 encoded. Licenses of other formats have no known expiry.
+func licenseExpiry(license []byte) (time.Time, bool) {
+	text := strings.TrimSpace(string(license))
+	if parts := strings.Split(text, "."); len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "=")); err == nil {
+			if expiry, ok := documentExpiry(payload); ok {
+				return expiry, true
+			}
+		}
+	}
+	if expiry, ok := documentExpiry([]byte(text)); ok {
+		return expiry, true
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+		return documentExpiry(decoded)
+	}
+	return time.Time{}, false
+}
+
+// documentExpiry returns the expiry field of a JSON or YAML document, either
+// a date, an RFC 3339 time or seconds since the epoch.
+func documentExpiry(data []byte) (time.Time, bool) {
+	document := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		var object map[string]interface{}
+		if json.Unmarshal(data, &object) != nil {
+			return time.Time{}, false
+		}
+		document = object
+	}
+	for _, key := range licenseExpiryKeys {
+		switch value := document[key].(type) {
+		case int:
+			return time.Unix(int64(value), 0).UTC(), true
+		case float64:
+			return time.Unix(int64(value), 0).UTC(), true
+		case string:
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return time.Unix(seconds, 0).UTC(), true
+			}
+			for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+				if expiry, err := time.Parse(layout, value); err == nil {
+					return expiry, true
+				}
+			}
+		case time.Time:
+			return value, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// VerifyLicense checks before an install or an upgrade of the controller that
+// the license of the topology is present and has not expired, so that the
+// enterprise charts do not crash for the lack of it later. The enterprise demo
+// runs on the trial license the controller creates.
+func VerifyLicense(specs *ConfigurationSpecs, installsController bool) {
+	lc := specs.Configuration.License
+	if !installsController {
+		return
+	}
+	if !licenseConfigured(lc) {
+		if enterpriseCharts(specs.Configuration.HelmChartConfiguration) && specs.Configuration.ClusterConfiguration.Profile != ProfileEntDemo {
+			util.Fatalf("%s The enterprise charts need a license, set configuration.license. Pre-flight checks failed, nothing was changed", util.Cross)
+		}
+		return
+	}
+	util.Printf("\nVerifying the license...")
+	license, err := readLicense(lc)
+	if err != nil {
+		util.Fatalf("%s %v. Pre-flight checks failed, nothing was changed", util.Cross, err)
+	}
+	expiry, known := licenseExpiry(license)
+	switch {
+	case !known:
+		util.Printf("%s The license carries no expiry the CLI can read, it is not checked", util.Warn)
+	case !expiry.After(time.Now()):
+		util.Fatalf("%s The license expired on %s, renew it and update configuration.license. Pre-flight checks failed, nothing was changed", util.Cross, expiry.Format("2006-01-02"))
+	default:
+		util.Printf("%s The license is valid until %s", util.Tick, expiry.Format("2006-01-02"))
+	}
+}
+
+// ApplyLicenseSecret creates the license of the topology as a secret in the
+// controller namespace, which the controller chart is pointed to. The
+// manifest is written readable by the user only and removed on exit.
+func ApplyLicenseSecret(specs *ConfigurationSpecs) {
+	lc := specs.Configuration.License
+	if !licenseConfigured(lc) {
+		return
+	}
+	cluster := specs.Configuration.ClusterConfiguration.ControllerCluster
+	namespace := ControllerNamespace(cluster)
+	name := licenseSecretName(lc)
+	license, err := readLicense(lc)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	manifest, err := marshalManifests(licenseSecretManifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   newObjectMeta(name, namespace, specs.Configuration.KubeSliceConfiguration, ObjectMetadata{}),
+		Type:       "Opaque",
+		Data:       map[string]string{licenseSecretKey: base64.StdEncoding.EncodeToString(license)},
+	})
+	if err != nil {
+		util.Fatalf("%s Unable to generate the manifest of the license secret: %v", util.Cross, err)
+	}
+	fileName := fmt.Sprintf("%s/license-secret-%s.yaml", kubesliceDirectory, cluster.Name)
+	if err := util.WriteFileAtomic(fileName, []byte(manifest), 0600); err != nil {
+		util.Fatalf("%s Unable to write the manifest of the license secret: %v", util.Cross, err)
+	}
+	registerGeneratedFile(fileName)
+	if err := kubeClient.Apply(&cluster, namespace, fileName); err != nil {
+		util.Fatalf("%s Unable to create the license secret %s on %s: %v", util.Cross, name, cluster.Name, err)
+	}
+	util.Printf("%s Created the license secret %s in namespace %s", util.Tick, name, namespace)
+}
+
+// licenseValues point the controller chart to the license secret.
+func licenseValues(lc LicenseConfiguration) map[string]interface{} {
+	return map[string]interface{}{
+		"kubeslice.license.mode":       "manual",
+		"kubeslice.license.secretName": licenseSecretName(lc),
+	}
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestLicenseExpiry(t *testing.T) {
+	t.Parallel()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"acme","exp":1893456000}`))
+	tests := []struct {
+		name     string
+		license  string
+		expected time.Time
+		known    bool
+	}{
+		{
+			name:     "JWT exp claim",
+			license:  "eyJhbGciOiJSUzI1NiJ9." + payload + ".c2lnbmF0dXJl",
+			expected: time.Unix(1893456000, 0).UTC(),
+			known:    true,
+		},
+		{
+			name:     "JSON expiry date",
+			license:  `{"customer":"acme","expiry":"2030-01-01T00:00:00Z"}`,
+			expected: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			known:    true,
+		},
+		{
+			name:     "YAML expiry date",
+			license:  "customer: acme\nvalid_until: \"2024-06-30\"\n",
+			expected: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+			known:    true,
+		},
+		{
+			name:     "Base64 document",
+			license:  base64.StdEncoding.EncodeToString([]byte("expiresAt: \"1893456000\"\n")),
+			expected: time.Unix(1893456000, 0).UTC(),
+			known:    true,
+		},
+		{
+			name:    "Opaque key",
+			license: "KS-4F2A-9C1E-77B0",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			expiry, known := licenseExpiry([]byte(tc.license))
+			if known != tc.known || !expiry.Equal(tc.expected) {
+				t.Errorf("licenseExpiry() = %v, %v, want %v, %v", expiry, known, tc.expected, tc.known)
+			}
+		})
+	}
+}
+
+func TestValidateLicenseConfiguration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		lc     LicenseConfiguration
+		errors int
+	}{
+		{name: "No license", lc: LicenseConfiguration{}},
+		{name: "License file", lc: LicenseConfiguration{File: "license.yaml", SecretName: "acme-license"}},
+		{name: "File and key", lc: LicenseConfiguration{File: "license.yaml", KeyEnv: "KUBESLICE_LICENSE"}, errors: 1},
+		{name: "Invalid secret name", lc: LicenseConfiguration{Key: "KS-4F2A", SecretName: "Acme_License"}, errors: 1},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if errors := ValidateLicenseConfiguration(tc.lc); len(errors) != tc.errors {
+				t.Errorf("ValidateLicenseConfiguration() = %v, want %d errors", errors, tc.errors)
+			}
+		})
+	}
+}
+
+func TestEnterpriseCharts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		hc       HelmChartConfiguration
+		expected bool
+	}{
+		{name: "Open source repository", hc: HelmChartConfiguration{RepoUrl: "https://kubeslice.github.io/kubeslice/"}},
+		{name: "Enterprise repository", hc: HelmChartConfiguration{RepoUrl: "https://kubeslice.aveshalabs.io/repository/kubeslice-helm-ent-prod/"}, expected: true},
+		{
+			name: "Local controller chart",
+			hc: HelmChartConfiguration{
+				RepoUrl:         "https://kubeslice.aveshalabs.io/repository/kubeslice-helm-ent-prod/",
+				ControllerChart: HelmChart{ChartName: "kubeslice-controller", LocalPath: "charts/kubeslice-controller"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := enterpriseCharts(tc.hc); got != tc.expected {
+				t.Errorf("enterpriseCharts() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
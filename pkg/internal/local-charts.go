@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// topologyCharts returns the charts of the topology keyed by their topology
+// field name, in a stable order.
+func topologyCharts(hc *HelmChartConfiguration) ([]string, map[string]*HelmChart) {
+	return []string{"cert_manager_chart", "controller_chart", "worker_chart", "ui_chart", "prometheus_chart"}, map[string]*HelmChart{
+		"cert_manager_chart": &hc.CertManagerChart,
+		"controller_chart":   &hc.ControllerChart,
+		"worker_chart":       &hc.WorkerChart,
+		"ui_chart":           &hc.UIChart,
+		"prometheus_chart":   &hc.PrometheusChart,
+	}
+}
+
+// AllChartsLocal reports whether every configured chart is installed from a
+// local_path, in which case no chart repository is needed.
+func AllChartsLocal(hc HelmChartConfiguration) bool {
+	names, charts := topologyCharts(&hc)
+	for _, name := range names {
+		chart := charts[name]
+		if chart.ChartName != "" && chart.LocalPath == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateLocalCharts reports the local_path entries which are neither a chart
+// archive nor a directory containing Chart.yaml.
+func ValidateLocalCharts(hc *HelmChartConfiguration) []string {
+	errors := make([]string, 0)
+	names, charts := topologyCharts(hc)
+	for _, name := range names {
+		if err := validateLocalChart(charts[name].LocalPath); err != nil {
+			errors = append(errors, fmt.Sprintf("%s configuration.helm_chart_configuration.%s.local_path: %v", util.Cross, name, err))
+		}
+	}
+	return errors
+}
+
+func validateLocalChart(path string) error {
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(path, "Chart.yaml")); err != nil {
+			return fmt.Errorf("%s does not contain a Chart.yaml", path)
+		}
+		return nil
+	}
+	if !strings.HasSuffix(path, ".tgz") && !strings.HasSuffix(path, ".tar.gz") {
+		return fmt.Errorf("%s is neither a chart directory nor a .tgz chart archive", path)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateLocalChart(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "kubeslice-test-charts")
+	if err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	chartDir := filepath.Join(dir, "kubeslice-worker")
+	emptyDir := filepath.Join(dir, "empty")
+	for _, d := range []string{chartDir, emptyDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", d, err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(chartDir, "Chart.yaml"):            "name: kubeslice-worker\n",
+		filepath.Join(dir, "kubeslice-worker-1.0.0.tgz"): "",
+		filepath.Join(dir, "values.yaml"):                "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		err  string
+	}{
+		{
+			name: "Chart archive",
+			path: filepath.Join(dir, "kubeslice-worker-1.0.0.tgz"),
+		},
+		{
+			name: "Unpacked chart directory",
+			path: chartDir,
+		},
+		{
+			name: "Directory without Chart.yaml",
+			path: emptyDir,
+			err:  "does not contain a Chart.yaml",
+		},
+		{
+			name: "File which is not a chart archive",
+			path: filepath.Join(dir, "values.yaml"),
+			err:  "is neither a chart directory nor a .tgz chart archive",
+		},
+		{
+			name: "Missing path",
+			path: filepath.Join(dir, "missing.tgz"),
+			err:  "no such file or directory",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateLocalChart(tc.path)
+			if tc.err == "" && err != nil {
+				t.Errorf("validateLocalChart() returned error: %v", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Errorf("validateLocalChart() error = %v, expected it to contain %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestLocalChartReference(t *testing.T) {
+	t.Parallel()
+
+	hc := HelmChartConfiguration{
+		RepoAlias:       "kubeslice",
+		RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+		ControllerChart: HelmChart{ChartName: "kubeslice-controller", LocalPath: "/charts/kubeslice-controller-1.0.0.tgz"},
+		WorkerChart:     HelmChart{ChartName: "kubeslice-worker"},
+	}
+	if got := chartReference(hc, hc.ControllerChart); got != "/charts/kubeslice-controller-1.0.0.tgz" {
+		t.Errorf("chartReference() = %s, expected the local path", got)
+	}
+	if AllChartsLocal(hc) {
+		t.Errorf("AllChartsLocal() should be false while the worker chart uses the repository")
+	}
+	hc.WorkerChart.LocalPath = "/charts/kubeslice-worker"
+	if !AllChartsLocal(hc) {
+		t.Errorf("AllChartsLocal() should be true once every configured chart has a local path")
+	}
+}
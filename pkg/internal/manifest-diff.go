@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// manifestResource is a rendered resource of a release, keyed by kind and
+// name in the diff.
+type manifestResource struct {
+	key      string
+	manifest string
+}
+
+// showManifestDiff renders the pending upgrade of a release with helm template
+// and prints the per resource diff against the manifest of the deployed
+// release when --diff is set, asking for confirmation before the upgrade.
+// Releases which are not installed yet are skipped silently.
+func showManifestDiff(cluster Cluster, release, namespace, valuesFile string, upgradeArgs []string) {
+	if !Options.Diff {
+		return
+	}
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, true, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "get", "manifest", release, "--namespace", namespace)
+	if err != nil {
+		if strings.Contains(errB.String(), "not found") {
+			return
+		}
+		util.Fatalf("%s Unable to fetch the manifest of release %s on %s: %s", util.Cross, release, cluster.Name, strings.TrimSpace(errB.String()))
+	}
+	deployed, err := parseManifest(outB.Bytes())
+	if err != nil {
+		util.Fatalf("%s Unable to parse the manifest of release %s on %s: %v", util.Cross, release, cluster.Name, err)
+	}
+
+	var renderedB bytes.Buffer
+	errB.Reset()
+	err = withSensitiveValues(valuesFile, templateArgs(upgradeArgs), func(args []string) error {
+		return util.RunCommandCustomIO("helm", &renderedB, &errB, true, args...)
+	})
+	if err != nil {
+		util.Fatalf("%s Unable to render release %s for %s: %s", util.Cross, release, cluster.Name, strings.TrimSpace(errB.String()))
+	}
+	pending, err := parseManifest(renderedB.Bytes())
+	if err != nil {
+		util.Fatalf("%s Unable to parse the rendered manifest of release %s for %s: %v", util.Cross, release, cluster.Name, err)
+	}
+
+	diff, changed := manifestDiff(deployed, pending)
+	if !changed {
+		util.Printf("%s No manifest changes for release %s on %s", util.Tick, release, cluster.Name)
+		return
+	}
+	util.Printf("Manifest changes for release %s on %s:\n%s", release, cluster.Name, diff)
+	if !Options.AssumeYes && !util.IsTerminal(os.Stdin) {
+		util.Fatalf("%s The manifest of release %s on %s differs from the deployed one, re-run with --yes to upgrade it", util.Cross, release, cluster.Name)
+	}
+	if !Options.AssumeYes && !util.Confirm("Proceed with upgrade of release %s on %s?", release, cluster.Name) {
+		util.Fatalf("%s Upgrade of release %s aborted", util.Cross, release)
+	}
+}
+
+// templateArgs turns the arguments of `helm upgrade -i` into those of `helm
+// template` rendering the same release. Flags which only apply to an upgrade
+// are dropped.
+func templateArgs(upgradeArgs []string) []string {
+	args := make([]string, 0, len(upgradeArgs))
+	for i := 0; i < len(upgradeArgs); i++ {
+		switch arg := upgradeArgs[i]; arg {
+		case "upgrade":
+			args = append(args, "template", "--is-upgrade")
+			if i+1 < len(upgradeArgs) && upgradeArgs[i+1] == "-i" {
+				i++
+			}
+		case "--create-namespace", "--wait":
+		case "--timeout":
+			i++
+		default:
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// parseManifest splits a multi document manifest into its resources, sorted
+// by kind and name. Each resource is re-encoded so that formatting and
+// comments do not show up as changes, with the data of secrets replaced by a
+// checksum.
+func parseManifest(data []byte) ([]manifestResource, error) {
+	resources := make([]manifestResource, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document map[interface{}]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(document) == 0 {
+			continue
+		}
+		metadata, _ := document["metadata"].(map[interface{}]interface{})
+		key := fmt.Sprintf("%v/%v", document["kind"], metadata["name"])
+		if ns, ok := metadata["namespace"]; ok {
+			key = fmt.Sprintf("%v/%v/%v", document["kind"], ns, metadata["name"])
+		}
+		if document["kind"] == "Secret" {
+			redactSecretData(document)
+		}
+		encoded, err := yaml.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, manifestResource{key: key, manifest: string(encoded)})
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].key < resources[j].key })
+	return resources, nil
+}
+
+// redactSecretData replaces the values of a secret with their checksum, so
+// that changes are visible without printing the values.
+func redactSecretData(secret map[interface{}]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		data, ok := secret[field].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range data {
+			data[k] = fmt.Sprintf("<redacted sha256:%x>", sha256.Sum256([]byte(fmt.Sprint(v))))
+		}
+	}
+}
+
+// manifestDiff renders the diff of every resource of the deployed and pending
+// manifests. Resources only present on one side are shown as added or
+// removed, unchanged resources with a single line. changed is false when no
+// resource differs.
+func manifestDiff(deployed, pending []manifestResource) (diff string, changed bool) {
+	before := make(map[string]string, len(deployed))
+	for _, r := range deployed {
+		before[r.key] = r.manifest
+	}
+	after := make(map[string]string, len(pending))
+	for _, r := range pending {
+		after[r.key] = r.manifest
+	}
+	keys := make([]string, 0, len(before)+len(after))
+	for _, r := range deployed {
+		keys = append(keys, r.key)
+	}
+	for _, r := range pending {
+		if _, found := before[r.key]; !found {
+			keys = append(keys, r.key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		from, inDeployed := before[key]
+		to, inPending := after[key]
+		switch {
+		case !inPending:
+			fmt.Fprintf(&sb, "- %s removed\n", key)
+		case !inDeployed:
+			fmt.Fprintf(&sb, "+ %s added\n", key)
+		case from == to:
+			fmt.Fprintf(&sb, "  %s unchanged\n", key)
+			continue
+		default:
+			fmt.Fprintf(&sb, "~ %s changed\n", key)
+		}
+		changed = true
+		sb.WriteString(util.UnifiedDiff(key+" (deployed)", key+" (pending)", from, to, 3))
+	}
+	return sb.String(), changed
+}
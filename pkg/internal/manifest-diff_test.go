@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateArgs(t *testing.T) {
+	t.Parallel()
+
+	upgrade := []string{"--kube-context", "kind-worker-1", "upgrade", "-i", "kubeslice-worker", "kubeslice/kubeslice-worker", "--namespace", "kubeslice-system", "--create-namespace", "-f", "values.yaml", "--wait", "--timeout", "5m0s", "--version", "1.0.0"}
+	expected := []string{"--kube-context", "kind-worker-1", "template", "--is-upgrade", "kubeslice-worker", "kubeslice/kubeslice-worker", "--namespace", "kubeslice-system", "-f", "values.yaml", "--version", "1.0.0"}
+	if got := templateArgs(upgrade); !reflect.DeepEqual(got, expected) {
+		t.Errorf("templateArgs() mismatch\nwant: %q\ngot:  %q", expected, got)
+	}
+}
+
+func TestManifestDiff(t *testing.T) {
+	t.Parallel()
+
+	deployed := `---
+# Source: worker/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: kubeslice-system
+data:
+  level: info
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+data:
+  token: b2xk
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: legacy
+`
+	pending := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  namespace: kubeslice-system
+  name: settings
+data:
+  level: info
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+data:
+  token: bmV3
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+`
+	parse := func(manifest string) []manifestResource {
+		resources, err := parseManifest([]byte(manifest))
+		if err != nil {
+			t.Fatalf("parseManifest() returned error: %v", err)
+		}
+		return resources
+	}
+	diff, changed := manifestDiff(parse(deployed), parse(pending))
+	if !changed {
+		t.Fatalf("manifestDiff() reported no changes")
+	}
+	for _, expected := range []string{"  ConfigMap/kubeslice-system/settings unchanged\n", "+ Deployment/operator added\n", "- Service/legacy removed\n", "~ Secret/credentials changed\n"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("manifestDiff() does not contain %q:\n%s", expected, diff)
+		}
+	}
+	for _, secret := range []string{"b2xk", "bmV3"} {
+		if strings.Contains(diff, secret) {
+			t.Errorf("manifestDiff() contains secret data %q:\n%s", secret, diff)
+		}
+	}
+
+	if _, changed := manifestDiff(parse(deployed), parse(deployed)); changed {
+		t.Errorf("manifestDiff() reported changes between identical manifests")
+	}
+}
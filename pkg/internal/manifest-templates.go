@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Files of the templates directory overriding the generated manifests. A
+// file renders a single object, the files which are not present fall back to
+// the generated manifests.
+const (
+	projectTemplateFile = "project.yaml.tmpl"
+	clusterTemplateFile = "cluster.yaml.tmpl"
+	sliceTemplateFile   = "slice.yaml.tmpl"
+)
+
+// ManifestTemplateData is the data the manifest templates are rendered with.
+// The metadata is the one the CLI generates, the topology labels and
+// annotations merged with the ones of the object.
+type ManifestTemplateData struct {
+	APIVersion  string
+	Kind        string
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Project the object belongs to, Namespace of a Project is the controller
+	// namespace while ProjectNamespace is the namespace of the project
+	Project          string
+	ProjectNamespace string
+	// project.yaml.tmpl: the users of the project
+	ReadWrite []string
+	ReadOnly  []string
+	// cluster.yaml.tmpl: the worker entry of the topology and the node IPs
+	Cluster Cluster
+	NodeIPs []string
+	// slice.yaml.tmpl: the slice with the defaults of the fields left out
+	Slice SliceConfiguration
+	// Spec is the spec the CLI generates, e.g. {{ toYaml .Spec | indent 2 }}
+	Spec interface{}
+}
+
+var manifestTemplateFuncs = template.FuncMap{
+	"toYaml": func(v interface{}) (string, error) {
+		out, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(out), "\n"), err
+	},
+	"indent": func(spaces int, s string) string {
+		padding := strings.Repeat(" ", spaces)
+		return padding + strings.ReplaceAll(s, "\n", "\n"+padding)
+	},
+	"quote": func(s string) string {
+		return fmt.Sprintf("%q", s)
+	},
+}
+
+// loadManifestTemplate returns the template of the templates directory
+// overriding a manifest, nil when the manifest is generated.
+func loadManifestTemplate(name string) (*template.Template, error) {
+	if Options.TemplatesDir == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(Options.TemplatesDir); err != nil {
+		return nil, fmt.Errorf("templates directory: %v", err)
+	}
+	path := filepath.Join(Options.TemplatesDir, name)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the template is named after its path, so that errors name the file and line
+	return template.New(path).Funcs(manifestTemplateFuncs).Option("missingkey=error").Parse(string(data))
+}
+
+// renderManifestTemplate renders one object with a manifest template and
+// decodes it into object, whose kind, name and namespace must be the ones of
+// the generated manifest.
+func renderManifestTemplate(tmpl *template.Template, data ManifestTemplateData, object interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	manifest := strings.TrimPrefix(out.String(), "---\n")
+	var meta struct {
+		Kind     string     `yaml:"kind"`
+		Metadata objectMeta `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(manifest), &meta); err != nil {
+		return "", fmt.Errorf("%s rendered an invalid manifest for %s %s: %v", tmpl.Name(), data.Kind, data.Name, err)
+	}
+	if err := yaml.Unmarshal([]byte(manifest), object); err != nil {
+		return "", fmt.Errorf("%s rendered an invalid manifest for %s %s: %v", tmpl.Name(), data.Kind, data.Name, err)
+	}
+	switch {
+	case meta.Kind != data.Kind:
+		return "", fmt.Errorf("%s rendered a %s for %s %s, want a %s", tmpl.Name(), meta.Kind, data.Kind, data.Name, data.Kind)
+	case meta.Metadata.Name != data.Name || meta.Metadata.Namespace != data.Namespace:
+		return "", fmt.Errorf("%s rendered %s %s/%s, want %s/%s", tmpl.Name(), data.Kind, meta.Metadata.Namespace, meta.Metadata.Name, data.Namespace, data.Name)
+	}
+	if !strings.HasSuffix(manifest, "\n") {
+		manifest += "\n"
+	}
+	return manifest, nil
+}
+
+// validateRenderedProject checks the users of a rendered project like the
+// ones of the topology.
+func validateRenderedProject(tmpl *template.Template, project projectManifest) error {
+	for _, user := range append(append([]string{}, project.Spec.ServiceAccount.ReadWrite...), project.Spec.ServiceAccount.ReadOnly...) {
+		if err := validateName("user name", user, maxProjectUserLength); err != nil {
+			return fmt.Errorf("%s: %v", tmpl.Name(), err)
+		}
+	}
+	return nil
+}
+
+// validateRenderedSlice checks a rendered slice like the slice of the
+// topology, against the clusters of the generated one. Its QoS profile is
+// checked as rendered, the template has to write every field of it.
+func validateRenderedSlice(tmpl *template.Template, slice sliceConfigManifest, clusters []string) error {
+	configured := SliceConfiguration{
+		Name:        slice.Metadata.Name,
+		SliceSubnet: slice.Spec.SliceSubnet,
+		Clusters:    slice.Spec.Clusters,
+	}
+	for _, ns := range slice.Spec.NamespaceIsolationProfile.ApplicationNamespaces {
+		configured.ApplicationNamespaces = append(configured.ApplicationNamespaces, SliceNamespace(ns))
+	}
+	for _, ns := range slice.Spec.NamespaceIsolationProfile.AllowedNamespaces {
+		configured.AllowedNamespaces = append(configured.AllowedNamespaces, SliceNamespace(ns))
+	}
+	errors := append(validateSlice(configured, clusters), validateQoSProfileDetails(configured.Name, slice.Spec.QosProfileDetails)...)
+	if len(errors) > 0 {
+		return fmt.Errorf("%s rendered an invalid slice:\n%s", tmpl.Name(), strings.Join(errors, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestSampleManifestTemplates(t *testing.T) {
+	defer func(dir string) { Options.TemplatesDir = dir }(Options.TemplatesDir)
+	Options.TemplatesDir = filepath.Join("..", "..", "samples", "templates")
+
+	ksc := KubeSliceConfiguration{ProjectName: "demo", Labels: map[string]string{"team": "platform"}}
+	manifest, err := kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, ksc)
+	if err != nil {
+		t.Fatalf("kubesliceProjectManifest() returned error: %v", err)
+	}
+	var project struct {
+		Metadata objectMeta `yaml:"metadata"`
+		Spec     struct {
+			ServiceAccount       projectServiceAccount `yaml:"serviceAccount"`
+			DefaultSliceCreation *bool                 `yaml:"defaultSliceCreation"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(manifest), &project); err != nil {
+		t.Fatalf("rendered project is not valid YAML: %v\n%s", err, manifest)
+	}
+	if project.Metadata.Annotations["example.com/owner"] != "demo" || project.Metadata.Labels["team"] != "platform" ||
+		project.Spec.DefaultSliceCreation == nil || project.Spec.ServiceAccount.ReadWrite[0] != "admin" {
+		t.Errorf("rendered project lacks the fields of the template or the generated ones:\n%s", manifest)
+	}
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: ksc,
+		ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1", ContextName: "ctx-1", NodeIPs: []string{"10.0.0.1"}}}},
+	}}
+	manifest, err = clusterRegistrationManifest(specs, "")
+	if err != nil {
+		t.Fatalf("clusterRegistrationManifest() returned error: %v", err)
+	}
+	var cluster clusterManifest
+	if err := yaml.Unmarshal([]byte(manifest), &cluster); err != nil {
+		t.Fatalf("rendered cluster is not valid YAML: %v\n%s", err, manifest)
+	}
+	if cluster.Metadata.Annotations["example.com/context"] != "ctx-1" || cluster.Spec.NodeIPs[0] != "10.0.0.1" {
+		t.Errorf("rendered cluster lacks the fields of the template or the generated ones:\n%s", manifest)
+	}
+
+	manifest, err = sliceConfigurationManifest(ksc, configuredSlice(specs, nil, ""), "kubeslice-demo")
+	if err != nil {
+		t.Fatalf("sliceConfigurationManifest() returned error: %v", err)
+	}
+	if !strings.Contains(manifest, "maxClusters: 16") || !strings.Contains(manifest, "sliceSubnet: 10.1.0.0/16") {
+		t.Errorf("rendered slice lacks the fields of the template or the generated ones:\n%s", manifest)
+	}
+}
+
+func TestManifestTemplateErrors(t *testing.T) {
+	defer func(dir string) { Options.TemplatesDir = dir }(Options.TemplatesDir)
+
+	tests := []struct {
+		name     string
+		file     string
+		template string
+		expected string
+	}{
+		{
+			name:     "Parse error",
+			file:     projectTemplateFile,
+			template: "kind: Project\nmetadata:\n  name: {{ .Name }\n",
+			expected: projectTemplateFile + ":3:",
+		},
+		{
+			name:     "Unknown field",
+			file:     projectTemplateFile,
+			template: "kind: Project\nmetadata:\n  name: {{ .ProjectLabel }}\n",
+			expected: projectTemplateFile + ":3:",
+		},
+		{
+			name:     "Renamed object",
+			file:     projectTemplateFile,
+			template: "kind: Project\nmetadata:\n  name: other\n  namespace: {{ .Namespace }}\n",
+			expected: "rendered Project kubeslice-controller/other, want kubeslice-controller/demo",
+		},
+		{
+			name:     "Invalid slice",
+			file:     sliceTemplateFile,
+			template: "kind: SliceConfig\nmetadata:\n  name: {{ .Name }}\n  namespace: {{ .Namespace }}\nspec:\n  sliceSubnet: 10.1.0.0\n",
+			expected: `slice subnet "10.1.0.0" of slice demo is not a valid CIDR`,
+		},
+		{
+			name: "QoS profile without a bandwidth ceiling",
+			file: sliceTemplateFile,
+			template: "kind: SliceConfig\nmetadata:\n  name: {{ .Name }}\n  namespace: {{ .Namespace }}\nspec:\n  sliceSubnet: 10.1.0.0/16\n" +
+				"  qosProfileDetails:\n    queueType: HTB\n    priority: 1\n    bandwidthGuaranteedKbps: 2560\n    dscpClass: AF11\n",
+			expected: "bandwidth ceiling 0 kbps of slice demo must be at least the guaranteed bandwidth 2560 kbps",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.TemplatesDir = t.TempDir()
+			if err := os.WriteFile(filepath.Join(Options.TemplatesDir, tc.file), []byte(tc.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ksc := KubeSliceConfiguration{ProjectName: "demo"}
+			var err error
+			if tc.file == sliceTemplateFile {
+				_, err = sliceConfigurationManifest(ksc, DemoSlice, "kubeslice-demo")
+			} else {
+				_, err = kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, ksc)
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("error = %v, want an error containing %q", err, tc.expected)
+			}
+			// the manifests without a template are generated
+			if _, err := clusterRegistrationManifest(&ConfigurationSpecs{Configuration: Configuration{KubeSliceConfiguration: ksc}}, ""); err != nil {
+				t.Errorf("clusterRegistrationManifest() without a template returned error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// The custom resources of the KubeSlice controller the manifests are
+// generated for. They are marshalled rather than templated, so that names and
+// values need no quoting or indentation of their own. Their apiVersion is
+// detected from the controller cluster, see api-versions.go.
+
+type objectMeta struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type projectManifest struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   objectMeta  `yaml:"metadata"`
+	Spec       projectSpec `yaml:"spec"`
+}
+
+type projectSpec struct {
+	ServiceAccount projectServiceAccount `yaml:"serviceAccount"`
+}
+
+type projectServiceAccount struct {
+	ReadWrite []string `yaml:"readWrite,omitempty"`
+	ReadOnly  []string `yaml:"readOnly,omitempty"`
+}
+
+type clusterManifest struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   objectMeta  `yaml:"metadata"`
+	Spec       clusterSpec `yaml:"spec"`
+}
+
+type clusterSpec struct {
+	NodeIPs         []string        `yaml:"nodeIPs,omitempty"`
+	ClusterProperty clusterProperty `yaml:"clusterProperty"`
+}
+
+type clusterProperty struct {
+	GeoLocation *geoLocation `yaml:"geoLocation,omitempty"`
+}
+
+type geoLocation struct {
+	CloudProvider string `yaml:"cloudProvider"`
+	CloudRegion   string `yaml:"cloudRegion"`
+	Latitude      string `yaml:"latitude"`
+	Longitude     string `yaml:"longitude"`
+}
+
+type sliceConfigManifest struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   objectMeta      `yaml:"metadata"`
+	Spec       sliceConfigSpec `yaml:"spec"`
+}
+
+type sliceConfigSpec struct {
+	SliceSubnet               string                    `yaml:"sliceSubnet"`
+	SliceType                 string                    `yaml:"sliceType"`
+	SliceGatewayProvider      sliceGatewayProvider      `yaml:"sliceGatewayProvider"`
+	SliceIpamType             string                    `yaml:"sliceIpamType"`
+	Clusters                  []string                  `yaml:"clusters"`
+	QosProfileDetails         qosProfileDetails         `yaml:"qosProfileDetails"`
+	NamespaceIsolationProfile namespaceIsolationProfile `yaml:"namespaceIsolationProfile"`
+}
+
+type sliceGatewayProvider struct {
+	SliceGatewayType string `yaml:"sliceGatewayType"`
+	SliceCaType      string `yaml:"sliceCaType"`
+}
+
+type qosProfileDetails struct {
+	QueueType               string `yaml:"queueType"`
+	Priority                int    `yaml:"priority"`
+	TcType                  string `yaml:"tcType"`
+	BandwidthCeilingKbps    int    `yaml:"bandwidthCeilingKbps"`
+	BandwidthGuaranteedKbps int    `yaml:"bandwidthGuaranteedKbps"`
+	DscpClass               string `yaml:"dscpClass"`
+}
+
+type namespaceIsolationProfile struct {
+	IsolationEnabled      bool             `yaml:"isolationEnabled,omitempty"`
+	ApplicationNamespaces []sliceNamespace `yaml:"applicationNamespaces"`
+	AllowedNamespaces     []sliceNamespace `yaml:"allowedNamespaces,omitempty"`
+}
+
+type sliceNamespace struct {
+	Namespace string   `yaml:"namespace"`
+	Clusters  []string `yaml:"clusters"`
+}
+
+// manifestOutput is where dry runs print the manifests they would apply.
+var manifestOutput io.Writer = os.Stdout
+
+// printDryRunManifest prints the manifest of a dry run. Every manifest starts
+// with a document separator, so that the output of several commands is one
+// stream of documents.
+func printDryRunManifest(manifest string) {
+	if !strings.HasSuffix(manifest, "\n") {
+		manifest += "\n"
+	}
+	fmt.Fprintf(manifestOutput, "---\n%s", strings.TrimPrefix(manifest, "---\n"))
+}
+
+// PrintManifestFile prints the manifest passed with -f in a dry run instead
+// of applying it. Directories and URLs would need kubectl, and are rejected.
+func PrintManifestFile(filename string) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		util.Fatalf("%s Unable to read %s for the dry run: %v", util.Cross, filename, err)
+	}
+	printDryRunManifest(string(data))
+}
+
+// marshalManifests returns the YAML documents of the objects.
+func marshalManifests(objects ...interface{}) (string, error) {
+	documents := make([]string, 0, len(objects))
+	for _, object := range objects {
+		out, err := yaml.Marshal(object)
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, string(out))
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+type serviceExportConfigManifest struct {
+	APIVersion string                  `yaml:"apiVersion"`
+	Kind       string                  `yaml:"kind"`
+	Metadata   objectMeta              `yaml:"metadata"`
+	Spec       serviceExportConfigSpec `yaml:"spec"`
+}
+
+type serviceExportConfigSpec struct {
+	ServiceName           string                 `yaml:"serviceName"`
+	ServiceNamespace      string                 `yaml:"serviceNamespace"`
+	SourceCluster         string                 `yaml:"sourceCluster"`
+	SliceName             string                 `yaml:"sliceName"`
+	ServiceDiscoveryPorts []serviceDiscoveryPort `yaml:"serviceDiscoveryPorts"`
+}
+
+type serviceDiscoveryPort struct {
+	Name     string `yaml:"name"`
+	Port     int    `yaml:"port"`
+	Protocol string `yaml:"protocol"`
+}
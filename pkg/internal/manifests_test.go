@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// decodeManifests returns the documents of a manifest, so that generated
+// manifests are compared by what kubectl apply reads from them.
+func decodeManifests(t *testing.T, manifest string) []interface{} {
+	t.Helper()
+	documents := make([]interface{}, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(manifest)))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return documents
+		}
+		if err != nil {
+			t.Fatalf("manifest is not valid YAML: %v\n%s", err, manifest)
+		}
+		if document != nil {
+			documents = append(documents, document)
+		}
+	}
+}
+
+func TestGeneratedManifests(t *testing.T) {
+	t.Parallel()
+
+	demo := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			Profile:        ProfileEntDemo,
+			WorkerClusters: []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+	}}
+	topology := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1"}}},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+	}}
+	project := func() (string, error) {
+		return kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, KubeSliceConfiguration{ProjectName: "demo", ProjectUsers: []string{"alice", "bob"}})
+	}
+	enterpriseClusters := func() (string, error) { return clusterRegistrationManifest(demo, "") }
+	clusters := func() (string, error) { return clusterRegistrationManifest(topology, "kubeslice-custom") }
+	slice := func() (string, error) {
+		return sliceConfigurationManifest(KubeSliceConfiguration{}, configuredSlice(demo, nil, ""), "kubeslice-demo")
+	}
+	iPerfExport := func() (string, error) {
+		return serviceExportConfigurationManifest(iPerfServiceExport(demo), "kubeslice-demo")
+	}
+
+	// golden are the manifests the fmt.Sprintf templates generated
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		golden   string
+	}{
+		{
+			name:     "Project",
+			generate: project,
+			golden: `
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: Project
+metadata:
+  name: demo
+  namespace: kubeslice-controller
+spec:
+  serviceAccount:
+    readWrite:
+      - alice
+      - bob
+`,
+		},
+		{
+			name:     "Enterprise demo clusters",
+			generate: enterpriseClusters,
+			golden: `
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: Cluster
+metadata:
+  name: ks-w-1
+  namespace: kubeslice-demo
+spec:
+  clusterProperty:
+    geoLocation:
+      cloudProvider: GCP
+      cloudRegion: custom
+      latitude: "36.7783"
+      longitude: "-119.4179"
+
+---
+
+
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: Cluster
+metadata:
+  name: ks-w-2
+  namespace: kubeslice-demo
+spec:
+  clusterProperty:
+    geoLocation:
+      cloudProvider: DATACENTER
+      cloudRegion: custom
+      latitude: "40.6976633"
+      longitude: "-74.1201054"
+
+---
+
+`,
+		},
+		{
+			name:     "Clusters",
+			generate: clusters,
+			golden: `
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: Cluster
+metadata:
+  name: worker-1
+  namespace: kubeslice-custom
+spec:
+  clusterProperty: {}
+---
+
+`,
+		},
+		{
+			name:     "SliceConfig",
+			generate: slice,
+			golden: `
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: SliceConfig
+metadata:
+  name: demo
+  namespace: kubeslice-demo
+spec:
+  sliceSubnet: 10.1.0.0/16
+  sliceType: Application
+  sliceGatewayProvider:
+    sliceGatewayType: OpenVPN
+    sliceCaType: Local
+  sliceIpamType: Local
+  clusters: [ks-w-1,ks-w-2]
+  qosProfileDetails:
+    queueType: HTB
+    priority: 1
+    tcType: BANDWIDTH_CONTROL
+    bandwidthCeilingKbps: 5120
+    bandwidthGuaranteedKbps: 2560
+    dscpClass: AF11
+  namespaceIsolationProfile:
+   applicationNamespaces:
+    - namespace: iperf
+      clusters:
+      - '*'
+`,
+		},
+		{
+			name:     "iPerf ServiceExportConfig",
+			generate: iPerfExport,
+			// the port is named after its protocol and number now
+			golden: `
+---
+apiVersion: controller.kubeslice.io/v1alpha1
+kind: ServiceExportConfig
+metadata:
+  name: iperf-server-iperf-ks-w-1
+  namespace: kubeslice-demo
+spec:
+  serviceName: iperf-server
+  serviceNamespace: iperf
+  sourceCluster: ks-w-1
+  sliceName: demo
+  serviceDiscoveryPorts:
+  - name: tcp-5201
+    port: 5201
+    protocol: TCP
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifest, err := tc.generate()
+			if err != nil {
+				t.Fatalf("generating the manifest returned error: %v", err)
+			}
+			if got, want := decodeManifests(t, manifest), decodeManifests(t, tc.golden); !reflect.DeepEqual(got, want) {
+				t.Errorf("manifest =\n%s\nwant the documents of\n%s", manifest, tc.golden)
+			}
+		})
+	}
+}
+
+func TestMarshalManifestsQuoting(t *testing.T) {
+	t.Parallel()
+
+	// names the templates could not carry, which now round trip unchanged
+	names := []string{"key: value", `it's "quoted"`, "ünïcödé-クラスタ", "- dash", "# comment"}
+	manifest, err := sliceConfigurationManifest(KubeSliceConfiguration{}, SliceConfiguration{Name: "demo", SliceSubnet: DemoSlice.SliceSubnet, Clusters: names}, "kubeslice-demo")
+	if err != nil {
+		t.Fatalf("sliceConfigurationManifest() returned error: %v", err)
+	}
+	var slice sliceConfigManifest
+	if err := yaml.Unmarshal([]byte(manifest), &slice); err != nil {
+		t.Fatalf("sliceConfigurationManifest() is not valid YAML: %v\n%s", err, manifest)
+	}
+	if !reflect.DeepEqual(slice.Spec.Clusters, names) {
+		t.Errorf("clusters = %q, want %q", slice.Spec.Clusters, names)
+	}
+}
+
+func TestPrintDryRunManifests(t *testing.T) {
+	var out bytes.Buffer
+	defer func(output io.Writer) { manifestOutput = output }(manifestOutput)
+	manifestOutput = &out
+
+	project, err := kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, KubeSliceConfiguration{ProjectName: "demo"})
+	if err != nil {
+		t.Fatalf("kubesliceProjectManifest() returned error: %v", err)
+	}
+	clusters, err := clusterRegistrationManifest(&ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1"}, {Name: "worker-2"}}},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+	}}, "")
+	if err != nil {
+		t.Fatalf("clusterRegistrationManifest() returned error: %v", err)
+	}
+	// a manifest passed with -f, without a trailing newline
+	file := filepath.Join(t.TempDir(), "slice.yaml")
+	if err := os.WriteFile(file, []byte("---\napiVersion: controller.kubeslice.io/v1alpha1\nkind: SliceConfig"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	printDryRunManifest(project)
+	printDryRunManifest(clusters)
+	PrintManifestFile(file)
+
+	kinds := make([]string, 0)
+	for _, document := range decodeManifests(t, out.String()) {
+		kinds = append(kinds, document.(map[interface{}]interface{})["kind"].(string))
+	}
+	if expected := []string{"Project", "Cluster", "Cluster", "SliceConfig"}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("kinds of the printed documents = %v, want %v", kinds, expected)
+	}
+	if !strings.HasPrefix(out.String(), "---\n") || strings.Contains(out.String(), "---\n---") {
+		t.Errorf("printed manifests =\n%s\nwant one separator before every document", out.String())
+	}
+}
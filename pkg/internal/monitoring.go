@@ -0,0 +1,380 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// The chart monitoring installs when no Prometheus Operator runs on the
+// controller cluster, and the CRD an existing one is detected by.
+const (
+	monitoringChartName = "kube-prometheus-stack"
+	monitoringRepoAlias = "prometheus-community"
+	monitoringRepoUrl   = "https://prometheus-community.github.io/helm-charts"
+	monitoringNamespace = "monitoring"
+	serviceMonitorCRD   = "servicemonitors.monitoring.coreos.com"
+	// the Prometheus Operator labels the service of every Prometheus it runs
+	prometheusServiceSelector = "operated-prometheus=true"
+	prometheusService         = "prometheus-operated:9090"
+	// the workers remote-write the metrics of their operators to the
+	// Prometheus of the controller on this port of its nodes
+	remoteWriteNodePort = 32710
+)
+
+// The values enabling the metrics of the KubeSlice operators, set in the
+// generated values of their charts when monitoring is enabled.
+var (
+	controllerMetricsValues = []string{"kubeslice.controller.metrics.enabled"}
+	workerMetricsValues     = []string{"metrics.enabled"}
+)
+
+// monitoringValues make the installed Prometheus select the ServiceMonitors of
+// every release, not only those of its own, and receive the metrics the
+// workers remote-write to it.
+var monitoringValues = fmt.Sprintf(`
+prometheus:
+  service:
+    type: NodePort
+    nodePort: %d
+  prometheusSpec:
+    serviceMonitorSelectorNilUsesHelmValues: false
+    enableRemoteWriteReceiver: true
+`, remoteWriteNodePort)
+
+// workerMonitoringValuesTemplate makes the Prometheus installed on a worker
+// scrape its ServiceMonitors only and remote-write the metrics of the worker
+// namespace to the Prometheus of the controller, labelled with the cluster.
+const workerMonitoringValuesTemplate = `
+defaultRules:
+  create: false
+alertmanager:
+  enabled: false
+grafana:
+  enabled: false
+kubeStateMetrics:
+  enabled: false
+nodeExporter:
+  enabled: false
+prometheus:
+  prometheusSpec:
+    serviceMonitorSelectorNilUsesHelmValues: false
+    retention: 2h
+    externalLabels:
+      cluster: %[1]s
+    remoteWrite:
+    - url: http://%[2]s:%[3]d/api/v1/write
+      writeRelabelConfigs:
+      - sourceLabels: [namespace]
+        regex: %[4]s
+        action: keep
+`
+
+const serviceMonitorTemplate = `
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+  labels:
+    app.kubernetes.io/part-of: kubeslice
+    release: %[3]s
+spec:
+  namespaceSelector:
+    matchNames:
+    - %[2]s
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  endpoints:
+  - port: https
+    scheme: https
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    tlsConfig:
+      insecureSkipVerify: true
+`
+
+// serviceMonitor is the ServiceMonitor of the operator of a KubeSlice chart on
+// a cluster.
+type serviceMonitor struct {
+	cluster   Cluster
+	name      string
+	namespace string
+}
+
+// monitoringChart returns the chart of the monitoring section with its
+// defaults filled in.
+func monitoringChart(mc MonitoringConfiguration) HelmChart {
+	chart := mc.Chart
+	if chart.ChartName == "" {
+		chart.ChartName = monitoringChartName
+	}
+	if chart.RepoUrl == "" && chart.LocalPath == "" {
+		chart.RepoAlias, chart.RepoUrl = monitoringRepoAlias, monitoringRepoUrl
+	}
+	return chart
+}
+
+// monitoringReleaseNamespace returns the namespace the monitoring chart is
+// installed to.
+func monitoringReleaseNamespace(mc MonitoringConfiguration) string {
+	if mc.Namespace != "" {
+		return mc.Namespace
+	}
+	return monitoringNamespace
+}
+
+// withMetricsValues returns the defaults of a chart with the metrics values
+// enabled.
+func withMetricsValues(defaults string, paths []string) (string, error) {
+	values := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		values[path] = true
+	}
+	return withValues(defaults, values)
+}
+
+// withValues returns the defaults of a chart with the values of the dotted
+// paths set.
+func withValues(defaults string, values map[string]interface{}) (string, error) {
+	defaultsMap, err := parseValues([]byte(defaults))
+	if err != nil {
+		return "", err
+	}
+	for path, value := range values {
+		setValuePath(defaultsMap, path, value)
+	}
+	data, err := yaml.Marshal(defaultsMap)
+	return string(data), err
+}
+
+// kubesliceServiceMonitors returns the ServiceMonitors of the controller and
+// of the touched workers.
+func kubesliceServiceMonitors(specs *ConfigurationSpecs) []serviceMonitor {
+	cc := specs.Configuration.ClusterConfiguration
+	monitors := []serviceMonitor{{cc.ControllerCluster, "kubeslice-controller-metrics", ControllerNamespace(cc.ControllerCluster)}}
+	for _, cluster := range touchedWorkers(cc.WorkerClusters) {
+		monitors = append(monitors, serviceMonitor{cluster, "kubeslice-worker-metrics", WorkerNamespace(cluster)})
+	}
+	return monitors
+}
+
+// InstallMonitoring installs the monitoring section of the topology: the
+// monitoring chart on the controller cluster, unless a Prometheus Operator
+// runs there already, and the ServiceMonitors of the controller and of the
+// workers. The workers without a Prometheus Operator get the monitoring chart
+// too, scraping their operator and remote-writing its metrics to the
+// Prometheus installed on the controller.
+func InstallMonitoring(specs *ConfigurationSpecs) {
+	util.Printf("\nInstalling monitoring...")
+	mc := specs.Configuration.Monitoring
+	controller := specs.Configuration.ClusterConfiguration.ControllerCluster
+	chart := monitoringChart(mc)
+	namespace := monitoringReleaseNamespace(mc)
+	release, err := findRelease(controller, chart.ChartName, namespace)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	existing, err := objectExists(&controller, crdResource, "", serviceMonitorCRD)
+	if err != nil {
+		util.Fatalf("%s Unable to look up CRD %s on %s: %v", util.Cross, serviceMonitorCRD, controller.Name, err)
+	}
+	hc := specs.Configuration.HelmChartConfiguration
+	// a release of a previous run is upgraded
+	receiver := !existing || release != nil
+	if receiver {
+		installMonitoringChart(InstallationName(specs), controller, hc, chart, namespace, monitoringValues)
+	} else {
+		util.Printf("%s Detected the Prometheus Operator on %s, only the ServiceMonitors are created", util.Tick, controller.Name)
+	}
+	installWorkerMonitoring(specs, chart, namespace, receiver)
+	applyServiceMonitors(kubesliceServiceMonitors(specs), chart.ChartName)
+}
+
+// installWorkerMonitoring installs the monitoring chart on the touched workers
+// without a Prometheus Operator, remote-writing to the Prometheus installed on
+// the controller. Workers sharing the cluster of the controller are scraped by
+// its Prometheus.
+func installWorkerMonitoring(specs *ConfigurationSpecs, chart HelmChart, namespace string, receiver bool) {
+	cc := specs.Configuration.ClusterConfiguration
+	controller := cc.ControllerCluster
+	endpoint := ""
+	for _, cluster := range touchedWorkers(cc.WorkerClusters) {
+		if cluster.ContextName == controller.ContextName && cluster.KubeConfigPath == controller.KubeConfigPath {
+			continue
+		}
+		served, err := objectExists(&cluster, crdResource, "", serviceMonitorCRD)
+		if err != nil {
+			util.Fatalf("%s Unable to look up CRD %s on %s: %v", util.Cross, serviceMonitorCRD, cluster.Name, err)
+		}
+		if served {
+			util.Printf("%s Detected the Prometheus Operator on %s, the metrics of its worker stay in its Prometheus", util.Warn, cluster.Name)
+			continue
+		}
+		if !receiver {
+			util.Printf("%s The Prometheus of %s is not installed by kubeslice-cli, the metrics of worker %s are not forwarded to it", util.Warn, controller.Name, cluster.Name)
+			continue
+		}
+		if endpoint == "" {
+			if endpoint = controller.NodeIP; endpoint == "" {
+				if endpoint, err = getNodeIP(&controller); err != nil {
+					util.Fatalf("%s Unable to look up the node address of %s for the remote write of the workers: %v", util.Cross, controller.Name, err)
+				}
+			}
+		}
+		installMonitoringChart(InstallationName(specs), cluster, specs.Configuration.HelmChartConfiguration, chart, namespace, workerMonitoringValues(cluster, endpoint))
+	}
+}
+
+// workerMonitoringValues returns the defaults of the monitoring chart of a
+// worker remote-writing to the Prometheus of the controller at endpoint.
+func workerMonitoringValues(cluster Cluster, endpoint string) string {
+	return fmt.Sprintf(workerMonitoringValuesTemplate, cluster.Name, endpoint, remoteWriteNodePort, WorkerNamespace(cluster))
+}
+
+func installMonitoringChart(installation string, cluster Cluster, hc HelmChartConfiguration, chart HelmChart, namespace, defaults string) {
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cluster, chart)
+	verifyValueKeys(hc, &chart)
+	err := generateValuesFile(valuesFile, &chart, defaults, propagatedImagePullSecrets(hc))
+	if err == nil {
+		err = rewriteImageRegistry(hc, &chart, valuesFile)
+	}
+	if err != nil {
+		util.Fatalf("%s %s", util.Cross, err)
+	}
+	util.Printf("%s Generated Helm Values file for the monitoring installation %s", util.Tick, valuesFile)
+	if chart.LocalPath == "" {
+		cleanup := ensureHelmRepo(hc.forChart(chart))
+		defer cleanup()
+	}
+	showValuesDiff(cluster, chart.ChartName, namespace, valuesFile)
+	installRelease(releaseRequest{Cluster: cluster, Release: chart.ChartName, Namespace: namespace, Chart: chart, Repo: hc, ValuesFile: valuesFile, CreateNamespace: true})
+	recordValuesChecksum(installation, cluster, chart.ChartName, valuesFile)
+	util.Printf("%s Successfully installed helm chart %s on cluster %s", util.Tick, chartReference(hc, chart), cluster.Name)
+	time.Sleep(200 * time.Millisecond)
+	PodVerification("Waiting for the monitoring Pods to be Healthy", cluster, namespace)
+}
+
+// applyServiceMonitors creates the ServiceMonitors on the clusters serving the
+// ServiceMonitor CRD, the others are reported as not scraped.
+func applyServiceMonitors(monitors []serviceMonitor, release string) {
+	GenerateKubeSliceDirectory()
+	for _, m := range monitors {
+		cluster := m.cluster
+		served, err := objectExists(&cluster, crdResource, "", serviceMonitorCRD)
+		if err != nil {
+			util.Fatalf("%s Unable to look up CRD %s on %s: %v", util.Cross, serviceMonitorCRD, cluster.Name, err)
+		}
+		if !served {
+			util.Printf("%s %s runs no Prometheus Operator, the metrics of %s are not scraped", util.Warn, cluster.Name, m.name)
+			continue
+		}
+		fileName := kubesliceDirectory + "/" + cluster.Name + "-" + m.name + ".yaml"
+		util.DumpFile(fmt.Sprintf(serviceMonitorTemplate, m.name, m.namespace, release), fileName)
+		if err := kubeClient.Apply(&cluster, m.namespace, fileName); err != nil {
+			util.Fatalf("%s Unable to create ServiceMonitor %s on %s: %v", util.Cross, m.name, cluster.Name, err)
+		}
+		util.Printf("%s Created ServiceMonitor %s in namespace %s of %s", util.Tick, m.name, m.namespace, cluster.Name)
+	}
+}
+
+// prometheusTargets is the response of the targets API of Prometheus.
+type prometheusTargets struct {
+	Data struct {
+		ActiveTargets []struct {
+			ScrapePool string `json:"scrapePool"`
+			Health     string `json:"health"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// scrapedTargets returns the number of targets of a ServiceMonitor Prometheus
+// scrapes, and how many of them are up.
+func scrapedTargets(targets prometheusTargets, namespace, name string) (up, total int) {
+	pool := fmt.Sprintf("serviceMonitor/%s/%s/", namespace, name)
+	for _, target := range targets.Data.ActiveTargets {
+		if strings.HasPrefix(target.ScrapePool, pool) {
+			total++
+			if target.Health == "up" {
+				up++
+			}
+		}
+	}
+	return up, total
+}
+
+// clusterPrometheusTargets queries the targets of the Prometheus instances the
+// Prometheus Operator runs on a cluster through its API server.
+func clusterPrometheusTargets(cluster Cluster) ([]prometheusTargets, error) {
+	out, err := runKubectl(cluster, "get", "services", "-A", "-l", prometheusServiceSelector, "-o", `jsonpath={range .items[*]}{.metadata.namespace}{"\n"}{end}`)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := strings.Fields(out)
+	sort.Strings(namespaces)
+	all := make([]prometheusTargets, 0, len(namespaces))
+	for _, ns := range namespaces {
+		out, err := runKubectl(cluster, "get", "--raw", fmt.Sprintf("/api/v1/namespaces/%s/services/%s/proxy/api/v1/targets?state=active", ns, prometheusService))
+		if err != nil {
+			return nil, fmt.Errorf("unable to query the targets of Prometheus in namespace %s: %v", ns, err)
+		}
+		var targets prometheusTargets
+		if err := json.Unmarshal([]byte(out), &targets); err != nil {
+			return nil, fmt.Errorf("unable to decode the targets of Prometheus in namespace %s: %v", ns, err)
+		}
+		all = append(all, targets)
+	}
+	return all, nil
+}
+
+// ShowMonitoringStatus reports whether the metrics of the KubeSlice operators
+// are scraped, when the topology enables monitoring. It returns false when the
+// metrics of an operator are not, or not all, scraped.
+func ShowMonitoringStatus(specs *ConfigurationSpecs) bool {
+	if !specs.Configuration.Monitoring.Enabled {
+		return true
+	}
+	util.Printf("\nMetrics:")
+	healthy := true
+	for _, m := range kubesliceServiceMonitors(specs) {
+		cluster := m.cluster
+		exists, err := objectExists(&cluster, serviceMonitorCRD, m.namespace, m.name)
+		if err != nil {
+			util.Printf("%s %s: %v", util.Cross, cluster.Name, err)
+			healthy = false
+			continue
+		}
+		if !exists {
+			util.Printf("%s %s: ServiceMonitor %s is not created, the metrics are not scraped", util.Cross, cluster.Name, m.name)
+			healthy = false
+			continue
+		}
+		all, err := clusterPrometheusTargets(cluster)
+		if err != nil {
+			util.Printf("%s %s: %v", util.Cross, cluster.Name, err)
+			healthy = false
+			continue
+		}
+		up, total := 0, 0
+		for _, targets := range all {
+			u, t := scrapedTargets(targets, m.namespace, m.name)
+			up, total = up+u, total+t
+		}
+		switch {
+		case total == 0:
+			util.Printf("%s %s: no Prometheus scrapes ServiceMonitor %s, check the serviceMonitorSelector of Prometheus", util.Cross, cluster.Name, m.name)
+			healthy = false
+		case up < total:
+			util.Printf("%s %s: %d of %d targets of %s are up", util.Cross, cluster.Name, up, total, m.name)
+			healthy = false
+		default:
+			util.Printf("%s %s: %d targets of %s scraped", util.Tick, cluster.Name, total, m.name)
+		}
+	}
+	return healthy
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithMetricsValues(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		defaults string
+		paths    []string
+		expected map[interface{}]interface{}
+	}{
+		{
+			name:     "Controller metrics join the controller defaults",
+			defaults: "kubeslice:\n  controller:\n    loglevel: info\n",
+			paths:    controllerMetricsValues,
+			expected: map[interface{}]interface{}{"kubeslice": map[interface{}]interface{}{"controller": map[interface{}]interface{}{
+				"loglevel": "info",
+				"metrics":  map[interface{}]interface{}{"enabled": true},
+			}}},
+		},
+		{
+			name:     "Worker metrics keep insecure",
+			defaults: "metrics:\n  insecure: true\n",
+			paths:    workerMetricsValues,
+			expected: map[interface{}]interface{}{"metrics": map[interface{}]interface{}{"insecure": true, "enabled": true}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := withMetricsValues(tc.defaults, tc.paths)
+			if err != nil {
+				t.Fatalf("withMetricsValues() error = %v", err)
+			}
+			values, err := parseValues([]byte(out))
+			if err != nil {
+				t.Fatalf("parseValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(values, tc.expected) {
+				t.Errorf("withMetricsValues() = %v, want %v", values, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMonitoringChart(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		mc       MonitoringConfiguration
+		expected HelmChart
+	}{
+		{
+			name:     "Defaults to kube-prometheus-stack",
+			mc:       MonitoringConfiguration{Enabled: true, Chart: HelmChart{Version: "55.5.0"}},
+			expected: HelmChart{ChartName: "kube-prometheus-stack", Version: "55.5.0", RepoAlias: "prometheus-community", RepoUrl: "https://prometheus-community.github.io/helm-charts"},
+		},
+		{
+			name:     "Local chart needs no repository",
+			mc:       MonitoringConfiguration{Enabled: true, Chart: HelmChart{ChartName: "prometheus", LocalPath: "charts/prometheus"}},
+			expected: HelmChart{ChartName: "prometheus", LocalPath: "charts/prometheus"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if chart := monitoringChart(tc.mc); !reflect.DeepEqual(chart, tc.expected) {
+				t.Errorf("monitoringChart() = %+v, want %+v", chart, tc.expected)
+			}
+		})
+	}
+}
+
+func TestScrapedTargets(t *testing.T) {
+	t.Parallel()
+	var targets prometheusTargets
+	for _, target := range []struct{ pool, health string }{
+		{"serviceMonitor/kubeslice-controller/kubeslice-controller-metrics/0", "up"},
+		{"serviceMonitor/kubeslice-controller/kubeslice-controller-metrics/0", "down"},
+		{"serviceMonitor/kubeslice-system/kubeslice-worker-metrics/0", "up"},
+		{"serviceMonitor/monitoring/kube-prometheus-stack-operator/0", "up"},
+	} {
+		targets.Data.ActiveTargets = append(targets.Data.ActiveTargets, struct {
+			ScrapePool string `json:"scrapePool"`
+			Health     string `json:"health"`
+		}{target.pool, target.health})
+	}
+	tests := []struct {
+		name      string
+		namespace string
+		monitor   string
+		up, total int
+	}{
+		{name: "Controller target down", namespace: "kubeslice-controller", monitor: "kubeslice-controller-metrics", up: 1, total: 2},
+		{name: "Worker scraped", namespace: "kubeslice-system", monitor: "kubeslice-worker-metrics", up: 1, total: 1},
+		{name: "Not scraped", namespace: "kubeslice-system", monitor: "kubeslice-controller-metrics"},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if up, total := scrapedTargets(targets, tc.namespace, tc.monitor); up != tc.up || total != tc.total {
+				t.Errorf("scrapedTargets() = %d, %d, want %d, %d", up, total, tc.up, tc.total)
+			}
+		})
+	}
+}
+
+func TestWorkerMonitoringValues(t *testing.T) {
+	t.Parallel()
+	values, err := parseValues([]byte(workerMonitoringValues(Cluster{Name: "ks-w-1"}, "172.18.0.2")))
+	if err != nil {
+		t.Fatalf("parseValues() error = %v", err)
+	}
+	spec := values["prometheus"].(map[interface{}]interface{})["prometheusSpec"].(map[interface{}]interface{})
+	expected := []interface{}{map[interface{}]interface{}{
+		"url": "http://172.18.0.2:32710/api/v1/write",
+		"writeRelabelConfigs": []interface{}{map[interface{}]interface{}{
+			"sourceLabels": []interface{}{"namespace"},
+			"regex":        "kubeslice-system",
+			"action":       "keep",
+		}},
+	}}
+	if !reflect.DeepEqual(spec["remoteWrite"], expected) {
+		t.Errorf("remoteWrite = %v, want %v", spec["remoteWrite"], expected)
+	}
+	if labels := spec["externalLabels"]; !reflect.DeepEqual(labels, map[interface{}]interface{}{"cluster": "ks-w-1"}) {
+		t.Errorf("externalLabels = %v, want cluster ks-w-1", labels)
+	}
+}
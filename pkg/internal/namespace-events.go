@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Which events of a namespace are printed when a step fails: the warnings
+// of the last minutes, newest first.
+var (
+	recentEventsWindow = 15 * time.Minute
+	recentEventsLimit  = 10
+)
+
+// namespaceEvent is an event of `kubectl get events -o json`.
+type namespaceEvent struct {
+	Type           string `json:"type"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+	Count          int    `json:"count"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	EventTime     time.Time `json:"eventTime"`
+	Series        struct {
+		LastObservedTime time.Time `json:"lastObservedTime"`
+	} `json:"series"`
+	Metadata struct {
+		CreationTimestamp time.Time `json:"creationTimestamp"`
+	} `json:"metadata"`
+}
+
+// lastSeen is when the event last occurred, events recorded with the
+// events.k8s.io API have no lastTimestamp.
+func (e namespaceEvent) lastSeen() time.Time {
+	for _, t := range []time.Time{e.LastTimestamp, e.Series.LastObservedTime, e.EventTime} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return e.Metadata.CreationTimestamp
+}
+
+// recentWarningEvents returns the Warning events of `kubectl get events -o
+// json` which occurred within window before now, newest first and at most
+// limit of them.
+func recentWarningEvents(data []byte, now time.Time, window time.Duration, limit int) ([]namespaceEvent, error) {
+	var list struct {
+		Items []namespaceEvent `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse the events: %v", err)
+	}
+	events := make([]namespaceEvent, 0)
+	for _, event := range list.Items {
+		if event.Type == "Warning" && !event.lastSeen().Before(now.Add(-window)) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].lastSeen().After(events[j].lastSeen()) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// formatEvents prints events as a table, with how long ago they were last
+// seen.
+func formatEvents(events []namespaceEvent, now time.Time) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tREASON\tOBJECT\tCOUNT\tMESSAGE\t")
+	for _, event := range events {
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t\n", now.Sub(event.lastSeen()).Truncate(time.Second), event.Reason, object, count, strings.TrimSpace(event.Message))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// fetchRecentWarningEvents reads the recent Warning events of a namespace.
+func fetchRecentWarningEvents(cluster *Cluster, namespace string) ([]namespaceEvent, error) {
+	var outB, errB bytes.Buffer
+	args := append(kubectlClusterArgs(cluster), "get", "events", "-n", namespace, "--sort-by=.lastTimestamp", "-o", "json")
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+		return nil, fmt.Errorf("unable to get the events of namespace %s: %s", namespace, commandError(err, &errB))
+	}
+	return recentWarningEvents(outB.Bytes(), time.Now(), recentEventsWindow, recentEventsLimit)
+}
+
+// printRecentEvents prints the recent Warning events of the namespace of a
+// step which failed, e.g. FailedScheduling or ImagePullBackOff, which the
+// logs of the pods do not tell. It is best effort, failures are only warned
+// about.
+func printRecentEvents(cluster *Cluster, namespace string) {
+	events, err := fetchRecentWarningEvents(cluster, namespace)
+	if err != nil {
+		util.Printf("%s %v", util.Warn, err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	name := "the current context"
+	if cluster != nil {
+		name = "cluster " + cluster.Name
+	}
+	util.Printf("\nRecent events in namespace %s on %s:\n%s", namespace, name, strings.TrimRight(util.Redact(formatEvents(events, time.Now())), "\n"))
+}
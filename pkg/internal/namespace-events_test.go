@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const namespaceEvents = `{"items": [
+	{"type": "Warning", "reason": "FailedScheduling", "message": "0/1 nodes are available: 1 Insufficient cpu.", "count": 4,
+		"involvedObject": {"kind": "Pod", "name": "controller-0"}, "lastTimestamp": "2026-10-14T10:58:00Z"},
+	{"type": "Normal", "reason": "Pulled", "message": "Container image pulled",
+		"involvedObject": {"kind": "Pod", "name": "controller-0"}, "lastTimestamp": "2026-10-14T10:59:00Z"},
+	{"type": "Warning", "reason": "BackOff", "message": "Back-off pulling image",
+		"involvedObject": {"kind": "Pod", "name": "webhook-0"}, "lastTimestamp": null, "eventTime": "2026-10-14T10:59:30.000000Z"},
+	{"type": "Warning", "reason": "FailedMount", "message": "secret not found",
+		"involvedObject": {"kind": "Pod", "name": "old-0"}, "lastTimestamp": "2026-10-14T09:00:00Z"},
+	{"type": "Warning", "reason": "Unhealthy", "message": "Readiness probe failed",
+		"involvedObject": {"kind": "Pod", "name": "worker-0"}, "metadata": {"creationTimestamp": "2026-10-14T10:50:00Z"}}
+]}`
+
+func TestRecentWarningEvents(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		data     string
+		limit    int
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "Warnings of the window newest first",
+			data:     namespaceEvents,
+			limit:    10,
+			expected: []string{"BackOff", "FailedScheduling", "Unhealthy"},
+		},
+		{
+			name:     "Limit",
+			data:     namespaceEvents,
+			limit:    1,
+			expected: []string{"BackOff"},
+		},
+		{
+			name:     "No events",
+			data:     `{"items": []}`,
+			limit:    10,
+			expected: []string{},
+		},
+		{
+			name: "Not JSON",
+			data: "No resources found",
+			err:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			events, err := recentWarningEvents([]byte(tc.data), now, 15*time.Minute, tc.limit)
+			if tc.err {
+				if err == nil {
+					t.Errorf("recentWarningEvents() returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("recentWarningEvents() returned error: %v", err)
+			}
+			reasons := make([]string, 0, len(events))
+			for _, event := range events {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tc.expected) {
+				t.Errorf("reasons = %v, want %v", reasons, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFormatEvents(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)
+	events, err := recentWarningEvents([]byte(namespaceEvents), now, 15*time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(formatEvents(events, now)), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[1]), " ") != "30s BackOff pod/webhook-0 1 Back-off pulling image" {
+		t.Errorf("formatEvents() =\n%s", strings.Join(lines, "\n"))
+	}
+}
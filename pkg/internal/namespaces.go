@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+type namespaceManifest struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   objectMeta `yaml:"metadata"`
+}
+
+// ControllerNamespace returns the namespace the controller is installed to on
+// the controller cluster.
+func ControllerNamespace(controllerCluster Cluster) string {
+	if controllerCluster.Namespace != "" {
+		return controllerCluster.Namespace
+	}
+	return KUBESLICE_CONTROLLER_NAMESPACE
+}
+
+// WorkerNamespace returns the namespace the worker is installed to on a worker
+// cluster.
+func WorkerNamespace(cluster Cluster) string {
+	if cluster.Namespace != "" {
+		return cluster.Namespace
+	}
+	return KUBESLICE_WORKER_NAMESPACE
+}
+
+// CreateNamespace creates or updates the namespace a component is installed
+// to with the labels and annotations of the topology and of the cluster,
+// instead of leaving it to helm --create-namespace which creates it without.
+// Applying it again to an existing namespace only updates its metadata.
+func CreateNamespace(cluster Cluster, namespace string, ksc KubeSliceConfiguration) {
+	fileName := fmt.Sprintf("%s/namespace-%s-%s.yaml", kubesliceDirectory, cluster.Name, namespace)
+	manifest, err := marshalManifests(namespaceManifest{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Metadata:   newObjectMeta(namespace, "", ksc, cluster.NamespaceMetadata),
+	})
+	if err != nil {
+		util.Fatalf("%s Unable to generate the manifest of namespace %s: %v", util.Cross, namespace, err)
+	}
+	util.DumpFile(manifest, fileName)
+	if err := kubeClient.Apply(&cluster, "", fileName); err != nil {
+		util.Fatalf("%s Unable to create namespace %s on cluster %s: %v", util.Cross, namespace, cluster.Name, err)
+	}
+	util.Printf("%s Created namespace %s on cluster %s", util.Tick, namespace, cluster.Name)
+}
+
+// ValidateNamespaces checks the namespace overrides of the controller and the
+// workers. The controller namespace must not be the namespace of a project.
+func ValidateNamespaces(config Configuration) []string {
+	errors := make([]string, 0)
+	cc := config.ClusterConfiguration
+	check := func(field string, cluster Cluster) {
+		if cluster.Namespace == "" {
+			return
+		}
+		if err := validateName(field, cluster.Namespace, 63); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+		}
+	}
+	check("configuration.cluster_configuration.controller.namespace", cc.ControllerCluster)
+	for _, project := range ProjectNames(config.KubeSliceConfiguration) {
+		if ControllerNamespace(cc.ControllerCluster) == projectNamespace(project) {
+			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.controller.namespace %s is the namespace of project %s", util.Cross, cc.ControllerCluster.Namespace, project))
+		}
+	}
+	for i, cluster := range cc.WorkerClusters {
+		check(fmt.Sprintf("configuration.cluster_configuration.workers[%d].namespace", i), cluster)
+	}
+	return errors
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCustomNamespaces(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+	kubesliceDirectory = t.TempDir()
+	rolledOut := []deploymentReadiness{{Name: "operator", Replicas: 1, Ready: 1, Updated: 1, Available: 1, Observed: true}}
+	fake := &fakeKubeClient{deployments: map[string][]deploymentReadiness{"team-a-kubeslice": rolledOut, "team-a-worker": rolledOut}}
+	kubeClient = fake
+	mock := &util.MockExecutor{}
+	defer util.UseExecutor(mock)()
+
+	ksc := KubeSliceConfiguration{ProjectName: "demo", Labels: map[string]string{"owner": "platform"}}
+	controller := Cluster{Name: "controller", ContextName: "kind-controller", Namespace: "team-a-kubeslice", NamespaceMetadata: ObjectMetadata{Labels: map[string]string{"team": "a"}}}
+	worker := Cluster{Name: "worker-1", ContextName: "kind-worker-1", Namespace: "team-a-worker"}
+	hc := HelmChartConfiguration{RepoAlias: "kubeslice", ControllerChart: HelmChart{ChartName: "kubeslice-controller"}, WorkerChart: HelmChart{ChartName: "kubeslice-worker"}}
+
+	// reinstalling applies the namespaces again
+	for i := 0; i < 2; i++ {
+		CreateNamespace(controller, ControllerNamespace(controller), ksc)
+		installKubeSliceController(controller, hc, "values.yaml")
+		WaitForRollout(controller, "kubeslice-controller", ControllerNamespace(controller))
+		CreateNamespace(worker, WorkerNamespace(worker), ksc)
+		installKubeSliceWorkerHelm(worker, "values.yaml", hc)
+		WaitForRollout(worker, "kubeslice-worker", WorkerNamespace(worker))
+	}
+
+	namespaces := make([]string, 0)
+	for _, applied := range fake.applied {
+		var namespace namespaceManifest
+		if err := yaml.Unmarshal([]byte(applied.Manifest), &namespace); err != nil {
+			t.Fatalf("applied manifest is not valid YAML: %v", err)
+		}
+		namespaces = append(namespaces, applied.Context+" "+namespace.Metadata.Name+" "+namespace.Metadata.Labels["owner"]+" "+namespace.Metadata.Labels["team"])
+	}
+	expected := []string{"kind-controller team-a-kubeslice platform a", "kind-worker-1 team-a-worker platform ", "kind-controller team-a-kubeslice platform a", "kind-worker-1 team-a-worker platform "}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Errorf("applied namespaces = %q, want %q", namespaces, expected)
+	}
+
+	upgrades := make([]string, 0)
+	for _, command := range mock.Commands {
+		if strings.Contains(strings.Join(command, " "), " upgrade -i ") {
+			upgrades = append(upgrades, strings.Join(command, " "))
+		}
+	}
+	if len(upgrades) != 4 {
+		t.Fatalf("helm upgrades = %q, want 4", upgrades)
+	}
+	for i, namespace := range []string{"team-a-kubeslice", "team-a-worker"} {
+		if !strings.Contains(upgrades[i], "--namespace "+namespace+" ") || strings.Contains(upgrades[i], "--create-namespace") {
+			t.Errorf("helm upgrade %q, want it in the existing namespace %s", upgrades[i], namespace)
+		}
+	}
+
+	manifest, err := kubesliceProjectManifest(ControllerNamespace(controller), ksc)
+	if err != nil {
+		t.Fatalf("kubesliceProjectManifest() returned error: %v", err)
+	}
+	if !strings.Contains(manifest, "namespace: team-a-kubeslice\n") {
+		t.Errorf("kubesliceProjectManifest() =\n%s\nwant the project in namespace team-a-kubeslice", manifest)
+	}
+	releases := componentReleases(&ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{ControllerCluster: controller, WorkerClusters: []Cluster{worker}}}})
+	if got := releases[UI_install_Component].namespace(controller); got != "team-a-kubeslice" {
+		t.Errorf("namespace of the UI release = %s, want team-a-kubeslice", got)
+	}
+	if got := releases[Worker_Component].namespace(worker); got != "team-a-worker" {
+		t.Errorf("namespace of the worker release = %s, want team-a-worker", got)
+	}
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   Configuration
+		expected []string
+	}{
+		{
+			name: "Default namespaces",
+			config: Configuration{
+				KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+				ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1"}}},
+			},
+			expected: []string{},
+		},
+		{
+			name: "Invalid worker namespace",
+			config: Configuration{
+				KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+				ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1", Namespace: "Team_A"}}},
+			},
+			expected: []string{util.Cross + ` configuration.cluster_configuration.workers[0].namespace "Team_A" is invalid: must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character`},
+		},
+		{
+			name: "Controller in the namespace of a project",
+			config: Configuration{
+				KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+				ClusterConfiguration:   ClusterConfiguration{ControllerCluster: Cluster{Name: "controller", Namespace: "kubeslice-demo"}},
+			},
+			expected: []string{util.Cross + " configuration.cluster_configuration.controller.namespace kubeslice-demo is the namespace of project demo"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ValidateNamespaces(tc.config); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ValidateNamespaces() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// nodeAddressesJSONPath lists every address of every node as <type>=<address>.
+const nodeAddressesJSONPath = `jsonpath={range .items[*]}{range .status.addresses[*]}{.type}={.address}{"\n"}{end}{end}`
+
+// clusterNodeIPs returns the node IPs of a worker cluster, node_ips or the
+// single node_ip of older topologies.
+func clusterNodeIPs(cluster Cluster) []string {
+	if len(cluster.NodeIPs) > 0 {
+		return cluster.NodeIPs
+	}
+	if cluster.NodeIP != "" {
+		return []string{cluster.NodeIP}
+	}
+	return nil
+}
+
+// workerNodeIP returns the address of the nodeIp value of the worker chart,
+// which takes a single address. All the node IPs are registered in the
+// Cluster of the worker on the controller.
+func workerNodeIP(cluster Cluster) string {
+	if ips := clusterNodeIPs(cluster); len(ips) > 0 {
+		return ips[0]
+	}
+	return ""
+}
+
+// ValidateNodeIPs checks the node IPs of the worker clusters, which must be
+// of the same IP family unless the cluster allows mixing them.
+func ValidateNodeIPs(config Configuration) []string {
+	errors := make([]string, 0)
+	for i, cluster := range config.ClusterConfiguration.WorkerClusters {
+		if cluster.NodeIP != "" && net.ParseIP(cluster.NodeIP) == nil {
+			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers[%d].node_ip: %q is not a valid IP address", util.Cross, i, cluster.NodeIP))
+		}
+		if err := validateNodeIPs(cluster.NodeIPs, cluster.AllowMixedIPFamilies); err != nil {
+			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers[%d].node_ips: %v", util.Cross, i, err))
+		}
+	}
+	return errors
+}
+
+func validateNodeIPs(ips []string, allowMixed bool) error {
+	ipv4, ipv6 := 0, 0
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("%q is not a valid IP address", s)
+		}
+		if ip.To4() != nil {
+			ipv4++
+		} else {
+			ipv6++
+		}
+	}
+	if ipv4 > 0 && ipv6 > 0 && !allowMixed {
+		return fmt.Errorf("%s mixes IPv4 and IPv6 addresses, set allow_mixed_ip_families to allow it", strings.Join(ips, ", "))
+	}
+	return nil
+}
+
+// detectNodeIPs picks the node IPs of a cluster from the addresses of its
+// nodes, the ExternalIPs if any node has one and the InternalIPs otherwise.
+// Unless mixing them is allowed, the addresses of the family of the first one
+// are kept. It returns the address type the IPs were picked from.
+func detectNodeIPs(addresses string, allowMixed bool) ([]string, string) {
+	byType := make(map[string][]string)
+	for _, line := range strings.Split(addresses, "\n") {
+		splits := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(splits) == 2 && net.ParseIP(splits[1]) != nil {
+			byType[splits[0]] = appendUnique(byType[splits[0]], splits[1])
+		}
+	}
+	for _, addressType := range []string{"ExternalIP", "InternalIP"} {
+		ips := byType[addressType]
+		if len(ips) == 0 {
+			continue
+		}
+		if allowMixed {
+			return ips, addressType
+		}
+		ipv4 := net.ParseIP(ips[0]).To4() != nil
+		family := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			if (net.ParseIP(ip).To4() != nil) == ipv4 {
+				family = append(family, ip)
+			}
+		}
+		return family, addressType
+	}
+	return nil, ""
+}
+
+// setNodeIPs detects the node IPs of the clusters without node_ips or node_ip.
+func setNodeIPs(clusterConfig *ClusterConfiguration) {
+	for _, cluster := range getAllClusters(clusterConfig) {
+		if ips := clusterNodeIPs(*cluster); len(ips) > 0 {
+			cluster.NodeIPs = ips
+			cluster.NodeIP = ips[0]
+			util.Printf("%s Node IPs of %s set in the topology: %s", util.Tick, cluster.Name, strings.Join(ips, ", "))
+			continue
+		}
+		var outB, errB bytes.Buffer
+		err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cluster.ContextName, "--kubeconfig="+cluster.KubeConfigPath, "get", "nodes", "-o", nodeAddressesJSONPath)
+		if err != nil {
+			util.Fatalf("%s Failed to list the nodes of %s\nOutput: %s\nError: %s %v", util.Cross, cluster.Name, outB.String(), errB.String(), err)
+		}
+		ips, addressType := detectNodeIPs(outB.String(), cluster.AllowMixedIPFamilies)
+		if len(ips) == 0 {
+			util.Fatalf("%s No node of %s has an ExternalIP or InternalIP address, set node_ips in the topology", util.Cross, cluster.Name)
+		}
+		cluster.NodeIPs = ips
+		cluster.NodeIP = ips[0]
+		util.Printf("%s Node IPs fetched for %s from the %s addresses of its nodes: %s", util.Tick, cluster.Name, addressType, strings.Join(ips, ", "))
+	}
+}
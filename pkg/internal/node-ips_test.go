@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestValidateNodeIPs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cluster Cluster
+		err     string
+	}{
+		{name: "IPv4 list", cluster: Cluster{NodeIPs: []string{"34.105.12.7", "34.105.12.8"}}},
+		{name: "IPv6 list", cluster: Cluster{NodeIPs: []string{"2001:db8::7", "2001:db8::8"}}},
+		{name: "Mixed list allowed", cluster: Cluster{NodeIPs: []string{"34.105.12.7", "2001:db8::7"}, AllowMixedIPFamilies: true}},
+		{name: "Malformed IP", cluster: Cluster{NodeIPs: []string{"34.105.12.7", "34.105.12"}}, err: `node_ips: "34.105.12" is not a valid IP address`},
+		{name: "Hostname", cluster: Cluster{NodeIPs: []string{"lb.example.com"}}, err: `"lb.example.com" is not a valid IP address`},
+		{name: "Mixed list", cluster: Cluster{NodeIPs: []string{"34.105.12.7", "2001:db8::7"}}, err: "mixes IPv4 and IPv6 addresses, set allow_mixed_ip_families"},
+		{name: "Malformed node_ip", cluster: Cluster{NodeIP: "10.0.0.256"}, err: `node_ip: "10.0.0.256" is not a valid IP address`},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errors := ValidateNodeIPs(Configuration{ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{tc.cluster}}})
+			if tc.err == "" {
+				if len(errors) != 0 {
+					t.Errorf("ValidateNodeIPs() = %q, want no errors", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || !strings.Contains(errors[0], tc.err) {
+				t.Errorf("ValidateNodeIPs() = %q, want an error containing %q", errors, tc.err)
+			}
+		})
+	}
+}
+
+func TestSetNodeIPs(t *testing.T) {
+	tests := []struct {
+		name       string
+		cluster    Cluster
+		addresses  string
+		expected   []string
+		lookedUpIP bool
+	}{
+		{
+			name:     "Explicit list",
+			cluster:  Cluster{Name: "worker-1", NodeIPs: []string{"34.105.12.7", "34.105.12.8"}},
+			expected: []string{"34.105.12.7", "34.105.12.8"},
+		},
+		{
+			name:     "Explicit node_ip",
+			cluster:  Cluster{Name: "worker-1", NodeIP: "34.105.12.7"},
+			expected: []string{"34.105.12.7"},
+		},
+		{
+			name:       "ExternalIPs preferred",
+			cluster:    Cluster{Name: "worker-1"},
+			addresses:  "InternalIP=10.128.0.2\nExternalIP=34.105.12.7\nHostname=node-1\nInternalIP=10.128.0.3\nExternalIP=34.105.12.8\n",
+			expected:   []string{"34.105.12.7", "34.105.12.8"},
+			lookedUpIP: true,
+		},
+		{
+			name:       "InternalIPs without ExternalIPs",
+			cluster:    Cluster{Name: "worker-1"},
+			addresses:  "InternalIP=10.128.0.2\nHostname=node-1\nInternalIP=10.128.0.3\n",
+			expected:   []string{"10.128.0.2", "10.128.0.3"},
+			lookedUpIP: true,
+		},
+		{
+			name:       "Dual stack nodes",
+			cluster:    Cluster{Name: "worker-1"},
+			addresses:  "InternalIP=10.128.0.2\nInternalIP=fd00:10:128::2\n",
+			expected:   []string{"10.128.0.2"},
+			lookedUpIP: true,
+		},
+		{
+			name:       "Dual stack nodes allowed",
+			cluster:    Cluster{Name: "worker-1", AllowMixedIPFamilies: true},
+			addresses:  "InternalIP=10.128.0.2\nInternalIP=fd00:10:128::2\n",
+			expected:   []string{"10.128.0.2", "fd00:10:128::2"},
+			lookedUpIP: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stdout, tc.addresses)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			config := &ClusterConfiguration{ControllerCluster: Cluster{Name: "controller", NodeIP: "10.0.0.1"}, WorkerClusters: []Cluster{tc.cluster}}
+			setNodeIPs(config)
+			worker := config.WorkerClusters[0]
+			if !reflect.DeepEqual(worker.NodeIPs, tc.expected) || worker.NodeIP != tc.expected[0] {
+				t.Errorf("node IPs = %q, node IP = %q, want %q", worker.NodeIPs, worker.NodeIP, tc.expected)
+			}
+			if nodeIP := workerNodeIP(worker); nodeIP != tc.expected[0] {
+				t.Errorf("worker chart node IP = %q, want %q", nodeIP, tc.expected[0])
+			}
+			if lookups := len(mock.Commands); tc.lookedUpIP && lookups != 1 || !tc.lookedUpIP && lookups != 0 {
+				t.Errorf("kubectl get nodes ran %d times: %q", lookups, mock.Commands)
+			}
+
+			manifest, err := clusterRegistrationManifest(&ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: *config}}, "kubeslice-demo")
+			if err != nil {
+				t.Fatalf("clusterRegistrationManifest() returned error: %v", err)
+			}
+			if !strings.Contains(manifest, "nodeIPs:\n  - "+strings.Join(tc.expected, "\n  - ")+"\n") {
+				t.Errorf("cluster registration manifest =\n%s\nwant the nodeIPs %q", manifest, tc.expected)
+			}
+		})
+	}
+}
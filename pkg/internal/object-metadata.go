@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+var (
+	qualifiedName = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	dns1123Domain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// newObjectMeta returns the metadata of a generated object, with the labels
+// and annotations of the topology and the ones of the object on top.
+func newObjectMeta(name, namespace string, ksc KubeSliceConfiguration, object ObjectMetadata) objectMeta {
+	return objectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      mergeStringMaps(ksc.Labels, object.Labels),
+		Annotations: mergeStringMaps(ksc.Annotations, object.Annotations),
+	}
+}
+
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ValidateObjectMetadata checks the labels and annotations of the topology
+// against the Kubernetes syntax, and rejects keys of kubeslice.io which the
+// controller manages itself.
+func ValidateObjectMetadata(config Configuration) []string {
+	errors := make([]string, 0)
+	ksc := config.KubeSliceConfiguration
+	check := func(field string, metadata ObjectMetadata) {
+		for _, key := range sortedKeys(metadata.Labels) {
+			if err := validateMetadataKey(key); err != nil {
+				errors = append(errors, fmt.Sprintf("%s %s.labels: %v", util.Cross, field, err))
+			} else if err := validateLabelValue(metadata.Labels[key]); err != nil {
+				errors = append(errors, fmt.Sprintf("%s %s.labels.%s: %v", util.Cross, field, key, err))
+			}
+		}
+		for _, key := range sortedKeys(metadata.Annotations) {
+			if err := validateMetadataKey(key); err != nil {
+				errors = append(errors, fmt.Sprintf("%s %s.annotations: %v", util.Cross, field, err))
+			}
+		}
+	}
+	check("configuration.kubeslice_configuration", ObjectMetadata{Labels: ksc.Labels, Annotations: ksc.Annotations})
+	check("configuration.kubeslice_configuration.project_metadata", ksc.ProjectMetadata)
+	check("configuration.kubeslice_configuration.slice_metadata", ksc.SliceMetadata)
+	check("configuration.cluster_configuration.controller.namespace_metadata", config.ClusterConfiguration.ControllerCluster.NamespaceMetadata)
+	for i, cluster := range config.ClusterConfiguration.WorkerClusters {
+		check(fmt.Sprintf("configuration.cluster_configuration.workers[%d].registration_metadata", i), cluster.RegistrationMetadata)
+		check(fmt.Sprintf("configuration.cluster_configuration.workers[%d].namespace_metadata", i), cluster.NamespaceMetadata)
+	}
+	return errors
+}
+
+// validateMetadataKey checks a label or annotation key, an optional DNS
+// subdomain prefix and a name of at most 63 characters.
+func validateMetadataKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) > 253 || !dns1123Domain.MatchString(prefix) {
+			return fmt.Errorf("key %q is invalid: the prefix must be a DNS subdomain of at most 253 characters", key)
+		}
+		if prefix == "kubeslice.io" || strings.HasSuffix(prefix, ".kubeslice.io") {
+			return fmt.Errorf("key %q is invalid: keys of kubeslice.io are reserved for KubeSlice", key)
+		}
+	}
+	if name == "" || len(name) > 63 || !qualifiedName.MatchString(name) {
+		return fmt.Errorf("key %q is invalid: the name must be at most 63 alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character", key)
+	}
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if value != "" && (len(value) > 63 || !qualifiedName.MatchString(value)) {
+		return fmt.Errorf("value %q is invalid: must be at most 63 alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character", value)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestGeneratedManifestsMetadata(t *testing.T) {
+	t.Parallel()
+
+	ksc := KubeSliceConfiguration{
+		ProjectName:     "demo",
+		Labels:          map[string]string{"team": "platform", "example.com/cost-center": "cc-42"},
+		Annotations:     map[string]string{"example.com/owner": "platform@example.com"},
+		ProjectMetadata: ObjectMetadata{Labels: map[string]string{"team": "security"}},
+		SliceMetadata:   ObjectMetadata{Annotations: map[string]string{"example.com/note": "demo slice: iperf"}},
+	}
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{
+			{Name: "worker-1", RegistrationMetadata: ObjectMetadata{Labels: map[string]string{"region": "eu-west-1"}}},
+		}},
+		KubeSliceConfiguration: ksc,
+	}}
+	project := func() (string, error) { return kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, ksc) }
+	clusters := func() (string, error) { return clusterRegistrationManifest(specs, "") }
+	slice := func() (string, error) {
+		return sliceConfigurationManifest(ksc, configuredSlice(specs, nil, ""), "kubeslice-demo")
+	}
+
+	tests := []struct {
+		name        string
+		generate    func() (string, error)
+		labels      map[string]string
+		annotations map[string]string
+	}{
+		{
+			name:        "Project",
+			generate:    project,
+			labels:      map[string]string{"team": "security", "example.com/cost-center": "cc-42"},
+			annotations: map[string]string{"example.com/owner": "platform@example.com"},
+		},
+		{
+			name:        "Cluster",
+			generate:    clusters,
+			labels:      map[string]string{"team": "platform", "example.com/cost-center": "cc-42", "region": "eu-west-1"},
+			annotations: map[string]string{"example.com/owner": "platform@example.com"},
+		},
+		{
+			name:        "SliceConfig",
+			generate:    slice,
+			labels:      map[string]string{"team": "platform", "example.com/cost-center": "cc-42"},
+			annotations: map[string]string{"example.com/owner": "platform@example.com", "example.com/note": "demo slice: iperf"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifest, err := tc.generate()
+			if err != nil {
+				t.Fatalf("generating the manifest returned error: %v", err)
+			}
+			var object struct {
+				Metadata objectMeta `yaml:"metadata"`
+			}
+			if err := yaml.Unmarshal([]byte(manifest), &object); err != nil {
+				t.Fatalf("manifest is not valid YAML: %v", err)
+			}
+			if !reflect.DeepEqual(object.Metadata.Labels, tc.labels) {
+				t.Errorf("labels = %v, want %v", object.Metadata.Labels, tc.labels)
+			}
+			if !reflect.DeepEqual(object.Metadata.Annotations, tc.annotations) {
+				t.Errorf("annotations = %v, want %v", object.Metadata.Annotations, tc.annotations)
+			}
+		})
+	}
+}
+
+func TestValidateObjectMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		err         string
+	}{
+		{
+			name:        "Valid",
+			labels:      map[string]string{"team": "platform", "app.kubernetes.io/part-of": "kubeslice", "empty": ""},
+			annotations: map[string]string{"example.com/description": "any value: is fine"},
+		},
+		{
+			name:   "Invalid label key",
+			labels: map[string]string{"cost center": "cc-42"},
+			err:    `configuration.kubeslice_configuration.labels: key "cost center" is invalid`,
+		},
+		{
+			name:   "Invalid label value",
+			labels: map[string]string{"team": "platform team"},
+			err:    `configuration.kubeslice_configuration.labels.team: value "platform team" is invalid`,
+		},
+		{
+			name:   "Long label key",
+			labels: map[string]string{strings.Repeat("a", 64): "x"},
+			err:    "the name must be at most 63",
+		},
+		{
+			name:   "Invalid prefix",
+			labels: map[string]string{"Example.com/team": "platform"},
+			err:    "the prefix must be a DNS subdomain",
+		},
+		{
+			name:   "Reserved label",
+			labels: map[string]string{"kubeslice.io/slice": "demo"},
+			err:    `key "kubeslice.io/slice" is invalid: keys of kubeslice.io are reserved`,
+		},
+		{
+			name:        "Reserved annotation",
+			annotations: map[string]string{"controller.kubeslice.io/managed": "true"},
+			err:         `annotations: key "controller.kubeslice.io/managed" is invalid: keys of kubeslice.io are reserved`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errors := ValidateObjectMetadata(Configuration{KubeSliceConfiguration: KubeSliceConfiguration{Labels: tc.labels, Annotations: tc.annotations}})
+			if tc.err == "" {
+				if len(errors) != 0 {
+					t.Errorf("ValidateObjectMetadata() = %q, want no errors", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || !strings.Contains(errors[0], tc.err) {
+				t.Errorf("ValidateObjectMetadata() = %q, want an error containing %q", errors, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// hostArchitecture is the architecture kubeslice-cli runs on.
+var hostArchitecture = runtime.GOARCH
+
+// platformNodeImages are the node images publishing no variant of the
+// platform of the kind nodes, by the image used in their place.
+var platformNodeImages = make(map[string]string)
+
+// architectureSummary records the architecture decisions of a run.
+type architectureSummary struct {
+	Host string `yaml:"host"`
+	// Runtime is the architecture of the server of the container runtime,
+	// which differs from the one of the host when it is emulated
+	Runtime string `yaml:"runtime,omitempty"`
+	// Platform is the one of the kind nodes, selected with Source
+	Platform  string   `yaml:"platform"`
+	Source    string   `yaml:"source"`
+	Decisions []string `yaml:"decisions,omitempty"`
+}
+
+func (s *architectureSummary) decide(format string, args ...interface{}) {
+	s.Decisions = append(s.Decisions, fmt.Sprintf(format, args...))
+}
+
+// normalizeArchitecture returns the GOARCH name of an architecture, which
+// the container runtimes report as uname does on some hosts.
+func normalizeArchitecture(arch string) string {
+	switch arch = strings.TrimSpace(arch); arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	}
+	return arch
+}
+
+// runtimeArchitecture returns the architecture of the server of the container
+// runtime of kind. Docker Desktop may run a server of another architecture
+// than the host.
+func runtimeArchitecture() (string, error) {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO(kindRuntime(), &outB, &errB, true, "version", "--format", "{{.Server.Arch}}"); err != nil {
+		return "", fmt.Errorf("%s", commandError(err, &errB))
+	}
+	arch := normalizeArchitecture(outB.String())
+	if arch == "" {
+		return "", fmt.Errorf("%s version reported no server architecture", kindRuntime())
+	}
+	return arch, nil
+}
+
+// imagePlatforms returns the platforms an image publishes, read from its
+// manifest in the registry.
+func imagePlatforms(image string) ([]string, error) {
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO(kindRuntime(), &outB, &errB, true, "manifest", "inspect", "--verbose", image); err != nil {
+		return nil, fmt.Errorf("%s", commandError(err, &errB))
+	}
+	return manifestPlatforms(outB.Bytes())
+}
+
+type manifestPlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+}
+
+// manifestPlatforms parses the platforms of the verbose output of docker
+// manifest inspect, a list of descriptors for a multi-platform image and a
+// single one otherwise, or the manifest list podman prints.
+func manifestPlatforms(out []byte) ([]string, error) {
+	type descriptor struct {
+		Descriptor struct {
+			Platform *manifestPlatform `json:"platform"`
+		} `json:"Descriptor"`
+		Manifests []struct {
+			Platform *manifestPlatform `json:"platform"`
+		} `json:"manifests"`
+	}
+	descriptors := make([]descriptor, 0)
+	if err := json.Unmarshal(out, &descriptors); err != nil {
+		var single descriptor
+		if err := json.Unmarshal(out, &single); err != nil {
+			return nil, fmt.Errorf("unable to parse the manifest: %v", err)
+		}
+		descriptors = append(descriptors, single)
+	}
+	platforms := make([]string, 0)
+	add := func(p *manifestPlatform) {
+		if p == nil || p.Architecture == "" || p.Architecture == "unknown" {
+			return
+		}
+		if platform := p.OS + "/" + p.Architecture; !containsString(platforms, platform) {
+			platforms = append(platforms, platform)
+		}
+	}
+	for _, d := range descriptors {
+		add(d.Descriptor.Platform)
+		for _, m := range d.Manifests {
+			add(m.Platform)
+		}
+	}
+	return platforms, nil
+}
+
+// VerifyPlatformOption verifies that --platform can be honoured before the
+// run touches anything: kind pulls the node images of its own for the
+// platform of the container runtime with --skip-image-prepull, and there are
+// no kind nodes to run it when the run creates no kind clusters.
+func VerifyPlatformOption(createsKindClusters bool) {
+	if Options.Platform == "" {
+		return
+	}
+	if !createsKindClusters {
+		util.Printf("%s Ignoring --platform %s, the run creates no kind clusters", util.Warn, Options.Platform)
+		return
+	}
+	if Options.SkipImagePrepull {
+		util.Fatalf("%s --platform %s requires pulling the node images before kind runs, it cannot be combined with --skip-image-prepull", util.Cross, Options.Platform)
+	}
+}
+
+// SelectPlatform selects the platform of the kind nodes: the architecture of
+// the container runtime, or --platform. Off amd64 the node images and the
+// images of the charts of the components are verified to publish a variant
+// of it, as emulated or failing pods otherwise make the clusters slow or
+// broken. A node image pinned to a digest which does not is replaced by its
+// tag when the tag does. The decisions are recorded in the run summary.
+func SelectPlatform(specs *ConfigurationSpecs, components []string) {
+	util.Printf("\nSelecting the platform of the kind clusters...")
+	summary := &architectureSummary{Host: hostArchitecture}
+	runSummary.Architecture = summary
+	arch, err := runtimeArchitecture()
+	if err != nil {
+		util.Printf("%s Unable to detect the architecture of the %s server, assuming the one of the host %s: %v", util.Warn, kindRuntime(), hostArchitecture, err)
+		summary.decide("the architecture of the %s server is unknown, assumed %s", kindRuntime(), hostArchitecture)
+		arch = hostArchitecture
+	} else {
+		summary.Runtime = arch
+		if arch != hostArchitecture {
+			util.Printf("%s The %s server runs %s, unlike the host which runs %s", util.Warn, kindRuntime(), arch, hostArchitecture)
+			summary.decide("the %s server runs %s on a %s host", kindRuntime(), arch, hostArchitecture)
+		}
+	}
+	summary.Platform, summary.Source = "linux/"+arch, kindRuntime()+" server"
+	if Options.Platform != "" {
+		if !strings.HasPrefix(Options.Platform, "linux/") || len(Options.Platform) == len("linux/") {
+			util.Fatalf("%s Invalid --platform %s, the kind nodes run linux/<architecture>, e.g. linux/amd64", util.Cross, Options.Platform)
+		}
+		summary.Platform, summary.Source = Options.Platform, "--platform"
+		if Options.Platform != "linux/"+arch {
+			util.Printf("%s The kind nodes run %s emulated on the %s %s server, which is slow", util.Warn, Options.Platform, arch, kindRuntime())
+			summary.decide("the kind nodes run %s emulated", Options.Platform)
+		}
+	}
+	util.Printf("%s The kind nodes run %s, selected by the %s", util.Tick, summary.Platform, summary.Source)
+	// every image publishes amd64
+	if summary.Platform == "linux/amd64" {
+		return
+	}
+	if err := selectNodeImages(&specs.Configuration.ClusterConfiguration, summary); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if err := verifyChartImagePlatforms(specs, components, summary); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+}
+
+// selectNodeImages verifies that the node images publish a variant of the
+// platform, replacing those pinned to a digest which do not by their tag when
+// the tag does.
+func selectNodeImages(cc *ClusterConfiguration, summary *architectureSummary) error {
+	checked := make([]string, 0)
+	for _, cluster := range selectedClusters(cc) {
+		image, err := kindNodeImageOf(cc, cluster)
+		if err != nil || containsString(checked, image) {
+			continue
+		}
+		checked = append(checked, image)
+		platforms, err := imagePlatforms(image)
+		if err != nil {
+			util.Printf("%s Unable to inspect the platforms of node image %s, assuming it publishes %s: %v", util.Warn, image, summary.Platform, err)
+			summary.decide("the platforms of node image %s are not verified", image)
+			continue
+		}
+		if containsString(platforms, summary.Platform) {
+			continue
+		}
+		if i := strings.Index(image, "@"); i >= 0 {
+			tag := image[:i]
+			if platforms, err := imagePlatforms(tag); err == nil && containsString(platforms, summary.Platform) {
+				platformNodeImages[image] = tag
+				util.Printf("%s Node image %s publishes no %s variant, using %s", util.Warn, image, summary.Platform, tag)
+				summary.decide("node image %s is replaced by %s, which publishes %s", image, tag, summary.Platform)
+				continue
+			}
+		}
+		return fmt.Errorf("node image %s publishes no %s variant, only %s. Set a node_image publishing one, or pass --platform %s to run the kind nodes emulated",
+			image, summary.Platform, strings.Join(platforms, ", "), emulatedPlatform(platforms))
+	}
+	return nil
+}
+
+// verifyChartImagePlatforms verifies that the images of the charts of the
+// components, as pulled with the registry of the topology, publish a variant
+// of the platform.
+func verifyChartImagePlatforms(specs *ConfigurationSpecs, components []string, summary *architectureSummary) error {
+	// the control plane addresses the rendering fills in must not stick
+	copied := *specs
+	cc := &copied.Configuration.ClusterConfiguration
+	cc.WorkerClusters = append([]Cluster{}, cc.WorkerClusters...)
+	missing := make([]string, 0)
+	published := make([]string, 0)
+	for _, image := range collectChartImages(&copied, components) {
+		platforms, err := imagePlatforms(image.Image)
+		if err != nil {
+			util.Printf("%s Unable to inspect the platforms of image %s, assuming it publishes %s: %v", util.Warn, image.Image, summary.Platform, err)
+			summary.decide("the platforms of image %s are not verified", image.Image)
+			continue
+		}
+		if !containsString(platforms, summary.Platform) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", image.Image, strings.Join(image.Charts, ", ")))
+			published = append(published, platforms...)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the images %s publish no %s variant, their pods would fail or run emulated. Pass --platform %s to run the kind nodes emulated, or mirror images with a %s variant and set global_image_registry",
+			strings.Join(missing, ", "), summary.Platform, emulatedPlatform(published), summary.Platform)
+	}
+	util.Printf("%s The images of the charts publish %s", util.Tick, summary.Platform)
+	return nil
+}
+
+// emulatedPlatform suggests the platform of --platform running images which
+// publish the platforms, amd64 when they do.
+func emulatedPlatform(platforms []string) string {
+	if len(platforms) == 0 || containsString(platforms, "linux/amd64") {
+		return "linux/amd64"
+	}
+	return platforms[0]
+}
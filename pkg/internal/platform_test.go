@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestManifestPlatforms(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		out      string
+		expected []string
+	}{
+		{
+			name: "Multi-platform image",
+			out: `[{"Ref": "docker.io/kindest/node:v1.25.11@sha256:01", "Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}},
+{"Ref": "docker.io/kindest/node:v1.25.11@sha256:02", "Descriptor": {"platform": {"architecture": "arm64", "os": "linux"}}}]`,
+			expected: []string{"linux/amd64", "linux/arm64"},
+		},
+		{
+			name:     "Single platform image",
+			out:      `{"Ref": "docker.io/aveshasystems/worker-operator:1.0.0", "Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}}`,
+			expected: []string{"linux/amd64"},
+		},
+		{
+			name:     "Manifest list of podman",
+			out:      `{"schemaVersion": 2, "manifests": [{"platform": {"architecture": "arm64", "os": "linux"}}, {"platform": {"architecture": "unknown", "os": "unknown"}}]}`,
+			expected: []string{"linux/arm64"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			platforms, err := manifestPlatforms([]byte(tc.out))
+			if err != nil {
+				t.Fatalf("manifestPlatforms() error = %v", err)
+			}
+			if !reflect.DeepEqual(platforms, tc.expected) {
+				t.Errorf("manifestPlatforms() = %v, want %v", platforms, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSelectNodeImages(t *testing.T) {
+	defer func(images map[string]string) { platformNodeImages = images }(platformNodeImages)
+
+	amd64 := `{"Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}}`
+	multi := `[{"Descriptor": {"platform": {"architecture": "amd64", "os": "linux"}}}, {"Descriptor": {"platform": {"architecture": "arm64", "os": "linux"}}}]`
+	tests := []struct {
+		name      string
+		nodeImage string
+		manifests map[string]string
+		expected  map[string]string
+		err       string
+	}{
+		{
+			name:      "Published",
+			nodeImage: "kindest/node:v1.27.3",
+			manifests: map[string]string{"kindest/node:v1.27.3": multi},
+			expected:  map[string]string{},
+		},
+		{
+			name:      "Digest replaced by its tag",
+			nodeImage: "kindest/node:v1.27.3@sha256:0123",
+			manifests: map[string]string{"kindest/node:v1.27.3@sha256:0123": amd64, "kindest/node:v1.27.3": multi},
+			expected:  map[string]string{"kindest/node:v1.27.3@sha256:0123": "kindest/node:v1.27.3"},
+		},
+		{
+			name:      "Not published",
+			nodeImage: "registry.local/node:v1.27.3",
+			manifests: map[string]string{"registry.local/node:v1.27.3": amd64},
+			err:       "node image registry.local/node:v1.27.3 publishes no linux/arm64 variant, only linux/amd64. Set a node_image publishing one, or pass --platform linux/amd64",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			platformNodeImages = make(map[string]string)
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				out, found := tc.manifests[command[len(command)-1]]
+				if !found {
+					fmt.Fprint(stderr, "no such manifest")
+					return fmt.Errorf("exit status 1")
+				}
+				fmt.Fprint(stdout, out)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			cc := &ClusterConfiguration{NodeImage: tc.nodeImage, ControllerCluster: Cluster{Name: "ks-ctrl"}, WorkerClusters: []Cluster{{Name: "ks-w-1"}}}
+			err := selectNodeImages(cc, &architectureSummary{Platform: "linux/arm64"})
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("selectNodeImages() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectNodeImages() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(platformNodeImages, tc.expected) {
+				t.Errorf("selectNodeImages() replaced %v, want %v", platformNodeImages, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// preflightCheck is a permission every cluster of the topology needs. The
+// controller and worker charts both create their namespace and ship CRDs.
+type preflightCheck struct {
+	title    string
+	verb     string
+	resource string
+}
+
+var preflightChecks = []preflightCheck{
+	{"CREATE NAMESPACES", "create", "namespaces"},
+	{"CREATE CRDS", "create", "customresourcedefinitions.apiextensions.k8s.io"},
+}
+
+// preflightResult is the outcome of the checks against one cluster.
+type preflightResult struct {
+	cluster   Cluster
+	exempt    bool
+	reachable bool
+	allowed   []bool
+	errors    []string
+	// version is the Kubernetes version of the cluster, or of the node image
+	// of a kind cluster to be created
+	version     string
+	unsupported string
+}
+
+// RunPreflightChecks checks that every cluster the charts install onto is
+// reachable, that its credentials have the permissions the install needs and
+// that it runs a Kubernetes version the charts to install support, before
+// anything is changed. Kind clusters which are yet to be created by the install are only
+// listed with the version of their node image. The run fails when a check
+// fails, unsupported versions only warn with --ignore-version-check.
+func RunPreflightChecks(specs *ConfigurationSpecs, charts []string, createsKindClusters bool) {
+	if Options.SkipPreflight {
+		return
+	}
+	cc := specs.Configuration.ClusterConfiguration
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	exempt := kindClustersToCreate(clusters, createsKindClusters)
+
+	util.Printf("\nRunning pre-flight checks...")
+	results := make([]preflightResult, 0, len(clusters))
+	for i, cluster := range clusters {
+		// the clusters no selected component is installed onto are left alone
+		if !touchesCluster(i == 0, charts) {
+			continue
+		}
+		image, _ := kindNodeImageOf(&cc, &clusters[i])
+		result := runPreflightChecks(cluster, exempt[i], image)
+		components := []string{Worker_Component}
+		if i == 0 {
+			components = []string{Controller_Component, UI_install_Component}
+		}
+		// the node images of the kind clusters to create are checked by
+		// ValidateKindNodeImages
+		if supported, ok := supportedKubernetes(components); ok && result.version != "" && !result.exempt {
+			switch {
+			case !supported.supports(result.version):
+				result.unsupported = fmt.Sprintf("cluster %s runs Kubernetes %s, %s", cluster.Name, result.version, supported)
+			case supported.untested(result.version):
+				util.Printf("%s Cluster %s runs Kubernetes %s, newer than the Kubernetes %s %s were tested with", util.Warn, cluster.Name, result.version, supported.max, strings.Join(supported.charts, ", "))
+			}
+		}
+		results = append(results, result)
+	}
+	printPreflightResults(results)
+	errors := make([]string, 0)
+	unsupported := make([]string, 0)
+	for _, result := range results {
+		errors = append(errors, result.errors...)
+		if result.unsupported != "" {
+			unsupported = append(unsupported, result.unsupported)
+		}
+	}
+	if Options.IgnoreVersionCheck {
+		for _, s := range unsupported {
+			util.Printf("%s %s", util.Warn, s)
+		}
+	} else {
+		errors = append(errors, unsupported...)
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf("%s %s", util.Cross, s)
+		}
+		if len(unsupported) > 0 && !Options.IgnoreVersionCheck {
+			util.Printf("%s Use --ignore-version-check to install onto unsupported Kubernetes versions anyway", util.Warn)
+		}
+		util.Fatalf("%s Pre-flight checks failed, nothing was changed. Use --skip-preflight to bypass them", util.Cross)
+	}
+	util.Printf("%s Pre-flight checks passed", util.Tick)
+}
+
+// kindClustersToCreate tells which of the clusters are kind clusters the
+// install is yet to create.
+func kindClustersToCreate(clusters []Cluster, createsKindClusters bool) []bool {
+	toCreate := make([]bool, len(clusters))
+	if !createsKindClusters {
+		return toCreate
+	}
+	if Options.RecreateClusters {
+		for i := range toCreate {
+			toCreate[i] = true
+		}
+		return toCreate
+	}
+	pointers := make([]*Cluster, len(clusters))
+	for i := range clusters {
+		pointers[i] = &clusters[i]
+	}
+	existing := getExistingClusters(pointers)
+	for i := range clusters {
+		toCreate[i] = !existing[i]
+	}
+	return toCreate
+}
+
+// runPreflightChecks checks a cluster, only the version of its node image when
+// it is a kind cluster yet to be created.
+func runPreflightChecks(cluster Cluster, exempt bool, nodeImage string) preflightResult {
+	result := preflightResult{cluster: cluster, exempt: exempt, allowed: make([]bool, len(preflightChecks))}
+	if exempt {
+		result.version = nodeImageVersion(nodeImage)
+		return result
+	}
+	kubectl := func(outB, errB *bytes.Buffer, args ...string) error {
+		args = append([]string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath, "--request-timeout", "10s"}, args...)
+		return util.RunCommandCustomIO("kubectl", outB, errB, true, args...)
+	}
+	var outB, errB bytes.Buffer
+	if err := kubectl(&outB, &errB, "get", "--raw", "/readyz"); err != nil {
+		result.errors = append(result.errors, fmt.Sprintf("cluster %s is not reachable with context %s: %s", cluster.Name, cluster.ContextName, commandError(err, &errB)))
+		return result
+	}
+	result.reachable = true
+	outB.Reset()
+	errB.Reset()
+	if err := kubectl(&outB, &errB, "version", "-o", "json"); err != nil {
+		result.errors = append(result.errors, fmt.Sprintf("unable to query the Kubernetes version of cluster %s: %s", cluster.Name, commandError(err, &errB)))
+	} else if result.version, err = serverVersion(outB.Bytes()); err != nil {
+		result.errors = append(result.errors, fmt.Sprintf("unable to query the Kubernetes version of cluster %s: %v", cluster.Name, err))
+	}
+	for i, check := range preflightChecks {
+		outB.Reset()
+		errB.Reset()
+		err := kubectl(&outB, &errB, "auth", "can-i", check.verb, check.resource)
+		// can-i prints no and exits with 1 when the permission is missing
+		answer := strings.TrimSpace(outB.String())
+		switch {
+		case answer == "yes":
+			result.allowed[i] = true
+		case strings.HasPrefix(answer, "no"):
+			result.errors = append(result.errors, fmt.Sprintf("context %s of cluster %s is not allowed to %s %s", cluster.ContextName, cluster.Name, check.verb, check.resource))
+		default:
+			result.errors = append(result.errors, fmt.Sprintf("unable to check whether context %s of cluster %s may %s %s: %s", cluster.ContextName, cluster.Name, check.verb, check.resource, commandError(err, &errB)))
+		}
+	}
+	return result
+}
+
+// serverVersion returns the server gitVersion of the output of kubectl version
+// -o json.
+func serverVersion(data []byte) (string, error) {
+	var version struct {
+		ServerVersion *struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return "", fmt.Errorf("unable to parse kubectl version: %v", err)
+	}
+	if version.ServerVersion == nil || version.ServerVersion.GitVersion == "" {
+		return "", fmt.Errorf("kubectl version returned no server version")
+	}
+	return version.ServerVersion.GitVersion, nil
+}
+
+// commandError returns the error output of a failed command, or its error
+// when it printed none.
+func commandError(err error, errB *bytes.Buffer) string {
+	if s := strings.TrimSpace(errB.String()); s != "" {
+		return util.Redact(s)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "no output"
+}
+
+func printPreflightResults(results []preflightResult) {
+	w := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	header := []string{"CLUSTER", "CONTEXT", "REACHABLE"}
+	for _, check := range preflightChecks {
+		header = append(header, check.title)
+	}
+	header = append(header, "KUBERNETES")
+	fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+	mark := func(ok bool) string {
+		if ok {
+			return util.Tick
+		}
+		return util.Cross
+	}
+	for _, result := range results {
+		row := []string{result.cluster.Name, result.cluster.ContextName}
+		switch {
+		case result.exempt:
+			row = append(row, "created by kind")
+			for range preflightChecks {
+				row = append(row, "-")
+			}
+		case !result.reachable:
+			row = append(row, mark(false))
+			for range preflightChecks {
+				row = append(row, "-")
+			}
+		default:
+			row = append(row, mark(true))
+			for _, allowed := range result.allowed {
+				row = append(row, mark(allowed))
+			}
+		}
+		switch {
+		case result.version == "":
+			row = append(row, "-")
+		case result.unsupported != "":
+			row = append(row, util.Cross+" "+result.version)
+		default:
+			row = append(row, util.Tick+" "+result.version)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	w.Flush()
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// preflightHandler answers kubectl version with the server version and
+// kubectl auth can-i with can.
+func preflightHandler(can func(resource string) bool) func(command []string, stdout, stderr io.Writer) error {
+	return func(command []string, stdout, stderr io.Writer) error {
+		switch command[5] {
+		case "version":
+			fmt.Fprint(stdout, `{"clientVersion": {"gitVersion": "v1.28.2"}, "serverVersion": {"gitVersion": "v1.27.3-gke.100"}}`)
+		case "auth":
+			if !can(command[len(command)-1]) {
+				fmt.Fprintln(stdout, "no")
+				return errors.New("exit status 1")
+			}
+			fmt.Fprintln(stdout, "yes")
+		}
+		return nil
+	}
+}
+
+func TestRunPreflightChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		exempt   bool
+		handler  func(command []string, stdout, stderr io.Writer) error
+		allowed  []bool
+		version  string
+		commands int
+		errors   []string
+	}{
+		{
+			name:     "Reachable cluster with permissions",
+			handler:  preflightHandler(func(string) bool { return true }),
+			allowed:  []bool{true, true},
+			version:  "v1.27.3-gke.100",
+			commands: 4,
+			errors:   nil,
+		},
+		{
+			name: "Unreachable cluster",
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprintln(stderr, "Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout")
+				return errors.New("exit status 1")
+			},
+			allowed:  []bool{false, false},
+			commands: 1,
+			errors:   []string{"cluster worker-1 is not reachable with context ctx-1: Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout"},
+		},
+		{
+			name: "Missing permission to create CRDs",
+			handler: preflightHandler(func(resource string) bool {
+				return resource != "customresourcedefinitions.apiextensions.k8s.io"
+			}),
+			allowed:  []bool{true, false},
+			version:  "v1.27.3-gke.100",
+			commands: 4,
+			errors:   []string{"context ctx-1 of cluster worker-1 is not allowed to create customresourcedefinitions.apiextensions.k8s.io"},
+		},
+		{
+			name:   "Kind cluster to be created",
+			exempt: true,
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				return errors.New("unexpected command")
+			},
+			allowed:  []bool{false, false},
+			version:  "v1.25.11",
+			commands: 0,
+			errors:   nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: tc.handler}
+			defer util.UseExecutor(mock)()
+
+			result := runPreflightChecks(Cluster{Name: "worker-1", ContextName: "ctx-1", KubeConfigPath: "kubeconfig.yaml"}, tc.exempt, kindNodeImage)
+			if !reflect.DeepEqual(result.allowed, tc.allowed) {
+				t.Errorf("allowed = %v, want %v", result.allowed, tc.allowed)
+			}
+			if result.version != tc.version {
+				t.Errorf("version = %q, want %q", result.version, tc.version)
+			}
+			if !reflect.DeepEqual(result.errors, tc.errors) {
+				t.Errorf("errors = %q, want %q", result.errors, tc.errors)
+			}
+			if len(mock.Commands) != tc.commands {
+				t.Fatalf("ran %d commands, want %d: %v", len(mock.Commands), tc.commands, mock.Commands)
+			}
+			if tc.commands > 0 && strings.Join(mock.Commands[0], " ") != "kubectl --context=ctx-1 --kubeconfig=kubeconfig.yaml --request-timeout 10s get --raw /readyz" {
+				t.Errorf("connectivity check = %v", mock.Commands[0])
+			}
+		})
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-const windowsEnvSet = `
+var windowsEnvSet = `
 PowerShell(ps):
 	$env:KUBECONFIG=` + KubeconfigPath + `
 
@@ -15,7 +15,7 @@ Command Prompt(cmd):
 	set KUBECONFIG=` + KubeconfigPath + `
 `
 
-const linuxEnvSet = `export KUBECONFIG=` + KubeconfigPath
+var linuxEnvSet = `export KUBECONFIG=` + KubeconfigPath
 
 const printVerificationStepsTemplate = `
 ========================================================================
@@ -91,7 +91,7 @@ For Worker 2
 
 ===
 Before you can verify the connectivity, the iPerf server needs to be exported for visibility. Run the following command
-to export the iPerf server
+to export the iPerf server from the controller cluster
 
 %s %s
 
@@ -140,12 +140,13 @@ func printNamespaceIsolationSteps(ApplicationConfiguration *ConfigurationSpecs)
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster
 	wc := ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters
 	iperfCommand := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[1].ContextName, "--kubeconfig="+wc[1].KubeConfigPath, "exec", "-it", "deploy/iperf-sleep", "-c", "iperf", "-n", "iperf", "--", "iperf", "-c", "iperf-server.iperf.svc.slice.local", "-p", "5201", "-i", "1", "-b", "10Mb;")
-	sliceApplyCommand := exec.Command(util.ExecutablePaths["kubectl"], "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "apply", "-f", kubesliceDirectory+"/"+sliceTemplateFileName)
-	sliceVerifyCommandWorker1 := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[0].ContextName, "--kubeconfig="+wc[0].KubeConfigPath, "get", "slice", "-n", "kubeslice-system")
-	sliceVerifyCommandWorker2 := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[1].ContextName, "--kubeconfig="+wc[1].KubeConfigPath, "get", "slice", "-n", "kubeslice-system")
+	sliceApplyCommand := exec.Command(util.ExecutablePaths["kubectl"], "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "apply", "-f", kubesliceDirectory+"/"+sliceFileName(configuredSlice(ApplicationConfiguration, nil, "").Name))
+	sliceVerifyCommandWorker1 := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[0].ContextName, "--kubeconfig="+wc[0].KubeConfigPath, "get", "slice", "-n", WorkerNamespace(wc[0]))
+	sliceVerifyCommandWorker2 := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[1].ContextName, "--kubeconfig="+wc[1].KubeConfigPath, "get", "slice", "-n", WorkerNamespace(wc[1]))
 	applyIPerfWorker1 := exec.Command(util.ExecutablePaths["kubectl"], "rollout ", "restart", "deployment/iperf-server", "-n", "iperf", "--context="+wc[0].ContextName, "--kubeconfig="+wc[0].KubeConfigPath)
 	applyIPerfWorker2 := exec.Command(util.ExecutablePaths["kubectl"], "rollout ", "restart", "deployment/iperf-sleep", "-n", "iperf", "--context="+wc[1].ContextName, "--kubeconfig="+wc[1].KubeConfigPath)
-	applyIPerfServiceExportWorker2 := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc[0].ContextName, "--kubeconfig="+wc[0].KubeConfigPath, "apply ", "-f", kubesliceDirectory+"/"+iPerfServerServiceExportFileName, "-n", "iperf")
+	applyIPerfServiceExportConfig := exec.Command(util.ExecutablePaths["kubectl"], append(kubectlClusterArgs(&cc), "apply", "-f", kubesliceDirectory+"/"+iPerfServiceExportConfigFileName(ApplicationConfiguration),
+		"-n", projectNamespace(ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName))...)
 	template := fmt.Sprintf(printNextStepsTemplateForSliceInstallation,
 		util.Run, iperfCommand.String(),
 		util.Run, sliceApplyCommand.String(),
@@ -153,8 +154,22 @@ func printNamespaceIsolationSteps(ApplicationConfiguration *ConfigurationSpecs)
 		util.Run, sliceVerifyCommandWorker2.String(),
 		util.Run, applyIPerfWorker1.String(),
 		util.Run, applyIPerfWorker2.String(),
-		util.Run, applyIPerfServiceExportWorker2.String(),
+		util.Run, applyIPerfServiceExportConfig.String(),
 		util.Run, iperfCommand.String(),
 	)
 	util.Printf(template)
 }
+
+// PrintSliceNextSteps prints how to create the slice of a demo with too few
+// workers for the iPerf application, and how to verify it propagated.
+func PrintSliceNextSteps(ApplicationConfiguration *ConfigurationSpecs) {
+	cc := ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster
+	sliceApplyCommand := exec.Command(util.ExecutablePaths["kubectl"], "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "apply", "-f", kubesliceDirectory+"/"+sliceFileName(configuredSlice(ApplicationConfiguration, nil, "").Name))
+	util.Printf("\n========================================================================")
+	util.Printf("Now that the KubeSlice Cluster Setup is complete, you can create a Slice using the following command:\n\n%s %s", util.Run, sliceApplyCommand.String())
+	util.Printf("\nThe slice propagation will take a few seconds. You can run the following commands to verify that slice\nhas propagated to worker clusters")
+	for _, wc := range ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters {
+		verifyCommand := exec.Command(util.ExecutablePaths["kubectl"], "--context="+wc.ContextName, "--kubeconfig="+wc.KubeConfigPath, "get", "slice", "-n", WorkerNamespace(wc))
+		util.Printf("\nFor %s\n%s %s", wc.Name, util.Run, verifyCommand.String())
+	}
+}
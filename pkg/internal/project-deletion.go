@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// workerSliceConfigObject is created by the controller in the project
+// namespace for every cluster of a slice, and is deleted once the worker is
+// offboarded from the slice.
+const workerSliceConfigObject = "workersliceconfigs.worker.kubeslice.io"
+
+// projectDependentObjects are the resources of the project namespace which
+// block the deletion of a project, in the order --cascade deletes them.
+var projectDependentObjects = []string{ServiceExportConfigObject, SliceConfigObject, ClusterObject}
+
+// projectDeletionPollInterval is how often the deletion of a project checks
+// whether the workers are offboarded and the project namespace is gone, for
+// up to --timeout.
+var projectDeletionPollInterval = 5 * time.Second
+
+// deleteKubeSliceProject deletes a project after the resources of its
+// namespace, which are only deleted with cascade. The service exports are
+// deleted first, then the slices, and the cluster registrations once the
+// workers are offboarded from the slices. With wait the workers of the
+// topology are waited for to offboard every deleted slice, as for the deletion
+// of a single sliceConfig.
+func deleteKubeSliceProject(specs *ConfigurationSpecs, projectName, namespace string, controllerCluster *Cluster, cascade, wait bool) error {
+	projectNS := projectNamespace(projectName)
+	dependents := make(map[string][]string)
+	remaining := make([]string, 0)
+	for _, object := range projectDependentObjects {
+		names, err := listResourceNames(object, projectNS, controllerCluster)
+		if err != nil {
+			return err
+		}
+		dependents[object] = names
+		for _, name := range names {
+			remaining = append(remaining, objectKind(object)+"/"+name)
+		}
+	}
+	if len(remaining) > 0 && !cascade {
+		return fmt.Errorf("project %s still has %s in namespace %s, delete them first or pass --cascade", projectName, strings.Join(remaining, ", "), projectNS)
+	}
+	offboarding := make(map[string][]sliceOffboarding)
+	if wait {
+		for _, slice := range dependents[SliceConfigObject] {
+			workers, err := sliceOffboardingWorkers(specs, slice, projectNS, controllerCluster)
+			if err != nil {
+				return err
+			}
+			offboarding[slice] = workers
+		}
+	}
+	for _, object := range projectDependentObjects {
+		if object == ClusterObject && len(dependents[SliceConfigObject]) > 0 {
+			err := waitForProjectDeletion("the workers to be offboarded from the slices", func() (bool, error) {
+				names, err := listResourceNames(workerSliceConfigObject, projectNS, controllerCluster)
+				return len(names) == 0, err
+			})
+			if err != nil {
+				return err
+			}
+			for _, slice := range dependents[SliceConfigObject] {
+				if err := waitForSliceOffboarding(slice, offboarding[slice]); err != nil {
+					return err
+				}
+			}
+			util.Printf("%s Workers offboarded from the slices of project %s", util.Tick, projectName)
+		}
+		for _, name := range dependents[object] {
+			if err := deleteResource(object, name, projectNS, controllerCluster); err != nil {
+				return err
+			}
+			util.Printf("%s Deleted %s %s", util.Tick, objectKind(object), name)
+		}
+	}
+	if err := deleteResource(ProjectObject, projectName, namespace, controllerCluster); err != nil {
+		return err
+	}
+	util.Printf("%s Deleted %s %s", util.Tick, objectKind(ProjectObject), projectName)
+	if !wait {
+		return nil
+	}
+	err := waitForProjectDeletion("namespace "+projectNS+" to be deleted", func() (bool, error) {
+		exists, err := objectExists(controllerCluster, namespaceResource, "", projectNS)
+		if err != nil {
+			return false, fmt.Errorf("unable to get namespace %s: %v", projectNS, err)
+		}
+		return !exists, nil
+	})
+	if err != nil {
+		return err
+	}
+	util.Printf("%s Namespace %s deleted", util.Tick, projectNS)
+	return nil
+}
+
+// objectKind returns the resource of an object type, e.g. sliceconfigs.
+func objectKind(object string) string {
+	return strings.SplitN(object, ".", 2)[0]
+}
+
+func listResourceNames(object, namespace string, cluster *Cluster) ([]string, error) {
+	names, err := kubeClient.List(cluster, object, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the %s of namespace %s: %v", objectKind(object), namespace, err)
+	}
+	return names, nil
+}
+
+func deleteResource(object, name, namespace string, cluster *Cluster) error {
+	if err := kubeClient.Delete(cluster, object, namespace, name); err != nil {
+		return fmt.Errorf("unable to delete %s %s: %v", objectKind(object), name, err)
+	}
+	return nil
+}
+
+func waitForProjectDeletion(description string, done func() (bool, error)) error {
+	start := time.Now()
+	for {
+		finished, err := done()
+		if err != nil || finished {
+			return err
+		}
+		elapsed := time.Since(start)
+		if elapsed >= Options.DeletionTimeout {
+			return fmt.Errorf("timed out after %d seconds waiting for %s", int(elapsed.Seconds()), description)
+		}
+		util.Printf("%s Waiting for %s... %d seconds elapsed", util.Wait, description, int(elapsed.Seconds()))
+		time.Sleep(projectDeletionPollInterval)
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestDeleteKubeSliceProject(t *testing.T) {
+	defer func(interval time.Duration) { projectDeletionPollInterval = interval }(projectDeletionPollInterval)
+	projectDeletionPollInterval = time.Millisecond
+
+	resources := map[string]string{
+		ServiceExportConfigObject: "iperf-server-iperf-ks-w-1",
+		SliceConfigObject:         "red blue",
+		ClusterObject:             "ks-w-1",
+	}
+	tests := []struct {
+		name     string
+		cascade  bool
+		expected []string
+		err      string
+	}{
+		{
+			name: "Refused with dependents",
+			err:  "project demo still has serviceexportconfigs/iperf-server-iperf-ks-w-1, sliceconfigs/red, sliceconfigs/blue, clusters/ks-w-1 in namespace kubeslice-demo",
+		},
+		{
+			name:    "Cascade",
+			cascade: true,
+			expected: []string{
+				"get sliceconfigs red",
+				"get sliceconfigs blue",
+				"delete serviceexportconfigs iperf-server-iperf-ks-w-1",
+				"delete sliceconfigs red",
+				"delete sliceconfigs blue",
+				"get workersliceconfigs",
+				"get workersliceconfigs",
+				"delete clusters ks-w-1",
+				"delete projects demo",
+				"get namespace",
+				"get namespace",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			workerSlices, namespaceLookups := 0, 0
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch {
+				case command[1] == "get" && command[2] == SliceConfigObject && command[3] != "-o":
+					fmt.Fprint(stdout, `{"spec": {"clusters": []}}`)
+				case command[1] == "get" && command[2] == workerSliceConfigObject:
+					// the workers are offboarded after the first poll
+					if workerSlices++; workerSlices == 1 {
+						fmt.Fprint(stdout, "red-ks-w-1")
+					}
+				case command[1] == "get" && command[2] == "namespaces":
+					if namespaceLookups++; namespaceLookups > 1 {
+						fmt.Fprint(stderr, `Error from server (NotFound): namespaces "kubeslice-demo" not found`)
+						return fmt.Errorf("exit status 1")
+					}
+					fmt.Fprint(stdout, `{"kind": "Namespace"}`)
+				case command[1] == "get":
+					fmt.Fprint(stdout, resources[command[2]])
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			err := deleteKubeSliceProject(&ConfigurationSpecs{}, "demo", KUBESLICE_CONTROLLER_NAMESPACE, nil, tc.cascade, true)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("deleteKubeSliceProject() error = %v, want %q", err, tc.err)
+				}
+				for _, command := range mock.Commands {
+					if command[1] == "delete" {
+						t.Errorf("deleteKubeSliceProject() ran %q after refusing the deletion", command)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("deleteKubeSliceProject() returned error: %v", err)
+			}
+			// the dependents are listed before anything is deleted
+			got := make([]string, 0)
+			for _, command := range mock.Commands[len(projectDependentObjects):] {
+				got = append(got, strings.Join([]string{command[1], objectKind(command[2]), command[3]}, " "))
+			}
+			matches := len(got) == len(tc.expected)
+			for i := 0; matches && i < len(got); i++ {
+				matches = strings.HasPrefix(got[i], tc.expected[i])
+			}
+			if !matches {
+				t.Errorf("commands =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.expected, "\n"))
+			}
+		})
+	}
+}
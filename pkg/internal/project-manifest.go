@@ -2,6 +2,8 @@ package internal
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
@@ -9,57 +11,216 @@ import (
 
 const (
 	projectFileName = "project.yaml"
+	// the namespace of a project is kubeslice-<project name>
+	maxProjectNameLength = 63 - len("kubeslice-")
+	// the controller creates the service account kubeslice-rbac-ro-<user name>
+	maxProjectUserLength = 63 - len("kubeslice-rbac-ro-")
 )
 
-const kubesliceProjectTemplate = `
-apiVersion: controller.kubeslice.io/v1alpha1
-kind: Project
-metadata:
-  name: %s
-  namespace: kubeslice-controller
-spec:
-  serviceAccount:
-    readWrite: %s
-`
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// projectUsersColumns shows the users of projects grouped by role when no
+// output format is requested.
+const projectUsersColumns = "custom-columns=NAME:.metadata.name,READWRITE:.spec.serviceAccount.readWrite,READONLY:.spec.serviceAccount.readOnly"
 
 func CreateKubeSliceProject(ApplicationConfiguration *ConfigurationSpecs, cliOptions *CliOptionsStruct) {
 	util.Printf("\nCreating KubeSlice Project...")
+	ksc := ApplicationConfiguration.Configuration.KubeSliceConfiguration
+	projects := configuredProjects(ksc)
+	namespace := ControllerNamespace(ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster)
+	if cliOptions != nil {
+		// create project only creates the project it is passed
+		projects = []KubeSliceConfiguration{projectConfiguration(ksc, ksc.ProjectName)}
+		if cliOptions.Namespace != "" {
+			namespace = cliOptions.Namespace
+		}
+	}
+	if cliOptions != nil && cliOptions.DryRun {
+		useForcedAPIVersion()
+		if cliOptions.FileName != "" {
+			PrintManifestFile(cliOptions.FileName)
+			return
+		}
+		manifest, err := kubesliceProjectManifest(namespace, projects...)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		printDryRunManifest(manifest)
+		return
+	}
+	GenerateKubeSliceDirectory()
+	controllerCluster := &ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster
+	if cliOptions != nil {
+		controllerCluster = cliOptions.Cluster
+	} else if untouchedClusters[controllerCluster.Name] {
+		util.Printf("%s Leaving cluster %s untouched", util.Tick, controllerCluster.Name)
+		return
+	}
+	WaitForControllerCRDs(controllerCluster)
 
-	generateKubeSliceProjectManifest(ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName, ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectUsers)
-	util.Printf("%s Generated project manifest %s", util.Tick, projectFileName)
-	time.Sleep(200 * time.Millisecond)
+	// a project manifest passed with -f is applied as is
+	if cliOptions == nil || cliOptions.FileName == "" {
+		DetectControllerAPIVersion(controllerCluster)
+		generateKubeSliceProjectManifest(namespace, projects...)
+		util.Printf("%s Generated project manifest %s", util.Tick, projectFileName)
+		time.Sleep(200 * time.Millisecond)
+	}
 	if cliOptions != nil {
 		if cliOptions.FileName == "" {
 			cliOptions.FileName = kubesliceDirectory + "/" + projectFileName
 		}
 		ApplyKubectlManifest(cliOptions.FileName, cliOptions.Namespace, cliOptions.Cluster)
 	} else {
-		ApplyKubectlManifest(kubesliceDirectory+"/"+projectFileName, KUBESLICE_CONTROLLER_NAMESPACE, &ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster)
+		ApplyKubectlManifest(kubesliceDirectory+"/"+projectFileName, namespace, controllerCluster)
 	}
 	util.Printf("%s Applied %s", util.Tick, projectFileName)
 	time.Sleep(3 * time.Second)
 	util.Printf("Created KubeSlice Project.")
 }
 
-func GetKubeSliceProject(projectName string, namespace string, controllerCluster *Cluster) {
+func GetKubeSliceProject(projectName string, namespace string, controllerCluster *Cluster, outputFormat string) {
 	util.Printf("\nFetching KubeSlice Project...")
-	GetKubectlResources(ProjectObject, projectName, namespace, controllerCluster, "")
+	if outputFormat == "" {
+		outputFormat = projectUsersColumns
+	}
+	GetKubectlResources(ProjectObject, projectName, namespace, controllerCluster, outputFormat)
 	time.Sleep(200 * time.Millisecond)
 }
-func generateKubeSliceProjectManifest(projectName string, users []string) {
-	if len(users) == 0 {
-		users = []string{"admin"}
+
+func generateKubeSliceProjectManifest(namespace string, projects ...KubeSliceConfiguration) {
+	manifest, err := kubesliceProjectManifest(namespace, projects...)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	util.DumpFile(manifest, kubesliceDirectory+"/"+projectFileName)
+}
+
+// kubesliceProjectManifest returns the manifest of the projects in the
+// controller namespace, one document per project.
+func kubesliceProjectManifest(namespace string, projects ...KubeSliceConfiguration) (string, error) {
+	tmpl, err := loadManifestTemplate(projectTemplateFile)
+	if err != nil {
+		return "", err
+	}
+	documents := make([]string, 0, len(projects))
+	for _, ksc := range projects {
+		if err := ValidateProjectName(ksc.ProjectName); err != nil {
+			return "", err
+		}
+		readWrite, readOnly, err := projectUsers(ksc)
+		if err != nil {
+			return "", err
+		}
+		project := projectManifest{
+			APIVersion: kubesliceControllerAPIVersion,
+			Kind:       "Project",
+			Metadata:   newObjectMeta(ksc.ProjectName, namespace, ksc, ksc.ProjectMetadata),
+			Spec:       projectSpec{ServiceAccount: projectServiceAccount{ReadWrite: readWrite, ReadOnly: readOnly}},
+		}
+		if tmpl == nil {
+			document, err := marshalManifests(project)
+			if err != nil {
+				return "", err
+			}
+			documents = append(documents, document)
+			continue
+		}
+		var rendered projectManifest
+		document, err := renderManifestTemplate(tmpl, ManifestTemplateData{
+			APIVersion:       project.APIVersion,
+			Kind:             project.Kind,
+			Name:             project.Metadata.Name,
+			Namespace:        project.Metadata.Namespace,
+			Labels:           project.Metadata.Labels,
+			Annotations:      project.Metadata.Annotations,
+			Project:          ksc.ProjectName,
+			ProjectNamespace: projectNamespace(ksc.ProjectName),
+			ReadWrite:        readWrite,
+			ReadOnly:         readOnly,
+			Spec:             project.Spec,
+		}, &rendered)
+		if err != nil {
+			return "", err
+		}
+		if err := validateRenderedProject(tmpl, rendered); err != nil {
+			return "", err
+		}
+		documents = append(documents, document)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+// projectUsers returns the read-write and read-only users of the project.
+// project_users are read-write users, and admin is the read-write user of a
+// project without users.
+func projectUsers(ksc KubeSliceConfiguration) ([]string, []string, error) {
+	var readWrite, readOnly []string
+	for _, user := range append(append([]string{}, ksc.ProjectUsers...), ksc.Users.ReadWrite...) {
+		readWrite = appendUnique(readWrite, user)
 	}
-	userString := "\n"
-	for _, user := range users {
-		userString = fmt.Sprintf(`%s      - %s%s`, userString, user, "\n")
+	for _, user := range ksc.Users.ReadOnly {
+		readOnly = appendUnique(readOnly, user)
 	}
-	util.DumpFile(fmt.Sprintf(kubesliceProjectTemplate, projectName, userString), kubesliceDirectory+"/"+projectFileName)
+	for _, user := range append(append([]string{}, readWrite...), readOnly...) {
+		if err := validateName("user name", user, maxProjectUserLength); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, user := range readOnly {
+		for _, rw := range readWrite {
+			if user == rw {
+				return nil, nil, fmt.Errorf("user %s of project %s cannot be both in configuration.kubeslice_configuration.users.readwrite and users.readonly", user, ksc.ProjectName)
+			}
+		}
+	}
+	if len(readWrite) == 0 && len(readOnly) == 0 {
+		readWrite = []string{"admin"}
+	}
+	return readWrite, readOnly, nil
 }
 
-func DeleteKubeSliceProject(projectName string, namespace string, controllerCluster *Cluster) {
+// ValidateProject returns the errors of the project name and users of the
+// topology, which are found before any cluster is touched.
+func ValidateProject(ksc KubeSliceConfiguration) []string {
+	errors := make([]string, 0)
+	// a missing project name is reported by the topology validation
+	if ksc.ProjectName != "" {
+		if err := ValidateProjectName(ksc.ProjectName); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+		}
+	}
+	if _, _, err := projectUsers(ksc); err != nil {
+		errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+	}
+	return errors
+}
+
+// ValidateProjectName checks that the name of a project is a DNS-1123 label
+// short enough for the namespace of the project to be one as well.
+func ValidateProjectName(name string) error {
+	return validateName("project name", name, maxProjectNameLength)
+}
+
+func validateName(kind, name string, maxLength int) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%s must not be empty", kind)
+	case len(name) > maxLength:
+		return fmt.Errorf("%s %q is invalid: must be no more than %d characters", kind, name, maxLength)
+	case !dns1123Label.MatchString(name):
+		return fmt.Errorf("%s %q is invalid: must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character", kind, name)
+	}
+	return nil
+}
+
+// DeleteKubeSliceProject deletes a project, which is refused while its
+// namespace has slices, service exports or registered clusters unless they
+// are deleted with cascade. wait blocks until the project namespace is gone.
+func DeleteKubeSliceProject(specs *ConfigurationSpecs, projectName string, namespace string, controllerCluster *Cluster, cascade, wait bool) {
 	util.Printf("\nDeleting KubeSlice Project...")
-	DeleteKubectlResources(ProjectObject, projectName, namespace, controllerCluster)
+	if err := deleteKubeSliceProject(specs, projectName, namespace, controllerCluster, cascade, wait); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
 	time.Sleep(200 * time.Millisecond)
 }
 
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestKubesliceProjectManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		ksc            KubeSliceConfiguration
+		serviceAccount projectServiceAccount
+		err            string
+	}{
+		{
+			name:           "Default admin",
+			ksc:            KubeSliceConfiguration{ProjectName: "demo"},
+			serviceAccount: projectServiceAccount{ReadWrite: []string{"admin"}},
+		},
+		{
+			name:           "Legacy flat list",
+			ksc:            KubeSliceConfiguration{ProjectName: "demo", ProjectUsers: []string{"alice", "bob", "alice"}},
+			serviceAccount: projectServiceAccount{ReadWrite: []string{"alice", "bob"}},
+		},
+		{
+			name:           "Only readonly",
+			ksc:            KubeSliceConfiguration{ProjectName: "demo", Users: ProjectUsers{ReadOnly: []string{"auditor"}}},
+			serviceAccount: projectServiceAccount{ReadOnly: []string{"auditor"}},
+		},
+		{
+			name: "Both roles",
+			ksc: KubeSliceConfiguration{ProjectName: "demo", ProjectUsers: []string{"alice"},
+				Users: ProjectUsers{ReadWrite: []string{"bob"}, ReadOnly: []string{"auditor", "viewer"}}},
+			serviceAccount: projectServiceAccount{ReadWrite: []string{"alice", "bob"}, ReadOnly: []string{"auditor", "viewer"}},
+		},
+		{
+			name: "Duplicate across roles",
+			ksc: KubeSliceConfiguration{ProjectName: "demo", ProjectUsers: []string{"alice"},
+				Users: ProjectUsers{ReadOnly: []string{"alice"}}},
+			err: "user alice of project demo cannot be both",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			manifest, err := kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, tc.ksc)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("kubesliceProjectManifest() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("kubesliceProjectManifest() returned error: %v", err)
+			}
+			var project projectManifest
+			if err := yaml.Unmarshal([]byte(manifest), &project); err != nil {
+				t.Fatalf("kubesliceProjectManifest() is not valid YAML: %v", err)
+			}
+			if !reflect.DeepEqual(project.Spec.ServiceAccount, tc.serviceAccount) {
+				t.Errorf("kubesliceProjectManifest() =\n%s\nwant serviceAccount %+v", manifest, tc.serviceAccount)
+			}
+		})
+	}
+}
+
+func TestValidateProjectNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		project string
+		user    string
+		err     string
+	}{
+		{name: "Valid", project: "demo-1", user: "alice"},
+		{name: "Leading digits are DNS-1123 labels", project: "1demo", user: "007"},
+		{name: "Empty project", project: "", user: "alice", err: "project name must not be empty"},
+		{name: "Empty user", project: "demo", user: "", err: "user name must not be empty"},
+		{name: "Uppercase project", project: "Demo", user: "alice", err: `project name "Demo" is invalid: must consist of lower case`},
+		{name: "Underscore user", project: "demo", user: "team_a", err: `user name "team_a" is invalid: must consist of lower case`},
+		{name: "Space in user", project: "demo", user: "alice smith", err: `user name "alice smith" is invalid`},
+		{name: "Trailing dash project", project: "demo-", user: "alice", err: "must start and end with an alphanumeric character"},
+		{name: "64 character project", project: strings.Repeat("a", 64), user: "alice", err: "must be no more than 53 characters"},
+		{name: "Project namespace too long", project: strings.Repeat("a", 54), user: "alice", err: "must be no more than 53 characters"},
+		{name: "64 character user", project: "demo", user: strings.Repeat("a", 64), err: "must be no more than 45 characters"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, KubeSliceConfiguration{ProjectName: tc.project, ProjectUsers: []string{tc.user}})
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("kubesliceProjectManifest() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("kubesliceProjectManifest() error = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Roles of the users of a project.
+const (
+	ProjectRoleReadWrite = "readwrite"
+	ProjectRoleReadOnly  = "readonly"
+)
+
+const projectEditFileName = "project-edit.yaml"
+
+// projectRoleFields are the fields of spec.serviceAccount holding the users of
+// each role.
+var projectRoleFields = map[string]string{
+	ProjectRoleReadWrite: "readWrite",
+	ProjectRoleReadOnly:  "readOnly",
+}
+
+// EditKubeSliceProjectUsers adds and removes users of a role of a live
+// project and applies the result, or prints it with dryRun.
+func EditKubeSliceProjectUsers(projectName, namespace string, controllerCluster *Cluster, add, remove []string, role string, force, dryRun bool) {
+	util.Printf("\nEditing users of KubeSlice Project %s...", projectName)
+	var outB, errB bytes.Buffer
+	cmdArgs := []string{}
+	if controllerCluster != nil {
+		cmdArgs = append(cmdArgs, "--context="+controllerCluster.ContextName, "--kubeconfig="+controllerCluster.KubeConfigPath)
+	}
+	cmdArgs = append(cmdArgs, "get", ProjectObject, projectName, "-n", namespace, "-o", "json")
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, cmdArgs...); err != nil {
+		util.Fatalf("%s Unable to fetch project %s: %v %s", util.Cross, projectName, err, errB.String())
+	}
+	var project map[string]interface{}
+	if err := json.Unmarshal(outB.Bytes(), &project); err != nil {
+		util.Fatalf("%s Unable to parse project %s: %v", util.Cross, projectName, err)
+	}
+	warnings, err := editProjectUsers(project, add, remove, role, force)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	for _, warning := range warnings {
+		util.Printf("%s %s", util.Warn, warning)
+	}
+	out, err := yaml.Marshal(project)
+	if err != nil {
+		util.Fatalf("%s Unable to encode project %s: %v", util.Cross, projectName, err)
+	}
+	if dryRun {
+		util.Printf("%s", out)
+		return
+	}
+	GenerateKubeSliceDirectory()
+	util.DumpFile(string(out), kubesliceDirectory+"/"+projectEditFileName)
+	ApplyKubectlManifest(kubesliceDirectory+"/"+projectEditFileName, namespace, controllerCluster)
+	util.Printf("%s Updated users of project %s", util.Tick, projectName)
+}
+
+// editProjectUsers adds and removes users of a role of a Project fetched with
+// kubectl get -o json. Removing a user which is not present is a warning, and
+// removing the last read-write user requires force. The fields of the live
+// object kubectl apply does not accept are dropped, the resource version is
+// kept so that a concurrent change is not overwritten.
+func editProjectUsers(project map[string]interface{}, add, remove []string, role string, force bool) ([]string, error) {
+	if _, ok := projectRoleFields[role]; !ok {
+		return nil, fmt.Errorf("unknown role: %s. Possible values %s", role, []string{ProjectRoleReadWrite, ProjectRoleReadOnly})
+	}
+	name := ""
+	if metadata, ok := project["metadata"].(map[string]interface{}); ok {
+		name, _ = metadata["name"].(string)
+		delete(metadata, "managedFields")
+	}
+	delete(project, "status")
+	spec, _ := project["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = make(map[string]interface{})
+		project["spec"] = spec
+	}
+	serviceAccount, _ := spec["serviceAccount"].(map[string]interface{})
+	if serviceAccount == nil {
+		serviceAccount = make(map[string]interface{})
+		spec["serviceAccount"] = serviceAccount
+	}
+	users := make(map[string][]string)
+	for r, f := range projectRoleFields {
+		items, _ := serviceAccount[f].([]interface{})
+		for _, item := range items {
+			if user, ok := item.(string); ok {
+				users[r] = appendUnique(users[r], user)
+			}
+		}
+	}
+	hadReadWrite := len(users[ProjectRoleReadWrite]) > 0
+
+	warnings := make([]string, 0)
+	for _, user := range remove {
+		kept := make([]string, 0, len(users[role]))
+		for _, u := range users[role] {
+			if u != user {
+				kept = append(kept, u)
+			}
+		}
+		if len(kept) == len(users[role]) {
+			warnings = append(warnings, fmt.Sprintf("User %s is not a %s user of project %s", user, role, name))
+		}
+		users[role] = kept
+	}
+	for _, user := range add {
+		if err := validateName("user name", user, maxProjectUserLength); err != nil {
+			return nil, err
+		}
+		for r := range projectRoleFields {
+			if r == role {
+				continue
+			}
+			for _, u := range users[r] {
+				if u == user {
+					return nil, fmt.Errorf("user %s is a %s user of project %s, remove it from that role first", user, r, name)
+				}
+			}
+		}
+		users[role] = appendUnique(users[role], user)
+	}
+	if hadReadWrite && len(users[ProjectRoleReadWrite]) == 0 && !force {
+		return nil, fmt.Errorf("refusing to remove the last readwrite user of project %s, pass --force to remove it", name)
+	}
+
+	for r, f := range projectRoleFields {
+		if len(users[r]) == 0 {
+			delete(serviceAccount, f)
+			continue
+		}
+		items := make([]interface{}, 0, len(users[r]))
+		for _, user := range users[r] {
+			items = append(items, user)
+		}
+		serviceAccount[f] = items
+	}
+	return warnings, nil
+}
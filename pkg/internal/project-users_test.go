@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditProjectUsers(t *testing.T) {
+	t.Parallel()
+
+	const live = `{
+  "apiVersion": "controller.kubeslice.io/v1alpha1",
+  "kind": "Project",
+  "metadata": {"name": "demo", "namespace": "kubeslice-controller", "resourceVersion": "42", "managedFields": [{"manager": "kubectl"}]},
+  "spec": {"serviceAccount": {"readWrite": ["admin", "bob"], "readOnly": ["auditor"]}},
+  "status": {"namespace": "kubeslice-demo"}
+}`
+	tests := []struct {
+		name      string
+		add       []string
+		remove    []string
+		role      string
+		force     bool
+		readWrite []interface{}
+		readOnly  []interface{}
+		warnings  int
+		err       string
+	}{
+		{
+			name:      "Add and remove readwrite users",
+			add:       []string{"alice", "admin"},
+			remove:    []string{"bob"},
+			role:      ProjectRoleReadWrite,
+			readWrite: []interface{}{"admin", "alice"},
+			readOnly:  []interface{}{"auditor"},
+		},
+		{
+			name:      "Add readonly user",
+			add:       []string{"viewer"},
+			role:      ProjectRoleReadOnly,
+			readWrite: []interface{}{"admin", "bob"},
+			readOnly:  []interface{}{"auditor", "viewer"},
+		},
+		{
+			name:      "Remove missing user",
+			remove:    []string{"carol"},
+			role:      ProjectRoleReadOnly,
+			readWrite: []interface{}{"admin", "bob"},
+			readOnly:  []interface{}{"auditor"},
+			warnings:  1,
+		},
+		{
+			name:   "Remove last readwrite user",
+			remove: []string{"admin", "bob"},
+			role:   ProjectRoleReadWrite,
+			err:    "refusing to remove the last readwrite user of project demo",
+		},
+		{
+			name:     "Remove last readwrite user with force",
+			remove:   []string{"admin", "bob"},
+			role:     ProjectRoleReadWrite,
+			force:    true,
+			readOnly: []interface{}{"auditor"},
+		},
+		{
+			name: "User with both roles",
+			add:  []string{"auditor"},
+			role: ProjectRoleReadWrite,
+			err:  "user auditor is a readonly user of project demo",
+		},
+		{
+			name: "Invalid user name",
+			add:  []string{"Alice"},
+			role: ProjectRoleReadWrite,
+			err:  `user name "Alice" is invalid`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var project map[string]interface{}
+			if err := json.Unmarshal([]byte(live), &project); err != nil {
+				t.Fatal(err)
+			}
+			warnings, err := editProjectUsers(project, tc.add, tc.remove, tc.role, tc.force)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("editProjectUsers() error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("editProjectUsers() returned error: %v", err)
+			}
+			if len(warnings) != tc.warnings {
+				t.Errorf("editProjectUsers() warnings = %q, want %d", warnings, tc.warnings)
+			}
+			serviceAccount := project["spec"].(map[string]interface{})["serviceAccount"].(map[string]interface{})
+			if got, _ := serviceAccount["readWrite"].([]interface{}); !reflect.DeepEqual(got, tc.readWrite) {
+				t.Errorf("readWrite = %v, want %v", got, tc.readWrite)
+			}
+			if got, _ := serviceAccount["readOnly"].([]interface{}); !reflect.DeepEqual(got, tc.readOnly) {
+				t.Errorf("readOnly = %v, want %v", got, tc.readOnly)
+			}
+			metadata := project["metadata"].(map[string]interface{})
+			if _, ok := metadata["managedFields"]; ok || project["status"] != nil || metadata["resourceVersion"] != "42" {
+				t.Errorf("editProjectUsers() metadata = %v, status = %v", metadata, project["status"])
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func projectNamespace(projectName string) string {
+	return "kubeslice-" + projectName
+}
+
+// SetDefaultProject makes the first project of the project section the one
+// of project_name when it is not set.
+func SetDefaultProject(ksc *KubeSliceConfiguration) {
+	if ksc.ProjectName == "" && len(ksc.Projects) > 0 {
+		ksc.ProjectName = ksc.Projects[0].Name
+	}
+}
+
+// configuredProjects returns the configuration of every project of the
+// topology, the single project of project_name without a project section.
+func configuredProjects(ksc KubeSliceConfiguration) []KubeSliceConfiguration {
+	if len(ksc.Projects) == 0 {
+		return []KubeSliceConfiguration{ksc}
+	}
+	projects := make([]KubeSliceConfiguration, 0, len(ksc.Projects))
+	for _, project := range ksc.Projects {
+		projects = append(projects, projectConfiguration(ksc, project.Name))
+	}
+	return projects
+}
+
+// projectConfiguration returns the configuration of the project named
+// projectName, with the users and metadata of its entry in the project
+// section. Projects outside of the section keep the users of the topology.
+func projectConfiguration(ksc KubeSliceConfiguration, projectName string) KubeSliceConfiguration {
+	config := ksc
+	config.ProjectName = projectName
+	for _, project := range ksc.Projects {
+		if project.Name == projectName {
+			config.ProjectUsers = nil
+			config.Users = project.Users
+			config.ProjectMetadata = ObjectMetadata{
+				Labels:      mergeStringMaps(ksc.ProjectMetadata.Labels, project.Metadata.Labels),
+				Annotations: mergeStringMaps(ksc.ProjectMetadata.Annotations, project.Metadata.Annotations),
+			}
+		}
+	}
+	return config
+}
+
+// ProjectNames returns the names of the projects of the topology.
+func ProjectNames(ksc KubeSliceConfiguration) []string {
+	names := make([]string, 0)
+	for _, project := range configuredProjects(ksc) {
+		names = append(names, project.ProjectName)
+	}
+	return names
+}
+
+// workerProject returns the project a worker is registered with.
+func workerProject(ksc KubeSliceConfiguration, cluster Cluster) string {
+	if cluster.Project != "" {
+		return cluster.Project
+	}
+	return ksc.ProjectName
+}
+
+// projectWorkers returns the workers registered with a project.
+func projectWorkers(config Configuration, projectName string) []Cluster {
+	workers := make([]Cluster, 0)
+	for _, cluster := range config.ClusterConfiguration.WorkerClusters {
+		if workerProject(config.KubeSliceConfiguration, cluster) == projectName {
+			workers = append(workers, cluster)
+		}
+	}
+	return workers
+}
+
+// withWorkers returns a copy of the topology with only the workers passed.
+func withWorkers(ApplicationConfiguration *ConfigurationSpecs, workers []Cluster) *ConfigurationSpecs {
+	specs := *ApplicationConfiguration
+	specs.Configuration.ClusterConfiguration.WorkerClusters = workers
+	return &specs
+}
+
+// ValidateProjects checks the projects of the topology and the projects the
+// workers are registered with.
+func ValidateProjects(config Configuration) []string {
+	errors := make([]string, 0)
+	ksc := config.KubeSliceConfiguration
+	if len(ksc.Projects) > 0 {
+		if len(ksc.ProjectUsers) > 0 || len(ksc.Users.ReadWrite) > 0 || len(ksc.Users.ReadOnly) > 0 {
+			errors = append(errors, fmt.Sprintf("%s configuration.kubeslice_configuration.project_users and users cannot be combined with configuration.kubeslice_configuration.project, set the users of each project instead", util.Cross))
+		}
+		seen := make(map[string]bool)
+		for i, project := range ksc.Projects {
+			if seen[project.Name] {
+				errors = append(errors, fmt.Sprintf("%s configuration.kubeslice_configuration.project[%d]: project %s is configured more than once", util.Cross, i, project.Name))
+			}
+			seen[project.Name] = true
+		}
+		if ksc.ProjectName != "" && !seen[ksc.ProjectName] {
+			errors = append(errors, fmt.Sprintf("%s configuration.kubeslice_configuration.project_name: project %s is not one of configuration.kubeslice_configuration.project", util.Cross, ksc.ProjectName))
+		}
+	}
+	for _, project := range configuredProjects(ksc) {
+		errors = append(errors, ValidateProject(project)...)
+	}
+	projects := ProjectNames(ksc)
+	for i, cluster := range config.ClusterConfiguration.WorkerClusters {
+		if cluster.Project != "" && !containsString(projects, cluster.Project) {
+			errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers[%d].project: project %s is not a project of the topology", util.Cross, i, cluster.Project))
+		}
+	}
+	return errors
+}
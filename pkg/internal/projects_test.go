@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestProjectList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []string
+	}{
+		{name: "Single project", yaml: "project:\n  name: red\n", expected: []string{"red"}},
+		{name: "List of projects", yaml: "project:\n- name: red\n- name: blue\n", expected: []string{"red", "blue"}},
+		{name: "Legacy project_name", yaml: "project_name: demo\n", expected: []string{"demo"}},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ksc KubeSliceConfiguration
+			if err := yaml.Unmarshal([]byte(tc.yaml), &ksc); err != nil {
+				t.Fatalf("yaml.Unmarshal() returned error: %v", err)
+			}
+			SetDefaultProject(&ksc)
+			if got := ProjectNames(ksc); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ProjectNames() = %v, want %v", got, tc.expected)
+			}
+			if ksc.ProjectName != tc.expected[0] {
+				t.Errorf("ProjectName = %q, want %q", ksc.ProjectName, tc.expected[0])
+			}
+		})
+	}
+}
+
+func TestConfiguredProjects(t *testing.T) {
+	t.Parallel()
+
+	ksc := KubeSliceConfiguration{
+		ProjectName:     "red",
+		ProjectMetadata: ObjectMetadata{Labels: map[string]string{"team": "platform", "env": "dev"}},
+		Projects: ProjectList{
+			{Name: "red", Users: ProjectUsers{ReadWrite: []string{"alice"}}},
+			{Name: "blue", Metadata: ObjectMetadata{Labels: map[string]string{"env": "prod"}}},
+		},
+	}
+	projects := configuredProjects(ksc)
+	if len(projects) != 2 {
+		t.Fatalf("configuredProjects() returned %d projects, want 2", len(projects))
+	}
+	if projects[0].ProjectName != "red" || !reflect.DeepEqual(projects[0].Users.ReadWrite, []string{"alice"}) {
+		t.Errorf("project red = %+v, want the read-write user alice", projects[0])
+	}
+	if expected := map[string]string{"team": "platform", "env": "prod"}; !reflect.DeepEqual(projects[1].ProjectMetadata.Labels, expected) {
+		t.Errorf("labels of project blue = %v, want %v", projects[1].ProjectMetadata.Labels, expected)
+	}
+
+	manifest, err := kubesliceProjectManifest(KUBESLICE_CONTROLLER_NAMESPACE, projects...)
+	if err != nil {
+		t.Fatalf("kubesliceProjectManifest() returned error: %v", err)
+	}
+	if got := strings.Count(manifest, "kind: Project"); got != 2 {
+		t.Errorf("kubesliceProjectManifest() has %d projects, want 2:\n%s", got, manifest)
+	}
+}
+
+func TestValidateProjects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		ksc      KubeSliceConfiguration
+		project  string // project of the worker
+		expected string
+	}{
+		{
+			name: "Valid",
+			ksc:  KubeSliceConfiguration{ProjectName: "red", Projects: ProjectList{{Name: "red"}, {Name: "blue"}}},
+		},
+		{
+			name:     "Duplicate project",
+			ksc:      KubeSliceConfiguration{ProjectName: "red", Projects: ProjectList{{Name: "red"}, {Name: "red"}}},
+			expected: "project red is configured more than once",
+		},
+		{
+			name:     "Unknown project_name",
+			ksc:      KubeSliceConfiguration{ProjectName: "green", Projects: ProjectList{{Name: "red"}}},
+			expected: "project green is not one of",
+		},
+		{
+			name:     "Users outside of the projects",
+			ksc:      KubeSliceConfiguration{ProjectName: "red", ProjectUsers: []string{"alice"}, Projects: ProjectList{{Name: "red"}}},
+			expected: "set the users of each project instead",
+		},
+		{
+			name:     "Unknown project of a worker",
+			ksc:      KubeSliceConfiguration{ProjectName: "red", Projects: ProjectList{{Name: "red"}}},
+			project:  "blue",
+			expected: "workers[0].project: project blue is not a project of the topology",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := Configuration{
+				KubeSliceConfiguration: tc.ksc,
+				ClusterConfiguration:   ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1", Project: tc.project}}},
+			}
+			errors := ValidateProjects(config)
+			if tc.expected == "" {
+				if len(errors) != 0 {
+					t.Errorf("ValidateProjects() = %q, want no errors", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || !strings.Contains(errors[0], tc.expected) {
+				t.Errorf("ValidateProjects() = %q, want an error containing %q", errors, tc.expected)
+			}
+		})
+	}
+}
+
+func TestClusterRegistrationProjectNamespaces(t *testing.T) {
+	t.Parallel()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "red", Projects: ProjectList{{Name: "red"}, {Name: "blue"}}},
+		ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{
+			{Name: "worker-1"},
+			{Name: "worker-2", Project: "blue"},
+		}},
+	}}
+	manifest, err := clusterRegistrationManifest(specs, "")
+	if err != nil {
+		t.Fatalf("clusterRegistrationManifest() returned error: %v", err)
+	}
+	namespaces := make(map[string]string)
+	for _, document := range strings.Split(manifest, "\n---\n") {
+		var cluster clusterManifest
+		if err := yaml.Unmarshal([]byte(document), &cluster); err != nil {
+			t.Fatalf("clusterRegistrationManifest() is not valid YAML: %v", err)
+		}
+		namespaces[cluster.Metadata.Name] = cluster.Metadata.Namespace
+	}
+	if expected := map[string]string{"worker-1": "kubeslice-red", "worker-2": "kubeslice-blue"}; !reflect.DeepEqual(namespaces, expected) {
+		t.Errorf("namespaces of the clusters = %v, want %v", namespaces, expected)
+	}
+	if got := configuredSlice(specs, nil, "").Clusters; !reflect.DeepEqual(got, []string{"worker-1"}) {
+		t.Errorf("clusters of the slice = %v, want the workers of the default project [worker-1]", got)
+	}
+	if got := projectClusterRegistrationFileName(specs.Configuration.KubeSliceConfiguration, "blue"); got != "cluster-registration-blue.yaml" {
+		t.Errorf("projectClusterRegistrationFileName() = %q, want cluster-registration-blue.yaml", got)
+	}
+}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// proxyCommands are the external commands the proxy settings are injected
+// into. The container runtimes and kind pass them on to the image pulls of
+// the kind nodes, kubectl only needs no_proxy to reach the api servers of the
+// kind clusters directly.
+var proxyCommands = []string{"helm", "docker", "podman", "nerdctl", "kind", "kubectl"}
+
+// activeProxy is the proxy configured for the run, empty when there is none.
+var activeProxy ProxyConfiguration
+
+// ConfigureProxy injects the proxy of the topology, overridden by the
+// --http-proxy, --https-proxy and --no-proxy flags, into the environment of
+// the external commands. no_proxy always includes the local addresses and the
+// api servers of the clusters, so that only the repositories and registries
+// are reached through the proxy.
+func ConfigureProxy(specs *ConfigurationSpecs) {
+	proxy := specs.Configuration.ProxyConfiguration
+	if Options.HTTPProxy != "" {
+		proxy.HTTPProxy = Options.HTTPProxy
+	}
+	if Options.HTTPSProxy != "" {
+		proxy.HTTPSProxy = Options.HTTPSProxy
+	}
+	if Options.NoProxy != "" {
+		proxy.NoProxy = Options.NoProxy
+	}
+	if proxy.HTTPProxy == "" && proxy.HTTPSProxy == "" {
+		activeProxy = ProxyConfiguration{}
+		return
+	}
+	proxy.NoProxy = strings.Join(noProxyHosts(proxy.NoProxy, &specs.Configuration.ClusterConfiguration), ",")
+	for _, p := range []string{proxy.HTTPProxy, proxy.HTTPSProxy} {
+		if u, err := url.Parse(p); err == nil && u.User != nil {
+			password, _ := u.User.Password()
+			util.RegisterSecret(password)
+		}
+	}
+	activeProxy = proxy
+	env := proxyEnv(proxy)
+	for _, cli := range proxyCommands {
+		util.SetCommandEnv(cli, env...)
+	}
+	registryClient.Transport = &http.Transport{Proxy: proxyForRequest}
+	util.Debugf("Using http proxy %q, https proxy %q, no proxy %q", util.Redact(proxy.HTTPProxy), util.Redact(proxy.HTTPSProxy), proxy.NoProxy)
+}
+
+// proxyEnv returns the environment variables of the proxy, in upper and lower
+// case since tools disagree on which one they read.
+func proxyEnv(proxy ProxyConfiguration) []string {
+	env := make([]string, 0, 6)
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", proxy.HTTPProxy},
+		{"HTTPS_PROXY", proxy.HTTPSProxy},
+		{"NO_PROXY", proxy.NoProxy},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+		}
+	}
+	return env
+}
+
+// proxySettings describes the proxy of the run with its credentials
+// redacted, empty when there is none.
+func proxySettings() string {
+	if activeProxy.HTTPProxy == "" && activeProxy.HTTPSProxy == "" {
+		return ""
+	}
+	value := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return util.Redact(s)
+	}
+	return fmt.Sprintf("http proxy %s, https proxy %s, no proxy %s", value(activeProxy.HTTPProxy), value(activeProxy.HTTPSProxy), value(activeProxy.NoProxy))
+}
+
+// noProxyHosts returns the configured no_proxy entries, or those of the
+// environment when none are configured, followed by the local addresses and
+// the api servers of the clusters.
+func noProxyHosts(noProxy string, cc *ClusterConfiguration) []string {
+	if noProxy == "" {
+		noProxy = os.Getenv("NO_PROXY")
+	}
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	hosts := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(host string) {
+		host = strings.TrimSpace(host)
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	for _, host := range strings.Split(noProxy, ",") {
+		add(host)
+	}
+	add("localhost")
+	add("127.0.0.1")
+	add("::1")
+	kind := kindTopology(*cc)
+	for _, cluster := range getAllClusters(cc) {
+		if kind {
+			add(cluster.Name + "-control-plane")
+		}
+		if u, err := url.Parse(cluster.ControlPlaneAddress); err == nil && u.Hostname() != "" {
+			add(u.Hostname())
+		}
+	}
+	return hosts
+}
+
+// proxyForRequest selects the proxy of a request of the registry check the
+// same way helm does from the injected environment.
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	if bypassProxy(req.URL.Hostname(), activeProxy.NoProxy) {
+		return nil, nil
+	}
+	proxy := activeProxy.HTTPProxy
+	if req.URL.Scheme == "https" {
+		proxy = activeProxy.HTTPSProxy
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	return parseProxyURL(proxy)
+}
+
+// bypassProxy reports whether host matches an entry of noProxy, which is a
+// host, a domain suffix like .example.com, a CIDR, or * for every host.
+func bypassProxy(host, noProxy string) bool {
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if strings.EqualFold(host, domain) || strings.HasSuffix(strings.ToLower(host), "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	return url.Parse(proxy)
+}
+
+// proxyErrorHint explains a connectivity failure in the output of a command
+// when a proxy is configured and it cannot be reached, which otherwise only
+// shows up as an unreachable repository or registry.
+func proxyErrorHint(output string) string {
+	proxy := activeProxy.HTTPSProxy
+	if proxy == "" {
+		proxy = activeProxy.HTTPProxy
+	}
+	if proxy == "" || !isConnectivityError(output) {
+		return ""
+	}
+	if err := dialProxy(proxy); err != nil {
+		return fmt.Sprintf("the configured proxy %s could not be reached (%v). Check configuration.proxy_configuration or the --http-proxy and --https-proxy flags", util.Redact(proxy), err)
+	}
+	if strings.Contains(strings.ToLower(output), "proxyconnect") {
+		return fmt.Sprintf("the configured proxy %s refused the connection. Check configuration.proxy_configuration and the proxy credentials", util.Redact(proxy))
+	}
+	return ""
+}
+
+// dialProxy tests whether a TCP connection to the proxy can be opened.
+func dialProxy(proxy string) error {
+	u, err := parseProxyURL(proxy)
+	if err != nil {
+		return err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
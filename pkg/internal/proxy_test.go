@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestConfigureProxy(t *testing.T) {
+	defer func(transport http.RoundTripper) {
+		Options.HTTPProxy, Options.HTTPSProxy, Options.NoProxy = "", "", ""
+		activeProxy = ProxyConfiguration{}
+		for _, cli := range proxyCommands {
+			util.SetCommandEnv(cli)
+		}
+		registryClient.Transport = transport
+	}(registryClient.Transport)
+
+	clusters := ClusterConfiguration{
+		ClusterType:       Kind_Component,
+		ControllerCluster: Cluster{Name: "ks-ctrl"},
+		WorkerClusters:    []Cluster{{Name: "ks-w1", ControlPlaneAddress: "https://172.18.0.3:6443"}},
+	}
+	kindNoProxy := "localhost,127.0.0.1,::1,ks-ctrl-control-plane,ks-w1-control-plane,172.18.0.3"
+
+	tests := []struct {
+		name     string
+		proxy    ProxyConfiguration
+		flags    RunOptions
+		expected []string
+	}{
+		{
+			name:  "Proxy of the topology",
+			proxy: ProxyConfiguration{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: "internal.example.com"},
+			expected: []string{
+				"HTTPS_PROXY=http://proxy.example.com:3128", "https_proxy=http://proxy.example.com:3128",
+				"NO_PROXY=internal.example.com," + kindNoProxy, "no_proxy=internal.example.com," + kindNoProxy,
+			},
+		},
+		{
+			name:  "Flags override the topology",
+			proxy: ProxyConfiguration{HTTPProxy: "http://proxy.example.com:3128", NoProxy: "internal.example.com"},
+			flags: RunOptions{HTTPProxy: "http://other.example.com:8080", NoProxy: ".corp"},
+			expected: []string{
+				"HTTP_PROXY=http://other.example.com:8080", "http_proxy=http://other.example.com:8080",
+				"NO_PROXY=.corp," + kindNoProxy, "no_proxy=.corp," + kindNoProxy,
+			},
+		},
+		{
+			name:  "No proxy configured",
+			proxy: ProxyConfiguration{NoProxy: "internal.example.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.HTTPProxy, Options.HTTPSProxy, Options.NoProxy = tc.flags.HTTPProxy, tc.flags.HTTPSProxy, tc.flags.NoProxy
+			for _, cli := range proxyCommands {
+				util.SetCommandEnv(cli)
+			}
+			mock := &util.MockExecutor{}
+			defer util.UseExecutor(mock)()
+
+			ConfigureProxy(&ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: clusters, ProxyConfiguration: tc.proxy}})
+			var outB, errB bytes.Buffer
+			util.RunCommandCustomIO("helm", &outB, &errB, true, "repo", "update")
+			util.RunCommandCustomIO("kubectl", &outB, &errB, true, "get", "nodes")
+
+			for i, env := range mock.Envs {
+				if !reflect.DeepEqual(env, tc.expected) {
+					t.Errorf("environment of %v = %q, expected %q", mock.Commands[i], env, tc.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		host     string
+		noProxy  string
+		expected bool
+	}{
+		{
+			name:     "Exact host",
+			host:     "localhost",
+			noProxy:  "localhost,127.0.0.1",
+			expected: true,
+		},
+		{
+			name:     "Domain suffix",
+			host:     "charts.corp.example.com",
+			noProxy:  ".example.com",
+			expected: true,
+		},
+		{
+			name:     "Domain without leading dot",
+			host:     "charts.example.com",
+			noProxy:  "example.com",
+			expected: true,
+		},
+		{
+			name:     "Address in CIDR",
+			host:     "172.18.0.3",
+			noProxy:  "172.18.0.0/16",
+			expected: true,
+		},
+		{
+			name:     "Wildcard",
+			host:     "registry-1.docker.io",
+			noProxy:  "*",
+			expected: true,
+		},
+		{
+			name:    "Other host",
+			host:    "registry-1.docker.io",
+			noProxy: "localhost,.example.com,172.18.0.0/16",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := bypassProxy(tc.host, tc.noProxy); got != tc.expected {
+				t.Errorf("bypassProxy(%q, %q) = %v, expected %v", tc.host, tc.noProxy, got, tc.expected)
+			}
+		})
+	}
+}
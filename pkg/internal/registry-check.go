@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+var registryClient = &http.Client{Timeout: 15 * time.Second}
+
+// VerifyRegistryCredentials tests the image pull credentials of every
+// registry before anything is installed, so that bad credentials fail the run
+// instead of surfacing as ImagePullBackOff later. With docker available the
+// registry is logged in to, otherwise the registry token endpoint is asked
+// directly, as it is when the manifests are rendered or only the plan is
+// printed.
+func VerifyRegistryCredentials(specs *ConfigurationSpecs) {
+	if Options.SkipRegistryCheck {
+		return
+	}
+	secrets := make([]ImagePullSecrets, 0)
+	for _, ips := range specs.Configuration.HelmChartConfiguration.ImagePullSecrets {
+		if ips.Username != "" && ips.Password != "" {
+			secrets = append(secrets, ips)
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+	util.Printf("\nVerifying registry credentials...")
+	errors := make([]string, 0)
+	for _, ips := range secrets {
+		if ips.Registry == "" {
+			ips.Registry = "https://index.docker.io/v1/"
+		}
+		var err error
+		if util.ExecutablePaths["docker"] != "" && Options.RenderTo == "" && !Options.PlanOnly {
+			err = loginRegistry(ips)
+		} else {
+			err = authenticateRegistry(ips)
+		}
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			continue
+		}
+		util.Printf("%s Authenticated to %s", util.Tick, ips.Registry)
+	}
+	if len(errors) > 0 {
+		for _, s := range errors {
+			util.Printf(s)
+		}
+		util.Fatalf("%s Process failed due to invalid registry credentials. Use --skip-registry-check for registries which cannot be checked", util.Cross)
+	}
+}
+
+// loginRegistry tests the credentials with docker login. The login is stored
+// in a temporary DOCKER_CONFIG, removed once it is tested, so that the docker
+// configuration of the user is left untouched.
+func loginRegistry(ips ImagePullSecrets) error {
+	dir, err := ioutil.TempDir("", "kubeslice-docker-config")
+	if err != nil {
+		return fmt.Errorf("unable to create a docker configuration to log in to %s: %v", ips.Registry, err)
+	}
+	defer os.RemoveAll(dir)
+	env := util.CommandEnv("docker")
+	defer util.SetCommandEnv("docker", env...)
+	util.SetCommandEnv("docker", append(env, "DOCKER_CONFIG="+dir)...)
+
+	var outB, errB bytes.Buffer
+	err = util.RunCommandWithInput("docker", ips.Password, &outB, &errB, true, "login", registryHost(ips.Registry), "--username", ips.Username, "--password-stdin")
+	if err != nil {
+		return fmt.Errorf("authentication to %s failed: %s", ips.Registry, util.Redact(strings.TrimSpace(errB.String())))
+	}
+	return nil
+}
+
+// authenticateRegistry tests the credentials against the v2 API of the
+// registry, following the bearer token challenge if there is one.
+func authenticateRegistry(ips ImagePullSecrets) error {
+	base := registryBaseURL(ips.Registry)
+	resp, err := registryClient.Get(base + "/v2/")
+	if err != nil {
+		return registryUnreachable(ips.Registry, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		// the registry allows anonymous access, the credentials are not needed
+		return nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	endpoint := base + "/v2/"
+	if strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		params := parseChallenge(challenge[len("bearer "):])
+		if params["realm"] == "" {
+			return fmt.Errorf("registry %s sent a token challenge without realm", ips.Registry)
+		}
+		query := url.Values{}
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		endpoint = params["realm"] + "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("unable to authenticate to %s: %v", ips.Registry, err)
+	}
+	req.SetBasicAuth(ips.Username, ips.Password)
+	resp, err = registryClient.Do(req)
+	if err != nil {
+		return registryUnreachable(ips.Registry, err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("authentication to %s failed", ips.Registry)
+	}
+	return fmt.Errorf("unable to authenticate to %s: unexpected status %s", ips.Registry, resp.Status)
+}
+
+// registryUnreachable explains why a registry could not be reached, pointing
+// at the proxy when it is the cause.
+func registryUnreachable(registry string, err error) error {
+	if hint := proxyErrorHint(err.Error()); hint != "" {
+		return fmt.Errorf("unable to reach registry %s: %s", registry, hint)
+	}
+	return fmt.Errorf("unable to reach registry %s: %v", registry, err)
+}
+
+// registryBaseURL returns the API endpoint of a registry like
+// https://index.docker.io/v1/.
+func registryBaseURL(registry string) string {
+	scheme := "https"
+	if strings.HasPrefix(registry, "http://") {
+		scheme = "http"
+	}
+	host := registryHost(registry)
+	// docker hub serves its API from a different host than its index
+	if host == "index.docker.io" || host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return scheme + "://" + host
+}
+
+// parseChallenge parses the key="value" parameters of a WWW-Authenticate
+// challenge.
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(challenge, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return params
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestAuthenticateRegistry(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.example.com"`, server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" || r.URL.Query().Get("service") != "registry.example.com" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token":"abc"}`)
+	})
+
+	tests := []struct {
+		name     string
+		password string
+		err      string
+	}{
+		{
+			name:     "Valid credentials",
+			password: "pass",
+		},
+		{
+			name:     "Invalid credentials",
+			password: "wrong",
+			err:      fmt.Sprintf("authentication to %s failed", server.URL),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := authenticateRegistry(ImagePullSecrets{Registry: server.URL, Username: "user", Password: tc.password})
+			if tc.err == "" && err != nil {
+				t.Fatalf("authenticateRegistry() returned error: %v", err)
+			}
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("authenticateRegistry() error = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestLoginRegistry(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+	}{
+		{
+			name: "Valid credentials",
+		},
+		{
+			name:   "Invalid credentials",
+			failed: true,
+		},
+	}
+
+	defer util.SetCommandEnv("docker")
+	util.SetCommandEnv("docker", "HTTPS_PROXY=http://proxy.internal:3128")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dockerConfig := ""
+			mock := &util.MockExecutor{
+				Handler: func(command []string, stdout, stderr io.Writer) error {
+					for _, env := range util.CommandEnv("docker") {
+						if strings.HasPrefix(env, "DOCKER_CONFIG=") {
+							dockerConfig = strings.TrimPrefix(env, "DOCKER_CONFIG=")
+						}
+					}
+					if tc.failed {
+						fmt.Fprint(stderr, "Error response from daemon: unauthorized: incorrect username or password")
+						return errors.New("exit status 1")
+					}
+					return nil
+				},
+			}
+			defer util.UseExecutor(mock)()
+
+			err := loginRegistry(ImagePullSecrets{Registry: "https://harbor.example.com/v2/", Username: "user", Password: "pass"})
+			if (err != nil) != tc.failed {
+				t.Fatalf("loginRegistry() error = %v, want failure %v", err, tc.failed)
+			}
+			expected := [][]string{{"docker", "login", "harbor.example.com", "--username", "user", "--password-stdin"}}
+			if !reflect.DeepEqual(mock.Commands, expected) {
+				t.Errorf("commands mismatch\nwant: %q\ngot:  %q", expected, mock.Commands)
+			}
+			if !reflect.DeepEqual(mock.Inputs, []string{"pass"}) {
+				t.Errorf("password was not passed on stdin: %q", mock.Inputs)
+			}
+			if dockerConfig == "" || !containsString(mock.Envs[0], "HTTPS_PROXY=http://proxy.internal:3128") {
+				t.Errorf("docker login ran with env %q, want a temporary DOCKER_CONFIG and the proxy", mock.Envs[0])
+			}
+			if _, err := os.Stat(dockerConfig); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("temporary DOCKER_CONFIG %s was not removed: %v", dockerConfig, err)
+			}
+			if env := util.CommandEnv("docker"); !reflect.DeepEqual(env, []string{"HTTPS_PROXY=http://proxy.internal:3128"}) {
+				t.Errorf("docker env after the login = %q, want the proxy only", env)
+			}
+		})
+	}
+}
+
+func TestRegistryBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"https://index.docker.io/v1/":   "https://registry-1.docker.io",
+		"harbor.example.com":            "https://harbor.example.com",
+		"http://localhost:5000/v2/":     "http://localhost:5000",
+		"https://harbor.example.com/v2": "https://harbor.example.com",
+	}
+	for registry, expected := range tests {
+		if got := registryBaseURL(registry); got != expected {
+			t.Errorf("registryBaseURL(%q) = %q, want %q", registry, got, expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Exit codes of a failed upgrade with --rollback-on-failure.
+const (
+	exitRolledBack     = 2 // the upgrade failed, the release was rolled back
+	exitRollbackFailed = 3 // the upgrade and the rollback failed
+)
+
+// helmRevision is an entry of `helm history --output json`.
+type helmRevision struct {
+	Revision int    `json:"revision"`
+	Status   string `json:"status"`
+	Chart    string `json:"chart"`
+}
+
+// releaseFailure records a failed upgrade and the outcome of its rollback in
+// the run summary.
+type releaseFailure struct {
+	Cluster  string `yaml:"cluster"`
+	Release  string `yaml:"release"`
+	Error    string `yaml:"error"`
+	Rollback string `yaml:"rollback,omitempty"`
+}
+
+// previousRevision returns the deployed revision of the release, which a
+// failed upgrade is rolled back to. It is 0 when --rollback-on-failure is not
+// set or there is no deployed revision to roll back to.
+func previousRevision(cluster Cluster, release, namespace string) int {
+	if !Options.RollbackOnFailure {
+		return 0
+	}
+	var outB, errB bytes.Buffer
+	err := util.RunCommandCustomIO("helm", &outB, &errB, true, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "history", release, "--namespace", namespace, "--output", "json")
+	if err != nil {
+		if !strings.Contains(errB.String(), "not found") {
+			util.Printf("%s Unable to fetch the history of release %s on %s, it cannot be rolled back: %s", util.Warn, release, cluster.Name, strings.TrimSpace(errB.String()))
+		}
+		return 0
+	}
+	revision, err := deployedRevision(outB.Bytes())
+	if err != nil {
+		util.Printf("%s Unable to parse the history of release %s on %s, it cannot be rolled back: %v", util.Warn, release, cluster.Name, err)
+	}
+	return revision
+}
+
+// deployedRevision returns the latest revision of a release history which is
+// deployed, or 0 if there is none.
+func deployedRevision(history []byte) (int, error) {
+	revisions := make([]helmRevision, 0)
+	if len(bytes.TrimSpace(history)) == 0 {
+		return 0, nil
+	}
+	if err := json.Unmarshal(history, &revisions); err != nil {
+		return 0, err
+	}
+	deployed := 0
+	for _, r := range revisions {
+		if r.Status == "deployed" && r.Revision > deployed {
+			deployed = r.Revision
+		}
+	}
+	return deployed, nil
+}
+
+// releaseFailed handles a failed install or upgrade of a release. With a
+// revision to roll back to, the release is rolled back and the run exits with
+// exitRolledBack, or exitRollbackFailed when the rollback fails too. Both
+// outcomes are recorded in the run summary.
+func releaseFailed(cluster Cluster, release, namespace string, revision int, err error) {
+	diagnoseRelease(cluster, release, namespace)
+	if revision == 0 {
+		util.Fatalf("Process failed %v", err)
+	}
+	util.Printf("%s Upgrade of release %s on %s failed: %v", util.Cross, release, cluster.Name, err)
+	util.Printf("%s Rolling back release %s on %s to revision %d...", util.Wait, release, cluster.Name, revision)
+	failure := releaseFailure{Cluster: cluster.Name, Release: release, Error: err.Error()}
+	args := []string{"--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "rollback", release, strconv.Itoa(revision), "--namespace", namespace}
+	rollbackErr := util.RunCommand("helm", append(args, helmWaitFlags()...)...)
+	code := exitRolledBack
+	if rollbackErr == nil {
+		failure.Rollback = fmt.Sprintf("rolled back to revision %d", revision)
+		util.Printf("%s Rolled back release %s on %s to revision %d", util.Tick, release, cluster.Name, revision)
+	} else {
+		code = exitRollbackFailed
+		failure.Rollback = fmt.Sprintf("rollback to revision %d failed: %v", revision, rollbackErr)
+		util.Printf("%s Rollback of release %s on %s to revision %d failed: %v", util.Cross, release, cluster.Name, revision, rollbackErr)
+		diagnoseRelease(cluster, release, namespace)
+	}
+	runSummary.Failures = append(runSummary.Failures, failure)
+	writeRunSummary()
+	util.Exit(code)
+}
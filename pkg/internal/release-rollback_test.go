@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestDeployedRevision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		history  string
+		expected int
+	}{
+		{
+			name:     "Latest deployed revision",
+			history:  `[{"revision":1,"status":"superseded"},{"revision":2,"status":"deployed"}]`,
+			expected: 2,
+		},
+		{
+			name:     "Revision deployed before a failed upgrade",
+			history:  `[{"revision":1,"status":"superseded"},{"revision":2,"status":"deployed"},{"revision":3,"status":"failed"}]`,
+			expected: 2,
+		},
+		{
+			name:     "No deployed revision",
+			history:  `[{"revision":1,"status":"failed"}]`,
+			expected: 0,
+		},
+		{
+			name:     "Empty output",
+			history:  "",
+			expected: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := deployedRevision([]byte(tc.history))
+			if err != nil {
+				t.Fatalf("deployedRevision() returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("deployedRevision() = %d, want %d", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// RenderKubeSlice writes the values files and the manifests an install would
+// apply into dir instead of applying them, so that a GitOps tool can apply
+// them. Nothing is installed on or read from the clusters:
+//
+//	<dir>/<cluster>/<release>/values.yaml    generated values of the release
+//	<dir>/<cluster>/<release>/manifest.yaml  output of helm template
+//	<dir>/<controller>/kubeslice/*.yaml      project, cluster registration and slice
+//
+// Sensitive values are left out of both files, they have to be provided to the
+// clusters separately. The worker charts are rendered without the controller
+// secret, which only exists once the clusters are registered.
+func RenderKubeSlice(ApplicationConfiguration *ConfigurationSpecs, components []string, createProject, registerWorkers bool, dir string) {
+	util.Printf("\nRendering KubeSlice manifests to %s...", dir)
+	config := ApplicationConfiguration.Configuration
+	cc := config.ClusterConfiguration
+	hc := config.HelmChartConfiguration
+	setControlPlaneAddress(&ApplicationConfiguration.Configuration.ClusterConfiguration)
+	useForcedAPIVersion()
+
+	releases := componentReleases(ApplicationConfiguration)
+	for _, component := range components {
+		cr := releases[component]
+		for _, cluster := range cr.clusters {
+			valuesFile := generateComponentValues(ApplicationConfiguration, component, cluster, cr)
+			releaseDir := filepath.Join(dir, cluster.Name, cr.release)
+			copyRenderedFile(valuesFile, filepath.Join(releaseDir, "values.yaml"))
+			renderRelease(cluster, hc, cr, valuesFile, filepath.Join(releaseDir, "manifest.yaml"))
+			util.Printf("%s Rendered release %s of %s", util.Tick, cr.release, cluster.Name)
+		}
+	}
+
+	manifestDir := filepath.Join(dir, cc.ControllerCluster.Name, "kubeslice")
+	if createProject {
+		generateKubeSliceProjectManifest(ControllerNamespace(cc.ControllerCluster), configuredProjects(config.KubeSliceConfiguration)...)
+		copyRenderedFile(kubesliceDirectory+"/"+projectFileName, filepath.Join(manifestDir, projectFileName))
+	}
+	if registerWorkers {
+		util.CreateDirectoryPath(manifestDir)
+		generateClusterRegistrationManifest(ApplicationConfiguration, filepath.Join(manifestDir, clusterRegistrationFileName), "")
+		GenerateSliceConfiguration(ApplicationConfiguration, nil, "", "")
+		sliceFile := sliceFileName(configuredSlice(ApplicationConfiguration, nil, "").Name)
+		copyRenderedFile(kubesliceDirectory+"/"+sliceFile, filepath.Join(manifestDir, sliceFile))
+	}
+	util.Printf("%s Sensitive values are not part of the rendered files, provide them to the clusters separately", util.Warn)
+	util.Printf("%s Successfully rendered KubeSlice manifests to %s\n", util.Tick, dir)
+}
+
+// generateComponentValues generates the values file of the release of a
+// component on a cluster without reading from the clusters, and returns its
+// path.
+func generateComponentValues(specs *ConfigurationSpecs, component string, cluster Cluster, cr componentRelease) string {
+	config := specs.Configuration
+	hc := config.HelmChartConfiguration
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cluster, cr.chart)
+	switch component {
+	case CertManager_Component:
+		generateCertManagerValuesFile(cluster, config, valuesFile)
+	case Controller_Component:
+		generateControllerValuesFile(cluster, config, valuesFile)
+	case UI_install_Component:
+		generateUIValuesFile(cluster, config, valuesFile)
+	case Worker_Component:
+		writeWorkerValuesFile(cluster, valuesFileName(cluster, cr.chart), config, config.ClusterConfiguration.ClusterType == Kind_Component, renderedWorkerSecrets(config, cluster))
+	case Prometheus_Component:
+		generatePrometheusValuesFile(hc, valuesFile)
+	}
+	return valuesFile
+}
+
+// renderedWorkerSecrets stands in for the controller secret of a worker which
+// is not registered yet. The CA and token are sensitive values and are never
+// rendered anyway.
+func renderedWorkerSecrets(config Configuration, cluster Cluster) map[string]string {
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	return map[string]string{
+		"namespace":          encode(projectNamespace(workerProject(config.KubeSliceConfiguration, cluster))),
+		"controllerEndpoint": encode(config.ClusterConfiguration.ControllerCluster.ControlPlaneAddress),
+	}
+}
+
+// renderRelease writes the output of helm template of a release to file. The
+// sensitive values split out of the values file are deliberately not passed.
+func renderRelease(cluster Cluster, hc HelmChartConfiguration, cr componentRelease, valuesFile, file string) {
+	manifest, err := templateRelease(hc, cr, cr.namespace(cluster), valuesFile)
+	if err != nil {
+		util.Fatalf("%s Unable to render release %s for %s: %v", util.Cross, cr.release, cluster.Name, err)
+	}
+	writeRenderedFile(file, manifest)
+}
+
+// templateRelease returns the output of helm template of a release.
+func templateRelease(hc HelmChartConfiguration, cr componentRelease, namespace, valuesFile string) ([]byte, error) {
+	args := []string{"template", cr.release, chartReference(hc, cr.chart), "--namespace", namespace, "-f", valuesFile}
+	args = append(args, helmTLSFlags(hc.forChart(cr.chart), false)...)
+	args = append(args, helmVerifyFlags(cr.chart)...)
+	args = append(args, helmDevelFlags(cr.chart)...)
+	if cr.chart.Version != "" {
+		args = append(args, "--version", cr.chart.Version)
+	}
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...); err != nil {
+		return nil, errors.New(strings.TrimSpace(errB.String()))
+	}
+	return outB.Bytes(), nil
+}
+
+func copyRenderedFile(src, dst string) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		util.Fatalf("%s Failed to read %s: %v", util.Cross, src, err)
+	}
+	writeRenderedFile(dst, data)
+}
+
+func writeRenderedFile(file string, data []byte) {
+	util.CreateDirectoryPath(filepath.Dir(file))
+	if err := util.WriteFileAtomic(file, data, 0644); err != nil {
+		util.Fatalf("%s Failed to write %s: %v", util.Cross, file, err)
+	}
+}
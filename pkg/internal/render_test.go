@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestRenderKubeSlice(t *testing.T) {
+	defer func(dir string) { kubesliceDirectory = dir }(kubesliceDirectory)
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "controller", ControlPlaneAddress: "https://10.0.0.1:6443"},
+			WorkerClusters:    []Cluster{{Name: "worker-1", ControlPlaneAddress: "https://10.0.0.2:6443"}},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		HelmChartConfiguration: HelmChartConfiguration{
+			RepoAlias:       "kubeslice",
+			RepoUrl:         "https://kubeslice.github.io/kubeslice/",
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.0.0"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker", Version: "1.0.0"},
+		},
+	}}
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		io.WriteString(stdout, "# Source: "+command[2]+"/templates/deployment.yaml\nkind: Deployment\n")
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	render := func() map[string]string {
+		kubesliceDirectory = t.TempDir()
+		dir := t.TempDir()
+		RenderKubeSlice(specs, []string{Controller_Component, Worker_Component}, true, true, dir)
+		files := make(map[string]string)
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			rel, _ := filepath.Rel(dir, path)
+			files[rel] = string(data)
+			return err
+		})
+		return files
+	}
+	first := render()
+
+	expected := []string{
+		"controller/kubeslice-controller/manifest.yaml",
+		"controller/kubeslice-controller/values.yaml",
+		"controller/kubeslice/cluster-registration.yaml",
+		"controller/kubeslice/project.yaml",
+		"controller/kubeslice/slice-demo.yaml",
+		"worker-1/kubeslice-worker/manifest.yaml",
+		"worker-1/kubeslice-worker/values.yaml",
+	}
+	got := make([]string, 0, len(first))
+	for file := range first {
+		got = append(got, file)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("rendered files mismatch\nwant: %q\ngot:  %q", expected, got)
+	}
+	for _, command := range mock.Commands {
+		if command[0] != "helm" || command[1] != "template" {
+			t.Errorf("unexpected command %q", command)
+		}
+	}
+	if values := first["worker-1/kubeslice-worker/values.yaml"]; strings.Contains(values, "token") {
+		t.Errorf("worker values contain the controller secret token:\n%s", values)
+	}
+	if second := render(); !reflect.DeepEqual(first, second) {
+		t.Errorf("rendering is not deterministic")
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"io/ioutil"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// reuseReleaseValues merges the generated values file on top of the values of
+// the deployed release when --reuse-values is set, so that values set on the
+// release outside of the topology survive the upgrade. Releases which are not
+// installed yet are left alone.
+func reuseReleaseValues(cluster Cluster, release, namespace, valuesFile string) {
+	if !Options.ReuseValues {
+		return
+	}
+	deployed, installed, err := deployedReleaseValues(cluster, release, namespace)
+	if err != nil {
+		util.Fatalf("%s %v, unable to reuse its values", util.Cross, err)
+	}
+	if !installed {
+		return
+	}
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		util.Fatalf("%s Failed to read %s: %v", util.Cross, valuesFile, err)
+	}
+	generated, err := parseValues(data)
+	if err != nil {
+		util.Fatalf("%s Failed to parse %s: %v", util.Cross, valuesFile, err)
+	}
+
+	sensitiveValuesMu.Lock()
+	merged, secrets := mergeReleaseValues(deployed, generated, sensitiveValues[valuesFile], sensitiveValuePaths[valuesFile])
+	sensitiveValues[valuesFile] = secrets
+	sensitiveValuesMu.Unlock()
+
+	finalData, err := yaml.Marshal(merged)
+	if err != nil {
+		util.Fatalf("%s error encoding final data as YAML: %v", util.Cross, err)
+	}
+	if err := util.WriteFileAtomic(valuesFile, finalData, 0600); err != nil {
+		util.Fatalf("%s error writing values file: %v", util.Cross, err)
+	}
+	util.Printf("%s Reusing the values of deployed release %s on %s, values of the topology take precedence", util.Tick, release, cluster.Name)
+}
+
+// mergeReleaseValues merges the generated values and the sensitive values
+// split out of them on top of the deployed values. The sensitive paths of the
+// deployed values are split out as well so that they never end up in the
+// values file. A null in the generated values is kept, which makes helm
+// delete the key, including a value only set on the deployed release.
+func mergeReleaseValues(deployed, generated, generatedSecrets map[interface{}]interface{}, paths map[string]bool) (merged, secrets map[interface{}]interface{}) {
+	deployedSecrets := splitSensitiveValues(deployed, paths, "")
+	return mergeMaps(deployed, generated), mergeMaps(deployedSecrets, generatedSecrets)
+}
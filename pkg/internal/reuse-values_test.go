@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestMergeReleaseValues(t *testing.T) {
+	t.Parallel()
+
+	paths := map[string]bool{"imagePullSecrets.password": true}
+	tests := []struct {
+		name            string
+		deployed        string
+		generated       string
+		secrets         string
+		expected        string
+		expectedSecrets string
+	}{
+		{
+			name:      "Values set only on the release are kept",
+			deployed:  "operator:\n  image: hotfix\n  tag: 1.1.1\n",
+			generated: "operator:\n  tag: 1.2.0\ncluster:\n  name: worker-1\n",
+			expected:  "cluster:\n  name: worker-1\noperator:\n  image: hotfix\n  tag: 1.2.0\n",
+		},
+		{
+			name:      "Topology replaces a list",
+			deployed:  "tolerations:\n- key: a\n- key: b\n",
+			generated: "tolerations:\n- key: c\n",
+			expected:  "tolerations:\n- key: c\n",
+		},
+		{
+			name:      "Null in the topology deletes a value of the release",
+			deployed:  "metrics:\n  insecure: true\n  port: 9090\n",
+			generated: "metrics:\n  insecure: null\n",
+			expected:  "metrics:\n  insecure: null\n  port: 9090\n",
+		},
+		{
+			name:            "Sensitive values of the release stay out of the values file",
+			deployed:        "imagePullSecrets:\n  password: old\n  username: user\n",
+			generated:       "replicas: 2\n",
+			expected:        "imagePullSecrets:\n  username: user\nreplicas: 2\n",
+			expectedSecrets: "imagePullSecrets:\n  password: old\n",
+		},
+		{
+			name:            "Sensitive values of the topology take precedence",
+			deployed:        "imagePullSecrets:\n  password: old\n",
+			generated:       "imagePullSecrets:\n  username: user\n",
+			secrets:         "imagePullSecrets:\n  password: new\n",
+			expected:        "imagePullSecrets:\n  username: user\n",
+			expectedSecrets: "imagePullSecrets:\n  password: new\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			parse := func(input string) map[interface{}]interface{} {
+				values, err := parseValues([]byte(input))
+				if err != nil {
+					t.Fatalf("parseValues() returned error: %v", err)
+				}
+				return values
+			}
+			merged, secrets := mergeReleaseValues(parse(tc.deployed), parse(tc.generated), parse(tc.secrets), paths)
+			check := func(what string, values map[interface{}]interface{}, expected string) {
+				encoded, err := encodeValues(values)
+				if err != nil {
+					t.Fatalf("encodeValues() returned error: %v", err)
+				}
+				if encoded != expected {
+					t.Errorf("mergeReleaseValues() %s mismatch:\nwant: %q\ngot:  %q", what, expected, encoded)
+				}
+			}
+			check("values", merged, tc.expected)
+			check("sensitive values", secrets, tc.expectedSecrets)
+		})
+	}
+}
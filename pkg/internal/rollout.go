@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// rolloutPollInterval is how often the deployments of a component are polled
+// while waiting for their rollout.
+var rolloutPollInterval = 5 * time.Second
+
+// PollUntil calls done every interval until it returns true or an error, and
+// fails once timeout elapsed.
+func PollUntil(interval, timeout time.Duration, done func(elapsed time.Duration) (bool, error)) error {
+	start := time.Now()
+	for {
+		elapsed := time.Since(start)
+		finished, err := done(elapsed)
+		if err != nil || finished {
+			return err
+		}
+		if elapsed >= timeout {
+			return fmt.Errorf("timed out after %d seconds", int(elapsed.Seconds()))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// WaitForRollout waits until the deployments of a component are rolled out
+// and all their replicas are ready, printing the progress. When they are not
+// ready within --rollout-timeout, the unready pods are diagnosed.
+func WaitForRollout(cluster Cluster, component, namespace string) {
+	if err := waitForRollout(cluster, component, namespace); err != nil {
+		util.Printf("%s %s is not ready on cluster %s: %v", util.Cross, component, cluster.Name, err)
+		diagnoseRelease(cluster, component, namespace)
+		util.Fatalf("%s Process failed, %s did not become ready", util.Cross, component)
+	}
+	util.Printf("%s %s is ready on cluster %s", util.Tick, component, cluster.Name)
+}
+
+func waitForRollout(cluster Cluster, component, namespace string) error {
+	return PollUntil(rolloutPollInterval, Options.RolloutTimeout, func(elapsed time.Duration) (bool, error) {
+		deployments, err := kubeClient.Deployments(&cluster, namespace)
+		if err != nil {
+			return false, err
+		}
+		ready, replicas, rolledOut := 0, 0, len(deployments) > 0
+		for _, deployment := range deployments {
+			ready += deployment.Ready
+			replicas += deployment.Replicas
+			rolledOut = rolledOut && deployment.rolledOut()
+		}
+		if rolledOut {
+			return true, nil
+		}
+		pods, err := kubeClient.Pods(&cluster, namespace)
+		if err != nil {
+			return false, err
+		}
+		util.Printf("%s Waiting for %s to roll out... %d/%d replicas ready%s, %d seconds elapsed", util.Wait, component, ready, replicas, unreadyPod(pods), int(elapsed.Seconds()))
+		return false, nil
+	})
+}
+
+// unreadyPod describes the first pod which is not ready, e.g. pod
+// kubeslice-operator-6b7f ContainerCreating.
+func unreadyPod(pods []podReadiness) string {
+	for _, pod := range pods {
+		if pod.Phase == "Succeeded" || (pod.Total > 0 && pod.Ready == pod.Total) {
+			continue
+		}
+		status := pod.Phase
+		if pod.Reason != "" {
+			status = pod.Reason
+		}
+		return fmt.Sprintf(", pod %s %s", pod.Name, status)
+	}
+	return ""
+}
+
+// WaitForWorkersReady waits for the rollout of the workers of the topology,
+// e.g. before the demo applications are verified on them.
+func WaitForWorkersReady(ApplicationConfiguration *ConfigurationSpecs) {
+	for _, cluster := range ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters {
+		WaitForRollout(cluster, "kubeslice-worker", WorkerNamespace(cluster))
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestWaitForRollout(t *testing.T) {
+	defer func(interval, timeout time.Duration) { rolloutPollInterval, Options.RolloutTimeout = interval, timeout }(rolloutPollInterval, Options.RolloutTimeout)
+	rolloutPollInterval, Options.RolloutTimeout = time.Millisecond, 50*time.Millisecond
+
+	deployment := `{"items": [{"metadata": {"name": "kubeslice-controller-manager", "generation": 1}, "spec": {"replicas": 1},
+		"status": {"observedGeneration": 1, "readyReplicas": %d, "updatedReplicas": 1, "availableReplicas": %[1]d}}]}`
+	pods := `{"items": [{"metadata": {"name": "kubeslice-controller-manager-0"}, "status": {"phase": "Pending",
+		"containerStatuses": [{"ready": false, "state": {"waiting": {"reason": "ContainerCreating"}}}]}}]}`
+	tests := []struct {
+		name string
+		// readyAfter is the number of polls after which the replica is ready
+		readyAfter int
+		err        string
+	}{
+		{name: "Not ready then ready", readyAfter: 2},
+		{name: "Never ready", readyAfter: -1, err: "timed out after"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch command[4] {
+				case "deployments":
+					ready := 0
+					if polls++; tc.readyAfter >= 0 && polls > tc.readyAfter {
+						ready = 1
+					}
+					fmt.Fprintf(stdout, deployment, ready)
+				case "pods":
+					fmt.Fprint(stdout, pods)
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			err := waitForRollout(Cluster{Name: "controller", ContextName: "kind-controller"}, "kubeslice-controller", KUBESLICE_CONTROLLER_NAMESPACE)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("waitForRollout() error = %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("waitForRollout() returned error: %v", err)
+			}
+			if polls != tc.readyAfter+1 {
+				t.Errorf("waitForRollout() polled the deployments %d times, want %d", polls, tc.readyAfter+1)
+			}
+		})
+	}
+}
+
+func TestUnreadyPod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pods     []podReadiness
+		expected string
+	}{
+		{name: "All ready", pods: []podReadiness{{Name: "a", Phase: "Running", Ready: 1, Total: 1}, {Name: "job", Phase: "Succeeded"}}},
+		{name: "Waiting", pods: []podReadiness{{Name: "a", Phase: "Running", Ready: 1, Total: 1}, {Name: "b", Phase: "Running", Total: 1, Reason: "CrashLoopBackOff"}}, expected: ", pod b CrashLoopBackOff"},
+		{name: "Pending", pods: []podReadiness{{Name: "a", Phase: "Pending"}}, expected: ", pod a Pending"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := unreadyPod(tc.pods); got != tc.expected {
+				t.Errorf("unreadyPod() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Actions of the steps of an execution plan.
+const (
+	planCreateCluster = "create cluster"
+	planReuseCluster  = "reuse cluster"
+	planDeleteCluster = "delete cluster"
+	planInstall       = "install"
+	planUpgrade       = "upgrade"
+	planUninstall     = "uninstall"
+	planApply         = "apply"
+	planCleanup       = "clean up"
+	planRun           = "run"
+	planAdopt         = "adopt"
+	planSkip          = "skip"
+	planUpToDate      = "up-to-date"
+)
+
+// ExecutionPlan is what a run of install, uninstall or upgrade is going to do,
+// printed before anything is changed so that it can be reviewed, or approved
+// by external tooling with --plan-only -o json.
+type ExecutionPlan struct {
+	Command      string        `json:"command"`
+	Installation string        `json:"installation"`
+	Profile      string        `json:"profile,omitempty"`
+	Components   []string      `json:"components,omitempty"`
+	Steps        []PlannedStep `json:"steps"`
+}
+
+// PlannedStep is a step of an execution plan with what it does on every
+// cluster.
+type PlannedStep struct {
+	Step        string `json:"step"`
+	Description string `json:"description"`
+	// Resumed marks a step a failed install completed, skipped with --resume
+	Resumed bool            `json:"resumed,omitempty"`
+	Actions []PlannedAction `json:"actions"`
+}
+
+// PlannedAction is what a step does on a cluster: a cluster created or
+// deleted, a release installed, upgraded or left as it is, or an object
+// applied.
+type PlannedAction struct {
+	Action    string `json:"action"`
+	Cluster   string `json:"cluster"`
+	Context   string `json:"context,omitempty"`
+	Release   string `json:"release,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Chart     string `json:"chart,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Object    string `json:"object,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+func (a PlannedAction) String() string {
+	var b strings.Builder
+	b.WriteString(a.Cluster)
+	if a.Context != "" && a.Context != a.Cluster {
+		fmt.Fprintf(&b, " (context %s)", a.Context)
+	}
+	fmt.Fprintf(&b, ": %s", a.Action)
+	switch {
+	case a.Release != "":
+		fmt.Fprintf(&b, " release %s", a.Release)
+	case a.Object != "":
+		fmt.Fprintf(&b, " %s", a.Object)
+	}
+	if a.Namespace != "" {
+		fmt.Fprintf(&b, " in namespace %s", a.Namespace)
+	}
+	if a.Chart != "" {
+		fmt.Fprintf(&b, ", chart %s", a.Chart)
+		if a.Version != "" {
+			fmt.Fprintf(&b, " %s", a.Version)
+		}
+	}
+	if a.Note != "" {
+		fmt.Fprintf(&b, " (%s)", a.Note)
+	}
+	return b.String()
+}
+
+// Text formats the plan with its steps numbered in execution order.
+func (p ExecutionPlan) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s plan of installation %s", strings.ToUpper(p.Command[:1])+p.Command[1:], p.Installation)
+	if p.Profile != "" {
+		fmt.Fprintf(&b, ", profile %s", p.Profile)
+	}
+	if len(p.Components) > 0 {
+		fmt.Fprintf(&b, ", components %s", strings.Join(p.Components, ", "))
+	}
+	b.WriteString(":")
+	if len(p.Steps) == 0 {
+		b.WriteString("\n  nothing to do")
+	}
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "\n[%d/%d] %s", i+1, len(p.Steps), step.Description)
+		if step.Resumed {
+			b.WriteString(" (completed by the failed install, skipped with --resume)")
+		}
+		for _, action := range step.Actions {
+			fmt.Fprintf(&b, "\n    %s", action)
+		}
+	}
+	return b.String()
+}
+
+// PrintExecutionPlan prints the plan of a run, as JSON on stdout with the
+// json output format.
+func PrintExecutionPlan(plan ExecutionPlan, outputFormat string) {
+	if outputFormat == "json" {
+		out, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			util.Fatalf("%s Unable to encode the plan: %v", util.Cross, err)
+		}
+		fmt.Fprintln(os.Stdout, string(out))
+		return
+	}
+	util.Printf("%s", plan.Text())
+}
+
+// StopAfterPlan tells whether the run stops once its plan is printed, with
+// --plan-only.
+func StopAfterPlan() bool {
+	if Options.PlanOnly && Options.PlanOutput != "json" {
+		util.Printf("\n%s Stopping after the plan, nothing was changed", util.Tick)
+	}
+	return Options.PlanOnly
+}
+
+// clusterAction returns an action on a cluster.
+func clusterAction(action string, cluster Cluster) PlannedAction {
+	return PlannedAction{Action: action, Cluster: cluster.Name, Context: cluster.ContextName}
+}
+
+// releaseAction returns the action installing the release of a component on
+// a cluster at its resolved version, or leaving it as installed when it was
+// adopted or its cluster is skipped.
+func releaseAction(hc HelmChartConfiguration, component string, cr componentRelease, cluster Cluster) PlannedAction {
+	action := clusterAction(planInstall, cluster)
+	action.Release = cr.release
+	action.Namespace = cr.namespace(cluster)
+	action.Chart = chartReference(hc, cr.chart)
+	action.Version = cr.chart.Version
+	if chart, ok := runSummary.Charts[component]; ok {
+		action.Version = chart.Version
+	}
+	switch {
+	case untouchedClusters[cluster.Name]:
+		action.Action, action.Note = planSkip, "cluster left untouched, --on-existing=skip"
+	case adoptedReleases[cluster.Name+"/"+cr.release]:
+		action.Action, action.Note = planAdopt, "left as installed"
+	}
+	return action
+}
+
+// Execution expands the steps of the install plan into what they do on every
+// cluster: the kind clusters created or reused, the releases installed with
+// their chart versions, adopted or skipped, and the objects applied.
+func (p *InstallPlan) Execution(specs *ConfigurationSpecs) ExecutionPlan {
+	config := specs.Configuration
+	cc := config.ClusterConfiguration
+	hc := config.HelmChartConfiguration
+	releases := componentReleases(specs)
+	plan := ExecutionPlan{Command: "install", Installation: InstallationName(specs), Profile: p.Profile, Components: p.Components, Steps: make([]PlannedStep, 0, len(p.Steps))}
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	for _, step := range p.Steps {
+		actions := make([]PlannedAction, 0)
+		switch step {
+		case Kind_Component:
+			toCreate := kindClustersToCreate(clusters, true)
+			for i, cluster := range clusters {
+				action := clusterAction(planReuseCluster, cluster)
+				if toCreate[i] {
+					action.Action = planCreateCluster
+				}
+				actions = append(actions, action)
+			}
+		case Calico_Component:
+			for _, cluster := range clusters {
+				action := clusterAction(planInstall, cluster)
+				action.Object = "Calico"
+				actions = append(actions, action)
+			}
+		case CertManager_Component, UI_install_Component, Worker_Component, Prometheus_Component:
+			cr := releases[step]
+			for _, cluster := range cr.clusters {
+				actions = append(actions, releaseAction(hc, step, cr, cluster))
+			}
+		case Controller_Component:
+			cluster := cc.ControllerCluster
+			if licenseConfigured(config.License) {
+				action := clusterAction(planApply, cluster)
+				action.Object, action.Namespace = "Secret/"+licenseSecretName(config.License), ControllerNamespace(cluster)
+				actions = append(actions, action)
+			}
+			actions = append(actions, releaseAction(hc, step, releases[step], cluster))
+			for _, project := range ProjectNames(config.KubeSliceConfiguration) {
+				action := clusterAction(planApply, cluster)
+				action.Object, action.Namespace = "Project/"+project, ControllerNamespace(cluster)
+				actions = append(actions, action)
+			}
+		case Worker_registration_Component:
+			for _, worker := range touchedWorkers(cc.WorkerClusters) {
+				action := clusterAction(planApply, cc.ControllerCluster)
+				action.Object = "Cluster/" + worker.Name
+				action.Namespace = projectNamespace(workerProject(config.KubeSliceConfiguration, worker))
+				actions = append(actions, action)
+			}
+		case Monitoring_Component:
+			action := clusterAction(planInstall, cc.ControllerCluster)
+			chart := monitoringChart(config.Monitoring)
+			action.Release, action.Namespace, action.Chart, action.Version = chart.ChartName, monitoringReleaseNamespace(config.Monitoring), chartReference(hc, chart), chart.Version
+			action.Note = "unless a Prometheus Operator runs on the cluster"
+			actions = append(actions, action)
+			for _, m := range kubesliceServiceMonitors(specs) {
+				action := clusterAction(planApply, m.cluster)
+				action.Object, action.Namespace = "ServiceMonitor/"+m.name, m.namespace
+				actions = append(actions, action)
+			}
+		case HelmTestsStep:
+			for _, cluster := range clusters {
+				if !untouchedClusters[cluster.Name] {
+					action := clusterAction(planRun, cluster)
+					action.Object = "helm tests"
+					actions = append(actions, action)
+				}
+			}
+		case Demo_Component:
+			action := clusterAction(planApply, cc.ControllerCluster)
+			action.Object = "SliceConfig/" + configuredSlice(specs, nil, "").Name
+			action.Namespace = projectNamespace(config.KubeSliceConfiguration.ProjectName)
+			actions = append(actions, action)
+			for _, worker := range touchedWorkers(cc.WorkerClusters) {
+				action := clusterAction(planInstall, worker)
+				action.Object = "iperf"
+				actions = append(actions, action)
+			}
+		}
+		plan.Steps = append(plan.Steps, PlannedStep{Step: step, Description: stepDescriptions[step], Resumed: p.Resumed(step), Actions: actions})
+	}
+	return plan
+}
+
+// UninstallPlan is the execution plan of an uninstall of the components from
+// the workers, or of deleting the kind clusters of a demo.
+func UninstallPlan(specs *ConfigurationSpecs, components, workers map[string]string, deleteClusters bool) ExecutionPlan {
+	cc := specs.Configuration.ClusterConfiguration
+	releases := componentReleases(specs)
+	plan := ExecutionPlan{Command: "uninstall", Installation: InstallationName(specs), Profile: cc.Profile, Steps: make([]PlannedStep, 0)}
+	clusters := append([]Cluster{cc.ControllerCluster}, cc.WorkerClusters...)
+	if cc.Profile != "" && deleteClusters {
+		actions := make([]PlannedAction, 0, len(clusters))
+		for _, cluster := range clusters {
+			action := clusterAction(planDeleteCluster, cluster)
+			action.Note = "asked first when kubeslice-cli did not create it"
+			actions = append(actions, action)
+		}
+		plan.Steps = append(plan.Steps, PlannedStep{Step: Kind_Component, Description: "Deleting the kind clusters", Actions: actions})
+		return plan
+	}
+	_, allWorkers := workers["*"]
+	uninstalledWorkers := make([]Cluster, 0)
+	for _, cluster := range cc.WorkerClusters {
+		if _, found := workers[cluster.Name]; found || allWorkers {
+			uninstalledWorkers = append(uninstalledWorkers, cluster)
+		}
+	}
+	uninstall := func(component, description, note string, clusters []Cluster) {
+		if _, found := components[component]; !found {
+			return
+		}
+		cr := releases[component]
+		actions := make([]PlannedAction, 0, len(clusters))
+		for _, cluster := range clusters {
+			action := clusterAction(planUninstall, cluster)
+			action.Release, action.Namespace, action.Note = cr.release, cr.namespace(cluster), note
+			actions = append(actions, action)
+		}
+		plan.Steps = append(plan.Steps, PlannedStep{Step: component, Description: description, Actions: actions})
+	}
+	uninstall(UI_install_Component, "Uninstalling the KubeSlice Manager", "", []Cluster{cc.ControllerCluster})
+	uninstall(Worker_Component, "Uninstalling the KubeSlice Workers", "", uninstalledWorkers)
+	uninstall(Controller_Component, "Uninstalling the KubeSlice Controller", "", []Cluster{cc.ControllerCluster})
+	if _, found := components[Controller_Component]; found {
+		uninstall(CertManager_Component, "Uninstalling Cert-Manager", "only when kubeslice-cli installed it", []Cluster{cc.ControllerCluster})
+	}
+
+	notes := make([]string, 0)
+	if Options.RetainResources {
+		notes = append(notes, "custom resources retained")
+	}
+	if Options.RetainCRDs {
+		notes = append(notes, "CRDs retained")
+	}
+	if Options.DeleteNamespaces {
+		notes = append(notes, "namespace deleted")
+	}
+	actions := make([]PlannedAction, 0)
+	if _, found := components[Controller_Component]; found {
+		namespaces := []string{ControllerNamespace(cc.ControllerCluster)}
+		for _, project := range ProjectNames(specs.Configuration.KubeSliceConfiguration) {
+			namespaces = append(namespaces, projectNamespace(project))
+		}
+		for _, namespace := range namespaces {
+			action := clusterAction(planCleanup, cc.ControllerCluster)
+			action.Namespace, action.Note = namespace, strings.Join(notes, ", ")
+			actions = append(actions, action)
+		}
+	}
+	if _, found := components[Worker_Component]; found {
+		for _, cluster := range uninstalledWorkers {
+			action := clusterAction(planCleanup, cluster)
+			action.Namespace, action.Note = WorkerNamespace(cluster), strings.Join(notes, ", ")
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) > 0 {
+		plan.Steps = append(plan.Steps, PlannedStep{Step: "cleanup", Description: "Cleaning up the KubeSlice resources", Actions: actions})
+	}
+	return plan
+}
+
+// upgradeExecution is the execution plan of the steps of an upgrade, one
+// step per component.
+func upgradeExecution(specs *ConfigurationSpecs, steps []upgradeStep) ExecutionPlan {
+	hc := specs.Configuration.HelmChartConfiguration
+	plan := ExecutionPlan{Command: "upgrade", Installation: InstallationName(specs), Steps: make([]PlannedStep, 0)}
+	for _, component := range UpgradeComponents {
+		actions := make([]PlannedAction, 0)
+		for _, step := range steps {
+			if step.component != component {
+				continue
+			}
+			action := clusterAction(planUpgrade, step.cluster)
+			action.Release, action.Namespace, action.Chart, action.Version = step.release, step.namespace, chartReference(hc, step.chart), step.to
+			switch {
+			case step.from == "":
+				action.Action, action.Note = planSkip, "not installed, left to install"
+			case step.upToDate():
+				action.Action = planUpToDate
+			case pinnedChartVersion(step.component) != "" && compareVersions(step.from, step.to) > 0:
+				action.Note = "downgrade from " + step.from
+			default:
+				action.Note = "from " + step.from
+			}
+			actions = append(actions, action)
+		}
+		if len(actions) > 0 {
+			plan.Steps = append(plan.Steps, PlannedStep{Step: component, Description: upgradeDescriptions[component], Actions: actions})
+		}
+	}
+	return plan
+}
+
+// upgradeDescriptions describe the steps of an upgrade.
+var upgradeDescriptions = map[string]string{
+	Controller_Component: "Upgrading the KubeSlice Controller",
+	UI_install_Component: "Upgrading the KubeSlice Manager",
+	Worker_Component:     "Upgrading the KubeSlice Workers",
+}
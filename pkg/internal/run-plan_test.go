@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlannedActionString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		action   PlannedAction
+		expected string
+	}{
+		{
+			name:     "Cluster",
+			action:   PlannedAction{Action: planCreateCluster, Cluster: "ks-ctrl", Context: "kind-ks-ctrl"},
+			expected: "ks-ctrl (context kind-ks-ctrl): create cluster",
+		},
+		{
+			name:     "Release",
+			action:   PlannedAction{Action: planInstall, Cluster: "ks-w-1", Context: "ks-w-1", Release: "kubeslice-worker", Namespace: "kubeslice-system", Chart: "kubeslice/kubeslice-worker", Version: "1.2.0"},
+			expected: "ks-w-1: install release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.2.0",
+		},
+		{
+			name:     "Object with a note",
+			action:   PlannedAction{Action: planApply, Cluster: "ks-ctrl", Object: "Cluster/ks-w-1", Namespace: "kubeslice-demo", Note: "registered"},
+			expected: "ks-ctrl: apply Cluster/ks-w-1 in namespace kubeslice-demo (registered)",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.action.String(); got != tc.expected {
+				t.Errorf("String() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestInstallPlanExecution(t *testing.T) {
+	defer func(summary RunSummary) { runSummary = summary }(runSummary)
+	defer func() {
+		adoptedReleases = make(map[string]bool)
+		untouchedClusters = make(map[string]bool)
+	}()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl"},
+			WorkerClusters:    []Cluster{{Name: "ks-w-1", ContextName: "ks-w-1"}, {Name: "ks-w-2", ContextName: "ks-w-2"}},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		HelmChartConfiguration: HelmChartConfiguration{
+			RepoAlias:       "kubeslice",
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker"},
+		},
+	}}
+	runSummary = RunSummary{Charts: map[string]chartSummary{Worker_Component: {Chart: "kubeslice-worker", Version: "1.2.0"}}}
+	adoptedReleases = map[string]bool{"ks-ctrl/kubeslice-controller": true}
+	untouchedClusters = map[string]bool{"ks-w-2": true}
+
+	plan := (&InstallPlan{Steps: []string{Controller_Component, Worker_registration_Component, Worker_Component}}).Execution(specs)
+	actions := make(map[string][]string)
+	for _, step := range plan.Steps {
+		for _, action := range step.Actions {
+			actions[step.Step] = append(actions[step.Step], action.String())
+		}
+	}
+	expected := map[string][]string{
+		Controller_Component: {
+			"ks-ctrl: adopt release kubeslice-controller in namespace kubeslice-controller, chart kubeslice/kubeslice-controller (left as installed)",
+			"ks-ctrl: apply Project/demo in namespace kubeslice-controller",
+		},
+		Worker_registration_Component: {
+			"ks-ctrl: apply Cluster/ks-w-1 in namespace kubeslice-demo",
+		},
+		Worker_Component: {
+			"ks-w-1: install release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.2.0",
+			"ks-w-2: skip release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.2.0 (cluster left untouched, --on-existing=skip)",
+		},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Execution() = %v, want %v", actions, expected)
+	}
+}
+
+func TestUninstallPlan(t *testing.T) {
+	defer func(options RunOptions) { *Options = options }(*Options)
+	Options.RetainResources, Options.RetainCRDs, Options.DeleteNamespaces = false, true, false
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl"},
+			WorkerClusters:    []Cluster{{Name: "ks-w-1", ContextName: "ks-w-1"}, {Name: "ks-w-2", ContextName: "ks-w-2"}},
+		},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+	}}
+	plan := UninstallPlan(specs, map[string]string{Worker_Component: ""}, map[string]string{"ks-w-2": ""}, false)
+	steps := make([]string, 0)
+	actions := make([]string, 0)
+	for _, step := range plan.Steps {
+		steps = append(steps, step.Step)
+		for _, action := range step.Actions {
+			actions = append(actions, action.String())
+		}
+	}
+	if expected := []string{Worker_Component, "cleanup"}; !reflect.DeepEqual(steps, expected) {
+		t.Errorf("UninstallPlan() steps = %v, want %v", steps, expected)
+	}
+	expected := []string{
+		"ks-w-2: uninstall release kubeslice-worker in namespace kubeslice-system",
+		"ks-w-2: clean up in namespace kubeslice-system (CRDs retained)",
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("UninstallPlan() actions = %v, want %v", actions, expected)
+	}
+}
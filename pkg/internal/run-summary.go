@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"path/filepath"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+const runSummaryFileName = "summary.yaml"
+
+// chartSummary records which chart version a run installed. Resolved is set
+// when the version was not pinned in the topology.
+type chartSummary struct {
+	Chart    string `yaml:"chart"`
+	Version  string `yaml:"version"`
+	Resolved bool   `yaml:"resolved,omitempty"`
+	// Prerelease marks a pre-release version, installed with --devel
+	Prerelease bool `yaml:"prerelease,omitempty"`
+}
+
+// releaseSummary records the status of a release after it was installed or
+// upgraded.
+type releaseSummary struct {
+	Cluster   string `yaml:"cluster"`
+	Release   string `yaml:"release"`
+	Namespace string `yaml:"namespace"`
+	Revision  string `yaml:"revision"`
+	Status    string `yaml:"status"`
+	Chart     string `yaml:"chart"`
+}
+
+// RunSummary is written to the workspace so that a run can be reproduced
+// after the fact.
+type RunSummary struct {
+	// Profile, selected components and steps of the install plan
+	Profile    string   `yaml:"profile,omitempty"`
+	Components []string `yaml:"components,omitempty"`
+	Plan       []string `yaml:"plan,omitempty"`
+	// Resumed are the steps of the plan a failed install completed, skipped
+	// with --resume
+	Resumed []string `yaml:"resumed,omitempty"`
+	// NodeImages are the node images of the kind clusters, by cluster
+	NodeImages map[string]string `yaml:"node_images,omitempty"`
+	// Architecture is the platform the kind nodes run and how it was selected
+	Architecture *architectureSummary    `yaml:"architecture,omitempty"`
+	Charts       map[string]chartSummary `yaml:"charts"`
+	Failures     []releaseFailure        `yaml:"failures,omitempty"`
+	Releases     []releaseSummary        `yaml:"releases,omitempty"`
+	Tests        []releaseTest           `yaml:"tests,omitempty"`
+	// ControllerAPI is the apiVersion of the generated controller manifests
+	ControllerAPI *apiVersionSummary `yaml:"controller_api,omitempty"`
+}
+
+var runSummary = RunSummary{
+	Charts: make(map[string]chartSummary),
+}
+
+// writeRunSummary writes the summary of the current run to the workspace.
+func writeRunSummary() {
+	data, err := yaml.Marshal(runSummary)
+	if err != nil {
+		util.Fatalf("%s Failed to encode run summary: %v", util.Cross, err)
+	}
+	fileName := filepath.Join(kubesliceDirectory, runSummaryFileName)
+	if err := util.WriteFileAtomic(fileName, data, 0644); err != nil {
+		util.Fatalf("%s Failed to write run summary %s: %v", util.Cross, fileName, err)
+	}
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fieldManager owns the fields kubeslice-cli applies server side.
+const fieldManager = "kubeslice-cli"
+
+var applyConflictManager = regexp.MustCompile(`conflicts? with "([^"]+)"`)
+
+// applyConflictManagers returns the managers of the fields a server side
+// apply conflicted on, none when the apply failed otherwise.
+func applyConflictManagers(message string) []string {
+	managers := make([]string, 0)
+	for _, match := range applyConflictManager.FindAllStringSubmatch(message, -1) {
+		managers = appendUnique(managers, match[1])
+	}
+	return managers
+}
+
+// applyConflictError explains a server side apply which conflicted with the
+// fields of other managers.
+func applyConflictError(fileName string, managers []string, message string) error {
+	conflicts := make([]string, 0)
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "- ") || applyConflictManager.MatchString(line) {
+			conflicts = append(conflicts, line)
+		}
+	}
+	return fmt.Errorf("applying %s conflicts with fields managed by %s:\n%s\nRerun with --force-conflicts to take ownership of them deliberately",
+		fileName, strings.Join(managers, ", "), strings.Join(conflicts, "\n"))
+}
+
+// isControllerObject tells whether an object is a kubeslice controller object,
+// e.g. a Project, Cluster or SliceConfig, which is applied server side. The
+// other objects, namespaces, secrets or applications, are applied client side.
+func isControllerObject(apiVersion string) bool {
+	return strings.HasPrefix(apiVersion, kubesliceControllerGroup+"/")
+}
+
+// controllerManifest tells whether every object of a manifest file is a
+// kubeslice controller object.
+func controllerManifest(fileName string) bool {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	objects := 0
+	for {
+		var object struct {
+			APIVersion string `yaml:"apiVersion"`
+		}
+		if err := decoder.Decode(&object); errors.Is(err, io.EOF) {
+			return objects > 0
+		} else if err != nil {
+			return false
+		}
+		if object.APIVersion == "" {
+			continue
+		}
+		if !isControllerObject(object.APIVersion) {
+			return false
+		}
+		objects++
+	}
+}
+
+// serverSideApplyUnsupported tells whether an apply failed because the API
+// server does not support server side apply, which older clusters do not.
+func serverSideApplyUnsupported(message string) bool {
+	for _, s := range []string{"unknown format", "UnsupportedMediaType", "Unsupported Media Type", "does not support server-side apply"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	clientSideApplyMu       sync.Mutex
+	clientSideApplyClusters = make(map[string]bool) // by kubeconfig and context
+)
+
+func clusterKey(cluster *Cluster) string {
+	if cluster == nil {
+		return ""
+	}
+	return cluster.KubeConfigPath + "\x00" + cluster.ContextName
+}
+
+// useClientSideApply tells whether the objects are applied to a cluster client
+// side since it was found not supporting server side apply.
+func useClientSideApply(cluster *Cluster) bool {
+	clientSideApplyMu.Lock()
+	defer clientSideApplyMu.Unlock()
+	return clientSideApplyClusters[clusterKey(cluster)]
+}
+
+func fallBackToClientSideApply(cluster *Cluster) {
+	clientSideApplyMu.Lock()
+	defer clientSideApplyMu.Unlock()
+	clientSideApplyClusters[clusterKey(cluster)] = true
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const applyConflictOutput = `error: Apply failed with 2 conflicts: conflicts with "kubectl-client-side-apply" using controller.kubeslice.io/v1alpha1:
+- .spec.serviceAccount.readWrite
+conflicts with "argocd-controller" using controller.kubeslice.io/v1alpha1:
+- .metadata.labels.team
+Please review the fields above--they currently have other managers. Here
+are the ways you can resolve this warning:
+`
+
+func TestKubectlApply(t *testing.T) {
+	defer func(force bool) { Options.ForceConflicts = force }(Options.ForceConflicts)
+	dir := t.TempDir()
+	manifests := map[string]string{
+		"project.yaml":   "apiVersion: controller.kubeslice.io/v1alpha1\nkind: Project\nmetadata:\n  name: demo\n",
+		"namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: iperf\n",
+	}
+	for name, manifest := range manifests {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		cluster  Cluster
+		force    bool
+		handler  func(command []string, stdout, stderr io.Writer) error
+		commands []string
+		err      string
+	}{
+		{
+			name:     "Server side apply",
+			cluster:  Cluster{ContextName: "ssa", KubeConfigPath: "kubeconfig.yaml"},
+			commands: []string{"kubectl --context=ssa --kubeconfig=kubeconfig.yaml apply --server-side --field-manager=kubeslice-cli -f project.yaml -n kubeslice-controller"},
+		},
+		{
+			name:     "Other objects applied client side",
+			file:     "namespace.yaml",
+			cluster:  Cluster{ContextName: "ssa", KubeConfigPath: "kubeconfig.yaml"},
+			commands: []string{"kubectl --context=ssa --kubeconfig=kubeconfig.yaml apply -f namespace.yaml -n kubeslice-controller"},
+		},
+		{
+			name:     "Forced conflicts",
+			cluster:  Cluster{ContextName: "ssa", KubeConfigPath: "kubeconfig.yaml"},
+			force:    true,
+			commands: []string{"kubectl --context=ssa --kubeconfig=kubeconfig.yaml apply --server-side --field-manager=kubeslice-cli --force-conflicts -f project.yaml -n kubeslice-controller"},
+		},
+		{
+			name:    "Conflict",
+			cluster: Cluster{ContextName: "ssa", KubeConfigPath: "kubeconfig.yaml"},
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stderr, applyConflictOutput)
+				return errors.New("exit status 1")
+			},
+			commands: []string{"kubectl --context=ssa --kubeconfig=kubeconfig.yaml apply --server-side --field-manager=kubeslice-cli -f project.yaml -n kubeslice-controller"},
+			err: `applying project.yaml conflicts with fields managed by kubectl-client-side-apply, argocd-controller:
+error: Apply failed with 2 conflicts: conflicts with "kubectl-client-side-apply" using controller.kubeslice.io/v1alpha1:
+- .spec.serviceAccount.readWrite
+conflicts with "argocd-controller" using controller.kubeslice.io/v1alpha1:
+- .metadata.labels.team
+Rerun with --force-conflicts to take ownership of them deliberately`,
+		},
+		{
+			name:    "Cluster without server side apply",
+			cluster: Cluster{ContextName: "csa", KubeConfigPath: "kubeconfig.yaml"},
+			handler: func(command []string, stdout, stderr io.Writer) error {
+				if containsString(command, "--server-side") {
+					fmt.Fprint(stderr, "Error from server (UnsupportedMediaType): the body of the request was in an unknown format - accepted media types include: application/json-patch+json, application/merge-patch+json")
+					return errors.New("exit status 1")
+				}
+				return nil
+			},
+			commands: []string{
+				"kubectl --context=csa --kubeconfig=kubeconfig.yaml apply --server-side --field-manager=kubeslice-cli -f project.yaml -n kubeslice-controller",
+				"kubectl --context=csa --kubeconfig=kubeconfig.yaml apply -f project.yaml -n kubeslice-controller",
+				// the cluster is applied to client side from then on
+				"kubectl --context=csa --kubeconfig=kubeconfig.yaml apply -f project.yaml -n kubeslice-controller",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Options.ForceConflicts = tc.force
+			mock := &util.MockExecutor{Handler: tc.handler}
+			defer util.UseExecutor(mock)()
+
+			file := tc.file
+			if file == "" {
+				file = "project.yaml"
+			}
+			err := kubectlClient{}.Apply(&tc.cluster, KUBESLICE_CONTROLLER_NAMESPACE, filepath.Join(dir, file))
+			if tc.err == "" && err != nil {
+				t.Fatalf("Apply() returned error: %v", err)
+			}
+			if tc.err != "" && (err == nil || strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), "") != tc.err) {
+				t.Fatalf("Apply() error = %v, want\n%s", err, tc.err)
+			}
+			if len(tc.commands) > 1 {
+				kubectlClient{}.Apply(&tc.cluster, KUBESLICE_CONTROLLER_NAMESPACE, filepath.Join(dir, file))
+			}
+			commands := make([]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				commands = append(commands, strings.ReplaceAll(strings.Join(command, " "), dir+string(filepath.Separator), ""))
+			}
+			if !reflect.DeepEqual(commands, tc.commands) {
+				t.Errorf("commands =\n%s\nwant\n%s", strings.Join(commands, "\n"), strings.Join(tc.commands, "\n"))
+			}
+		})
+	}
+}
@@ -1,27 +1,270 @@
 package internal
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
 )
 
-const (
-	serviceExportConfigFileName = "serviceExportConfig.yaml"
-)
+var serviceProtocols = []string{"TCP", "UDP", "SCTP"}
+
+// ServiceExport is a service of a worker cluster exported to the other
+// clusters of a slice.
+type ServiceExport struct {
+	// Name of the ServiceExportConfig, <service>-<namespace>-<cluster> when empty
+	Name             string
+	Slice            string
+	Service          string
+	ServiceNamespace string
+	SourceCluster    string
+	// port[/protocol], the protocol is TCP when omitted
+	Ports []string
+}
+
+// serviceExportConfigColumns shows the exported services when no output
+// format is requested.
+const serviceExportConfigColumns = "custom-columns=NAME:.metadata.name,SLICE:.spec.sliceName,SERVICE:.spec.serviceName," +
+	"NAMESPACE:.spec.serviceNamespace,CLUSTER:.spec.sourceCluster,PORTS:.spec.serviceDiscoveryPorts[*].port"
+
+func serviceExportConfigFileName(serviceExportConfigName string) string {
+	return "serviceExportConfig-" + serviceExportConfigName + ".yaml"
+}
+
+func serviceExportConfigName(export ServiceExport) string {
+	if export.Name != "" {
+		return export.Name
+	}
+	return export.Service + "-" + export.ServiceNamespace + "-" + export.SourceCluster
+}
+
+// parseServicePort parses a port[/protocol] flag value. The port is named
+// after its protocol and number, the names only have to be unique.
+func parseServicePort(s string) (serviceDiscoveryPort, error) {
+	value, protocol := s, "TCP"
+	if i := strings.Index(s, "/"); i >= 0 {
+		value, protocol = s[:i], strings.ToUpper(s[i+1:])
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return serviceDiscoveryPort{}, fmt.Errorf("port %q is invalid: must be a number between 1 and 65535", s)
+	}
+	if !containsString(serviceProtocols, protocol) {
+		return serviceDiscoveryPort{}, fmt.Errorf("protocol of port %q is invalid: must be one of %s", s, strings.Join(serviceProtocols, ", "))
+	}
+	return serviceDiscoveryPort{Name: strings.ToLower(protocol) + "-" + value, Port: port, Protocol: protocol}, nil
+}
+
+// validateServiceExport checks the names and ports of an export, before the
+// slice it is exported to is looked up.
+func validateServiceExport(export ServiceExport) []string {
+	errors := make([]string, 0)
+	for _, field := range []struct{ kind, name string }{
+		{"slice name", export.Slice},
+		{"service name", export.Service},
+		{"service namespace", export.ServiceNamespace},
+		{"source cluster", export.SourceCluster},
+	} {
+		if err := validateName(field.kind, field.name, 63); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+		}
+	}
+	if len(export.Ports) == 0 {
+		errors = append(errors, fmt.Sprintf("%s service %s must export at least one port", util.Cross, export.Service))
+	}
+	seen := make(map[string]bool)
+	for _, value := range export.Ports {
+		port, err := parseServicePort(value)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			continue
+		}
+		if seen[port.Name] {
+			errors = append(errors, fmt.Sprintf("%s port %d/%s of service %s is exported more than once", util.Cross, port.Port, port.Protocol, export.Service))
+		}
+		seen[port.Name] = true
+	}
+	return errors
+}
+
+// validateServiceExportSlice checks that the source cluster of an export is
+// part of the slice, and its namespace onboarded to the slice on that cluster.
+func validateServiceExportSlice(export ServiceExport, slice sliceConfigManifest) error {
+	if !containsString(slice.Spec.Clusters, export.SourceCluster) {
+		return fmt.Errorf("cluster %s is not a cluster of slice %s", export.SourceCluster, export.Slice)
+	}
+	for _, ns := range slice.Spec.NamespaceIsolationProfile.ApplicationNamespaces {
+		if ns.Namespace == export.ServiceNamespace && (containsString(ns.Clusters, "*") || containsString(ns.Clusters, export.SourceCluster)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %s is not an application namespace of slice %s on cluster %s", export.ServiceNamespace, export.Slice, export.SourceCluster)
+}
+
+// VerifyServiceExportSlice looks up the slice of an export on the controller
+// cluster and fails unless the service can be exported to it.
+func VerifyServiceExportSlice(export ServiceExport, namespace string, controllerCluster *Cluster) {
+	var outB, errB bytes.Buffer
+	cmdArgs := kubectlClusterArgs(controllerCluster)
+	cmdArgs = append(cmdArgs, "get", SliceConfigObject, export.Slice, "-n", namespace, "-o", "yaml")
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, cmdArgs...); err != nil {
+		util.Fatalf("%s Slice %s does not exist in %s: %v %s", util.Cross, export.Slice, namespace, err, errB.String())
+	}
+	var slice sliceConfigManifest
+	if err := yaml.Unmarshal(outB.Bytes(), &slice); err != nil {
+		util.Fatalf("%s Unable to read slice %s: %v", util.Cross, export.Slice, err)
+	}
+	if err := validateServiceExportSlice(export, slice); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+}
+
+func serviceDiscoveryPorts(export ServiceExport) ([]serviceDiscoveryPort, error) {
+	ports := make([]serviceDiscoveryPort, 0, len(export.Ports))
+	for _, value := range export.Ports {
+		port, err := parseServicePort(value)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func serviceExportConfigurationManifest(export ServiceExport, namespace string) (string, error) {
+	ports, err := serviceDiscoveryPorts(export)
+	if err != nil {
+		return "", err
+	}
+	return marshalManifests(serviceExportConfigManifest{
+		APIVersion: kubesliceControllerAPIVersion,
+		Kind:       "ServiceExportConfig",
+		Metadata:   objectMeta{Name: serviceExportConfigName(export), Namespace: namespace},
+		Spec: serviceExportConfigSpec{
+			ServiceName:           export.Service,
+			ServiceNamespace:      export.ServiceNamespace,
+			SourceCluster:         export.SourceCluster,
+			SliceName:             export.Slice,
+			ServiceDiscoveryPorts: ports,
+		},
+	})
+}
+
+// GenerateAndApplyServiceExportConfig validates an export against its slice,
+// generates its ServiceExportConfig and applies it on the controller cluster.
+// A dry run prints the ServiceExportConfig without looking up the slice.
+func GenerateAndApplyServiceExportConfig(export ServiceExport, namespace string, controllerCluster *Cluster, dryRun bool) {
+	if errors := validateServiceExport(export); len(errors) > 0 {
+		util.Fatalf("%s", strings.Join(errors, "\n"))
+	}
+	if dryRun {
+		useForcedAPIVersion()
+	} else {
+		VerifyServiceExportSlice(export, namespace, controllerCluster)
+		DetectControllerAPIVersion(controllerCluster)
+	}
+	if dryRun {
+		manifest, err := serviceExportConfigurationManifest(export, namespace)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		printDryRunManifest(manifest)
+		return
+	}
+	fileName := GenerateServiceExportConfig(export, namespace)
+	CreateServiceExportConfig(namespace, controllerCluster, kubesliceDirectory+"/"+fileName)
+}
+
+// GenerateServiceExportConfig generates the ServiceExportConfig of an export
+// in the kubeslice directory and returns the name of its file.
+func GenerateServiceExportConfig(export ServiceExport, namespace string) string {
+	if errors := validateServiceExport(export); len(errors) > 0 {
+		util.Fatalf("%s", strings.Join(errors, "\n"))
+	}
+	manifest, err := serviceExportConfigurationManifest(export, namespace)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	GenerateKubeSliceDirectory()
+	fileName := serviceExportConfigFileName(serviceExportConfigName(export))
+	util.DumpFile(manifest, kubesliceDirectory+"/"+fileName)
+	util.Printf("%s Generated service export config manifest %s", util.Tick, fileName)
+	return fileName
+}
 
 func CreateServiceExportConfig(namespace string, controllerCluster *Cluster, filename string) {
 	ApplyFile(filename, namespace, controllerCluster)
-	util.Printf("\nSuccessfully Applied Slice Configuration.")
+	util.Printf("\nSuccessfully Applied Service Export Configuration.")
 }
 
-func GetServiceExportConfig(serviceExportConfigName string, namespace string, controllerCluster *Cluster) {
-	util.Printf("\nFetching KubeSlice serviceExportConfig...")
-	GetKubectlResources(ServiceExportConfigObject, serviceExportConfigName, namespace, controllerCluster, "")
-	time.Sleep(200 * time.Millisecond)
+// listServiceExportConfigs returns the ServiceExportConfigs of a slice.
+func listServiceExportConfigs(sliceName, namespace string, controllerCluster *Cluster) ([]serviceExportConfigManifest, error) {
+	var outB, errB bytes.Buffer
+	cmdArgs := kubectlClusterArgs(controllerCluster)
+	cmdArgs = append(cmdArgs, "get", ServiceExportConfigObject, "-n", namespace, "-o", "yaml")
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, cmdArgs...); err != nil {
+		return nil, fmt.Errorf("unable to list the serviceExportConfigs in %s: %v %s", namespace, err, errB.String())
+	}
+	var list struct {
+		Items []serviceExportConfigManifest `yaml:"items"`
+	}
+	if err := yaml.Unmarshal(outB.Bytes(), &list); err != nil {
+		return nil, fmt.Errorf("unable to read the serviceExportConfigs in %s: %v", namespace, err)
+	}
+	exports := make([]serviceExportConfigManifest, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.Spec.SliceName == sliceName {
+			exports = append(exports, item)
+		}
+	}
+	return exports, nil
+}
+
+func kubectlClusterArgs(cluster *Cluster) []string {
+	if cluster == nil {
+		return []string{}
+	}
+	return []string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath}
 }
-func generateServiceExportConfigManifest(serviceExportConfigName string) {
-	//util.DumpFile(fmt.Sprintf(ServiceExportConfigTemplate, serviceExportConfigName), kubesliceDirectory+"/"+serviceExportConfigFileName)
+
+// GetServiceExportConfig shows one or all ServiceExportConfigs of the
+// namespace, or the ones of a slice when sliceName is set.
+func GetServiceExportConfig(serviceExportConfigName string, namespace string, controllerCluster *Cluster, sliceName string, outputFormat string) {
+	util.Printf("\nFetching KubeSlice serviceExportConfig...")
+	if sliceName == "" {
+		if outputFormat == "" {
+			outputFormat = serviceExportConfigColumns
+		}
+		GetKubectlResources(ServiceExportConfigObject, serviceExportConfigName, namespace, controllerCluster, outputFormat)
+		time.Sleep(200 * time.Millisecond)
+		return
+	}
+	if serviceExportConfigName != "" || outputFormat != "" {
+		util.Fatalf("%s --slice can not be combined with a serviceExportConfig name or an output format", util.Cross)
+	}
+	exports, err := listServiceExportConfigs(sliceName, namespace, controllerCluster)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if len(exports) == 0 {
+		util.Printf("No serviceExportConfigs of slice %s in %s", sliceName, namespace)
+		return
+	}
+	w := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSERVICE\tNAMESPACE\tCLUSTER\tPORTS\t")
+	for _, export := range exports {
+		ports := make([]string, 0, len(export.Spec.ServiceDiscoveryPorts))
+		for _, port := range export.Spec.ServiceDiscoveryPorts {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", export.Metadata.Name, export.Spec.ServiceName, export.Spec.ServiceNamespace, export.Spec.SourceCluster, strings.Join(ports, ","))
+	}
+	w.Flush()
 }
 
 func DeleteServiceExportConfig(serviceExportConfigName string, namespace string, controllerCluster *Cluster) {
@@ -30,6 +273,23 @@ func DeleteServiceExportConfig(serviceExportConfigName string, namespace string,
 	time.Sleep(200 * time.Millisecond)
 }
 
+// DeleteSliceServiceExportConfigs deletes the ServiceExportConfigs of a slice.
+func DeleteSliceServiceExportConfigs(sliceName string, namespace string, controllerCluster *Cluster) {
+	util.Printf("\nDeleting KubeSlice serviceExportConfigs of slice %s...", sliceName)
+	exports, err := listServiceExportConfigs(sliceName, namespace, controllerCluster)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if len(exports) == 0 {
+		util.Printf("No serviceExportConfigs of slice %s in %s", sliceName, namespace)
+		return
+	}
+	for _, export := range exports {
+		DeleteKubectlResources(ServiceExportConfigObject, export.Metadata.Name, namespace, controllerCluster)
+	}
+	time.Sleep(200 * time.Millisecond)
+}
+
 func EditServiceExportConfig(serviceExportConfigName string, namespace string, controllerCluster *Cluster) {
 	util.Printf("\nEditing KubeSlice serviceExportConfig...")
 	EditKubectlResources(ServiceExportConfigObject, serviceExportConfigName, namespace, controllerCluster)
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseServicePort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected serviceDiscoveryPort
+		err      string
+	}{
+		{value: "5201", expected: serviceDiscoveryPort{Name: "tcp-5201", Port: 5201, Protocol: "TCP"}},
+		{value: "53/udp", expected: serviceDiscoveryPort{Name: "udp-53", Port: 53, Protocol: "UDP"}},
+		{value: "0/TCP", err: "must be a number between 1 and 65535"},
+		{value: "http", err: "must be a number between 1 and 65535"},
+		{value: "80/HTTP", err: "must be one of TCP, UDP, SCTP"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseServicePort(tc.value)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("parseServicePort(%q) error = %v, want an error containing %q", tc.value, err, tc.err)
+				}
+				return
+			}
+			if err != nil || got != tc.expected {
+				t.Errorf("parseServicePort(%q) = %+v, %v, want %+v", tc.value, got, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestServiceExportConfigurationManifest(t *testing.T) {
+	t.Parallel()
+
+	export := ServiceExport{Slice: "demo", Service: "iperf-server", ServiceNamespace: "iperf", SourceCluster: "ks-w-1", Ports: []string{"5201/TCP", "5201/UDP"}}
+	if errors := validateServiceExport(export); len(errors) != 0 {
+		t.Fatalf("validateServiceExport() = %q, want no errors", errors)
+	}
+	manifest, err := serviceExportConfigurationManifest(export, "kubeslice-demo")
+	if err != nil {
+		t.Fatalf("serviceExportConfigurationManifest() returned error: %v", err)
+	}
+	var config serviceExportConfigManifest
+	if err := yaml.Unmarshal([]byte(manifest), &config); err != nil {
+		t.Fatalf("serviceExportConfigurationManifest() is not valid YAML: %v", err)
+	}
+	expected := serviceExportConfigManifest{
+		APIVersion: "controller.kubeslice.io/v1alpha1",
+		Kind:       "ServiceExportConfig",
+		Metadata:   objectMeta{Name: "iperf-server-iperf-ks-w-1", Namespace: "kubeslice-demo"},
+		Spec: serviceExportConfigSpec{
+			ServiceName:      "iperf-server",
+			ServiceNamespace: "iperf",
+			SourceCluster:    "ks-w-1",
+			SliceName:        "demo",
+			ServiceDiscoveryPorts: []serviceDiscoveryPort{
+				{Name: "tcp-5201", Port: 5201, Protocol: "TCP"},
+				{Name: "udp-5201", Port: 5201, Protocol: "UDP"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("serviceExportConfig = %+v, want %+v", config, expected)
+	}
+
+	export.Ports = []string{"5201", "5201/tcp"}
+	if errors := validateServiceExport(export); len(errors) != 1 || !strings.Contains(errors[0], "exported more than once") {
+		t.Errorf("validateServiceExport() = %q, want the duplicate port reported", errors)
+	}
+}
+
+func TestValidateServiceExportSlice(t *testing.T) {
+	t.Parallel()
+
+	slice := sliceConfigManifest{Spec: sliceConfigSpec{
+		Clusters: []string{"worker-1", "worker-2"},
+		NamespaceIsolationProfile: namespaceIsolationProfile{ApplicationNamespaces: []sliceNamespace{
+			{Namespace: "iperf", Clusters: []string{"*"}},
+			{Namespace: "bookinfo", Clusters: []string{"worker-2"}},
+		}},
+	}}
+	tests := []struct {
+		name    string
+		cluster string
+		ns      string
+		err     string
+	}{
+		{name: "Namespace on every cluster", cluster: "worker-1", ns: "iperf"},
+		{name: "Namespace on the cluster", cluster: "worker-2", ns: "bookinfo"},
+		{name: "Namespace on another cluster", cluster: "worker-1", ns: "bookinfo", err: "namespace bookinfo is not an application namespace of slice demo on cluster worker-1"},
+		{name: "Namespace not onboarded", cluster: "worker-1", ns: "default", err: "namespace default is not an application namespace"},
+		{name: "Cluster outside of the slice", cluster: "worker-3", ns: "iperf", err: "cluster worker-3 is not a cluster of slice demo"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateServiceExportSlice(ServiceExport{Slice: "demo", ServiceNamespace: tc.ns, SourceCluster: tc.cluster}, slice)
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("validateServiceExportSlice() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("validateServiceExportSlice() = %v, want an error containing %q", err, tc.err)
+			}
+		})
+	}
+}
@@ -3,75 +3,281 @@ package internal
 import (
 	"bytes"
 	"fmt"
+	"net"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
+// DemoSlice is the slice of the demo profiles, which isolates the iperf
+// namespace on every worker. It is the slice of topologies without one.
+var DemoSlice = SliceConfiguration{
+	Name:                  "demo",
+	SliceSubnet:           "10.1.0.0/16",
+	ApplicationNamespaces: []SliceNamespace{{Namespace: "iperf", Clusters: []string{"*"}}},
+}
+
+// The QoS profile of slices without one, and the values the SliceConfig CRD
+// accepts.
+var (
+	defaultQoSProfile = qosProfileDetails{
+		QueueType:               "HTB",
+		Priority:                1,
+		TcType:                  "BANDWIDTH_CONTROL",
+		BandwidthCeilingKbps:    5120,
+		BandwidthGuaranteedKbps: 2560,
+		DscpClass:               "AF11",
+	}
+	qosQueueTypes  = []string{"HTB"}
+	qosDscpClasses = []string{"Default", "AF11", "AF12", "AF13", "AF21", "AF22", "AF23", "AF31", "AF32", "AF33", "AF41", "AF42", "AF43", "EF"}
+)
+
 const (
-	sliceTemplateFileName = "slice-demo.yaml"
+	minQoSPriority = 0
+	maxQoSPriority = 3
 )
 
-const sliceTemplate = `
-apiVersion: controller.kubeslice.io/v1alpha1
-kind: SliceConfig
-metadata:
-  name: %s
-  namespace: %s
-spec:
-  sliceSubnet: 10.1.0.0/16
-  sliceType: Application
-  sliceGatewayProvider:
-    sliceGatewayType: OpenVPN
-    sliceCaType: Local
-  sliceIpamType: Local
-  clusters: [%s]
-  qosProfileDetails:
-    queueType: HTB
-    priority: 1
-    tcType: BANDWIDTH_CONTROL
-    bandwidthCeilingKbps: 5120
-    bandwidthGuaranteedKbps: 2560
-    dscpClass: AF11
-  namespaceIsolationProfile:
-   applicationNamespaces:
-    - namespace: iperf
-      clusters:
-      - '*'
-`
+func sliceFileName(sliceConfigName string) string {
+	return "slice-" + sliceConfigName + ".yaml"
+}
 
-func GenerateSliceConfiguration(ApplicationConfiguration *ConfigurationSpecs, worker []string, sliceConfigName string, namespace string) {
-	util.Printf("\nGenerating Slice Configuration to %s directory", kubesliceDirectory)
-	clusters := make([]string, 0)
+// configuredSlice returns the slice of the topology with the defaults
+// applied, joining the workers passed instead of the ones of the slice and
+// named sliceConfigName when they are set.
+func configuredSlice(ApplicationConfiguration *ConfigurationSpecs, worker []string, sliceConfigName string) SliceConfiguration {
+	slice := ApplicationConfiguration.Configuration.KubeSliceConfiguration.Slice
+	if slice.Name == "" && slice.SliceSubnet == "" && len(slice.Clusters) == 0 && len(slice.ApplicationNamespaces) == 0 && len(slice.AllowedNamespaces) == 0 {
+		qos := slice.QoSProfile
+		slice = DemoSlice
+		slice.QoSProfile = qos
+	}
 	if len(worker) != 0 {
-		clusters = append(clusters, worker...)
-	} else {
-		wc := ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters
-		for _, cluster := range wc {
-			clusters = append(clusters, cluster.Name)
+		slice.Clusters = worker
+	}
+	// the slice is created in the default project, on its workers by default
+	if len(slice.Clusters) == 0 {
+		for _, cluster := range projectWorkers(ApplicationConfiguration.Configuration, ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName) {
+			slice.Clusters = append(slice.Clusters, cluster.Name)
 		}
 	}
-	clusterString := strings.Join(clusters, ",")
-	if len(sliceConfigName) == 0 {
-		sliceConfigName = "demo"
+	if sliceConfigName != "" {
+		slice.Name = sliceConfigName
 	}
-	projectNamespace := "kubeslice-" + ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName
-	if len(namespace) != 0 {
-		projectNamespace = namespace
+	if slice.Name == "" {
+		slice.Name = DemoSlice.Name
+	}
+	if slice.SliceSubnet == "" {
+		slice.SliceSubnet = DemoSlice.SliceSubnet
 	}
-	util.DumpFile(fmt.Sprintf(sliceTemplate, sliceConfigName, projectNamespace, clusterString), kubesliceDirectory+"/"+"slice-"+sliceConfigName+".yaml")
-	util.Printf("%s Generated %s", util.Tick, "slice-"+sliceConfigName+".yaml")
+	return slice
+}
+
+func GenerateSliceConfiguration(ApplicationConfiguration *ConfigurationSpecs, worker []string, sliceConfigName string, namespace string) {
+	util.Printf("\nGenerating Slice Configuration to %s directory", kubesliceDirectory)
+	GenerateKubeSliceDirectory()
+	slice := configuredSlice(ApplicationConfiguration, worker, sliceConfigName)
+	manifest, err := sliceConfigurationManifest(ApplicationConfiguration.Configuration.KubeSliceConfiguration, slice, sliceProjectNamespace(ApplicationConfiguration, namespace))
+	if err != nil {
+		util.Fatalf("%s Failed to generate slice configuration: %v", util.Cross, err)
+	}
+	util.DumpFile(manifest, kubesliceDirectory+"/"+sliceFileName(slice.Name))
+	util.Printf("%s Generated %s", util.Tick, sliceFileName(slice.Name))
 	time.Sleep(200 * time.Millisecond)
 
 	util.Printf("Generated Slice Configuration")
 }
 
+func sliceProjectNamespace(ApplicationConfiguration *ConfigurationSpecs, namespace string) string {
+	if len(namespace) != 0 {
+		return namespace
+	}
+	return "kubeslice-" + ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName
+}
+
+// sliceConfigurationManifest returns the SliceConfig of a slice of the
+// project.
+func sliceConfigurationManifest(ksc KubeSliceConfiguration, slice SliceConfiguration, namespace string) (string, error) {
+	sliceConfig := sliceConfigManifest{
+		APIVersion: kubesliceControllerAPIVersion,
+		Kind:       "SliceConfig",
+		Metadata:   newObjectMeta(slice.Name, namespace, ksc, ksc.SliceMetadata),
+		Spec: sliceConfigSpec{
+			SliceSubnet: slice.SliceSubnet,
+			SliceType:   "Application",
+			SliceGatewayProvider: sliceGatewayProvider{
+				SliceGatewayType: "OpenVPN",
+				SliceCaType:      "Local",
+			},
+			SliceIpamType:     "Local",
+			Clusters:          slice.Clusters,
+			QosProfileDetails: sliceQoSProfile(slice.QoSProfile),
+			NamespaceIsolationProfile: namespaceIsolationProfile{
+				IsolationEnabled:      slice.NamespaceIsolation,
+				ApplicationNamespaces: sliceNamespaces(slice.ApplicationNamespaces),
+				AllowedNamespaces:     sliceNamespaces(slice.AllowedNamespaces),
+			},
+		},
+	}
+	tmpl, err := loadManifestTemplate(sliceTemplateFile)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return marshalManifests(sliceConfig)
+	}
+	var rendered sliceConfigManifest
+	manifest, err := renderManifestTemplate(tmpl, ManifestTemplateData{
+		APIVersion:       sliceConfig.APIVersion,
+		Kind:             sliceConfig.Kind,
+		Name:             sliceConfig.Metadata.Name,
+		Namespace:        sliceConfig.Metadata.Namespace,
+		Labels:           sliceConfig.Metadata.Labels,
+		Annotations:      sliceConfig.Metadata.Annotations,
+		Project:          strings.TrimPrefix(namespace, "kubeslice-"),
+		ProjectNamespace: namespace,
+		Slice:            slice,
+		Spec:             sliceConfig.Spec,
+	}, &rendered)
+	if err != nil {
+		return "", err
+	}
+	if err := validateRenderedSlice(tmpl, rendered, slice.Clusters); err != nil {
+		return "", err
+	}
+	return manifest, nil
+}
+
+// sliceQoSProfile returns the QoS profile of a slice, the defaults for the
+// fields the topology leaves out.
+func sliceQoSProfile(qos QoSProfile) qosProfileDetails {
+	details := defaultQoSProfile
+	if qos.QueueType != "" {
+		details.QueueType = qos.QueueType
+	}
+	if qos.Priority != nil {
+		details.Priority = *qos.Priority
+	}
+	if qos.BandwidthCeilingKbps != 0 {
+		details.BandwidthCeilingKbps = qos.BandwidthCeilingKbps
+	}
+	if qos.BandwidthGuaranteedKbps != 0 {
+		details.BandwidthGuaranteedKbps = qos.BandwidthGuaranteedKbps
+	}
+	if qos.DscpClass != "" {
+		details.DscpClass = qos.DscpClass
+	}
+	return details
+}
+
+func validateQoSProfile(sliceName string, qos QoSProfile) []string {
+	return validateQoSProfileDetails(sliceName, sliceQoSProfile(qos))
+}
+
+// validateQoSProfileDetails checks the QoS profile of a SliceConfig, with the
+// defaults filled in or as a template rendered it.
+func validateQoSProfileDetails(sliceName string, details qosProfileDetails) []string {
+	errors := make([]string, 0)
+	if !containsString(qosQueueTypes, details.QueueType) {
+		errors = append(errors, fmt.Sprintf("%s queue type %s of slice %s is not supported. Possible values %s", util.Cross, details.QueueType, sliceName, qosQueueTypes))
+	}
+	if details.Priority < minQoSPriority || details.Priority > maxQoSPriority {
+		errors = append(errors, fmt.Sprintf("%s priority %d of slice %s must be between %d and %d", util.Cross, details.Priority, sliceName, minQoSPriority, maxQoSPriority))
+	}
+	if !containsString(qosDscpClasses, details.DscpClass) {
+		errors = append(errors, fmt.Sprintf("%s DSCP class %s of slice %s is not supported. Possible values %s", util.Cross, details.DscpClass, sliceName, qosDscpClasses))
+	}
+	if details.BandwidthCeilingKbps < 0 || details.BandwidthGuaranteedKbps < 0 {
+		errors = append(errors, fmt.Sprintf("%s bandwidths of slice %s must not be negative", util.Cross, sliceName))
+	} else if details.BandwidthCeilingKbps < details.BandwidthGuaranteedKbps {
+		errors = append(errors, fmt.Sprintf("%s bandwidth ceiling %d kbps of slice %s must be at least the guaranteed bandwidth %d kbps", util.Cross, details.BandwidthCeilingKbps, sliceName, details.BandwidthGuaranteedKbps))
+	}
+	return errors
+}
+
+func sliceNamespaces(namespaces []SliceNamespace) []sliceNamespace {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	converted := make([]sliceNamespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		clusters := ns.Clusters
+		if len(clusters) == 0 {
+			clusters = []string{"*"}
+		}
+		converted = append(converted, sliceNamespace{Namespace: ns.Namespace, Clusters: clusters})
+	}
+	return converted
+}
+
+// ValidateSlice checks the slice of the topology against the workers of the
+// topology, which are the clusters the install registers.
+func ValidateSlice(ApplicationConfiguration *ConfigurationSpecs) []string {
+	registered := make([]string, 0)
+	for _, cluster := range projectWorkers(ApplicationConfiguration.Configuration, ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName) {
+		registered = append(registered, cluster.Name)
+	}
+	return validateSlice(configuredSlice(ApplicationConfiguration, nil, ""), registered)
+}
+
+// VerifySliceClusters checks a slice against the clusters registered with
+// the project on the controller cluster, before the slice is applied.
+func VerifySliceClusters(ApplicationConfiguration *ConfigurationSpecs, worker []string, sliceConfigName, namespace string, controllerCluster *Cluster) {
+	registered, err := kubeClient.List(controllerCluster, ClusterObject, namespace)
+	if err != nil {
+		util.Fatalf("%s Unable to list the clusters registered in %s: %v", util.Cross, namespace, err)
+	}
+	errors := validateSlice(configuredSlice(ApplicationConfiguration, worker, sliceConfigName), registered)
+	if len(errors) > 0 {
+		util.Fatalf("%s", strings.Join(errors, "\n"))
+	}
+}
+
+func validateSlice(slice SliceConfiguration, registered []string) []string {
+	errors := make([]string, 0)
+	isRegistered := make(map[string]bool)
+	for _, name := range registered {
+		isRegistered[name] = true
+	}
+	if err := validateName("slice name", slice.Name, 63); err != nil {
+		errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+	}
+	if _, _, err := net.ParseCIDR(slice.SliceSubnet); err != nil {
+		errors = append(errors, fmt.Sprintf("%s slice subnet %q of slice %s is not a valid CIDR", util.Cross, slice.SliceSubnet, slice.Name))
+	}
+	for _, cluster := range slice.Clusters {
+		if !isRegistered[cluster] {
+			errors = append(errors, fmt.Sprintf("%s cluster %s of slice %s is not a registered worker cluster", util.Cross, cluster, slice.Name))
+		}
+	}
+	errors = append(errors, validateQoSProfile(slice.Name, slice.QoSProfile)...)
+	inSlice := make(map[string]bool)
+	for _, cluster := range slice.Clusters {
+		inSlice[cluster] = true
+	}
+	for kind, namespaces := range map[string][]SliceNamespace{"application": slice.ApplicationNamespaces, "allowed": slice.AllowedNamespaces} {
+		for _, ns := range namespaces {
+			if err := validateName(kind+" namespace", ns.Namespace, 63); err != nil {
+				errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+			}
+			for _, cluster := range ns.Clusters {
+				if cluster != "*" && !inSlice[cluster] {
+					errors = append(errors, fmt.Sprintf("%s cluster %s of %s namespace %s is not a cluster of slice %s", util.Cross, cluster, kind, ns.Namespace, slice.Name))
+				}
+			}
+		}
+	}
+	sort.Strings(errors)
+	return errors
+}
+
 func ApplySliceConfiguration(ApplicationConfiguration *ConfigurationSpecs) {
 	verifyNodeIPsInClusters(ApplicationConfiguration)
-	util.Printf("\nApplying Slice Manifest %s to %s cluster", sliceTemplateFileName, ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster.Name)
+	fileName := sliceFileName(configuredSlice(ApplicationConfiguration, nil, "").Name)
+	util.Printf("\nApplying Slice Manifest %s to %s cluster", fileName, ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster.Name)
 
-	ApplyKubectlManifest(kubesliceDirectory+"/"+sliceTemplateFileName, "kubeslice-demo", &ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster)
+	ApplyKubectlManifest(kubesliceDirectory+"/"+fileName, "kubeslice-"+ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName, &ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster)
 
 	util.Printf("\nSuccessfully Applied Slice Configuration.")
 }
@@ -79,7 +285,7 @@ func ApplySliceConfiguration(ApplicationConfiguration *ConfigurationSpecs) {
 func verifyNodeIPsInClusters(ApplicationConfiguration *ConfigurationSpecs) {
 	var outB, errB bytes.Buffer
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration.ControllerCluster
-	wc := ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters
+	wc := projectWorkers(ApplicationConfiguration.Configuration, ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName)
 	projectNamespace := "kubeslice-" + ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName
 	for _, cluster := range wc {
 		util.Printf("%s Waiting for NodeIPs to be populated in %s...", util.Wait, cluster.Name)
@@ -100,16 +306,42 @@ func verifyNodeIPsInClusters(ApplicationConfiguration *ConfigurationSpecs) {
 
 }
 
-func GetSliceConfig(sliceConfigName string, namespace string, controllerCluster *Cluster) {
+// sliceConfigColumns shows the subnet, clusters and QoS profile of slices
+// when no output format is requested.
+const sliceConfigColumns = "custom-columns=NAME:.metadata.name,SUBNET:.spec.sliceSubnet,CLUSTERS:.spec.clusters," +
+	"QUEUE:.spec.qosProfileDetails.queueType,PRIORITY:.spec.qosProfileDetails.priority," +
+	"CEILING_KBPS:.spec.qosProfileDetails.bandwidthCeilingKbps,GUARANTEED_KBPS:.spec.qosProfileDetails.bandwidthGuaranteedKbps," +
+	"DSCP:.spec.qosProfileDetails.dscpClass"
+
+func GetSliceConfig(sliceConfigName string, namespace string, controllerCluster *Cluster, outputFormat string) {
 	util.Printf("\nFetching KubeSlice sliceConfig...")
-	GetKubectlResources(SliceConfigObject, sliceConfigName, namespace, controllerCluster, "")
+	if outputFormat == "" {
+		outputFormat = sliceConfigColumns
+	}
+	GetKubectlResources(SliceConfigObject, sliceConfigName, namespace, controllerCluster, outputFormat)
 	time.Sleep(200 * time.Millisecond)
 }
 
-func DeleteSliceConfig(sliceConfigName string, namespace string, controllerCluster *Cluster) {
+// DeleteSliceConfig deletes a slice and, with wait, waits until the workers of
+// the topology it spans are offboarded from it.
+func DeleteSliceConfig(specs *ConfigurationSpecs, sliceConfigName string, namespace string, controllerCluster *Cluster, wait bool) {
 	util.Printf("\nDeleting KubeSlice SliceConfig...")
+	var workers []sliceOffboarding
+	if wait {
+		var err error
+		if workers, err = sliceOffboardingWorkers(specs, sliceConfigName, namespace, controllerCluster); err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+	}
 	DeleteKubectlResources(SliceConfigObject, sliceConfigName, namespace, controllerCluster)
 	time.Sleep(200 * time.Millisecond)
+	if !wait {
+		return
+	}
+	if err := waitForSliceOffboarding(sliceConfigName, workers); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	util.Printf("%s Slice %s offboarded from its workers", util.Tick, sliceConfigName)
 }
 
 func EditSliceConfig(sliceConfigName string, namespace string, controllerCluster *Cluster) {
@@ -128,3 +360,52 @@ func CreateSliceConfig(namespace string, controllerCluster *Cluster, filename st
 	ApplyFile(filename, namespace, controllerCluster)
 	util.Printf("\nSuccessfully Applied Slice Configuration.")
 }
+
+// GenerateAndApplySliceConfig generates the SliceConfig of the slice of the
+// topology, verifies its clusters are registered and applies it. A dry run
+// prints the SliceConfig without looking up the clusters.
+func GenerateAndApplySliceConfig(ApplicationConfiguration *ConfigurationSpecs, worker []string, sliceConfigName, namespace string, controllerCluster *Cluster, dryRun bool) {
+	if dryRun {
+		useForcedAPIVersion()
+		manifest, err := sliceConfigurationManifest(ApplicationConfiguration.Configuration.KubeSliceConfiguration, configuredSlice(ApplicationConfiguration, worker, sliceConfigName), sliceProjectNamespace(ApplicationConfiguration, namespace))
+		if err != nil {
+			util.Fatalf("%s Failed to generate slice configuration: %v", util.Cross, err)
+		}
+		printDryRunManifest(manifest)
+		return
+	}
+	WaitForControllerCRDs(controllerCluster)
+	VerifySliceClusters(ApplicationConfiguration, worker, sliceConfigName, namespace, controllerCluster)
+	DetectControllerAPIVersion(controllerCluster)
+	GenerateSliceConfiguration(ApplicationConfiguration, worker, sliceConfigName, namespace)
+	fileName := sliceFileName(configuredSlice(ApplicationConfiguration, worker, sliceConfigName).Name)
+	CreateSliceConfig(namespace, controllerCluster, kubesliceDirectory+"/"+fileName)
+}
+
+// ParseSliceNamespace parses a namespace of a slice passed as
+// namespace[=cluster,...], which is on every cluster of the slice without
+// clusters.
+func ParseSliceNamespace(s string) (SliceNamespace, error) {
+	ns := SliceNamespace{Namespace: s}
+	if i := strings.Index(s, "="); i >= 0 {
+		ns.Namespace = s[:i]
+		for _, cluster := range strings.Split(s[i+1:], ",") {
+			if cluster = strings.TrimSpace(cluster); cluster != "" {
+				ns.Clusters = append(ns.Clusters, cluster)
+			}
+		}
+	}
+	if ns.Namespace == "" {
+		return ns, fmt.Errorf("invalid slice namespace %q, expected namespace[=cluster,...]", s)
+	}
+	return ns, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestSliceConfigurationManifest(t *testing.T) {
+	t.Parallel()
+
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{{Name: "worker-1"}, {Name: "worker-2"}, {Name: "worker-3"}}},
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo", Slice: SliceConfiguration{
+			Name:               "red",
+			SliceSubnet:        "10.20.0.0/16",
+			Clusters:           []string{"worker-1", "worker-2"},
+			NamespaceIsolation: true,
+			ApplicationNamespaces: []SliceNamespace{
+				{Namespace: "bookinfo"},
+				{Namespace: "reviews", Clusters: []string{"worker-2"}},
+			},
+			AllowedNamespaces: []SliceNamespace{{Namespace: "kube-system"}},
+		}},
+	}}
+	manifest, err := sliceConfigurationManifest(specs.Configuration.KubeSliceConfiguration, configuredSlice(specs, nil, ""), "kubeslice-demo")
+	if err != nil {
+		t.Fatalf("sliceConfigurationManifest() returned error: %v", err)
+	}
+	var slice sliceConfigManifest
+	if err := yaml.Unmarshal([]byte(manifest), &slice); err != nil {
+		t.Fatalf("sliceConfigurationManifest() is not valid YAML: %v", err)
+	}
+	if slice.Metadata.Name != "red" || slice.Spec.SliceSubnet != "10.20.0.0/16" || !reflect.DeepEqual(slice.Spec.Clusters, []string{"worker-1", "worker-2"}) {
+		t.Errorf("slice = %+v, want slice red of 10.20.0.0/16 on worker-1 and worker-2", slice)
+	}
+	expected := namespaceIsolationProfile{
+		IsolationEnabled: true,
+		ApplicationNamespaces: []sliceNamespace{
+			{Namespace: "bookinfo", Clusters: []string{"*"}},
+			{Namespace: "reviews", Clusters: []string{"worker-2"}},
+		},
+		AllowedNamespaces: []sliceNamespace{{Namespace: "kube-system", Clusters: []string{"*"}}},
+	}
+	if !reflect.DeepEqual(slice.Spec.NamespaceIsolationProfile, expected) {
+		t.Errorf("namespaceIsolationProfile = %+v, want %+v", slice.Spec.NamespaceIsolationProfile, expected)
+	}
+
+	// the workers passed to create sliceConfig replace the clusters of the slice
+	if got := configuredSlice(specs, []string{"worker-3"}, "blue"); got.Name != "blue" || !reflect.DeepEqual(got.Clusters, []string{"worker-3"}) {
+		t.Errorf("configuredSlice() = %+v, want slice blue on worker-3", got)
+	}
+}
+
+func TestValidateSlice(t *testing.T) {
+	t.Parallel()
+
+	registered := []string{"worker-1", "worker-2"}
+	tests := []struct {
+		name  string
+		slice SliceConfiguration
+		err   string
+	}{
+		{
+			name:  "Valid",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: registered, ApplicationNamespaces: []SliceNamespace{{Namespace: "iperf", Clusters: []string{"*"}}}},
+		},
+		{
+			name:  "Invalid subnet",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0", Clusters: registered},
+			err:   `slice subnet "10.1.0.0" of slice demo is not a valid CIDR`,
+		},
+		{
+			name:  "Unregistered cluster",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: []string{"worker-1", "worker-9"}},
+			err:   "cluster worker-9 of slice demo is not a registered worker cluster",
+		},
+		{
+			name: "Namespace on a cluster outside of the slice",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: []string{"worker-1"},
+				AllowedNamespaces: []SliceNamespace{{Namespace: "monitoring", Clusters: []string{"worker-2"}}}},
+			err: "cluster worker-2 of allowed namespace monitoring is not a cluster of slice demo",
+		},
+		{
+			name:  "Invalid namespace",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: registered, ApplicationNamespaces: []SliceNamespace{{Namespace: "Book_Info"}}},
+			err:   `application namespace "Book_Info" is invalid`,
+		},
+		{
+			name:  "Priority out of range",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: registered, QoSProfile: QoSProfile{Priority: intPointer(4)}},
+			err:   "priority 4 of slice demo must be between 0 and 3",
+		},
+		{
+			name:  "Unknown DSCP class",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: registered, QoSProfile: QoSProfile{DscpClass: "CS1"}},
+			err:   "DSCP class CS1 of slice demo is not supported",
+		},
+		{
+			name:  "Ceiling below guaranteed",
+			slice: SliceConfiguration{Name: "demo", SliceSubnet: "10.1.0.0/16", Clusters: registered, QoSProfile: QoSProfile{BandwidthCeilingKbps: 1024}},
+			err:   "bandwidth ceiling 1024 kbps of slice demo must be at least the guaranteed bandwidth 2560 kbps",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errors := validateSlice(tc.slice, registered)
+			if tc.err == "" {
+				if len(errors) != 0 {
+					t.Errorf("validateSlice() = %q, want no errors", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || !strings.Contains(errors[0], tc.err) {
+				t.Errorf("validateSlice() = %q, want an error containing %q", errors, tc.err)
+			}
+		})
+	}
+}
+
+func TestSliceQoSProfile(t *testing.T) {
+	t.Parallel()
+
+	if got := sliceQoSProfile(QoSProfile{}); got != defaultQoSProfile {
+		t.Errorf("sliceQoSProfile() of an empty profile = %+v, want the defaults %+v", got, defaultQoSProfile)
+	}
+	expected := qosProfileDetails{QueueType: "HTB", Priority: 0, TcType: "BANDWIDTH_CONTROL", BandwidthCeilingKbps: 20480, BandwidthGuaranteedKbps: 10240, DscpClass: "EF"}
+	got := sliceQoSProfile(QoSProfile{Priority: intPointer(0), BandwidthCeilingKbps: 20480, BandwidthGuaranteedKbps: 10240, DscpClass: "EF"})
+	if got != expected {
+		t.Errorf("sliceQoSProfile() = %+v, want %+v", got, expected)
+	}
+}
+
+func intPointer(i int) *int {
+	return &i
+}
+
+func TestParseSliceNamespace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected SliceNamespace
+		err      bool
+	}{
+		{value: "iperf", expected: SliceNamespace{Namespace: "iperf"}},
+		{value: "iperf=worker-1,worker-2", expected: SliceNamespace{Namespace: "iperf", Clusters: []string{"worker-1", "worker-2"}}},
+		{value: "=worker-1", err: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSliceNamespace(tc.value)
+			if (err != nil) != tc.err {
+				t.Fatalf("ParseSliceNamespace(%q) error = %v, want error %v", tc.value, err, tc.err)
+			}
+			if !tc.err && !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ParseSliceNamespace(%q) = %+v, want %+v", tc.value, got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// sliceNamespaceLabel is set by the workers on the namespaces they onboard to
+// a slice, and removed once the namespace is offboarded.
+const sliceNamespaceLabel = "kubeslice.io/slice"
+
+// sliceOffboarding is a worker a deleted slice is offboarded from, with the
+// application namespaces it onboarded to the slice.
+type sliceOffboarding struct {
+	cluster    Cluster
+	namespaces []string
+}
+
+// offboardingObject is an object of a worker the offboarding of a deleted
+// slice still waits for.
+type offboardingObject struct {
+	cluster   Cluster
+	resource  string
+	namespace string
+	name      string
+	deleting  bool
+	// finalizers are the kubeslice finalizers of an object stuck in deletion,
+	// rest the other ones
+	finalizers, rest []string
+}
+
+func (o offboardingObject) String() string {
+	if o.resource == namespaceResource {
+		return fmt.Sprintf("namespace %s labeled %s", o.name, sliceNamespaceLabel)
+	}
+	description := fmt.Sprintf("%s %s/%s", objectKind(o.resource), o.namespace, o.name)
+	switch {
+	case len(o.finalizers) > 0:
+		description += " stuck in deletion on finalizers " + strings.Join(o.finalizers, ", ")
+	case o.deleting:
+		description += " being deleted"
+	}
+	return description
+}
+
+type namespaceLabelsObject struct {
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+}
+
+// sliceOffboardingWorkers reads the workers a slice spans from its
+// SliceConfig, along with the namespaces they onboarded, before the slice is
+// deleted. The workers are reached with the contexts of the topology, the
+// clusters of the slice missing from it are not waited for.
+func sliceOffboardingWorkers(specs *ConfigurationSpecs, sliceName, namespace string, controllerCluster *Cluster) ([]sliceOffboarding, error) {
+	object, err := kubeClient.Get(controllerCluster, SliceConfigObject, namespace, sliceName)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("sliceConfig %s not found in namespace %s", sliceName, namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get sliceConfig %s: %v", sliceName, err)
+	}
+	var sliceConfig sliceConfigObject
+	if err := decodeObject(object, &sliceConfig); err != nil {
+		return nil, fmt.Errorf("unable to parse sliceConfig %s: %v", sliceName, err)
+	}
+
+	workers := make(map[string]Cluster)
+	for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+		workers[cluster.Name] = cluster
+	}
+	offboarding := make([]sliceOffboarding, 0, len(sliceConfig.Spec.Clusters))
+	for _, name := range sliceConfig.Spec.Clusters {
+		cluster, found := workers[name]
+		if !found {
+			util.Printf("%s Cluster %s of slice %s is not in the topology, its offboarding is not waited for", util.Warn, name, sliceName)
+			continue
+		}
+		worker := sliceOffboarding{cluster: cluster}
+		if object, err := kubeClient.Get(&cluster, sliceResource, WorkerNamespace(cluster), sliceName); err == nil {
+			var slice workerSliceObject
+			if decodeObject(object, &slice) == nil {
+				worker.namespaces = slice.Status.ApplicationNamespaces
+			}
+		}
+		offboarding = append(offboarding, worker)
+	}
+	return offboarding, nil
+}
+
+// waitForSliceOffboarding waits until every worker of a deleted slice removed
+// its Slice, its SliceGateways and the label of its onboarded namespaces,
+// printing the progress of every worker. Once --timeout elapsed the objects
+// still there are reported, and with --force-finalizers the kubeslice
+// finalizers of those stuck in deletion are removed and the workers waited for
+// again.
+func waitForSliceOffboarding(sliceName string, workers []sliceOffboarding) error {
+	blocking, err := pollSliceOffboarding(sliceName, workers)
+	if err != nil || len(blocking) == 0 {
+		return err
+	}
+	if Options.ForceFinalizers && removeOffboardingFinalizers(blocking) {
+		if blocking, err = pollSliceOffboarding(sliceName, workers); err != nil || len(blocking) == 0 {
+			return err
+		}
+	}
+	stuck := false
+	lines := make([]string, 0, len(blocking))
+	for _, o := range blocking {
+		lines = append(lines, fmt.Sprintf("  %s: %s", o.cluster.Name, o))
+		stuck = stuck || len(o.finalizers) > 0
+	}
+	message := fmt.Sprintf("slice %s is not offboarded after %s, the workers still have:\n%s", sliceName, Options.DeletionTimeout, strings.Join(lines, "\n"))
+	if stuck && !Options.ForceFinalizers {
+		message += "\nPass --force-finalizers to remove the kubeslice finalizers of the objects stuck in deletion"
+	}
+	return errors.New(message)
+}
+
+// pollSliceOffboarding polls the workers until they are offboarded from the
+// slice, and returns the objects they still have once --timeout elapsed.
+func pollSliceOffboarding(sliceName string, workers []sliceOffboarding) ([]offboardingObject, error) {
+	offboarded := make(map[string]bool)
+	var blocking []offboardingObject
+	var readErr error
+	err := PollUntil(projectDeletionPollInterval, Options.DeletionTimeout, func(elapsed time.Duration) (bool, error) {
+		blocking = make([]offboardingObject, 0)
+		for _, worker := range workers {
+			if offboarded[worker.cluster.Name] {
+				continue
+			}
+			left, err := sliceLeftovers(worker, sliceName)
+			if err != nil {
+				readErr = fmt.Errorf("unable to read the objects of slice %s on %s: %v", sliceName, worker.cluster.Name, err)
+				return false, readErr
+			}
+			if len(left) == 0 {
+				offboarded[worker.cluster.Name] = true
+				util.Printf("%s Worker %s offboarded from slice %s", util.Tick, worker.cluster.Name, sliceName)
+				continue
+			}
+			descriptions := make([]string, 0, len(left))
+			for _, o := range left {
+				descriptions = append(descriptions, o.String())
+			}
+			util.Printf("%s Waiting for worker %s to be offboarded from slice %s... %s, %d seconds elapsed", util.Wait, worker.cluster.Name, sliceName, strings.Join(descriptions, ", "), int(elapsed.Seconds()))
+			blocking = append(blocking, left...)
+		}
+		return len(blocking) == 0, nil
+	})
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err != nil {
+		return blocking, nil
+	}
+	return nil, nil
+}
+
+// sliceLeftovers returns the Slice and SliceGateways of a slice on a worker
+// and the onboarded namespaces still labeled with it.
+func sliceLeftovers(worker sliceOffboarding, sliceName string) ([]offboardingObject, error) {
+	cluster := worker.cluster
+	namespace := WorkerNamespace(cluster)
+	left := make([]offboardingObject, 0)
+	object, err := kubeClient.Get(&cluster, sliceResource, namespace, sliceName)
+	switch {
+	case errors.Is(err, errNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		left = append(left, offboardingLeftover(cluster, sliceResource, namespace, sliceName, object))
+	}
+
+	names, err := kubeClient.List(&cluster, sliceGatewayResource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		object, err := kubeClient.Get(&cluster, sliceGatewayResource, namespace, name)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var gateway sliceGatewayObject
+		if err := decodeObject(object, &gateway); err != nil || gateway.Spec.SliceName != sliceName {
+			continue
+		}
+		left = append(left, offboardingLeftover(cluster, sliceGatewayResource, namespace, name, object))
+	}
+
+	for _, name := range worker.namespaces {
+		object, err := kubeClient.Get(&cluster, namespaceResource, "", name)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var ns namespaceLabelsObject
+		if err := decodeObject(object, &ns); err == nil && ns.Metadata.Labels[sliceNamespaceLabel] == sliceName {
+			left = append(left, offboardingObject{cluster: cluster, resource: namespaceResource, name: name})
+		}
+	}
+	return left, nil
+}
+
+func offboardingLeftover(cluster Cluster, resource, namespace, name string, object map[string]interface{}) offboardingObject {
+	o := offboardingObject{cluster: cluster, resource: resource, namespace: namespace, name: name}
+	var r stuckResource
+	if decodeObject(object, &r) == nil && r.Metadata.DeletionTimestamp != "" {
+		o.deleting = true
+		o.finalizers, o.rest = r.kubesliceFinalizers()
+	}
+	return o
+}
+
+// removeOffboardingFinalizers removes the kubeslice finalizers of the objects
+// stuck in deletion, whose worker did not finish offboarding them. It tells
+// whether any was removed.
+func removeOffboardingFinalizers(blocking []offboardingObject) bool {
+	removed := false
+	for _, o := range blocking {
+		if len(o.finalizers) == 0 {
+			continue
+		}
+		patch, _ := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"finalizers": o.rest}})
+		_, err := runKubectl(o.cluster, "patch", o.resource, o.name, "--namespace", o.namespace, "--type", "merge", "-p", string(patch))
+		if err != nil {
+			util.Printf("%s Unable to remove the finalizers of %s %s/%s on %s: %v", util.Cross, objectKind(o.resource), o.namespace, o.name, o.cluster.Name, err)
+			continue
+		}
+		util.Printf("%s Removed the finalizers %s of %s %s/%s on %s", util.Tick, strings.Join(o.finalizers, ", "), objectKind(o.resource), o.namespace, o.name, o.cluster.Name)
+		removed = true
+	}
+	return removed
+}
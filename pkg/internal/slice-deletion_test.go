@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestWaitForSliceOffboarding(t *testing.T) {
+	defer func(client KubeClient, options RunOptions, interval time.Duration) {
+		kubeClient, *Options, projectDeletionPollInterval = client, options, interval
+	}(kubeClient, *Options, projectDeletionPollInterval)
+	projectDeletionPollInterval = time.Millisecond
+	Options.DeletionTimeout = 0
+
+	worker := sliceOffboarding{cluster: Cluster{Name: "ks-w-1", ContextName: "ks-w-1"}, namespaces: []string{"iperf"}}
+	sliceKey := objectsKey(sliceResource, KUBESLICE_WORKER_NAMESPACE) + "/red"
+	namespaceKey := objectsKey(namespaceResource, "") + "/iperf"
+	leftovers := func() map[string]map[string]interface{} {
+		return map[string]map[string]interface{}{
+			sliceKey: {"metadata": map[string]interface{}{
+				"name": "red", "namespace": KUBESLICE_WORKER_NAMESPACE, "deletionTimestamp": "2026-10-14T10:00:00Z",
+				"finalizers": []interface{}{"networking.kubeslice.io/slice-finalizer"},
+			}},
+			namespaceKey: {"metadata": map[string]interface{}{"name": "iperf", "labels": map[string]interface{}{sliceNamespaceLabel: "red"}}},
+		}
+	}
+	tests := []struct {
+		name      string
+		documents map[string]map[string]interface{}
+		force     bool
+		patched   int
+		err       []string
+	}{
+		{
+			name:      "Offboarded",
+			documents: map[string]map[string]interface{}{},
+		},
+		{
+			name:      "Stuck on finalizers",
+			documents: leftovers(),
+			err: []string{
+				"ks-w-1: slices kubeslice-system/red stuck in deletion on finalizers networking.kubeslice.io/slice-finalizer",
+				"ks-w-1: namespace iperf labeled kubeslice.io/slice",
+				"Pass --force-finalizers",
+			},
+		},
+		{
+			name:      "Finalizers forced",
+			documents: leftovers(),
+			force:     true,
+			patched:   1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeKubeClient{documents: tc.documents}
+			kubeClient = fake
+			Options.ForceFinalizers = tc.force
+			// removing the finalizers lets the worker finish the offboarding
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				delete(fake.documents, sliceKey)
+				delete(fake.documents, namespaceKey)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			err := waitForSliceOffboarding("red", []sliceOffboarding{worker})
+			if len(mock.Commands) != tc.patched {
+				t.Errorf("waitForSliceOffboarding() ran %q, want %d patches", mock.Commands, tc.patched)
+			}
+			if len(tc.err) == 0 {
+				if err != nil {
+					t.Fatalf("waitForSliceOffboarding() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("waitForSliceOffboarding() returned no error, want %q", tc.err)
+			}
+			for _, expected := range tc.err {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("waitForSliceOffboarding() error = %v, want %q", err, expected)
+				}
+			}
+		})
+	}
+}
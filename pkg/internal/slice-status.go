@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// Resources of a slice on the worker clusters.
+const (
+	sliceResource        = "slices.networking.kubeslice.io"
+	sliceGatewayResource = "slicegateways.networking.kubeslice.io"
+)
+
+// States of a worker cluster of a slice.
+const (
+	sliceClusterReady       = "ready"
+	sliceClusterNotReady    = "not-ready"
+	sliceClusterUnreachable = "unreachable"
+	sliceClusterMissing     = "slice-missing"
+	sliceClusterUnknown     = "not-in-topology"
+)
+
+// sliceStatus is the state of a slice on the controller and on every worker
+// cluster it spans.
+type sliceStatus struct {
+	Name      string               `json:"name"`
+	Namespace string               `json:"namespace"`
+	Clusters  []sliceClusterStatus `json:"clusters"`
+}
+
+type sliceClusterStatus struct {
+	Cluster               string               `json:"cluster"`
+	Context               string               `json:"context,omitempty"`
+	State                 string               `json:"state"`
+	Error                 string               `json:"error,omitempty"`
+	Health                string               `json:"health,omitempty"`
+	Gateways              []sliceGatewayStatus `json:"gateways"`
+	ApplicationNamespaces []string             `json:"applicationNamespaces"`
+	AppPods               []string             `json:"appPods"`
+}
+
+type sliceGatewayStatus struct {
+	Name    string   `json:"name"`
+	Peer    string   `json:"peer,omitempty"`
+	Tunnels []string `json:"tunnels"`
+}
+
+// tunnelsUp counts the tunnels of the gateways of a cluster which are up.
+func (s sliceClusterStatus) tunnelsUp() (up, total int) {
+	for _, gateway := range s.Gateways {
+		for _, tunnel := range gateway.Tunnels {
+			if tunnel == "up" {
+				up++
+			}
+			total++
+		}
+	}
+	return up, total
+}
+
+// The parts of the SliceConfig of the controller and of the Slice and
+// SliceGateway of the workers the status is made of.
+type sliceConfigObject struct {
+	Spec struct {
+		Clusters []string `json:"clusters"`
+	} `json:"spec"`
+}
+
+type workerSliceObject struct {
+	Status struct {
+		ApplicationNamespaces []string `json:"applicationNamespaces"`
+		AppPods               []struct {
+			PodName      string `json:"podName"`
+			PodNamespace string `json:"podNamespace"`
+		} `json:"appPods"`
+		SliceHealth struct {
+			SliceHealthStatus string `json:"sliceHealthStatus"`
+		} `json:"sliceHealth"`
+	} `json:"status"`
+}
+
+type sliceGatewayObject struct {
+	Spec struct {
+		SliceName           string `json:"sliceName"`
+		RemoteGatewayConfig struct {
+			ClusterName string `json:"clusterName"`
+		} `json:"remoteGatewayConfig"`
+	} `json:"spec"`
+	Status struct {
+		GatewayPodStatus []struct {
+			PodName      string `json:"podName"`
+			TunnelStatus struct {
+				// 0 is up
+				Status int `json:"status"`
+			} `json:"tunnelStatus"`
+		} `json:"gatewayPodStatus"`
+	} `json:"status"`
+}
+
+// decodeObject converts an object the KubeClient returned to the struct of
+// the fields of interest.
+func decodeObject(object map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ShowSliceStatus aggregates the state of a slice: the clusters of its
+// SliceConfig on the controller and, on every one of them, the health of
+// the Slice, the tunnels of its gateways, the onboarded namespaces and app
+// pods. The worker clusters are reached with the contexts of the topology, a
+// worker which cannot be reached is reported as unreachable.
+func ShowSliceStatus(specs *ConfigurationSpecs, sliceName, namespace string, controllerCluster *Cluster, outputFormat string) {
+	status, err := getSliceStatus(specs, sliceName, namespace, controllerCluster)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if outputFormat == OutputFormatJson {
+		out, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			util.Fatalf("%s Unable to marshal the status of slice %s: %v", util.Cross, sliceName, err)
+		}
+		util.Printf("%s", out)
+		return
+	}
+	printSliceStatus(status)
+}
+
+func getSliceStatus(specs *ConfigurationSpecs, sliceName, namespace string, controllerCluster *Cluster) (*sliceStatus, error) {
+	object, err := kubeClient.Get(controllerCluster, SliceConfigObject, namespace, sliceName)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("sliceConfig %s not found in namespace %s", sliceName, namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get sliceConfig %s: %v", sliceName, err)
+	}
+	var sliceConfig sliceConfigObject
+	if err := decodeObject(object, &sliceConfig); err != nil {
+		return nil, fmt.Errorf("unable to parse sliceConfig %s: %v", sliceName, err)
+	}
+
+	workers := make(map[string]Cluster)
+	for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+		workers[cluster.Name] = cluster
+	}
+	status := &sliceStatus{Name: sliceName, Namespace: namespace, Clusters: make([]sliceClusterStatus, 0, len(sliceConfig.Spec.Clusters))}
+	for _, name := range sliceConfig.Spec.Clusters {
+		cluster, found := workers[name]
+		if !found {
+			status.Clusters = append(status.Clusters, sliceClusterStatus{Cluster: name, State: sliceClusterUnknown, Error: "the topology has no context for this cluster"})
+			continue
+		}
+		status.Clusters = append(status.Clusters, workerSliceStatus(cluster, sliceName))
+	}
+	return status, nil
+}
+
+// workerSliceStatus reads the Slice and SliceGateways of a slice on a worker.
+func workerSliceStatus(cluster Cluster, sliceName string) sliceClusterStatus {
+	status := sliceClusterStatus{Cluster: cluster.Name, Context: cluster.ContextName, Gateways: make([]sliceGatewayStatus, 0), ApplicationNamespaces: make([]string, 0), AppPods: make([]string, 0)}
+	namespace := WorkerNamespace(cluster)
+	object, err := kubeClient.Get(&cluster, sliceResource, namespace, sliceName)
+	if errors.Is(err, errNotFound) {
+		status.State = sliceClusterMissing
+		return status
+	}
+	if err != nil {
+		status.State, status.Error = sliceClusterUnreachable, err.Error()
+		return status
+	}
+	var slice workerSliceObject
+	if err := decodeObject(object, &slice); err != nil {
+		status.State, status.Error = sliceClusterNotReady, fmt.Sprintf("unable to parse slice %s: %v", sliceName, err)
+		return status
+	}
+	status.Health = slice.Status.SliceHealth.SliceHealthStatus
+	status.ApplicationNamespaces = append(status.ApplicationNamespaces, slice.Status.ApplicationNamespaces...)
+	for _, pod := range slice.Status.AppPods {
+		status.AppPods = append(status.AppPods, pod.PodNamespace+"/"+pod.PodName)
+	}
+	sort.Strings(status.AppPods)
+
+	names, err := kubeClient.List(&cluster, sliceGatewayResource, namespace)
+	if err != nil {
+		status.State, status.Error = sliceClusterUnreachable, err.Error()
+		return status
+	}
+	for _, name := range names {
+		object, err := kubeClient.Get(&cluster, sliceGatewayResource, namespace, name)
+		if err != nil {
+			status.State, status.Error = sliceClusterUnreachable, err.Error()
+			return status
+		}
+		var gateway sliceGatewayObject
+		if err := decodeObject(object, &gateway); err != nil || gateway.Spec.SliceName != sliceName {
+			continue
+		}
+		gw := sliceGatewayStatus{Name: name, Peer: gateway.Spec.RemoteGatewayConfig.ClusterName, Tunnels: make([]string, 0)}
+		for _, pod := range gateway.Status.GatewayPodStatus {
+			tunnel := "down"
+			if pod.TunnelStatus.Status == 0 {
+				tunnel = "up"
+			}
+			gw.Tunnels = append(gw.Tunnels, tunnel)
+		}
+		status.Gateways = append(status.Gateways, gw)
+	}
+
+	up, total := status.tunnelsUp()
+	status.State = sliceClusterReady
+	if len(status.Gateways) == 0 || total == 0 || up < total || (status.Health != "" && status.Health != "Normal") {
+		status.State = sliceClusterNotReady
+	}
+	return status
+}
+
+func printSliceStatus(status *sliceStatus) {
+	util.Printf("\nSlice %s in namespace %s:", status.Name, status.Namespace)
+	w := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSTATE\tHEALTH\tGATEWAYS\tTUNNELS UP\tNAMESPACES\tAPP PODS\t")
+	for _, cluster := range status.Clusters {
+		up, total := cluster.tunnelsUp()
+		health := cluster.Health
+		if health == "" {
+			health = "-"
+		}
+		namespaces := strings.Join(cluster.ApplicationNamespaces, ",")
+		if namespaces == "" {
+			namespaces = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d/%d\t%s\t%d\t\n", cluster.Cluster, cluster.State, health, len(cluster.Gateways), up, total, namespaces, len(cluster.AppPods))
+	}
+	w.Flush()
+	for _, cluster := range status.Clusters {
+		if cluster.Error != "" {
+			util.Printf("%s %s: %s", util.Warn, cluster.Cluster, cluster.Error)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// sliceStatusClient serves the objects of a slice, failing on the clusters
+// which are unreachable.
+type sliceStatusClient struct {
+	fakeKubeClient
+	objects     map[string]string // JSON by context, resource and name
+	unreachable string
+}
+
+func (c *sliceStatusClient) Get(cluster *Cluster, resource, namespace, name string) (map[string]interface{}, error) {
+	context := ""
+	if cluster != nil {
+		context = cluster.ContextName
+	}
+	if context == c.unreachable {
+		return nil, errors.New("dial tcp 10.0.0.1:443: i/o timeout")
+	}
+	data, found := c.objects[context+"/"+resource+"/"+name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s %s", errNotFound, resource, name)
+	}
+	object := make(map[string]interface{})
+	return object, json.Unmarshal([]byte(data), &object)
+}
+
+func (c *sliceStatusClient) List(cluster *Cluster, resource, namespace string) ([]string, error) {
+	return []string{"demo-ks-w-1-ks-w-2", "other-ks-w-1-ks-w-2"}, nil
+}
+
+func TestGetSliceStatus(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	kubeClient = &sliceStatusClient{
+		objects: map[string]string{
+			"/" + SliceConfigObject + "/demo": `{"spec": {"clusters": ["ks-w-1", "ks-w-2", "ks-w-3", "ks-w-4"]}}`,
+			"kind-ks-w-1/" + sliceResource + "/demo": `{"status": {"applicationNamespaces": ["iperf"],
+				"appPods": [{"podName": "iperf-sleep", "podNamespace": "iperf"}], "sliceHealth": {"sliceHealthStatus": "Normal"}}}`,
+			"kind-ks-w-1/" + sliceGatewayResource + "/demo-ks-w-1-ks-w-2": `{"spec": {"sliceName": "demo", "remoteGatewayConfig": {"clusterName": "ks-w-2"}},
+				"status": {"gatewayPodStatus": [{"podName": "gw-0", "tunnelStatus": {"status": 0}}, {"podName": "gw-1", "tunnelStatus": {"status": 1}}]}}`,
+			"kind-ks-w-1/" + sliceGatewayResource + "/other-ks-w-1-ks-w-2": `{"spec": {"sliceName": "other"}}`,
+		},
+		unreachable: "kind-ks-w-2",
+	}
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{WorkerClusters: []Cluster{
+		{Name: "ks-w-1", ContextName: "kind-ks-w-1"},
+		{Name: "ks-w-2", ContextName: "kind-ks-w-2"},
+		{Name: "ks-w-3", ContextName: "kind-ks-w-3"},
+	}}}}
+
+	status, err := getSliceStatus(specs, "demo", "kubeslice-demo", nil)
+	if err != nil {
+		t.Fatalf("getSliceStatus() returned error: %v", err)
+	}
+	states := make([]string, 0)
+	for _, cluster := range status.Clusters {
+		states = append(states, cluster.State)
+	}
+	if expected := []string{sliceClusterNotReady, sliceClusterUnreachable, sliceClusterMissing, sliceClusterUnknown}; !reflect.DeepEqual(states, expected) {
+		t.Errorf("states = %v, want %v", states, expected)
+	}
+	expected := sliceClusterStatus{
+		Cluster: "ks-w-1", Context: "kind-ks-w-1", State: sliceClusterNotReady, Health: "Normal",
+		Gateways:              []sliceGatewayStatus{{Name: "demo-ks-w-1-ks-w-2", Peer: "ks-w-2", Tunnels: []string{"up", "down"}}},
+		ApplicationNamespaces: []string{"iperf"},
+		AppPods:               []string{"iperf/iperf-sleep"},
+	}
+	if !reflect.DeepEqual(status.Clusters[0], expected) {
+		t.Errorf("ks-w-1 = %+v, want %+v", status.Clusters[0], expected)
+	}
+
+	if _, err := getSliceStatus(specs, "missing", "kubeslice-demo", nil); err == nil {
+		t.Errorf("getSliceStatus() of a missing slice returned no error")
+	}
+}
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// fieldAliases are the fields types accept besides those of their yaml tags.
+var fieldAliases = map[reflect.Type]map[string]reflect.Type{
+	reflect.TypeOf(Cluster{}): {"kube_context": reflect.TypeOf("")},
+}
+
+var unknownFieldError = regexp.MustCompile(`^line (\d+): field (\S+) not found in type `)
+
+// unknownField is a key of the topology no field of the configuration is
+// decoded from, e.g. a typo or a key indented below the wrong parent.
+type unknownField struct {
+	path       string
+	field      string
+	suggestion string
+}
+
+// DecodeTopology strictly decodes a topology file, returning every problem of
+// it: the fields which are not known, with their YAML path and the closest
+// known field, and the values which do not fit their field, with their line.
+func DecodeTopology(data []byte) (*ConfigurationSpecs, []string) {
+	specs := &ConfigurationSpecs{}
+	err := yaml.UnmarshalStrict(data, specs)
+	if err == nil {
+		return specs, nil
+	}
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return nil, []string{fmt.Sprintf("%s Failed to parse configuration file: %v", util.Cross, err)}
+	}
+
+	// the decoder tells the lines of the unknown fields in document order,
+	// the walk of the document their paths
+	lines := make(map[string][]string)
+	errors := make([]string, 0, len(typeErr.Errors))
+	others := make([]string, 0)
+	for _, e := range typeErr.Errors {
+		if m := unknownFieldError.FindStringSubmatch(e); m != nil {
+			lines[m[2]] = append(lines[m[2]], m[1])
+			continue
+		}
+		others = append(others, e)
+	}
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(data, &document); err == nil {
+		for _, u := range unknownFields(document, reflect.TypeOf(ConfigurationSpecs{}), "") {
+			location := ""
+			if l := lines[u.field]; len(l) > 0 {
+				location, lines[u.field] = "line "+l[0]+": ", l[1:]
+			}
+			if u.path != "" {
+				location += u.path + ": "
+			}
+			hint := ""
+			if u.suggestion != "" {
+				hint = fmt.Sprintf(" (did you mean %s?)", u.suggestion)
+			}
+			errors = append(errors, fmt.Sprintf("%s %sunknown field '%s'%s", util.Cross, location, u.field, hint))
+		}
+	}
+	// unknown fields the walk did not find
+	for _, e := range typeErr.Errors {
+		if m := unknownFieldError.FindStringSubmatch(e); m != nil && len(lines[m[2]]) > 0 && lines[m[2]][0] == m[1] {
+			lines[m[2]] = lines[m[2]][1:]
+			errors = append(errors, fmt.Sprintf("%s line %s: unknown field '%s'", util.Cross, m[1], m[2]))
+		}
+	}
+	for _, e := range others {
+		errors = append(errors, fmt.Sprintf("%s %s", util.Cross, e))
+	}
+	return specs, errors
+}
+
+// unknownFields walks a decoded document along the type it is decoded to.
+// Lists which accept a single item are walked with the item as well.
+func unknownFields(node interface{}, t reflect.Type, path string) []unknownField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	found := make([]unknownField, 0)
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(yaml.MapSlice)
+		if !ok {
+			return found
+		}
+		fields := yamlFields(t)
+		for _, item := range m {
+			key := fmt.Sprint(item.Key)
+			field, known := fields[key]
+			if !known {
+				names := make([]string, 0, len(fields))
+				for name := range fields {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				u := unknownField{path: path, field: key}
+				if matches := closeContexts(key, names); len(matches) > 0 {
+					u.suggestion = matches[0]
+				}
+				found = append(found, u)
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			found = append(found, unknownFields(item.Value, field, childPath)...)
+		}
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			return unknownFields(node, t.Elem(), path)
+		}
+		for i, item := range items {
+			found = append(found, unknownFields(item, t.Elem(), path+"["+strconv.Itoa(i)+"]")...)
+		}
+	}
+	return found
+}
+
+// yamlFields returns the types of the fields of a struct by their key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			for name, field := range yamlFields(f.Type) {
+				fields[name] = field
+			}
+			continue
+		}
+		name := tag[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	for name, field := range fieldAliases[t] {
+		fields[name] = field
+	}
+	return fields
+}
+
+// ValidateRequiredFields checks the fields a topology cannot do without: the
+// names of the clusters, the project and the controller and worker charts,
+// and the workers of the profile. cert-manager is installed only when its
+// chart is set.
+func ValidateRequiredFields(specs *ConfigurationSpecs) []string {
+	errors := make([]string, 0)
+	cc := specs.Configuration.ClusterConfiguration
+	hc := specs.Configuration.HelmChartConfiguration
+	required := func(path string) {
+		errors = append(errors, fmt.Sprintf("%s %s: required", util.Cross, path))
+	}
+	if minimum := installProfiles[cc.Profile].minWorkers; len(cc.WorkerClusters) < minimum {
+		errors = append(errors, fmt.Sprintf("%s configuration.cluster_configuration.workers: at least %d required for the %s profile", util.Cross, minimum, cc.Profile))
+	}
+	if cc.ControllerCluster.Name == "" {
+		required("configuration.cluster_configuration.controller.name")
+	}
+	for i, cluster := range cc.WorkerClusters {
+		if cluster.Name == "" {
+			required(fmt.Sprintf("configuration.cluster_configuration.workers[%d].name", i))
+		}
+	}
+	if specs.Configuration.KubeSliceConfiguration.ProjectName == "" {
+		required("configuration.kubeslice_configuration.project_name")
+	}
+	charts := []struct {
+		key   string
+		chart HelmChart
+	}{
+		{"controller_chart", hc.ControllerChart},
+		{"worker_chart", hc.WorkerChart},
+	}
+	for _, c := range charts {
+		if c.chart.ChartName == "" {
+			required("configuration.helm_chart_configuration." + c.key + ".chart_name")
+		}
+	}
+	return errors
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestDecodeTopology(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		topology string
+		expected []string
+	}{
+		{
+			name: "Valid",
+			topology: `configuration:
+  cluster_configuration:
+    kube_config_path: kubeconfig.yaml
+    controller:
+      name: controller
+      kube_context: kind-controller
+    workers:
+    - name: worker-1
+      context_name: kind-worker-1
+  kubeslice_configuration:
+    project_name: demo
+  helm_chart_configuration:
+    image_pull_secret:
+      registry: https://index.docker.io/v1/
+`,
+			expected: []string{},
+		},
+		{
+			name: "Typo",
+			topology: `configuration:
+  cluster_configuration:
+    controller:
+      name: controller
+    workers:
+    - name: worker-1
+    - name: worker-2
+      kube_confg_path: kubeconfig.yaml
+`,
+			expected: []string{"line 8: configuration.cluster_configuration.workers[1]: unknown field 'kube_confg_path' (did you mean kube_config_path?)"},
+		},
+		{
+			name: "Mis-indented section",
+			topology: `configuration:
+  cluster_configuration:
+    profile: full-demo
+  kubeslice_configuration:
+  project_name: demo
+`,
+			expected: []string{"line 5: configuration: unknown field 'project_name'"},
+		},
+		{
+			name: "Top-level typo",
+			topology: `configuraton:
+  cluster_configuration:
+    profile: full-demo
+`,
+			expected: []string{"line 1: unknown field 'configuraton' (did you mean configuration?)"},
+		},
+		{
+			name: "Every problem at once",
+			topology: `configuration:
+  cluster_configuration:
+    controller:
+      name: controller
+      gateway_nodes: two
+  kubeslice_configuration:
+    projct_name: demo
+  helm_chart_configuration:
+    image_pull_secret:
+    - registry: https://index.docker.io/v1/
+      pasword: s3cr3t
+`,
+			expected: []string{
+				"line 7: configuration.kubeslice_configuration: unknown field 'projct_name' (did you mean project_name?)",
+				"line 11: configuration.helm_chart_configuration.image_pull_secret[0]: unknown field 'pasword' (did you mean password?)",
+				"line 5: cannot unmarshal !!str `two` into int",
+			},
+		},
+		{
+			name:     "Not YAML",
+			topology: "configuration:\n  cluster_configuration: [\n",
+			expected: []string{"Failed to parse configuration file: yaml: line 2: did not find expected node content"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, errors := DecodeTopology([]byte(tc.topology))
+			got := make([]string, 0, len(errors))
+			for _, e := range errors {
+				got = append(got, strings.TrimPrefix(e, util.Cross+" "))
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("DecodeTopology() errors =\n%q\nwant\n%q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	t.Parallel()
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			Profile:           "full-demo",
+			ControllerCluster: Cluster{Name: "controller"},
+			WorkerClusters:    []Cluster{{Name: ""}},
+		},
+		HelmChartConfiguration: HelmChartConfiguration{
+			CertManagerChart: HelmChart{ChartName: "cert-manager"},
+			ControllerChart:  HelmChart{ChartName: "kubeslice-controller"},
+		},
+	}}
+	expected := []string{
+		"configuration.cluster_configuration.workers: at least 2 required for the full-demo profile",
+		"configuration.cluster_configuration.workers[0].name: required",
+		"configuration.kubeslice_configuration.project_name: required",
+		"configuration.helm_chart_configuration.worker_chart.chart_name: required",
+	}
+	got := make([]string, 0)
+	for _, e := range ValidateRequiredFields(specs) {
+		got = append(got, strings.TrimPrefix(e, util.Cross+" "))
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ValidateRequiredFields() =\n%q\nwant\n%q", got, expected)
+	}
+}
@@ -0,0 +1,323 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const defaultImagePullRegistry = "https://index.docker.io/v1/"
+
+// topologyAnswers are the choices a topology is written from by config init.
+type topologyAnswers struct {
+	// kind demo profile, empty for existing clusters
+	Profile           string
+	KubeConfigPath    string
+	ControllerName    string
+	ControllerContext string
+	WorkerNames       []string
+	WorkerContexts    []string
+	ProjectName       string
+	ReadWriteUsers    []string
+	ReadOnlyUsers     []string
+	RepoAlias         string
+	RepoUrl           string
+	ControllerVersion string
+	WorkerVersion     string
+	// image pull secret, none when the registry is empty
+	ImagePullRegistry string
+	ImagePullUsername string
+}
+
+// defaultTopologyAnswers are those of the minimal-demo profile.
+func defaultTopologyAnswers() topologyAnswers {
+	return topologyAnswers{
+		Profile:        "minimal-demo",
+		ControllerName: "ks-ctrl",
+		WorkerNames:    []string{"ks-w-1"},
+		ProjectName:    "demo",
+		RepoAlias:      "kubeslice-demo",
+		RepoUrl:        "https://kubeslice.github.io/kubeslice/",
+	}
+}
+
+// DefaultTopology is the topology of config init --from-defaults.
+func DefaultTopology() string {
+	return renderTopology(defaultTopologyAnswers())
+}
+
+// AskTopology walks through the choices of a topology, validating every
+// answer as it is entered and asking again until it is valid.
+func AskTopology(in io.Reader, out io.Writer) string {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+	a := defaultTopologyAnswers()
+
+	clusters := p.ask("Create kind clusters for a demo or use existing clusters (kind, existing)", "kind", oneOf("kind", "existing"))
+	if clusters == "kind" {
+		a.Profile = p.ask("Demo profile (minimal-demo, full-demo)", a.Profile, oneOf("minimal-demo", "full-demo"))
+		minimum := strconv.Itoa(installProfiles[a.Profile].minWorkers)
+		workers, _ := strconv.Atoi(p.ask("Number of worker clusters", minimum, atLeast(installProfiles[a.Profile].minWorkers)))
+		a.WorkerNames = make([]string, 0, workers)
+		for i := 1; i <= workers; i++ {
+			a.WorkerNames = append(a.WorkerNames, fmt.Sprintf("ks-w-%d", i))
+		}
+	} else {
+		a.Profile = ""
+		workers, _ := strconv.Atoi(p.ask("Number of worker clusters", "1", atLeast(1)))
+		var contexts []string
+		a.KubeConfigPath = p.ask("Kubeconfig with the contexts of the clusters", defaultKubeconfig(), func(path string) error {
+			var err error
+			if contexts, err = kubeconfigContexts(path); err == nil && len(contexts) == 0 {
+				err = fmt.Errorf("%s has no contexts", path)
+			}
+			return err
+		})
+		fmt.Fprintf(out, "Contexts of %s: %s\n", a.KubeConfigPath, strings.Join(contexts, ", "))
+		a.ControllerContext = p.ask("Context of the controller cluster", contexts[0], contextOf(contexts, nil))
+		a.ControllerName = sanitizedClusterName(a.ControllerContext)
+		a.WorkerNames, a.WorkerContexts = make([]string, 0, workers), make([]string, 0, workers)
+		// the other contexts are suggested for the workers
+		others := make([]string, 0, len(contexts))
+		for _, context := range contexts {
+			if context != a.ControllerContext {
+				others = append(others, context)
+			}
+		}
+		for i := 0; i < workers; i++ {
+			suggestion := ""
+			if i < len(others) {
+				suggestion = others[i]
+			}
+			context := p.ask(fmt.Sprintf("Context of worker cluster %d", i+1), suggestion, contextOf(contexts, a.WorkerContexts))
+			a.WorkerContexts = append(a.WorkerContexts, context)
+			a.WorkerNames = append(a.WorkerNames, sanitizedClusterName(context))
+		}
+	}
+
+	a.ProjectName = p.ask("Project name", a.ProjectName, ValidateProjectName)
+	a.ReadWriteUsers = splitList(p.ask("Read-write users of the project, comma separated", "", validUsers))
+	a.ReadOnlyUsers = splitList(p.ask("Read-only users of the project, comma separated", "", validUsers))
+	a.RepoAlias = p.ask("Alias of the helm chart repository", a.RepoAlias, func(alias string) error {
+		return validateName("repo alias", alias, maxClusterNameLength)
+	})
+	a.RepoUrl = p.ask("URL of the helm chart repository", a.RepoUrl, validRepoUrl)
+	a.ControllerVersion = p.ask("Version of the controller chart, latest when empty", "", validVersion)
+	a.WorkerVersion = p.ask("Version of the worker chart, latest when empty", a.ControllerVersion, validVersion)
+	if p.ask("Pull the images with an image pull secret (yes, no)", "no", oneOf("yes", "no")) == "yes" {
+		a.ImagePullRegistry = p.ask("Registry of the image pull secret", defaultImagePullRegistry, validRepoUrl)
+		a.ImagePullUsername = p.ask("Username of the registry", "", func(username string) error {
+			if username == "" {
+				return fmt.Errorf("username must not be empty")
+			}
+			return nil
+		})
+	}
+	return renderTopology(a)
+}
+
+// prompter asks the questions of config init.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask asks a question until the answer is valid, an empty answer being the
+// default.
+func (p *prompter) ask(question, defaultValue string, validate func(string) error) string {
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		line, readErr := p.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = defaultValue
+		}
+		err := validate(answer)
+		if err == nil {
+			return answer
+		}
+		if readErr != nil {
+			util.Fatalf("\n%s No valid answer to %q: %v", util.Cross, question, err)
+		}
+		fmt.Fprintf(p.out, "%s %v\n", util.Cross, err)
+	}
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(answer string) error {
+		if !containsString(values, answer) {
+			return fmt.Errorf("%q is not one of %s", answer, strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+func atLeast(minimum int) func(string) error {
+	return func(answer string) error {
+		if n, err := strconv.Atoi(answer); err != nil || n < minimum {
+			return fmt.Errorf("%q is not a number of at least %d", answer, minimum)
+		}
+		return nil
+	}
+}
+
+// contextOf accepts the contexts of a kubeconfig which are not taken yet.
+func contextOf(contexts, taken []string) func(string) error {
+	return func(answer string) error {
+		if containsString(taken, answer) {
+			return fmt.Errorf("context %q is already a worker cluster", answer)
+		}
+		if !containsString(contexts, answer) {
+			if matches := closeContexts(answer, contexts); len(matches) > 0 {
+				return fmt.Errorf("context %q is not in the kubeconfig, did you mean %s?", answer, quoteAll(matches))
+			}
+			return fmt.Errorf("context %q is not in the kubeconfig", answer)
+		}
+		if sanitizedClusterName(answer) == "" {
+			return fmt.Errorf("no cluster name can be derived from context %q", answer)
+		}
+		return nil
+	}
+}
+
+func validUsers(answer string) error {
+	for _, user := range splitList(answer) {
+		if strings.ContainsAny(user, " \t") {
+			return fmt.Errorf("user %q must not contain spaces", user)
+		}
+	}
+	return nil
+}
+
+func validRepoUrl(answer string) error {
+	u, err := url.Parse(answer)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "oci") {
+		return fmt.Errorf("%q is not an http, https or oci URL", answer)
+	}
+	return nil
+}
+
+func validVersion(answer string) error {
+	if strings.ContainsAny(answer, " \t") {
+		return fmt.Errorf("version %q must not contain spaces", answer)
+	}
+	return nil
+}
+
+func splitList(answer string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(answer, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// defaultKubeconfig is the first file of $KUBECONFIG, ~/.kube/config
+// otherwise.
+func defaultKubeconfig() string {
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 && paths[0] != "" {
+		return paths[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// renderTopology writes the topology of the answers with comments on what
+// each field is for.
+func renderTopology(a topologyAnswers) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+	line("# Topology of kubeslice-cli, check it with: kubeslice-cli validate -c <file>")
+	line("# Refer: https://github.com/kubeslice/kubeslice-cli/blob/master/samples/template.yaml")
+	line("configuration:")
+	line("  cluster_configuration:")
+	if a.Profile != "" {
+		line("    # Creates kind clusters for a demo, they are not given contexts or kubeconfigs")
+		line("    profile: %s", a.Profile)
+	} else {
+		line("    # Kubeconfig of the clusters, each cluster may set its own kube_config_path")
+		line("    kube_config_path: %s", yamlString(a.KubeConfigPath))
+	}
+	line("    # Cluster the KubeSlice Controller is installed on")
+	line("    controller:")
+	line("      name: %s", a.ControllerName)
+	if a.ControllerContext != "" {
+		line("      context_name: %s", yamlString(a.ControllerContext))
+	}
+	line("    # Clusters the KubeSlice Worker is installed on and registered with the controller")
+	line("    workers:")
+	for i, name := range a.WorkerNames {
+		line("    - name: %s", name)
+		if i < len(a.WorkerContexts) {
+			line("      context_name: %s", yamlString(a.WorkerContexts[i]))
+		}
+	}
+	line("  kubeslice_configuration:")
+	line("    # Project of the controller the workers are registered with")
+	line("    project_name: %s", a.ProjectName)
+	if len(a.ReadWriteUsers) > 0 || len(a.ReadOnlyUsers) > 0 {
+		line("    # Users of the service accounts the project grants access to")
+		line("    users:")
+		for _, users := range []struct {
+			role  string
+			names []string
+		}{{"readwrite", a.ReadWriteUsers}, {"readonly", a.ReadOnlyUsers}} {
+			if len(users.names) > 0 {
+				line("      %s:", users.role)
+				for _, user := range users.names {
+					line("      - %s", yamlString(user))
+				}
+			}
+		}
+	}
+	line("  helm_chart_configuration:")
+	line("    # Helm repository the charts are installed from")
+	line("    repo_alias: %s", a.RepoAlias)
+	line("    repo_url: %s", yamlString(a.RepoUrl))
+	line("    cert_manager_chart:")
+	line("      chart_name: cert-manager")
+	line("    # Versions are the latest of the repository when not set")
+	line("    controller_chart:")
+	line("      chart_name: kubeslice-controller")
+	if a.ControllerVersion != "" {
+		line("      version: %s", yamlString(a.ControllerVersion))
+	}
+	line("    worker_chart:")
+	line("      chart_name: kubeslice-worker")
+	if a.WorkerVersion != "" {
+		line("      version: %s", yamlString(a.WorkerVersion))
+	}
+	if a.ImagePullRegistry != "" {
+		line("    # The password is read from the environment variable KUBESLICE_IMAGE_PULL_PASSWORD")
+		line("    image_pull_secret:")
+		line("      registry: %s", yamlString(a.ImagePullRegistry))
+		line("      username: %s", yamlString(a.ImagePullUsername))
+	}
+	return b.String()
+}
+
+// yamlString quotes a string when it would not be read back as the same
+// string, e.g. a version like 1.0 or a Windows path.
+func yamlString(s string) string {
+	if s != "" && !strings.ContainsAny(s, "#{}[],&*!|>'\"%@`\\ ") && !strings.HasSuffix(s, ":") && strings.Trim(s, "0123456789.-+eE") != "" && !containsString([]string{"true", "false", "yes", "no", "on", "off", "null", "~"}, strings.ToLower(s)) {
+		return s
+	}
+	return strconv.Quote(s)
+}
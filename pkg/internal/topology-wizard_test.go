@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAskTopology(t *testing.T) {
+	t.Parallel()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig.yaml")
+	contexts := "contexts:\n- name: kind-ctrl\n- name: arn:aws:eks:us-east-1:1234:cluster/prod\n"
+	if err := ioutil.WriteFile(kubeconfig, []byte(contexts), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		answers          []string
+		workers          []Cluster
+		expectedProfile  string
+		expectedProject  string
+		expectedReadOnly []string
+		expectedVersion  string
+	}{
+		{
+			name:            "Defaults",
+			answers:         []string{"", "", "", "", "", "", "", "", "", "", ""},
+			workers:         []Cluster{{Name: "ks-w-1"}},
+			expectedProfile: "minimal-demo",
+			expectedProject: "demo",
+		},
+		{
+			name: "Existing clusters with invalid answers asked again",
+			answers: []string{
+				"existing",
+				"0", "1",
+				"missing.yaml", kubeconfig,
+				"kind-ctl", "kind-ctrl",
+				"",
+				"Demo", "team-a",
+				"", "alice, bob",
+				"", "ftp://charts", "",
+				"1.0",
+				"",
+				"maybe", "no",
+			},
+			workers:          []Cluster{{Name: "arn-aws-eks-us-east-1-1234-cluster-prod", ContextName: "arn:aws:eks:us-east-1:1234:cluster/prod"}},
+			expectedProject:  "team-a",
+			expectedReadOnly: []string{"alice", "bob"},
+			expectedVersion:  "1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var out strings.Builder
+			topology := AskTopology(strings.NewReader(strings.Join(tc.answers, "\n")+"\n"), &out)
+			specs, errors := DecodeTopology([]byte(topology))
+			if len(errors) > 0 {
+				t.Fatalf("written topology does not decode: %v\n%s", errors, topology)
+			}
+			if errors := ValidateRequiredFields(specs); len(errors) > 0 {
+				t.Errorf("written topology misses fields: %v\n%s", errors, topology)
+			}
+			cc, ksc := specs.Configuration.ClusterConfiguration, specs.Configuration.KubeSliceConfiguration
+			if cc.Profile != tc.expectedProfile || ksc.ProjectName != tc.expectedProject {
+				t.Errorf("profile %q, project %q, want %q, %q", cc.Profile, ksc.ProjectName, tc.expectedProfile, tc.expectedProject)
+			}
+			if !reflect.DeepEqual(cc.WorkerClusters, tc.workers) {
+				t.Errorf("workers = %+v, want %+v", cc.WorkerClusters, tc.workers)
+			}
+			if !reflect.DeepEqual(ksc.Users.ReadOnly, tc.expectedReadOnly) {
+				t.Errorf("read-only users = %v, want %v", ksc.Users.ReadOnly, tc.expectedReadOnly)
+			}
+			if version := specs.Configuration.HelmChartConfiguration.WorkerChart.Version; version != tc.expectedVersion {
+				t.Errorf("worker chart version = %q, want %q", version, tc.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestDefaultTopology(t *testing.T) {
+	t.Parallel()
+	specs, errors := DecodeTopology([]byte(DefaultTopology()))
+	if len(errors) > 0 {
+		t.Fatalf("default topology does not decode: %v", errors)
+	}
+	if cc := specs.Configuration.ClusterConfiguration; cc.Profile != "minimal-demo" || cc.ControllerCluster.Name != "ks-ctrl" || len(cc.WorkerClusters) != 1 {
+		t.Errorf("default topology = %+v", cc)
+	}
+}
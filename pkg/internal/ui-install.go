@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// uiChartName is the chart ui.enabled installs from the repository of the
+// topology when it configures no ui_chart.
+const uiChartName = "kubeslice-ui"
+
+// How often and how long the token of the project user the UI is logged into
+// with is waited for, the controller creates it along with the project.
+var (
+	uiTokenPollInterval = 5 * time.Second
+	uiTokenTimeout      = 2 * time.Minute
+)
+
+// uiService are the fields of the UI proxy service it is reached through.
+type uiService struct {
+	Spec struct {
+		Type  string `json:"type"`
+		Ports []struct {
+			Name     string `json:"name"`
+			Port     int    `json:"port"`
+			NodePort int    `json:"nodePort"`
+		} `json:"ports"`
+	} `json:"spec"`
+	Status struct {
+		LoadBalancer struct {
+			Ingress []struct {
+				IP       string `json:"ip"`
+				Hostname string `json:"hostname"`
+			} `json:"ingress"`
+		} `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+// ApplyUIConfiguration installs the kubeslice-ui chart of the repository of
+// the topology when ui.enabled is set and no ui_chart is configured.
+func ApplyUIConfiguration(specs *ConfigurationSpecs) {
+	hc := &specs.Configuration.HelmChartConfiguration
+	if specs.Configuration.UI.Enabled && hc.UIChart.ChartName == "" {
+		hc.UIChart.ChartName = uiChartName
+	}
+}
+
+// ResolveUIChart leaves the KubeSlice Manager out of the plan when ui.enabled
+// is set and its chart is not published in the configured repository. The
+// repository failing to answer fails the install, and a pinned version which
+// does not exist still fails the chart version check.
+func ResolveUIChart(specs *ConfigurationSpecs, plan *InstallPlan) {
+	hc := specs.Configuration.HelmChartConfiguration
+	if !specs.Configuration.UI.Enabled || !plan.Includes(UI_install_Component) || hc.UIChart.LocalPath != "" || hc.UseLocal {
+		return
+	}
+	chart := hc.UIChart
+	chart.Version = ""
+	_, err := resolveChartVersion(hc, chart)
+	var notFound chartNotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		util.Printf("%s Skipping the KubeSlice Manager installation: %v", util.Warn, err)
+		plan.Steps = removeString(plan.Steps, UI_install_Component)
+	case err != nil:
+		util.Fatalf("%s Unable to look up the KubeSlice Manager chart: %v", util.Cross, err)
+	}
+}
+
+// uiAccessURL returns the URL the UI is reached at through its service, or
+// why it cannot be reached directly.
+func uiAccessURL(service uiService, nodeIP string) (string, error) {
+	if len(service.Spec.Ports) == 0 {
+		return "", fmt.Errorf("service %s has no ports", uiProxyService)
+	}
+	port := service.Spec.Ports[0]
+	for _, p := range service.Spec.Ports {
+		if p.Name == "http" { // the UI is served on the port named http
+			port = p
+		}
+	}
+	switch service.Spec.Type {
+	case "NodePort":
+		if nodeIP == "" {
+			return "", errors.New("no node address of the controller cluster is known")
+		}
+		return fmt.Sprintf("https://%s:%d", nodeIP, port.NodePort), nil
+	case "LoadBalancer":
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			host := ingress.IP
+			if host == "" {
+				host = ingress.Hostname
+			}
+			if host != "" {
+				return fmt.Sprintf("https://%s:%d", host, port.Port), nil
+			}
+		}
+		return "", errors.New("the load balancer of the service has no address yet")
+	}
+	return "", fmt.Errorf("service %s is of type %s", uiProxyService, service.Spec.Type)
+}
+
+// uiLoginToken waits for the token of the first read-write user of the
+// project and returns it with the user.
+func uiLoginToken(cluster Cluster, ksc KubeSliceConfiguration) (string, string, error) {
+	readWrite, _, err := projectUsers(ksc)
+	if err != nil {
+		return "", "", err
+	}
+	if len(readWrite) == 0 {
+		return "", "", fmt.Errorf("project %s has no read-write user", ksc.ProjectName)
+	}
+	user := readWrite[0]
+	namespace := projectNamespace(ksc.ProjectName)
+	secret := "kubeslice-rbac-rw-" + user
+	var token []byte
+	err = PollUntil(uiTokenPollInterval, uiTokenTimeout, func(elapsed time.Duration) (bool, error) {
+		data, err := kubeClient.SecretData(&cluster, namespace, secret)
+		if errors.Is(err, errNotFound) || (err == nil && data["token"] == "") {
+			util.Printf("%s Waiting for the token of user %s of project %s... %d seconds elapsed", util.Wait, user, ksc.ProjectName, int(elapsed.Seconds()))
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		token, err = base64.StdEncoding.DecodeString(data["token"])
+		return err == nil, err
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read the token of user %s from secret %s of namespace %s: %v", user, secret, namespace, err)
+	}
+	return user, string(token), nil
+}
+
+// PrintUIAccess prints the URL of the UI installed by ui.enabled and the
+// token to log in with. A UI its service does not expose is reached with a
+// port forward of kubeslice-cli ui.
+func PrintUIAccess(specs *ConfigurationSpecs) {
+	cluster := specs.Configuration.ClusterConfiguration.ControllerCluster
+	namespace := ControllerNamespace(cluster)
+	object, err := kubeClient.Get(&cluster, "services", namespace, uiProxyService)
+	var service uiService
+	if err == nil {
+		err = decodeObject(object, &service)
+	}
+	if err != nil {
+		util.Printf("%s Unable to get service %s of the KubeSlice Manager: %v", util.Warn, uiProxyService, err)
+	} else {
+		nodeIP := ""
+		if service.Spec.Type == "NodePort" {
+			if ip, err := getNodeIP(&cluster); err == nil {
+				nodeIP = strings.Trim(ip, "'")
+			}
+		}
+		url, err := uiAccessURL(service, nodeIP)
+		if err != nil {
+			util.Printf("%s The KubeSlice Manager is not exposed (%v), forward a local port to it with:\n  kubeslice-cli ui --installation %s", util.Warn, err, InstallationName(specs))
+		} else {
+			util.Printf("%s Visit %s from your browser to access the KubeSlice Manager.", util.Globe, url)
+		}
+	}
+	user, token, err := uiLoginToken(cluster, projectConfiguration(specs.Configuration.KubeSliceConfiguration, specs.Configuration.KubeSliceConfiguration.ProjectName))
+	if err != nil {
+		util.Printf("%s %v", util.Warn, err)
+		printUILoginToken(cluster, specs.Configuration.KubeSliceConfiguration.ProjectName)
+		return
+	}
+	util.Printf("%s Log in as %s with the token:\n%s", util.Lock, user, token)
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestUIAccessURL(t *testing.T) {
+	t.Parallel()
+	service := func(spec string) uiService {
+		var s uiService
+		if err := json.Unmarshal([]byte(spec), &s); err != nil {
+			t.Fatalf("invalid service: %v", err)
+		}
+		return s
+	}
+	tests := []struct {
+		name     string
+		service  uiService
+		nodeIP   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "NodePort on kind",
+			service:  service(`{"spec":{"type":"NodePort","ports":[{"name":"https","port":443,"nodePort":30443},{"name":"http","port":8443,"nodePort":31000}]}}`),
+			nodeIP:   "172.18.0.2",
+			expected: "https://172.18.0.2:31000",
+		},
+		{
+			name:     "LoadBalancer with a hostname",
+			service:  service(`{"spec":{"type":"LoadBalancer","ports":[{"name":"http","port":443}]},"status":{"loadBalancer":{"ingress":[{"hostname":"ui.elb.example.com"}]}}}`),
+			expected: "https://ui.elb.example.com:443",
+		},
+		{
+			name:    "LoadBalancer pending",
+			service: service(`{"spec":{"type":"LoadBalancer","ports":[{"name":"http","port":443}]}}`),
+			wantErr: true,
+		},
+		{
+			name:    "ClusterIP is port forwarded",
+			service: service(`{"spec":{"type":"ClusterIP","ports":[{"name":"http","port":443}]}}`),
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			url, err := uiAccessURL(tc.service, tc.nodeIP)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("uiAccessURL() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if url != tc.expected {
+				t.Errorf("uiAccessURL() = %q, want %q", url, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUILoginToken(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+	kubeClient = &fakeKubeClient{secrets: map[string]map[string]string{
+		"kubeslice-blue/kubeslice-rbac-rw-alice": {"token": "dG9rZW4="},
+	}}
+	user, token, err := uiLoginToken(Cluster{Name: "ctrl"}, KubeSliceConfiguration{ProjectName: "blue", Users: ProjectUsers{ReadWrite: []string{"alice", "bob"}}})
+	if err != nil {
+		t.Fatalf("uiLoginToken() error = %v", err)
+	}
+	if user != "alice" || token != "token" {
+		t.Errorf("uiLoginToken() = %s, %s, want alice, token", user, token)
+	}
+}
+
+func TestResolveUIChart(t *testing.T) {
+	tests := []struct {
+		name     string
+		search   string
+		expected []string
+	}{
+		{
+			name:     "Published chart is installed",
+			search:   `[{"name":"kubeslice/kubeslice-ui","version":"1.2.0","app_version":"1.2.0"}]`,
+			expected: []string{"controller", "ui"},
+		},
+		{
+			name:     "Unpublished chart is skipped",
+			search:   `[]`,
+			expected: []string{"controller"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				fmt.Fprint(stdout, tc.search)
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			specs := &ConfigurationSpecs{Configuration: Configuration{
+				UI: UIConfiguration{Enabled: true},
+				HelmChartConfiguration: HelmChartConfiguration{
+					RepoAlias: "kubeslice",
+					RepoUrl:   "https://kubeslice.github.io/kubeslice/",
+					UIChart:   HelmChart{ChartName: "kubeslice-ui"},
+				},
+			}}
+			plan := &InstallPlan{Steps: []string{"controller", "ui"}}
+			ResolveUIChart(specs, plan)
+			if !reflect.DeepEqual(plan.Steps, tc.expected) {
+				t.Errorf("ResolveUIChart() steps = %v, want %v", plan.Steps, tc.expected)
+			}
+		})
+	}
+}
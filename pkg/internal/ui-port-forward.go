@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// uiProxyService is the service of the KubeSlice Manager UI on the
+// controller cluster.
+const uiProxyService = "kubeslice-ui-proxy"
+
+// A dropped port forward is restarted after portForwardRestartDelay, doubled
+// on every drop up to maxPortForwardRestartDelay. A forward which ran for
+// portForwardStableAfter restarts without the backoff.
+var (
+	portForwardRestartDelay    = 2 * time.Second
+	maxPortForwardRestartDelay = time.Minute
+	portForwardStableAfter     = time.Minute
+)
+
+// PortForwardUI forwards a local port to the KubeSlice Manager UI on the
+// controller cluster and prints its URL and where the tokens to log in with
+// are, until interrupted with Ctrl+C. A forward which drops, e.g. when the
+// pod behind the service restarts, is started again.
+func PortForwardUI(specs *ConfigurationSpecs, port int) {
+	cluster := specs.Configuration.ClusterConfiguration.ControllerCluster
+	namespace := ControllerNamespace(cluster)
+	servicePort, err := uiServicePort(&cluster, namespace)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+
+	util.Printf("%s Visit https://localhost:%d from your browser to access the KubeSlice Manager.", util.Globe, port)
+	printUILoginToken(cluster, specs.Configuration.KubeSliceConfiguration.ProjectName)
+	util.Printf("Press Ctrl+C to stop forwarding.")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	args := append(kubectlClusterArgs(&cluster), "port-forward", "-n", namespace, "service/"+uiProxyService, fmt.Sprintf("%d:%d", port, servicePort))
+	portForwardUntilInterrupted(args, interrupt)
+}
+
+// uiServicePort returns the port of the http port of the UI service, failing
+// when the UI is not installed.
+func uiServicePort(cluster *Cluster, namespace string) (int, error) {
+	service, err := kubeClient.Get(cluster, "services", namespace, uiProxyService)
+	if errors.Is(err, errNotFound) {
+		return 0, fmt.Errorf("the KubeSlice Manager UI is not installed on %s: service %s not found in namespace %s. It is installed by the %s profile, with configuration.helm_chart_configuration.ui_chart or with configuration.ui.enabled", cluster.Name, uiProxyService, namespace, ProfileEntDemo)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get service %s of the KubeSlice Manager UI: %v", uiProxyService, err)
+	}
+	var object struct {
+		Spec struct {
+			Ports []struct {
+				Name string `json:"name"`
+				Port int    `json:"port"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	if err := decodeObject(service, &object); err != nil || len(object.Spec.Ports) == 0 {
+		return 0, fmt.Errorf("service %s of the KubeSlice Manager UI has no ports", uiProxyService)
+	}
+	for _, port := range object.Spec.Ports {
+		if port.Name == "http" { // the UI is served on the port named http
+			return port.Port, nil
+		}
+	}
+	return object.Spec.Ports[0].Port, nil
+}
+
+// printUILoginToken prints the command to read the token of the admin of the
+// project, the UI is logged into with.
+func printUILoginToken(cluster Cluster, projectName string) {
+	namespace := projectNamespace(projectName)
+	accounts, err := kubeClient.List(&cluster, serviceAccountResource, namespace)
+	if err == nil {
+		for _, account := range accounts {
+			if strings.Contains(account, "rbac-rw-admin") {
+				util.Printf("%s Log in with the token of the admin of project %s, read it with:\n  kubectl %s | base64 -d", util.Lock, projectName,
+					strings.Join(util.QuoteArgs(append(kubectlClusterArgs(&cluster), "get", "secret", account, "-n", namespace, "-o", "jsonpath={.data.token}")...), " "))
+				return
+			}
+		}
+	}
+	util.Printf("%s Log in with the token of a user of the project, stored in the secrets kubeslice-rbac-rw-<user> and kubeslice-rbac-ro-<user> of namespace %s", util.Lock, namespace)
+}
+
+// portForwardUntilInterrupted runs kubectl port-forward, starting it again
+// with a backoff when it exits before the interrupt.
+func portForwardUntilInterrupted(args []string, interrupt <-chan os.Signal) {
+	delay := portForwardRestartDelay
+	// the forward has no timeout, it runs until interrupted
+	PollUntil(0, time.Duration(math.MaxInt64), func(time.Duration) (bool, error) {
+		started := time.Now()
+		err := util.RunCommandCustomIO("kubectl", os.Stdout, os.Stderr, true, args...)
+		select {
+		case <-interrupt:
+			return true, nil
+		default:
+		}
+		if time.Since(started) >= portForwardStableAfter {
+			delay = portForwardRestartDelay
+		}
+		if err != nil {
+			util.Printf("%s Port forward to the KubeSlice Manager dropped: %v. Restarting it in %s...", util.Warn, err, delay)
+		} else {
+			util.Printf("%s Port forward to the KubeSlice Manager exited. Restarting it in %s...", util.Warn, delay)
+		}
+		select {
+		case <-interrupt:
+			return true, nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxPortForwardRestartDelay {
+			delay = maxPortForwardRestartDelay
+		}
+		return false, nil
+	})
+}
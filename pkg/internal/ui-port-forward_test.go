@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestPortForwardUntilInterrupted(t *testing.T) {
+	defer func(delay time.Duration, out io.Writer) { portForwardRestartDelay, util.Output = delay, out }(portForwardRestartDelay, util.Output)
+	portForwardRestartDelay = time.Millisecond
+	var out bytes.Buffer
+	util.Output = &out
+
+	interrupt := make(chan os.Signal, 1)
+	mock := &util.MockExecutor{}
+	mock.Handler = func(command []string, stdout, stderr io.Writer) error {
+		// the forward drops twice and is interrupted on the third run
+		if len(mock.Commands) == 3 {
+			interrupt <- os.Interrupt
+		}
+		return errors.New("lost connection to pod")
+	}
+	defer util.UseExecutor(mock)()
+
+	args := []string{"--context=kind-ks-ctrl", "port-forward", "-n", "kubeslice-controller", "service/kubeslice-ui-proxy", "8443:443"}
+	portForwardUntilInterrupted(args, interrupt)
+	if len(mock.Commands) != 3 {
+		t.Fatalf("port-forward ran %d times, want 3", len(mock.Commands))
+	}
+	if got := strings.Join(mock.Commands[2], " "); got != "kubectl "+strings.Join(args, " ") {
+		t.Errorf("command = %s", got)
+	}
+	// the restarts back off
+	if !strings.Contains(out.String(), "Restarting it in 1ms") || !strings.Contains(out.String(), "Restarting it in 2ms") {
+		t.Errorf("output =\n%s\nwant the restart delay doubled", out.String())
+	}
+}
+
+func TestUIServicePort(t *testing.T) {
+	defer func(client KubeClient) { kubeClient = client }(kubeClient)
+
+	kubeClient = &sliceStatusClient{objects: map[string]string{
+		"kind-ks-ctrl/services/kubeslice-ui-proxy": `{"spec": {"ports": [{"name": "metrics", "port": 9090}, {"name": "http", "port": 443}]}}`,
+	}}
+	cluster := &Cluster{Name: "ks-ctrl", ContextName: "kind-ks-ctrl"}
+	if port, err := uiServicePort(cluster, KUBESLICE_CONTROLLER_NAMESPACE); err != nil || port != 443 {
+		t.Errorf("uiServicePort() = %d, %v, want 443", port, err)
+	}
+	cluster.ContextName = "kind-ks-oss"
+	if _, err := uiServicePort(cluster, KUBESLICE_CONTROLLER_NAMESPACE); err == nil || !strings.Contains(err.Error(), "UI is not installed on ks-ctrl") {
+		t.Errorf("uiServicePort() without the UI = %v", err)
+	}
+}
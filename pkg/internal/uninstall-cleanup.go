@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// cleanupResult is one cleanup action listed in the uninstall summary.
+type cleanupResult struct {
+	action string
+	err    error
+}
+
+// CleanupKubeSlice removes what the helm releases leave behind on the clusters
+// whose KubeSlice components were uninstalled. The custom resources are
+// deleted with --retain-custom-resources=false, dependents first, then the
+// resources stuck in Terminating on kubeslice finalizers are released, then
+// the CRDs are deleted with --retain-crds=false and finally the namespaces
+// with --delete-namespaces, so that nothing is deleted while something still
+// depends on it. Retained custom resources are counted for install to adopt.
+func CleanupKubeSlice(specs *ConfigurationSpecs, controller bool, workersToUninstall map[string]string) {
+	cc := specs.Configuration.ClusterConfiguration
+	results := make([]cleanupResult, 0)
+	if controller {
+		namespaces := make([]string, 0)
+		for _, project := range ProjectNames(specs.Configuration.KubeSliceConfiguration) {
+			namespaces = append(namespaces, projectNamespace(project))
+		}
+		results = append(results, cleanupCluster(cc.ControllerCluster, append(namespaces, ControllerNamespace(cc.ControllerCluster)))...)
+	}
+	_, allWorkers := workersToUninstall["*"]
+	for _, cluster := range cc.WorkerClusters {
+		if _, found := workersToUninstall[cluster.Name]; found || allWorkers {
+			results = append(results, cleanupCluster(cluster, []string{WorkerNamespace(cluster)})...)
+		}
+	}
+	if len(results) == 0 {
+		return
+	}
+	util.Printf("\nCleanup summary:")
+	retained := false
+	for _, r := range results {
+		retained = retained || strings.HasPrefix(r.action, "Retained")
+		if r.err != nil {
+			util.Printf("%s %s: %v", util.Cross, r.action, r.err)
+		} else {
+			util.Printf("%s %s", util.Tick, r.action)
+		}
+	}
+	if retained {
+		util.Printf("\nReinstall with install --on-existing=adopt to resume with the retained custom resources")
+	}
+}
+
+func cleanupCluster(cluster Cluster, namespaces []string) []cleanupResult {
+	util.Printf("\nCleaning up KubeSlice resources on %s...", cluster.Name)
+	results := make([]cleanupResult, 0)
+	out, err := runKubectl(cluster, "get", "crd", "-o", "name")
+	if err != nil {
+		return append(results, cleanupResult{"List KubeSlice CRDs on " + cluster.Name, err})
+	}
+	crds := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		crd := strings.TrimPrefix(strings.TrimSpace(line), "customresourcedefinition.apiextensions.k8s.io/")
+		if strings.HasSuffix(crd, ".kubeslice.io") {
+			crds = append(crds, crd)
+		}
+	}
+	if len(crds) > 0 && !Options.RetainResources {
+		ordered := append([]string{}, crds...)
+		sortByDependency(ordered)
+		for _, crd := range ordered {
+			_, err := runKubectl(cluster, "delete", crd, "--all", "--all-namespaces", "--ignore-not-found", "--wait=false")
+			results = append(results, cleanupResult{fmt.Sprintf("Delete the %s on %s", crd, cluster.Name), err})
+		}
+	}
+	if len(crds) > 0 {
+		resources, err := listKubeSliceResources(cluster, crds)
+		if err != nil {
+			return append(results, cleanupResult{"List KubeSlice resources on " + cluster.Name, err})
+		}
+		results = append(results, clearStuckFinalizers(cluster, resources)...)
+		if Options.RetainResources {
+			results = append(results, retainedResources(cluster, resources)...)
+		}
+	}
+	if !Options.RetainCRDs {
+		for _, crd := range crds {
+			results = append(results, deleteCRD(cluster, crd))
+		}
+	}
+	if Options.DeleteNamespaces {
+		for _, kind := range []string{"mutatingwebhookconfiguration", "validatingwebhookconfiguration"} {
+			results = append(results, deleteWebhookConfigurations(cluster, kind)...)
+		}
+		for _, namespace := range namespaces {
+			_, err := runKubectl(cluster, "delete", "namespace", namespace, "--ignore-not-found", "--wait=false")
+			results = append(results, cleanupResult{fmt.Sprintf("Delete namespace %s on %s", namespace, cluster.Name), err})
+		}
+	}
+	return results
+}
+
+type stuckResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name              string   `json:"name"`
+		Namespace         string   `json:"namespace"`
+		DeletionTimestamp string   `json:"deletionTimestamp"`
+		Finalizers        []string `json:"finalizers"`
+	} `json:"metadata"`
+}
+
+// kubesliceFinalizers splits the finalizers of a resource in the ones owned
+// by kubeslice and the rest.
+func (r stuckResource) kubesliceFinalizers() (owned, rest []string) {
+	rest = make([]string, 0)
+	for _, f := range r.Metadata.Finalizers {
+		if strings.Contains(f, "kubeslice.io") {
+			owned = append(owned, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	return owned, rest
+}
+
+// dependencyOrder ranks the kubeslice resources referring to others before
+// the resources they refer to, by plural name. Unranked ones go in between.
+var dependencyOrder = struct{ first, last []string }{
+	first: []string{"serviceexportconfigs", "serviceexports", "serviceimports", "slicegateways", "workerserviceimports", "workerslicegateways", "workersliceconfigs", "sliceconfigs"},
+	last:  []string{"slices", "clusters", "projects"},
+}
+
+// sortByDependency sorts CRDs for their resources to be deleted dependents
+// first.
+func sortByDependency(crds []string) {
+	rank := func(crd string) int {
+		plural := strings.SplitN(crd, ".", 2)[0]
+		for i, p := range dependencyOrder.first {
+			if p == plural {
+				return i
+			}
+		}
+		for i, p := range dependencyOrder.last {
+			if p == plural {
+				return len(dependencyOrder.first) + 1 + i
+			}
+		}
+		return len(dependencyOrder.first)
+	}
+	sort.SliceStable(crds, func(i, j int) bool { return rank(crds[i]) < rank(crds[j]) })
+}
+
+func listKubeSliceResources(cluster Cluster, crds []string) ([]stuckResource, error) {
+	out, err := runKubectl(cluster, "get", strings.Join(crds, ","), "--all-namespaces", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	list := struct {
+		Items []stuckResource `json:"items"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// retainedResources counts the custom resources which are kept, by kind.
+func retainedResources(cluster Cluster, resources []stuckResource) []cleanupResult {
+	counts := make(map[string]int)
+	kinds := make([]string, 0)
+	for _, r := range resources {
+		if r.Metadata.DeletionTimestamp != "" {
+			continue
+		}
+		if counts[r.Kind] == 0 {
+			kinds = append(kinds, r.Kind)
+		}
+		counts[r.Kind]++
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	sort.Strings(kinds)
+	retained := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		retained = append(retained, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+	return []cleanupResult{{fmt.Sprintf("Retained custom resources on %s: %s", cluster.Name, strings.Join(retained, ", ")), nil}}
+}
+
+// clearStuckFinalizers finds the kubeslice resources stuck in Terminating,
+// whose controller is gone with the uninstalled release, and removes their
+// kubeslice finalizers after confirmation.
+func clearStuckFinalizers(cluster Cluster, resources []stuckResource) []cleanupResult {
+	stuck := make([]stuckResource, 0)
+	for _, r := range resources {
+		if owned, _ := r.kubesliceFinalizers(); r.Metadata.DeletionTimestamp != "" && len(owned) > 0 {
+			stuck = append(stuck, r)
+			util.Printf("%s %s %s is stuck in Terminating on finalizers %s", util.Warn, r.Kind, resourceName(r), strings.Join(owned, ", "))
+		}
+	}
+	if len(stuck) == 0 {
+		return nil
+	}
+	if !Options.AssumeYes && !util.Confirm("Remove the kubeslice finalizers of %d resources on %s?", len(stuck), cluster.Name) {
+		return []cleanupResult{{fmt.Sprintf("Clear finalizers of %d resources on %s", len(stuck), cluster.Name), fmt.Errorf("declined")}}
+	}
+	results := make([]cleanupResult, 0, len(stuck))
+	for _, r := range stuck {
+		_, rest := r.kubesliceFinalizers()
+		patch, _ := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"finalizers": rest}})
+		args := []string{"patch", r.resourceType(), r.Metadata.Name, "--type", "merge", "-p", string(patch)}
+		if r.Metadata.Namespace != "" {
+			args = append(args, "--namespace", r.Metadata.Namespace)
+		}
+		_, err := runKubectl(cluster, args...)
+		results = append(results, cleanupResult{fmt.Sprintf("Clear finalizers of %s %s on %s", r.Kind, resourceName(r), cluster.Name), err})
+	}
+	return results
+}
+
+// resourceType returns the fully qualified type of the resource, as kinds
+// are not unique across the kubeslice API groups.
+func (r stuckResource) resourceType() string {
+	group := strings.SplitN(r.APIVersion, "/", 2)[0]
+	return strings.ToLower(r.Kind) + "." + group
+}
+
+func resourceName(r stuckResource) string {
+	if r.Metadata.Namespace == "" {
+		return r.Metadata.Name
+	}
+	return r.Metadata.Namespace + "/" + r.Metadata.Name
+}
+
+// deleteCRD deletes a CRD unless custom resources of it are retained, which
+// would be deleted along with it.
+func deleteCRD(cluster Cluster, crd string) cleanupResult {
+	action := fmt.Sprintf("Delete CRD %s on %s", crd, cluster.Name)
+	if !Options.RetainResources {
+		_, err := runKubectl(cluster, "delete", "crd", crd, "--ignore-not-found")
+		return cleanupResult{action, err}
+	}
+	out, err := runKubectl(cluster, "get", crd, "--all-namespaces", "-o", "name")
+	if err != nil {
+		return cleanupResult{action, err}
+	}
+	if remaining := strings.Fields(out); len(remaining) > 0 {
+		return cleanupResult{action, fmt.Errorf("%d resources remain, delete them first", len(remaining))}
+	}
+	_, err = runKubectl(cluster, "delete", "crd", crd, "--ignore-not-found")
+	return cleanupResult{action, err}
+}
+
+// deleteWebhookConfigurations deletes the kubeslice webhook configurations,
+// which would otherwise point to the deleted webhook services.
+func deleteWebhookConfigurations(cluster Cluster, kind string) []cleanupResult {
+	out, err := runKubectl(cluster, "get", kind, "-o", "name")
+	if err != nil {
+		return []cleanupResult{{fmt.Sprintf("List %ss on %s", kind, cluster.Name), err}}
+	}
+	results := make([]cleanupResult, 0)
+	for _, name := range strings.Fields(out) {
+		if !strings.Contains(name, "kubeslice") {
+			continue
+		}
+		_, err := runKubectl(cluster, "delete", name, "--ignore-not-found")
+		results = append(results, cleanupResult{fmt.Sprintf("Delete %s on %s", name, cluster.Name), err})
+	}
+	return results
+}
+
+// runKubectl runs kubectl against the cluster and returns its output.
+func runKubectl(cluster Cluster, args ...string) (string, error) {
+	var outB, errB bytes.Buffer
+	args = append([]string{"--context=" + cluster.ContextName, "--kubeconfig=" + cluster.KubeConfigPath}, args...)
+	if err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, args...); err != nil {
+		if msg := strings.TrimSpace(errB.String()); msg != "" {
+			return "", fmt.Errorf("%s", util.Redact(msg))
+		}
+		return "", err
+	}
+	return outB.String(), nil
+}
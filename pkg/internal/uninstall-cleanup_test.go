@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+const stuckSlices = `{"items":[
+	{"apiVersion":"networking.kubeslice.io/v1beta1","kind":"Slice","metadata":{"name":"red","namespace":"kubeslice-system","deletionTimestamp":"2024-01-01T00:00:00Z","finalizers":["networking.kubeslice.io/slice-finalizer","example.com/other"]}},
+	{"apiVersion":"networking.kubeslice.io/v1beta1","kind":"SliceGateway","metadata":{"name":"red-gw","namespace":"kubeslice-system","finalizers":["networking.kubeslice.io/slicegw-finalizer"]}}
+]}`
+
+func TestCleanupClusterOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		deleteResources  bool
+		deleteCRDs       bool
+		deleteNamespaces bool
+		remaining        string
+		expected         [][]string
+	}{
+		{
+			name: "Only stuck finalizers are cleared by default",
+			expected: [][]string{
+				{"get", "crd", "-o", "name"},
+				{"get", "slices.networking.kubeslice.io,slicegateways.networking.kubeslice.io", "--all-namespaces", "-o", "json"},
+				{"patch", "slice.networking.kubeslice.io", "red", "--type", "merge", "-p", `{"metadata":{"finalizers":["example.com/other"]}}`, "--namespace", "kubeslice-system"},
+			},
+		},
+		{
+			name:             "Resources before CRDs before namespaces",
+			deleteResources:  true,
+			deleteCRDs:       true,
+			deleteNamespaces: true,
+			expected: [][]string{
+				{"get", "crd", "-o", "name"},
+				{"delete", "slicegateways.networking.kubeslice.io", "--all", "--all-namespaces", "--ignore-not-found", "--wait=false"},
+				{"delete", "slices.networking.kubeslice.io", "--all", "--all-namespaces", "--ignore-not-found", "--wait=false"},
+				{"get", "slices.networking.kubeslice.io,slicegateways.networking.kubeslice.io", "--all-namespaces", "-o", "json"},
+				{"patch", "slice.networking.kubeslice.io", "red", "--type", "merge", "-p", `{"metadata":{"finalizers":["example.com/other"]}}`, "--namespace", "kubeslice-system"},
+				{"delete", "crd", "slices.networking.kubeslice.io", "--ignore-not-found"},
+				{"delete", "crd", "slicegateways.networking.kubeslice.io", "--ignore-not-found"},
+				{"get", "mutatingwebhookconfiguration", "-o", "name"},
+				{"delete", "mutatingwebhookconfiguration.admissionregistration.k8s.io/kubeslice-mutating-webhook", "--ignore-not-found"},
+				{"get", "validatingwebhookconfiguration", "-o", "name"},
+				{"delete", "namespace", "kubeslice-system", "--ignore-not-found", "--wait=false"},
+			},
+		},
+		{
+			name:       "CRDs with remaining resources are kept",
+			deleteCRDs: true,
+			remaining:  "slicegateway.networking.kubeslice.io/red-gw\n",
+			expected: [][]string{
+				{"get", "crd", "-o", "name"},
+				{"get", "slices.networking.kubeslice.io,slicegateways.networking.kubeslice.io", "--all-namespaces", "-o", "json"},
+				{"patch", "slice.networking.kubeslice.io", "red", "--type", "merge", "-p", `{"metadata":{"finalizers":["example.com/other"]}}`, "--namespace", "kubeslice-system"},
+				{"get", "slices.networking.kubeslice.io", "--all-namespaces", "-o", "name"},
+				{"delete", "crd", "slices.networking.kubeslice.io", "--ignore-not-found"},
+				{"get", "slicegateways.networking.kubeslice.io", "--all-namespaces", "-o", "name"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(options RunOptions) { *Options = options }(*Options)
+			Options.AssumeYes = true
+			Options.RetainResources = !tc.deleteResources
+			Options.RetainCRDs = !tc.deleteCRDs
+			Options.DeleteNamespaces = tc.deleteNamespaces
+
+			mock := &util.MockExecutor{
+				Handler: func(command []string, stdout, stderr io.Writer) error {
+					switch strings.Join(command[3:], " ") {
+					case "get crd -o name":
+						fmt.Fprint(stdout, "customresourcedefinition.apiextensions.k8s.io/slices.networking.kubeslice.io\ncustomresourcedefinition.apiextensions.k8s.io/slicegateways.networking.kubeslice.io\ncustomresourcedefinition.apiextensions.k8s.io/certificates.cert-manager.io\n")
+					case "get slices.networking.kubeslice.io,slicegateways.networking.kubeslice.io --all-namespaces -o json":
+						fmt.Fprint(stdout, stuckSlices)
+					case "get slicegateways.networking.kubeslice.io --all-namespaces -o name":
+						fmt.Fprint(stdout, tc.remaining)
+					case "get mutatingwebhookconfiguration -o name":
+						fmt.Fprint(stdout, "mutatingwebhookconfiguration.admissionregistration.k8s.io/kubeslice-mutating-webhook\nmutatingwebhookconfiguration.admissionregistration.k8s.io/cert-manager-webhook\n")
+					case "delete namespace kubeslice-system --ignore-not-found --wait=false":
+						fmt.Fprint(stderr, "forbidden")
+						return errors.New("exit status 1")
+					}
+					return nil
+				},
+			}
+			defer util.UseExecutor(mock)()
+
+			results := cleanupCluster(Cluster{Name: "ks-w-1"}, []string{"kubeslice-system"})
+
+			got := make([][]string, 0, len(mock.Commands))
+			for _, command := range mock.Commands {
+				got = append(got, command[3:])
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("command sequence mismatch\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+			retained := ""
+			for _, r := range results {
+				if strings.HasPrefix(r.action, "Retained") {
+					retained = r.action
+				}
+				failed := strings.HasPrefix(r.action, "Delete namespace") || (tc.remaining != "" && strings.HasPrefix(r.action, "Delete CRD slicegateways"))
+				if (r.err != nil) != failed {
+					t.Errorf("result %q error = %v, want failure %v", r.action, r.err, failed)
+				}
+			}
+			// the stuck slice is released, the gateway retained
+			if expected := "Retained custom resources on ks-w-1: 1 SliceGateway"; tc.deleteResources == (retained == expected) {
+				t.Errorf("retained = %q, want %q unless deleted", retained, expected)
+			}
+		})
+	}
+}
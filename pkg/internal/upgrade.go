@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+	YAML "sigs.k8s.io/yaml"
+)
+
+// UpgradeComponents are the components upgrade moves to the chart versions of
+// the topology, in upgrade order: the controller first, then the workers.
+var UpgradeComponents = []string{Controller_Component, UI_install_Component, Worker_Component}
+
+// upgradeStep is a release of the upgrade plan.
+type upgradeStep struct {
+	component string
+	cluster   Cluster
+	release   string
+	namespace string
+	chart     HelmChart
+	// from is the deployed chart version, empty when the release is not
+	// installed
+	from string
+	// to is the chart version of the topology
+	to string
+}
+
+// upToDate tells whether the release runs the chart version of the topology
+// already. Local charts and version ranges are always upgraded.
+func (s upgradeStep) upToDate() bool {
+	pinned := pinnedChartVersion(s.component)
+	return pinned != "" && compareVersions(s.from, pinned) == 0
+}
+
+// UpgradeKubeSlice moves the installed KubeSlice releases of the topology to
+// the chart versions it pins, the controller first and then the workers, each
+// waited for to be ready before the next one is upgraded. Releases at the
+// target version already are reported as up-to-date, releases which are not
+// installed are left to install. The license secret is applied again before
+// the controller is upgraded.
+func UpgradeKubeSlice(specs *ConfigurationSpecs, components []string) {
+	steps, err := buildUpgradePlan(specs, components)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	PrintExecutionPlan(upgradeExecution(specs, steps), Options.PlanOutput)
+	if StopAfterPlan() {
+		return
+	}
+	pending := make([]upgradeStep, 0, len(steps))
+	for _, step := range steps {
+		if step.from != "" && !step.upToDate() {
+			pending = append(pending, step)
+		}
+	}
+	if len(pending) == 0 {
+		util.Printf("%s Every installed component is up-to-date", util.Tick)
+		return
+	}
+	upgradesController := false
+	for _, step := range pending {
+		upgradesController = upgradesController || step.component == Controller_Component
+	}
+	VerifyLicense(specs, upgradesController)
+	config := specs.Configuration
+	for _, step := range pending {
+		util.Printf("\nUpgrading %s on %s from version %s to %s...", step.release, step.cluster.Name, step.from, step.to)
+		upgradeCRDs(step.cluster, config.HelmChartConfiguration, step.chart)
+		switch step.component {
+		case Controller_Component:
+			valuesFile := kubesliceDirectory + "/" + valuesFileName(step.cluster, config.HelmChartConfiguration.ControllerChart)
+			generateControllerValuesFile(step.cluster, config, valuesFile)
+			reuseReleaseValues(step.cluster, step.release, step.namespace, valuesFile)
+			showValuesDiff(step.cluster, step.release, step.namespace, valuesFile)
+			ApplyLicenseSecret(specs)
+			installKubeSliceController(step.cluster, config.HelmChartConfiguration, valuesFile)
+			recordValuesChecksum(InstallationName(specs), step.cluster, step.release, valuesFile)
+			util.Printf("%s Waiting for KubeSlice Controller Pods to be Healthy...", util.Wait)
+			WaitForRollout(step.cluster, "kubeslice-controller", step.namespace)
+		case UI_install_Component:
+			valuesFile := kubesliceDirectory + "/" + valuesFileName(step.cluster, config.HelmChartConfiguration.UIChart)
+			generateUIValuesFile(step.cluster, config, valuesFile)
+			reuseReleaseValues(step.cluster, step.release, step.namespace, valuesFile)
+			showValuesDiff(step.cluster, step.release, step.namespace, valuesFile)
+			installKubeSliceUI(step.cluster, config.HelmChartConfiguration, valuesFile)
+			recordValuesChecksum(InstallationName(specs), step.cluster, step.release, valuesFile)
+			util.Printf("%s Waiting for KubeSlice Manager Pods to be Healthy...", util.Wait)
+			PodVerification("Waiting for KubeSlice Manager Pods to be Healthy", step.cluster, "kubernetes-dashboard")
+		case Worker_Component:
+			filename := valuesFileName(step.cluster, config.HelmChartConfiguration.WorkerChart)
+			generateWorkerValuesFile(step.cluster, filename, config, config.ClusterConfiguration.ClusterType == Kind_Component)
+			installWorker(InstallationName(specs), step.cluster, filename, config.HelmChartConfiguration)
+		}
+		util.Printf("%s Upgraded %s on %s from version %s to %s", util.Tick, step.release, step.cluster.Name, step.from, step.to)
+	}
+	RecordInstallation(specs, false)
+	writeRunSummary()
+	util.Printf("%s Successfully upgraded %d releases", util.Tick, len(pending))
+}
+
+// buildUpgradePlan compares the chart versions of the components with the
+// releases deployed on their clusters. It fails on releases stuck in a state
+// helm cannot upgrade from.
+func buildUpgradePlan(specs *ConfigurationSpecs, components []string) ([]upgradeStep, error) {
+	releases := componentReleases(specs)
+	steps := make([]upgradeStep, 0)
+	for _, component := range UpgradeComponents {
+		cr, found := releases[component]
+		if !found || cr.chart.ChartName == "" || !containsString(components, component) {
+			continue
+		}
+		to := cr.chart.Version
+		if chart, ok := runSummary.Charts[component]; ok {
+			to = chart.Version
+		}
+		for _, cluster := range cr.clusters {
+			step := upgradeStep{component: component, cluster: cluster, release: cr.release, namespace: cr.namespace(cluster), chart: cr.chart, to: to}
+			deployed, err := findRelease(cluster, step.release, step.namespace)
+			if err != nil {
+				return nil, err
+			}
+			if deployed != nil {
+				if deployed.pending() {
+					return nil, fmt.Errorf("release %s in namespace %s on %s is in state %s. Roll it back with `helm rollback %s --namespace %s` before upgrading",
+						step.release, step.namespace, cluster.Name, deployed.Status, step.release, step.namespace)
+				}
+				step.from = deployed.chartVersion(cr.chart.ChartName)
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// chartCRD is a CRD of the crds directory of a chart.
+type chartCRD struct {
+	name     string
+	manifest []byte
+	// versions is the spec.versions of the CRD decoded from JSON, the way
+	// the CRD of the cluster is
+	versions interface{}
+}
+
+// upgradeCRDs applies the CRDs of the crds directory of a chart which are
+// missing on the cluster or differ from those of the cluster. Helm installs
+// them with the first release only and never upgrades them.
+func upgradeCRDs(cluster Cluster, hc HelmChartConfiguration, chart HelmChart) {
+	crds, err := chartCRDs(hc, chart)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	outdated := make([]chartCRD, 0)
+	for _, crd := range crds {
+		deployed, err := kubeClient.Get(&cluster, crdResource, "", crd.name)
+		if err != nil && !errors.Is(err, errNotFound) {
+			util.Fatalf("%s Unable to get CRD %s on %s: %v", util.Cross, crd.name, cluster.Name, err)
+		}
+		if crdChanged(crd, deployed) {
+			outdated = append(outdated, crd)
+		}
+	}
+	if len(outdated) == 0 {
+		return
+	}
+	names := make([]string, 0, len(outdated))
+	manifests := make([][]byte, 0, len(outdated))
+	for _, crd := range outdated {
+		names = append(names, crd.name)
+		manifests = append(manifests, crd.manifest)
+	}
+	util.Printf("%s Helm does not upgrade CRDs, applying the changed CRDs of chart %s %s on %s: %s", util.Warn, chart.ChartName, chart.Version, cluster.Name, strings.Join(names, ", "))
+	fileName := kubesliceDirectory + "/" + strings.TrimSuffix(valuesFileName(cluster, chart), ".yaml") + "-crds.yaml"
+	if err := util.WriteFileAtomic(fileName, bytes.Join(manifests, []byte("---\n")), 0644); err != nil {
+		util.Fatalf("%s Unable to write %s: %v", util.Cross, fileName, err)
+	}
+	if err := kubeClient.Apply(&cluster, "", fileName); err != nil {
+		util.Fatalf("%s Unable to apply the CRDs of chart %s on %s: %v", util.Cross, chart.ChartName, cluster.Name, err)
+	}
+	if err := waitForCRDsEstablished(&cluster, names); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	util.Printf("%s Applied %d CRDs of chart %s on %s", util.Tick, len(outdated), chart.ChartName, cluster.Name)
+}
+
+// chartCRDs returns the CRDs of the crds directory of the chart version to
+// install, with `helm show crds`.
+func chartCRDs(hc HelmChartConfiguration, chart HelmChart) ([]chartCRD, error) {
+	args := []string{"show", "crds", chartReference(hc, chart)}
+	if chart.Version != "" && chart.LocalPath == "" {
+		args = append(args, "--version", chart.Version)
+	}
+	args = append(args, helmTLSFlags(hc.forChart(chart), false)...)
+	args = append(args, helmDevelFlags(chart)...)
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...); err != nil {
+		return nil, fmt.Errorf("unable to show the CRDs of chart %s: %s", chartReference(hc, chart), commandError(err, &errB))
+	}
+	crds, err := parseChartCRDs(outB.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the CRDs of chart %s: %v", chartReference(hc, chart), err)
+	}
+	return crds, nil
+}
+
+// parseChartCRDs parses the CRDs out of the output of `helm show crds`,
+// ignoring anything else.
+func parseChartCRDs(data []byte) ([]chartCRD, error) {
+	crds := make([]chartCRD, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				return crds, nil
+			}
+			return nil, err
+		}
+		manifest, err := yaml.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		jsonManifest, err := YAML.YAMLToJSON(manifest)
+		if err != nil {
+			return nil, err
+		}
+		object := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Versions interface{} `json:"versions"`
+			} `json:"spec"`
+		}{}
+		if err := json.Unmarshal(jsonManifest, &object); err != nil {
+			return nil, err
+		}
+		if object.Kind != "CustomResourceDefinition" || object.Metadata.Name == "" {
+			continue
+		}
+		crds = append(crds, chartCRD{name: object.Metadata.Name, manifest: manifest, versions: object.Spec.Versions})
+	}
+}
+
+// crdChanged tells whether a CRD of a chart is missing on the cluster, nil
+// deployed, or its versions and their schemas differ from those deployed.
+func crdChanged(crd chartCRD, deployed map[string]interface{}) bool {
+	if deployed == nil {
+		return true
+	}
+	spec, _ := deployed["spec"].(map[string]interface{})
+	return !reflect.DeepEqual(crd.versions, spec["versions"])
+}
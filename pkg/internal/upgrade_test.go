@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestUpgradePlan(t *testing.T) {
+	defer func(summary RunSummary) { runSummary = summary }(runSummary)
+	runSummary = RunSummary{Charts: map[string]chartSummary{
+		Controller_Component: {Chart: "kubeslice/kubeslice-controller", Version: "1.1.0"},
+		Worker_Component:     {Chart: "kubeslice/kubeslice-worker", Version: "1.1.0"},
+	}}
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl", KubeConfigPath: "kubeconfig.yaml"},
+			WorkerClusters: []Cluster{
+				{Name: "ks-w-1", ContextName: "ks-w-1", KubeConfigPath: "kubeconfig.yaml"},
+				{Name: "ks-w-2", ContextName: "ks-w-2", KubeConfigPath: "kubeconfig.yaml"},
+				{Name: "ks-w-3", ContextName: "ks-w-3", KubeConfigPath: "kubeconfig.yaml"},
+			},
+		},
+		HelmChartConfiguration: HelmChartConfiguration{
+			RepoAlias:       "kubeslice",
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.1.0"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker", Version: "1.1.0"},
+		},
+	}}
+	// ks-w-1 is at the target version, ks-w-3 has no worker
+	deployed := map[string]string{"ks-ctrl": "kubeslice-controller-1.0.0", "ks-w-1": "kubeslice-worker-1.1.0", "ks-w-2": "kubeslice-worker-1.0.2"}
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		if chart, ok := deployed[command[2]]; ok {
+			release := chart[:strings.LastIndex(chart, "-")]
+			fmt.Fprintf(stdout, `[{"name": %q, "namespace": %q, "revision": "1", "status": "deployed", "chart": %q}]`, release, release, chart)
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	steps, err := buildUpgradePlan(specs, UpgradeComponents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+Upgrade plan of installation ks-ctrl:
+[1/2] Upgrading the KubeSlice Controller
+    ks-ctrl: upgrade release kubeslice-controller in namespace kubeslice-controller, chart kubeslice/kubeslice-controller 1.1.0 (from 1.0.0)
+[2/2] Upgrading the KubeSlice Workers
+    ks-w-1: up-to-date release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.1.0
+    ks-w-2: upgrade release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.1.0 (from 1.0.2)
+    ks-w-3: skip release kubeslice-worker in namespace kubeslice-system, chart kubeslice/kubeslice-worker 1.1.0 (not installed, left to install)`
+	if got := upgradeExecution(specs, steps).Text(); got != expected {
+		t.Errorf("upgradeExecution().Text() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestCRDChanged(t *testing.T) {
+	t.Parallel()
+	crds, err := parseChartCRDs([]byte(`---
+# Source: kubeslice-worker/crds/slicegateways.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: slicegateways.networking.kubeslice.io
+spec:
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+---
+`))
+	if err != nil || len(crds) != 1 || crds[0].name != "slicegateways.networking.kubeslice.io" {
+		t.Fatalf("parseChartCRDs() = %+v, %v", crds, err)
+	}
+	version := func(name string) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"versions": []interface{}{map[string]interface{}{
+			"name": name, "served": true, "storage": true,
+			"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{"type": "object"}},
+		}}}}
+	}
+	tests := []struct {
+		name     string
+		deployed map[string]interface{}
+		expected bool
+	}{
+		{name: "Missing", deployed: nil, expected: true},
+		{name: "Same versions", deployed: version("v1beta1"), expected: false},
+		{name: "Other versions", deployed: version("v1alpha1"), expected: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := crdChanged(crds[0], tc.deployed); got != tc.expected {
+				t.Errorf("crdChanged() = %t, want %t", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// UserDefaultsFileEnv overrides the path of the user defaults file.
+const UserDefaultsFileEnv = "KUBESLICE_CLI_CONFIG"
+
+// userDefaultsEnvPrefix prefixes the environment variables setting the keys
+// of the user defaults file, e.g. KUBESLICE_CLI_HTTP_PROXY for http-proxy.
+const userDefaultsEnvPrefix = "KUBESLICE_CLI_"
+
+// Sources of the value of a user setting, by precedence.
+const (
+	SettingSourceFlag    = "flag"
+	SettingSourceEnv     = "env"
+	SettingSourceFile    = "file"
+	SettingSourceDefault = "default"
+)
+
+// UserSetting is a key of the user defaults file, a global flag or one of the
+// defaults of kubeslice-cli, with its effective value and where it came from.
+type UserSetting struct {
+	Key    string
+	Value  string
+	Source string
+	// Origin is the flag, environment variable or file the value was read from
+	Origin string
+}
+
+// UserDefaultsFile returns the path of the user defaults file,
+// ~/.kubeslice-cli.yaml unless overridden with KUBESLICE_CLI_CONFIG.
+func UserDefaultsFile() string {
+	if path := os.Getenv(UserDefaultsFileEnv); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".kubeslice-cli.yaml"
+	}
+	return filepath.Join(home, ".kubeslice-cli.yaml")
+}
+
+// userSettingHints tell what to set instead of keys of other CLIs which
+// kubeslice-cli has no flag for.
+var userSettingHints = map[string]string{
+	"log-level": "set debug: true for debug output",
+	"no-color":  "the output of kubeslice-cli is not colored",
+}
+
+// userSettingEnv returns the environment variable of a key.
+func userSettingEnv(key string) string {
+	return userDefaultsEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// ResolveUserSettings resolves the settings whose value is not passed on the
+// command line, their Source is flag for those which are. The environment
+// variables win over the user defaults file, which wins over the built-in
+// values. The keys of the file which are not known are warned about with the
+// closest known key.
+func ResolveUserSettings(settings []UserSetting) ([]UserSetting, error) {
+	path := UserDefaultsFile()
+	file, err := loadUserDefaults(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveUserSettings(settings, path, file, os.LookupEnv), nil
+}
+
+func resolveUserSettings(settings []UserSetting, path string, file map[string]string, lookupEnv func(string) (string, bool)) []UserSetting {
+	known := make([]string, 0, len(settings))
+	resolved := make([]UserSetting, 0, len(settings))
+	for _, setting := range settings {
+		known = append(known, setting.Key)
+		if setting.Source == SettingSourceFlag {
+			resolved = append(resolved, setting)
+			continue
+		}
+		env := userSettingEnv(setting.Key)
+		if value, found := lookupEnv(env); found {
+			setting.Value, setting.Source, setting.Origin = value, SettingSourceEnv, env
+		} else if value, found := file[setting.Key]; found {
+			setting.Value, setting.Source, setting.Origin = value, SettingSourceFile, path
+		} else {
+			setting.Source, setting.Origin = SettingSourceDefault, ""
+		}
+		resolved = append(resolved, setting)
+	}
+
+	unknown := make([]string, 0)
+	for key := range file {
+		if !containsString(known, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	sort.Strings(known)
+	// the warnings go to stderr, the output of the command may be json
+	for _, key := range unknown {
+		hint := ""
+		if h, found := userSettingHints[key]; found {
+			hint = fmt.Sprintf(" (%s)", h)
+		} else if matches := closeContexts(key, known); len(matches) > 0 {
+			hint = fmt.Sprintf(" (did you mean %s?)", matches[0])
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: unknown key '%s'%s, it is ignored\n", util.Warn, path, key, hint)
+	}
+	return resolved
+}
+
+// loadUserDefaults reads the keys of the user defaults file, a missing file
+// sets none.
+func loadUserDefaults(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the user defaults %s: %v", path, err)
+	}
+	document := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("unable to parse the user defaults %s: %v", path, err)
+	}
+	defaults := make(map[string]string, len(document))
+	for key, value := range document {
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			return nil, fmt.Errorf("%s: the value of %s must be a scalar or a list", path, key)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			defaults[key] = strings.Join(items, ",")
+		case nil:
+			defaults[key] = ""
+		default:
+			defaults[key] = fmt.Sprint(v)
+		}
+	}
+	return defaults, nil
+}
+
+// ShowUserSettings prints the effective settings and where every value came
+// from.
+func ShowUserSettings(settings []UserSetting) {
+	path := UserDefaultsFile()
+	if _, err := os.Stat(path); err != nil {
+		path += " (not found)"
+	}
+	util.Printf("User defaults file: %s", path)
+	util.Printf("%s", userSettingsTable(settings))
+}
+
+// userSettingsTable formats the settings sorted by key, with the flag,
+// environment variable or file of their value.
+func userSettingsTable(settings []UserSetting) string {
+	sorted := append([]UserSetting{}, settings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range sorted {
+		value, source := s.Value, s.Source
+		if value == "" {
+			value = "-"
+		}
+		if s.Origin != "" {
+			source += " " + s.Origin
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, value, source)
+	}
+	w.Flush()
+	return strings.TrimSuffix(out.String(), "\n")
+}
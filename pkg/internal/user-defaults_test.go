@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveUserSettings(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), ".kubeslice-cli.yaml")
+	if err := ioutil.WriteFile(path, []byte("http-proxy: http://file:3128\ndebug: true\nworkspace: /tmp/file\nprofil: full-demo\ncomponents:\n- controller\n- worker\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := loadUserDefaults(path)
+	if err != nil {
+		t.Fatalf("loadUserDefaults() error = %v", err)
+	}
+	if file["components"] != "controller,worker" {
+		t.Errorf("loadUserDefaults() components = %q, want a comma separated list", file["components"])
+	}
+	env := map[string]string{"KUBESLICE_CLI_HTTP_PROXY": "http://env:3128", "KUBESLICE_CLI_WORKSPACE": "/tmp/env"}
+	lookupEnv := func(name string) (string, bool) {
+		value, found := env[name]
+		return value, found
+	}
+
+	settings := []UserSetting{
+		{Key: "workspace", Value: "/tmp/flag", Source: SettingSourceFlag, Origin: "--workspace"},
+		{Key: "http-proxy"},
+		{Key: "debug", Value: "false"},
+		{Key: "profile"},
+		{Key: "kube-client", Value: "kubectl"},
+	}
+	expected := []UserSetting{
+		{Key: "workspace", Value: "/tmp/flag", Source: SettingSourceFlag, Origin: "--workspace"},
+		{Key: "http-proxy", Value: "http://env:3128", Source: SettingSourceEnv, Origin: "KUBESLICE_CLI_HTTP_PROXY"},
+		{Key: "debug", Value: "true", Source: SettingSourceFile, Origin: path},
+		{Key: "profile", Source: SettingSourceDefault},
+		{Key: "kube-client", Value: "kubectl", Source: SettingSourceDefault},
+	}
+	resolved := resolveUserSettings(settings, path, file, lookupEnv)
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("resolveUserSettings() = %v, want %v", resolved, expected)
+	}
+
+	table := userSettingsTable(resolved)
+	expectedTable := `KEY           VALUE             SOURCE
+debug         true              file ` + path + `
+http-proxy    http://env:3128   env KUBESLICE_CLI_HTTP_PROXY
+kube-client   kubectl           default
+profile       -                 default
+workspace     /tmp/flag         flag --workspace`
+	if table != expectedTable {
+		t.Errorf("userSettingsTable() =\n%s\nwant\n%s", table, expectedTable)
+	}
+}
@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// defaultFreeFormValues lists the value prefixes whose keys are chosen by the
+// user and are therefore never checked against the chart defaults.
+var defaultFreeFormValues = []string{
+	"annotations",
+	"podAnnotations",
+	"labels",
+	"podLabels",
+	"extraEnv",
+	"env",
+	"nodeSelector",
+}
+
+// chartDefaultValues caches the output of `helm show values` per chart.
+var chartDefaultValues = map[string]map[interface{}]interface{}{}
+
+type unknownValue struct {
+	path       string
+	suggestion string
+}
+
+// verifyValueKeys checks every value path set for the chart in the topology
+// against the chart's default values. Unknown paths are reported as warnings,
+// or fail the run when strict values checking is enabled.
+func verifyValueKeys(hcConfig HelmChartConfiguration, hc *HelmChart) {
+	userValues := expandValues(hc.Values)
+	if valuesYAML, err := parseValuesYAML(hc); err == nil {
+		userValues = mergeMaps(valuesYAML, userValues)
+	}
+	if len(userValues) == 0 {
+		return
+	}
+	defaults, err := showChartValues(hcConfig, hc)
+	if err != nil {
+		util.Printf("%s Unable to fetch the default values of chart %s, skipping values check: %v", util.Warn, hc.ChartName, err)
+		return
+	}
+	unknown := unknownValuePaths(userValues, defaults, append(defaultFreeFormValues, hc.FreeFormValues...))
+	if len(unknown) == 0 {
+		return
+	}
+	strict := Options.StrictValues || hc.Strict
+	symbol := util.Warn
+	if strict {
+		symbol = util.Cross
+	}
+	for _, u := range unknown {
+		if u.suggestion != "" {
+			util.Printf("%s Value %s is not known to chart %s, did you mean %s?", symbol, u.path, hc.ChartName, u.suggestion)
+		} else {
+			util.Printf("%s Value %s is not known to chart %s", symbol, u.path, hc.ChartName)
+		}
+	}
+	if strict {
+		util.Fatalf("%s Chart %s has %d unknown values", util.Cross, hc.ChartName, len(unknown))
+	}
+}
+
+func showChartValues(hcConfig HelmChartConfiguration, hc *HelmChart) (map[interface{}]interface{}, error) {
+	ref := chartReference(hcConfig, *hc) + "@" + hc.Version
+	if values, found := chartDefaultValues[ref]; found {
+		return values, nil
+	}
+	args := []string{"show", "values", chartReference(hcConfig, *hc)}
+	if hc.Version != "" {
+		args = append(args, "--version", hc.Version)
+	}
+	args = append(args, helmTLSFlags(hcConfig.forChart(*hc), false)...)
+	args = append(args, helmDevelFlags(*hc)...)
+	var outB, errB bytes.Buffer
+	if err := util.RunCommandCustomIO("helm", &outB, &errB, true, args...); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(errB.String()))
+	}
+	values, err := parseValues(outB.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	chartDefaultValues[ref] = values
+	return values, nil
+}
+
+// unknownValuePaths returns the leaf paths of user which do not exist in
+// defaults, sorted by path. Paths below a default which is not a non-empty map
+// are accepted since the chart does not describe their keys, as are paths
+// below one of the allowed prefixes.
+func unknownValuePaths(user, defaults map[interface{}]interface{}, allowed []string) []unknownValue {
+	unknown := make([]unknownValue, 0)
+	var walk func(user, defaults map[interface{}]interface{}, prefix string)
+	walk = func(user, defaults map[interface{}]interface{}, prefix string) {
+		for k, v := range user {
+			key := fmt.Sprint(k)
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if hasAllowedPrefix(path, allowed) {
+				continue
+			}
+			d, found := defaults[key]
+			if !found {
+				for _, rest := range leafPaths(v) {
+					unknown = append(unknown, unknownValue{
+						path:       strings.Join(append([]string{path}, rest...), "."),
+						suggestion: suggestValuePath(defaults, key, rest, prefix),
+					})
+				}
+				continue
+			}
+			userMap, userIsMap := v.(map[interface{}]interface{})
+			defaultsMap, defaultsIsMap := d.(map[interface{}]interface{})
+			if userIsMap && defaultsIsMap && len(defaultsMap) > 0 {
+				walk(userMap, defaultsMap, path)
+			}
+		}
+	}
+	walk(user, defaults, "")
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].path < unknown[j].path
+	})
+	return unknown
+}
+
+func hasAllowedPrefix(path string, allowed []string) bool {
+	for _, prefix := range allowed {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// leafPaths returns the key paths of the leaves below value, relative to it.
+func leafPaths(value interface{}) [][]string {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok || len(m) == 0 {
+		return [][]string{{}}
+	}
+	paths := make([][]string, 0)
+	for k, v := range m {
+		for _, rest := range leafPaths(v) {
+			paths = append(paths, append([]string{fmt.Sprint(k)}, rest...))
+		}
+	}
+	return paths
+}
+
+// suggestValuePath returns the path of the default key closest to key, with
+// the rest of the user path appended when it exists below that key.
+func suggestValuePath(defaults map[interface{}]interface{}, key string, rest []string, prefix string) string {
+	best, bestDistance := "", len(key)/2+1
+	for k := range defaults {
+		candidate := fmt.Sprint(k)
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	suggestion := best
+	if prefix != "" {
+		suggestion = prefix + "." + best
+	}
+	current := defaults[best]
+	for _, k := range rest {
+		m, ok := current.(map[interface{}]interface{})
+		if !ok {
+			return suggestion
+		}
+		if current, ok = m[k]; !ok {
+			return suggestion
+		}
+	}
+	return strings.Join(append([]string{suggestion}, rest...), ".")
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnknownValuePaths(t *testing.T) {
+	t.Parallel()
+
+	defaults, err := parseValues([]byte(`
+operator:
+  logLevel: info
+  resources: {}
+podAnnotations: {}
+extraEnv: []
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse defaults: %v", err)
+	}
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		allowed  []string
+		expected []unknownValue
+	}{
+		{
+			name:     "Exact matches",
+			values:   map[string]interface{}{"operator.logLevel": "debug"},
+			expected: []unknownValue{},
+		},
+		{
+			name:     "Keys below empty defaults are accepted",
+			values:   map[string]interface{}{"operator.resources.limits.cpu": "2"},
+			expected: []unknownValue{},
+		},
+		{
+			name:     "Unknown leaf key",
+			values:   map[string]interface{}{"operator.logLevl": "debug"},
+			expected: []unknownValue{{path: "operator.logLevl", suggestion: "operator.logLevel"}},
+		},
+		{
+			name:     "Misspelled parent key",
+			values:   map[string]interface{}{"operater.logLevel": "debug"},
+			expected: []unknownValue{{path: "operater.logLevel", suggestion: "operator.logLevel"}},
+		},
+		{
+			name:     "Unknown key without a close match",
+			values:   map[string]interface{}{"gateway.enabled": true},
+			expected: []unknownValue{{path: "gateway.enabled"}},
+		},
+		{
+			name:     "Allowlisted prefixes",
+			values:   map[string]interface{}{"extraEnv.FOO": "bar", "metadata.team": "net"},
+			allowed:  []string{"extraEnv", "metadata"},
+			expected: []unknownValue{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := unknownValuePaths(expandValues(tc.values), defaults, tc.allowed)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unknownValuePaths() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// valuesChecksumsFile records the checksum of the values each release was last
+// installed with, keyed by installation, cluster and release, as installations
+// of the default topologies share the names of their clusters.
+var valuesChecksumsFile = filepath.Join(kubesliceHomeDirectory, "values-checksums.yaml")
+
+// releaseValues identifies the values file generated for a release.
+type releaseValues struct {
+	cluster    Cluster
+	release    string
+	namespace  string
+	valuesFile string
+}
+
+func valuesChecksumKey(installation string, cluster Cluster, release string) string {
+	return installation + "/" + cluster.Name + "/" + release
+}
+
+// valuesChecksum returns the sha256 of the complete values document of a
+// generated values file, including the sensitive values split out of it.
+func valuesChecksum(valuesFile string) (string, error) {
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return "", err
+	}
+	values, err := parseValues(data)
+	if err != nil {
+		return "", err
+	}
+	mergeMaps(values, normalizeValue(sensitiveValuesFor(valuesFile)).(map[interface{}]interface{}))
+	encoded, err := encodeValues(values)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(encoded))), nil
+}
+
+func loadValuesChecksums() (map[string]string, error) {
+	checksums := make(map[string]string)
+	data, err := ioutil.ReadFile(valuesChecksumsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &checksums); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", valuesChecksumsFile, err)
+	}
+	if checksums == nil {
+		checksums = make(map[string]string)
+	}
+	return checksums, nil
+}
+
+// recordValuesChecksum stores the checksum of the values a release was just
+// installed with. Failures only warn since the install itself succeeded.
+func recordValuesChecksum(installation string, cluster Cluster, release, valuesFile string) {
+	checksum, err := valuesChecksum(valuesFile)
+	if err == nil {
+		var checksums map[string]string
+		if checksums, err = loadValuesChecksums(); err == nil {
+			checksums[valuesChecksumKey(installation, cluster, release)] = checksum
+			var data []byte
+			if data, err = yaml.Marshal(checksums); err == nil {
+				util.CreateDirectoryPath(filepath.Dir(valuesChecksumsFile))
+				err = util.WriteFileAtomic(valuesChecksumsFile, data, 0600)
+			}
+		}
+	}
+	if err != nil {
+		util.Printf("%s Unable to record the values checksum of release %s on %s: %v", util.Warn, release, cluster.Name, err)
+	}
+}
+
+// generateReleaseValues generates the values files of every release described
+// by the topology.
+func generateReleaseValues(ApplicationConfiguration *ConfigurationSpecs) []releaseValues {
+	config := ApplicationConfiguration.Configuration
+	cc := config.ClusterConfiguration
+	hc := config.HelmChartConfiguration
+	releases := make([]releaseValues, 0)
+	if hc.CertManagerChart.ChartName != "" {
+		valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.CertManagerChart)
+		generateCertManagerValuesFile(cc.ControllerCluster, config, valuesFile)
+		releases = append(releases, releaseValues{cc.ControllerCluster, "cert-manager", "cert-manager", valuesFile})
+	}
+	valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.ControllerChart)
+	generateControllerValuesFile(cc.ControllerCluster, config, valuesFile)
+	releases = append(releases, releaseValues{cc.ControllerCluster, KUBESLICE_CONTROLLER_NAMESPACE, ControllerNamespace(cc.ControllerCluster), valuesFile})
+	if hc.UIChart.ChartName != "" {
+		valuesFile := kubesliceDirectory + "/" + valuesFileName(cc.ControllerCluster, hc.UIChart)
+		generateUIValuesFile(cc.ControllerCluster, config, valuesFile)
+		releases = append(releases, releaseValues{cc.ControllerCluster, "kubeslice-ui", ControllerNamespace(cc.ControllerCluster), valuesFile})
+	}
+	for _, cluster := range cc.WorkerClusters {
+		filename := valuesFileName(cluster, hc.WorkerChart)
+		generateWorkerValuesFile(cluster, filename, config, cc.ClusterType == Kind_Component)
+		releases = append(releases, releaseValues{cluster, "kubeslice-worker", WorkerNamespace(cluster), kubesliceDirectory + "/" + filename})
+		if hc.PrometheusChart.ChartName != "" {
+			valuesFile := kubesliceDirectory + "/" + valuesFileName(cluster, hc.PrometheusChart)
+			generatePrometheusValuesFile(hc, valuesFile)
+			releases = append(releases, releaseValues{cluster, hc.PrometheusChart.ChartName, PrometheusNamespace, valuesFile})
+		}
+	}
+	return releases
+}
+
+// ShowValuesDrift compares the values generated from the current topology with
+// the checksums recorded when the releases were installed.
+func ShowValuesDrift(ApplicationConfiguration *ConfigurationSpecs, showDiff bool) {
+	util.Printf("\nChecking values of installed releases...")
+	GenerateKubeSliceDirectory()
+	checksums, err := loadValuesChecksums()
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	installation := InstallationName(ApplicationConfiguration)
+	for _, r := range generateReleaseValues(ApplicationConfiguration) {
+		recorded, found := checksums[valuesChecksumKey(installation, r.cluster, r.release)]
+		if !found {
+			util.Printf("%s %s on %s: no values checksum recorded, the release was not installed by this version of kubeslice-cli", util.Warn, r.release, r.cluster.Name)
+			continue
+		}
+		checksum, err := valuesChecksum(r.valuesFile)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		if checksum == recorded {
+			util.Printf("%s %s on %s: in sync", util.Tick, r.release, r.cluster.Name)
+			continue
+		}
+		util.Printf("%s %s on %s: values drift detected", util.Cross, r.release, r.cluster.Name)
+		if !showDiff {
+			continue
+		}
+		diff, installed, err := releaseValuesDiff(r.cluster, r.release, r.namespace, r.valuesFile)
+		switch {
+		case err != nil:
+			util.Printf("%s %v", util.Warn, err)
+		case !installed:
+			util.Printf("%s Release %s is not installed on %s", util.Warn, r.release, r.cluster.Name)
+		default:
+			util.Printf("%s", diff)
+		}
+	}
+}
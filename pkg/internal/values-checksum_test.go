@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValuesChecksum(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "kubeslice-test-checksum")
+	if err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	checksum := func(name string, values map[string]interface{}) string {
+		filePath := filepath.Join(dir, name)
+		hc := &HelmChart{Values: values, SensitiveValues: []string{"license.key"}}
+		if err := generateValuesFile(filePath, hc, "", ""); err != nil {
+			t.Fatalf("generateValuesFile() returned error: %v", err)
+		}
+		sum, err := valuesChecksum(filePath)
+		if err != nil {
+			t.Fatalf("valuesChecksum() returned error: %v", err)
+		}
+		return sum
+	}
+
+	base := checksum("a.yaml", map[string]interface{}{"operator.logs": "info", "license.key": "one"})
+	if same := checksum("b.yaml", map[string]interface{}{"license.key": "one", "operator": map[interface{}]interface{}{"logs": "info"}}); same != base {
+		t.Errorf("equal values should have the same checksum, got %s and %s", base, same)
+	}
+	if changed := checksum("c.yaml", map[string]interface{}{"operator.logs": "info", "license.key": "two"}); changed == base {
+		t.Errorf("a changed sensitive value should change the checksum")
+	}
+}
+
+func TestRecordValuesChecksum(t *testing.T) {
+	defer func(file string) { valuesChecksumsFile = file }(valuesChecksumsFile)
+	dir := t.TempDir()
+	valuesChecksumsFile = filepath.Join(dir, "values-checksums.yaml")
+	cluster := Cluster{Name: "ks-w-1"}
+	// two installations of the default topology share the names of their clusters
+	sums := make(map[string]string)
+	for _, tc := range []struct {
+		installation string
+		logs         string
+	}{
+		{installation: "first", logs: "info"},
+		{installation: "second", logs: "debug"},
+	} {
+		valuesFile := filepath.Join(dir, tc.installation+".yaml")
+		if err := generateValuesFile(valuesFile, &HelmChart{Values: map[string]interface{}{"operator.logs": tc.logs}}, "", ""); err != nil {
+			t.Fatalf("generateValuesFile() returned error: %v", err)
+		}
+		sum, err := valuesChecksum(valuesFile)
+		if err != nil {
+			t.Fatalf("valuesChecksum() returned error: %v", err)
+		}
+		sums[tc.installation] = sum
+		recordValuesChecksum(tc.installation, cluster, "kubeslice-worker", valuesFile)
+	}
+
+	checksums, err := loadValuesChecksums()
+	if err != nil {
+		t.Fatalf("loadValuesChecksums() returned error: %v", err)
+	}
+	for installation, sum := range sums {
+		if got := checksums[valuesChecksumKey(installation, cluster, "kubeslice-worker")]; got != sum {
+			t.Errorf("checksum of installation %s = %q, want %q", installation, got, sum)
+		}
+	}
+}
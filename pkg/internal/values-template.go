@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches the {{name}} placeholders which may be used in
+// the chart values.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// workerTemplateVariables returns the placeholder values of a worker cluster.
+func workerTemplateVariables(cluster Cluster, controllerEndpoint, projectNamespace string) map[string]string {
+	return map[string]string{
+		"cluster.name":        cluster.Name,
+		"cluster.nodeIP":      cluster.NodeIP,
+		"cluster.nodeIPs":     strings.Join(clusterNodeIPs(cluster), ","),
+		"cluster.endpoint":    cluster.ControlPlaneAddress,
+		"controller.endpoint": controllerEndpoint,
+		"project.namespace":   projectNamespace,
+	}
+}
+
+// controllerTemplateVariables returns the placeholder values of the charts
+// installed on the controller cluster, whose endpoint is the one of the
+// controller.
+func controllerTemplateVariables(cluster Cluster, ksc KubeSliceConfiguration) map[string]string {
+	return workerTemplateVariables(cluster, cluster.ControlPlaneAddress, projectNamespace(ksc.ProjectName))
+}
+
+// substituteChartPlaceholders replaces the placeholders in the values of a
+// chart installed on the controller cluster.
+func substituteChartPlaceholders(chart *HelmChart, cluster Cluster, config Configuration) error {
+	values, err := substitutePlaceholders(chart.Values, controllerTemplateVariables(cluster, config.KubeSliceConfiguration), chart.ChartName+" values")
+	if err != nil {
+		return err
+	}
+	chart.Values = values
+	return nil
+}
+
+// substitutePlaceholders returns a copy of values with every placeholder in
+// string values, including those nested in maps and lists, replaced. field is
+// the path of values and is only used for error messages.
+func substitutePlaceholders(values map[string]interface{}, vars map[string]string, field string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		substituted, err := substituteInValue(v, vars, field+"."+k)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = substituted
+	}
+	return result, nil
+}
+
+func substituteInValue(value interface{}, vars map[string]string, field string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var err error
+		substituted := placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			value, found := vars[name]
+			if !found && err == nil {
+				err = fmt.Errorf("%s: unknown placeholder %s", field, placeholder)
+			}
+			return value
+		})
+		return substituted, err
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(v))
+		for k, item := range v {
+			substituted, err := substituteInValue(item, vars, fmt.Sprintf("%s.%v", field, k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = substituted
+		}
+		return result, nil
+	case map[string]interface{}:
+		return substitutePlaceholders(v, vars, field)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			substituted, err := substituteInValue(item, vars, fmt.Sprintf("%s[%d]", field, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = substituted
+		}
+		return result, nil
+	}
+	return value, nil
+}
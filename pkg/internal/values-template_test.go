@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstitutePlaceholders(t *testing.T) {
+	t.Parallel()
+
+	vars := workerTemplateVariables(Cluster{Name: "worker-1", NodeIP: "172.18.0.3"}, "https://10.0.0.1:6443", "kubeslice-demo")
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		expected map[string]interface{}
+		err      string
+	}{
+		{
+			name:     "Plain values are left untouched",
+			values:   map[string]interface{}{"operator.logs": "debug", "replicas": 2},
+			expected: map[string]interface{}{"operator.logs": "debug", "replicas": 2},
+		},
+		{
+			name: "Placeholders in nested keys",
+			values: map[string]interface{}{
+				"cluster": map[interface{}]interface{}{
+					"gateway": map[interface{}]interface{}{"nodeIP": "{{cluster.nodeIP}}"},
+					"label":   "{{ cluster.name }}-{{project.namespace}}",
+				},
+			},
+			expected: map[string]interface{}{
+				"cluster": map[interface{}]interface{}{
+					"gateway": map[interface{}]interface{}{"nodeIP": "172.18.0.3"},
+					"label":   "worker-1-kubeslice-demo",
+				},
+			},
+		},
+		{
+			name: "Placeholders in list elements",
+			values: map[string]interface{}{
+				"egress.endpoints": []interface{}{"{{controller.endpoint}}", map[interface{}]interface{}{"host": "{{cluster.name}}"}},
+			},
+			expected: map[string]interface{}{
+				"egress.endpoints": []interface{}{"https://10.0.0.1:6443", map[interface{}]interface{}{"host": "worker-1"}},
+			},
+		},
+		{
+			name: "Unknown placeholders name the key path",
+			values: map[string]interface{}{
+				"tolerations": []interface{}{map[interface{}]interface{}{"value": "{{cluster.zone}}"}},
+			},
+			err: "worker_chart.values.tolerations[0].value: unknown placeholder {{cluster.zone}}",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := substitutePlaceholders(tc.values, vars, "worker_chart.values")
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("substitutePlaceholders() error = %v, expected %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("substitutePlaceholders() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("substitutePlaceholders() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSubstituteChartPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	config := Configuration{KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"}}
+	cluster := Cluster{Name: "controller", ControlPlaneAddress: "https://172.18.0.2:6443"}
+	chart := HelmChart{ChartName: "kubeslice-controller", Values: map[string]interface{}{
+		"kubeslice.controller.endpoint": "{{controller.endpoint}}",
+		"prometheus.namespace":          "{{project.namespace}}",
+	}}
+	if err := substituteChartPlaceholders(&chart, cluster, config); err != nil {
+		t.Fatalf("substituteChartPlaceholders() returned error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"kubeslice.controller.endpoint": "https://172.18.0.2:6443",
+		"prometheus.namespace":          "kubeslice-demo",
+	}
+	if !reflect.DeepEqual(chart.Values, expected) {
+		t.Errorf("substituteChartPlaceholders() = %v, expected %v", chart.Values, expected)
+	}
+
+	chart.Values = map[string]interface{}{"ui.host": "{{cluster.zone}}"}
+	if err := substituteChartPlaceholders(&chart, cluster, config); err == nil || err.Error() != "kubeslice-controller values.ui.host: unknown placeholder {{cluster.zone}}" {
+		t.Errorf("substituteChartPlaceholders() error = %v", err)
+	}
+}
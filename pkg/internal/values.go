@@ -3,59 +3,316 @@ package internal
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/kubeslice/kubeslice-cli/util"
 	"gopkg.in/yaml.v2"
 )
 
+// defaultSensitiveValues lists the value paths which always carry credentials.
+var defaultSensitiveValues = []string{
+	"imagePullSecrets.password",
+	"imagePullSecrets.dockerconfigjson",
+	"controllerSecret.token",
+	"controllerSecret.ca.crt",
+}
+
+// sensitiveValues holds the values split out of each generated values file,
+// keyed by the file path, until the helm command consuming them runs.
+// The paths which were considered sensitive are kept as well.
+var (
+	sensitiveValuesMu   sync.Mutex
+	sensitiveValues     = map[string]map[interface{}]interface{}{}
+	sensitiveValuePaths = map[string]map[string]bool{}
+)
+
+func sensitiveValuesFor(valuesFile string) map[interface{}]interface{} {
+	sensitiveValuesMu.Lock()
+	defer sensitiveValuesMu.Unlock()
+	return sensitiveValues[valuesFile]
+}
+
+// mergeMaps deep merges src into dest, values of src taking precedence. Nested
+// maps of either flavor, including those inside lists, are normalized to
+// map[interface{}]interface{} first so that values parsed from JSON merge with
+// values parsed by yaml.v2.
 func mergeMaps(dest, src map[interface{}]interface{}) map[interface{}]interface{} {
+	for k, v := range dest {
+		dest[k] = normalizeValue(v)
+	}
 	for k, v := range src {
+		v = normalizeValue(v)
 		if d, ok := dest[k]; ok {
-			switch d.(type) {
-			case map[interface{}]interface{}:
-				dest[k] = mergeMaps(d.(map[interface{}]interface{}), v.(map[interface{}]interface{}))
-			default:
-				dest[k] = v
+			dm, destIsMap := d.(map[interface{}]interface{})
+			sm, srcIsMap := v.(map[interface{}]interface{})
+			if destIsMap && srcIsMap {
+				dest[k] = mergeMaps(dm, sm)
+				continue
 			}
-		} else {
-			dest[k] = v
 		}
+		dest[k] = v
 	}
 	return dest
 }
 
-func generateValuesFile(filePath string, hc *HelmChart, defaults string) error {
+// expandValues turns the dotted keys of a chart's Values into nested maps. The
+// values are copied so that the topology is never modified by later merges.
+func expandValues(values map[string]interface{}) map[interface{}]interface{} {
 	valuesMap := make(map[interface{}]interface{})
-	for k, v := range hc.Values {
+	for k, v := range values {
 		keys := strings.Split(k, ".")
 		currentMap := valuesMap
 		for i, key := range keys {
 			if i == len(keys)-1 {
-				currentMap[key] = v
+				currentMap[key] = normalizeValue(v)
 			} else {
-				if currentMap[key] == nil {
+				if _, ok := currentMap[key].(map[interface{}]interface{}); !ok {
 					currentMap[key] = make(map[interface{}]interface{})
 				}
 				currentMap = currentMap[key].(map[interface{}]interface{})
 			}
 		}
 	}
+	return valuesMap
+}
+
+// normalizeValue returns a deep copy of value in which every map is a
+// map[interface{}]interface{}.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		c := make(map[interface{}]interface{}, len(v))
+		for k, item := range v {
+			c[k] = normalizeValue(item)
+		}
+		return c
+	case map[string]interface{}:
+		c := make(map[interface{}]interface{}, len(v))
+		for k, item := range v {
+			c[k] = normalizeValue(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = normalizeValue(item)
+		}
+		return c
+	}
+	return value
+}
 
-	defaultsMap := make(map[interface{}]interface{})
-	if err := yaml.Unmarshal([]byte(defaults), &defaultsMap); err != nil {
-		return fmt.Errorf("error parsing defaults: %v", err)
+// overrideValues returns the chart Values with the per-cluster overrides merged
+// on top. Nested maps set by both are merged key by key.
+func overrideValues(values, overrides map[string]interface{}) map[string]interface{} {
+	merged := mergeMaps(expandValues(values), expandValues(overrides))
+	result := make(map[string]interface{}, len(merged))
+	for k, v := range merged {
+		result[fmt.Sprint(k)] = v
+	}
+	return result
+}
+
+// parseValuesYAML parses the values_yaml block of a chart. Line numbers in the
+// returned error are relative to the block.
+func parseValuesYAML(hc *HelmChart) (map[interface{}]interface{}, error) {
+	values, err := parseValues([]byte(hc.ValuesYAML))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing values_yaml of chart %s: %v", hc.ChartName, err)
+	}
+	return values, nil
+}
+
+// ValidateValuesYAML reports the charts whose values_yaml block is not a valid
+// values document.
+func ValidateValuesYAML(hc *HelmChartConfiguration) []string {
+	errors := make([]string, 0)
+	for _, chart := range []*HelmChart{&hc.CertManagerChart, &hc.ControllerChart, &hc.WorkerChart, &hc.UIChart, &hc.PrometheusChart} {
+		if _, err := parseValuesYAML(chart); err != nil {
+			errors = append(errors, fmt.Sprintf("%s %v", util.Cross, err))
+		}
+	}
+	return errors
+}
+
+// generateValuesFile writes the values of a chart to filePath. The embedded
+// defaults are overridden by the values_yaml block, which in turn is
+// overridden by the dotted keys of Values. pullSecrets is the rendered image
+// pull secret to propagate into the chart, if any.
+func generateValuesFile(filePath string, hc *HelmChart, defaults, pullSecrets string) error {
+	valuesMap := expandValues(hc.Values)
+
+	defaultsMap, err := parseValues([]byte(defaults))
+	if err != nil {
+		return fmt.Errorf("error parsing defaults of chart %s: %v", hc.ChartName, err)
+	}
+
+	valuesYAMLMap, err := parseValuesYAML(hc)
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[string]bool)
+	for _, path := range append(defaultSensitiveValues, hc.SensitiveValues...) {
+		paths[path] = true
+	}
+	if pullSecrets != "" && !hc.SkipImagePullSecrets {
+		key := hc.ImagePullSecretsKey
+		if key == "" {
+			key = "imagePullSecrets"
+		}
+		// charts setting their own pull secrets in the topology keep them
+		if !hasValuePath(valuesMap, key) && !hasValuePath(valuesYAMLMap, key) {
+			secretsMap := make(map[interface{}]interface{})
+			if err := yaml.Unmarshal([]byte(pullSecrets), &secretsMap); err != nil {
+				return fmt.Errorf("error parsing image pull secrets: %v", err)
+			}
+			setValuePath(defaultsMap, key, secretsMap["imagePullSecrets"])
+			paths[key+".password"] = true
+			paths[key+".dockerconfigjson"] = true
+		}
 	}
 
-	mergedMap := mergeMaps(valuesMap, defaultsMap)
+	mergedMap := mergeMaps(mergeMaps(defaultsMap, valuesYAMLMap), valuesMap)
+	secrets := splitSensitiveValues(mergedMap, paths, "")
+	sensitiveValuesMu.Lock()
+	sensitiveValues[filePath] = secrets
+	sensitiveValuePaths[filePath] = paths
+	sensitiveValuesMu.Unlock()
 
 	finalData, err := yaml.Marshal(mergedMap)
 	if err != nil {
 		return fmt.Errorf("error encoding final data as YAML: %v", err)
 	}
 
-	if err := ioutil.WriteFile(filePath, finalData, 0644); err != nil {
+	if err := util.WriteFileAtomic(filePath, finalData, 0600); err != nil {
 		return fmt.Errorf("error writing values file: %v", err)
 	}
+	registerGeneratedFile(filePath)
 
 	return nil
 }
+
+// hasValuePath reports whether the dotted path is set in values.
+func hasValuePath(values map[interface{}]interface{}, path string) bool {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		v, found := values[key]
+		if !found {
+			return false
+		}
+		if i == len(keys)-1 {
+			return true
+		}
+		if values, found = v.(map[interface{}]interface{}); !found {
+			return false
+		}
+	}
+	return false
+}
+
+// setValuePath sets the dotted path in values, replacing whatever was set
+// there before.
+func setValuePath(values map[interface{}]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := values[key].(map[interface{}]interface{})
+		if !ok {
+			nested = make(map[interface{}]interface{})
+			values[key] = nested
+		}
+		values = nested
+	}
+	values[keys[len(keys)-1]] = value
+}
+
+// propagatedImagePullSecrets returns the rendered image pull secrets which are
+// injected into the charts other than the KubeSlice ones, or an empty string
+// when propagation is disabled. A referenced secret is injected as the usual
+// list of secret names.
+func propagatedImagePullSecrets(hcConfig HelmChartConfiguration) string {
+	if !hcConfig.PropagateImagePullSecrets {
+		return ""
+	}
+	if name := imagePullSecretName(hcConfig.ImagePullSecrets); name != "" {
+		return marshalImagePullSecrets([]map[string]string{{"name": name}})
+	}
+	return generateImagePullSecretsValue(hcConfig.ImagePullSecrets)
+}
+
+// valuesFileName returns the name of the generated values file of a chart
+// installed on the given cluster.
+func valuesFileName(cluster Cluster, chart HelmChart) string {
+	return fmt.Sprintf("helm-values-%s-%s.yaml", cluster.Name, chart.ChartName)
+}
+
+// registerGeneratedFile removes the file when the process exits, unless the
+// generated files should be kept for inspection.
+func registerGeneratedFile(path string) {
+	if Options.KeepGeneratedFiles {
+		return
+	}
+	util.AtExit(func() {
+		os.Remove(path)
+	})
+}
+
+// splitSensitiveValues removes the given value paths from values and returns
+// them in a map of the same shape.
+func splitSensitiveValues(values map[interface{}]interface{}, paths map[string]bool, prefix string) map[interface{}]interface{} {
+	split := make(map[interface{}]interface{})
+	for k, v := range values {
+		path := fmt.Sprint(k)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if paths[path] {
+			split[k] = v
+			delete(values, k)
+			continue
+		}
+		if nested, ok := v.(map[interface{}]interface{}); ok {
+			if s := splitSensitiveValues(nested, paths, path); len(s) > 0 {
+				split[k] = s
+			}
+		}
+	}
+	return split
+}
+
+// runHelmWithValues runs helm with the given arguments. When the values file
+// passed in the arguments had sensitive values split out, they are handed to
+// helm in a second values file readable only by the current user, which is
+// removed as soon as the command returns.
+func runHelmWithValues(valuesFile string, args ...string) error {
+	return withSensitiveValues(valuesFile, args, func(args []string) error {
+		return util.RunCommand("helm", args...)
+	})
+}
+
+// withSensitiveValues calls run with the arguments extended by the file of
+// sensitive values split out of valuesFile, if there are any.
+func withSensitiveValues(valuesFile string, args []string, run func(args []string) error) error {
+	if secrets := sensitiveValuesFor(valuesFile); len(secrets) > 0 {
+		data, err := yaml.Marshal(secrets)
+		if err != nil {
+			return fmt.Errorf("error encoding sensitive values as YAML: %v", err)
+		}
+		// TempFile creates the file with 0600 permissions
+		f, err := ioutil.TempFile(filepath.Dir(valuesFile), ".sensitive-values-*.yaml")
+		if err != nil {
+			return fmt.Errorf("error creating sensitive values file: %v", err)
+		}
+		defer os.Remove(f.Name())
+		_, err = f.Write(data)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("error writing sensitive values file: %v", err)
+		}
+		args = append(args, "-f", f.Name())
+	}
+	return run(args)
+}
@@ -0,0 +1,396 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestGenerateValuesFileKeepsSecretsOffDisk(t *testing.T) {
+	t.Parallel()
+
+	const password = "sup3r-s3cret-fixture"
+	dir, err := ioutil.TempDir("", "kubeslice-test-values")
+	if err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	hc := &HelmChart{
+		Values: map[string]interface{}{
+			"license.key":   password,
+			"operator.logs": "debug",
+		},
+		SensitiveValues: []string{"license.key"},
+	}
+	defaults := fmt.Sprintf(controllerValuesTemplate, "https://10.0.0.1:6443") + generateImagePullSecretsValue(ImagePullSecretList{{Username: "user", Password: password}})
+	filePath := filepath.Join(dir, "values.yaml")
+	if err := generateValuesFile(filePath, hc, defaults, ""); err != nil {
+		t.Fatalf("generateValuesFile() returned error: %v", err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat values file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("values file should only be accessible by the owner, got mode: %v", info.Mode())
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read values file: %v", err)
+	}
+	if strings.Contains(string(data), password) {
+		t.Errorf("values file contains the password fixture:\n%s", data)
+	}
+	if !strings.Contains(string(data), "username: user") || !strings.Contains(string(data), "logs: debug") {
+		t.Errorf("values file is missing non sensitive values:\n%s", data)
+	}
+
+	secrets := sensitiveValuesFor(filePath)
+	ips, _ := secrets["imagePullSecrets"].(map[interface{}]interface{})
+	license, _ := secrets["license"].(map[interface{}]interface{})
+	if ips["password"] != password || license["key"] != password {
+		t.Errorf("sensitive values were not kept for helm: %v", secrets)
+	}
+}
+
+func TestGenerateValuesFileMergesValuesYAML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		valuesYAML string
+		values     map[string]interface{}
+		defaults   string
+		expected   string
+		err        string
+	}{
+		{
+			name: "Multi-line strings are kept verbatim",
+			valuesYAML: `tls:
+  cert: |
+    -----BEGIN CERTIFICATE-----
+    MIIB
+    -----END CERTIFICATE-----
+`,
+			expected: `tls:
+  cert: |
+    -----BEGIN CERTIFICATE-----
+    MIIB
+    -----END CERTIFICATE-----
+`,
+		},
+		{
+			name: "Nested lists override the defaults",
+			valuesYAML: `affinity:
+  nodeAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+      nodeSelectorTerms:
+      - matchExpressions:
+        - key: kubeslice.io/node-type
+          operator: In
+          values: [gateway]
+`,
+			defaults: "affinity:\n  nodeAffinity: {}\nreplicas: 1\n",
+			expected: `affinity:
+  nodeAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+      nodeSelectorTerms:
+      - matchExpressions:
+        - key: kubeslice.io/node-type
+          operator: In
+          values:
+          - gateway
+replicas: 1
+`,
+		},
+		{
+			name: "Anchors and aliases are resolved",
+			valuesYAML: `common: &resources
+  limits:
+    cpu: 500m
+operator:
+  resources: *resources
+`,
+			expected: `common:
+  limits:
+    cpu: 500m
+operator:
+  resources:
+    limits:
+      cpu: 500m
+`,
+		},
+		{
+			name:       "Dotted keys win over the block",
+			valuesYAML: "operator:\n  logs: info\n  replicas: 2\n",
+			values:     map[string]interface{}{"operator.logs": "debug"},
+			defaults:   "operator:\n  logs: error\n",
+			expected:   "operator:\n  logs: debug\n  replicas: 2\n",
+		},
+		{
+			name:       "Parse errors name the chart and the line",
+			valuesYAML: "operator:\n  logs: info\n  replicas: [2\n",
+			err:        "error parsing values_yaml of chart kubeslice-worker: yaml: line 3",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "kubeslice-test-values")
+			if err != nil {
+				t.Fatalf("Failed to create test directory: %v", err)
+			}
+			t.Cleanup(func() {
+				os.RemoveAll(dir)
+			})
+			hc := &HelmChart{ChartName: "kubeslice-worker", Values: tc.values, ValuesYAML: tc.valuesYAML}
+			filePath := filepath.Join(dir, "values.yaml")
+			err = generateValuesFile(filePath, hc, tc.defaults, "")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("generateValuesFile() error = %v, expected it to contain %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateValuesFile() returned error: %v", err)
+			}
+			data, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read values file: %v", err)
+			}
+			if string(data) != tc.expected {
+				t.Errorf("generateValuesFile() mismatch\nwant:\n%s\ngot:\n%s", tc.expected, data)
+			}
+		})
+	}
+}
+
+func TestOverrideValues(t *testing.T) {
+	t.Parallel()
+
+	global := map[string]interface{}{
+		"operator.logs": "info",
+		"resources": map[interface{}]interface{}{
+			"limits": map[interface{}]interface{}{"cpu": "500m", "memory": "512Mi"},
+		},
+	}
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+		expected  string
+	}{
+		{
+			name:     "Cluster without overrides gets the global values",
+			expected: "operator:\n  logs: info\nresources:\n  limits:\n    cpu: 500m\n    memory: 512Mi\n",
+		},
+		{
+			name:      "Cluster overriding a scalar",
+			overrides: map[string]interface{}{"operator.logs": "debug"},
+			expected:  "operator:\n  logs: debug\nresources:\n  limits:\n    cpu: 500m\n    memory: 512Mi\n",
+		},
+		{
+			name: "Cluster overriding a nested map also set globally",
+			overrides: map[string]interface{}{
+				"resources": map[interface{}]interface{}{
+					"limits": map[interface{}]interface{}{"cpu": "2"},
+				},
+			},
+			expected: "operator:\n  logs: info\nresources:\n  limits:\n    cpu: \"2\"\n    memory: 512Mi\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yaml.Marshal(expandValues(overrideValues(global, tc.overrides)))
+			if err != nil {
+				t.Fatalf("Failed to encode values: %v", err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("overrideValues() mismatch\nwant:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+	limits := global["resources"].(map[interface{}]interface{})["limits"].(map[interface{}]interface{})
+	if limits["cpu"] != "500m" {
+		t.Errorf("overrideValues() modified the global values: %v", global)
+	}
+}
+
+func TestGenerateValuesFilePropagatesImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	pullSecrets := generateImagePullSecretsValue(ImagePullSecretList{{Username: "user", Password: "pass"}})
+	tests := []struct {
+		name     string
+		chart    HelmChart
+		defaults string
+		expected string
+	}{
+		{
+			name:     "Injected under the default key",
+			chart:    HelmChart{ChartName: "prometheus"},
+			expected: "imagePullSecrets:\n  repository: https://index.docker.io/v1/\n  username: user\n",
+		},
+		{
+			name:     "Injected under a configured key",
+			chart:    HelmChart{ChartName: "cert-manager", ImagePullSecretsKey: "global.imagePullSecrets"},
+			defaults: certManagerValues,
+			expected: "global:\n  imagePullSecrets:\n    repository: https://index.docker.io/v1/\n    username: user\ninstallCRDs: true\n",
+		},
+		{
+			name:     "Charts can opt out",
+			chart:    HelmChart{ChartName: "cert-manager", SkipImagePullSecrets: true},
+			defaults: certManagerValues,
+			expected: "installCRDs: true\n",
+		},
+		{
+			name: "Pull secrets set in the topology are kept",
+			chart: HelmChart{
+				ChartName:  "prometheus",
+				ValuesYAML: "imagePullSecrets:\n- name: mirror-credentials\n",
+			},
+			expected: "imagePullSecrets:\n- name: mirror-credentials\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "kubeslice-test-values")
+			if err != nil {
+				t.Fatalf("Failed to create test directory: %v", err)
+			}
+			t.Cleanup(func() {
+				os.RemoveAll(dir)
+			})
+			filePath := filepath.Join(dir, "values.yaml")
+			if err := generateValuesFile(filePath, &tc.chart, tc.defaults, pullSecrets); err != nil {
+				t.Fatalf("generateValuesFile() returned error: %v", err)
+			}
+			data, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read values file: %v", err)
+			}
+			if string(data) != tc.expected {
+				t.Errorf("generateValuesFile() mismatch\nwant:\n%s\ngot:\n%s", tc.expected, data)
+			}
+		})
+	}
+}
+
+func TestMergeMapsMixedMapTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		dest     string // parsed with yaml.v2
+		src      string // parsed with encoding/json
+		expected string
+	}{
+		{
+			name:     "Top level keys of a JSON document",
+			dest:     "a: 1\nb: 2\n",
+			src:      `{"b": 3}`,
+			expected: "a: 1\nb: 3\n",
+		},
+		{
+			name:     "Nested maps are merged instead of replaced",
+			dest:     "operator:\n  logs: info\n  resources:\n    limits:\n      cpu: 500m\n      memory: 512Mi\n",
+			src:      `{"operator": {"resources": {"limits": {"cpu": "2"}}}}`,
+			expected: "operator:\n  logs: info\n  resources:\n    limits:\n      cpu: \"2\"\n      memory: 512Mi\n",
+		},
+		{
+			name:     "Maps inside lists are normalized",
+			dest:     "tolerations:\n- key: a\n",
+			src:      `{"tolerations": [{"key": "b", "effect": {"type": "NoSchedule"}}]}`,
+			expected: "tolerations:\n- effect:\n    type: NoSchedule\n  key: b\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dest := make(map[interface{}]interface{})
+			if err := yaml.Unmarshal([]byte(tc.dest), &dest); err != nil {
+				t.Fatalf("Failed to parse dest: %v", err)
+			}
+			jsonSrc := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(tc.src), &jsonSrc); err != nil {
+				t.Fatalf("Failed to parse src: %v", err)
+			}
+			src := make(map[interface{}]interface{})
+			for k, v := range jsonSrc {
+				src[k] = v
+			}
+
+			merged := mergeMaps(dest, src)
+			assertNormalized(t, merged)
+			got, err := yaml.Marshal(merged)
+			if err != nil {
+				t.Fatalf("Failed to encode merged values: %v", err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("mergeMaps() mismatch\nwant:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func assertNormalized(t *testing.T, value interface{}) {
+	t.Helper()
+	switch v := value.(type) {
+	case map[string]interface{}:
+		t.Errorf("mergeMaps() left a %v in the merged values: %v", reflect.TypeOf(v), v)
+	case map[interface{}]interface{}:
+		for _, item := range v {
+			assertNormalized(t, item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			assertNormalized(t, item)
+		}
+	}
+}
+
+func TestGenerateControllerValuesFilePropagatesImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	config := Configuration{HelmChartConfiguration: HelmChartConfiguration{
+		ControllerChart:           HelmChart{ChartName: "kubeslice-controller"},
+		ImagePullSecrets:          ImagePullSecretList{{SecretName: "regcred"}},
+		PropagateImagePullSecrets: true,
+	}}
+	filePath := filepath.Join(t.TempDir(), "values.yaml")
+	generateControllerValuesFile(Cluster{Name: "controller", ControlPlaneAddress: "https://172.18.0.2:6443"}, config, filePath)
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read values file: %v", err)
+	}
+	for _, expected := range []string{"imagePullSecrets:\n- name: regcred\n", "imagePullSecretsName: regcred\n"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("generateControllerValuesFile() wrote\n%s\nwant %q", data, expected)
+		}
+	}
+}
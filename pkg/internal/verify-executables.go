@@ -11,31 +11,74 @@ import (
 	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-func VerifyExecutables(ApplicationConfiguration *ConfigurationSpecs) {
+// executableRequirement tells whether a run needs an executable, and why it
+// is skipped when it does not.
+type executableRequirement struct {
+	name     string
+	required bool
+	reason   string
+}
+
+// kindTopology tells whether the clusters of the topology are kind clusters,
+// those of a demo profile or of cluster_type kind.
+func kindTopology(cc ClusterConfiguration) bool {
+	return cc.Profile != "" || cc.ClusterType == Kind_Component
+}
+
+// requiredExecutables returns the executables of a run: kubectl and helm are
+// always required, kind and its container runtime, docker unless
+// KIND_EXPERIMENTAL_PROVIDER selects podman or nerdctl, only when the run
+// creates or deletes kind clusters. The addresses of existing kind clusters are read with kubectl
+// when docker is missing.
+func requiredExecutables(cc ClusterConfiguration, kindClusters bool) []executableRequirement {
+	reason := ""
+	switch {
+	case kindClusters:
+	case kindTopology(cc):
+		reason = "no kind clusters are created or deleted"
+	case cc.ClusterType != "":
+		reason = fmt.Sprintf("the topology uses existing %s clusters", cc.ClusterType)
+	default:
+		reason = "the topology uses existing clusters"
+	}
+	return []executableRequirement{
+		{name: "kubectl", required: true},
+		{name: "helm", required: true},
+		{name: "kind", required: kindClusters, reason: reason},
+		{name: kindRuntime(), required: kindClusters, reason: reason},
+	}
+}
+
+// VerifyExecutables verifies the executables the run needs, kind and its
+// container runtime only when kindClusters tells that it creates or deletes kind clusters.
+func VerifyExecutables(ApplicationConfiguration *ConfigurationSpecs, kindClusters bool) {
 	util.Printf("Verifying Executables...")
 	time.Sleep(200 * time.Millisecond)
-	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" || ApplicationConfiguration.Configuration.ClusterConfiguration.ClusterType == "kind" {
-		util.ExecutablePaths = map[string]string{
-			"kind":    "kind",
-			"kubectl": "kubectl",
-			"docker":  "docker",
-			"helm":    "helm",
-		}
-	} else {
-		util.ExecutablePaths = map[string]string{
-			"kubectl": "kubectl",
-			"helm":    "helm",
-		}
-	}
-	for key := range util.ExecutablePaths {
+	util.ExecutablePaths = make(map[string]string)
+	for _, executable := range requiredExecutables(ApplicationConfiguration.Configuration.ClusterConfiguration, kindClusters) {
 		time.Sleep(200 * time.Millisecond)
-		verificationResult(verifyBinary(key), key)
+		if !executable.required {
+			util.Printf("%s %s not required, %s", util.Tick, executable.name, executable.reason)
+			continue
+		}
+		util.ExecutablePaths[executable.name] = executable.name
+		verificationResult(verifyBinary(executable.name), executable.name)
 	}
 
 	time.Sleep(200 * time.Millisecond)
 	util.Printf("All required executables were found\n")
 }
 
+// verifyExecutable verifies a single executable a command needs, keeping the
+// executables which are verified already.
+func verifyExecutable(name string) {
+	if util.ExecutablePaths == nil {
+		util.ExecutablePaths = make(map[string]string)
+	}
+	util.ExecutablePaths[name] = name
+	verificationResult(verifyBinary(name), name)
+}
+
 func verifyBinary(name string) int {
 	return _verifyBinary(name, strings.ToUpper(name)+"_PATH", util.ExecutableVerifyCommands[name])
 }
@@ -66,6 +109,8 @@ func executableDownloadMessage(executable string) string {
 		return helmExecutableMessage[fmt.Sprintf("%s", runtime.GOOS)]
 	case "docker":
 		return dockerExecutableMessage[fmt.Sprintf("%s", runtime.GOOS)]
+	case "podman", "nerdctl":
+		return fmt.Sprintf("\nKIND_EXPERIMENTAL_PROVIDER selects %s as the container runtime of kind, please install it or unset KIND_EXPERIMENTAL_PROVIDER\n", executable)
 	}
 	return ""
 }
@@ -76,7 +121,7 @@ func verificationResult(num int, cli string) {
 		util.Printf("%s %s found", util.Tick, cli)
 	case 1:
 		util.Printf("%s %s not found on path", util.Cross, cli)
-		util.Fatalf(executableDownloadMessage(cli))
+		util.Fatalf("%s", executableDownloadMessage(cli))
 	case 2:
 		util.Fatalf("%s %s is not executable", util.Cross, cli)
 	}
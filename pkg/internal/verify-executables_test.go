@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestRequiredExecutables(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		cc           ClusterConfiguration
+		kindClusters bool
+		required     []string
+		reason       string
+	}{
+		{
+			name:         "Demo profile creating kind clusters",
+			cc:           ClusterConfiguration{Profile: ProfileFullDemo},
+			kindClusters: true,
+			required:     []string{"kubectl", "helm", "kind", "docker"},
+		},
+		{
+			name:     "Demo profile with the kind clusters created",
+			cc:       ClusterConfiguration{Profile: ProfileFullDemo},
+			required: []string{"kubectl", "helm"},
+			reason:   "no kind clusters are created or deleted",
+		},
+		{
+			name:     "Existing kind clusters",
+			cc:       ClusterConfiguration{ClusterType: Kind_Component},
+			required: []string{"kubectl", "helm"},
+			reason:   "no kind clusters are created or deleted",
+		},
+		{
+			name:     "Cloud clusters",
+			cc:       ClusterConfiguration{ClusterType: "cloud"},
+			required: []string{"kubectl", "helm"},
+			reason:   "the topology uses existing cloud clusters",
+		},
+		{
+			name:     "Data center clusters",
+			cc:       ClusterConfiguration{ClusterType: "data-center"},
+			required: []string{"kubectl", "helm"},
+			reason:   "the topology uses existing data-center clusters",
+		},
+		{
+			name:     "Clusters without a type",
+			required: []string{"kubectl", "helm"},
+			reason:   "the topology uses existing clusters",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			required := make([]string, 0)
+			for _, executable := range requiredExecutables(tc.cc, tc.kindClusters) {
+				if executable.required {
+					required = append(required, executable.name)
+					continue
+				}
+				if executable.reason != tc.reason {
+					t.Errorf("requiredExecutables() skips %s because %q, want %q", executable.name, executable.reason, tc.reason)
+				}
+			}
+			if !reflect.DeepEqual(required, tc.required) {
+				t.Errorf("requiredExecutables() = %q, want %q", required, tc.required)
+			}
+		})
+	}
+}
+
+func TestRequiredExecutablesOfTheKindProvider(t *testing.T) {
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "podman")
+	required := make([]string, 0)
+	for _, executable := range requiredExecutables(ClusterConfiguration{Profile: ProfileFullDemo}, true) {
+		required = append(required, executable.name)
+	}
+	if expected := []string{"kubectl", "helm", "kind", "podman"}; !reflect.DeepEqual(required, expected) {
+		t.Errorf("requiredExecutables() = %q, want %q", required, expected)
+	}
+}
+
+func TestVerifyExecutableKeepsVerifiedExecutables(t *testing.T) {
+	defer func(paths map[string]string) { util.ExecutablePaths = paths }(util.ExecutablePaths)
+	t.Setenv("HELM_PATH", "true")
+	util.ExecutablePaths = map[string]string{"kubectl": "/usr/local/bin/kubectl"}
+
+	verifyExecutable("helm")
+
+	if util.ExecutablePaths["kubectl"] != "/usr/local/bin/kubectl" {
+		t.Errorf("kubectl path = %q, want it kept", util.ExecutablePaths["kubectl"])
+	}
+	if util.ExecutablePaths["helm"] == "" || util.ExecutablePaths["helm"] == "helm" {
+		t.Errorf("helm path = %q, want the path found", util.ExecutablePaths["helm"])
+	}
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// The commit and build date of kubeslice-cli, set with -ldflags at build time.
+var (
+	CLICommit    = "unknown"
+	CLIBuildDate = "unknown"
+)
+
+// unknownVersion is reported for the components of unreachable clusters.
+const unknownVersion = "unknown"
+
+// versionComponents are the components whose deployed versions version
+// --remote reports.
+var versionComponents = []string{Controller_Component, Worker_Component, CertManager_Component}
+
+// versionReport is the output of the version command.
+type versionReport struct {
+	CLI        cliVersion         `json:"cli"`
+	Components []componentVersion `json:"components,omitempty"`
+	Mismatches []string           `json:"mismatches,omitempty"`
+}
+
+type cliVersion struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// componentVersion is the chart version and the images of a component
+// deployed on a cluster.
+type componentVersion struct {
+	Component string   `json:"component"`
+	Cluster   string   `json:"cluster"`
+	Version   string   `json:"version"`
+	Pinned    string   `json:"pinned,omitempty"`
+	Images    []string `json:"images,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ShowVersion prints the version of kubeslice-cli and, with the topology, the
+// chart versions and images of the components deployed on its clusters. The
+// components of a cluster which cannot be queried are reported as unknown.
+func ShowVersion(specs *ConfigurationSpecs, outputFormat string) {
+	report := versionReport{CLI: cliVersion{
+		Version:   CLIVersion,
+		Commit:    CLICommit,
+		BuildDate: CLIBuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}}
+	if specs != nil {
+		report.Components = deployedComponentVersions(specs)
+		report.Mismatches = versionMismatches(report.Components)
+	}
+	if outputFormat == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		util.Printf("%s", out)
+		return
+	}
+	util.Printf("kubeslice-cli version %s, commit %s, built %s with %s for %s", report.CLI.Version, report.CLI.Commit, report.CLI.BuildDate, report.CLI.GoVersion, report.CLI.Platform)
+	if specs == nil {
+		return
+	}
+	util.Printf("\n%s", componentVersionsTable(report.Components))
+	for _, v := range report.Components {
+		if v.Error != "" {
+			util.Printf("%s %s on %s: %s", util.Warn, v.Component, v.Cluster, v.Error)
+		}
+	}
+	for _, mismatch := range report.Mismatches {
+		util.Printf("%s %s", util.Warn, mismatch)
+	}
+}
+
+// deployedComponentVersions queries the releases and the images of the
+// components with a chart in the topology on their clusters.
+func deployedComponentVersions(specs *ConfigurationSpecs) []componentVersion {
+	releases := componentReleases(specs)
+	versions := make([]componentVersion, 0)
+	for _, component := range versionComponents {
+		cr := releases[component]
+		if cr.chart.ChartName == "" {
+			continue
+		}
+		// the version of the topology, the charts are not resolved here
+		pinned := exactChartVersion(cr.chart.Version)
+		for _, cluster := range cr.clusters {
+			versions = append(versions, deployedComponentVersion(component, cluster, cr, pinned))
+		}
+	}
+	return versions
+}
+
+func deployedComponentVersion(component string, cluster Cluster, cr componentRelease, pinned string) componentVersion {
+	version := componentVersion{Component: component, Cluster: cluster.Name, Version: unknownVersion, Pinned: pinned}
+	namespace := cr.namespace(cluster)
+	deployed, err := chartInstaller.List(cluster, namespace, cr.release)
+	if err != nil {
+		version.Error = err.Error()
+		return version
+	}
+	if len(deployed) == 0 {
+		version.Version = "not installed"
+		return version
+	}
+	version.Version = deployed[0].chartVersion(cr.chart.ChartName)
+	out, err := runKubectl(cluster, "get", "pods", "-n", namespace, "-o", "jsonpath={.items[*].spec.containers[*].image}")
+	if err != nil {
+		version.Error = fmt.Sprintf("unable to list the images of namespace %s: %v", namespace, err)
+		version.Images = []string{unknownVersion}
+		return version
+	}
+	for _, image := range strings.Fields(out) {
+		version.Images = appendUnique(version.Images, image)
+	}
+	sort.Strings(version.Images)
+	return version
+}
+
+// versionMismatches reports the components deployed in different versions
+// on their clusters, and the deployed versions which are not those the
+// topology pins. Unknown versions are left out.
+func versionMismatches(versions []componentVersion) []string {
+	mismatches := make([]string, 0)
+	byComponent := make(map[string][]componentVersion)
+	order := make([]string, 0)
+	for _, v := range versions {
+		if v.Version == unknownVersion || v.Version == "not installed" {
+			continue
+		}
+		if _, found := byComponent[v.Component]; !found {
+			order = append(order, v.Component)
+		}
+		byComponent[v.Component] = append(byComponent[v.Component], v)
+	}
+	for _, component := range order {
+		deployed := byComponent[component]
+		clusters := make([]string, 0, len(deployed))
+		differ := false
+		for _, v := range deployed {
+			clusters = append(clusters, v.Cluster+" "+v.Version)
+			differ = differ || v.Version != deployed[0].Version
+		}
+		if differ {
+			mismatches = append(mismatches, fmt.Sprintf("%s runs different chart versions on its clusters: %s", component, strings.Join(clusters, ", ")))
+		}
+		for _, v := range deployed {
+			if v.Pinned != "" && compareVersions(v.Version, v.Pinned) != 0 {
+				mismatches = append(mismatches, fmt.Sprintf("%s on %s runs chart version %s, the topology pins %s", component, v.Cluster, v.Version, v.Pinned))
+			}
+		}
+	}
+	return mismatches
+}
+
+// componentVersionsTable formats the deployed versions of the components.
+func componentVersionsTable(versions []componentVersion) string {
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tCLUSTER\tVERSION\tPINNED\tIMAGES")
+	for _, v := range versions {
+		pinned := v.Pinned
+		if pinned == "" {
+			pinned = "-"
+		}
+		images := strings.Join(v.Images, ",")
+		if images == "" {
+			images = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Component, v.Cluster, v.Version, pinned, images)
+	}
+	w.Flush()
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestDeployedComponentVersions(t *testing.T) {
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		ClusterConfiguration: ClusterConfiguration{
+			ControllerCluster: Cluster{Name: "ks-ctrl", ContextName: "ks-ctrl", KubeConfigPath: "kubeconfig.yaml"},
+			WorkerClusters: []Cluster{
+				{Name: "ks-w-1", ContextName: "ks-w-1", KubeConfigPath: "kubeconfig.yaml"},
+				{Name: "ks-w-2", ContextName: "ks-w-2", KubeConfigPath: "kubeconfig.yaml"},
+				{Name: "ks-w-3", ContextName: "ks-w-3", KubeConfigPath: "kubeconfig.yaml"},
+			},
+		},
+		HelmChartConfiguration: HelmChartConfiguration{
+			ControllerChart: HelmChart{ChartName: "kubeslice-controller", Version: "1.1.0"},
+			WorkerChart:     HelmChart{ChartName: "kubeslice-worker", Version: "~1.1"},
+		},
+	}}
+	// ks-w-3 is unreachable
+	deployed := map[string]string{"ks-ctrl": "kubeslice-controller-1.0.0", "ks-w-1": "kubeslice-worker-1.1.0", "ks-w-2": "kubeslice-worker-1.1.1"}
+	mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		if command[2] == "ks-w-3" {
+			fmt.Fprint(stderr, "Kubernetes cluster unreachable")
+			return errors.New("exit status 1")
+		}
+		switch line := strings.Join(command, " "); {
+		case command[0] == "helm":
+			chart := deployed[command[2]]
+			release := chart[:strings.LastIndex(chart, "-")]
+			fmt.Fprintf(stdout, `[{"name": %q, "namespace": %q, "revision": "1", "status": "deployed", "chart": %q}]`, release, release, chart)
+		case strings.HasSuffix(line, "jsonpath={.items[*].spec.containers[*].image}"):
+			fmt.Fprint(stdout, "aveshasystems/worker-operator:1.1.0 aveshasystems/kubeslice-router-sidecar:1.4.0 aveshasystems/worker-operator:1.1.0")
+		}
+		return nil
+	}}
+	defer util.UseExecutor(mock)()
+
+	versions := deployedComponentVersions(specs)
+	got := componentVersionsTable(versions)
+	expected := `COMPONENT    CLUSTER   VERSION   PINNED   IMAGES
+controller   ks-ctrl   1.0.0     1.1.0    aveshasystems/kubeslice-router-sidecar:1.4.0,aveshasystems/worker-operator:1.1.0
+worker       ks-w-1    1.1.0     -        aveshasystems/kubeslice-router-sidecar:1.4.0,aveshasystems/worker-operator:1.1.0
+worker       ks-w-2    1.1.1     -        aveshasystems/kubeslice-router-sidecar:1.4.0,aveshasystems/worker-operator:1.1.0
+worker       ks-w-3    unknown   -        -`
+	if got != expected {
+		t.Errorf("componentVersionsTable() =\n%s\nwant\n%s", got, expected)
+	}
+	if !strings.Contains(versions[3].Error, "Kubernetes cluster unreachable") {
+		t.Errorf("error of ks-w-3 = %q", versions[3].Error)
+	}
+	mismatches := []string{
+		"controller on ks-ctrl runs chart version 1.0.0, the topology pins 1.1.0",
+		"worker runs different chart versions on its clusters: ks-w-1 1.1.0, ks-w-2 1.1.1",
+	}
+	if got := versionMismatches(versions); !reflect.DeepEqual(got, mismatches) {
+		t.Errorf("versionMismatches() =\n%q\nwant\n%q", got, mismatches)
+	}
+}
@@ -0,0 +1,265 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// How often and how long the deregistration of a worker waits for it to be
+// offboarded from the slices and for its Cluster to be finalized.
+var (
+	deregisterPollInterval = 5 * time.Second
+	deregisterTimeout      = 10 * time.Minute
+)
+
+// sliceClusters are the fields of a SliceConfig which name its clusters.
+type sliceClusters struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Clusters                  []string `json:"clusters"`
+		NamespaceIsolationProfile struct {
+			ApplicationNamespaces []sliceNamespace `json:"applicationNamespaces"`
+			AllowedNamespaces     []sliceNamespace `json:"allowedNamespaces"`
+		} `json:"namespaceIsolationProfile"`
+	} `json:"spec"`
+}
+
+// sliceDetachPatch returns the merge patch of a SliceConfig removing a
+// cluster from the slice and from the namespaces of the slice naming it. A
+// namespace left on no cluster is removed.
+func sliceDetachPatch(slice sliceClusters, cluster string) (string, error) {
+	spec := map[string]interface{}{"clusters": removeString(slice.Spec.Clusters, cluster)}
+	profile := make(map[string]interface{})
+	for field, namespaces := range map[string][]sliceNamespace{
+		"applicationNamespaces": slice.Spec.NamespaceIsolationProfile.ApplicationNamespaces,
+		"allowedNamespaces":     slice.Spec.NamespaceIsolationProfile.AllowedNamespaces,
+	} {
+		detached, changed := detachNamespaces(namespaces, cluster)
+		if changed {
+			profile[field] = detached
+		}
+	}
+	if len(profile) > 0 {
+		spec["namespaceIsolationProfile"] = profile
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": spec})
+	return string(patch), err
+}
+
+// detachNamespaces removes a cluster from the clusters of the namespaces of a
+// slice, and tells whether any of them named it.
+func detachNamespaces(namespaces []sliceNamespace, cluster string) ([]map[string]interface{}, bool) {
+	detached := make([]map[string]interface{}, 0, len(namespaces))
+	changed := false
+	for _, ns := range namespaces {
+		clusters := removeString(ns.Clusters, cluster)
+		if len(clusters) != len(ns.Clusters) {
+			changed = true
+			if len(clusters) == 0 {
+				continue
+			}
+		}
+		detached = append(detached, map[string]interface{}{"namespace": ns.Namespace, "clusters": clusters})
+	}
+	return detached, changed
+}
+
+func removeString(list []string, s string) []string {
+	removed := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != s {
+			removed = append(removed, item)
+		}
+	}
+	return removed
+}
+
+// workerSlices returns the SliceConfigs of the project namespace the worker
+// participates in.
+func workerSlices(controller *Cluster, namespace, worker string) ([]sliceClusters, error) {
+	names, err := listResourceNames(SliceConfigObject, namespace, controller)
+	if err != nil {
+		return nil, err
+	}
+	slices := make([]sliceClusters, 0)
+	for _, name := range names {
+		object, err := kubeClient.Get(controller, SliceConfigObject, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get SliceConfig %s: %v", name, err)
+		}
+		var slice sliceClusters
+		if err := decodeObject(object, &slice); err != nil {
+			return nil, fmt.Errorf("unable to decode SliceConfig %s: %v", name, err)
+		}
+		if containsString(slice.Spec.Clusters, worker) {
+			slices = append(slices, slice)
+		}
+	}
+	return slices, nil
+}
+
+// detachWorkerFromSlices removes the worker from its slices and waits until
+// the controller offboarded it from them, i.e. deleted its WorkerSliceConfigs.
+func detachWorkerFromSlices(controller *Cluster, namespace, worker string, slices []sliceClusters) error {
+	for _, slice := range slices {
+		patch, err := sliceDetachPatch(slice, worker)
+		if err != nil {
+			return err
+		}
+		if _, err := runKubectl(*controller, "patch", SliceConfigObject, slice.Metadata.Name, "-n", namespace, "--type", "merge", "-p", patch); err != nil {
+			return fmt.Errorf("unable to remove worker %s from SliceConfig %s: %v", worker, slice.Metadata.Name, err)
+		}
+		util.Printf("%s Removed worker %s from slice %s", util.Tick, worker, slice.Metadata.Name)
+	}
+	err := PollUntil(deregisterPollInterval, deregisterTimeout, func(elapsed time.Duration) (bool, error) {
+		names, err := listResourceNames(workerSliceConfigObject, namespace, controller)
+		if err != nil {
+			return false, err
+		}
+		remaining := make([]string, 0)
+		for _, slice := range slices {
+			if containsString(names, slice.Metadata.Name+"-"+worker) {
+				remaining = append(remaining, slice.Metadata.Name)
+			}
+		}
+		if len(remaining) == 0 {
+			return true, nil
+		}
+		util.Printf("%s Waiting for worker %s to be offboarded from %s... %d seconds elapsed", util.Wait, worker, strings.Join(remaining, ", "), int(elapsed.Seconds()))
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("worker %s was not offboarded from its slices: %v", worker, err)
+	}
+	util.Printf("%s Worker %s offboarded from its slices", util.Tick, worker)
+	return nil
+}
+
+// uninstallDeregisteredWorker uninstalls the worker chart from a worker,
+// nothing is done when it is not installed.
+func uninstallDeregisteredWorker(cluster Cluster) error {
+	if _, err := runKubectl(cluster, "--request-timeout", "10s", "get", "--raw", "/readyz"); err != nil {
+		return fmt.Errorf("cluster %s is not reachable with context %s: %v", cluster.Name, cluster.ContextName, err)
+	}
+	release, err := findRelease(cluster, "kubeslice-worker", WorkerNamespace(cluster))
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		util.Printf("%s KubeSlice Worker is not installed on %s", util.Tick, cluster.Name)
+		return nil
+	}
+	if err := chartInstaller.Uninstall(cluster, "kubeslice-worker", WorkerNamespace(cluster)); err != nil {
+		return fmt.Errorf("unable to uninstall KubeSlice Worker from %s: %v", cluster.Name, err)
+	}
+	util.Printf("%s Uninstalled KubeSlice Worker from %s", util.Tick, cluster.Name)
+	return nil
+}
+
+// deleteWorkerRegistration deletes the Cluster of the worker on the
+// controller and waits until its finalizers ran.
+func deleteWorkerRegistration(controller *Cluster, namespace, worker string) error {
+	registered, err := objectExists(controller, ClusterObject, namespace, worker)
+	if err != nil {
+		return fmt.Errorf("unable to look up worker %s on controller cluster %s: %v", worker, controller.Name, err)
+	}
+	if !registered {
+		util.Printf("%s Worker %s is not registered in namespace %s", util.Tick, worker, namespace)
+		return nil
+	}
+	if err := deleteResource(ClusterObject, worker, namespace, controller); err != nil {
+		return err
+	}
+	err = PollUntil(deregisterPollInterval, deregisterTimeout, func(elapsed time.Duration) (bool, error) {
+		exists, err := objectExists(controller, ClusterObject, namespace, worker)
+		if err != nil || !exists {
+			return !exists, err
+		}
+		util.Printf("%s Waiting for the finalizers of worker %s... %d seconds elapsed", util.Wait, worker, int(elapsed.Seconds()))
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("the Cluster of worker %s was not finalized: %v", worker, err)
+	}
+	util.Printf("%s Deleted the registration of worker %s", util.Tick, worker)
+	return nil
+}
+
+// DeregisterWorkerCluster removes a worker from an installation: it is
+// offboarded from its slices, which is refused unless detach is set, the
+// worker chart is uninstalled from it, its Cluster is deleted on the
+// controller and it is removed from the state of the installation. With
+// force, the worker cluster is not reached, for a worker which is gone.
+func DeregisterWorkerCluster(specs *ConfigurationSpecs, name, namespace string, detach, force bool) {
+	util.Printf("\nDeregistering worker %s...", name)
+	controller := &specs.Configuration.ClusterConfiguration.ControllerCluster
+	slices, err := workerSlices(controller, namespace, name)
+	if err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if len(slices) > 0 && !detach {
+		names := make([]string, 0, len(slices))
+		for _, slice := range slices {
+			names = append(names, slice.Metadata.Name)
+		}
+		util.Fatalf("%s Worker %s participates in the slices %s, pass --detach-from-slices to remove it from them", util.Cross, name, strings.Join(names, ", "))
+	}
+	var worker *Cluster
+	for i, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+		if cluster.Name == name {
+			worker = &specs.Configuration.ClusterConfiguration.WorkerClusters[i]
+		}
+	}
+	if worker == nil && !force {
+		util.Fatalf("%s Worker %s is not in the topology, pass --force to deregister it without uninstalling the worker chart", util.Cross, name)
+	}
+
+	if len(slices) > 0 {
+		if err := detachWorkerFromSlices(controller, namespace, name, slices); err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+	}
+	if force {
+		util.Printf("%s Skipping the worker cluster %s, the worker chart and its resources are left on it", util.Warn, name)
+	} else if err := uninstallDeregisteredWorker(*worker); err != nil {
+		util.Fatalf("%s %v, pass --force to deregister it without uninstalling the worker chart", util.Cross, err)
+	}
+	if err := deleteWorkerRegistration(controller, namespace, name); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	recordDeregisteredWorker(specs, name)
+	util.Printf("%s Worker %s deregistered from installation %s", util.Tick, name, InstallationName(specs))
+}
+
+// recordDeregisteredWorker removes a worker and its releases from the state
+// of the installation.
+func recordDeregisteredWorker(specs *ConfigurationSpecs, worker string) {
+	name := InstallationName(specs)
+	err := updateInstallationState(name, func(state *InstallationState) {
+		state.RegisteredWorkers = removeString(state.RegisteredWorkers, worker)
+		releases := make([]installedRelease, 0, len(state.Releases))
+		for _, r := range state.Releases {
+			if r.Cluster != worker {
+				releases = append(releases, r)
+			}
+		}
+		state.Releases = releases
+		cc := &state.Topology.Configuration.ClusterConfiguration
+		workers := make([]Cluster, 0, len(cc.WorkerClusters))
+		for _, cluster := range cc.WorkerClusters {
+			if cluster.Name != worker {
+				workers = append(workers, cluster)
+			}
+		}
+		cc.WorkerClusters = workers
+	})
+	if err != nil {
+		util.Printf("%s Unable to remove worker %s from the state of installation %s: %v", util.Warn, worker, name, err)
+	}
+}
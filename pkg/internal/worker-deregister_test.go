@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSliceDetachPatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		clusters   []string
+		namespaces []sliceNamespace
+		expected   string
+	}{
+		{
+			name:     "Namespaces on every cluster",
+			clusters: []string{"ks-w-1", "ks-w-2", "ks-w-3"},
+			namespaces: []sliceNamespace{
+				{Namespace: "iperf", Clusters: []string{"*"}},
+			},
+			expected: `{"spec":{"clusters":["ks-w-1","ks-w-3"]}}`,
+		},
+		{
+			name:     "Namespaces naming the cluster",
+			clusters: []string{"ks-w-1", "ks-w-2", "ks-w-3"},
+			namespaces: []sliceNamespace{
+				{Namespace: "iperf", Clusters: []string{"ks-w-1", "ks-w-2"}},
+				{Namespace: "bookinfo", Clusters: []string{"ks-w-2"}},
+				{Namespace: "shared", Clusters: []string{"*"}},
+			},
+			expected: `{"spec":{"clusters":["ks-w-1","ks-w-3"],"namespaceIsolationProfile":{"applicationNamespaces":[{"clusters":["ks-w-1"],"namespace":"iperf"},{"clusters":["*"],"namespace":"shared"}]}}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var slice sliceClusters
+			slice.Spec.Clusters = tc.clusters
+			slice.Spec.NamespaceIsolationProfile.ApplicationNamespaces = tc.namespaces
+			got, err := sliceDetachPatch(slice, "ks-w-2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expected {
+				t.Errorf("sliceDetachPatch() = %s, want %s", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRecordDeregisteredWorker(t *testing.T) {
+	defer func(dir string) { installationStateDirectory = dir }(installationStateDirectory)
+	installationStateDirectory = t.TempDir()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{ControllerCluster: Cluster{Name: "ks-ctrl"}}}}
+	if err := updateInstallationState("ks-ctrl", func(state *InstallationState) {
+		state.RegisteredWorkers = []string{"ks-w-1", "ks-w-2"}
+		state.Releases = []installedRelease{
+			{Component: Controller_Component, Cluster: "ks-ctrl", Release: "kubeslice-controller"},
+			{Component: Worker_Component, Cluster: "ks-w-1", Release: "kubeslice-worker"},
+			{Component: Worker_Component, Cluster: "ks-w-2", Release: "kubeslice-worker"},
+		}
+		state.Topology.Configuration.ClusterConfiguration.WorkerClusters = []Cluster{{Name: "ks-w-1"}, {Name: "ks-w-2"}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	recordDeregisteredWorker(specs, "ks-w-2")
+	state, err := loadInstallationState("ks-ctrl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"ks-w-1"}; !reflect.DeepEqual(state.RegisteredWorkers, expected) {
+		t.Errorf("RegisteredWorkers = %q, want %q", state.RegisteredWorkers, expected)
+	}
+	releases := make([]string, 0)
+	for _, r := range state.Releases {
+		releases = append(releases, r.Cluster+"/"+r.Release)
+	}
+	if expected := []string{"ks-ctrl/kubeslice-controller", "ks-w-1/kubeslice-worker"}; !reflect.DeepEqual(releases, expected) {
+		t.Errorf("Releases = %q, want %q", releases, expected)
+	}
+	if got, expected := ClusterNames(&state.Topology, true), []string{"ks-w-1"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("topology workers = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// joinedTopology returns the topology with the worker joining it, replacing
+// the worker of the same name. The workers of the topology are not changed.
+func joinedTopology(specs *ConfigurationSpecs, worker Cluster) *ConfigurationSpecs {
+	joined := *specs
+	workers := make([]Cluster, 0, len(specs.Configuration.ClusterConfiguration.WorkerClusters)+1)
+	found := false
+	for _, cluster := range specs.Configuration.ClusterConfiguration.WorkerClusters {
+		if cluster.Name == worker.Name {
+			cluster, found = worker, true
+		}
+		workers = append(workers, cluster)
+	}
+	if !found {
+		workers = append(workers, worker)
+	}
+	joined.Configuration.ClusterConfiguration.WorkerClusters = workers
+	return &joined
+}
+
+// verifyWorkerNameAvailable checks that no worker of the name is registered
+// with the controller, or recorded as registered by the installation.
+func verifyWorkerNameAvailable(specs *ConfigurationSpecs, worker Cluster) error {
+	controller := specs.Configuration.ClusterConfiguration.ControllerCluster
+	namespace := projectNamespace(workerProject(specs.Configuration.KubeSliceConfiguration, worker))
+	guidance := fmt.Sprintf("pick another --name, or deregister it first with kubeslice-cli deregister worker %s -n %s", worker.Name, namespace)
+	state, err := loadInstallationState(InstallationName(specs))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if state != nil && containsString(state.RegisteredWorkers, worker.Name) {
+		return fmt.Errorf("worker %s is registered already by installation %s, %s", worker.Name, state.Name, guidance)
+	}
+	registered, err := objectExists(&controller, ClusterObject, namespace, worker.Name)
+	if err != nil {
+		return fmt.Errorf("unable to look up worker %s on controller cluster %s: %v", worker.Name, controller.Name, err)
+	}
+	if registered {
+		return fmt.Errorf("worker %s is registered already in namespace %s of controller cluster %s, %s", worker.Name, namespace, controller.Name, guidance)
+	}
+	return nil
+}
+
+// JoinWorkerCluster registers a worker with the controller of an existing
+// installation and installs the worker chart onto it: the Cluster is created
+// in the namespace of the project of the worker, its secret is waited for and
+// the worker values are generated from it, and the worker is waited for to
+// be ready. The other clusters are left untouched. The worker joins the
+// topology of the configuration passed with --config.
+func JoinWorkerCluster(specs *ConfigurationSpecs, worker Cluster) {
+	util.Printf("\nRegistering worker %s with the controller...", worker.Name)
+	if err := verifyWorkerNameAvailable(specs, worker); err != nil {
+		util.Fatalf("%s %v", util.Cross, err)
+	}
+	if result := runPreflightChecks(worker, false, ""); len(result.errors) > 0 {
+		printPreflightResults([]preflightResult{result})
+		for _, s := range result.errors {
+			util.Printf("%s %s", util.Cross, s)
+		}
+		util.Fatalf("%s Pre-flight checks of worker %s failed, nothing was changed", util.Cross, worker.Name)
+	}
+
+	joined := joinedTopology(specs, worker)
+	single := withWorkers(joined, []Cluster{worker})
+	GatherNetworkInformation(single)
+
+	controller := &single.Configuration.ClusterConfiguration.ControllerCluster
+	namespace := projectNamespace(workerProject(single.Configuration.KubeSliceConfiguration, worker))
+	WaitForControllerCRDs(controller)
+	DetectControllerAPIVersion(controller)
+	fileName := kubesliceDirectory + "/" + "cluster-registration-" + worker.Name + ".yaml"
+	generateClusterRegistrationManifest(single, fileName, namespace)
+	util.Printf("%s Generated cluster registration manifest %s", util.Tick, fileName)
+	time.Sleep(200 * time.Millisecond)
+	ApplyKubectlManifest(fileName, namespace, controller)
+	util.Printf("%s Registered worker %s in namespace %s", util.Tick, worker.Name, namespace)
+
+	LabelGatewayNodes(single)
+	InstallKubeSliceWorker(single)
+
+	RecordInstallation(joined, false)
+	recordRegisteredWorker(joined, worker.Name)
+	writeRunSummary()
+	util.Printf("%s Worker %s joined installation %s", util.Tick, worker.Name, InstallationName(specs))
+}
+
+// recordRegisteredWorker adds a worker to the registered workers of the state
+// of the installation.
+func recordRegisteredWorker(specs *ConfigurationSpecs, worker string) {
+	name := InstallationName(specs)
+	err := updateInstallationState(name, func(state *InstallationState) {
+		state.RegisteredWorkers = appendUnique(state.RegisteredWorkers, worker)
+	})
+	if err != nil {
+		util.Printf("%s Unable to record worker %s in the state of installation %s: %v", util.Warn, worker, name, err)
+	}
+}
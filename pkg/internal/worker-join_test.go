@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinedTopology(t *testing.T) {
+	t.Parallel()
+	specs := &ConfigurationSpecs{Configuration: Configuration{ClusterConfiguration: ClusterConfiguration{
+		WorkerClusters: []Cluster{{Name: "ks-w-1", ContextName: "ctx-1"}, {Name: "ks-w-2", ContextName: "ctx-2"}},
+	}}}
+	joined := joinedTopology(specs, Cluster{Name: "ks-w-3", ContextName: "ctx-3"})
+	expected := []Cluster{{Name: "ks-w-1", ContextName: "ctx-1"}, {Name: "ks-w-2", ContextName: "ctx-2"}, {Name: "ks-w-3", ContextName: "ctx-3"}}
+	if got := joined.Configuration.ClusterConfiguration.WorkerClusters; !reflect.DeepEqual(got, expected) {
+		t.Errorf("joinedTopology() workers = %v, want %v", got, expected)
+	}
+	joined = joinedTopology(specs, Cluster{Name: "ks-w-2", ContextName: "ctx-2b"})
+	expected = []Cluster{{Name: "ks-w-1", ContextName: "ctx-1"}, {Name: "ks-w-2", ContextName: "ctx-2b"}}
+	if got := joined.Configuration.ClusterConfiguration.WorkerClusters; !reflect.DeepEqual(got, expected) {
+		t.Errorf("joinedTopology() workers = %v, want %v", got, expected)
+	}
+	if len(specs.Configuration.ClusterConfiguration.WorkerClusters) != 2 || specs.Configuration.ClusterConfiguration.WorkerClusters[1].ContextName != "ctx-2" {
+		t.Errorf("joinedTopology() changed the topology: %v", specs.Configuration.ClusterConfiguration.WorkerClusters)
+	}
+}
+
+func TestVerifyWorkerNameAvailable(t *testing.T) {
+	defer func(dir string, client KubeClient) {
+		installationStateDirectory, kubeClient = dir, client
+	}(installationStateDirectory, kubeClient)
+	installationStateDirectory = t.TempDir()
+	kubeClient = &fakeKubeClient{objects: map[string][]string{
+		objectsKey(ClusterObject, "kubeslice-demo"): {"ks-w-1"},
+	}}
+	specs := &ConfigurationSpecs{Configuration: Configuration{
+		KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+		ClusterConfiguration:   ClusterConfiguration{ControllerCluster: Cluster{Name: "ks-ctrl"}},
+	}}
+	if err := updateInstallationState("ks-ctrl", func(state *InstallationState) {
+		state.RegisteredWorkers = []string{"ks-w-2"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		worker   string
+		expected string
+	}{
+		{name: "New worker", worker: "ks-w-3"},
+		{name: "Registered on the controller", worker: "ks-w-1", expected: "worker ks-w-1 is registered already in namespace kubeslice-demo of controller cluster ks-ctrl, pick another --name, or deregister it first with kubeslice-cli deregister worker ks-w-1 -n kubeslice-demo"},
+		{name: "Registered by the installation", worker: "ks-w-2", expected: "worker ks-w-2 is registered already by installation ks-ctrl, pick another --name, or deregister it first with kubeslice-cli deregister worker ks-w-2 -n kubeslice-demo"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ""
+			if err := verifyWorkerNameAvailable(specs, Cluster{Name: tc.worker}); err != nil {
+				got = err.Error()
+			}
+			if got != tc.expected {
+				t.Errorf("verifyWorkerNameAvailable() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
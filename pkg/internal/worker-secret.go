@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// workerSecretKeys are the keys of the secret of a registered worker the
+// worker chart is installed with.
+var workerSecretKeys = []string{"namespace", "controllerEndpoint", "ca.crt", "token"}
+
+// How often the secret of a registered worker is looked up, the controller
+// only creates it after the registration.
+var (
+	workerSecretAttempts      = 5
+	workerSecretRetryInterval = time.Second
+)
+
+// workerSecret is the secret the controller created in the project namespace
+// for a registered worker.
+type workerSecret struct {
+	Name      string
+	Namespace string
+	Cluster   string
+	// Data is base64 encoded, as the worker chart expects it
+	Data map[string]string
+}
+
+func (s workerSecret) String() string {
+	return fmt.Sprintf("secret %s in namespace %s of cluster %s", s.Name, s.Namespace, s.Cluster)
+}
+
+// validate checks that every key the worker chart needs is set and decodes.
+func (s workerSecret) validate() error {
+	for _, key := range workerSecretKeys {
+		value, ok := s.Data[key]
+		if !ok {
+			return fmt.Errorf("%s has no key %s", s, key)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("key %s of %s is not valid base64: %v", key, s, err)
+		}
+		if len(decoded) == 0 {
+			return fmt.Errorf("key %s of %s is empty", key, s)
+		}
+	}
+	return nil
+}
+
+// fetchWorkerSecrets returns the data of the secret the controller created for
+// the registered worker cluster.
+func fetchWorkerSecrets(cluster Cluster, config Configuration) map[string]string {
+	secret, err := fetchWorkerSecret(cluster, config)
+	if err != nil {
+		util.Fatalf("%s Unable to fetch the secret of worker %s\n%s", util.Cross, cluster.Name, err)
+	}
+	return secret.Data
+}
+
+func fetchWorkerSecret(cluster Cluster, config Configuration) (workerSecret, error) {
+	var secret workerSecret
+	err := Retry(workerSecretAttempts, workerSecretRetryInterval, func() (err error) {
+		secret, err = lookupWorkerSecret(cluster, config)
+		return err
+	})
+	return secret, err
+}
+
+// lookupWorkerSecret returns the valid secret of a registered worker, without
+// waiting for the controller to create it.
+func lookupWorkerSecret(cluster Cluster, config Configuration) (workerSecret, error) {
+	controllerCluster := config.ClusterConfiguration.ControllerCluster
+	namespace := projectNamespace(workerProject(config.KubeSliceConfiguration, cluster))
+	name, err := findWorkerSecret(cluster.Name, namespace, controllerCluster)
+	if err != nil {
+		return workerSecret{}, err
+	}
+	secret := workerSecret{Name: name, Namespace: namespace, Cluster: controllerCluster.Name}
+	if secret.Data, err = kubeClient.SecretData(&controllerCluster, namespace, name); err != nil {
+		return workerSecret{}, fmt.Errorf("unable to get %s: %v", secret, err)
+	}
+	return secret, secret.validate()
+}
+
+// findWorkerSecret returns the secret of the service account the controller
+// created for a worker, named kubeslice-rbac-worker-<worker>. Of several
+// matching service accounts the exact name is preferred, then the first one
+// in order.
+func findWorkerSecret(workerName, namespace string, controllerCluster Cluster) (string, error) {
+	serviceAccounts, err := kubeClient.List(&controllerCluster, serviceAccountResource, namespace)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the service accounts in namespace %s of cluster %s: %v", namespace, controllerCluster.Name, err)
+	}
+	matches := make([]string, 0)
+	for _, name := range serviceAccounts {
+		if name == "kubeslice-rbac-worker-"+workerName {
+			return name, nil
+		}
+		if strings.Contains(name, "rbac-worker-"+workerName) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no service account of worker %s in namespace %s of cluster %s", workerName, namespace, controllerCluster.Name)
+	}
+	sort.Strings(matches)
+	if len(matches) > 1 {
+		util.Printf("%s Service accounts %s match worker %s, using %s", util.Warn, strings.Join(matches, ", "), workerName, matches[0])
+	}
+	return matches[0], nil
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+func TestFetchWorkerSecret(t *testing.T) {
+	defer func(attempts int, interval time.Duration) {
+		workerSecretAttempts, workerSecretRetryInterval = attempts, interval
+	}(workerSecretAttempts, workerSecretRetryInterval)
+	workerSecretAttempts, workerSecretRetryInterval = 3, time.Millisecond
+
+	const valid = `{"kind": "Secret", "data": {"namespace": "a3ViZXNsaWNlLWRlbW8=", "controllerEndpoint": "aHR0cHM6Ly9jb250cm9sbGVy", "ca.crt": "Y2E=", "token": "dG9rZW4="}}`
+	tests := []struct {
+		name            string
+		serviceAccounts []string
+		secret          string
+		// createdAfter is the number of lookups the service account is missing for
+		createdAfter int
+		expected     string
+		err          string
+	}{
+		{
+			name:            "Valid",
+			serviceAccounts: []string{"default", "kubeslice-rbac-worker-worker-1"},
+			secret:          valid,
+			expected:        "kubeslice-rbac-worker-worker-1",
+		},
+		{
+			name:            "Delayed creation",
+			serviceAccounts: []string{"kubeslice-rbac-worker-worker-1"},
+			secret:          valid,
+			createdAfter:    2,
+			expected:        "kubeslice-rbac-worker-worker-1",
+		},
+		{
+			name:            "Several matches",
+			serviceAccounts: []string{"kubeslice-rbac-worker-worker-1-b", "kubeslice-rbac-worker-worker-1-a"},
+			secret:          valid,
+			expected:        "kubeslice-rbac-worker-worker-1-a",
+		},
+		{
+			name:            "Never created",
+			serviceAccounts: []string{"kubeslice-rbac-worker-worker-10"},
+			createdAfter:    3,
+			err:             "no service account of worker worker-1 in namespace kubeslice-demo of cluster controller",
+		},
+		{
+			name:            "Missing key",
+			serviceAccounts: []string{"kubeslice-rbac-worker-worker-1"},
+			secret:          `{"data": {"namespace": "a3ViZXNsaWNlLWRlbW8=", "ca.crt": "Y2E=", "token": "dG9rZW4="}}`,
+			err:             "secret kubeslice-rbac-worker-worker-1 in namespace kubeslice-demo of cluster controller has no key controllerEndpoint",
+		},
+		{
+			name:            "Bad base64",
+			serviceAccounts: []string{"kubeslice-rbac-worker-worker-1"},
+			secret:          `{"data": {"namespace": "a3ViZXNsaWNlLWRlbW8=", "controllerEndpoint": "aHR0cHM6Ly9jb250cm9sbGVy", "ca.crt": "Y2E=", "token": "not base64!"}}`,
+			err:             "key token of secret kubeslice-rbac-worker-worker-1 in namespace kubeslice-demo of cluster controller is not valid base64",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lookups := 0
+			mock := &util.MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+				switch command[4] {
+				case serviceAccountResource:
+					if lookups++; lookups > tc.createdAfter {
+						fmt.Fprint(stdout, strings.Join(tc.serviceAccounts, " "))
+					}
+				case "secret":
+					fmt.Fprint(stdout, tc.secret)
+				}
+				return nil
+			}}
+			defer util.UseExecutor(mock)()
+
+			config := Configuration{
+				KubeSliceConfiguration: KubeSliceConfiguration{ProjectName: "demo"},
+				ClusterConfiguration:   ClusterConfiguration{ControllerCluster: Cluster{Name: "controller", ContextName: "kind-controller"}},
+			}
+			secret, err := fetchWorkerSecret(Cluster{Name: "worker-1"}, config)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("fetchWorkerSecret() error = %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchWorkerSecret() returned error: %v", err)
+			}
+			if secret.Name != tc.expected || secret.Data["token"] != "dG9rZW4=" {
+				t.Errorf("fetchWorkerSecret() = %+v, want secret %s", secret, tc.expected)
+			}
+		})
+	}
+}
@@ -1,10 +1,7 @@
 package internal
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -22,6 +19,7 @@ metrics:
 
 cluster:
   name: %s
+  nodeIp: %q
   endpoint: %s
 
 `
@@ -30,8 +28,16 @@ func InstallKubeSliceWorker(ApplicationConfiguration *ConfigurationSpecs) {
 	util.Printf("\nInstalling KubeSlice Worker...")
 
 	cc := ApplicationConfiguration.Configuration.ClusterConfiguration
-	for _, cluster := range cc.WorkerClusters {
-		filename := "helm-values-" + cluster.Name + ".yaml"
+	overridden := make([]string, 0)
+	// the untouched clusters are left before their secret is looked up
+	for _, cluster := range touchedWorkers(cc.WorkerClusters) {
+		if leaveAsInstalled(cluster, "kubeslice-worker") {
+			continue
+		}
+		if len(cluster.WorkerValues) > 0 {
+			overridden = append(overridden, cluster.Name)
+		}
+		filename := valuesFileName(cluster, ApplicationConfiguration.Configuration.HelmChartConfiguration.WorkerChart)
 		insecureMetrics := ApplicationConfiguration.Configuration.ClusterConfiguration.ClusterType == Kind_Component
 		generateWorkerValuesFile(cluster,
 			filename,
@@ -42,10 +48,15 @@ func InstallKubeSliceWorker(ApplicationConfiguration *ConfigurationSpecs) {
 		util.Printf("%s Generated Helm Values file for Worker Installation %s", util.Tick, filename)
 		time.Sleep(200 * time.Millisecond)
 
-		installWorker(cluster, filename, ApplicationConfiguration.Configuration.HelmChartConfiguration)
+		CreateNamespace(cluster, WorkerNamespace(cluster), ApplicationConfiguration.Configuration.KubeSliceConfiguration)
+
+		installWorker(InstallationName(ApplicationConfiguration), cluster, filename, ApplicationConfiguration.Configuration.HelmChartConfiguration)
 	}
 
 	util.Printf("%s Successfully Installed Kubeslice Worker", util.Tick)
+	if len(overridden) > 0 {
+		util.Printf("%s Worker values were overridden on clusters: %s", util.Tick, strings.Join(overridden, ", "))
+	}
 	time.Sleep(200 * time.Millisecond)
 }
 
@@ -85,91 +96,58 @@ func Retry(backoffLimit int, sleep time.Duration, f func() error) (err error) {
 }
 
 func generateWorkerValuesFile(cluster Cluster, valuesFile string, config Configuration, insecureMetrics bool) {
-	var secrets map[string]string
-	err := Retry(3, 1*time.Second, func() (err error) {
-		secrets = fetchSecret(cluster.Name, config.ClusterConfiguration.ControllerCluster, config.KubeSliceConfiguration.ProjectName)
-		if secrets["namespace"] == "" || secrets["controllerEndpoint"] == "" || secrets["ca.crt"] == "" || secrets["token"] == "" {
-			return fmt.Errorf("secret is empty")
-		}
-		return nil
-	})
+	writeWorkerValuesFile(cluster, valuesFile, config, insecureMetrics, fetchWorkerSecrets(cluster, config))
+}
+
+// writeWorkerValuesFile generates the worker values file of a cluster from the
+// data of its controller secret.
+func writeWorkerValuesFile(cluster Cluster, valuesFile string, config Configuration, insecureMetrics bool, secrets map[string]string) {
+	var err error
+	chart := config.HelmChartConfiguration.WorkerChart
+	chart.Values, err = substitutePlaceholders(overrideValues(chart.Values, cluster.WorkerValues), workerTemplateVariables(cluster, secrets["controllerEndpoint"], secrets["namespace"]), "worker values of "+cluster.Name)
 	if err != nil {
-		log.Fatalf("Unable to fetch secrets\n%s", err)
+		util.Fatalf("%s %s", util.Cross, err)
+	}
+	verifyValueKeys(config.HelmChartConfiguration, &chart)
+	defaults := fmt.Sprintf(workerValuesTemplate, secrets["namespace"], secrets["controllerEndpoint"], secrets["ca.crt"], secrets["token"], insecureMetrics, cluster.Name, workerNodeIP(cluster), cluster.ControlPlaneAddress) + kubesliceImagePullSecretsValue(config.HelmChartConfiguration.ImagePullSecrets)
+	if config.Monitoring.Enabled {
+		defaults, err = withMetricsValues(defaults, workerMetricsValues)
+	}
+	if err == nil {
+		err = generateValuesFile(kubesliceDirectory+"/"+valuesFile, &chart, defaults, propagatedImagePullSecrets(config.HelmChartConfiguration))
+	}
+	if err == nil {
+		err = rewriteImageRegistry(config.HelmChartConfiguration, &chart, kubesliceDirectory+"/"+valuesFile)
 	}
-	err = generateValuesFile(kubesliceDirectory+"/"+valuesFile, &config.HelmChartConfiguration.WorkerChart, fmt.Sprintf(workerValuesTemplate+generateImagePullSecretsValue(config.HelmChartConfiguration.ImagePullSecret), secrets["namespace"], secrets["controllerEndpoint"], secrets["ca.crt"], secrets["token"], insecureMetrics, cluster.Name, cluster.ControlPlaneAddress))
 	if err != nil {
-		log.Fatalf("%s %s", util.Cross, err)
+		util.Fatalf("%s %s", util.Cross, err)
 	}
 }
 
-func installWorker(cluster Cluster, valuesName string, helmChartConfig HelmChartConfiguration) {
+func installWorker(installation string, cluster Cluster, valuesName string, helmChartConfig HelmChartConfiguration) {
 	hc := helmChartConfig
+	reuseReleaseValues(cluster, "kubeslice-worker", WorkerNamespace(cluster), kubesliceDirectory+"/"+valuesName)
+	showValuesDiff(cluster, "kubeslice-worker", WorkerNamespace(cluster), kubesliceDirectory+"/"+valuesName)
 	installKubeSliceWorkerHelm(cluster, valuesName, hc)
+	recordValuesChecksum(installation, cluster, "kubeslice-worker", kubesliceDirectory+"/"+valuesName)
 	util.Printf("%s Successfully installed helm chart %s/%s on %s", util.Tick, hc.RepoAlias, hc.WorkerChart.ChartName, cluster.Name)
 	time.Sleep(200 * time.Millisecond)
 
 	util.Printf("%s Waiting for KubeSlice Worker Pods to be Healthy...", util.Wait)
-	PodVerification("Waiting for KubeSlice Worker Pods to be Healthy", cluster, "kubeslice-system")
+	WaitForRollout(cluster, "kubeslice-worker", WorkerNamespace(cluster))
 
 	util.Printf("%s Successfully installed KubeSlice Worker %s.", util.Tick, cluster.Name)
 }
 
 func installKubeSliceWorkerHelm(cluster Cluster, valuesFile string, hc HelmChartConfiguration) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "upgrade", "-i", "kubeslice-worker", fmt.Sprintf("%s/%s", hc.RepoAlias, hc.WorkerChart.ChartName), "--namespace", "kubeslice-system", "--create-namespace", "-f", kubesliceDirectory+"/"+valuesFile)
-	if hc.WorkerChart.Version != "" {
-		args = append(args, "--version", hc.WorkerChart.Version)
-	}
-	err := util.RunCommand("helm", args...)
-	if err != nil {
-		log.Fatalf("Process failed %v", err)
-	}
-}
-
-func fetchSecret(clusterName string, cc Cluster, projectName string) map[string]string {
-	//kubectl get secrets -n kubeslice-demo -o name
-	secret := findSecret(clusterName, projectName, cc)
-	//kubectl get secret/kubeslice-rbac-worker-kubeslice-worker-1-token-h99pc -n kubeslice-demo -o jsonpath={.data}
-	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", secret, "-n", "kubeslice-"+projectName, "-o", "jsonpath={.data}")
-	if err != nil {
-		log.Fatalf("Process failed %v", err)
-	}
-	x := map[string]string{}
-	err = json.Unmarshal(outB.Bytes(), &x)
-	if err != nil {
-		log.Fatalf("failed to read secret %s", secret)
-	}
-	return x
-}
-
-func findSecret(workerName string, projectName string, cc Cluster) string {
-	var outB, errB bytes.Buffer
-	err := util.RunCommandCustomIO("kubectl", &outB, &errB, true, "--context="+cc.ContextName, "--kubeconfig="+cc.KubeConfigPath, "get", "sa", "-n", "kubeslice-"+projectName, "-o", "name")
-	if err != nil {
-		log.Fatalf("Process failed %v", err)
-	}
-
-	var secret string
-	for _, line := range strings.Split(outB.String(), "\n") {
-		if strings.Contains(line, "rbac-worker-"+workerName) {
-			secret = fmt.Sprintf("secrets/%s", strings.TrimPrefix(line, "serviceaccount/"))
-			break
-		}
-	}
-	if secret == "" {
-		log.Fatalf("failed to find secret for %s", workerName)
-	}
-	return secret
+	installRelease(releaseRequest{Cluster: cluster, Release: "kubeslice-worker", Namespace: WorkerNamespace(cluster), Chart: hc.WorkerChart, Repo: hc, ValuesFile: kubesliceDirectory + "/" + valuesFile})
 }
 
 func uninstallKubeSliceWorkerHelm(cluster Cluster) {
-	args := make([]string, 0)
-	args = append(args, "--kube-context", cluster.ContextName, "--kubeconfig", cluster.KubeConfigPath, "uninstall", "kubeslice-worker", "--namespace", "kubeslice-system")
-
-	err := util.RunCommand("helm", args...)
+	err := chartInstaller.Uninstall(cluster, "kubeslice-worker", WorkerNamespace(cluster))
 	if err != nil {
 		util.Printf("%s Uninstall failed. %v", util.Cross, err)
+		diagnoseRelease(cluster, "kubeslice-worker", WorkerNamespace(cluster))
 	}
 	util.Printf("%s Successfully uninstalled KubeSlice Worker %s.", util.Tick, cluster.Name)
 }
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/kubeslice/kubeslice-cli/util"
+)
+
+// kubesliceHomeDirectory holds the state kubeslice-cli keeps between runs.
+var kubesliceHomeDirectory = defaultHomeDirectory()
+
+// workspacesDirectory holds the workspace of every run which is not given one
+// with --workspace.
+var workspacesDirectory = filepath.Join(kubesliceHomeDirectory, "runs")
+
+// kubesliceDirectory is the workspace of the current run. Every generated
+// values file, manifest and kind configuration is written to it.
+var kubesliceDirectory = "kubeslice"
+
+func defaultHomeDirectory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".kubeslice"
+	}
+	return filepath.Join(home, ".kubeslice")
+}
+
+// keptWorkspaces is how many of the workspaces of earlier runs are kept under
+// the workspaces directory, the older ones are pruned.
+const keptWorkspaces = 10
+
+// SetWorkspace selects the workspace of the current run. Without an explicit
+// directory a new one is chosen under ~/.kubeslice/runs, or the workspace-dir
+// of the user defaults, named after the start
+// time and process id so that concurrent runs never share a directory. The
+// directory itself is only created once a step generates a file. Only the
+// most recent workspaces of earlier runs are kept, unless
+// --keep-generated-files keeps all of them.
+func SetWorkspace(dir string) {
+	if dir == "" {
+		dir = filepath.Join(workspacesDirectory, fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), os.Getpid()))
+		if !Options.KeepGeneratedFiles {
+			pruneWorkspaces(workspacesDirectory, keptWorkspaces)
+		}
+	}
+	kubesliceDirectory = dir
+	util.OnFailure(func() {
+		if _, err := os.Stat(kubesliceDirectory); !errors.Is(err, os.ErrNotExist) {
+			util.Printf("%s Generated files of this run are available in %s", util.Warn, kubesliceDirectory)
+		}
+	})
+}
+
+// pruneWorkspaces removes the workspaces of the runs in a directory but the
+// kept most recent ones. Their names start with the start time of the run, so
+// they sort by age.
+func pruneWorkspaces(dir string, kept int) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	runs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && workspaceNamePattern.MatchString(entry.Name()) {
+			runs = append(runs, entry.Name())
+		}
+	}
+	sort.Strings(runs)
+	for i := 0; i < len(runs)-kept; i++ {
+		if err := os.RemoveAll(filepath.Join(dir, runs[i])); err != nil {
+			util.Debugf("unable to prune workspace %s: %v", runs[i], err)
+		}
+	}
+}
+
+// workspaceNamePattern matches the names SetWorkspace gives the workspaces it
+// chooses, other directories are never pruned.
+var workspaceNamePattern = regexp.MustCompile(`^\d{8}-\d{6}-\d+$`)
+
+// SetWorkspacesDirectory selects the directory the workspaces of the runs are
+// created in.
+func SetWorkspacesDirectory(dir string) {
+	workspacesDirectory = dir
+}
+
+// WorkspacesDirectory returns the directory the workspaces of the runs are
+// created in.
+func WorkspacesDirectory() string {
+	return workspacesDirectory
+}
+
+// WorkspaceDirectory returns the workspace of the current run.
+func WorkspaceDirectory() string {
+	return kubesliceDirectory
+}
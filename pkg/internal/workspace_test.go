@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPruneWorkspaces(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"20261012-090000-41", "20261013-090000-42", "20261014-090000-43", "20261014-100000-44", "my-workspace"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneWorkspaces(dir, 2)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	expected := []string{"20261014-090000-43", "20261014-100000-44", "my-workspace"}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("pruneWorkspaces() kept %v, want %v", remaining, expected)
+	}
+}
@@ -4,23 +4,36 @@ import (
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
 )
 
+// ValidateProjectName returns an error when name cannot be the name of a
+// KubeSlice project.
+func ValidateProjectName(name string) error {
+	return internal.ValidateProjectName(name)
+}
+
 func CreateProject() {
 	ApplicationConfiguration.Configuration.KubeSliceConfiguration.ProjectName = CliOptions.ObjectName
 	internal.CreateKubeSliceProject(ApplicationConfiguration, CliOptions)
 }
 
 func GetProject() {
-	internal.GetKubeSliceProject(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
+	internal.GetKubeSliceProject(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, CliOptions.OutputFormat)
 }
 
-func DeleteProject() {
-	internal.DeleteKubeSliceProject(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
+// DeleteProject deletes the project, and the resources of its namespace with
+// cascade.
+func DeleteProject(cascade, wait bool) {
+	internal.DeleteKubeSliceProject(ApplicationConfiguration, CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, cascade, wait)
 }
 
 func EditProject() {
 	internal.EditKubeSliceProject(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
 }
 
+// EditProjectUsers adds and removes users of a role of the project.
+func EditProjectUsers(add, remove []string, role string, force, dryRun bool) {
+	internal.EditKubeSliceProjectUsers(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, add, remove, role, force, dryRun)
+}
+
 func DescribeProject() {
 	internal.DescribeKubeSliceProject(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
 }
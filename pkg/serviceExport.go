@@ -4,15 +4,47 @@ import (
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
 )
 
-func CreateServiceExportConfig(filename string) {
-	internal.CreateServiceExportConfig(CliOptions.Namespace, CliOptions.Cluster, filename)
+// ServiceExportParams describe the service create serviceExportConfig
+// exports when no file is passed.
+type ServiceExportParams struct {
+	Slice            string
+	Service          string
+	ServiceNamespace string
+	SourceCluster    string
+	// port[/protocol]
+	Ports []string
 }
 
-func GetServiceExportConfig() {
-	internal.GetServiceExportConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
+func CreateServiceExportConfig(params ServiceExportParams) {
+	if len(CliOptions.FileName) != 0 {
+		if CliOptions.DryRun {
+			internal.PrintManifestFile(CliOptions.FileName)
+			return
+		}
+		internal.CreateServiceExportConfig(CliOptions.Namespace, CliOptions.Cluster, CliOptions.FileName)
+		return
+	}
+	internal.GenerateAndApplyServiceExportConfig(internal.ServiceExport{
+		Name:             CliOptions.ObjectName,
+		Slice:            params.Slice,
+		Service:          params.Service,
+		ServiceNamespace: params.ServiceNamespace,
+		SourceCluster:    params.SourceCluster,
+		Ports:            params.Ports,
+	}, CliOptions.Namespace, CliOptions.Cluster, CliOptions.DryRun)
 }
 
-func DeleteServiceExportConfig() {
+func GetServiceExportConfig(sliceName string) {
+	internal.GetServiceExportConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, sliceName, CliOptions.OutputFormat)
+}
+
+// DeleteServiceExportConfig deletes the named serviceExportConfig, or all the
+// ones of a slice when no name is passed.
+func DeleteServiceExportConfig(sliceName string) {
+	if CliOptions.ObjectName == "" {
+		internal.DeleteSliceServiceExportConfigs(sliceName, CliOptions.Namespace, CliOptions.Cluster)
+		return
+	}
 	internal.DeleteServiceExportConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
 }
 
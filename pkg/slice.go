@@ -2,23 +2,93 @@ package pkg
 
 import (
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
+	"github.com/kubeslice/kubeslice-cli/util"
 )
 
-func CreateSliceConfig(worker []string) {
+// SliceParams override the slice of the topology create sliceConfig
+// generates.
+type SliceParams struct {
+	Workers     []string
+	SliceSubnet string
+	// namespace[=cluster,...]
+	ApplicationNamespaces []string
+	AllowedNamespaces     []string
+	NamespaceIsolation    *bool
+	// QoS profile fields set with flags, the others keep the topology values
+	QueueType               string
+	Priority                *int
+	BandwidthCeilingKbps    int
+	BandwidthGuaranteedKbps int
+	DscpClass               string
+}
+
+func CreateSliceConfig(params SliceParams) {
 	if len(CliOptions.FileName) != 0 {
+		if CliOptions.DryRun {
+			internal.PrintManifestFile(CliOptions.FileName)
+			return
+		}
 		internal.CreateSliceConfig(CliOptions.Namespace, CliOptions.Cluster, CliOptions.FileName)
-	} else if len(worker) != 0 {
-		internal.GenerateSliceConfiguration(ApplicationConfiguration, worker, CliOptions.ObjectName, CliOptions.Namespace)
-		internal.ApplyFile("kubeslice/slice-"+CliOptions.ObjectName+".yaml", CliOptions.Namespace, CliOptions.Cluster)
+		return
+	}
+	slice := &ApplicationConfiguration.Configuration.KubeSliceConfiguration.Slice
+	if params.SliceSubnet != "" {
+		slice.SliceSubnet = params.SliceSubnet
+	}
+	if params.NamespaceIsolation != nil {
+		slice.NamespaceIsolation = *params.NamespaceIsolation
+	}
+	if len(params.ApplicationNamespaces) != 0 {
+		slice.ApplicationNamespaces = parseSliceNamespaces(params.ApplicationNamespaces)
+	}
+	if len(params.AllowedNamespaces) != 0 {
+		slice.AllowedNamespaces = parseSliceNamespaces(params.AllowedNamespaces)
+	}
+	qos := &slice.QoSProfile
+	if params.QueueType != "" {
+		qos.QueueType = params.QueueType
+	}
+	if params.Priority != nil {
+		qos.Priority = params.Priority
 	}
+	if params.BandwidthCeilingKbps != 0 {
+		qos.BandwidthCeilingKbps = params.BandwidthCeilingKbps
+	}
+	if params.BandwidthGuaranteedKbps != 0 {
+		qos.BandwidthGuaranteedKbps = params.BandwidthGuaranteedKbps
+	}
+	if params.DscpClass != "" {
+		qos.DscpClass = params.DscpClass
+	}
+	internal.GenerateAndApplySliceConfig(ApplicationConfiguration, params.Workers, CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, CliOptions.DryRun)
+}
+
+func parseSliceNamespaces(values []string) []internal.SliceNamespace {
+	namespaces := make([]internal.SliceNamespace, 0, len(values))
+	for _, value := range values {
+		ns, err := internal.ParseSliceNamespace(value)
+		if err != nil {
+			util.Fatalf("%s %v", util.Cross, err)
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
 }
 
 func GetSliceConfig() {
-	internal.GetSliceConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
+	internal.GetSliceConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, CliOptions.OutputFormat)
+}
+
+// GetSliceStatus shows the state of the slice on every worker cluster it
+// spans, reached with the contexts of the topology.
+func GetSliceStatus() {
+	internal.ShowSliceStatus(ApplicationConfiguration, CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, CliOptions.OutputFormat)
 }
 
-func DeleteSliceConfig() {
-	internal.DeleteSliceConfig(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster)
+// DeleteSliceConfig deletes the slice and, with wait, waits until the workers
+// of the topology are offboarded from it.
+func DeleteSliceConfig(wait bool) {
+	internal.DeleteSliceConfig(ApplicationConfiguration, CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, wait)
 }
 
 func EditSliceConfig() {
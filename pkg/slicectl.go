@@ -1,6 +1,8 @@
 package pkg
 
 import (
+	"io"
+	"os"
 	"time"
 
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
@@ -8,8 +10,15 @@ import (
 )
 
 func Install(skipSteps map[string]string) {
-	basicInstall(skipSteps)
-	if _, skipDemo := skipSteps[internal.Demo_Component]; !skipDemo {
+	defer planOutput()()
+	if Options.RenderTo == "" && !Options.SkipDiagnostics {
+		internal.CollectDiagnosticsOnFailure(ApplicationConfiguration)
+	}
+	plan := internal.BuildInstallPlan(ApplicationConfiguration, skipSteps)
+	if !basicInstall(plan) {
+		return
+	}
+	if plan.Includes(internal.Demo_Component) && plan.Start(internal.Demo_Component) {
 		switch ApplicationConfiguration.Configuration.ClusterConfiguration.Profile {
 		case ProfileFullDemo:
 			fullDemo()
@@ -19,6 +28,13 @@ func Install(skipSteps map[string]string) {
 			entDemo()
 		}
 	}
+	// a minimal demo on one worker has no iPerf server and client, the slice
+	// is left to create
+	if _, skipDemo := skipSteps[internal.Demo_Component]; !skipDemo && !plan.Includes(internal.Demo_Component) && ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == ProfileMinimalDemo {
+		internal.GenerateSliceConfiguration(ApplicationConfiguration, nil, "", "")
+		internal.PrintSliceNextSteps(ApplicationConfiguration)
+	}
+	plan.Finish()
 }
 
 func fullDemo() {
@@ -27,12 +43,12 @@ func fullDemo() {
 	util.Printf("%s Waiting for configuration propagation", util.Wait)
 	time.Sleep(20 * time.Second)
 	internal.GenerateIPerfManifests()
-	internal.GenerateIPerfServiceExportManifest(ApplicationConfiguration)
 	internal.InstallIPerf(ApplicationConfiguration)
 	internal.ApplyIPerfServiceExportManifest(ApplicationConfiguration)
 	util.Printf("%s Waiting for configuration propagation", util.Wait)
 	time.Sleep(20 * time.Second)
 	internal.RolloutRestartIPerf(ApplicationConfiguration)
+	verifyDemo()
 	internal.PrintNextSteps(true, ApplicationConfiguration)
 }
 
@@ -51,74 +67,189 @@ func entDemo() {
 	util.Printf("%s Waiting for configuration propagation", util.Wait)
 	time.Sleep(20 * time.Second)
 	internal.GenerateIPerfManifests()
-	internal.GenerateIPerfServiceExportManifest(ApplicationConfiguration)
 	internal.InstallIPerf(ApplicationConfiguration)
 	internal.ApplyIPerfServiceExportManifest(ApplicationConfiguration)
 	util.Printf("%s Waiting for configuration propagation", util.Wait)
 	time.Sleep(20 * time.Second)
 	internal.RolloutRestartIPerf(ApplicationConfiguration)
+	verifyDemo()
 	internal.PrintNextSteps(true, ApplicationConfiguration)
 }
 
-func basicInstall(skipSteps map[string]string) {
-	internal.VerifyExecutables(ApplicationConfiguration)
-
-	_, skipKind := skipSteps[internal.Kind_Component]
-	_, skipCalico := skipSteps[internal.Calico_Component]
-	_, skipController := skipSteps[internal.Controller_Component]
-	_, skipWorker := skipSteps[internal.Worker_Component]
-	_, skipWorker_registration := skipSteps[internal.Worker_registration_Component]
-	_, skipUI := skipSteps[internal.UI_install_Component]
-	_, skipCertManager := skipSteps[internal.CertManager_Component]
-	_, skipPrometheus := skipSteps[internal.Prometheus_Component]
+// verifyDemo verifies the connectivity of the demo slice. A failure is
+// reported without failing the install, the slice is left to inspect.
+func verifyDemo() {
+	if Options.SkipVerify {
+		return
+	}
+	if err := internal.VerifyDemo(ApplicationConfiguration, Options.CheckQoS); err != nil {
+		util.Printf("%s Verification of slice failed: %v", util.Cross, err)
+	}
+}
 
-	if ApplicationConfiguration.Configuration.HelmChartConfiguration.PrometheusChart.ChartName == "" {
-		skipPrometheus = true
+// planOutput writes the progress of a run printing its plan as JSON to stderr,
+// so that stdout only carries the plan. It returns the function restoring the
+// output.
+func planOutput() func() {
+	output := util.Output
+	if Options.PlanOnly && Options.PlanOutput == "json" {
+		util.Output = os.Stderr
 	}
+	return func() { util.Output = output }
+}
+
+// basicInstall runs the steps of the plan up to the example applications. It
+// tells whether the run goes on, it stops after the plan with --plan-only and
+// after rendering with --render-to.
+func basicInstall(plan *internal.InstallPlan) bool {
+	internal.VerifyPlatformOption(plan.Includes(internal.Kind_Component))
+	internal.VerifyExecutables(ApplicationConfiguration, plan.Includes(internal.Kind_Component))
 
 	internal.GenerateKubeSliceDirectory()
+	if Options.FromBundle != "" {
+		internal.UseBundle(ApplicationConfiguration, Options.FromBundle)
+	}
+	// charts are resolved before any cluster is touched, so that an unavailable
+	// version fails the run right away
+	internal.AddHelmCharts(ApplicationConfiguration)
+	internal.ResolveUIChart(ApplicationConfiguration, plan)
+	charts := plan.Charts()
+	internal.ValidateChartVersions(ApplicationConfiguration, charts)
+	// the node images are checked against the resolved charts, with or
+	// without the pre-flight checks
+	if plan.Includes(internal.Kind_Component) {
+		if errors := internal.ValidateKindNodeImages(ApplicationConfiguration.Configuration.ClusterConfiguration); len(errors) > 0 {
+			for _, s := range errors {
+				util.Printf(s)
+			}
+			util.Fatalf("%s Process failed due to unsupported node images", util.Cross)
+		}
+	}
+	internal.VerifyChartProvenance(ApplicationConfiguration, charts)
+	internal.VerifyRegistryCredentials(ApplicationConfiguration)
+	if Options.RenderTo != "" {
+		internal.RenderKubeSlice(ApplicationConfiguration, charts, plan.Includes(internal.Controller_Component), plan.Includes(internal.Worker_registration_Component), Options.RenderTo)
+		return false
+	}
+	createsKindClusters := plan.Includes(internal.Kind_Component)
+	if createsKindClusters {
+		internal.SelectPlatform(ApplicationConfiguration, charts)
+	}
 	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" {
-		if !skipKind {
-			internal.GenerateKindConfiguration(ApplicationConfiguration)
+		// the kubeconfig of the kind clusters is created by the run
+		if !Options.PlanOnly {
+			internal.CreateKubeConfig()
 		}
-		internal.CreateKubeConfig()
 		internal.SetKubeConfigPath()
-		if !skipKind {
+		// the kind clusters of a previous run are checked along with the
+		// others, restarting their stopped nodes is left to the run
+		if createsKindClusters && !Options.PlanOnly {
+			internal.CheckExistingKindClusters(ApplicationConfiguration)
+		}
+	}
+	internal.VerifyLicense(ApplicationConfiguration, plan.Includes(internal.Controller_Component))
+	internal.RunPreflightChecks(ApplicationConfiguration, charts, createsKindClusters)
+	internal.ResolveCertManager(ApplicationConfiguration, plan, createsKindClusters)
+	charts = plan.Charts()
+	internal.VerifyComponentDependencies(ApplicationConfiguration, plan)
+	internal.DetectExistingInstallations(ApplicationConfiguration, charts, createsKindClusters)
+	plan.Resume(ApplicationConfiguration)
+	plan.Record(ApplicationConfiguration)
+	if internal.StopAfterPlan() {
+		return false
+	}
+	if createsKindClusters {
+		if plan.Start(internal.Kind_Component) {
+			internal.GenerateKindConfiguration(ApplicationConfiguration)
 			internal.CreateKindClusters(ApplicationConfiguration)
+			plan.Complete(internal.Kind_Component)
 		}
+		internal.MergeKindKubeconfigs(ApplicationConfiguration)
 	}
-	if (ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" || ApplicationConfiguration.Configuration.ClusterConfiguration.ClusterType == "kind") && !skipCalico {
+	if plan.Includes(internal.Calico_Component) && plan.Start(internal.Calico_Component) {
 		internal.InstallCalico(&ApplicationConfiguration.Configuration.ClusterConfiguration)
+		plan.Complete(internal.Calico_Component)
 	}
+	internal.VerifyImagePullSecretReference(ApplicationConfiguration, charts)
 	internal.GatherNetworkInformation(ApplicationConfiguration)
-	internal.AddHelmCharts(ApplicationConfiguration)
-	if !skipController {
-		if !skipCertManager {
-			internal.InstallCertManager(ApplicationConfiguration)
-		}
+	if plan.Includes(internal.CertManager_Component) && plan.Start(internal.CertManager_Component) {
+		internal.InstallCertManager(ApplicationConfiguration)
+		plan.Complete(internal.CertManager_Component)
+	}
+	if plan.Includes(internal.Controller_Component) && plan.Start(internal.Controller_Component) {
 		internal.InstallKubeSliceController(ApplicationConfiguration)
 		internal.CreateKubeSliceProject(ApplicationConfiguration, nil)
+		plan.Complete(internal.Controller_Component)
 	}
-	if !skipUI {
+	if plan.Includes(internal.UI_install_Component) && plan.Start(internal.UI_install_Component) {
 		internal.InstallKubeSliceUI(ApplicationConfiguration)
+		plan.Complete(internal.UI_install_Component)
 	}
-	if !skipWorker_registration {
+	if plan.Includes(internal.Worker_registration_Component) && plan.Start(internal.Worker_registration_Component) {
 		internal.RegisterWorkerClusters(ApplicationConfiguration, nil)
+		plan.Complete(internal.Worker_registration_Component)
 	}
-	if !skipWorker {
+	if plan.Includes(internal.Worker_Component) && plan.Start(internal.Worker_Component) {
+		internal.LabelGatewayNodes(ApplicationConfiguration)
 		internal.InstallKubeSliceWorker(ApplicationConfiguration)
+		plan.Complete(internal.Worker_Component)
 	}
-	if !skipPrometheus {
+	if plan.Includes(internal.Prometheus_Component) && plan.Start(internal.Prometheus_Component) {
 		internal.InstallPrometheus(ApplicationConfiguration)
+		plan.Complete(internal.Prometheus_Component)
+	}
+	if plan.Includes(internal.Monitoring_Component) && plan.Start(internal.Monitoring_Component) {
+		internal.InstallMonitoring(ApplicationConfiguration)
+		plan.Complete(internal.Monitoring_Component)
+	}
+	internal.RecordInstallation(ApplicationConfiguration, plan.Includes(internal.Worker_registration_Component))
+	if plan.Includes(internal.HelmTestsStep) && plan.Start(internal.HelmTestsStep) {
+		internal.RunHelmTests(ApplicationConfiguration, charts)
+		plan.Complete(internal.HelmTestsStep)
 	}
+	return true
 }
 
-func Uninstall(componentsToUninstall, workersToUninstall map[string]string) {
+// chartComponents returns the components whose charts an install with the
+// skipped steps deploys, in install order.
+func chartComponents(skipSteps map[string]string) []string {
+	return internal.BuildInstallPlan(ApplicationConfiguration, skipSteps).Charts()
+}
+
+// ListImages prints the images the charts an install with the skipped steps
+// deploys pull, or a script mirroring them to mirrorRegistry.
+func ListImages(skipSteps map[string]string, outputFormat, mirrorRegistry, script string) {
+	internal.ListImages(ApplicationConfiguration, chartComponents(skipSteps), outputFormat, mirrorRegistry, script)
+}
+
+// CreateBundle writes an offline bundle of the charts, images and topology
+// of topologyFile to output for an air-gapped install.
+func CreateBundle(topologyFile, output, script string) {
+	internal.CreateBundle(ApplicationConfiguration, topologyFile, output, script)
+}
 
-	internal.VerifyExecutables(ApplicationConfiguration)
+// VerifyBundle checks the integrity of an offline bundle.
+func VerifyBundle(archive string) {
+	internal.VerifyBundle(archive)
+}
+
+// Uninstall removes the components from the clusters. deleteClusters deletes
+// the kind clusters of a demo profile instead, the clusters of a topology of
+// existing clusters are never deleted.
+func Uninstall(componentsToUninstall, workersToUninstall map[string]string, deleteClusters bool) {
+	defer planOutput()()
+	profile := ApplicationConfiguration.Configuration.ClusterConfiguration.Profile
+	// the kind clusters of a demo are deleted, or listed as retained
+	internal.VerifyExecutables(ApplicationConfiguration, profile != "")
 
-	// Custom topology passed
-	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile == "" {
+	if profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	internal.PrintExecutionPlan(internal.UninstallPlan(ApplicationConfiguration, componentsToUninstall, workersToUninstall, deleteClusters), Options.PlanOutput)
+	if internal.StopAfterPlan() {
+		return
+	}
+	if profile == "" || !deleteClusters {
 		_, uninstallController := componentsToUninstall[internal.Controller_Component]
 		_, uninstallCertManager := componentsToUninstall[internal.CertManager_Component]
 		_, uninstallWorker := componentsToUninstall[internal.Worker_Component]
@@ -136,9 +267,108 @@ func Uninstall(componentsToUninstall, workersToUninstall map[string]string) {
 				internal.UninstallCertManager(ApplicationConfiguration)
 			}
 		}
+		if !uninstallWorker {
+			workersToUninstall = nil
+		}
+		internal.CleanupKubeSlice(ApplicationConfiguration, uninstallController, workersToUninstall)
+		internal.RecordUninstall(ApplicationConfiguration, componentsToUninstall, workersToUninstall)
+		if profile != "" {
+			internal.RetainKindClusters(ApplicationConfiguration)
+		}
 		return
 	}
-	// Cleanup setup of Minimal/Full Demo.
-	internal.SetKubeConfigPath()
+	// deleting the kind clusters of a demo removes everything on them
 	internal.DeleteKindClusters(ApplicationConfiguration)
+	internal.RemoveInstallation(ApplicationConfiguration)
+}
+
+// Upgrade moves the installed KubeSlice releases of the topology to the chart
+// versions it pins, the controller first and then the workers.
+func Upgrade() {
+	defer planOutput()()
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	internal.GenerateKubeSliceDirectory()
+	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	internal.AddHelmCharts(ApplicationConfiguration)
+	internal.ValidateChartVersions(ApplicationConfiguration, internal.UpgradeComponents)
+	internal.VerifyChartProvenance(ApplicationConfiguration, internal.UpgradeComponents)
+	internal.GatherNetworkInformation(ApplicationConfiguration)
+	internal.UpgradeKubeSlice(ApplicationConfiguration, internal.UpgradeComponents)
+}
+
+// Status reports the health of the installation on its clusters, and whether
+// the installed releases still match the topology, or the state of the
+// installation when it was loaded without a topology. It exits with 1 when
+// anything is unhealthy, the json output format only printing the health.
+func Status(showDiff bool, outputFormat string) {
+	// the progress goes to stderr, so that stdout only carries the health
+	if outputFormat == internal.OutputFormatJson {
+		defer func(output io.Writer) { util.Output = output }(util.Output)
+		util.Output = os.Stderr
+	}
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	healthy := internal.ShowInstallationHealth(ApplicationConfiguration, outputFormat)
+	if outputFormat != internal.OutputFormatJson {
+		if installation != nil {
+			internal.ShowInstallationStatus(installation)
+		} else {
+			internal.ShowValuesDrift(ApplicationConfiguration, showDiff)
+		}
+		internal.ShowGatewayNodes(ApplicationConfiguration)
+		if !internal.ShowMonitoringStatus(ApplicationConfiguration) {
+			healthy = false
+		}
+	}
+	if !healthy {
+		if outputFormat != internal.OutputFormatJson {
+			util.Printf("\n%s The installation is unhealthy", util.Cross)
+		}
+		util.Exit(1)
+	}
+}
+
+// CollectDiagnostics collects a diagnostics bundle of the clusters of the
+// topology to the workspace.
+func CollectDiagnostics() {
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	internal.GenerateKubeSliceDirectory()
+	internal.CollectDiagnostics(ApplicationConfiguration)
+}
+
+// ListInstallations prints the installations whose state is stored.
+func ListInstallations() {
+	internal.ShowInstallations()
+}
+
+// VerifyDemo verifies the connectivity of the slice of the topology with iperf
+// between two of its workers.
+func VerifyDemo() {
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	if err := internal.VerifyDemo(ApplicationConfiguration, Options.CheckQoS); err != nil {
+		util.Fatalf("%s Verification of slice failed: %v", util.Cross, err)
+	}
+}
+
+// Version prints the version of kubeslice-cli, and the versions of the
+// components deployed on the clusters of the topology when remote is set.
+func Version(remote bool, outputFormat string) {
+	if !remote {
+		internal.ShowVersion(nil, outputFormat)
+		return
+	}
+	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	internal.ShowVersion(ApplicationConfiguration, outputFormat)
+}
+
+// ChartVersions lists the published versions of the chart of a component.
+func ChartVersions(component, outputFormat string) {
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	internal.ShowChartVersions(ApplicationConfiguration, component, outputFormat)
 }
@@ -5,3 +5,10 @@ import "github.com/kubeslice/kubeslice-cli/pkg/internal"
 func GetUIEndpoint() {
 	internal.GetUIEndpoint(CliOptions.Cluster, ApplicationConfiguration.Configuration.ClusterConfiguration.Profile)
 }
+
+// PortForwardUI forwards port to the KubeSlice Manager UI of the controller
+// cluster until interrupted.
+func PortForwardUI(port int) {
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	internal.PortForwardUI(ApplicationConfiguration, port)
+}
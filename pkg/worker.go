@@ -2,16 +2,67 @@ package pkg
 
 import (
 	"github.com/kubeslice/kubeslice-cli/pkg/internal"
+	"github.com/kubeslice/kubeslice-cli/util"
 )
 
 func RegisterWorker() {
-	ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters = nil
-	ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters = append(ApplicationConfiguration.Configuration.ClusterConfiguration.WorkerClusters, internal.Cluster{
-		Name: CliOptions.ObjectName,
-	})
+	cc := &ApplicationConfiguration.Configuration.ClusterConfiguration
+	// the worker of the topology keeps its node_ips and registration metadata
+	worker := internal.Cluster{Name: CliOptions.ObjectName}
+	for _, cluster := range cc.WorkerClusters {
+		if cluster.Name == CliOptions.ObjectName {
+			worker = cluster
+		}
+	}
+	cc.WorkerClusters = []internal.Cluster{worker}
 	internal.RegisterWorkerClusters(ApplicationConfiguration, CliOptions)
 }
 
+// JoinWorker registers a worker with the controller of the topology and
+// installs the worker chart onto it. A worker of the topology keeps its
+// settings, the context and kubeconfig passed win.
+func JoinWorker(context, kubeconfig string) {
+	cc := &ApplicationConfiguration.Configuration.ClusterConfiguration
+	worker := internal.Cluster{Name: CliOptions.ObjectName}
+	for _, cluster := range cc.WorkerClusters {
+		if cluster.Name == CliOptions.ObjectName {
+			worker = cluster
+		}
+	}
+	if context != "" {
+		worker.ContextName = context
+	}
+	if kubeconfig != "" {
+		worker.KubeConfigPath = kubeconfig
+	}
+	if worker.KubeConfigPath == "" {
+		worker.KubeConfigPath = cc.KubeConfigPath
+	}
+	if worker.KubeConfigPath == "" {
+		util.Fatalf("%s Please pass the kubeconfig of worker %s with --kubeconfig", util.Cross, worker.Name)
+	}
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	internal.GenerateKubeSliceDirectory()
+	if cc.Profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	components := []string{internal.Worker_Component}
+	internal.AddHelmCharts(ApplicationConfiguration)
+	internal.ValidateChartVersions(ApplicationConfiguration, components)
+	internal.VerifyChartProvenance(ApplicationConfiguration, components)
+	internal.JoinWorkerCluster(ApplicationConfiguration, worker)
+}
+
+// DeregisterWorker offboards a worker from its slices with detach, uninstalls
+// the worker chart from it unless force is set, and deletes its registration.
+func DeregisterWorker(detach, force bool) {
+	internal.VerifyExecutables(ApplicationConfiguration, false)
+	if ApplicationConfiguration.Configuration.ClusterConfiguration.Profile != "" {
+		internal.SetKubeConfigPath()
+	}
+	internal.DeregisterWorkerCluster(ApplicationConfiguration, CliOptions.ObjectName, CliOptions.Namespace, detach, force)
+}
+
 func GetWorker() {
 	internal.GetKubeSliceCluster(CliOptions.ObjectName, CliOptions.Namespace, CliOptions.Cluster, CliOptions.OutputFormat)
 }
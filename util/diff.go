@@ -0,0 +1,121 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffLine struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	a, b int // index of the line in the old and new input
+}
+
+// UnifiedDiff renders a line based diff between from and to in the unified
+// format, keeping the given number of context lines around each change.
+// An empty string is returned when both inputs are identical.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	a := splitLines(from)
+	b := splitLines(to)
+	lines := diffLines(a, b)
+
+	changes := make([]int, 0)
+	for i, l := range lines {
+		if l.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for h := 0; h < len(changes); {
+		start := changes[h] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[h]
+		// extend the hunk while the next change is close enough to share context
+		for h < len(changes) && changes[h]-end <= 2*context {
+			end = changes[h]
+			h++
+		}
+		end = end + context + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		writeHunk(&sb, lines[start:end])
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, hunk []diffLine) {
+	aLen, bLen := 0, 0
+	for _, l := range hunk {
+		if l.kind != '+' {
+			aLen++
+		}
+		if l.kind != '-' {
+			bLen++
+		}
+	}
+	aStart, bStart := hunk[0].a, hunk[0].b
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, l := range hunk {
+		fmt.Fprintf(sb, "%c%s\n", l.kind, l.text)
+	}
+}
+
+// diffLines computes the edit script between a and b from their longest
+// common subsequence.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	result := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			result = append(result, diffLine{kind: ' ', text: a[i], a: i, b: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			result = append(result, diffLine{kind: '-', text: a[i], a: i, b: j})
+			i++
+		default:
+			result = append(result, diffLine{kind: '+', text: b[j], a: i, b: j})
+			j++
+		}
+	}
+	return result
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
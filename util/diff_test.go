@@ -0,0 +1,74 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "Identical inputs produce no diff",
+			from:     "a: 1\nb: 2\n",
+			to:       "a: 1\nb: 2\n",
+			expected: "",
+		},
+		{
+			name: "Changed line",
+			from: "a: 1\nb: 2\nc: 3\n",
+			to:   "a: 1\nb: 5\nc: 3\n",
+			expected: `--- old
++++ new
+@@ -1,3 +1,3 @@
+ a: 1
+-b: 2
++b: 5
+ c: 3
+`,
+		},
+		{
+			name: "Added lines to empty input",
+			from: "",
+			to:   "a: 1\n",
+			expected: `--- old
++++ new
+@@ -0,0 +1,1 @@
++a: 1
+`,
+		},
+		{
+			name: "Distant changes are split into hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:   "x\n2\n3\n4\n5\n6\n7\n8\ny\n",
+			expected: `--- old
++++ new
+@@ -1,2 +1,2 @@
+-1
++x
+ 2
+@@ -8,2 +8,2 @@
+ 8
+-9
++y
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := UnifiedDiff("old", "new", tc.from, tc.to, 1)
+			if got != tc.expected {
+				t.Errorf("UnifiedDiff() mismatch\nwant: %q\ngot:  %q", tc.expected, got)
+			}
+		})
+	}
+}
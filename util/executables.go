@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
 )
 
 var ExecutablePaths map[string]string
@@ -13,14 +15,42 @@ var ExecutableVerifyCommands = map[string][]string{
 	"kind":    {"version"},
 	"kubectl": {"version", "--client=true"},
 	"docker":  {"ps", "-a"},
+	"podman":  {"ps", "-a"},
+	"nerdctl": {"ps", "-a"},
 	"helm":    {"version"},
 }
 
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// RegisterSecret masks every occurrence of s in the printed commands and
+// command output.
+func RegisterSecret(s string) {
+	if s == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, s)
+}
+
+// Redact returns s with the registered secrets masked.
+func Redact(s string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "******")
+	}
+	return s
+}
+
 func RunCommand(cli string, arg ...string) error {
 	var outB, errB bytes.Buffer
 	err := RunCommandCustomIO(cli, &outB, &errB, false, arg...)
 	if err != nil {
-		Printf("%s Failed to run command\nOutput: %s\nError: %s %v", Cross, outB.String(), errB.String(), err)
+		Printf("%s Failed to run command\nOutput: %s\nError: %s %v", Cross, Redact(outB.String()), Redact(errB.String()), err)
 	}
 	return err
 }
@@ -38,12 +68,134 @@ func RunCommandOnStdIO(cli string, arg ...string) error {
 	return RunCommandCustomIO(cli, os.Stdout, os.Stderr, false, arg...)
 }
 
+// CommandLine returns the command as it can be pasted to a shell, quoting
+// the arguments with spaces or shell characters such as the contexts of EKS
+// clusters, arn:aws:eks:<region>:<account>:cluster/<name>.
+func CommandLine(cli string, arg ...string) string {
+	words := []string{ExecutablePaths[cli]}
+	if words[0] == "" {
+		words[0] = cli
+	}
+	return strings.Join(append(words, QuoteArgs(arg...)...), " ")
+}
+
+// QuoteArgs single-quotes the arguments a shell would otherwise split or
+// interpret.
+func QuoteArgs(arg ...string) []string {
+	quoted := make([]string, 0, len(arg))
+	for _, a := range arg {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@+%^", r))
+		}) < 0 {
+			quoted = append(quoted, a)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(a, "'", `'\''`)+"'")
+	}
+	return quoted
+}
+
 func RunCommandCustomIO(cli string, stdout, stderr io.Writer, suppressPrint bool, arg ...string) error {
-	cmd := exec.Command(ExecutablePaths[cli], arg...)
-	if !suppressPrint {
-		Printf("%s Running command: %s", Run, cmd.String())
+	if !suppressPrint && watchOutput == nil {
+		Printf("%s Running command: %s", Run, Redact(CommandLine(cli, arg...)))
+	}
+	return executeRecorded(cli, nil, stdout, stderr, arg...)
+}
+
+// RunCommandWithInput runs the command with input on its stdin, e.g. to pass a
+// password without exposing it in the process list.
+func RunCommandWithInput(cli, input string, stdout, stderr io.Writer, suppressPrint bool, arg ...string) error {
+	if !suppressPrint && watchOutput == nil {
+		Printf("%s Running command: %s", Run, Redact(CommandLine(cli, arg...)))
+	}
+	return executeRecorded(cli, strings.NewReader(input), stdout, stderr, arg...)
+}
+
+var (
+	commandEnvMu sync.Mutex
+	commandEnv   = make(map[string][]string)
+)
+
+// SetCommandEnv sets the KEY=value environment variables added to the
+// environment of every run of cli, e.g. proxy settings. Without env the
+// variables of cli are removed.
+func SetCommandEnv(cli string, env ...string) {
+	commandEnvMu.Lock()
+	defer commandEnvMu.Unlock()
+	if len(env) == 0 {
+		delete(commandEnv, cli)
+		return
 	}
+	commandEnv[cli] = append([]string(nil), env...)
+}
+
+// CommandEnv returns the environment variables added to the runs of cli.
+func CommandEnv(cli string) []string {
+	commandEnvMu.Lock()
+	defer commandEnvMu.Unlock()
+	return append([]string(nil), commandEnv[cli]...)
+}
+
+// Executor runs the external commands of the cli. stdin may be nil.
+type Executor interface {
+	Execute(cli string, stdin io.Reader, stdout, stderr io.Writer, arg ...string) error
+}
+
+type execExecutor struct{}
+
+func (execExecutor) Execute(cli string, stdin io.Reader, stdout, stderr io.Writer, arg ...string) error {
+	cmd := exec.Command(ExecutablePaths[cli], arg...)
+	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
+	if env := CommandEnv(cli); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	return cmd.Run()
 }
+
+var executor Executor = execExecutor{}
+
+// UseExecutor replaces the executor running the external commands and
+// returns a function restoring the previous one.
+func UseExecutor(e Executor) func() {
+	previous := executor
+	executor = e
+	return func() {
+		executor = previous
+	}
+}
+
+// MockExecutor records the commands instead of running them. It is meant for
+// tests asserting the sequence of commands a step runs.
+type MockExecutor struct {
+	mu       sync.Mutex
+	Commands [][]string
+	// Inputs holds the stdin of each command, empty when it had none
+	Inputs []string
+	// Envs holds the environment variables added to each command
+	Envs [][]string
+	// Handler optionally produces the output of a command
+	Handler func(command []string, stdout, stderr io.Writer) error
+}
+
+func (m *MockExecutor) Execute(cli string, stdin io.Reader, stdout, stderr io.Writer, arg ...string) error {
+	command := append([]string{cli}, arg...)
+	input := ""
+	if stdin != nil {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		input = string(data)
+	}
+	m.mu.Lock()
+	m.Commands = append(m.Commands, command)
+	m.Inputs = append(m.Inputs, input)
+	m.Envs = append(m.Envs, CommandEnv(cli))
+	m.mu.Unlock()
+	if m.Handler != nil {
+		return m.Handler(command, stdout, stderr)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	RegisterSecret("s3cr3t-fixture")
+	RegisterSecret("")
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Secret in command arguments",
+			input:    "/usr/bin/helm repo add ks https://charts --username user --password s3cr3t-fixture",
+			expected: "/usr/bin/helm repo add ks https://charts --username user --password ******",
+		},
+		{
+			name:     "Text without secrets",
+			input:    "/usr/bin/helm repo update",
+			expected: "/usr/bin/helm repo update",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Redact(tc.input); got != tc.expected {
+				t.Errorf("Redact() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestQuoteArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		arg      string
+		expected string
+	}{
+		{
+			name:     "Plain argument",
+			arg:      "--context=kind-ks-w-1",
+			expected: "--context=kind-ks-w-1",
+		},
+		{
+			name:     "EKS context",
+			arg:      "--context=arn:aws:eks:us-east-1:1234:cluster/prod",
+			expected: "--context=arn:aws:eks:us-east-1:1234:cluster/prod",
+		},
+		{
+			name:     "Context with spaces",
+			arg:      "--context=prod cluster",
+			expected: "'--context=prod cluster'",
+		},
+		{
+			name:     "Single quote",
+			arg:      "it's",
+			expected: `'it'\''s'`,
+		},
+		{
+			name:     "Empty argument",
+			arg:      "",
+			expected: "''",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := QuoteArgs(tc.arg)[0]; got != tc.expected {
+				t.Errorf("QuoteArgs() = %s, expected %s", got, tc.expected)
+			}
+		})
+	}
+}
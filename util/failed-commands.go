@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// How many failed commands are kept for the diagnostics of a failed run, and
+// how much of the output of each.
+const (
+	maxFailedCommands      = 10
+	maxFailedCommandOutput = 64 << 10
+)
+
+// FailedCommand is a command of the run which exited with an error.
+type FailedCommand struct {
+	Command string
+	Output  string
+	Err     string
+}
+
+var (
+	failedCommandsMu sync.Mutex
+	failedCommands   []FailedCommand
+	fatalMessage     string
+)
+
+// FailedCommands returns the last commands of the run which failed, the most
+// recent last, with their output and the registered secrets masked.
+func FailedCommands() []FailedCommand {
+	failedCommandsMu.Lock()
+	defer failedCommandsMu.Unlock()
+	return append([]FailedCommand(nil), failedCommands...)
+}
+
+// FatalMessage returns the message the run failed with through Fatalf.
+func FatalMessage() string {
+	failedCommandsMu.Lock()
+	defer failedCommandsMu.Unlock()
+	return fatalMessage
+}
+
+func recordFatalMessage(message string) {
+	failedCommandsMu.Lock()
+	defer failedCommandsMu.Unlock()
+	fatalMessage = Redact(message)
+}
+
+// tailBuffer keeps the last bytes written to it.
+type tailBuffer struct {
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > maxFailedCommandOutput {
+		b.data = b.data[len(b.data)-maxFailedCommandOutput:]
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	if b.truncated {
+		return fmt.Sprintf("[output truncated to the last %d bytes]\n%s", maxFailedCommandOutput, b.data)
+	}
+	return string(b.data)
+}
+
+// lookupCommands are the subcommands of the clis which only look up objects or
+// releases, and may fail because these do not exist.
+var lookupCommands = map[string][]string{
+	"kubectl": {"get"},
+	"helm":    {"status", "get", "history"},
+}
+
+// notFoundLookup tells whether a failed command looked up an object or a
+// release which does not exist, which the callers probe for before creating
+// it. These are expected and not recorded.
+func notFoundLookup(cli string, arg []string, output string) bool {
+	subcommand := ""
+	for _, a := range arg {
+		if !strings.HasPrefix(a, "-") {
+			subcommand = a
+			break
+		}
+	}
+	lookup := false
+	for _, c := range lookupCommands[cli] {
+		lookup = lookup || c == subcommand
+	}
+	return lookup && (strings.Contains(output, "(NotFound)") || strings.Contains(output, "release: not found"))
+}
+
+// executeRecorded runs a command with the executor and records it when it
+// fails, unless it only found what it looked up does not exist. The output
+// still goes to stdout and stderr.
+func executeRecorded(cli string, stdin io.Reader, stdout, stderr io.Writer, arg ...string) error {
+	output := &tailBuffer{}
+	err := executor.Execute(cli, stdin, io.MultiWriter(stdout, output), io.MultiWriter(stderr, output), arg...)
+	if err != nil && !notFoundLookup(cli, arg, output.String()) {
+		failedCommandsMu.Lock()
+		defer failedCommandsMu.Unlock()
+		failedCommands = append(failedCommands, FailedCommand{
+			Command: Redact(CommandLine(cli, arg...)),
+			Output:  Redact(strings.TrimRight(output.String(), "\n")),
+			Err:     Redact(err.Error()),
+		})
+		if len(failedCommands) > maxFailedCommands {
+			failedCommands = failedCommands[len(failedCommands)-maxFailedCommands:]
+		}
+	}
+	return err
+}
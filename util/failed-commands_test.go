@@ -0,0 +1,61 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFailedCommands(t *testing.T) {
+	defer func(commands []FailedCommand) { failedCommands = commands }(failedCommands)
+	failedCommands = nil
+	RegisterSecret("failed-commands-fixture")
+	mock := &MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		switch {
+		case command[1] == "install":
+			fmt.Fprint(stdout, "pulling chart\n")
+			fmt.Fprint(stderr, "401 unauthorized for failed-commands-fixture\n")
+			return errors.New("exit status 1")
+		case command[len(command)-1] == "missing":
+			fmt.Fprintf(stderr, "Error from server (NotFound): namespaces %q not found\n", "missing")
+			return errors.New("exit status 1")
+		}
+		fmt.Fprint(stdout, strings.Repeat("x", maxFailedCommandOutput+10))
+		return errors.New("exit status 2")
+	}}
+	defer UseExecutor(mock)()
+
+	var outB, errB bytes.Buffer
+	if err := RunCommandCustomIO("helm", &outB, &errB, true, "install", "--password", "failed-commands-fixture"); err == nil {
+		t.Fatal("RunCommandCustomIO() returned no error")
+	}
+	if errB.String() != "401 unauthorized for failed-commands-fixture\n" {
+		t.Errorf("stderr = %q, want the output of the command", errB.String())
+	}
+	RunCommandCustomIO("kubectl", &outB, &errB, true, "logs")
+	for i := 0; i < maxFailedCommands; i++ {
+		RunCommandCustomIO("kubectl", &outB, &errB, true, "get")
+	}
+
+	commands := FailedCommands()
+	if len(commands) != maxFailedCommands {
+		t.Fatalf("FailedCommands() has %d commands, want the last %d", len(commands), maxFailedCommands)
+	}
+	failedCommands = nil
+	RunCommandCustomIO("helm", &outB, &errB, true, "install", "--password", "failed-commands-fixture")
+	RunCommandCustomIO("kubectl", &outB, &errB, true, "--context=kind-ks-ctrl", "get", "namespace", "missing")
+	RunCommandCustomIO("kubectl", &outB, &errB, true, "logs")
+	commands = FailedCommands()
+	if len(commands) != 2 {
+		t.Fatalf("FailedCommands() = %+v, want the install and logs commands", commands)
+	}
+	if commands[0].Output != "pulling chart\n401 unauthorized for ******" || strings.Contains(commands[0].Command, "fixture") || commands[0].Err != "exit status 1" {
+		t.Errorf("FailedCommands()[0] = %+v, want the redacted command and output", commands[0])
+	}
+	if !strings.HasPrefix(commands[1].Output, "[output truncated to the last") || len(commands[1].Output) > maxFailedCommandOutput+100 {
+		t.Errorf("FailedCommands()[1] has %d bytes of output, want it truncated", len(commands[1].Output))
+	}
+}
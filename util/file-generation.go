@@ -2,9 +2,63 @@ package util
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 )
 
+var (
+	exitHandlersMu  sync.Mutex
+	exitHandlers    []func()
+	failureHandlers []func()
+)
+
+// AtExit registers a function to run before the process exits through Exit or
+// Fatalf, e.g. to remove generated files.
+func AtExit(f func()) {
+	exitHandlersMu.Lock()
+	defer exitHandlersMu.Unlock()
+	exitHandlers = append(exitHandlers, f)
+}
+
+// OnFailure registers a function to run before the process exits with a non
+// zero code through Exit or Fatalf.
+func OnFailure(f func()) {
+	exitHandlersMu.Lock()
+	defer exitHandlersMu.Unlock()
+	failureHandlers = append(failureHandlers, f)
+}
+
+// RunExitHandlers runs the registered exit handlers in reverse order of
+// registration. Each handler runs at most once.
+func RunExitHandlers() {
+	exitHandlersMu.Lock()
+	handlers := exitHandlers
+	exitHandlers = nil
+	exitHandlersMu.Unlock()
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handlers[i]()
+	}
+}
+
+// Exit runs the exit handlers, and the failure handlers for a non zero code,
+// and terminates the process with the given code.
+func Exit(code int) {
+	if code != 0 {
+		exitHandlersMu.Lock()
+		handlers := failureHandlers
+		failureHandlers = nil
+		exitHandlersMu.Unlock()
+		for i := len(handlers) - 1; i >= 0; i-- {
+			handlers[i]()
+		}
+	}
+	RunExitHandlers()
+	os.Exit(code)
+}
+
 func CreateDirectoryPath(path string) {
 	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
 		err := os.MkdirAll(path, os.ModePerm)
@@ -25,4 +79,29 @@ func DumpFile(template, filename string) {
 	if err2 != nil {
 		Fatalf("%s Failed to write %s", Cross, filename)
 	}
-}
\ No newline at end of file
+}
+
+// WriteFileAtomic writes data to a temporary file next to filename and renames
+// it into place, so readers never observe a partially written file.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, filename)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+	return nil
+}
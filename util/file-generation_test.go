@@ -199,3 +199,62 @@ func TestDumpFile_CreatesFileWithCorrectPermissions(t *testing.T) {
 		t.Errorf("File should be readable and writable by owner, got mode: %v", mode)
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	testDir := filepath.Join(os.TempDir(), "kubeslice-test-"+t.Name())
+	if err := os.MkdirAll(testDir, os.ModePerm); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(testDir)
+	})
+
+	targetFile := filepath.Join(testDir, "values.yaml")
+	if err := os.WriteFile(targetFile, []byte("old: content"), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	if err := WriteFileAtomic(targetFile, []byte("new: content"), 0600); err != nil {
+		t.Fatalf("WriteFileAtomic() returned error: %v", err)
+	}
+	content, err := os.ReadFile(targetFile)
+	if err != nil || string(content) != "new: content" {
+		t.Errorf("WriteFileAtomic() content mismatch, got %q (%v)", string(content), err)
+	}
+	info, err := os.Stat(targetFile)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("WriteFileAtomic() permissions mismatch, got %v (%v)", info.Mode(), err)
+	}
+
+	// a non empty directory at the target path makes the final rename fail
+	blocked := filepath.Join(testDir, "blocked.yaml")
+	if err := os.MkdirAll(filepath.Join(blocked, "child"), os.ModePerm); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	if err := WriteFileAtomic(blocked, []byte("partial"), 0600); err == nil {
+		t.Errorf("WriteFileAtomic() should fail when the file cannot be moved into place")
+	}
+	entries, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatalf("Failed to read test directory: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name() != "values.yaml" && e.Name() != "blocked.yaml" {
+			t.Errorf("WriteFileAtomic() left a temporary file behind: %s", e.Name())
+		}
+	}
+}
+
+func TestRunExitHandlers(t *testing.T) {
+	order := make([]int, 0)
+	AtExit(func() { order = append(order, 1) })
+	AtExit(func() { order = append(order, 2) })
+
+	RunExitHandlers()
+	RunExitHandlers()
+
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("RunExitHandlers() should run each handler once in reverse order, got %v", order)
+	}
+}
@@ -1,8 +1,12 @@
 package util
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 const (
@@ -15,19 +19,59 @@ const (
 	Globe = string(rune(0x1F310))
 )
 
+// Output is where Printf and Fatalf write to.
+var Output io.Writer = os.Stdout
+
 func Printf(format string, a ...interface{}) {
 	if len(a) > 0 {
-		fmt.Printf(format+"\n", a...)
+		fmt.Fprintf(Output, format+"\n", a...)
 	} else {
-		fmt.Println(format)
+		fmt.Fprintln(Output, format)
 	}
 }
 
 func Fatalf(format string, a ...interface{}) {
+	if watchOutput != nil {
+		buffered := Output.(*bytes.Buffer)
+		Output, watchOutput = watchOutput, nil
+		// the commands of a refresh interrupted with Ctrl+C fail as well
+		if len(watchInterrupt) > 0 {
+			fmt.Fprintln(Output)
+			Exit(0)
+		}
+		Output.Write(buffered.Bytes())
+	}
 	if len(a) > 0 {
-		fmt.Printf(format+"\n", a...)
+		fmt.Fprintf(Output, format+"\n", a...)
+		recordFatalMessage(fmt.Sprintf(format, a...))
 	} else {
-		fmt.Println(format + "\n")
+		fmt.Fprintln(Output, format+"\n")
+		recordFatalMessage(format)
+	}
+	Exit(1)
+}
+
+// Confirm asks a yes/no question on stdin, written to the output, and reports
+// whether it was answered with yes. Anything other than y/yes is treated as
+// no. It fails when stdin is not a terminal, as nothing can answer it.
+func Confirm(format string, a ...interface{}) bool {
+	question := fmt.Sprintf(format, a...)
+	if !IsTerminal(os.Stdin) {
+		Fatalf("%s %s stdin is not a terminal to answer on, re-run with --yes", Cross, question)
+	}
+	fmt.Fprint(Output, question+" [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Debug enables the output of Debugf.
+var Debug bool
+
+// Debugf prints a message only when debug output is enabled.
+func Debugf(format string, a ...interface{}) {
+	if !Debug {
+		return
 	}
-	os.Exit(1)
+	Printf("[debug] "+format, a...)
 }
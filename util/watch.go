@@ -0,0 +1,119 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clearScreen moves the cursor home and clears a terminal.
+const clearScreen = "\033[H\033[2J"
+
+var (
+	// watchOutput is where the output of a refresh goes to when it fails,
+	// Watch buffering it otherwise. The commands run by a refresh are not
+	// printed while it is set.
+	watchOutput io.Writer
+	// watchInterrupt receives the Ctrl+C which stops Watch
+	watchInterrupt chan os.Signal
+)
+
+// healthyValues are the column values of a row of a healthy object.
+var healthyValues = map[string]bool{
+	"ready": true, "Ready": true, "True": true, "Normal": true, "Running": true, "deployed": true, "up": true, "Healthy": true,
+}
+
+// Watch prints the output of render on every interval until interrupted
+// with Ctrl+C, redrawing it in place on a terminal and appending every
+// refresh below a timestamp otherwise. With markRows the rows which changed
+// since the previous refresh are marked with *, and with Tick when they
+// turned healthy, rows being identified by their first column.
+func Watch(interval time.Duration, markRows bool, render func()) {
+	watchInterrupt = make(chan os.Signal, 1)
+	signal.Notify(watchInterrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(watchInterrupt)
+
+	out := Output
+	terminal := IsTerminal(out)
+	var previous []string
+	for {
+		var buffer bytes.Buffer
+		Output, watchOutput = &buffer, out
+		render()
+		Output, watchOutput = out, nil
+
+		lines := strings.Split(strings.TrimRight(buffer.String(), "\n"), "\n")
+		printed := lines
+		if markRows {
+			printed = markChangedRows(previous, lines)
+		}
+		if terminal {
+			fmt.Fprint(out, clearScreen)
+		}
+		fmt.Fprintf(out, "Every %s: %s\n", interval, time.Now().Format(time.RFC3339))
+		fmt.Fprintln(out, strings.Join(printed, "\n"))
+		if !terminal {
+			fmt.Fprintln(out)
+		}
+		previous = lines
+
+		select {
+		case <-watchInterrupt:
+			fmt.Fprintln(out)
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// markChangedRows prefixes the rows with a mark of whether they changed
+// since the previous refresh, nothing being marked on the first one.
+func markChangedRows(previous, lines []string) []string {
+	rows := make(map[string]string)
+	for _, line := range previous {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows[fields[0]] = line
+		}
+	}
+	marked := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		mark := "  "
+		if previous != nil && len(fields) > 0 {
+			before, found := rows[fields[0]]
+			switch {
+			case found && before == line:
+			case healthy(fields) && !(found && healthy(strings.Fields(before))):
+				mark = Tick + " "
+			default:
+				mark = "* "
+			}
+		}
+		marked = append(marked, mark+line)
+	}
+	return marked
+}
+
+func healthy(fields []string) bool {
+	for _, field := range fields[1:] {
+		if healthyValues[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal tells whether a file, e.g. the output or stdin, is a terminal.
+func IsTerminal(out interface{}) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkChangedRows(t *testing.T) {
+	t.Parallel()
+
+	previous := []string{
+		"CLUSTER   STATE         TUNNELS UP",
+		"ks-w-1    not-ready     1/2",
+		"ks-w-2    unreachable   0/0",
+		"ks-w-3    ready         2/2",
+	}
+	tests := []struct {
+		name     string
+		previous []string
+		lines    []string
+		expected []string
+	}{
+		{
+			name:     "First refresh",
+			lines:    previous,
+			expected: []string{"  " + previous[0], "  " + previous[1], "  " + previous[2], "  " + previous[3]},
+		},
+		{
+			name:     "Changed and healthy rows",
+			previous: previous,
+			lines: []string{
+				"CLUSTER   STATE         TUNNELS UP",
+				"ks-w-1    ready         2/2",
+				"ks-w-2    not-ready     0/2",
+				"ks-w-3    ready         2/2",
+				"ks-w-4    ready         2/2",
+			},
+			expected: []string{
+				"  CLUSTER   STATE         TUNNELS UP",
+				Tick + " ks-w-1    ready         2/2",
+				"* ks-w-2    not-ready     0/2",
+				"  ks-w-3    ready         2/2",
+				Tick + " ks-w-4    ready         2/2",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := markChangedRows(tc.previous, tc.lines); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("markChangedRows() =\n%q\nexpected\n%q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWatchDoesNotPrintCommands(t *testing.T) {
+	defer func(out io.Writer) { Output = out }(Output)
+	var out bytes.Buffer
+	Output = &out
+	mock := &MockExecutor{Handler: func(command []string, stdout, stderr io.Writer) error {
+		fmt.Fprintln(stdout, "NAME   AGE\ndemo   5m")
+		return nil
+	}}
+	defer UseExecutor(mock)()
+
+	Watch(time.Second, true, func() {
+		RunCommandCustomIO("kubectl", Output, io.Discard, false, "get", "projects")
+		// stops Watch after the first refresh
+		watchInterrupt <- os.Interrupt
+	})
+	if strings.Contains(out.String(), "Running command") || !strings.Contains(out.String(), "demo   5m") {
+		t.Errorf("Watch() printed\n%s\nwant the objects without the command", out.String())
+	}
+}